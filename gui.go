@@ -1,193 +1,180 @@
 package main
 
 import (
-	"bufio"
+	"cmp"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
-	"sort"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-)
 
-// Segment represents a time range for muting audio
-type Segment struct {
-	Start float64 // Start time in seconds
-	End   float64 // End time in seconds
-}
+	"swear-killer/swearkiller"
+)
 
 // SubtitleStream represents an embedded subtitle stream
 type SubtitleStream struct {
 	Index    int
 	Language string
 	Title    string
+	Codec    string // Codec name, or swearkiller.CaptionsCEA608 for closed captions in the video stream
+	Forced   bool   // Only the foreign-language dialogue
 }
 
-// SwearKillerApp holds the GUI state
-type SwearKillerApp struct {
-	srtPath    string
-	videoPath  string
-	outputPath string
-	offset     float64
-	swears     []string
-
-	srtLabel        *widget.Label
-	srtButton       *widget.Button
-	videoLabel      *widget.Label
-	videoButton     *widget.Button
-	outputLabel     *widget.Label
-	offsetEntry     *widget.Entry
-	logText         *widget.Entry
-	processBtn      *widget.Button
-	executeBtn      *widget.Button
-	progressBar     *widget.ProgressBarInfinite
-	realProgressBar *widget.ProgressBar
-	progressLabel   *widget.Label
-	autoOutput      *widget.Check
-	settingsBtn     *widget.Button
-	lastCommand     string
-	myWindow        fyne.Window
-}
-
-// parseSRTTime converts SRT timestamp (e.g., "00:01:23,456") to seconds
-func parseSRTTime(srtTime string) (float64, error) {
-	// Replace comma with period for parsing milliseconds
-	srtTime = strings.Replace(srtTime, ",", ".", 1)
-	// Parse as duration (HH:MM:SS.sss)
-	d, err := time.Parse("15:04:05.000", srtTime)
-	if err != nil {
-		return 0, fmt.Errorf("failed to parse SRT time %s: %v", srtTime, err)
-	}
-	// Convert to seconds
-	seconds := float64(d.Hour()*3600+d.Minute()*60+d.Second()) + float64(d.Nanosecond())/1e9
-	return seconds, nil
-}
+// Choices for what replaces detected bleep tones
+const (
+	bleepActionSilence = "Silence"
+	bleepActionTone    = "Soft tone"
+)
 
-// findSwearTimestamps searches an SRT file for swear words and returns mute segments
-func (app *SwearKillerApp) findSwearTimestamps(srtPath string, swears []string, offset float64) ([]Segment, error) {
-	file, err := os.Open(srtPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open SRT file: %v", err)
-	}
-	defer file.Close()
-
-	var segments []Segment
-	var currentStart, currentEnd float64
-	var inSubtitleBlock bool
-	var subtitleText strings.Builder
-	srtTimePattern := regexp.MustCompile(`(\d{2}:\d{2}:\d{2},\d{3})\s*-->\s*(\d{2}:\d{2}:\d{2},\d{3})`)
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			// End of a subtitle block
-			if inSubtitleBlock {
-				// Check for swears in the collected subtitle text
-				text := strings.ToLower(subtitleText.String())
-				for _, swear := range swears {
-					lowerSwear := strings.ToLower(swear)
-					if strings.Contains(text, lowerSwear) {
-						// Apply offset to timestamps
-						adjustedStart := currentStart + offset
-						adjustedEnd := currentEnd + offset
-						// Ensure timestamps are non-negative
-						if adjustedStart < 0 || adjustedEnd < 0 {
-							app.log(fmt.Sprintf("Warning: Offset %f makes segment (%f, %f) negative, skipping", offset, currentStart, currentEnd))
-							continue
-						}
-						segments = append(segments, Segment{Start: adjustedStart, End: adjustedEnd})
-						break
-					}
-				}
-				inSubtitleBlock = false
-				subtitleText.Reset()
-			}
-			continue
-		}
-		if srtTimePattern.MatchString(line) && !inSubtitleBlock {
-			// Parse timestamp line
-			matches := srtTimePattern.FindStringSubmatch(line)
-			if len(matches) != 3 {
-				continue
-			}
-			start, err := parseSRTTime(matches[1])
-			if err != nil {
-				return nil, err
-			}
-			end, err := parseSRTTime(matches[2])
-			if err != nil {
-				return nil, err
-			}
-			currentStart = start
-			currentEnd = end
-			inSubtitleBlock = true
-			continue
-		}
-		if inSubtitleBlock {
-			// Collect subtitle text
-			subtitleText.WriteString(line + " ")
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading SRT file: %v", err)
-	}
-	// Process the last subtitle block if it exists
-	if inSubtitleBlock {
-		text := strings.ToLower(subtitleText.String())
-		for _, swear := range swears {
-			lowerSwear := strings.ToLower(swear)
-			if strings.Contains(text, lowerSwear) {
-				// Apply offset to timestamps
-				adjustedStart := currentStart + offset
-				adjustedEnd := currentEnd + offset
-				if adjustedStart >= 0 && adjustedEnd >= 0 {
-					segments = append(segments, Segment{Start: adjustedStart, End: adjustedEnd})
-				} else {
-					app.log(fmt.Sprintf("Warning: Offset %f makes segment (%f, %f) negative, skipping", offset, currentStart, currentEnd))
-				}
-				break
-			}
-		}
-	}
-	return segments, nil
-}
+// Choices for lining up the subtitle with the video using a Comskip EDL
+const (
+	edlRemapOff    = "Subtitle matches video"
+	edlRemapCut    = "Subtitle has commercials, video doesn't"
+	edlRemapInsert = "Video has commercials, subtitle doesn't"
+)
 
-// mergeSegments combines overlapping or close segments (within 1 second)
-func mergeSegments(segments []Segment) []Segment {
-	if len(segments) == 0 {
-		return segments
-	}
-	// Sort segments by start time
-	sort.Slice(segments, func(i, j int) bool {
-		return segments[i].Start < segments[j].Start
-	})
+// JobStatus describes where a queued job is in its lifecycle
+type JobStatus string
 
-	var merged []Segment
-	current := segments[0]
-	for i := 1; i < len(segments); i++ {
-		if segments[i].Start <= current.End+1.0 {
-			// Merge if segments overlap or are within 1 second
-			if segments[i].End > current.End {
-				current.End = segments[i].End
-			}
-		} else {
-			merged = append(merged, current)
-			current = segments[i]
-		}
-	}
-	merged = append(merged, current)
-	return merged
+const (
+	JobPending JobStatus = "Pending"
+	JobRunning JobStatus = "Running"
+	JobDone    JobStatus = "Done"
+	JobFailed  JobStatus = "Failed"
+	JobSkipped JobStatus = "Skipped" // Already processed with the same video, subtitle and settings
+)
+
+// Job is a video+subtitle pair waiting in the processing queue
+type Job struct {
+	VideoPath   string
+	SRTPath     string
+	SRTLang     string
+	ExtraSRTs   []string // More subtitle tracks for the video, searched too
+	OutputPath  string
+	Offset      float64
+	MuteBleeps  bool
+	BleepAction swearkiller.Action
+	SkipAds     bool // Leave commercial breaks in DVR recordings alone
+	Timing      subtitleTiming
+	Force       bool                        // Run even if the quality check fails
+	Reprocess   bool                        // Run even if it was already processed with the same settings
+	Overwrite   swearkiller.OverwritePolicy // What to do if the output exists when the job runs
+	Status      JobStatus
+	Progress    float64       // 0.0 to 1.0
+	ETA         time.Duration // How long the encode should still take, while it runs
+	Log         []string
+}
+
+// SwearKillerApp holds the GUI state
+type SwearKillerApp struct {
+	srtPath     string
+	srtLanguage string   // Language tag of the subtitle source, if known
+	srtEmbedded bool     // The subtitle came out of the video, so it follows the file's own timeline
+	extraSRTs   []string // More subtitle tracks for the video, searched too
+	videoPath   string
+	outputPath  string
+	offset      float64
+	swears      []string
+
+	srtLabel          *widget.Label
+	srtButton         *widget.Button
+	transcribeBtn     *widget.Button
+	videoLabel        *widget.Label
+	videoButton       *widget.Button
+	outputLabel       *widget.Label
+	offsetEntry       *widget.Entry
+	logText           *widget.Entry
+	logLevelSelect    *widget.Select
+	logEntries        []swearkiller.LogEntry // Everything logged, so the level filter can be changed later
+	logger            *swearkiller.Logger    // Writes the log file, if one is set
+	processBtn        *widget.Button
+	executeBtn        *widget.Button
+	progressBar       *widget.ProgressBarInfinite
+	realProgressBar   *widget.ProgressBar
+	progressLabel     *widget.Label
+	cancelBtn         *widget.Button
+	cancelEncode      context.CancelFunc // Stops the encode under way on this tab, if any
+	autoOutput        *widget.Check
+	containerSelect   *widget.Select
+	settingsBtn       *widget.Button
+	advisoryBtn       *widget.Button
+	muteBleepsCheck   *widget.Check
+	bleepActionSelect *widget.Select
+	skipAdsCheck      *widget.Check
+	edlRemapSelect    *widget.Select
+	remapFile         string // Piecewise remap table for an edited cut, if any
+	remapLabel        *widget.Label
+	extraSRTLabel     *widget.Label
+	previewEntry      *widget.Entry
+	previewBtn        *widget.Button
+	playerBtn         *widget.Button
+	exportMatchesBtn  *widget.Button
+	compareBtn        *widget.Button
+	blocksBtn         *widget.Button
+	editSegmentsBtn   *widget.Button
+	saveProjectBtn    *widget.Button
+	lastCommand       string
+	lastSegments      []swearkiller.Segment
+	lastDetected      []swearkiller.Segment // Segments as detected, before review and edits, for the project file
+	lastMatches       []swearkiller.Match   // Matches behind lastSegments, for the timeline
+	lastCues          []swearkiller.Cue     // Every subtitle block of the last detection, for the block list
+	timeline          *segmentTimeline
+	timelineLabel     *widget.Label
+	segmentPlayer     *exec.Cmd // ffplay playing a segment clicked on the timeline
+	myWindow          fyne.Window
+	settings          Settings
+	settingsFile      swearkiller.SettingsLocation // Where the settings are kept (see swearkiller.FindSettings)
+	settingsNote      string                       // What loading the settings file did that the log should say, once it's shown
+	lastBroadMatches  []swearkiller.Match          // Broad scan of the last detection, for comparing profiles
+	lastReviewItems   []swearkiller.ReviewItem     // Every match of the last detection and what was decided, for the export
+
+	// Job queue state; jobs and their fields are guarded by queueMu
+	queueMu       sync.Mutex
+	jobs          []*Job
+	selectedJob   int
+	queueRunning  bool
+	processed     *swearkiller.ProcessedState // Videos already processed, so re-runs skip them
+	queueList     *widget.List
+	jobLogText    *widget.Entry
+	parallelEntry *widget.Entry
+	addToQueueBtn *widget.Button
+	startQueueBtn *widget.Button
+	stopQueueBtn  *widget.Button
+	stopQueue     context.CancelFunc // Stops the running queue, if any
+	pauseBtns     []*widget.Button   // Pause buttons on every tab, relabeled together
+
+	// History tab state
+	historyEntries []swearkiller.HistoryEntry
+	historyList    *widget.List
+	historySearch  *widget.Entry
+
+	// Library tab state
+	libraryItems   []swearkiller.LibraryItem // Items shown, after the filter and search
+	libraryList    *widget.List
+	libraryFilter  *widget.Select
+	librarySearch  *widget.Entry
+	libraryRoots   *widget.Label
+	librarySummary *widget.Label
 }
 
 // detectEmbeddedSubtitles uses ffprobe to find embedded subtitle streams with detailed info
@@ -204,10 +191,14 @@ func detectEmbeddedSubtitles(videoPath string) ([]SubtitleStream, error) {
 		Streams []struct {
 			Index     int    `json:"index"`
 			CodecType string `json:"codec_type"`
+			CodecName string `json:"codec_name"`
 			Tags      struct {
 				Language string `json:"language"`
 				Title    string `json:"title"`
 			} `json:"tags"`
+			Disposition struct {
+				Forced int `json:"forced"`
+			} `json:"disposition"`
 		} `json:"streams"`
 	}
 
@@ -231,11 +222,17 @@ func detectEmbeddedSubtitles(videoPath string) ([]SubtitleStream, error) {
 			}
 
 			languageDisplay := formatLanguageDisplay(stream.Tags.Language)
+			forced := stream.Disposition.Forced == 1 || strings.Contains(strings.ToLower(stream.Tags.Title), "forced")
+			if forced && !strings.Contains(strings.ToLower(displayTitle), "forced") {
+				displayTitle += " (forced)"
+			}
 
 			finalStream := SubtitleStream{
 				Index:    subtitleStreamCount,
 				Language: stream.Tags.Language,
 				Title:    fmt.Sprintf("%s - [%s]", displayTitle, languageDisplay),
+				Codec:    stream.CodecName,
+				Forced:   forced,
 			}
 
 			streams = append(streams, finalStream)
@@ -347,33 +344,46 @@ func isSubtitleCodec(codec string) bool {
 	return false
 }
 
+// cleanedWarning returns a warning if video is already a clean output, going by its tag,
+// its name or the record of processed videos, or "" if it isn't
+func (app *SwearKillerApp) cleanedWarning(video string) string {
+	if warning := swearkiller.CleanedWarning(context.Background(), video); warning != "" {
+		return warning
+	}
+	app.loadProcessed()
+	if source, ok := app.processed.SourceOf(video); ok {
+		return fmt.Sprintf("This is the clean output made from %s; you may want to clean the original instead", filepath.Base(source))
+	}
+	return ""
+}
+
 // extractEmbeddedSubtitle extracts a specific subtitle stream to an SRT file
 func extractEmbeddedSubtitle(videoPath string, streamIndex int, outputPath string) error {
 	cmd := exec.Command("ffmpeg", "-i", videoPath, "-map", fmt.Sprintf("0:s:%d", streamIndex), "-c:s", "srt", "-y", outputPath)
 	return cmd.Run()
 }
 
-// generateFFmpegCommand creates an FFmpeg command to mute audio for the given segments
-func generateFFmpegCommand(inputVideo, outputVideo string, segments []Segment) string {
-	if len(segments) == 0 {
-		return fmt.Sprintf("No segments to mute. Copying input to output: ffmpeg -i %q -c copy %q", inputVideo, outputVideo)
-	}
-
-	var enableConditions []string
-	for _, seg := range segments {
-		enableConditions = append(enableConditions, fmt.Sprintf("between(t,%.3f,%.3f)", seg.Start, seg.End))
-	}
-	// Combine conditions with '+' for a single volume filter
-	enableExpr := strings.Join(enableConditions, "+")
-	filter := fmt.Sprintf("volume=enable='%s':volume=0", enableExpr)
-
-	return fmt.Sprintf("ffmpeg -i %q -af %q -c:v copy -c:a aac %q", inputVideo, filter, outputVideo)
-}
-
 // handleVideoSelection processes video file selection and checks for embedded subtitles
 func (app *SwearKillerApp) handleVideoSelection(videoPath string) {
 	app.videoPath = videoPath
+	app.clearExtraSubtitles()
+	app.hideTimeline()
 	app.videoLabel.SetText(fmt.Sprintf("Selected: %s", filepath.Base(videoPath)))
+	if warning := app.cleanedWarning(videoPath); warning != "" {
+		app.log("⚠️ Warning: " + warning)
+	}
+	if tracks, err := swearkiller.AudioTracks(context.Background(), videoPath); err == nil {
+		for _, track := range tracks {
+			if !track.Description {
+				continue
+			}
+			if app.settings.CensorDescriptions {
+				app.log(fmt.Sprintf("Audio description found in audio track %d; it will be censored too", track.Index+1))
+			} else {
+				app.log(fmt.Sprintf("Audio description found in audio track %d; it will be kept as it is (see Settings to censor it too)", track.Index+1))
+			}
+		}
+	}
 
 	// Check for embedded subtitles
 	app.log("Checking for embedded subtitles...")
@@ -383,6 +393,10 @@ func (app *SwearKillerApp) handleVideoSelection(videoPath string) {
 		app.showSRTUploadOption()
 		return
 	}
+	// Closed captions ride along in the video stream rather than in a subtitle stream of their own
+	if captions, err := swearkiller.FindCaptions(context.Background(), videoPath); err == nil && len(captions) > 0 && captions[0].Kind == swearkiller.CaptionsCEA608 {
+		streams = append(streams, SubtitleStream{Index: -1, Title: "📺 Closed captions (CEA-608/708)", Codec: swearkiller.CaptionsCEA608})
+	}
 
 	if len(streams) == 0 {
 		app.log("No embedded subtitles found. Please upload an SRT file.")
@@ -401,8 +415,60 @@ func (app *SwearKillerApp) handleVideoSelection(videoPath string) {
 func (app *SwearKillerApp) showSRTUploadOption() {
 	app.srtButton.Show()
 	app.srtLabel.Show()
+	app.transcribeBtn.Show()
+}
+
+// transcribeAudio turns the selected video's speech into a subtitle with Whisper in the
+// background and uses it as the subtitle. The transcript is saved next to the video.
+func (app *SwearKillerApp) transcribeAudio() {
+	if app.videoPath == "" {
+		return
+	}
+	opts := app.transcribeOptions()
+	if err := opts.Validate(); err != nil {
+		dialog.ShowError(err, app.myWindow)
+		return
+	}
+	videoPath := app.videoPath
+	name := strings.TrimSuffix(filepath.Base(videoPath), filepath.Ext(videoPath))
+	srtPath := filepath.Join(filepath.Dir(videoPath), name+".transcript.srt")
+
+	app.log(fmt.Sprintf("🎙️ Transcribing the audio with Whisper (%s model on %s)...", opts.ModelSize, opts.Device))
+	if duration, err := app.getVideoDuration(); err == nil {
+		app.log(fmt.Sprintf("Estimated time: about %s; finished chunks are cached if it's interrupted", swearkiller.EstimateTranscription(duration, opts.ModelSize, opts.Device)))
+	}
+	app.transcribeBtn.Disable()
+	go func() {
+		cues, err := swearkiller.Transcribe(context.Background(), videoPath, opts, app.logAsync, nil)
+		if err == nil {
+			err = swearkiller.WriteSRTFile(srtPath, cues)
+		}
+		fyne.Do(func() {
+			app.transcribeBtn.Enable()
+			if err != nil {
+				app.log(fmt.Sprintf("❌ Transcription failed: %v", err))
+				return
+			}
+			if app.videoPath != videoPath {
+				return // Another video was picked meanwhile
+			}
+			app.srtPath = srtPath
+			app.srtLanguage = ""
+			app.srtEmbedded = true
+			if opts.Language != "auto" {
+				app.srtLanguage = opts.Language
+			}
+			app.srtLabel.SetText(fmt.Sprintf("Using transcript: %s", filepath.Base(srtPath)))
+			app.srtLabel.Show()
+			app.log(fmt.Sprintf("✅ Transcribed %d line(s) to %s", len(cues), srtPath))
+			app.updateProcessButton()
+		})
+	}()
 }
 
+// transcribeOption is the subtitle source that transcribes the video's speech instead
+const transcribeOption = "🎙️ Transcribe the audio with Whisper"
+
 // showSubtitleSelectionDialog shows a dialog to select subtitle source
 func (app *SwearKillerApp) showSubtitleSelectionDialog(streams []SubtitleStream) {
 	var options []string
@@ -413,8 +479,8 @@ func (app *SwearKillerApp) showSubtitleSelectionDialog(streams []SubtitleStream)
 		_ = i // Keep for mapping back to stream index
 	}
 
-	// Add manual upload option
-	options = append(options, "📁 Upload SRT file manually")
+	// Add manual upload and transcription options
+	options = append(options, "📁 Upload SRT file manually", transcribeOption)
 
 	// Create selection dialog
 	selectWidget := widget.NewSelect(options, func(selected string) {
@@ -437,11 +503,15 @@ func (app *SwearKillerApp) showSubtitleSelectionDialog(streams []SubtitleStream)
 			app.showSRTUploadOption()
 			return
 		}
+		if selected == transcribeOption {
+			app.transcribeAudio()
+			return
+		}
 
 		// Find selected stream index
 		for i, option := range options[:len(streams)] {
 			if option == selected {
-				app.extractAndUseEmbeddedSubtitle(streams[i])
+				app.extractAndUseEmbeddedSubtitle(streams[i], streams)
 				break
 			}
 		}
@@ -450,15 +520,31 @@ func (app *SwearKillerApp) showSubtitleSelectionDialog(streams []SubtitleStream)
 	dialog.Show()
 }
 
-// extractAndUseEmbeddedSubtitle extracts and uses an embedded subtitle
-func (app *SwearKillerApp) extractAndUseEmbeddedSubtitle(stream SubtitleStream) {
+// extractAndUseEmbeddedSubtitle extracts and uses an embedded subtitle. The video's forced
+// text tracks are extracted too and searched as extra tracks.
+func (app *SwearKillerApp) extractAndUseEmbeddedSubtitle(stream SubtitleStream, streams []SubtitleStream) {
 	videoDir := filepath.Dir(app.videoPath)
 	srtPath := filepath.Join(videoDir, "extracted-srt.srt")
 
 	app.log(fmt.Sprintf("🎬 Selected: %s", stream.Title))
-	app.log(fmt.Sprintf("⚙️ Extracting subtitle track %d to %s...", stream.Index, srtPath))
-
-	err := extractEmbeddedSubtitle(app.videoPath, stream.Index, srtPath)
+	if stream.Forced {
+		app.log("⚠️ Warning: This is a forced track, which only covers foreign-language dialogue. Pick the full track to search everything; forced tracks are then searched too.")
+	}
+	if slices.Contains(swearkiller.ImageSubtitleCodecs, stream.Codec) {
+		app.ocrEmbeddedSubtitle(stream, srtPath)
+		return
+	}
+	var err error
+	switch stream.Codec {
+	case swearkiller.CaptionsCEA608, swearkiller.CaptionsTeletext, swearkiller.CaptionsDVB:
+		// TV captions can't simply be copied out as SRT
+		app.log(fmt.Sprintf("⚙️ Converting the captions to %s...", srtPath))
+		source := swearkiller.CaptionSource{Kind: stream.Codec, Stream: stream.Index, Language: stream.Language}
+		err = swearkiller.ExtractCaptions(context.Background(), app.videoPath, source, srtPath)
+	default:
+		app.log(fmt.Sprintf("⚙️ Extracting subtitle track %d to %s...", stream.Index, srtPath))
+		err = extractEmbeddedSubtitle(app.videoPath, stream.Index, srtPath)
+	}
 	if err != nil {
 		app.log(fmt.Sprintf("❌ Error extracting subtitle: %v", err))
 		app.log("💡 Tip: Try using 'Upload SRT file manually' option")
@@ -467,17 +553,87 @@ func (app *SwearKillerApp) extractAndUseEmbeddedSubtitle(stream SubtitleStream)
 	}
 
 	app.srtPath = srtPath
+	app.srtLanguage = stream.Language
+	app.srtEmbedded = true
 	app.srtLabel.SetText(fmt.Sprintf("Using extracted: %s (%s)", stream.Language, filepath.Base(srtPath)))
 	app.log("✅ Subtitle extracted successfully!")
+	if !stream.Forced {
+		app.extractForcedTracks(streams)
+	}
 	app.updateProcessButton()
 }
 
-// log adds a message to the log text area
+// extractForcedTracks extracts the video's forced text subtitle tracks and adds them as
+// extra tracks, so the foreign-language lines only they carry are searched too
+func (app *SwearKillerApp) extractForcedTracks(streams []SubtitleStream) {
+	for _, forced := range streams {
+		if !forced.Forced || forced.Index < 0 || slices.Contains(swearkiller.ImageSubtitleCodecs, forced.Codec) ||
+			slices.Contains([]string{swearkiller.CaptionsTeletext, swearkiller.CaptionsDVB}, forced.Codec) {
+			continue
+		}
+		path := filepath.Join(filepath.Dir(app.videoPath), fmt.Sprintf("extracted-forced-%d.srt", forced.Index))
+		if err := extractEmbeddedSubtitle(app.videoPath, forced.Index, path); err != nil {
+			app.log(fmt.Sprintf("Warning: Could not extract the forced track %s: %v", forced.Title, err))
+			continue
+		}
+		if !slices.Contains(app.extraSRTs, path) {
+			app.extraSRTs = append(app.extraSRTs, path)
+		}
+		app.log(fmt.Sprintf("Also searching the forced track: %s", forced.Title))
+	}
+	app.updateExtraSubtitleLabel()
+}
+
+// ocrEmbeddedSubtitle reads a Blu-ray or DVD image subtitle with OCR in the background and
+// uses the text as the subtitle
+func (app *SwearKillerApp) ocrEmbeddedSubtitle(stream SubtitleStream, srtPath string) {
+	videoPath := app.videoPath
+	app.log(fmt.Sprintf("🔤 Reading the subtitle images with OCR (%s); this can take a few minutes...", cmp.Or(app.settings.OCRLanguage, swearkiller.DefaultOCRLanguage)))
+	go func() {
+		opts := swearkiller.OCROptions{Language: app.settings.OCRLanguage}
+		cues, err := swearkiller.OCRSubtitles(context.Background(), videoPath, stream.Index, opts, app.logAsync)
+		if err == nil {
+			err = swearkiller.WriteSRTFile(srtPath, cues)
+		}
+		fyne.Do(func() {
+			if err != nil {
+				app.log(fmt.Sprintf("❌ Error reading the subtitle images: %v", err))
+				app.log("💡 Tip: Install Tesseract, or try using 'Upload SRT file manually' option")
+				app.showSRTUploadOption()
+				return
+			}
+			if app.videoPath != videoPath {
+				return // Another video was picked meanwhile
+			}
+			app.srtPath = srtPath
+			app.srtLanguage = stream.Language
+			app.srtEmbedded = true
+			app.srtLabel.SetText(fmt.Sprintf("Using OCR: %s (%s)", stream.Language, filepath.Base(srtPath)))
+			app.log(fmt.Sprintf("✅ Read %d subtitle line(s) with OCR; check the matches, as OCR can misread words", len(cues)))
+			app.updateProcessButton()
+		})
+	}()
+}
+
+// logLevels are the choices of the log view's level filter, from most to least detailed
+var logLevels = []string{"Debug", "Info", "Warnings", "Errors"}
+
+// log adds a message to the log, at a level inferred from its "Warning"/"Error" prefix
 func (app *SwearKillerApp) log(message string) {
+	app.logAt(swearkiller.MessageLevel(message, swearkiller.LevelInfo), message)
+}
+
+// logAt adds a message to the log at the given level, showing it if it passes the filter
+func (app *SwearKillerApp) logAt(level swearkiller.LogLevel, message string) {
+	entry := app.logger.Log(level, message)
 	if app.logText == nil {
 		fmt.Printf("LOG: %s\n", message) // Fallback to console if UI not ready
 		return
 	}
+	app.logEntries = append(app.logEntries, entry)
+	if level < app.logLevel() {
+		return
+	}
 	current := app.logText.Text
 	if current != "" {
 		current += "\n"
@@ -488,8 +644,47 @@ func (app *SwearKillerApp) log(message string) {
 	app.logText.CursorRow = len(strings.Split(app.logText.Text, "\n"))
 }
 
+// logLevel returns the least important level the log view shows
+func (app *SwearKillerApp) logLevel() swearkiller.LogLevel {
+	if app.logLevelSelect == nil {
+		return swearkiller.LevelInfo
+	}
+	return swearkiller.LogLevel(max(slices.Index(logLevels, app.logLevelSelect.Selected), 0))
+}
+
+// refreshLog redraws the log view with the entries that pass the level filter
+func (app *SwearKillerApp) refreshLog() {
+	var lines []string
+	for _, entry := range app.logEntries {
+		if entry.Level >= app.logLevel() {
+			lines = append(lines, entry.Message)
+		}
+	}
+	app.logText.SetText(strings.Join(lines, "\n"))
+	app.logText.CursorRow = len(lines)
+}
+
+// openLogFile starts appending the log to the file set in the settings, if any
+func (app *SwearKillerApp) openLogFile() {
+	app.logger.Close()
+	if app.settings.LogFile == "" {
+		return
+	}
+	if err := app.logger.OpenFile(app.settings.LogFile, swearkiller.LevelDebug); err != nil {
+		app.log(fmt.Sprintf("Warning: %v", err))
+	}
+}
+
+// logAsync adds a message to the log from a background goroutine
+func (app *SwearKillerApp) logAsync(message string) {
+	fyne.Do(func() {
+		app.log(message)
+	})
+}
+
 // clearLog clears the log text area
 func (app *SwearKillerApp) clearLog() {
+	app.logEntries = nil
 	app.logText.SetText("")
 }
 
@@ -519,29 +714,76 @@ func (app *SwearKillerApp) updateProcessButton() {
 		app.processBtn.Disable()
 	}
 
+	if app.advisoryBtn != nil {
+		if app.srtPath != "" {
+			app.advisoryBtn.Enable()
+		} else {
+			app.advisoryBtn.Disable()
+		}
+	}
+
+	if app.previewBtn != nil {
+		if canProcess {
+			app.previewBtn.Enable()
+		} else {
+			app.previewBtn.Disable()
+		}
+	}
+
+	if app.addToQueueBtn != nil {
+		if canProcess {
+			app.addToQueueBtn.Enable()
+		} else {
+			app.addToQueueBtn.Disable()
+		}
+	}
+
 	// Enable execute button only if we have a command
 	if app.lastCommand != "" && canProcess {
 		app.executeBtn.Enable()
 	} else {
 		app.executeBtn.Disable()
 	}
+
+	if app.playerBtn != nil {
+		if app.lastCommand != "" && app.videoPath != "" {
+			app.playerBtn.Enable()
+		} else {
+			app.playerBtn.Disable()
+		}
+	}
+	if app.exportMatchesBtn != nil {
+		if app.lastCommand != "" {
+			app.exportMatchesBtn.Enable()
+			app.compareBtn.Enable()
+			app.blocksBtn.Enable()
+			app.editSegmentsBtn.Enable()
+			app.saveProjectBtn.Enable()
+		} else {
+			app.exportMatchesBtn.Disable()
+			app.compareBtn.Disable()
+			app.blocksBtn.Disable()
+			app.editSegmentsBtn.Disable()
+			app.saveProjectBtn.Disable()
+		}
+	}
 }
 
-// generateAutoOutputPath creates output path based on input video with "-CLEAN" suffix
+// generateAutoOutputPath names the output after the input video with the name template
 func (app *SwearKillerApp) generateAutoOutputPath() {
 	if app.videoPath == "" || app.outputLabel == nil {
 		return
 	}
 
-	// Get directory and filename without extension
+	// Name it with the template, with the extension of the chosen container
+	name := swearkiller.NameTemplate(app.settings.NameTemplate).Name(app.videoPath, app.settings.Profile)
 	dir := filepath.Dir(app.videoPath)
-	filename := filepath.Base(app.videoPath)
-	ext := filepath.Ext(filename)
-	nameWithoutExt := strings.TrimSuffix(filename, ext)
-
-	// Create new filename with -CLEAN suffix and .mp4 extension
-	cleanFilename := nameWithoutExt + "-CLEAN.mp4"
-	app.outputPath = filepath.Join(dir, cleanFilename)
+	outputPath, err := swearkiller.ApplyContainer(app.videoPath, filepath.Join(dir, name), app.settings.Container)
+	if err != nil {
+		outputPath = filepath.Join(dir, name)
+	}
+	cleanFilename := filepath.Base(outputPath)
+	app.outputPath = outputPath
 
 	// Update the label
 	app.outputLabel.SetText(fmt.Sprintf("Output: %s", cleanFilename))
@@ -550,331 +792,3478 @@ func (app *SwearKillerApp) generateAutoOutputPath() {
 // processVideo runs the swear killing process
 func (app *SwearKillerApp) processVideo() {
 	app.clearLog()
+	app.hideTimeline()
 	app.log("Starting swear killer process...")
 
 	// Parse offset
-	offsetStr := app.offsetEntry.Text
-	if offsetStr == "" {
-		app.offset = 0.0
-	} else {
-		var err error
-		app.offset, err = strconv.ParseFloat(offsetStr, 64)
-		if err != nil {
-			app.log(fmt.Sprintf("Error: Invalid offset value: %v", err))
-			return
-		}
+	var err error
+	app.offset, err = app.parseOffset()
+	if err != nil {
+		app.log(fmt.Sprintf("Error: Invalid offset value: %v", err))
+		return
 	}
 
+	app.rememberOffset(app.offset)
+
 	app.log(fmt.Sprintf("Using offset: %.1f seconds", app.offset))
 	app.log(fmt.Sprintf("Processing SRT: %s", app.srtPath))
 	app.log(fmt.Sprintf("Input video: %s", app.videoPath))
 	app.log(fmt.Sprintf("Output video: %s", app.outputPath))
 
-	// Find swear timestamps
-	segments, err := app.findSwearTimestamps(app.srtPath, app.swears, app.offset)
+	// Find and merge swear timestamps
+	det, err := app.detectSegments(context.Background(), app.subtitlePaths(), app.srtLanguage, app.videoPath, app.timing(), app.offset, app.log)
 	if err != nil {
 		app.log(fmt.Sprintf("Error processing SRT file: %v", err))
 		return
 	}
 
-	app.log(fmt.Sprintf("Found %d swear segments", len(segments)))
-
-	// Merge overlapping segments
-	mergedSegments := mergeSegments(segments)
-	app.log(fmt.Sprintf("Merged to %d segments", len(mergedSegments)))
-
-	// Generate FFmpeg command
-	ffmpegCmd := generateFFmpegCommand(app.videoPath, app.outputPath, mergedSegments)
-	app.lastCommand = ffmpegCmd
-	app.log("\n=== GENERATED FFMPEG COMMAND ===")
-	if ffmpegCmd == "" {
-		app.log("ERROR: Generated command is empty!")
-	} else {
-		app.log(ffmpegCmd)
+	if det.Quality.Passed() {
+		app.continueProcessing(det)
+		return
 	}
-	app.log("=====================================")
-	app.log("\nClick 'Execute FFmpeg' to run the command automatically!")
-	app.updateProcessButton()
+	dialog.ShowConfirm("Subtitle May Not Match",
+		"The quality check found problems:\n\n"+det.Quality.String()+"\n\nContinue anyway?",
+		func(proceed bool) {
+			if !proceed {
+				app.log("Stopped before any work was done. Check the subtitle and try again.")
+				return
+			}
+			app.log("Continuing despite the quality check")
+			app.continueProcessing(det)
+		}, app.myWindow)
 }
 
-// executeFFmpeg runs the generated FFmpeg command
-func (app *SwearKillerApp) executeFFmpeg() {
-	// Add safety checks
-	if app.progressBar == nil || app.processBtn == nil || app.executeBtn == nil {
-		app.log("Error: UI components not initialized")
+// continueProcessing scans the video if needed and shows the FFmpeg command for a detection
+func (app *SwearKillerApp) continueProcessing(det detection) {
+	if app.settings.RapidReview && !det.Reviewed && len(det.Matches)+len(det.Review) > 0 {
+		app.showRapidReview(det, app.continueProcessing)
 		return
 	}
-
-	if app.lastCommand == "" {
-		app.log("Error: No FFmpeg command to execute")
+	if det.TVEdit && !app.muteBleepsCheck.Checked {
+		dialog.ShowInformation("Possible TV Edit",
+			"This subtitle contains bleeped or starred-out words,\nso the video may already be censored.\n\n"+
+				"Tick 'Also censor existing bleep tones' to silence or soften the bleeps themselves.",
+			app.myWindow)
+	}
+	finish := func(segments []swearkiller.Segment) {
+		app.lastMatches = det.Matches
+		app.lastCues = det.Cues
+		app.lastBroadMatches = det.Broad
+		app.lastDetected = segments
+		app.lastReviewItems = det.Items
+		if app.lastReviewItems == nil {
+			app.lastReviewItems = swearkiller.NewReviewItems(append(append([]swearkiller.Match{}, det.Matches...), det.Review...), app.offset, app.minConfidence())
+		}
+		generate := func(segments []swearkiller.Segment) {
+			app.showGeneratedCommand(segments)
+			if len(det.Review) > 0 {
+				app.showReview(det.Review)
+			}
+		}
+		if app.settings.EditSegments {
+			app.showSegmentEditor(segments, generate)
+			return
+		}
+		generate(segments)
+	}
+	scan := app.videoScan()
+	if !scan.needed() {
+		finish(det.Segments)
 		return
 	}
 
-	app.log("\n=== Executing FFmpeg Command ===")
-	app.log("Starting video processing...")
-
+	// Scanning the video takes a while, so keep the UI responsive
+	app.processBtn.Disable()
 	app.progressBar.Show()
+	videoPath := app.videoPath
+	go func() {
+		segments := scanVideo(context.Background(), videoPath, det.Segments, scan, app.logAsync)
+		fyne.Do(func() {
+			app.progressBar.Hide()
+			finish(segments)
+		})
+	}()
+}
 
-	// Disable buttons during execution
-	app.processBtn.Disable()
-	app.executeBtn.Disable()
+// showReview lets the user choose which uncertain matches to mute as well
+func (app *SwearKillerApp) showReview(review []swearkiller.Match) {
+	checks := make([]*widget.Check, len(review))
+	list := container.NewVBox()
+	for i, match := range review {
+		checks[i] = widget.NewCheck(match.String(), nil)
+		list.Add(checks[i])
+	}
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(600, 250))
+	content := container.NewBorder(
+		widget.NewLabel("These matches were too uncertain to mute automatically. Tick any that should be muted:"),
+		nil, nil, nil, scroll,
+	)
 
-	// Check if input files exist first
-	if _, err := os.Stat(app.videoPath); os.IsNotExist(err) {
-		app.log(fmt.Sprintf("Error: Input video file does not exist: %s", app.videoPath))
-		return
+	reviewDialog := dialog.NewCustomConfirm("Review Uncertain Matches", "Mute Selected", "Leave Unmuted", content, func(mute bool) {
+		var selected []swearkiller.Match
+		for i, check := range checks {
+			decision := swearkiller.ReviewRejected
+			if mute && check.Checked {
+				selected = append(selected, review[i])
+				decision = swearkiller.ReviewAccepted
+			}
+			app.decideReviewItem(review[i], decision)
+		}
+		if len(selected) == 0 {
+			return
+		}
+		app.log(fmt.Sprintf("Adding %d reviewed match(es)", len(selected)))
+		app.addMatches(selected)
+	}, app.myWindow)
+	reviewDialog.Resize(fyne.NewSize(700, 400))
+	reviewDialog.Show()
+}
+
+// addMatches mutes more matches on top of the generated command and generates it again
+func (app *SwearKillerApp) addMatches(matches []swearkiller.Match) {
+	extra := swearkiller.MatchSegments(matches, app.offset, app.log)
+	if profile, ok := app.profile(); ok {
+		extra = profile.Shape(extra)
 	}
+	app.lastMatches = append(append([]swearkiller.Match{}, app.lastMatches...), matches...)
+	app.showGeneratedCommand(swearkiller.MergeSegments(append(append([]swearkiller.Segment{}, app.lastSegments...), extra...)))
+}
 
-	// Check if output directory exists and is writable
-	outputDir := filepath.Dir(app.outputPath)
-	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
-		app.log(fmt.Sprintf("Error: Output directory does not exist: %s", outputDir))
-		return
+// decideReviewItem records what the user decided about an uncertain match, for the export
+func (app *SwearKillerApp) decideReviewItem(match swearkiller.Match, decision swearkiller.ReviewDecision) {
+	for i, item := range app.lastReviewItems {
+		if item.Match.Cue.Start == match.Cue.Start && item.Match.Cue.Text == match.Cue.Text {
+			app.lastReviewItems[i].Decision = decision
+		}
 	}
+}
 
-	// Get volume filter safely
-	volumeFilter := app.getVolumeFilter()
-	if volumeFilter == "" {
-		app.log("Error: Could not generate volume filter")
-		return
+// exportMatches saves every match of the last detection and whether it was muted as CSV
+func (app *SwearKillerApp) exportMatches() {
+	items := app.lastReviewItems
+	saveDialog := app.newFileSave(app.settings.LastOutputDir, func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+		if err := swearkiller.WriteMatchesCSV(writer, items); err != nil {
+			dialog.ShowError(err, app.myWindow)
+			return
+		}
+		app.log(fmt.Sprintf("📝 %d match(es) exported to: %s", len(items), writer.URI().Path()))
+	})
+	title := strings.TrimSuffix(filepath.Base(app.videoPath), filepath.Ext(app.videoPath))
+	saveDialog.SetFileName(title + "-matches.csv")
+	saveDialog.Show()
+}
+
+// showProfileComparison shows what the current settings and each built-in profile would
+// mute, from the last detection's broad scan so nothing is read again
+func (app *SwearKillerApp) showProfileComparison() {
+	current := swearkiller.ProfileResult{Name: "current", Lines: len(app.lastMatches)}
+	segments := swearkiller.MatchSegments(app.lastMatches, app.offset, nil)
+	if profile, ok := app.profile(); ok {
+		segments = profile.Shape(segments)
+	}
+	segments = swearkiller.MergeSegments(segments)
+	current.Segments, current.MutedSeconds = len(segments), swearkiller.SegmentsDuration(segments)
+	for _, item := range app.lastReviewItems {
+		if item.Decision == swearkiller.ReviewUndecided {
+			current.Review++
+		}
 	}
+	results := append([]swearkiller.ProfileResult{current}, swearkiller.CompareProfiles(app.lastBroadMatches, app.offset)...)
+	runtime, _ := app.getVideoDuration()
 
-	// Build FFmpeg command with proper arguments
-	args := []string{
-		"-i", app.videoPath,
-		"-af", fmt.Sprintf("volume=enable='%s':volume=0", volumeFilter),
-		"-c:v", "copy",
-		"-c:a", "aac",
-		"-y", // Overwrite output file if it exists
-		app.outputPath,
+	text := swearkiller.FormatProfileComparison(results, runtime) + "\n\n"
+	if profile, ok := app.profile(); ok {
+		text += fmt.Sprintf("current: the %s profile\n", profile.Name)
+	} else {
+		text += "current: your matching settings\n"
 	}
+	for _, p := range swearkiller.Profiles {
+		text += fmt.Sprintf("%s: %s\n", p.Name, p.Description)
+	}
+	label := widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	content := container.NewVBox(
+		label,
+		widget.NewLabel("Choose a profile in Settings, then generate the command again to use it."),
+	)
+	dialog.ShowCustom("Compare Profiles", "Close", content, app.myWindow)
+}
 
-	app.log(fmt.Sprintf("Running: ffmpeg %s", strings.Join(args, " ")))
+// blockCell is one cell of the subtitle block table; right-clicking it opens its block's menu
+type blockCell struct {
+	widget.Label
+	onMenu func(pos fyne.Position)
+}
 
-	// Get video duration for progress calculation
-	duration, err := app.getVideoDuration()
-	if err != nil {
-		app.log(fmt.Sprintf("Warning: Could not get video duration: %v", err))
-		duration = 0 // Fall back to spinner
+func newBlockCell() *blockCell {
+	c := &blockCell{}
+	c.Truncation = fyne.TextTruncateEllipsis
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// TappedSecondary opens the block's menu where the cell was right-clicked
+func (c *blockCell) TappedSecondary(ev *fyne.PointEvent) {
+	if c.onMenu != nil {
+		c.onMenu(ev.AbsolutePosition)
 	}
+}
 
-	if duration > 0 {
-		app.log(fmt.Sprintf("📹 Video duration: %.1f minutes", duration/60))
-		app.progressBar.Hide()
-		app.realProgressBar.Show()
-		app.progressLabel.Show()
-		app.progressLabel.SetText("Preparing to process video...")
-	} else {
-		app.log("⏳ Processing video... This may take several minutes depending on video length.")
+// blockColumns are the headings of the subtitle block table
+var blockColumns = []string{"#", "Time", "Muted", "Text"}
+
+// blockMuted reports whether any of the generated command's segments covers part of cue
+func (app *SwearKillerApp) blockMuted(cue swearkiller.Cue) bool {
+	start, end := cue.Start+app.offset, cue.End+app.offset
+	return slices.ContainsFunc(app.lastSegments, func(seg swearkiller.Segment) bool { return seg.Start < end && seg.End > start })
+}
+
+// showSubtitleBlocks lists every block of the last detection's subtitle in a searchable
+// table. Right-clicking a block mutes it or adds one of its words to the swear list.
+func (app *SwearKillerApp) showSubtitleBlocks() {
+	cues := app.lastCues
+	shown := cues
+	win := fyne.CurrentApp().NewWindow("Subtitle Blocks")
+	count := widget.NewLabel("")
+	detail := widget.NewLabel("Select a block to read all of it; right-click one to mute it or add a word to the swear list")
+	detail.Wrapping = fyne.TextWrapWord
+
+	var table *widget.Table
+	menu := func(cue swearkiller.Cue, pos fyne.Position) {
+		muteItem := fyne.NewMenuItem("Mute This Block", func() {
+			marked := []swearkiller.Match{swearkiller.MarkedMatch(cue)}
+			app.log(fmt.Sprintf("Muting a block marked by hand: %s", marked[0]))
+			app.lastReviewItems = append(app.lastReviewItems, swearkiller.NewReviewItems(marked, app.offset, 0)...)
+			app.addMatches(marked)
+			table.Refresh()
+		})
+		muteItem.Disabled = app.blockMuted(cue)
+		var words []*fyne.MenuItem
+		for _, word := range swearkiller.CueWords(cue) {
+			words = append(words, fyne.NewMenuItem(word, func() { app.addSwear(word) }))
+		}
+		addItem := fyne.NewMenuItem("Add to Swear List", nil)
+		addItem.ChildMenu = fyne.NewMenu("", words...)
+		addItem.Disabled = len(words) == 0
+		widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", muteItem, addItem), win.Canvas(), pos)
 	}
 
-	// Run ffmpeg command in a separate goroutine to keep UI responsive
+	table = widget.NewTable(
+		func() (int, int) { return len(shown), len(blockColumns) },
+		func() fyne.CanvasObject { return newBlockCell() },
+		func(id widget.TableCellID, object fyne.CanvasObject) {
+			cell, cue := object.(*blockCell), shown[id.Row]
+			switch id.Col {
+			case 0:
+				cell.SetText(strconv.Itoa(cue.Index))
+			case 1:
+				cell.SetText(swearkiller.FormatTimestamp(cue.Start))
+			case 2:
+				cell.SetText("")
+				if app.blockMuted(cue) {
+					cell.SetText("🔇")
+				}
+			default:
+				cell.SetText(cue.Text)
+			}
+			cell.onMenu = func(pos fyne.Position) { menu(cue, pos) }
+		})
+	table.ShowHeaderRow = true
+	table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	table.UpdateHeader = func(id widget.TableCellID, object fyne.CanvasObject) {
+		if id.Col >= 0 {
+			object.(*widget.Label).SetText(blockColumns[id.Col])
+		}
+	}
+	for col, width := range []float32{60, 110, 60, 560} {
+		table.SetColumnWidth(col, width)
+	}
+	table.OnSelected = func(id widget.TableCellID) {
+		cue := shown[id.Row]
+		detail.SetText(fmt.Sprintf("#%d at %s: %s", cue.Index, swearkiller.FormatTimestamp(cue.Start), cue.Text))
+	}
+
+	search := widget.NewEntry()
+	search.SetPlaceHolder("Search the subtitle...")
+	search.OnChanged = func(query string) {
+		shown = swearkiller.SearchCues(cues, query)
+		count.SetText(fmt.Sprintf("Showing %d of %d blocks", len(shown), len(cues)))
+		table.UnselectAll()
+		table.ScrollToTop()
+		table.Refresh()
+	}
+	search.OnChanged("")
+
+	win.SetContent(container.NewBorder(
+		container.NewBorder(nil, nil, nil, count, search),
+		detail, nil, nil, table,
+	))
+	win.Resize(fyne.NewSize(860, 560))
+	win.Show()
+	win.Canvas().Focus(search)
+}
+
+// addSwear adds word to the swear list and saves it. Other blocks are only searched for it
+// when the command is generated again.
+func (app *SwearKillerApp) addSwear(word string) {
+	if slices.ContainsFunc(app.swears, func(swear string) bool { return swearkiller.NormalizeText(swear) == swearkiller.NormalizeText(word) }) {
+		app.log(fmt.Sprintf("%q is already in the swear list", word))
+		return
+	}
+	app.swears = append(app.swears, word)
+	if err := app.saveSettings(); err != nil {
+		app.log(fmt.Sprintf("Warning: Could not save settings: %v", err))
+	}
+	app.log(fmt.Sprintf("Added %q to the swear list. Generate the command again to find it throughout the subtitle.", word))
+	if app.settings.SwearListURL != "" {
+		app.log("Note: the swear list follows a shared list, which will replace it when it is next fetched")
+	}
+}
+
+// rapidReviewHelp lists the rapid review keys
+const rapidReviewHelp = "A / Enter: mute    R / Delete: keep    ←/→: move start    ↓/↑: move end    Space: replay    Backspace: back    Esc: finish"
+
+// showRapidReview steps through every match one at a time, playing its audio, so each can
+// be muted, kept or retimed with a single key. done gets the detection with the reviewed
+// segments once the window is finished or closed.
+func (app *SwearKillerApp) showRapidReview(det detection, done func(detection)) {
+	items := swearkiller.NewReviewItems(append(append([]swearkiller.Match{}, det.Matches...), det.Review...), app.offset, app.minConfidence())
+	win := fyne.CurrentApp().NewWindow("Rapid Review")
+	position := widget.NewLabel("")
+	timing := widget.NewLabel("")
+	text := widget.NewLabelWithStyle("", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	text.Wrapping = fyne.TextWrapWord
+	words := widget.NewLabel("")
+	decision := widget.NewLabel("")
+	summary := widget.NewLabel("")
+
+	// Only one snippet plays at a time; moving on stops the last one
+	var player *exec.Cmd
+	playerMissing := false
+	stopPlayer := func() {
+		if player != nil && player.Process != nil {
+			player.Process.Kill()
+		}
+		player = nil
+	}
+	current := 0
+	play := func() {
+		stopPlayer()
+		if playerMissing || current >= len(items) {
+			return
+		}
+		cmd := exec.Command("ffplay", swearkiller.SnippetArgs(app.videoPath, items[current].Segment, swearkiller.SnippetPadding)...)
+		if err := cmd.Start(); err != nil {
+			playerMissing = true
+			app.log(fmt.Sprintf("Warning: Can't play audio snippets (is ffplay installed?): %v", err))
+			return
+		}
+		player = cmd
+		go cmd.Wait()
+	}
+
+	show := func() {
+		accepted, rejected, undecided := swearkiller.ReviewCounts(items)
+		summary.SetText(fmt.Sprintf("Muting %d, keeping %d, undecided %d (undecided matches are kept)", accepted, rejected, undecided))
+		if current >= len(items) {
+			position.SetText(fmt.Sprintf("All %d matches reviewed", len(items)))
+			timing.SetText("")
+			text.SetText("Press Esc or Finish to apply, or Backspace to go back")
+			words.SetText("")
+			decision.SetText("")
+			return
+		}
+		item := items[current]
+		position.SetText(fmt.Sprintf("Match %d of %d", current+1, len(items)))
+		timing.SetText(fmt.Sprintf("%s - %s (%.1fs)", swearkiller.FormatTimestamp(item.Segment.Start), swearkiller.FormatTimestamp(item.Segment.End), item.Segment.End-item.Segment.Start))
+		text.SetText(item.Match.Cue.Text)
+		words.SetText(fmt.Sprintf("%s (%.0f%% confidence)", strings.Join(item.Match.Words, ", "), item.Match.Confidence*100))
+		decision.SetText("Decision: " + item.Decision.String())
+	}
+	move := func(to int) {
+		current = max(0, min(to, len(items)))
+		show()
+		play()
+	}
+	decide := func(d swearkiller.ReviewDecision) {
+		if current < len(items) {
+			items[current].Decision = d
+			move(current + 1)
+		}
+	}
+	adjust := func(startDelta, endDelta float64) {
+		if current < len(items) {
+			items[current].Adjust(startDelta, endDelta)
+			show()
+			play()
+		}
+	}
+	finished := false
+	finish := func() {
+		if finished {
+			return
+		}
+		finished = true
+		stopPlayer()
+		win.Close()
+		accepted, rejected, undecided := swearkiller.ReviewCounts(items)
+		app.log(fmt.Sprintf("Rapid review: muting %d match(es), keeping %d, %d left undecided", accepted, rejected, undecided))
+		det.Segments = swearkiller.MergeSegments(swearkiller.ReviewedSegments(items))
+		det.Review = nil
+		det.Reviewed = true
+		det.Items = items
+		done(det)
+	}
+
+	win.Canvas().SetOnTypedKey(func(ev *fyne.KeyEvent) {
+		switch ev.Name {
+		case fyne.KeyA, fyne.KeyReturn, fyne.KeyEnter:
+			decide(swearkiller.ReviewAccepted)
+		case fyne.KeyR, fyne.KeyDelete:
+			decide(swearkiller.ReviewRejected)
+		case fyne.KeyLeft:
+			adjust(-swearkiller.ReviewStep, 0)
+		case fyne.KeyRight:
+			adjust(swearkiller.ReviewStep, 0)
+		case fyne.KeyDown:
+			adjust(0, -swearkiller.ReviewStep)
+		case fyne.KeyUp:
+			adjust(0, swearkiller.ReviewStep)
+		case fyne.KeySpace:
+			play()
+		case fyne.KeyBackspace:
+			move(current - 1)
+		case fyne.KeyEscape:
+			finish()
+		}
+	})
+	win.SetCloseIntercept(finish)
+
+	buttons := container.NewHBox(
+		widget.NewButton("Mute", func() { decide(swearkiller.ReviewAccepted) }),
+		widget.NewButton("Keep", func() { decide(swearkiller.ReviewRejected) }),
+		widget.NewButton("Replay", play),
+		widget.NewButton("Back", func() { move(current - 1) }),
+		widget.NewButton("Finish", finish),
+	)
+	win.SetContent(container.NewVBox(
+		position, timing, text, words, decision,
+		widget.NewSeparator(),
+		summary,
+		buttons,
+		widget.NewLabel(rapidReviewHelp),
+	))
+	win.Resize(fyne.NewSize(700, 320))
+	win.Show()
+	move(0)
+}
+
+// encodeOptions returns the options for encoding video to output, keeping its subtitle
+// streams and audio descriptions and censoring those as the settings say, and keeping what
+// undoing the censoring needs if asked
+func (app *SwearKillerApp) encodeOptions(ctx context.Context, video, output string) swearkiller.EncodeOptions {
+	opts := swearkiller.EncodeOptions{CensorDescriptions: app.settings.CensorDescriptions, Fade: app.settings.Fade, KeepOriginal: app.settings.KeepOriginal,
+		Threads: app.settings.Threads}
+	if app.settings.Stash {
+		opts.Stash = swearkiller.StashPath(output)
+	}
+	return opts.WithStreams(ctx, video, output)
+}
+
+// showGeneratedCommand builds the FFmpeg command for the segments and shows it in the log
+func (app *SwearKillerApp) showGeneratedCommand(mergedSegments []swearkiller.Segment) {
+	// Make sure the streams fit the output's container before building the command
+	warnings, err := swearkiller.CheckContainer(context.Background(), app.videoPath, app.outputPath)
+	if err != nil {
+		app.log(fmt.Sprintf("❌ Error: %v", err))
+		app.lastCommand = ""
+		app.updateProcessButton()
+		return
+	}
+	for _, warning := range warnings {
+		app.log("⚠️ Warning: " + warning)
+	}
+
+	// Generate FFmpeg command
+	ffmpegCmd := swearkiller.GenerateFFmpegCommand(app.videoPath, app.outputPath, mergedSegments,
+		app.encodeOptions(context.Background(), app.videoPath, app.outputPath))
+	app.lastCommand = ffmpegCmd
+	app.lastSegments = mergedSegments
+	app.log("\n=== GENERATED FFMPEG COMMAND ===")
+	if ffmpegCmd == "" {
+		app.log("ERROR: Generated command is empty!")
+	} else {
+		app.log(ffmpegCmd)
+	}
+	app.log("=====================================")
+	app.log("\nClick 'Execute FFmpeg' to run the command automatically!")
+	app.showTimeline(mergedSegments)
+	app.updateProcessButton()
+}
+
+// timelineHeight is the height of the segment timeline bar
+const timelineHeight = 28
+
+// timelineSlop is how many pixels either side of a segment still count as pointing at it,
+// so segments a pixel wide on a long video can be hovered and clicked
+const timelineSlop = 3
+
+// segmentTimeline draws the video as a horizontal bar with its censored segments marked.
+// Hovering over a segment describes it and clicking one plays it.
+type segmentTimeline struct {
+	widget.BaseWidget
+	duration float64
+	marks    []swearkiller.TimelineMark
+	hovered  int            // Index of the mark under the pointer, or -1
+	onHover  func(mark int) // Called with -1 when the pointer leaves the marks
+	onTapped func(mark int)
+}
+
+func newSegmentTimeline(onHover, onTapped func(mark int)) *segmentTimeline {
+	t := &segmentTimeline{hovered: -1, onHover: onHover, onTapped: onTapped}
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+// SetMarks shows marks on a video of the given length in seconds
+func (t *segmentTimeline) SetMarks(marks []swearkiller.TimelineMark, duration float64) {
+	t.marks, t.duration, t.hovered = marks, duration, -1
+	t.Refresh()
+}
+
+// markAt returns the index of the mark under a point on the bar, or -1
+func (t *segmentTimeline) markAt(pos fyne.Position) int {
+	width := t.Size().Width
+	if width <= 0 || t.duration <= 0 {
+		return -1
+	}
+	secondsPerPixel := t.duration / float64(width)
+	return swearkiller.MarkAt(t.marks, float64(pos.X)*secondsPerPixel, timelineSlop*secondsPerPixel)
+}
+
+// hover highlights a mark and reports it, if it changed
+func (t *segmentTimeline) hover(mark int) {
+	if mark == t.hovered {
+		return
+	}
+	t.hovered = mark
+	t.Refresh()
+	if t.onHover != nil {
+		t.onHover(mark)
+	}
+}
+
+// MouseIn implements desktop.Hoverable
+func (t *segmentTimeline) MouseIn(ev *desktop.MouseEvent) { t.hover(t.markAt(ev.Position)) }
+
+// MouseMoved implements desktop.Hoverable
+func (t *segmentTimeline) MouseMoved(ev *desktop.MouseEvent) { t.hover(t.markAt(ev.Position)) }
+
+// MouseOut implements desktop.Hoverable
+func (t *segmentTimeline) MouseOut() { t.hover(-1) }
+
+// Tapped reports the mark that was clicked, if any
+func (t *segmentTimeline) Tapped(ev *fyne.PointEvent) {
+	if mark := t.markAt(ev.Position); mark >= 0 && t.onTapped != nil {
+		t.onTapped(mark)
+	}
+}
+
+func (t *segmentTimeline) CreateRenderer() fyne.WidgetRenderer {
+	r := &timelineRenderer{timeline: t, bar: canvas.NewRectangle(theme.Color(theme.ColorNameInputBackground))}
+	r.Refresh()
+	return r
+}
+
+// timelineRenderer draws a segmentTimeline: the bar, then a rectangle per mark
+type timelineRenderer struct {
+	timeline *segmentTimeline
+	bar      *canvas.Rectangle
+	marks    []*canvas.Rectangle
+}
+
+func (r *timelineRenderer) Layout(size fyne.Size) {
+	r.bar.Resize(size)
+	t := r.timeline
+	if t.duration <= 0 {
+		return
+	}
+	for i, rect := range r.marks {
+		seg := t.marks[i].Segment
+		x := float32(seg.Start/t.duration) * size.Width
+		width := max(float32((seg.End-seg.Start)/t.duration)*size.Width, 2)
+		rect.Move(fyne.NewPos(x, 0))
+		rect.Resize(fyne.NewSize(width, size.Height))
+	}
+}
+
+func (r *timelineRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(100, timelineHeight)
+}
+
+// Refresh recolors the marks: the hovered one in the primary color, tones in the warning
+// color and mutes in the error color
+func (r *timelineRenderer) Refresh() {
+	t := r.timeline
+	for len(r.marks) < len(t.marks) {
+		r.marks = append(r.marks, canvas.NewRectangle(nil))
+	}
+	r.marks = r.marks[:len(t.marks)]
+	r.bar.FillColor = theme.Color(theme.ColorNameInputBackground)
+	for i, rect := range r.marks {
+		switch {
+		case i == t.hovered:
+			rect.FillColor = theme.Color(theme.ColorNamePrimary)
+		case t.marks[i].Segment.EffectiveAction() == swearkiller.ActionTone:
+			rect.FillColor = theme.Color(theme.ColorNameWarning)
+		default:
+			rect.FillColor = theme.Color(theme.ColorNameError)
+		}
+	}
+	r.Layout(t.Size())
+	canvas.Refresh(t)
+}
+
+func (r *timelineRenderer) Objects() []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{r.bar}
+	for _, rect := range r.marks {
+		objects = append(objects, rect)
+	}
+	return objects
+}
+
+func (r *timelineRenderer) Destroy() {}
+
+// showTimeline marks segments on the timeline, across the length of the video
+func (app *SwearKillerApp) showTimeline(segments []swearkiller.Segment) {
+	duration, err := app.getVideoDuration()
+	if err != nil || duration <= 0 {
+		// Without ffprobe the timeline ends with the last segment
+		duration = 0
+		for _, seg := range segments {
+			duration = max(duration, seg.End)
+		}
+	}
+	if duration <= 0 {
+		app.hideTimeline()
+		return
+	}
+	app.timeline.SetMarks(swearkiller.TimelineMarks(segments, app.lastMatches, app.offset), duration)
+	app.hoverTimeline(-1)
+	app.timeline.Show()
+	app.timelineLabel.Show()
+}
+
+// hideTimeline hides the timeline until the next command is generated
+func (app *SwearKillerApp) hideTimeline() {
+	app.timeline.SetMarks(nil, 0)
+	app.timeline.Hide()
+	app.timelineLabel.Hide()
+}
+
+// hoverTimeline describes the hovered mark under the timeline, or how to use it
+func (app *SwearKillerApp) hoverTimeline(mark int) {
+	if mark < 0 {
+		app.timelineLabel.SetText(fmt.Sprintf("%d segment(s) censored. Hover over one to see why, or click it to play it.", len(app.timeline.marks)))
+		return
+	}
+	app.timelineLabel.SetText(app.timeline.marks[mark].Label())
+}
+
+// playSegment plays a segment clicked on the timeline
+func (app *SwearKillerApp) playSegment(mark int) {
+	app.previewSegment(app.timeline.marks[mark].Segment)
+}
+
+// previewSegment plays a segment of the original video, with a second either side, in
+// ffplay; playing another segment stops the last one
+func (app *SwearKillerApp) previewSegment(seg swearkiller.Segment) {
+	if app.segmentPlayer != nil && app.segmentPlayer.Process != nil {
+		app.segmentPlayer.Process.Kill()
+	}
+	app.segmentPlayer = nil
+	cmd := exec.Command("ffplay", swearkiller.SegmentPreviewArgs(app.videoPath, seg, swearkiller.SnippetPadding)...)
+	if err := cmd.Start(); err != nil {
+		app.log(fmt.Sprintf("Warning: Can't play the segment (is ffplay installed?): %v", err))
+		return
+	}
+	app.logAt(swearkiller.LevelDebug, fmt.Sprintf("Playing %s - %s", swearkiller.FormatTimestamp(seg.Start), swearkiller.FormatTimestamp(seg.End)))
+	app.segmentPlayer = cmd
+	go cmd.Wait()
+}
+
+// playerFrameWidth is the width in pixels of the frames the preview player shows
+const playerFrameWidth = 640
+
+// editBarPadding is how many seconds the segment editor's bar shows either side of the
+// selected segment, zoomed in so an edge can be dragged to a few hundredths of a second
+const editBarPadding = 5.0
+
+// editBarHandleWidth is the width of the handles on a segment's edges, in pixels
+const editBarHandleWidth = 4
+
+// segmentEditBar draws the segments around the selected one, zoomed in, with handles on
+// their edges that can be dragged to move them
+type segmentEditBar struct {
+	widget.BaseWidget
+	segments    []swearkiller.Segment
+	selected    int     // Index of the segment zoomed in on, or -1
+	from, to    float64 // Seconds shown across the bar
+	dragStarted bool
+	dragging    int // Segment whose edge is being dragged, or -1
+	edge        swearkiller.SegmentEdge
+	onChanged   func(i int) // Called as an edge of segment i is dragged
+}
+
+func newSegmentEditBar(onChanged func(i int)) *segmentEditBar {
+	b := &segmentEditBar{selected: -1, dragging: -1, onChanged: onChanged}
+	b.ExtendBaseWidget(b)
+	return b
+}
+
+// SetSegments shows segments, zoomed in on the selected one. The zoom stays put while an
+// edge is dragged.
+func (b *segmentEditBar) SetSegments(segments []swearkiller.Segment, selected int) {
+	b.segments, b.selected = segments, selected
+	if selected >= 0 && b.dragging < 0 {
+		seg := segments[selected]
+		b.from, b.to = max(seg.Start-editBarPadding, 0), seg.End+editBarPadding
+	}
+	b.Refresh()
+}
+
+// timeAt returns the time x pixels along the bar
+func (b *segmentEditBar) timeAt(x float32) float64 {
+	return b.from + float64(x/b.Size().Width)*(b.to-b.from)
+}
+
+// xAt returns how many pixels along the bar t seconds is
+func (b *segmentEditBar) xAt(t float64) float32 {
+	return float32((t-b.from)/(b.to-b.from)) * b.Size().Width
+}
+
+// Dragged moves the edge the drag started on, if it started on one
+func (b *segmentEditBar) Dragged(ev *fyne.DragEvent) {
+	if b.selected < 0 || b.Size().Width <= 0 {
+		return
+	}
+	if !b.dragStarted {
+		b.dragStarted = true
+		secondsPerPixel := (b.to - b.from) / float64(b.Size().Width)
+		b.dragging, b.edge = swearkiller.EdgeAt(b.segments, b.timeAt(ev.Position.X-ev.Dragged.DX), editBarHandleWidth*secondsPerPixel)
+	}
+	if b.dragging < 0 {
+		return
+	}
+	b.segments[b.dragging] = swearkiller.MoveEdge(b.segments[b.dragging], b.edge, b.timeAt(ev.Position.X))
+	b.Refresh()
+	b.onChanged(b.dragging)
+}
+
+// DragEnd lets go of the edge and zooms in on the selected segment again
+func (b *segmentEditBar) DragEnd() {
+	b.dragStarted, b.dragging = false, -1
+	b.SetSegments(b.segments, b.selected)
+}
+
+func (b *segmentEditBar) CreateRenderer() fyne.WidgetRenderer {
+	r := &editBarRenderer{bar: b, background: canvas.NewRectangle(theme.Color(theme.ColorNameInputBackground))}
+	r.Refresh()
+	return r
+}
+
+// editBarRenderer draws a segmentEditBar: the background, then each segment and its handles
+type editBarRenderer struct {
+	bar        *segmentEditBar
+	background *canvas.Rectangle
+	rects      []*canvas.Rectangle // Body, start handle and end handle of each segment
+}
+
+func (r *editBarRenderer) Layout(size fyne.Size) {
+	r.background.Resize(size)
+	b := r.bar
+	handle := func(rect *canvas.Rectangle, x float32) {
+		if x < 0 || x > size.Width {
+			rect.Hide()
+			return
+		}
+		rect.Move(fyne.NewPos(min(max(x-editBarHandleWidth/2, 0), size.Width-editBarHandleWidth), 0))
+		rect.Resize(fyne.NewSize(editBarHandleWidth, size.Height))
+		rect.Show()
+	}
+	for i, seg := range b.segments {
+		body, start, end := r.rects[3*i], r.rects[3*i+1], r.rects[3*i+2]
+		if b.selected < 0 || seg.End < b.from || seg.Start > b.to {
+			body.Hide()
+			start.Hide()
+			end.Hide()
+			continue
+		}
+		x0, x1 := max(b.xAt(seg.Start), 0), min(b.xAt(seg.End), size.Width)
+		body.Move(fyne.NewPos(x0, 0))
+		body.Resize(fyne.NewSize(max(x1-x0, 2), size.Height))
+		body.Show()
+		handle(start, b.xAt(seg.Start))
+		handle(end, b.xAt(seg.End))
+	}
+}
+
+func (r *editBarRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(100, 2*timelineHeight)
+}
+
+// Refresh colors the selected segment in the primary color, the others in the error color
+// and the handles in the foreground color
+func (r *editBarRenderer) Refresh() {
+	b := r.bar
+	for len(r.rects) < 3*len(b.segments) {
+		r.rects = append(r.rects, canvas.NewRectangle(nil))
+	}
+	r.rects = r.rects[:3*len(b.segments)]
+	r.background.FillColor = theme.Color(theme.ColorNameInputBackground)
+	for i, rect := range r.rects {
+		switch {
+		case i%3 != 0:
+			rect.FillColor = theme.Color(theme.ColorNameForeground)
+		case i/3 == b.selected:
+			rect.FillColor = theme.Color(theme.ColorNamePrimary)
+		default:
+			rect.FillColor = theme.Color(theme.ColorNameError)
+		}
+	}
+	r.Layout(b.Size())
+	canvas.Refresh(b)
+}
+
+func (r *editBarRenderer) Objects() []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{r.background}
+	for _, rect := range r.rects {
+		objects = append(objects, rect)
+	}
+	return objects
+}
+
+func (r *editBarRenderer) Destroy() {}
+
+// showSegmentEditor lets segments be added, deleted and retimed by hand, by typing their
+// times or dragging their edges, for the scene where the subtitle is a little off. done
+// gets the edited segments once they are applied, or the ones passed in if the changes
+// are discarded.
+func (app *SwearKillerApp) showSegmentEditor(segments []swearkiller.Segment, done func([]swearkiller.Segment)) {
+	edited := slices.Clone(segments)
+	selected := -1
+	win := fyne.CurrentApp().NewWindow("Edit Segments")
+	startEntry, endEntry := widget.NewEntry(), widget.NewEntry()
+	startEntry.SetPlaceHolder("00:00:00,000")
+	endEntry.SetPlaceHolder("00:00:00,000")
+	view := widget.NewLabel("")
+	view.Wrapping = fyne.TextWrapWord
+	describe := func(seg swearkiller.Segment) string {
+		return fmt.Sprintf("%s - %s (%.2fs, %s)", swearkiller.FormatSRTTime(seg.Start), swearkiller.FormatSRTTime(seg.End), seg.End-seg.Start, seg.EffectiveAction())
+	}
+
+	var list *widget.List
+	var setBtn, deleteBtn, playBtn *widget.Button
+	bar := newSegmentEditBar(func(i int) {
+		list.RefreshItem(i)
+		if i == selected {
+			startEntry.SetText(swearkiller.FormatSRTTime(edited[i].Start))
+			endEntry.SetText(swearkiller.FormatSRTTime(edited[i].End))
+		}
+	})
+	showSelected := func() {
+		bar.SetSegments(edited, selected)
+		if selected < 0 {
+			startEntry.SetText("")
+			endEntry.SetText("")
+			view.SetText("Select a segment to zoom in on it, or type a start and end and add a new one.")
+			setBtn.Disable()
+			deleteBtn.Disable()
+			playBtn.Disable()
+			return
+		}
+		startEntry.SetText(swearkiller.FormatSRTTime(edited[selected].Start))
+		endEntry.SetText(swearkiller.FormatSRTTime(edited[selected].End))
+		view.SetText(fmt.Sprintf("Showing %s to %s. Drag a segment's edges to move its start or end.",
+			swearkiller.FormatSRTTime(bar.from), swearkiller.FormatSRTTime(bar.to)))
+		setBtn.Enable()
+		deleteBtn.Enable()
+		playBtn.Enable()
+	}
+	list = widget.NewList(
+		func() int { return len(edited) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			object.(*widget.Label).SetText(describe(edited[id]))
+		})
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		showSelected()
+	}
+	// reorder puts the segments back in time order and selects seg
+	reorder := func(seg swearkiller.Segment) {
+		swearkiller.SortSegments(edited)
+		list.UnselectAll() // So selecting the same row again shows the new times
+		list.Refresh()
+		list.Select(slices.Index(edited, seg))
+	}
+
+	setBtn = widget.NewButton("Set Times", func() {
+		seg, err := swearkiller.ParseSegment(startEntry.Text, endEntry.Text, edited[selected].Action)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		edited[selected] = seg
+		reorder(seg)
+	})
+	addBtn := widget.NewButton("Add as New", func() {
+		// New segments are censored like the one selected, or the first one
+		var action swearkiller.Action
+		if selected >= 0 {
+			action = edited[selected].Action
+		} else if len(edited) > 0 {
+			action = edited[0].Action
+		}
+		seg, err := swearkiller.ParseSegment(startEntry.Text, endEntry.Text, action)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		edited = append(edited, seg)
+		reorder(seg)
+	})
+	deleteBtn = widget.NewButton("Delete", func() {
+		edited = slices.Delete(edited, selected, selected+1)
+		list.UnselectAll()
+		list.Refresh()
+		if next := min(selected, len(edited)-1); next >= 0 {
+			list.Select(next)
+			return
+		}
+		selected = -1
+		showSelected()
+	})
+	playBtn = widget.NewButton("Play", func() { app.previewSegment(edited[selected]) })
+
+	finished := false
+	finish := func(apply bool) {
+		if finished {
+			return
+		}
+		finished = true
+		win.Close()
+		if !apply {
+			done(segments)
+			return
+		}
+		swearkiller.SortSegments(edited)
+		app.log(fmt.Sprintf("✏️ Segments edited by hand: %d, from %d detected", len(edited), len(segments)))
+		done(edited)
+	}
+	win.SetCloseIntercept(func() { finish(false) })
+
+	times := container.NewGridWithColumns(2,
+		container.NewBorder(nil, nil, widget.NewLabel("Start:"), nil, startEntry),
+		container.NewBorder(nil, nil, widget.NewLabel("End:"), nil, endEntry),
+	)
+	win.SetContent(container.NewBorder(
+		container.NewVBox(bar, view, times, container.NewHBox(setBtn, addBtn, deleteBtn, playBtn), widget.NewSeparator()),
+		container.NewHBox(
+			widget.NewButton("Discard Changes", func() { finish(false) }),
+			widget.NewButtonWithIcon("Apply", theme.ConfirmIcon(), func() { finish(true) }),
+		),
+		nil, nil, list,
+	))
+	showSelected()
+	if len(edited) > 0 {
+		list.Select(0)
+	}
+	win.Resize(fyne.NewSize(760, 560))
+	win.Show()
+}
+
+// editSegments opens the segment editor on the generated command's segments and generates
+// the command again if they were changed
+func (app *SwearKillerApp) editSegments() {
+	app.showSegmentEditor(app.lastSegments, func(segments []swearkiller.Segment) {
+		if !slices.Equal(segments, app.lastSegments) {
+			app.showGeneratedCommand(segments)
+		}
+	})
+}
+
+// saveProject saves the session to a project file, so the review can be picked up later or
+// the video encoded again with other settings
+func (app *SwearKillerApp) saveProject() {
+	project := swearkiller.Project{
+		Video:          app.videoPath,
+		Subtitle:       app.srtPath,
+		Language:       app.srtLanguage,
+		Embedded:       app.srtEmbedded,
+		ExtraSubtitles: app.extraSRTs,
+		Output:         app.outputPath,
+		Offset:         app.offset,
+		Profile:        app.settings.Profile,
+		Detected:       app.lastDetected,
+		Segments:       app.lastSegments,
+		Review:         swearkiller.ProjectItems(app.lastReviewItems),
+	}
+	saveDialog := app.newFileSave(filepath.Dir(app.videoPath), func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		writer.Close()
+		path := writer.URI().Path()
+		if err := swearkiller.WriteProjectFile(path, project); err != nil {
+			dialog.ShowError(err, app.myWindow)
+			return
+		}
+		app.log(fmt.Sprintf("💾 Project saved to: %s", path))
+	})
+	saveDialog.SetFileName(filepath.Base(swearkiller.ProjectPath(app.videoPath)))
+	saveDialog.Show()
+}
+
+// openProject picks a project file and carries on with it
+func (app *SwearKillerApp) openProject() {
+	app.showFileOpen(app.settings.LastVideoDir, func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		reader.Close()
+		path := reader.URI().Path()
+		project, err := swearkiller.ReadProjectFile(path)
+		if err != nil {
+			dialog.ShowError(err, app.myWindow)
+			return
+		}
+		app.loadProject(path, project)
+	})
+}
+
+// loadProject restores a saved session: its files and offset, the segments to censor and
+// the decisions made so far. The command is generated with the current encoding settings,
+// and matches nobody decided about yet are asked about again.
+func (app *SwearKillerApp) loadProject(path string, project swearkiller.Project) {
+	app.clearLog()
+	app.hideTimeline()
+	app.log(fmt.Sprintf("📂 Opened project %s, saved %s", path, project.Saved.Local().Format("2006-01-02 15:04")))
+	for _, file := range append([]string{project.Video, project.Subtitle}, project.ExtraSubtitles...) {
+		if _, err := os.Stat(file); file != "" && err != nil {
+			app.log(fmt.Sprintf("⚠️ Warning: %s is missing; the project needs it", file))
+		}
+	}
+	if project.Profile != app.settings.Profile {
+		app.log(fmt.Sprintf("The project's matches were found with the %q profile and your settings use %q; the saved segments are used as they are",
+			project.Profile, app.settings.Profile))
+	}
+
+	app.videoPath = project.Video
+	app.videoLabel.SetText(fmt.Sprintf("Selected: %s", filepath.Base(app.videoPath)))
+	app.srtPath, app.srtLanguage, app.srtEmbedded = project.Subtitle, project.Language, project.Embedded
+	if app.srtPath != "" {
+		app.srtLabel.SetText(fmt.Sprintf("SRT: %s", filepath.Base(app.srtPath)))
+	}
+	app.showSRTUploadOption()
+	app.extraSRTs = project.ExtraSubtitles
+	app.updateExtraSubtitleLabel()
+	app.outputPath = project.Output
+	app.outputLabel.SetText(fmt.Sprintf("Output: %s", filepath.Base(app.outputPath)))
+	app.offset = project.Offset
+	app.offsetEntry.SetText(strconv.FormatFloat(project.Offset, 'f', -1, 64))
+	app.updateProcessButton() // Names the output by the template instead, if that's chosen
+
+	app.lastDetected = project.Detected
+	app.lastReviewItems = project.ReviewItems()
+	app.lastMatches, app.lastBroadMatches = nil, nil
+	var undecided []swearkiller.Match
+	for _, item := range app.lastReviewItems {
+		switch item.Decision {
+		case swearkiller.ReviewAccepted:
+			app.lastMatches = append(app.lastMatches, item.Match)
+		case swearkiller.ReviewUndecided:
+			undecided = append(undecided, item.Match)
+		}
+	}
+	app.lastCues = nil
+	if cues, err := readSubtitle(app.subtitlePaths(), app.videoPath, app.timing(), func(message string) { app.logAt(swearkiller.LevelDebug, message) }); err == nil {
+		app.lastCues = cues
+	}
+	app.log(fmt.Sprintf("%d segment(s) to censor; %d were detected", len(project.Segments), len(project.Detected)))
+	app.showGeneratedCommand(project.Segments)
+	if len(undecided) > 0 {
+		app.log(fmt.Sprintf("🔍 %d match(es) still to review", len(undecided)))
+		app.showReview(undecided)
+	}
+}
+
+// showPlayer opens the preview player, where the video can be scrubbed or jumped to a
+// detected line, played as it is or as it would be censored, and the offset nudged until
+// the mutes line up
+func (app *SwearKillerApp) showPlayer() {
+	duration, err := app.getVideoDuration()
+	if err != nil || duration <= 0 {
+		dialog.ShowError(fmt.Errorf("couldn't read the video's length (is ffprobe installed?): %v", err), app.myWindow)
+		return
+	}
+	matches := append([]swearkiller.Match{}, app.lastMatches...)
+	slices.SortStableFunc(matches, func(a, b swearkiller.Match) int { return cmp.Compare(a.Cue.Start, b.Cue.Start) })
+	offset := app.offset
+	position := 0.0
+
+	win := fyne.CurrentApp().NewWindow("Preview Player")
+	frame := canvas.NewImageFromResource(nil)
+	frame.FillMode = canvas.ImageFillContain
+	frame.SetMinSize(fyne.NewSize(480, 270))
+	timeLabel := widget.NewLabel("")
+	offsetLabel := widget.NewLabel("")
+	slider := widget.NewSlider(0, duration)
+	slider.Step = 0.1
+	showTime := func(at float64) {
+		timeLabel.SetText(fmt.Sprintf("%s / %s", swearkiller.FormatTimestamp(at), swearkiller.FormatTimestamp(duration)))
+	}
+
+	// Frames are extracted in the background; only the latest one asked for is shown
+	frameRequest := 0
+	loadFrame := func() {
+		frameRequest++
+		request, at, video := frameRequest, position, app.videoPath
+		go func() {
+			data, err := swearkiller.ExtractFrame(video, at, playerFrameWidth)
+			fyne.Do(func() {
+				if request != frameRequest {
+					return
+				}
+				frame.Resource = nil
+				if err != nil {
+					app.logAt(swearkiller.LevelDebug, fmt.Sprintf("Preview player: %v", err))
+				} else {
+					frame.Resource = fyne.NewStaticResource("frame.png", data)
+				}
+				frame.Refresh()
+			})
+		}()
+	}
+	seek := func(to float64) {
+		position = max(0, min(to, duration))
+		slider.Value = position
+		slider.Refresh()
+		showTime(position)
+		loadFrame()
+	}
+	slider.OnChanged = showTime
+	slider.OnChangeEnded = seek
+
+	lines := widget.NewList(
+		func() int { return len(matches) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			m := matches[id]
+			item.(*widget.Label).SetText(fmt.Sprintf("%s  %s: %s", swearkiller.FormatTimestamp(m.Cue.Start+offset), strings.Join(m.Words, ", "), m.Cue.Text))
+		},
+	)
+	lines.OnSelected = func(id widget.ListItemID) {
+		seek(matches[id].Cue.Start + offset - swearkiller.SnippetPadding)
+	}
+	setOffset := func(value float64) {
+		offset = math.Round(value*10) / 10
+		offsetLabel.SetText(fmt.Sprintf("Offset: %+.1fs", offset))
+		lines.Refresh()
+	}
+
+	// Only one clip plays at a time; playing another stops the last one
+	var player *exec.Cmd
+	stopPlayer := func() {
+		if player != nil && player.Process != nil {
+			player.Process.Kill()
+		}
+		player = nil
+	}
+	play := func(censored bool) {
+		stopPlayer()
+		segments := swearkiller.MatchSegments(matches, offset, nil)
+		if profile, ok := app.profile(); ok {
+			segments = profile.Shape(segments)
+		}
+		segments = swearkiller.MergeSegments(segments)
+		cmd := exec.Command("ffplay", swearkiller.PlayerArgs(app.videoPath, position, swearkiller.PlayerClipLength, segments, censored)...)
+		if err := cmd.Start(); err != nil {
+			app.log(fmt.Sprintf("Warning: Can't play the video (is ffplay installed?): %v", err))
+			return
+		}
+		player = cmd
+		go cmd.Wait()
+	}
+
+	nudge := func(label string, delta float64) *widget.Button {
+		return widget.NewButton(label, func() { setOffset(offset + delta) })
+	}
+	controls := container.NewHBox(
+		widget.NewButton("◀ 5s", func() { seek(position - 5) }),
+		widget.NewButton("Play", func() { play(false) }),
+		widget.NewButton("Play Censored", func() { play(true) }),
+		widget.NewButton("5s ▶", func() { seek(position + 5) }),
+	)
+	offsetControls := container.NewHBox(
+		offsetLabel,
+		nudge("-1s", -1), nudge("-0.1s", -0.1), nudge("+0.1s", 0.1), nudge("+1s", 1),
+		widget.NewButton("Use This Offset", func() {
+			app.offsetEntry.SetText(strconv.FormatFloat(offset, 'f', -1, 64))
+			app.log(fmt.Sprintf("Offset set to %g seconds in the preview player; generate the command again to use it", offset))
+		}),
+	)
+	help := widget.NewLabel(fmt.Sprintf("Play Censored plays %g seconds with the detected lines muted at this offset. Use headphones to hear whether each mute covers the word.", swearkiller.PlayerClipLength))
+	help.Wrapping = fyne.TextWrapWord
+
+	win.SetContent(container.NewBorder(
+		container.NewVBox(frame, slider, timeLabel, controls, offsetControls, help,
+			widget.NewLabel("Detected lines (select one to jump to it):")),
+		nil, nil, nil, lines,
+	))
+	win.SetOnClosed(stopPlayer)
+	win.Resize(fyne.NewSize(700, 750))
+	win.Show()
+	setOffset(offset)
+	if len(matches) > 0 {
+		lines.Select(0)
+	} else {
+		seek(0)
+	}
+}
+
+// parseOffset reads the time offset entry; an empty entry means no offset
+func (app *SwearKillerApp) parseOffset() (float64, error) {
+	offsetStr := strings.TrimSpace(app.offsetEntry.Text)
+	if offsetStr == "" {
+		return 0.0, nil
+	}
+	return strconv.ParseFloat(offsetStr, 64)
+}
+
+// subtitleTiming says how to move subtitle timestamps onto the video's timeline
+type subtitleTiming struct {
+	EDLRemap  swearkiller.RemapMode // Remap around the breaks in the Comskip EDL next to the video
+	RemapFile string                // Piecewise remap table for an edited cut
+	Embedded  bool                  // The subtitle came out of the video, so ordered chapters don't apply
+}
+
+// readSubtitle reads subtitle files, merging several tracks into one, moves them from
+// playback order onto the file if the video is an MKV with ordered chapters, and, if asked,
+// remaps their timestamps onto the video's timeline using the Comskip EDL next to the video
+// and then a remap table
+func readSubtitle(srtPaths []string, videoPath string, timing subtitleTiming, logFn func(string)) ([]swearkiller.Cue, error) {
+	cues, report, err := swearkiller.ReadSubtitleTracks(srtPaths)
+	if err != nil {
+		return nil, err
+	}
+	if len(srtPaths) > 1 {
+		logFn(fmt.Sprintf("Merged %d subtitle tracks into %d line(s)", len(srtPaths), len(cues)))
+	}
+	if report.Skipped > 0 {
+		logFn("⚠️ " + report.String())
+	}
+	if !timing.Embedded {
+		timeline, err := swearkiller.PlaybackTimeline(videoPath)
+		if err != nil {
+			return nil, err
+		}
+		if timeline != nil {
+			cues = timeline.Apply(cues)
+			logFn(fmt.Sprintf("📑 The video has %d ordered chapter(s); moved the subtitle from playback order onto the file's timeline", len(timeline)))
+		}
+	}
+
+	if timing.EDLRemap != swearkiller.RemapNone {
+		edlPath := swearkiller.EDLPathFor(videoPath)
+		if edlPath == "" {
+			return nil, fmt.Errorf("no Comskip EDL file found next to the video to remap the subtitle with")
+		}
+		breaks, err := swearkiller.ReadEDLFile(edlPath)
+		if err != nil {
+			return nil, err
+		}
+		remapped := swearkiller.RemapCues(cues, breaks, timing.EDLRemap)
+		logFn(fmt.Sprintf("📺 Remapped subtitle timestamps around %d commercial break(s) from %s", len(breaks), filepath.Base(edlPath)))
+		if dropped := len(cues) - len(remapped); dropped > 0 {
+			logFn(fmt.Sprintf("Dropped %d subtitle line(s) that were inside the cut commercials", dropped))
+		}
+		cues = remapped
+	}
+
+	if timing.RemapFile != "" {
+		table, err := swearkiller.ReadRemapFile(timing.RemapFile)
+		if err != nil {
+			return nil, err
+		}
+		remapped := table.Apply(cues)
+		logFn(fmt.Sprintf("✂️ Remapped subtitle timestamps with %d range(s) from %s", len(table), filepath.Base(timing.RemapFile)))
+		if dropped := len(cues) - len(remapped); dropped > 0 {
+			logFn(fmt.Sprintf("Dropped %d subtitle line(s) that fall outside every range", dropped))
+		}
+		cues = remapped
+	}
+	return cues, nil
+}
+
+// timing returns the subtitle timing fixes chosen in the main window
+func (app *SwearKillerApp) timing() subtitleTiming {
+	return subtitleTiming{EDLRemap: app.remapMode(), RemapFile: app.remapFile, Embedded: app.srtEmbedded}
+}
+
+// chooseRemapFile lets the user pick a remap table, checking it before it is used
+func (app *SwearKillerApp) chooseRemapFile() {
+	app.showFileOpen(app.settings.LastSubtitleDir, func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+		path := reader.URI().Path()
+		table, err := swearkiller.ReadRemapFile(path)
+		if err != nil {
+			dialog.ShowError(err, app.myWindow)
+			return
+		}
+		app.remapFile = path
+		app.remapLabel.SetText(fmt.Sprintf("%s (%d ranges)", filepath.Base(path), len(table)))
+		app.log(fmt.Sprintf("Remap table: %s", path))
+	})
+}
+
+// subtitlePaths returns the chosen subtitle followed by any extra tracks
+func (app *SwearKillerApp) subtitlePaths() []string {
+	return append([]string{app.srtPath}, app.extraSRTs...)
+}
+
+// addExtraSubtitle lets the user pick another subtitle track for the video, like a forced
+// track or another language, whose matches are added to the main subtitle's
+func (app *SwearKillerApp) addExtraSubtitle() {
+	app.showFileOpen(app.settings.LastSubtitleDir, func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+		path := reader.URI().Path()
+		if path == app.srtPath || slices.Contains(app.extraSRTs, path) {
+			return
+		}
+		if _, _, err := swearkiller.ReadSRTFileWithReport(path); err != nil {
+			dialog.ShowError(err, app.myWindow)
+			return
+		}
+		app.extraSRTs = append(app.extraSRTs, path)
+		app.updateExtraSubtitleLabel()
+		app.log(fmt.Sprintf("Extra subtitle track: %s", path))
+	})
+}
+
+// clearExtraSubtitles stops searching extra subtitle tracks
+func (app *SwearKillerApp) clearExtraSubtitles() {
+	app.extraSRTs = nil
+	app.updateExtraSubtitleLabel()
+}
+
+// updateExtraSubtitleLabel lists the extra subtitle tracks in the main window
+func (app *SwearKillerApp) updateExtraSubtitleLabel() {
+	if app.extraSRTLabel == nil {
+		return
+	}
+	if len(app.extraSRTs) == 0 {
+		app.extraSRTLabel.SetText("No extra tracks")
+		return
+	}
+	names := make([]string, len(app.extraSRTs))
+	for i, path := range app.extraSRTs {
+		names[i] = filepath.Base(path)
+	}
+	app.extraSRTLabel.SetText(strings.Join(names, ", "))
+}
+
+// clearRemapFile stops using a remap table
+func (app *SwearKillerApp) clearRemapFile() {
+	app.remapFile = ""
+	app.remapLabel.SetText("No remap table")
+}
+
+// remapMode returns how the subtitle's timestamps should be remapped onto the video
+func (app *SwearKillerApp) remapMode() swearkiller.RemapMode {
+	if app.edlRemapSelect == nil {
+		return swearkiller.RemapNone
+	}
+	switch app.edlRemapSelect.Selected {
+	case edlRemapCut:
+		return swearkiller.RemapCut
+	case edlRemapInsert:
+		return swearkiller.RemapInsert
+	}
+	return swearkiller.RemapNone
+}
+
+// detection is the result of scanning a subtitle for swears
+type detection struct {
+	Segments []swearkiller.Segment // Merged segments to mute
+	Matches  []swearkiller.Match   // Matches confident enough to mute
+	Review   []swearkiller.Match   // Uncertain matches held back for the user to check
+	Reviewed bool                  // Every match has been through rapid review
+	TVEdit   bool                  // The subtitle looks like an already-censored TV edit
+	Quality  swearkiller.QualityReport
+	Items    []swearkiller.ReviewItem // The rapid review decisions, if it ran
+	Broad    []swearkiller.Match      // Matches of a broad scan, for comparing profiles
+	Cues     []swearkiller.Cue        // Every block of the subtitle
+}
+
+// detectSegments finds swears in a subtitle file and returns the merged mute segments.
+// Matches below the minimum confidence are returned for review instead of being muted.
+func (app *SwearKillerApp) detectSegments(ctx context.Context, srtPaths []string, langHint, videoPath string, timing subtitleTiming, offset float64, logFn func(string)) (detection, error) {
+	cues, err := readSubtitle(srtPaths, videoPath, timing, logFn)
+	if err != nil {
+		return detection{}, err
+	}
+
+	tvEdit := swearkiller.DetectTVEdit(cues)
+	if tvEdit.Likely() {
+		logFn(fmt.Sprintf("⚠️ This may already be a censored TV edit: %d subtitle line(s) contain bleeped or starred-out words (first at %s)",
+			len(tvEdit.Cues), swearkiller.FormatTimestamp(tvEdit.Cues[0].Start)))
+	}
+
+	swears, languages := app.swearsForSubtitle(cues, langHint, logFn)
+	quality := swearkiller.CheckVideoQuality(ctx, cues, videoPath, languages)
+	if !quality.Passed() {
+		logFn("⚠️ Quality check: the subtitle may not match this video:\n" + quality.String())
+	}
+	matches, broad := app.findMatches(cues, swears, languages)
+	for _, match := range matches {
+		logFn("  " + match.String())
+	}
+	matches, review := swearkiller.SplitByConfidence(matches, app.minConfidence())
+	if len(review) > 0 {
+		logFn(fmt.Sprintf("🔍 %d uncertain match(es) below %.0f%% confidence were held back for review", len(review), app.minConfidence()*100))
+	}
+	if len(matches) == 0 && len(review) == 0 {
+		logFn("🔍 " + swearkiller.DiagnoseZeroMatches(srtPaths[0], cues, swears, app.matchOptions(languages)).String())
+	} else if warning := swearkiller.LanguageMismatch(swearkiller.GuessLanguage(cues), languages); warning != "" {
+		logFn("⚠️ " + warning)
+	}
+	segments := swearkiller.MatchSegments(matches, offset, logFn)
+	if profile, ok := app.profile(); ok {
+		segments = profile.Shape(segments)
+	}
+	logFn(fmt.Sprintf("Found %d swear segments", len(segments)))
+
+	// Merge overlapping segments
+	mergedSegments := swearkiller.MergeSegments(segments)
+	logFn(fmt.Sprintf("Merged to %d segments", len(mergedSegments)))
+	runtime, _ := swearkiller.ProbeDuration(ctx, videoPath)
+	logFn("📊 " + swearkiller.BuildMatchStats(matches, review, mergedSegments, runtime).String())
+	return detection{Segments: mergedSegments, Matches: matches, Review: review, Broad: broad, TVEdit: tvEdit.Likely(), Quality: quality, Cues: cues}, nil
+}
+
+// findBleepSegments scans the video's audio for existing bleep tones so they can be censored
+// with the given action too
+func findBleepSegments(ctx context.Context, videoPath string, opts swearkiller.ToneOptions, action swearkiller.Action, logFn func(string)) []swearkiller.Segment {
+	logFn("🔊 Scanning audio for existing bleep tones...")
+	tones, err := swearkiller.DetectTones(ctx, videoPath, opts)
+	if err != nil {
+		logFn(fmt.Sprintf("Warning: Could not scan for bleep tones: %v", err))
+		return nil
+	}
+	logFn(fmt.Sprintf("Found %d bleep tone(s)", len(tones)))
+	return swearkiller.WithAction(tones, action)
+}
+
+// videoScan holds the options that need FFmpeg to read through the video itself
+type videoScan struct {
+	MuteBleeps  bool
+	BleepAction swearkiller.Action
+	SkipAds     bool
+	MaxDuration float64 // Only scan this many seconds from the start (0 = whole file)
+}
+
+// needed reports whether any scan was chosen
+func (scan videoScan) needed() bool {
+	return scan.MuteBleeps || scan.SkipAds
+}
+
+// videoScan returns the video scan options chosen in the main window
+func (app *SwearKillerApp) videoScan() videoScan {
+	return videoScan{
+		MuteBleeps:  app.muteBleepsCheck.Checked,
+		BleepAction: app.bleepAction(),
+		SkipAds:     app.skipAds(),
+	}
+}
+
+// skipAds reports whether commercial breaks in the video should be left alone. A video
+// whose commercials were already cut out has none, even if the subtitle's EDL lists them.
+func (app *SwearKillerApp) skipAds() bool {
+	return app.skipAdsCheck.Checked && app.remapMode() != swearkiller.RemapCut
+}
+
+// scanVideo adds existing bleep tones to the segments and removes commercial breaks from them,
+// as chosen. Both read through the video, so call it off the UI thread; ctx stops them.
+func scanVideo(ctx context.Context, videoPath string, segments []swearkiller.Segment, scan videoScan, logFn func(string)) []swearkiller.Segment {
+	if scan.MuteBleeps {
+		toneOpts := swearkiller.DefaultToneOptions
+		toneOpts.MaxDuration = scan.MaxDuration
+		bleeps := findBleepSegments(ctx, videoPath, toneOpts, scan.BleepAction, logFn)
+		segments = swearkiller.MergeSegments(append(segments, bleeps...))
+	}
+	if scan.SkipAds {
+		segments = swearkiller.ExcludeBreaks(segments, findAdBreaks(ctx, videoPath, scan.MaxDuration, logFn))
+	}
+	return segments
+}
+
+// findAdBreaks finds the commercial breaks in a DVR recording from its Comskip EDL or by scanning it
+func findAdBreaks(ctx context.Context, videoPath string, maxDuration float64, logFn func(string)) []swearkiller.Break {
+	opts := swearkiller.DefaultCommercialOptions
+	opts.MaxDuration = maxDuration
+	breaks, err := swearkiller.FindCommercialBreaks(ctx, videoPath, "", opts, logFn)
+	if err != nil {
+		logFn(fmt.Sprintf("Warning: Could not find commercial breaks: %v", err))
+		return nil
+	}
+	logFn(fmt.Sprintf("📺 Found %d commercial break(s) totalling %s; they will be left untouched",
+		len(breaks), swearkiller.FormatTimestamp(swearkiller.BreaksDuration(breaks))))
+	return breaks
+}
+
+// bleepAction returns how detected bleep tones should be censored
+func (app *SwearKillerApp) bleepAction() swearkiller.Action {
+	if app.bleepActionSelect != nil && app.bleepActionSelect.Selected == bleepActionTone {
+		return swearkiller.ActionTone
+	}
+	return swearkiller.ActionMute
+}
+
+// executeFFmpeg runs the generated FFmpeg command, once the overwrite setting (or the
+// user, if it says to ask) has said what to do about an output that already exists
+func (app *SwearKillerApp) executeFFmpeg() {
+	app.chooseOverwrite(app.outputPath, func(policy swearkiller.OverwritePolicy) {
+		output, skip, err := policy.ResolveOutput(app.outputPath)
+		switch {
+		case err != nil:
+			dialog.ShowError(err, app.myWindow)
+			return
+		case skip:
+			app.log("⏭️ Skipped: " + app.outputPath + " already exists")
+			return
+		case output != app.outputPath:
+			app.log(fmt.Sprintf("%s already exists; writing %s instead", filepath.Base(app.outputPath), filepath.Base(output)))
+			app.outputPath = output
+			app.outputLabel.SetText(fmt.Sprintf("Output: %s", filepath.Base(output)))
+		}
+		app.runCommand()
+	})
+}
+
+// chooseOverwrite calls proceed with the policy for output: the one in Settings, or if
+// Settings says to ask and output exists, the user's choice. Nothing is called if the user
+// cancels. With nothing there yet, a queued job keeps whatever turns up by the time it runs.
+func (app *SwearKillerApp) chooseOverwrite(output string, proceed func(policy swearkiller.OverwritePolicy)) {
+	if app.settings.Overwrite != "" {
+		policy, err := swearkiller.ParseOverwritePolicy(app.settings.Overwrite)
+		if err != nil {
+			dialog.ShowError(err, app.myWindow)
+			return
+		}
+		proceed(policy)
+		return
+	}
+	if _, err := os.Stat(output); err != nil {
+		proceed(swearkiller.OverwriteRename)
+		return
+	}
+	message := widget.NewLabel(fmt.Sprintf("%s already exists. Replace it, or keep it and save the new one beside it with a number added?", filepath.Base(output)))
+	message.Wrapping = fyne.TextWrapWord
+	prompt := dialog.NewCustomWithoutButtons("Output Already Exists", message, app.myWindow)
+	choose := func(policy swearkiller.OverwritePolicy) func() {
+		return func() {
+			prompt.Hide()
+			proceed(policy)
+		}
+	}
+	prompt.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Cancel", prompt.Hide),
+		widget.NewButton("Keep Both", choose(swearkiller.OverwriteRename)),
+		widget.NewButtonWithIcon("Replace", theme.WarningIcon(), choose(swearkiller.OverwriteReplace)),
+	})
+	prompt.Resize(fyne.NewSize(480, 0))
+	prompt.Show()
+}
+
+// runCommand runs the generated FFmpeg command, writing app.outputPath
+func (app *SwearKillerApp) runCommand() {
+	// Add safety checks
+	if app.progressBar == nil || app.processBtn == nil || app.executeBtn == nil {
+		app.log("Error: UI components not initialized")
+		return
+	}
+
+	if app.lastCommand == "" {
+		app.log("Error: No FFmpeg command to execute")
+		return
+	}
+
+	app.log("\n=== Executing FFmpeg Command ===")
+	app.log("Starting video processing...")
+
+	app.progressBar.Show()
+
+	// Disable buttons during execution
+	app.processBtn.Disable()
+	app.executeBtn.Disable()
+
+	// Check if input files exist first
+	if _, err := os.Stat(app.videoPath); os.IsNotExist(err) {
+		app.log(fmt.Sprintf("Error: Input video file does not exist: %s", app.videoPath))
+		return
+	}
+
+	// Check if output directory exists and is writable
+	outputDir := filepath.Dir(app.outputPath)
+	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
+		app.log(fmt.Sprintf("Error: Output directory does not exist: %s", outputDir))
+		return
+	}
+
+	// Build FFmpeg command with proper arguments
+	args := swearkiller.BuildFFmpegArgs(app.videoPath, app.outputPath, app.lastSegments,
+		app.encodeOptions(context.Background(), app.videoPath, app.outputPath))
+
+	app.logAt(swearkiller.LevelDebug, fmt.Sprintf("Running: ffmpeg %s", strings.Join(args, " ")))
+
+	// Get video duration for progress calculation
+	duration, err := app.getVideoDuration()
+	if err != nil {
+		app.log(fmt.Sprintf("Warning: Could not get video duration: %v", err))
+		duration = 0 // Fall back to spinner
+	}
+
+	if duration > 0 {
+		app.log(fmt.Sprintf("📹 Video duration: %.1f minutes", duration/60))
+		app.progressBar.Hide()
+		app.realProgressBar.Show()
+		app.progressLabel.Show()
+		app.progressLabel.SetText("Preparing to process video...")
+	} else {
+		app.log("⏳ Processing video... This may take several minutes depending on video length.")
+	}
+
+	segments, videoPath, outputPath := app.lastSegments, app.videoPath, app.outputPath
+	opts := app.encodeOptions(context.Background(), videoPath, outputPath)
+	app.runFFmpegWithProgress(args, duration, func() {
+		app.log("✅ Video processing completed successfully!")
+		app.log(fmt.Sprintf("📁 Clean video saved to: %s", outputPath))
+		app.log("🎉 You can now play your clean video!")
+		verify := app.settings.VerifyOutput
+		check, deleteCorrupt := app.settings.CheckOutput, app.settings.DeleteCorrupt
+		go func() {
+			ctx := context.Background()
+			stashAudio(ctx, videoPath, segments, opts, app.logAsync)
+			var err error
+			if verify && !verifyOutput(ctx, outputPath, segments, app.logAsync) {
+				err = fmt.Errorf("some muted segments aren't silent in the output")
+			}
+			if check && !checkOutput(ctx, videoPath, outputPath, segments, opts, deleteCorrupt, app.logAsync) {
+				err = fmt.Errorf("the output failed its check")
+			}
+			if err == nil {
+				app.refreshMediaServers(outputPath, app.logAsync)
+			}
+			app.notify(swearkiller.JobNotification(videoPath, outputPath, len(segments), err))
+		}()
+	}, func(err error) {
+		app.notify(swearkiller.JobNotification(videoPath, outputPath, len(segments), err))
+	})
+}
+
+// notify shows n as a desktop notification and sends it wherever the notification
+// settings say. It blocks while sending, so call it in the background.
+func (app *SwearKillerApp) notify(n swearkiller.Notification) {
+	if app.settings.DesktopNotify {
+		fyne.Do(func() {
+			fyne.CurrentApp().SendNotification(fyne.NewNotification(n.Title, n.Message))
+		})
+	}
+	if !app.settings.Notify.Enabled() {
+		return
+	}
+	if err := app.settings.Notify.Send(context.Background(), n); err != nil {
+		app.logAsync(fmt.Sprintf("Warning: Could not send the notification: %v", err))
+	}
+}
+
+// refreshMediaServers tells the media servers in Settings about a clean video. It blocks while
+// they answer, so call it in the background.
+func (app *SwearKillerApp) refreshMediaServers(path string, logFn func(string)) {
+	if !app.settings.Refresh.Enabled() {
+		return
+	}
+	if err := app.settings.Refresh.Refresh(context.Background(), path); err != nil {
+		logFn(fmt.Sprintf("Warning: Could not refresh the library: %v", err))
+		return
+	}
+	logFn("📺 Refreshed the media server library")
+}
+
+// stashAudio saves the audio censored out of video to the stash file the options name, if
+// any, so the censoring can be undone later
+func stashAudio(ctx context.Context, video string, segments []swearkiller.Segment, opts swearkiller.EncodeOptions, logFn func(string)) {
+	if opts.Stash == "" {
+		return
+	}
+	logFn("💾 Stashing the censored audio in " + opts.Stash)
+	if err := swearkiller.WriteStash(ctx, video, opts.Stash, segments, opts); err != nil {
+		logFn(fmt.Sprintf("❌ Could not save the stash: %v", err))
+	}
+}
+
+// checkOutput checks the output decodes cleanly and its streams are whole, logging what is
+// wrong and deleting it if deleteCorrupt is set. It reports whether the output passed.
+func checkOutput(ctx context.Context, video, outputPath string, segments []swearkiller.Segment, opts swearkiller.EncodeOptions, deleteCorrupt bool, logFn func(string)) bool {
+	logFn("🔍 Checking the output decodes cleanly...")
+	report, err := swearkiller.CheckIntegrity(ctx, video, outputPath, segments, opts)
+	if err != nil {
+		logFn(fmt.Sprintf("❌ Could not check the output: %v", err))
+		return false
+	}
+	if report.OK() {
+		logFn("✅ Output checked: it decodes cleanly and its streams are whole")
+		return true
+	}
+	for _, problem := range report.Problems {
+		logFn("  " + problem)
+	}
+	if deleteCorrupt {
+		os.Remove(outputPath)
+		logFn("❌ The output failed its check and was deleted")
+	} else {
+		logFn("❌ The output failed its check; it was left in place for a look")
+	}
+	return false
+}
+
+// verifyOutput measures the output during every muted segment and logs any that aren't
+// silent. It reports whether all of them were.
+func verifyOutput(ctx context.Context, outputPath string, segments []swearkiller.Segment, logFn func(string)) bool {
+	logFn("🔍 Verifying muted segments in the output...")
+	results, err := swearkiller.VerifyOutput(ctx, outputPath, segments, swearkiller.EncodeOptions{})
+	if err != nil {
+		logFn(fmt.Sprintf("❌ Could not verify output: %v", err))
+		return false
+	}
+	failed := swearkiller.FailedVerifications(results)
+	for _, result := range failed {
+		logFn("  " + result.String())
+	}
+	if len(failed) > 0 {
+		logFn(fmt.Sprintf("❌ Verification failed: %d of %d muted segment(s) still have audio", len(failed), len(results)))
+		return false
+	}
+	logFn(fmt.Sprintf("✅ Verified: all %d muted segment(s) are silent", len(results)))
+	return true
+}
+
+// runFFmpegWithProgress runs FFmpeg in the background, driving the main progress bar, until
+// it finishes or Cancel is clicked. onSuccess runs on the main thread once FFmpeg finishes
+// without error; onError, if not nil, runs in the background if it fails.
+func (app *SwearKillerApp) runFFmpegWithProgress(args []string, duration float64, onSuccess func(), onError func(error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	app.cancelEncode = cancel
+	app.cancelBtn.Enable()
+	app.cancelBtn.Show()
+	// Run ffmpeg command in a separate goroutine to keep UI responsive
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				app.log(fmt.Sprintf("Panic during FFmpeg execution: %v", r))
+			}
+			cancel()
+			fyne.Do(app.cancelBtn.Hide)
+			if app.progressBar != nil {
+				app.progressBar.Hide()
+			}
+			if app.realProgressBar != nil {
+				app.realProgressBar.Hide()
+			}
+			if app.progressLabel != nil {
+				app.progressLabel.Hide()
+			}
+			app.enableButtons()
+		}()
+
+		err := swearkiller.RunFFmpegToFile(ctx, args, duration, swearkiller.EncodeProgress(duration, barProgress{app}))
+
+		if err != nil && ctx.Err() != nil {
+			fyne.Do(func() {
+				app.log("⏹️ Cancelled; removed the unfinished output")
+			})
+		} else if err != nil {
+			fyne.Do(func() {
+				app.log(fmt.Sprintf("❌ Error executing FFmpeg: %v", err))
+				if output := swearkiller.FFmpegOutput(err); output != "" {
+					app.logAt(swearkiller.LevelDebug, "FFmpeg output:\n"+strings.TrimSpace(output))
+				}
+			})
+			if onError != nil {
+				onError(err)
+			}
+		} else {
+			fyne.Do(func() {
+				if app.realProgressBar != nil {
+					app.realProgressBar.SetValue(1.0) // 1.0 = 100% for Fyne
+				}
+				if app.progressLabel != nil {
+					app.progressLabel.SetText("✅ Processing complete!")
+				}
+				onSuccess()
+			})
+		}
+	}()
+}
+
+// barProgress shows an encode's progress in the progress bar and label under the buttons
+type barProgress struct {
+	app *SwearKillerApp
+}
+
+func (p barProgress) SegmentsFound(count int) {}
+
+func (p barProgress) Encoding(fraction float64, eta time.Duration) {
+	text := fmt.Sprintf("Processing: %.1f%% complete", fraction*100)
+	if eta > 0 {
+		text += fmt.Sprintf(" (about %s left)", eta.Round(time.Second))
+	}
+	// Update UI elements on the main thread
+	fyne.Do(func() {
+		if p.app.realProgressBar != nil {
+			p.app.realProgressBar.SetValue(fraction) // Fyne ProgressBar expects 0.0 to 1.0
+		}
+		if p.app.progressLabel != nil {
+			p.app.progressLabel.SetText(text)
+		}
+	})
+}
+
+// renderPreview encodes only the first few minutes of the clean video so the result can be checked quickly
+func (app *SwearKillerApp) renderPreview() {
+	minutes, err := strconv.ParseFloat(strings.TrimSpace(app.previewEntry.Text), 64)
+	if err != nil || minutes <= 0 {
+		dialog.ShowError(fmt.Errorf("preview length must be a positive number of minutes"), app.myWindow)
+		return
+	}
+	offset, err := app.parseOffset()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("invalid offset value: %v", err), app.myWindow)
+		return
+	}
+
+	app.clearLog()
+	app.log(fmt.Sprintf("🎞️ Rendering a %g minute preview...", minutes))
+
+	det, err := app.detectSegments(context.Background(), app.subtitlePaths(), app.srtLanguage, app.videoPath, app.timing(), offset, app.log)
+	if err != nil {
+		app.log(fmt.Sprintf("Error processing SRT file: %v", err))
+		return
+	}
+	segments := det.Segments
+
+	previewPath := previewOutputPath(app.outputPath)
+	opts := app.encodeOptions(context.Background(), app.videoPath, previewPath)
+	opts.MaxDuration = minutes * 60
+	opts.Stash = "" // Only full encodes are worth undoing
+	if scan := app.videoScan(); scan.needed() {
+		// Only the preview range needs scanning, which keeps this quick
+		scan.MaxDuration = opts.MaxDuration
+		segments = scanVideo(context.Background(), app.videoPath, segments, scan, app.log)
+	}
+	args := swearkiller.BuildFFmpegArgs(app.videoPath, previewPath, segments, opts)
+	app.logAt(swearkiller.LevelDebug, fmt.Sprintf("Running: ffmpeg %s", strings.Join(args, " ")))
+
+	// Progress runs up to the preview length, or the whole video if it is shorter
+	progressDuration := opts.MaxDuration
+	if duration, err := app.getVideoDuration(); err == nil && duration > 0 && duration < progressDuration {
+		progressDuration = duration
+	}
+
+	app.processBtn.Disable()
+	app.executeBtn.Disable()
+	app.previewBtn.Disable()
+	app.realProgressBar.SetValue(0)
+	app.realProgressBar.Show()
+	app.progressLabel.Show()
+	app.progressLabel.SetText("Preparing preview...")
+
+	app.runFFmpegWithProgress(args, progressDuration, func() {
+		app.log(fmt.Sprintf("✅ Preview saved to: %s", previewPath))
+		app.log("▶️ Play it to check the mutes line up, then run the full encode.")
+	}, nil)
+}
+
+// cancelRunningEncode stops the encode running on the Clean Video tab
+func (app *SwearKillerApp) cancelRunningEncode() {
+	if app.cancelEncode != nil {
+		app.cancelBtn.Disable()
+		app.cancelEncode()
+	}
+}
+
+// previewOutputPath returns the output path with a "-PREVIEW" suffix before the extension
+func previewOutputPath(outputPath string) string {
+	ext := filepath.Ext(outputPath)
+	return strings.TrimSuffix(outputPath, ext) + "-PREVIEW" + ext
+}
+
+// enableButtons re-enables the buttons after execution
+func (app *SwearKillerApp) enableButtons() {
+	app.updateProcessButton()
+}
+
+// getVideoDuration gets the total duration of the video in seconds
+func (app *SwearKillerApp) getVideoDuration() (float64, error) {
+	return swearkiller.ProbeDuration(context.Background(), app.videoPath)
+}
+
+// addCurrentToQueue queues the currently selected video and subtitle
+func (app *SwearKillerApp) addCurrentToQueue() {
+	offset, err := app.parseOffset()
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("invalid offset value: %v", err), app.myWindow)
+		return
+	}
+
+	app.rememberOffset(offset)
+
+	// Catch a mismatched subtitle now rather than when the queue runs unattended
+	det, err := app.detectSegments(context.Background(), app.subtitlePaths(), app.srtLanguage, app.videoPath, app.timing(), offset, func(string) {})
+	if err != nil {
+		dialog.ShowError(err, app.myWindow)
+		return
+	}
+	if det.Quality.Passed() {
+		app.enqueue(offset, false)
+		return
+	}
+	dialog.ShowConfirm("Subtitle May Not Match",
+		"The quality check found problems:\n\n"+det.Quality.String()+"\n\nQueue it anyway?",
+		func(force bool) {
+			if force {
+				app.enqueue(offset, true)
+			}
+		}, app.myWindow)
+}
+
+// enqueue adds the current video and subtitle to the queue, once chooseOverwrite has said
+// what to do if its output exists. force skips the quality check when the job runs.
+func (app *SwearKillerApp) enqueue(offset float64, force bool) {
+	app.chooseOverwrite(app.outputPath, func(policy swearkiller.OverwritePolicy) {
+		app.addJob(offset, force, policy)
+	})
+}
+
+// addJob adds the current video and subtitle to the queue
+func (app *SwearKillerApp) addJob(offset float64, force bool, policy swearkiller.OverwritePolicy) {
+	job := &Job{
+		VideoPath:   app.videoPath,
+		SRTPath:     app.srtPath,
+		SRTLang:     app.srtLanguage,
+		ExtraSRTs:   slices.Clone(app.extraSRTs),
+		OutputPath:  app.outputPath,
+		Offset:      offset,
+		MuteBleeps:  app.muteBleepsCheck.Checked,
+		BleepAction: app.bleepAction(),
+		SkipAds:     app.skipAds(),
+		Timing:      app.timing(),
+		Force:       force,
+		Overwrite:   policy,
+		Status:      JobPending,
+	}
+
+	app.queueMu.Lock()
+	app.jobs = append(app.jobs, job)
+	count := len(app.jobs)
+	app.queueMu.Unlock()
+
+	app.queueList.Refresh()
+	app.log(fmt.Sprintf("➕ Added to queue (%d job(s)): %s", count, filepath.Base(job.VideoPath)))
+}
+
+// moveSelectedJob moves the selected job up (-1) or down (+1) in the queue
+func (app *SwearKillerApp) moveSelectedJob(delta int) {
+	app.queueMu.Lock()
+	from := app.selectedJob
+	to := from + delta
+	if from < 0 || from >= len(app.jobs) || to < 0 || to >= len(app.jobs) {
+		app.queueMu.Unlock()
+		return
+	}
+	app.jobs[from], app.jobs[to] = app.jobs[to], app.jobs[from]
+	app.queueMu.Unlock()
+
+	app.queueList.Refresh()
+	app.queueList.Select(to)
+}
+
+// removeSelectedJob removes the selected job unless it is currently running
+func (app *SwearKillerApp) removeSelectedJob() {
+	app.queueMu.Lock()
+	idx := app.selectedJob
+	if idx < 0 || idx >= len(app.jobs) || app.jobs[idx].Status == JobRunning {
+		app.queueMu.Unlock()
+		return
+	}
+	app.jobs = append(app.jobs[:idx], app.jobs[idx+1:]...)
+	app.selectedJob = -1
+	app.queueMu.Unlock()
+
+	app.queueList.UnselectAll()
+	app.queueList.Refresh()
+	app.jobLogText.SetText("")
+}
+
+// clearFinishedJobs removes completed and failed jobs from the queue
+func (app *SwearKillerApp) clearFinishedJobs() {
+	app.queueMu.Lock()
+	var remaining []*Job
+	for _, job := range app.jobs {
+		if job.Status == JobPending || job.Status == JobRunning {
+			remaining = append(remaining, job)
+		}
+	}
+	app.jobs = remaining
+	app.selectedJob = -1
+	app.queueMu.Unlock()
+
+	app.queueList.UnselectAll()
+	app.queueList.Refresh()
+	app.jobLogText.SetText("")
+}
+
+// jobLog appends a message to a job's log and refreshes the queue view
+func (app *SwearKillerApp) jobLog(job *Job, message string) {
+	app.queueMu.Lock()
+	job.Log = append(job.Log, message)
+	app.queueMu.Unlock()
+	app.logger.Log(swearkiller.MessageLevel(message, swearkiller.LevelInfo), message+" ("+filepath.Base(job.VideoPath)+")")
+	fyne.Do(app.refreshQueueView)
+}
+
+// setJobStatus updates a job's status and refreshes the queue view. Failures are recorded
+// with the job's last log message, for the library tab.
+func (app *SwearKillerApp) setJobStatus(job *Job, status JobStatus) {
+	app.queueMu.Lock()
+	job.Status = status
+	reason := ""
+	if len(job.Log) > 0 {
+		reason = job.Log[len(job.Log)-1]
+	}
+	app.queueMu.Unlock()
+	if status == JobFailed && app.processed != nil {
+		if err := app.processed.RecordFailure(job.VideoPath, reason); err != nil {
+			app.jobLog(job, fmt.Sprintf("Warning: Could not record the failure: %v", err))
+		}
+	}
+	fyne.Do(app.refreshQueueView)
+}
+
+// refreshQueueView redraws the job list and the selected job's log (main thread only)
+func (app *SwearKillerApp) refreshQueueView() {
+	app.queueList.Refresh()
+
+	app.queueMu.Lock()
+	text := ""
+	if app.selectedJob >= 0 && app.selectedJob < len(app.jobs) {
+		text = strings.Join(app.jobs[app.selectedJob].Log, "\n")
+	}
+	app.queueMu.Unlock()
+
+	if app.jobLogText.Text != text {
+		app.jobLogText.SetText(text)
+		app.jobLogText.CursorRow = len(strings.Split(text, "\n"))
+	}
+}
+
+// newPauseButton creates a button that pauses and resumes every running encode, so a long
+// batch can give the computer back for a while
+func (app *SwearKillerApp) newPauseButton() *widget.Button {
+	btn := widget.NewButton(pauseLabel(), app.togglePause)
+	app.pauseBtns = append(app.pauseBtns, btn)
+	return btn
+}
+
+// pauseLabel names what the pause buttons do next
+func pauseLabel() string {
+	if swearkiller.EncodesPaused() {
+		return "Resume Encoding"
+	}
+	return "Pause Encoding"
+}
+
+// togglePause pauses or resumes every running encode, and any started meanwhile
+func (app *SwearKillerApp) togglePause() {
+	if swearkiller.EncodesPaused() {
+		if err := swearkiller.ResumeEncodes(); err != nil {
+			app.log(fmt.Sprintf("Warning: Could not resume every encode: %v", err))
+		}
+		app.log("▶️ Encoding resumed")
+	} else {
+		if err := swearkiller.PauseEncodes(); err != nil {
+			app.log(fmt.Sprintf("Warning: Could not pause every encode: %v", err))
+		}
+		app.log("⏸️ Encoding paused; encodes started meanwhile wait too. Click Resume Encoding to carry on.")
+	}
+	for _, btn := range app.pauseBtns {
+		btn.SetText(pauseLabel())
+	}
+}
+
+// startQueue runs all pending jobs, using up to the configured number of parallel workers
+func (app *SwearKillerApp) startQueue() {
+	workers, err := strconv.Atoi(strings.TrimSpace(app.parallelEntry.Text))
+	if err != nil || workers < 1 {
+		dialog.ShowError(fmt.Errorf("parallel jobs must be a whole number of at least 1"), app.myWindow)
+		return
+	}
+
+	app.queueMu.Lock()
+	if app.queueRunning {
+		app.queueMu.Unlock()
+		return
+	}
+	app.queueRunning = true
+	ctx, cancel := context.WithCancel(context.Background())
+	app.stopQueue = cancel
+	app.queueMu.Unlock()
+
+	app.loadProcessed()
+
+	app.startQueueBtn.Disable()
+	app.stopQueueBtn.Enable()
+	app.log(fmt.Sprintf("▶️ Starting job queue with %d parallel job(s)", workers))
+
+	go func() {
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for ctx.Err() == nil {
+					job := app.nextPendingJob()
+					if job == nil {
+						return
+					}
+					app.runJob(ctx, job)
+				}
+			}()
+		}
+		wg.Wait()
+		stopped := ctx.Err() != nil
+		cancel()
+
+		app.queueMu.Lock()
+		app.queueRunning = false
+		app.stopQueue = nil
+		done, failed, skipped := 0, 0, 0
+		for _, job := range app.jobs {
+			switch job.Status {
+			case JobDone:
+				done++
+			case JobFailed:
+				failed++
+			case JobSkipped:
+				skipped++
+			}
+		}
+		app.queueMu.Unlock()
+
+		fyne.Do(func() {
+			app.startQueueBtn.Enable()
+			app.stopQueueBtn.Disable()
+			if stopped {
+				app.log(fmt.Sprintf("⏹️ Job queue stopped: %d done, %d failed, %d skipped; the rest are still queued", done, failed, skipped))
+			} else {
+				app.log(fmt.Sprintf("🏁 Job queue finished: %d done, %d failed, %d skipped", done, failed, skipped))
+			}
+			app.refreshLibrary()
+		})
+		if !stopped {
+			app.notify(swearkiller.BatchNotification(done, failed, skipped))
+		}
+	}()
+}
+
+// stopRunningQueue stops the jobs under way, removing their unfinished outputs, and puts
+// them back in the queue; jobs that haven't started stay queued
+func (app *SwearKillerApp) stopRunningQueue() {
+	app.queueMu.Lock()
+	stop := app.stopQueue
+	app.queueMu.Unlock()
+	if stop != nil {
+		app.stopQueueBtn.Disable()
+		stop()
+	}
+}
+
+// nextPendingJob claims the first pending job in queue order, or returns nil when none are left
+func (app *SwearKillerApp) nextPendingJob() *Job {
+	app.queueMu.Lock()
+	defer app.queueMu.Unlock()
+	for _, job := range app.jobs {
+		if job.Status == JobPending {
+			job.Status = JobRunning
+			job.Progress = 0
+			return job
+		}
+	}
+	return nil
+}
+
+// runJob detects swears for a single job and runs FFmpeg, recording progress and logs on the
+// job. If ctx is stopped the job is put back in the queue.
+func (app *SwearKillerApp) runJob(ctx context.Context, job *Job) {
+	fyne.Do(app.refreshQueueView)
+
+	// A failure in one job must never take down the rest of the queue
+	defer func() {
+		if r := recover(); r != nil {
+			app.jobLog(job, fmt.Sprintf("Panic during job: %v", r))
+			app.setJobStatus(job, JobFailed)
+		}
+	}()
+
+	logFn := func(message string) { app.jobLog(job, message) }
+
+	logFn(fmt.Sprintf("Processing SRT: %s", job.SRTPath))
+	logFn(fmt.Sprintf("Input video: %s", job.VideoPath))
+	logFn(fmt.Sprintf("Output video: %s", job.OutputPath))
+	logFn(fmt.Sprintf("Using offset: %.1f seconds", job.Offset))
+
+	if warning := app.cleanedWarning(job.VideoPath); warning != "" {
+		if app.settings.SkipCleaned && !job.Reprocess {
+			logFn("⏭️ Skipped: " + warning)
+			logFn("Select the job and click Process Again to clean it anyway")
+			app.setJobStatus(job, JobSkipped)
+			return
+		}
+		logFn("Warning: " + warning)
+	}
+
+	videoHash, fingerprint, err := app.jobFingerprint(job)
+	if err != nil {
+		logFn(fmt.Sprintf("Warning: Could not fingerprint the job, so it can't be skipped next time: %v", err))
+	} else if record, done := app.processed.AlreadyProcessed(job.VideoPath, videoHash, fingerprint); done && !job.Reprocess {
+		logFn(fmt.Sprintf("⏭️ Already processed on %s with the same video, subtitle and settings: %s",
+			record.ProcessedAt.Format("2006-01-02 15:04"), record.OutputPath))
+		logFn("Select the job and click Process Again to redo it")
+		app.setJobStatus(job, JobSkipped)
+		return
+	}
+
+	output, skip, err := job.Overwrite.ResolveOutput(job.OutputPath)
+	switch {
+	case err != nil:
+		logFn("❌ " + err.Error())
+		app.setJobStatus(job, JobFailed)
+		return
+	case skip:
+		logFn("⏭️ Skipped: " + job.OutputPath + " already exists")
+		app.setJobStatus(job, JobSkipped)
+		return
+	case output != job.OutputPath:
+		logFn(fmt.Sprintf("%s already exists; writing %s instead", filepath.Base(job.OutputPath), filepath.Base(output)))
+		app.queueMu.Lock()
+		job.OutputPath = output
+		app.queueMu.Unlock()
+	}
+
+	hookJob := swearkiller.HookJob{Video: job.VideoPath, Subtitle: job.SRTPath, Output: job.OutputPath}
+	if err := app.settings.Hooks.RunBefore(ctx, hookJob, logFn); err != nil {
+		if ctx.Err() != nil {
+			app.requeueStoppedJob(job, logFn)
+			return
+		}
+		logFn("❌ " + err.Error())
+		app.setJobStatus(job, JobFailed)
+		return
+	}
+	segments := 0
+	defer func() {
+		if ctx.Err() != nil {
+			app.requeueStoppedJob(job, logFn)
+			return // Stopped, so there is nothing for the after hook to do
+		}
+		app.runAfterHook(job, hookJob, segments, logFn)
+		app.queueMu.Lock()
+		done := job.Status == JobDone
+		app.queueMu.Unlock()
+		if done {
+			app.refreshMediaServers(job.OutputPath, logFn)
+		}
+	}()
+
+	det, err := app.detectSegments(ctx, append([]string{job.SRTPath}, job.ExtraSRTs...), job.SRTLang, job.VideoPath, job.Timing, job.Offset, logFn)
+	if err != nil {
+		logFn(fmt.Sprintf("Error processing SRT file: %v", err))
+		app.setJobStatus(job, JobFailed)
+		return
+	}
+	if !det.Quality.Passed() && !job.Force {
+		logFn("❌ Stopped by the quality check; queue the job again and choose to continue if the subtitle is right")
+		app.setJobStatus(job, JobFailed)
+		return
+	}
+	// Queued jobs run unattended, so uncertain matches are only listed
+	for _, match := range det.Review {
+		logFn("  Not muted (needs review): " + match.String())
+	}
+	mergedSegments := det.Segments
+	mergedSegments = scanVideo(ctx, job.VideoPath, mergedSegments, videoScan{
+		MuteBleeps:  job.MuteBleeps,
+		BleepAction: job.BleepAction,
+		SkipAds:     job.SkipAds,
+	}, logFn)
+	segments = len(mergedSegments)
+	if ctx.Err() != nil {
+		return
+	}
+
+	duration, err := swearkiller.ProbeDuration(ctx, job.VideoPath)
+	if err != nil {
+		logFn(fmt.Sprintf("Warning: Could not get video duration: %v", err))
+		duration = 0
+	}
+
+	opts := app.encodeOptions(ctx, job.VideoPath, job.OutputPath)
+	args := swearkiller.BuildFFmpegArgs(job.VideoPath, job.OutputPath, mergedSegments, opts)
+	logFn(fmt.Sprintf("Running: ffmpeg %s", strings.Join(args, " ")))
+
+	err = swearkiller.RunFFmpegToFile(ctx, args, duration, swearkiller.EncodeProgress(duration, jobProgress{app, job}))
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		logFn(fmt.Sprintf("❌ Error executing FFmpeg: %v", err))
+		if output := swearkiller.FFmpegOutput(err); output != "" {
+			logFn("FFmpeg output:\n" + strings.TrimSpace(output))
+		}
+		app.setJobStatus(job, JobFailed)
+		return
+	}
+
+	app.queueMu.Lock()
+	job.Progress = 1.0
+	app.queueMu.Unlock()
+	logFn(fmt.Sprintf("✅ Clean video saved to: %s", job.OutputPath))
+	stashAudio(ctx, job.VideoPath, mergedSegments, opts, logFn)
+	if app.settings.VerifyOutput && !verifyOutput(ctx, job.OutputPath, mergedSegments, logFn) {
+		if ctx.Err() == nil {
+			app.setJobStatus(job, JobFailed)
+		}
+		return
+	}
+	if app.settings.CheckOutput && !checkOutput(ctx, job.VideoPath, job.OutputPath, mergedSegments, opts, app.settings.DeleteCorrupt, logFn) {
+		if ctx.Err() == nil {
+			app.setJobStatus(job, JobFailed)
+		}
+		return
+	}
+	if fingerprint != "" {
+		record := swearkiller.ProcessedRecord{
+			VideoHash: videoHash, Fingerprint: fingerprint, OutputPath: job.OutputPath, ProcessedAt: time.Now(),
+			Subtitle: job.SRTPath, Settings: app.jobSettings(job), Segments: len(mergedSegments),
+			MutedSeconds: swearkiller.SegmentsDuration(mergedSegments), Duration: duration,
+		}
+		if err := app.processed.Record(job.VideoPath, record); err != nil {
+			logFn(fmt.Sprintf("Warning: Could not record the job as processed: %v", err))
+		}
+		fyne.Do(app.refreshHistory)
+	}
+	app.setJobStatus(job, JobDone)
+}
+
+// jobProgress shows a queued job's encode progress in the queue list
+type jobProgress struct {
+	app *SwearKillerApp
+	job *Job
+}
+
+func (p jobProgress) SegmentsFound(count int) {}
+
+func (p jobProgress) Encoding(fraction float64, eta time.Duration) {
+	p.app.queueMu.Lock()
+	p.job.Progress, p.job.ETA = fraction, eta
+	p.app.queueMu.Unlock()
+	fyne.Do(p.app.queueList.Refresh)
+}
+
+// requeueStoppedJob puts a job stopped with the queue back in it, to run from the start when
+// the queue is started again
+func (app *SwearKillerApp) requeueStoppedJob(job *Job, logFn func(string)) {
+	logFn("⏹️ Stopped; removed the unfinished output, and the job is back in the queue")
+	app.queueMu.Lock()
+	job.Progress = 0
+	app.queueMu.Unlock()
+	app.setJobStatus(job, JobPending)
+}
+
+// runAfterHook runs the after hook in Settings once a queued job has finished, marking the
+// job failed if the hook fails
+func (app *SwearKillerApp) runAfterHook(job *Job, hookJob swearkiller.HookJob, segments int, logFn func(string)) {
+	if app.settings.Hooks.After == "" {
+		return
+	}
+	app.queueMu.Lock()
+	status, reason := job.Status, "the job failed"
+	if len(job.Log) > 0 {
+		reason = job.Log[len(job.Log)-1]
+	}
+	app.queueMu.Unlock()
+	hookJob.Segments = segments
+	if status != JobDone {
+		hookJob.Err = fmt.Errorf("%s", reason)
+	}
+	if err := app.settings.Hooks.RunAfter(context.Background(), hookJob, logFn); err != nil {
+		logFn("❌ " + err.Error())
+		if status == JobDone {
+			app.setJobStatus(job, JobFailed)
+		}
+	}
+}
+
+// jobFingerprint returns the video's content hash and a fingerprint of the subtitle and
+// every setting that affects the result, so changing any of them means processing again
+func (app *SwearKillerApp) jobFingerprint(job *Job) (videoHash, fingerprint string, err error) {
+	videoHash, err = swearkiller.QuickHash(job.VideoPath)
+	if err != nil {
+		return "", "", err
+	}
+	subtitleHash, err := swearkiller.QuickHash(job.SRTPath)
+	if err != nil {
+		return "", "", err
+	}
+	var extraHashes []string
+	for _, path := range job.ExtraSRTs {
+		hash, err := swearkiller.QuickHash(path)
+		if err != nil {
+			return "", "", err
+		}
+		extraHashes = append(extraHashes, hash)
+	}
+	remapHash := ""
+	if job.Timing.RemapFile != "" {
+		if remapHash, err = swearkiller.QuickHash(job.Timing.RemapFile); err != nil {
+			return "", "", err
+		}
+	}
+	fingerprint, err = swearkiller.Fingerprint(struct {
+		Subtitle       string
+		ExtraSubtitles []string `json:",omitempty"`
+		SubtitleLang   string
+		Swears         []string
+		MatchOptions   swearkiller.MatchOptions
+		MinConfidence  float64
+		Profile        string `json:",omitempty"` // Left out when unset so earlier records still match
+		AutoLanguage   *bool
+		ExtraLanguages []string
+		Offset         float64
+		MuteBleeps     bool
+		BleepAction    swearkiller.Action
+		SkipAds        bool
+		EDLRemap       swearkiller.RemapMode
+		RemapTable     string
+		OutputPath     string
+	}{
+		subtitleHash, extraHashes, job.SRTLang, app.swears, app.matchOptions(nil), app.minConfidence(), app.settings.Profile,
+		app.settings.AutoLanguage, app.settings.ExtraLanguages, job.Offset,
+		job.MuteBleeps, job.BleepAction, job.SkipAds, job.Timing.EDLRemap, remapHash, job.OutputPath,
+	})
+	return videoHash, fingerprint, err
+}
+
+// jobSettings describes the options a job ran with, for the history export
+func (app *SwearKillerApp) jobSettings(job *Job) map[string]string {
+	opts := app.matchOptions(nil)
+	autoLanguage := app.settings.AutoLanguage == nil || *app.settings.AutoLanguage
+	settings := map[string]string{
+		"offset":          strconv.FormatFloat(job.Offset, 'f', -1, 64),
+		"swear_words":     strconv.Itoa(len(app.swears)),
+		"min_confidence":  strconv.FormatFloat(app.minConfidence(), 'f', -1, 64),
+		"profile":         app.settings.Profile,
+		"deobfuscate":     strconv.FormatBool(opts.Deobfuscate),
+		"whole_words":     strconv.FormatBool(opts.WholeWords),
+		"fuzzy":           strconv.FormatBool(opts.Fuzzy),
+		"phrase_gap":      strconv.FormatFloat(opts.PhraseGap, 'f', -1, 64),
+		"auto_language":   strconv.FormatBool(autoLanguage),
+		"extra_languages": strings.Join(app.settings.ExtraLanguages, ","),
+		"mute_bleeps":     strconv.FormatBool(job.MuteBleeps),
+		"skip_ads":        strconv.FormatBool(job.SkipAds),
+		"edl_remap":       string(job.Timing.EDLRemap),
+		"remap_table":     job.Timing.RemapFile,
+	}
+	if job.MuteBleeps {
+		settings["bleep_action"] = string(job.BleepAction)
+	}
+	if len(job.ExtraSRTs) > 0 {
+		settings["extra_subtitles"] = strings.Join(job.ExtraSRTs, ",")
+	}
+	return settings
+}
+
+// reprocessSelectedJob queues the selected finished job to run again, even if it was
+// already processed with the same settings
+func (app *SwearKillerApp) reprocessSelectedJob() {
+	app.queueMu.Lock()
+	idx := app.selectedJob
+	if idx < 0 || idx >= len(app.jobs) || app.jobs[idx].Status == JobRunning {
+		app.queueMu.Unlock()
+		return
+	}
+	job := app.jobs[idx]
+	job.Reprocess = true
+	job.Overwrite = swearkiller.OverwriteReplace // Redoing the job redoes its output too
+	job.Status = JobPending
+	job.Progress = 0
+	job.Log = nil
+	app.queueMu.Unlock()
+
+	app.refreshQueueView()
+}
+
+// loadProcessed reads the record of processed videos, if it hasn't been read yet
+func (app *SwearKillerApp) loadProcessed() {
+	if app.processed != nil {
+		return
+	}
+	state, err := swearkiller.LoadProcessedState(getStatePath())
+	if err != nil {
+		app.log(fmt.Sprintf("Warning: Could not load the record of processed videos: %v", err))
+	}
+	app.processed = state
+}
+
+// refreshHistory lists the processed titles matching the history search
+func (app *SwearKillerApp) refreshHistory() {
+	if app.historyList == nil {
+		return
+	}
+	app.historyEntries = app.processed.History(app.historySearch.Text)
+	app.historyList.UnselectAll()
+	app.historyList.Refresh()
+}
+
+// ratingOptions are the choices in the history tab's rating menu, indexed by rating
+var ratingOptions = func() []string {
+	options := make([]string, swearkiller.MaxRating+1)
+	for rating := range options {
+		options[rating] = swearkiller.RatingStars(rating)
+	}
+	return options
+}()
+
+// buildHistoryPanel creates the history tab, where processed titles can be searched and
+// given notes and a rating
+func (app *SwearKillerApp) buildHistoryPanel() fyne.CanvasObject {
+	app.loadProcessed()
+
+	details := widget.NewLabel("Select a title to see and edit its notes")
+	details.Wrapping = fyne.TextWrapWord
+	notes := widget.NewMultiLineEntry()
+	notes.SetPlaceHolder("Notes, like \"great clean edit\" or \"still too many gaps\"")
+	notes.Wrapping = fyne.TextWrapWord
+	notes.SetMinRowsVisible(3)
+	rating := widget.NewSelect(ratingOptions, nil)
+	saveBtn := widget.NewButton("Save Notes", nil)
+	notes.Disable()
+	rating.Disable()
+	saveBtn.Disable()
+
+	app.historySearch = widget.NewEntry()
+	app.historySearch.SetPlaceHolder("Search titles and notes")
+	app.historySearch.OnChanged = func(string) { app.refreshHistory() }
+
+	app.historyList = widget.NewList(
+		func() int { return len(app.historyEntries) },
+		func() fyne.CanvasObject { return widget.NewLabel("Title") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			entry := app.historyEntries[id]
+			text := fmt.Sprintf("%s  %s  (%s)", swearkiller.RatingStars(entry.Rating), entry.Title(), entry.ProcessedAt.Local().Format("2006-01-02"))
+			if entry.Notes != "" {
+				text += " - " + strings.SplitN(entry.Notes, "\n", 2)[0]
+			}
+			item.(*widget.Label).SetText(text)
+		},
+	)
+	selected := -1
+	app.historyList.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		entry := app.historyEntries[id]
+		details.SetText(fmt.Sprintf("%s\nProcessed %s\nOutput: %s", entry.Video, entry.ProcessedAt.Local().Format("2006-01-02 15:04"), entry.OutputPath))
+		notes.SetText(entry.Notes)
+		rating.SetSelectedIndex(min(max(entry.Rating, 0), swearkiller.MaxRating))
+		notes.Enable()
+		rating.Enable()
+		saveBtn.Enable()
+	}
+	app.historyList.OnUnselected = func(widget.ListItemID) {
+		selected = -1
+		details.SetText("Select a title to see and edit its notes")
+		notes.SetText("")
+		rating.ClearSelected()
+		notes.Disable()
+		rating.Disable()
+		saveBtn.Disable()
+	}
+	saveBtn.OnTapped = func() {
+		if selected < 0 {
+			return
+		}
+		video := app.historyEntries[selected].Video
+		if err := app.processed.Annotate(video, notes.Text, max(rating.SelectedIndex(), 0)); err != nil {
+			dialog.ShowError(err, app.myWindow)
+			return
+		}
+		app.refreshHistory()
+		for i, entry := range app.historyEntries {
+			if entry.Video == video {
+				app.historyList.Select(i)
+			}
+		}
+	}
+	app.refreshHistory()
+
+	editor := container.NewVBox(
+		widget.NewSeparator(),
+		details,
+		container.NewHBox(widget.NewLabel("Rating:"), rating),
+		notes,
+		saveBtn,
+	)
+	return container.NewBorder(
+		container.NewBorder(nil, nil, widget.NewLabel("Search:"), nil, app.historySearch),
+		editor, nil, nil,
+		app.historyList,
+	)
+}
+
+// libraryBadges are the status badges of the library tab
+var libraryBadges = map[swearkiller.LibraryStatus]string{
+	swearkiller.LibraryClean:   "✅",
+	swearkiller.LibraryReview:  "📝",
+	swearkiller.LibraryFailed:  "❌",
+	swearkiller.LibraryPending: "⬜",
+}
+
+// libraryAll is the library filter choice that shows every status
+const libraryAll = "All"
+
+// refreshLibrary scans the library folders in the background and lists the videos matching
+// the filter and search
+func (app *SwearKillerApp) refreshLibrary() {
+	if app.libraryList == nil {
+		return
+	}
+	roots := slices.Clone(app.settings.LibraryRoots)
+	filter, query := app.libraryFilter.Selected, strings.ToLower(app.librarySearch.Text)
+	app.librarySummary.SetText("Scanning...")
 	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				app.log(fmt.Sprintf("Panic during FFmpeg execution: %v", r))
+		items, err := swearkiller.ScanLibrary(roots, app.processed)
+		var shown []swearkiller.LibraryItem
+		for _, item := range items {
+			if filter != libraryAll && item.Status.Label() != filter {
+				continue
 			}
-			if app.progressBar != nil {
-				app.progressBar.Hide()
+			if query != "" && !strings.Contains(strings.ToLower(item.Video), query) {
+				continue
 			}
-			if app.realProgressBar != nil {
-				app.realProgressBar.Hide()
+			shown = append(shown, item)
+		}
+		counts := swearkiller.CountLibrary(items)
+		var parts []string
+		for _, status := range swearkiller.LibraryStatuses {
+			parts = append(parts, fmt.Sprintf("%s %d %s", libraryBadges[status], counts[status], strings.ToLower(status.Label())))
+		}
+		fyne.Do(func() {
+			if err != nil {
+				app.librarySummary.SetText(fmt.Sprintf("❌ %v", err))
+				return
 			}
-			if app.progressLabel != nil {
-				app.progressLabel.Hide()
+			app.libraryItems = shown
+			app.librarySummary.SetText(strings.Join(parts, "   "))
+			app.libraryList.UnselectAll()
+			app.libraryList.Refresh()
+		})
+	}()
+}
+
+// updateLibraryRoots shows the library folders and lists their videos again
+func (app *SwearKillerApp) updateLibraryRoots() {
+	if len(app.settings.LibraryRoots) == 0 {
+		app.libraryRoots.SetText("No folders; only videos processed before are listed")
+	} else {
+		app.libraryRoots.SetText(strings.Join(app.settings.LibraryRoots, ", "))
+	}
+	app.refreshLibrary()
+}
+
+// libraryDetails describes a library item for the details pane
+func libraryDetails(item swearkiller.LibraryItem) string {
+	lines := []string{item.Video, libraryBadges[item.Status] + " " + item.Status.Label()}
+	switch item.Status {
+	case swearkiller.LibraryFailed:
+		lines = append(lines, fmt.Sprintf("Failed %s: %s", item.Failure.FailedAt.Local().Format("2006-01-02 15:04"), item.Failure.Reason))
+	case swearkiller.LibraryReview:
+		lines = append(lines, "Plan: "+item.Plan)
+	}
+	if !item.Record.ProcessedAt.IsZero() {
+		lines = append(lines, fmt.Sprintf("Processed %s: %s", item.Record.ProcessedAt.Local().Format("2006-01-02 15:04"), item.Record.OutputPath))
+		if item.Record.Segments > 0 {
+			lines = append(lines, fmt.Sprintf("%d segment(s), %.1fs muted", item.Record.Segments, item.Record.MutedSeconds))
+		}
+		lines = append(lines, "Rating: "+swearkiller.RatingStars(item.Record.Rating))
+		if item.Record.Notes != "" {
+			lines = append(lines, "Notes: "+item.Record.Notes)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// buildLibraryPanel creates the library tab, a read-only view of every video in the library
+// folders and the processed record, with where each one stands
+func (app *SwearKillerApp) buildLibraryPanel() fyne.CanvasObject {
+	app.loadProcessed()
+
+	details := widget.NewLabel("Select a video to see its details")
+	details.Wrapping = fyne.TextWrapWord
+	app.librarySummary = widget.NewLabel("")
+	app.libraryRoots = widget.NewLabel("")
+	app.libraryRoots.Wrapping = fyne.TextWrapWord
+
+	filters := []string{libraryAll}
+	for _, status := range swearkiller.LibraryStatuses {
+		filters = append(filters, status.Label())
+	}
+	app.libraryFilter = widget.NewSelect(filters, func(string) { app.refreshLibrary() })
+	app.libraryFilter.Selected = libraryAll
+	app.librarySearch = widget.NewEntry()
+	app.librarySearch.SetPlaceHolder("Search paths")
+	app.librarySearch.OnChanged = func(string) { app.refreshLibrary() }
+
+	app.libraryList = widget.NewList(
+		func() int { return len(app.libraryItems) },
+		func() fyne.CanvasObject { return widget.NewLabel("Title") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			entry := app.libraryItems[id]
+			item.(*widget.Label).SetText(fmt.Sprintf("%s %s  (%s)", libraryBadges[entry.Status], entry.Title(), entry.Status.Label()))
+		},
+	)
+	app.libraryList.OnSelected = func(id widget.ListItemID) {
+		details.SetText(libraryDetails(app.libraryItems[id]))
+	}
+	app.libraryList.OnUnselected = func(widget.ListItemID) {
+		details.SetText("Select a video to see its details")
+	}
+
+	addBtn := widget.NewButton("Add Folder...", func() {
+		folderDialog := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err != nil || dir == nil || slices.Contains(app.settings.LibraryRoots, dir.Path()) {
+				return
 			}
-			app.enableButtons()
-		}()
+			app.settings.LibraryRoots = append(app.settings.LibraryRoots, dir.Path())
+			app.persistSettings()
+			app.updateLibraryRoots()
+		}, app.myWindow)
+		if location := listableDir(app.settings.LastVideoDir); location != nil {
+			folderDialog.SetLocation(location)
+		}
+		folderDialog.Show()
+	})
+	clearBtn := widget.NewButton("Clear Folders", func() {
+		app.settings.LibraryRoots = nil
+		app.persistSettings()
+		app.updateLibraryRoots()
+	})
+	refreshBtn := widget.NewButton("Refresh", app.refreshLibrary)
+	app.updateLibraryRoots()
+
+	header := container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Folders:"), container.NewHBox(addBtn, clearBtn, refreshBtn), app.libraryRoots),
+		container.NewBorder(nil, nil, widget.NewLabel("Show:"), nil, container.NewBorder(nil, nil, app.libraryFilter, nil, app.librarySearch)),
+		app.librarySummary,
+	)
+	return container.NewBorder(
+		header,
+		container.NewVBox(widget.NewSeparator(), details), nil, nil,
+		app.libraryList,
+	)
+}
+
+// buildQueuePanel creates the job queue tab
+func (app *SwearKillerApp) buildQueuePanel() fyne.CanvasObject {
+	app.selectedJob = -1
+
+	app.queueList = widget.NewList(
+		func() int {
+			app.queueMu.Lock()
+			defer app.queueMu.Unlock()
+			return len(app.jobs)
+		},
+		func() fyne.CanvasObject {
+			return container.NewVBox(widget.NewLabel("Job"), widget.NewProgressBar())
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			app.queueMu.Lock()
+			if id >= len(app.jobs) {
+				app.queueMu.Unlock()
+				return
+			}
+			job := app.jobs[id]
+			status := string(job.Status)
+			if job.Status == JobRunning && job.ETA > 0 {
+				status += fmt.Sprintf(", about %s left", job.ETA.Round(time.Second))
+			}
+			text := fmt.Sprintf("%d. %s → %s [%s]", id+1, filepath.Base(job.VideoPath), filepath.Base(job.OutputPath), status)
+			progress := job.Progress
+			app.queueMu.Unlock()
+
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(text)
+			row.Objects[1].(*widget.ProgressBar).SetValue(progress)
+		},
+	)
+	app.queueList.OnSelected = func(id widget.ListItemID) {
+		app.queueMu.Lock()
+		app.selectedJob = id
+		app.queueMu.Unlock()
+		app.refreshQueueView()
+	}
+	app.queueList.OnUnselected = func(id widget.ListItemID) {
+		app.queueMu.Lock()
+		app.selectedJob = -1
+		app.queueMu.Unlock()
+	}
+
+	upBtn := widget.NewButton("Move Up", func() { app.moveSelectedJob(-1) })
+	downBtn := widget.NewButton("Move Down", func() { app.moveSelectedJob(1) })
+	removeBtn := widget.NewButton("Remove", app.removeSelectedJob)
+	clearBtn := widget.NewButton("Clear Finished", app.clearFinishedJobs)
+	reprocessBtn := widget.NewButton("Process Again", app.reprocessSelectedJob)
+
+	app.parallelEntry = widget.NewEntry()
+	app.parallelEntry.SetText("1")
+	app.startQueueBtn = widget.NewButton("Start Queue", app.startQueue)
+	app.stopQueueBtn = widget.NewButton("Stop Queue", app.stopRunningQueue)
+	app.stopQueueBtn.Disable()
+	pauseBtn := app.newPauseButton()
+
+	app.jobLogText = widget.NewMultiLineEntry()
+	app.jobLogText.SetPlaceHolder("Select a job to see its log...")
+	app.jobLogText.Wrapping = fyne.TextWrapWord
+	jobLogScroll := container.NewScroll(app.jobLogText)
+	jobLogScroll.SetMinSize(fyne.NewSize(500, 250))
+
+	controls := container.NewVBox(
+		container.NewHBox(upBtn, downBtn, removeBtn, clearBtn, reprocessBtn),
+		container.NewHBox(widget.NewLabel("Parallel jobs:"), app.parallelEntry, app.startQueueBtn, app.stopQueueBtn, pauseBtn),
+		widget.NewSeparator(),
+		widget.NewLabel("Job Log:"),
+		jobLogScroll,
+	)
+
+	return container.NewBorder(
+		widget.NewLabel("Add videos from the 'Clean Video' tab, then start the queue:"),
+		controls, nil, nil,
+		app.queueList,
+	)
+}
+
+// showAdvisory builds a shareable content advisory for the selected subtitle and shows it
+func (app *SwearKillerApp) showAdvisory() {
+	cues, err := readSubtitle(app.subtitlePaths(), app.videoPath, app.timing(), app.log)
+	if err != nil {
+		dialog.ShowError(err, app.myWindow)
+		return
+	}
+	swears, languages := app.swearsForSubtitle(cues, app.srtLanguage, app.log)
+	matches, _ := app.findMatches(cues, swears, languages)
+	matches, _ = swearkiller.SplitByConfidence(matches, app.minConfidence())
+
+	// Prefer the real video runtime; fall back to the end of the last subtitle
+	runtime, err := app.getVideoDuration()
+	if err != nil && len(cues) > 0 {
+		runtime = cues[len(cues)-1].End
+	}
+
+	sourcePath := app.videoPath
+	if sourcePath == "" {
+		sourcePath = app.srtPath
+	}
+	title := strings.TrimSuffix(filepath.Base(sourcePath), filepath.Ext(sourcePath))
+
+	if !app.skipAds() || app.videoPath == "" {
+		app.showAdvisoryDialog(title, swearkiller.BuildAdvisory(title, matches, runtime).String())
+		return
+	}
+
+	// Report on the program alone; finding the breaks may mean scanning the video
+	app.advisoryBtn.Disable()
+	videoPath := app.videoPath
+	go func() {
+		breaks := findAdBreaks(context.Background(), videoPath, 0, app.logAsync)
+		programMatches := swearkiller.ProgramMatches(matches, breaks, 0)
+		advisory := swearkiller.BuildAdvisory(title, programMatches, swearkiller.ProgramTime(runtime, breaks)).String()
+		fyne.Do(func() {
+			app.advisoryBtn.Enable()
+			app.showAdvisoryDialog(title, advisory)
+		})
+	}()
+}
+
+// showAdvisoryDialog shows a content advisory with buttons to copy or save it
+func (app *SwearKillerApp) showAdvisoryDialog(title, advisory string) {
+
+	advisoryText := widget.NewMultiLineEntry()
+	advisoryText.SetText(advisory)
+	advisoryText.TextStyle = fyne.TextStyle{Monospace: true}
+	scroll := container.NewScroll(advisoryText)
+	scroll.SetMinSize(fyne.NewSize(600, 250))
+
+	copyBtn := widget.NewButton("Copy to Clipboard", func() {
+		fyne.CurrentApp().Clipboard().SetContent(advisory)
+		app.log("📋 Content advisory copied to clipboard")
+	})
+	saveBtn := widget.NewButton("Save...", func() {
+		saveDialog := app.newFileSave(app.settings.LastOutputDir, func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+			if _, err := writer.Write([]byte(advisory)); err != nil {
+				dialog.ShowError(err, app.myWindow)
+				return
+			}
+			app.log(fmt.Sprintf("📝 Content advisory saved to: %s", writer.URI().Path()))
+		})
+		saveDialog.SetFileName(title + "-advisory.txt")
+		saveDialog.Show()
+	})
+
+	content := container.NewBorder(
+		widget.NewLabel("Share this summary with other families. It contains no quotes from the movie."),
+		container.NewHBox(copyBtn, saveBtn), nil, nil,
+		scroll,
+	)
+	advisoryDialog := dialog.NewCustom("Content Advisory", "Close", content, app.myWindow)
+	advisoryDialog.Resize(fyne.NewSize(680, 400))
+	advisoryDialog.Show()
+}
+
+// Settings structure for saving/loading configuration
+type Settings struct {
+	Version int `json:"version"` // settingsVersion when written; files without it are version 0
+
+	SwearWords      []string `json:"swear_words"`
+	LastVideoDir    string   `json:"last_video_dir,omitempty"`
+	LastSubtitleDir string   `json:"last_subtitle_dir,omitempty"`
+	LastOutputDir   string   `json:"last_output_dir,omitempty"`
+	LastOffset      float64  `json:"last_offset,omitempty"`
+	AutoOutput      *bool    `json:"auto_output,omitempty"`
+	AutoLanguage    *bool    `json:"auto_detect_language,omitempty"`
+	ExtraLanguages  []string `json:"extra_languages,omitempty"`
+	Deobfuscate     bool     `json:"deobfuscate,omitempty"`
+	WholeWords      bool     `json:"whole_words,omitempty"`
+	Fuzzy           bool     `json:"fuzzy,omitempty"` // Also hold words one letter away from a swear for review
+	PhraseGap       *float64 `json:"phrase_gap,omitempty"`
+	Allowlist       []string `json:"allowlist"` // nil means the built-in allowlist
+	MinConfidence   *float64 `json:"min_confidence,omitempty"`
+	Profile         string   `json:"profile,omitempty"` // Built-in profile used instead of the matching settings, if any
+	VerifyOutput    bool     `json:"verify_output,omitempty"`
+	RapidReview     bool     `json:"rapid_review,omitempty"`  // Review every match one at a time before encoding
+	EditSegments    bool     `json:"edit_segments,omitempty"` // Open the segment editor before generating the command
+	WhisperModel    string   `json:"whisper_model_size,omitempty"`
+	WhisperModelDir string   `json:"whisper_model_dir,omitempty"`
+	WhisperDevice   string   `json:"whisper_device,omitempty"`
+	WhisperLanguage string   `json:"whisper_language,omitempty"`
+	WhisperBeam     int      `json:"whisper_beam,omitempty"`
+	LogFile         string   `json:"log_file,omitempty"`  // Also append the log to this file
+	LogLevel        string   `json:"log_level,omitempty"` // The log view's level filter
+	WindowWidth     float32  `json:"window_width,omitempty"`
+	WindowHeight    float32  `json:"window_height,omitempty"`
+	CacheMaxAgeDays *float64 `json:"cache_max_age_days,omitempty"` // Nil means the default cache policy
+	CacheMaxSizeGB  *float64 `json:"cache_max_size_gb,omitempty"`
+	LibraryRoots    []string `json:"library_roots,omitempty"` // Folders the library tab lists videos from
+	OCRLanguage     string   `json:"ocr_language,omitempty"`  // Tesseract language for image subtitles
+
+	CensorDescriptions bool   `json:"censor_descriptions,omitempty"` // Censor audio description tracks too
+	Container          string `json:"container,omitempty"`           // Output container, one of swearkiller.Containers
+
+	Fade float64 `json:"fade,omitempty"` // Seconds to ramp the volume around each mute (0 = cut hard)
+
+	KeepOriginal bool `json:"keep_original_audio,omitempty"` // Keep the uncensored audio as an extra track in MKV outputs
+	Stash        bool `json:"stash,omitempty"`               // Save the censored audio next to the output
+	SkipCleaned  bool `json:"skip_cleaned,omitempty"`        // Skip queued videos that are already clean outputs
+
+	LowPriority bool `json:"low_priority,omitempty"`   // Run FFmpeg at low CPU priority
+	Threads     int  `json:"ffmpeg_threads,omitempty"` // CPU threads FFmpeg may use (0 = no limit)
+
+	CheckOutput   bool `json:"check_output,omitempty"`   // Check the output decodes and is whole after processing
+	DeleteCorrupt bool `json:"delete_corrupt,omitempty"` // Delete an output that fails the check
+
+	DesktopNotify bool                     `json:"desktop_notify,omitempty"` // Show a desktop notification when an encode or the queue finishes
+	Notify        swearkiller.NotifyConfig `json:"notify,omitzero"`          // Also send the notification to a webhook, ntfy, Pushover or email
+
+	Hooks swearkiller.Hooks `json:"hooks,omitzero"` // Commands run before and after each queued job
+
+	Refresh swearkiller.LibraryRefresh `json:"refresh,omitzero"` // Plex and Jellyfin servers told about each clean video
+
+	SwearListURL string `json:"swear_list_url,omitempty"` // Shared swear list the local one is replaced with at startup
+
+	NameTemplate string `json:"name_template,omitempty"` // How auto-generated outputs are named (empty = swearkiller.DefaultNameTemplate)
+	Overwrite    string `json:"overwrite,omitempty"`     // A swearkiller.OverwritePolicy for existing outputs, or empty to ask
+}
+
+// settingsVersion is the settings file version this build writes. Raise it, and add a
+// migration to settingsMigrations, when a setting is renamed, moved or changes meaning.
+const settingsVersion = 1
+
+// settingsMigrations upgrade a settings file one version at a time: settingsMigrations[i]
+// turns version i into version i+1. They work on the decoded JSON, so settings that were
+// renamed or removed can still be read.
+var settingsMigrations = []func(settings map[string]any){
+	// 0 to 1: files from before the version field. No setting changed meaning.
+	func(settings map[string]any) {},
+}
+
+// migrateSettings upgrades settings file data written by an older version, saving a copy of
+// the old file beside it first, and writes the upgraded file back. It returns the data to
+// load and a note for the log, if there is something to say.
+func migrateSettings(settingsPath string, data []byte) ([]byte, string) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return data, "" // Loading it reports the problem
+	}
+	version := 0
+	if v, ok := raw["version"].(float64); ok {
+		version = int(v)
+	}
+	switch {
+	case version == settingsVersion:
+		return data, ""
+	case version > settingsVersion || version < 0:
+		backup, err := backupSettings(settingsPath, data, version)
+		if err != nil {
+			return data, fmt.Sprintf("Warning: The settings file is from a newer version of Swear Killer, and a copy could not be saved: %v", err)
+		}
+		return data, fmt.Sprintf("Warning: The settings file is from a newer version of Swear Killer; settings this version doesn't know are dropped when it saves. The file was copied to %s", backup)
+	}
+
+	backup, err := backupSettings(settingsPath, data, version)
+	for _, migrate := range settingsMigrations[version:] {
+		migrate(raw)
+	}
+	raw["version"] = settingsVersion
+	upgraded, marshalErr := json.MarshalIndent(raw, "", "  ")
+	if marshalErr != nil {
+		return data, fmt.Sprintf("Warning: Could not upgrade the settings file: %v", marshalErr)
+	}
+	if err != nil {
+		// Used as upgraded, but the file is only rewritten once it's safe to
+		return upgraded, fmt.Sprintf("Warning: Could not save a copy of the settings file before upgrading it, so it was left as it is: %v", err)
+	}
+	if err := os.WriteFile(settingsPath, upgraded, 0600); err != nil {
+		return upgraded, fmt.Sprintf("Warning: Could not save the upgraded settings file: %v", err)
+	}
+	return upgraded, fmt.Sprintf("Upgraded the settings file from version %d to %d; the old one was copied to %s", version, settingsVersion, backup)
+}
+
+// backupSettings copies the settings file data, of the given version, beside it, keeping
+// a copy already there, and returns the copy's path
+func backupSettings(settingsPath string, data []byte, version int) (string, error) {
+	backup := fmt.Sprintf("%s.v%d.bak", settingsPath, version)
+	if _, err := os.Stat(backup); err == nil {
+		return backup, nil // The first copy of this version is the one worth keeping
+	}
+	if err := os.WriteFile(backup, data, 0600); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+// getStatePath returns the path to the file recording already-processed videos
+func getStatePath() string {
+	return swearkiller.DefaultStatePath()
+}
+
+// loadSettings loads swear words from settings file
+func (app *SwearKillerApp) loadSettings() {
+	settingsPath := app.settingsFile.Path
+	data, err := os.ReadFile(settingsPath)
+	if err != nil || len(strings.TrimSpace(string(data))) == 0 {
+		// First run, or an empty portable settings file: default swear words, and the profile
+		// vetted for new users
+		app.settings.Profile = swearkiller.DefaultProfile
+		return
+	}
+	data, app.settingsNote = migrateSettings(settingsPath, data)
+
+	var settings Settings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return
+	}
+	app.settings = settings
+
+	if len(settings.SwearWords) > 0 {
+		app.swears = settings.SwearWords
+	}
+}
 
-		// Add progress flag to FFmpeg - use stdout for progress
-		progressArgs := make([]string, 0, len(args)+2)
-		progressArgs = append(progressArgs, args[:len(args)-1]...)
-		progressArgs = append(progressArgs, "-progress", "pipe:1")
-		progressArgs = append(progressArgs, args[len(args)-1])
-		cmd := exec.Command("ffmpeg", progressArgs...)
+// saveSettings saves current swear words and preferences to settings file
+func (app *SwearKillerApp) saveSettings() error {
+	app.settings.SwearWords = app.swears
+	return app.writeSettings()
+}
 
-		// Set up pipes to capture stdout for progress
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			app.log(fmt.Sprintf("Error setting up progress pipe: %v", err))
-			return
-		}
+// persistSettings saves preferences without touching the swear list, logging any failure
+func (app *SwearKillerApp) persistSettings() {
+	if err := app.writeSettings(); err != nil {
+		app.log(fmt.Sprintf("Warning: Could not save settings: %v", err))
+	}
+}
 
-		// Start the command
-		if err := cmd.Start(); err != nil {
-			app.log(fmt.Sprintf("Error starting FFmpeg: %v", err))
-			return
-		}
+// rememberDir records the directory of a chosen file so the next dialog opens there
+func (app *SwearKillerApp) rememberDir(dir *string, filePath string) {
+	*dir = filepath.Dir(filePath)
+	app.persistSettings()
+}
 
-		// Read progress in real-time if we have duration
-		if duration > 0 {
-			go func() {
-				scanner := bufio.NewScanner(stdout)
-				for scanner.Scan() {
-					line := scanner.Text()
-
-					// Don't log progress lines to keep output clean
-
-					currentTime, found := parseFFmpegProgress(line)
-					if found {
-						percentage := (currentTime / duration) * 100
-						if percentage > 100 {
-							percentage = 100
-						}
-
-						// Fyne ProgressBar expects values between 0.0 and 1.0, not 0-100
-						progressValue := percentage / 100.0
-						if progressValue > 1.0 {
-							progressValue = 1.0
-						}
-
-						remainingTime := duration - currentTime
-						if remainingTime < 0 {
-							remainingTime = 0
-						}
-
-						// Progress is shown in progress bar only, no logging needed
-
-						// Update UI elements on the main thread
-						fyne.Do(func() {
-							if app.realProgressBar != nil {
-								app.realProgressBar.SetValue(progressValue)
-							}
-							if app.progressLabel != nil {
-								app.progressLabel.SetText(fmt.Sprintf("Processing: %.1f%% complete (%.1fs remaining)",
-									percentage, remainingTime))
-							}
-						})
-					}
-				}
-			}()
-		}
+// writeSettings writes the current settings to the settings file
+func (app *SwearKillerApp) writeSettings() error {
+	app.settings.Version = settingsVersion
+	data, err := json.MarshalIndent(app.settings, "", "  ")
+	if err != nil {
+		return err
+	}
 
-		// Wait for command to complete
-		err = cmd.Wait()
+	// Private, as the file may hold an SMTP password; older files are made private too
+	settingsPath := app.settingsFile.Path
+	if err := os.MkdirAll(filepath.Dir(settingsPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(settingsPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(settingsPath, 0600)
+}
 
-		if err != nil {
-			fyne.Do(func() {
-				app.log(fmt.Sprintf("❌ Error executing FFmpeg: %v", err))
-			})
-		} else {
-			fyne.Do(func() {
-				if app.realProgressBar != nil {
-					app.realProgressBar.SetValue(1.0) // 1.0 = 100% for Fyne
-				}
-				if app.progressLabel != nil {
-					app.progressLabel.SetText("✅ Processing complete!")
-				}
-				app.log("✅ Video processing completed successfully!")
-				app.log(fmt.Sprintf("📁 Clean video saved to: %s", app.outputPath))
-				app.log("🎉 You can now play your clean video!")
-			})
-		}
-	}()
+// autoDetectLanguage reports whether built-in lists are added for detected subtitle languages
+func (app *SwearKillerApp) autoDetectLanguage() bool {
+	return app.settings.AutoLanguage == nil || *app.settings.AutoLanguage
 }
 
-// getVolumeFilter extracts just the volume filter part from the last command
-func (app *SwearKillerApp) getVolumeFilter() string {
-	// Extract the volume filter from the generated command
-	cmdStr := app.lastCommand
-	start := strings.Index(cmdStr, "between(")
-	end := strings.LastIndex(cmdStr, ")")
-	if start == -1 || end == -1 {
-		return ""
+// allowlist returns the harmless words whose swear-like parts are ignored
+func (app *SwearKillerApp) allowlist() []string {
+	if app.settings.Allowlist == nil {
+		return swearkiller.DefaultAllowlist
 	}
-	return cmdStr[start : end+1]
+	return app.settings.Allowlist
 }
 
-// enableButtons re-enables the buttons after execution
-func (app *SwearKillerApp) enableButtons() {
-	app.updateProcessButton()
+// minConfidence returns the confidence a match needs to be muted without review
+func (app *SwearKillerApp) minConfidence() float64 {
+	if profile, ok := app.profile(); ok {
+		return profile.MinConfidence
+	}
+	if app.settings.MinConfidence == nil {
+		return swearkiller.DefaultMinConfidence
+	}
+	return *app.settings.MinConfidence
 }
 
-// getVideoDuration gets the total duration of the video in seconds
-func (app *SwearKillerApp) getVideoDuration() (float64, error) {
-	cmd := exec.Command("ffprobe", "-v", "quiet", "-show_entries", "format=duration", "-of", "csv=p=0", app.videoPath)
-	output, err := cmd.Output()
-	if err != nil {
-		return 0, err
+// profile returns the built-in profile chosen in settings, if any
+func (app *SwearKillerApp) profile() (swearkiller.Profile, bool) {
+	if app.settings.Profile == "" {
+		return swearkiller.Profile{}, false
 	}
+	profile, err := swearkiller.FindProfile(app.settings.Profile)
+	return profile, err == nil
+}
 
-	durationStr := strings.TrimSpace(string(output))
-	duration, err := strconv.ParseFloat(durationStr, 64)
-	if err != nil {
-		return 0, err
+// findMatches finds swears in cues with the chosen profile, or with the matching settings
+// if there is none. It also returns the matches of a broad scan, for comparing profiles.
+func (app *SwearKillerApp) findMatches(cues []swearkiller.Cue, swears, languages []string) (matches, broad []swearkiller.Match) {
+	opts := app.matchOptions(languages)
+	broad = swearkiller.FindMatches(cues, swears, swearkiller.BroadMatchOptions(opts))
+	if profile, ok := app.profile(); ok {
+		return profile.Apply(broad), broad
 	}
-
-	return duration, nil
+	return swearkiller.FindMatches(cues, swears, opts), broad
 }
 
-// parseFFmpegProgress parses FFmpeg progress output and returns current time in seconds
-func parseFFmpegProgress(line string) (float64, bool) {
-	// Look for "out_time_us=" (microseconds)
-	if strings.Contains(line, "out_time_us=") {
-		timeRegex := regexp.MustCompile(`out_time_us=(\d+)`)
-		matches := timeRegex.FindStringSubmatch(line)
-		if len(matches) == 2 {
-			microseconds, err := strconv.ParseInt(matches[1], 10, 64)
-			if err == nil {
-				seconds := float64(microseconds) / 1000000.0
-				return seconds, true
-			}
+// parseWordLines splits a text area into a word list, one entry per non-empty line
+func parseWordLines(text string) []string {
+	words := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if word := strings.TrimSpace(line); word != "" {
+			words = append(words, word)
 		}
 	}
+	return words
+}
 
-	// Skip out_time_ms= - FFmpeg puts microseconds there, not milliseconds!
-
-	// Skip out_time= - regex not working properly for HH:MM:SS format
+// matchOptions returns the swear matching options chosen in settings for a subtitle in the given languages
+func (app *SwearKillerApp) matchOptions(languages []string) swearkiller.MatchOptions {
+	opts := swearkiller.DefaultMatchOptions
+	opts.Deobfuscate = app.settings.Deobfuscate
+	opts.WholeWords = app.settings.WholeWords
+	opts.Fuzzy = app.settings.Fuzzy
+	opts.Languages = languages
+	opts.Allow = app.allowlist()
+	if app.settings.PhraseGap != nil {
+		opts.PhraseGap = *app.settings.PhraseGap
+	}
+	return opts
+}
 
-	// Skip time= format too - not needed since out_time_us= works perfectly
-	return 0, false
+// cachePolicy returns the cache limits chosen in settings
+func (app *SwearKillerApp) cachePolicy() swearkiller.CachePolicy {
+	policy := swearkiller.DefaultCachePolicy
+	if app.settings.CacheMaxAgeDays != nil {
+		policy.MaxAge = time.Duration(*app.settings.CacheMaxAgeDays * 24 * float64(time.Hour))
+	}
+	if app.settings.CacheMaxSizeGB != nil {
+		policy.MaxBytes = int64(*app.settings.CacheMaxSizeGB * (1 << 30))
+	}
+	return policy
 }
 
-// Settings structure for saving/loading configuration
-type Settings struct {
-	SwearWords []string `json:"swear_words"`
+// pruneCaches applies the cache policy in the background, logging what it freed
+func (app *SwearKillerApp) pruneCaches() {
+	policy := app.cachePolicy()
+	go func() {
+		report, err := swearkiller.PruneCaches("", policy, false)
+		fyne.Do(func() {
+			if err != nil {
+				app.log(fmt.Sprintf("Warning: Could not clean up the caches: %v", err))
+				return
+			}
+			for _, err := range report.Failed {
+				app.log(fmt.Sprintf("Warning: %v", err))
+			}
+			if len(report.Removed) > 0 {
+				app.log("🧹 Cache cleanup: " + report.String())
+			} else {
+				app.logAt(swearkiller.LevelDebug, "Cache cleanup: nothing to remove; "+report.String())
+			}
+		})
+	}()
 }
 
-// getSettingsPath returns the path to the settings file
-func getSettingsPath() string {
-	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".swear-killer-settings.json")
+// fetchSharedSwears syncs the shared swear list at listURL in the background and calls done
+// with its words on the UI thread. A list that couldn't be checked is still used, with a
+// warning, if it was saved before.
+func (app *SwearKillerApp) fetchSharedSwears(listURL string, done func(words []string, err error)) {
+	go func() {
+		sync, err := swearkiller.SyncWordList(context.Background(), listURL, "")
+		var words []string
+		if err == nil {
+			err = swearkiller.VerifyChecksumFile(sync.Path)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		}
+		if err == nil {
+			var text string
+			text, _, err = swearkiller.ReadTextFile(sync.Path)
+			words = parseWordLines(text)
+		}
+		fyne.Do(func() {
+			if sync.Offline != nil {
+				app.log(fmt.Sprintf("Warning: Using the shared swear list saved last time, as it couldn't be checked: %v", sync.Offline))
+			}
+			if err == nil && len(words) == 0 {
+				err = fmt.Errorf("the shared swear list at %s is empty", listURL)
+			}
+			done(words, err)
+		})
+	}()
 }
 
-// loadSettings loads swear words from settings file
-func (app *SwearKillerApp) loadSettings() {
-	settingsPath := getSettingsPath()
-	data, err := os.ReadFile(settingsPath)
-	if err != nil {
-		// Use default swear words if no settings file exists
+// syncSwearList replaces the swear list with the shared one in Settings, if there is one
+func (app *SwearKillerApp) syncSwearList() {
+	listURL := app.settings.SwearListURL
+	if listURL == "" {
 		return
 	}
+	app.fetchSharedSwears(listURL, func(words []string, err error) {
+		if err != nil {
+			app.log(fmt.Sprintf("Warning: Could not get the shared swear list, so the saved one is used: %v", err))
+			return
+		}
+		if slices.Equal(words, app.swears) {
+			app.logAt(swearkiller.LevelDebug, "The shared swear list is unchanged")
+			return
+		}
+		app.swears = words
+		if err := app.saveSettings(); err != nil {
+			app.log(fmt.Sprintf("Warning: Could not save settings: %v", err))
+		}
+		app.log(fmt.Sprintf("🔄 Updated the swear list from the shared list (%d words)", len(words)))
+	})
+}
 
-	var settings Settings
-	if err := json.Unmarshal(data, &settings); err != nil {
-		return
+// transcribeOptions returns the Whisper options chosen in settings
+func (app *SwearKillerApp) transcribeOptions() swearkiller.TranscribeOptions {
+	opts := swearkiller.TranscribeOptions{
+		ModelSize: app.settings.WhisperModel,
+		ModelDir:  app.settings.WhisperModelDir,
+		Device:    app.settings.WhisperDevice,
+		Language:  app.settings.WhisperLanguage,
+		BeamSize:  app.settings.WhisperBeam,
+	}
+	if opts.ModelSize == "" {
+		opts.ModelSize = swearkiller.DefaultModelSize
+	}
+	if opts.Device == "" {
+		opts.Device = "auto"
+	}
+	if opts.Language == "" {
+		opts.Language = "auto"
 	}
+	return opts
+}
 
-	if len(settings.SwearWords) > 0 {
-		app.swears = settings.SwearWords
+// swearsForSubtitle returns the user's swear list plus built-in lists for the subtitle's languages
+// and any languages the user always wants included, along with the languages the result covers
+func (app *SwearKillerApp) swearsForSubtitle(cues []swearkiller.Cue, langHint string, logFn func(string)) ([]string, []string) {
+	languages := append([]string{}, app.settings.ExtraLanguages...)
+	if app.autoDetectLanguage() {
+		detected := swearkiller.SubtitleLanguages(cues, langHint)
+		if len(detected) > 0 {
+			var names []string
+			for _, code := range detected {
+				names = append(names, swearkiller.LanguageName(code))
+			}
+			logFn(fmt.Sprintf("🌐 Detected subtitle language(s): %s", strings.Join(names, ", ")))
+		}
+		languages = append(languages, detected...)
+	}
+
+	swears := swearkiller.ExpandSwears(app.swears, languages)
+	if added := len(swears) - len(swearkiller.CombineLists(app.swears)); added > 0 {
+		logFn(fmt.Sprintf("Added %d words from built-in language lists", added))
 	}
+	// The user's own list stands in for English
+	return swears, append([]string{"en"}, languages...)
 }
 
-// saveSettings saves current swear words to settings file
-func (app *SwearKillerApp) saveSettings() error {
-	settings := Settings{
-		SwearWords: app.swears,
+// rememberOffset saves the offset so it is prefilled next time
+func (app *SwearKillerApp) rememberOffset(offset float64) {
+	if app.settings.LastOffset != offset {
+		app.settings.LastOffset = offset
+		app.persistSettings()
 	}
+}
 
-	data, err := json.MarshalIndent(settings, "", "  ")
+// listableDir returns a dialog location for dir, or nil if it no longer exists
+func listableDir(dir string) fyne.ListableURI {
+	if dir == "" {
+		return nil
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil
+	}
+	lister, err := storage.ListerForURI(storage.NewFileURI(dir))
 	if err != nil {
-		return err
+		return nil
 	}
+	return lister
+}
+
+// showFileOpen shows a file open dialog starting in startDir when possible
+func (app *SwearKillerApp) showFileOpen(startDir string, callback func(fyne.URIReadCloser, error)) {
+	openDialog := dialog.NewFileOpen(callback, app.myWindow)
+	if location := listableDir(startDir); location != nil {
+		openDialog.SetLocation(location)
+	}
+	openDialog.Show()
+}
 
-	settingsPath := getSettingsPath()
-	return os.WriteFile(settingsPath, data, 0644)
+// newFileSave creates a file save dialog starting in startDir when possible
+func (app *SwearKillerApp) newFileSave(startDir string, callback func(fyne.URIWriteCloser, error)) *dialog.FileDialog {
+	saveDialog := dialog.NewFileSave(callback, app.myWindow)
+	if location := listableDir(startDir); location != nil {
+		saveDialog.SetLocation(location)
+	}
+	return saveDialog
+}
+
+// showWordImport asks for a public swear list, as a file or URL, and adds the words it has
+// that swearText is missing
+func (app *SwearKillerApp) showWordImport(swearText *widget.Entry) {
+	sourceEntry := widget.NewEntry()
+	sourceEntry.SetPlaceHolder("https://... or a .txt or .csv file")
+	browseBtn := widget.NewButton("Browse...", func() {
+		app.showFileOpen("", func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			defer reader.Close()
+			sourceEntry.SetText(reader.URI().Path())
+		})
+	})
+	severityEntry := widget.NewEntry()
+	severityEntry.SetText("0")
+	content := container.NewVBox(
+		widget.NewLabel("Add the words of a public list, like LDNOOBW's, one word per line, or a CSV with a severity column."),
+		widget.NewForm(
+			widget.NewFormItem("List", container.NewBorder(nil, nil, nil, browseBtn, sourceEntry)),
+			widget.NewFormItem("Minimum severity (CSV, 0 = all)", severityEntry),
+		),
+	)
+	importDialog := dialog.NewCustomConfirm("Import a Word List", "Import", "Cancel", content, func(ok bool) {
+		source := strings.TrimSpace(sourceEntry.Text)
+		if !ok || source == "" {
+			return
+		}
+		minSeverity, err := strconv.ParseFloat(strings.TrimSpace(severityEntry.Text), 64)
+		if err != nil || minSeverity < 0 {
+			dialog.ShowError(fmt.Errorf("minimum severity must be zero or a positive number"), app.myWindow)
+			return
+		}
+		go func() {
+			text, err := swearkiller.ReadWordListSource(context.Background(), source)
+			var list swearkiller.ImportedList
+			if err == nil {
+				list, err = swearkiller.ParseWordList(source, text, swearkiller.ImportOptions{MinSeverity: minSeverity})
+			}
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, app.myWindow)
+					return
+				}
+				merged, added := swearkiller.MergeWords(parseWordLines(swearText.Text), list.Words)
+				swearText.SetText(strings.Join(merged, "\n"))
+				message := fmt.Sprintf("Added %d new word(s) of the %d in the list.", added, len(list.Words))
+				if list.Dropped > 0 {
+					message += fmt.Sprintf(" %d below the minimum severity were left out.", list.Dropped)
+				}
+				dialog.ShowInformation("Word List Imported", message+"\nClick Save to keep them.", app.myWindow)
+			})
+		}()
+	}, app.myWindow)
+	importDialog.Resize(fyne.NewSize(560, 220))
+	importDialog.Show()
 }
 
+// customProfile is the profile choice that uses the matching settings instead of a profile
+const customProfile = "Custom"
+
 // showSettings displays the settings dialog
 func (app *SwearKillerApp) showSettings() {
 	// Create a large text area for editing swear words
@@ -885,27 +4274,531 @@ func (app *SwearKillerApp) showSettings() {
 	// Instructions label
 	instructions := widget.NewLabel("Edit swear words (one per line):")
 
-	// Scroll container for the text area
+	// Harmless words that contain swears
+	allowText := widget.NewMultiLineEntry()
+	allowText.SetText(strings.Join(app.allowlist(), "\n"))
+	allowInstructions := widget.NewLabel("Allowed words, never muted (one per line):")
+
+	// Built-in language lists
+	autoLangCheck := widget.NewCheck("Auto-detect subtitle language and add its built-in list", nil)
+	autoLangCheck.SetChecked(app.autoDetectLanguage())
+	languageChecks := map[string]*widget.Check{}
+	languageRow := container.NewHBox(widget.NewLabel("Always include:"))
+	for _, code := range swearkiller.BuiltinLanguages() {
+		if code == "en" {
+			continue // The list above is the English list
+		}
+		check := widget.NewCheck(swearkiller.LanguageName(code), nil)
+		for _, extra := range app.settings.ExtraLanguages {
+			if extra == code {
+				check.SetChecked(true)
+			}
+		}
+		languageChecks[code] = check
+		languageRow.Add(check)
+	}
+
+	// Obfuscated spellings
+	deobfuscateCheck := widget.NewCheck("Also match disguised spellings (f*ck, sh1t, f u c k) - may cause false positives", nil)
+	deobfuscateCheck.SetChecked(app.settings.Deobfuscate)
+
+	// Swears inside longer words
+	wholeWordsCheck := widget.NewCheck("Only match whole words (suffixes and compounds count in Finnish, Turkish and German)", nil)
+	wholeWordsCheck.SetChecked(app.settings.WholeWords)
+
+	// Typos from OCR and transcription
+	fuzzyCheck := widget.NewCheck("Also match words one letter off (typos like fuckinq) - held for review", nil)
+	fuzzyCheck.SetChecked(app.settings.Fuzzy)
+
+	// Checking the result
+	verifyCheck := widget.NewCheck("Verify muted segments are silent in the output after processing", nil)
+	verifyCheck.SetChecked(app.settings.VerifyOutput)
+	deleteCorruptCheck := widget.NewCheck("Delete an output that fails the check", nil)
+	deleteCorruptCheck.SetChecked(app.settings.DeleteCorrupt)
+	checkOutputCheck := widget.NewCheck("Check the output decodes cleanly and lasts as long as the video after processing", func(checked bool) {
+		if checked {
+			deleteCorruptCheck.Enable()
+		} else {
+			deleteCorruptCheck.Disable()
+		}
+	})
+	checkOutputCheck.SetChecked(app.settings.CheckOutput)
+	if !app.settings.CheckOutput {
+		deleteCorruptCheck.Disable()
+	}
+
+	// Audio description tracks, whose narrator often repeats the dialogue
+	descriptionsCheck := widget.NewCheck("Also censor audio description tracks (otherwise they're kept as they are)", nil)
+	descriptionsCheck.SetChecked(app.settings.CensorDescriptions)
+
+	// Ramps around mutes, so the cuts don't pop
+	fadeEntry := widget.NewEntry()
+	fadeEntry.SetText(strconv.FormatFloat(app.settings.Fade*1000, 'f', -1, 64))
+	fadeRow := container.NewBorder(nil, nil,
+		widget.NewLabel("Fade the sound out and back in over"), widget.NewLabel(fmt.Sprintf("ms around each mute (0 = cut hard, try %g)", swearkiller.DefaultFade*1000)),
+		fadeEntry)
+
+	// Keeping what undoing the censoring needs
+	keepOriginalCheck := widget.NewCheck("Keep the original audio as an extra track that isn't played (MKV outputs only)", nil)
+	keepOriginalCheck.SetChecked(app.settings.KeepOriginal)
+	stashCheck := widget.NewCheck("Save the censored audio next to the output ("+swearkiller.StashExt+"), so 'swear-killer restore' can undo it", nil)
+	stashCheck.SetChecked(app.settings.Stash)
+
+	// Queued videos that were cleaned already, like a folder holding originals and clean copies
+	skipCleanedCheck := widget.NewCheck("Skip queued videos that are already clean outputs (tagged, named -CLEAN or made here)", nil)
+	skipCleanedCheck.SetChecked(app.settings.SkipCleaned)
+
+	// How auto-generated outputs are named
+	nameTemplateEntry := widget.NewEntry()
+	nameTemplateEntry.SetPlaceHolder(string(swearkiller.DefaultNameTemplate))
+	nameTemplateEntry.SetText(app.settings.NameTemplate)
+	nameTemplateRow := container.NewBorder(nil, nil,
+		widget.NewLabel("Name outputs"), widget.NewLabel("({name}, {ext}, {profile}, {severity}, {date})"),
+		nameTemplateEntry)
+
+	// What to do when the output already exists
+	overwriteLabels := map[string]string{
+		"":                                   "Ask",
+		string(swearkiller.OverwriteReplace): "Replace it",
+		string(swearkiller.OverwriteRename):  "Keep both, adding a number to the new one",
+		string(swearkiller.OverwriteSkip):    "Skip the video",
+		string(swearkiller.OverwriteFail):    "Stop with an error",
+	}
+	overwriteOptions := []string{overwriteLabels[""]}
+	for _, policy := range swearkiller.OverwritePolicies {
+		overwriteOptions = append(overwriteOptions, overwriteLabels[string(policy)])
+	}
+	overwriteSelect := widget.NewSelect(overwriteOptions, nil)
+	overwriteSelect.SetSelected(overwriteLabels[app.settings.Overwrite])
+	overwriteRow := container.NewHBox(widget.NewLabel("When the output already exists:"), overwriteSelect)
+
+	// Sharing the computer while encoding
+	lowPriorityCheck := widget.NewCheck("Encode at low priority, so the computer stays responsive (encodes take longer while it's in use)", nil)
+	lowPriorityCheck.SetChecked(app.settings.LowPriority)
+	threadsEntry := widget.NewEntry()
+	threadsEntry.SetText(strconv.Itoa(app.settings.Threads))
+	threadsRow := container.NewBorder(nil, nil,
+		widget.NewLabel("Let FFmpeg use at most"), widget.NewLabel(fmt.Sprintf("CPU threads (0 = all %d; fewer run cooler and quieter)", runtime.NumCPU())),
+		threadsEntry)
+
+	rapidReviewCheck := widget.NewCheck("Review every match one at a time with the keyboard before encoding (rapid review)", nil)
+	rapidReviewCheck.SetChecked(app.settings.RapidReview)
+	editSegmentsCheck := widget.NewCheck("Edit the segments by hand before generating the command", nil)
+	editSegmentsCheck.SetChecked(app.settings.EditSegments)
+
+	// Phrases split across subtitle blocks
+	phraseGapEntry := widget.NewEntry()
+	phraseGapEntry.SetText(strconv.FormatFloat(app.matchOptions(nil).PhraseGap, 'f', -1, 64))
+	phraseGapRow := container.NewBorder(nil, nil,
+		widget.NewLabel("Join phrases split across subtitles up to"), widget.NewLabel("seconds apart (0 = off)"),
+		phraseGapEntry)
+
+	// Confidence needed to mute without review
+	confidenceEntry := widget.NewEntry()
+	confidenceEntry.SetText(strconv.FormatFloat(app.minConfidence()*100, 'f', -1, 64))
+	confidenceRow := container.NewBorder(nil, nil,
+		widget.NewLabel("Mute matches at least"), widget.NewLabel("% confident; review the rest"),
+		confidenceEntry)
+
+	// A built-in profile instead of the matching settings above
+	profileSelect := widget.NewSelect(append([]string{customProfile}, swearkiller.ProfileNames()...), nil)
+	profileSelect.SetSelected(customProfile)
+	if profile, ok := app.profile(); ok {
+		profileSelect.SetSelected(profile.Name)
+	}
+	profileRow := container.NewHBox(widget.NewLabel("Profile:"), profileSelect,
+		widget.NewLabel("(a profile replaces the disguised spellings, whole words and confidence settings)"))
+
+	// Transcription for videos without subtitles
+	whisper := app.transcribeOptions()
+	modelSizeSelect := widget.NewSelect(swearkiller.WhisperModelSizes, nil)
+	modelSizeSelect.SetSelected(whisper.ModelSize)
+	modelDirEntry := widget.NewEntry()
+	modelDirEntry.SetPlaceHolder(swearkiller.WhisperModelDir())
+	modelDirEntry.SetText(whisper.ModelDir)
+	deviceSelect := widget.NewSelect([]string{"auto", "cpu", "gpu:0", "gpu:1", "gpu:2", "gpu:3"}, nil)
+	deviceSelect.SetSelected(whisper.Device)
+	whisperLangEntry := widget.NewEntry()
+	whisperLangEntry.SetText(whisper.Language)
+	beamEntry := widget.NewEntry()
+	beamEntry.SetText(strconv.Itoa(whisper.BeamSize))
+	ocrLangEntry := widget.NewEntry()
+	ocrLangEntry.SetPlaceHolder(swearkiller.DefaultOCRLanguage)
+	ocrLangEntry.SetText(app.settings.OCRLanguage)
+	estimateLabel := widget.NewLabel("Select a video to see how long each model would take")
+	estimateLabel.Wrapping = fyne.TextWrapWord
+	if app.videoPath != "" {
+		if duration, err := app.getVideoDuration(); err == nil {
+			updateEstimate := func(string) {
+				var estimates []string
+				for _, size := range swearkiller.WhisperModelSizes {
+					estimates = append(estimates, fmt.Sprintf("%s ~%s", size, swearkiller.EstimateTranscription(duration, size, deviceSelect.Selected)))
+				}
+				estimateLabel.SetText(fmt.Sprintf("Estimated time for %s: %s", filepath.Base(app.videoPath), strings.Join(estimates, ", ")))
+			}
+			updateEstimate("")
+			deviceSelect.OnChanged = updateEstimate
+		}
+	}
+	// Keeping the log
+	logFileEntry := widget.NewEntry()
+	logFileEntry.SetPlaceHolder("No log file")
+	logFileEntry.SetText(app.settings.LogFile)
+	logFileRow := container.NewBorder(nil, nil, widget.NewLabel("Also save the log to:"), nil, logFileEntry)
+
+	// Limits for the transcript cache and leftover temporary files
+	policy := app.cachePolicy()
+	cacheAgeEntry := widget.NewEntry()
+	cacheAgeEntry.SetText(strconv.FormatFloat(policy.MaxAge.Hours()/24, 'f', -1, 64))
+	cacheSizeEntry := widget.NewEntry()
+	cacheSizeEntry.SetText(strconv.FormatFloat(float64(policy.MaxBytes)/(1<<30), 'f', -1, 64))
+	cacheUsageLabel := widget.NewLabel("Checking the cache...")
+	cacheUsageLabel.Wrapping = fyne.TextWrapWord
+	updateCacheUsage := func() {
+		go func() {
+			entries, err := swearkiller.CacheEntries("")
+			var total int64
+			for _, entry := range entries {
+				total += entry.Size
+			}
+			fyne.Do(func() {
+				if err != nil {
+					cacheUsageLabel.SetText(err.Error())
+					return
+				}
+				cacheUsageLabel.SetText(fmt.Sprintf("%d cached item(s) using %s in %s. The cache is cleaned up with these limits every time Swear Killer starts.",
+					len(entries), swearkiller.FormatBytes(total), swearkiller.TranscriptCacheDir()))
+			})
+		}()
+	}
+	updateCacheUsage()
+	cleanUpBtn := widget.NewButton("Clean Up Now", func() {
+		days, errAge := strconv.ParseFloat(strings.TrimSpace(cacheAgeEntry.Text), 64)
+		gb, errSize := strconv.ParseFloat(strings.TrimSpace(cacheSizeEntry.Text), 64)
+		if errAge != nil || errSize != nil || days < 0 || gb < 0 {
+			dialog.ShowError(fmt.Errorf("cache limits must be zero or positive numbers"), app.myWindow)
+			return
+		}
+		policy := swearkiller.CachePolicy{MaxAge: time.Duration(days * 24 * float64(time.Hour)), MaxBytes: int64(gb * (1 << 30))}
+		go func() {
+			report, err := swearkiller.PruneCaches("", policy, false)
+			fyne.Do(func() {
+				if err != nil {
+					dialog.ShowError(err, app.myWindow)
+					return
+				}
+				app.log("🧹 Cache cleanup: " + report.String())
+				updateCacheUsage()
+			})
+		}()
+	})
+
+	// Notifications when an encode or the queue finishes
+	desktopNotifyCheck := widget.NewCheck("Show a desktop notification", nil)
+	desktopNotifyCheck.SetChecked(app.settings.DesktopNotify)
+	webhookEntry := widget.NewEntry()
+	webhookEntry.SetText(app.settings.Notify.Webhook)
+	webhookEntry.SetPlaceHolder("https://example.com/hook")
+	ntfyEntry := widget.NewEntry()
+	ntfyEntry.SetText(app.settings.Notify.Ntfy)
+	ntfyEntry.SetPlaceHolder("https://ntfy.sh/my-encodes")
+	pushoverTokenEntry := widget.NewEntry()
+	pushoverTokenEntry.SetText(app.settings.Notify.PushoverToken)
+	pushoverUserEntry := widget.NewEntry()
+	pushoverUserEntry.SetText(app.settings.Notify.PushoverUser)
+	emailToEntry := widget.NewEntry()
+	emailToEntry.SetText(app.settings.Notify.EmailTo)
+	emailToEntry.SetPlaceHolder("me@example.com")
+	emailFromEntry := widget.NewEntry()
+	emailFromEntry.SetText(app.settings.Notify.EmailFrom)
+	smtpServerEntry := widget.NewEntry()
+	smtpServerEntry.SetText(app.settings.Notify.SMTPServer)
+	smtpServerEntry.SetPlaceHolder("smtp.example.com:587")
+	smtpUserEntry := widget.NewEntry()
+	smtpUserEntry.SetText(app.settings.Notify.SMTPUser)
+	smtpPasswordEntry := widget.NewPasswordEntry()
+	smtpPasswordEntry.SetText(app.settings.Notify.SMTPPassword)
+	notifyConfig := func() swearkiller.NotifyConfig {
+		return swearkiller.NotifyConfig{
+			Webhook:       strings.TrimSpace(webhookEntry.Text),
+			Ntfy:          strings.TrimSpace(ntfyEntry.Text),
+			PushoverToken: strings.TrimSpace(pushoverTokenEntry.Text),
+			PushoverUser:  strings.TrimSpace(pushoverUserEntry.Text),
+			EmailTo:       strings.TrimSpace(emailToEntry.Text),
+			EmailFrom:     strings.TrimSpace(emailFromEntry.Text),
+			SMTPServer:    strings.TrimSpace(smtpServerEntry.Text),
+			SMTPUser:      strings.TrimSpace(smtpUserEntry.Text),
+			SMTPPassword:  smtpPasswordEntry.Text,
+		}
+	}
+	testNotifyBtn := widget.NewButton("Send a Test Notification", func() {
+		config := notifyConfig()
+		if err := config.Validate(); err != nil {
+			dialog.ShowError(err, app.myWindow)
+			return
+		}
+		if desktopNotifyCheck.Checked {
+			fyne.CurrentApp().SendNotification(fyne.NewNotification("Swear Killer", "This is a test notification"))
+		}
+		if !config.Enabled() {
+			return
+		}
+		go func() {
+			n := swearkiller.Notification{Title: "Swear Killer", Message: "This is a test notification", Success: true, Time: time.Now()}
+			if err := config.Send(context.Background(), n); err != nil {
+				fyne.Do(func() { dialog.ShowError(err, app.myWindow) })
+				return
+			}
+			app.logAsync("🔔 Test notification sent")
+		}()
+	})
+
+	notifications := widget.NewAccordion(widget.NewAccordionItem("Notifications",
+		container.NewVBox(
+			desktopNotifyCheck,
+			widget.NewForm(
+				widget.NewFormItem("Webhook URL (JSON POST)", webhookEntry),
+				widget.NewFormItem("ntfy topic URL", ntfyEntry),
+				widget.NewFormItem("Pushover app token", pushoverTokenEntry),
+				widget.NewFormItem("Pushover user key", pushoverUserEntry),
+				widget.NewFormItem("Email to (commas between)", emailToEntry),
+				widget.NewFormItem("Email from (default: SMTP user)", emailFromEntry),
+				widget.NewFormItem("SMTP server (host:port)", smtpServerEntry),
+				widget.NewFormItem("SMTP user", smtpUserEntry),
+				widget.NewFormItem("SMTP password", smtpPasswordEntry),
+			),
+			container.NewHBox(testNotifyBtn),
+		)))
+
+	// Commands run around each queued job
+	beforeHookEntry := widget.NewEntry()
+	beforeHookEntry.SetText(app.settings.Hooks.Before)
+	afterHookEntry := widget.NewEntry()
+	afterHookEntry.SetText(app.settings.Hooks.After)
+	afterHookEntry.SetPlaceHolder(`mv "$SK_OUTPUT" /media/clean/`)
+	hooks := widget.NewAccordion(widget.NewAccordionItem("Scripts (for queued jobs)",
+		container.NewVBox(
+			widget.NewForm(
+				widget.NewFormItem("Before each job", beforeHookEntry),
+				widget.NewFormItem("After each job", afterHookEntry),
+			),
+			widget.NewLabel("Shell commands, given SK_INPUT, SK_SUBTITLE and SK_OUTPUT, and afterwards SK_STATUS, SK_SEGMENTS and SK_ERROR.\nIf the first one fails, the job doesn't run."),
+		)))
+
+	// Media servers told about each clean video
+	plexEntry := widget.NewEntry()
+	plexEntry.SetText(app.settings.Refresh.Plex)
+	plexEntry.SetPlaceHolder("http://localhost:32400")
+	plexTokenEntry := widget.NewPasswordEntry()
+	plexTokenEntry.SetText(app.settings.Refresh.PlexToken)
+	jellyfinEntry := widget.NewEntry()
+	jellyfinEntry.SetText(app.settings.Refresh.Jellyfin)
+	jellyfinEntry.SetPlaceHolder("http://localhost:8096")
+	jellyfinKeyEntry := widget.NewPasswordEntry()
+	jellyfinKeyEntry.SetText(app.settings.Refresh.JellyfinKey)
+	mediaPathMapEntry := widget.NewMultiLineEntry()
+	mediaPathMapEntry.SetText(strings.Join(app.settings.Refresh.PathMap, "\n"))
+	mediaPathMapEntry.SetMinRowsVisible(2)
+	mediaPathMapEntry.SetPlaceHolder("/mnt/media=/data")
+	mediaServers := widget.NewAccordion(widget.NewAccordionItem("Media Servers",
+		container.NewVBox(
+			widget.NewForm(
+				widget.NewFormItem("Plex URL", plexEntry),
+				widget.NewFormItem("Plex token", plexTokenEntry),
+				widget.NewFormItem("Jellyfin URL", jellyfinEntry),
+				widget.NewFormItem("Jellyfin API key", jellyfinKeyEntry),
+				widget.NewFormItem("Path map (one per line)", mediaPathMapEntry),
+			),
+			widget.NewLabel("The library holding each clean video is refreshed, so it shows up straight away.\nMap paths if the server sees the files elsewhere, like in a container."),
+		)))
+
+	caches := widget.NewAccordion(widget.NewAccordionItem("Cache Cleanup",
+		container.NewVBox(
+			widget.NewForm(
+				widget.NewFormItem("Remove items unused for (days, 0 = keep)", cacheAgeEntry),
+				widget.NewFormItem("Keep the cache under (GB, 0 = no limit)", cacheSizeEntry),
+			),
+			cacheUsageLabel,
+			container.NewHBox(cleanUpBtn),
+		)))
+
+	transcription := widget.NewAccordion(widget.NewAccordionItem("Transcription (for videos without subtitles)",
+		container.NewVBox(
+			widget.NewForm(
+				widget.NewFormItem("Model", modelSizeSelect),
+				widget.NewFormItem("Model folder", modelDirEntry),
+				widget.NewFormItem("Device", deviceSelect),
+				widget.NewFormItem("Spoken language", whisperLangEntry),
+				widget.NewFormItem("Beam size (0 = default)", beamEntry),
+			),
+			estimateLabel,
+		)))
+
+	ocrSettings := widget.NewAccordion(widget.NewAccordionItem("Image Subtitles (Blu-ray and DVD)",
+		widget.NewForm(
+			widget.NewFormItem("OCR language (like eng or eng+spa)", ocrLangEntry),
+		)))
+
+	// Scroll containers for the text areas, side by side
 	scroll := container.NewScroll(swearText)
 	scroll.SetMinSize(fyne.NewSize(400, 300))
+	allowScroll := container.NewScroll(allowText)
+	allowScroll.SetMinSize(fyne.NewSize(300, 300))
+	importBtn := widget.NewButton("Import List...", func() { app.showWordImport(swearText) })
+
+	// A swear list shared between machines
+	swearURLEntry := widget.NewEntry()
+	swearURLEntry.SetText(app.settings.SwearListURL)
+	swearURLEntry.SetPlaceHolder("https://gist.githubusercontent.com/.../raw/swears.txt")
+	syncBtn := widget.NewButton("Sync Now", func() {
+		listURL := strings.TrimSpace(swearURLEntry.Text)
+		if !swearkiller.IsWordListURL(listURL) {
+			dialog.ShowError(fmt.Errorf("enter the shared list's http or https URL"), app.myWindow)
+			return
+		}
+		app.fetchSharedSwears(listURL, func(words []string, err error) {
+			if err != nil {
+				dialog.ShowError(err, app.myWindow)
+				return
+			}
+			swearText.SetText(strings.Join(words, "\n"))
+		})
+	})
+	sharedList := container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel("Shared list URL"), syncBtn, swearURLEntry),
+		widget.NewLabel("With a shared list, the swear words above are replaced with it each time Swear Killer starts."),
+	)
+	lists := container.NewGridWithColumns(2,
+		container.NewBorder(container.NewBorder(nil, nil, nil, importBtn, instructions), nil, nil, nil, scroll),
+		container.NewBorder(allowInstructions, nil, nil, nil, allowScroll),
+	)
 
 	// Buttons
 	saveBtn := widget.NewButton("Save", func() {
-		// Parse the text and update swear words
-		text := strings.TrimSpace(swearText.Text)
-		if text == "" {
-			app.swears = []string{}
-		} else {
-			lines := strings.Split(text, "\n")
-			app.swears = []string{}
-			for _, line := range lines {
-				word := strings.TrimSpace(line)
-				if word != "" {
-					app.swears = append(app.swears, word)
-				}
+		phraseGap, err := strconv.ParseFloat(strings.TrimSpace(phraseGapEntry.Text), 64)
+		if err != nil || phraseGap < 0 {
+			dialog.ShowError(fmt.Errorf("phrase gap must be zero or a positive number of seconds"), app.myWindow)
+			return
+		}
+		confidencePercent, err := strconv.ParseFloat(strings.TrimSpace(confidenceEntry.Text), 64)
+		if err != nil || confidencePercent < 0 || confidencePercent > 100 {
+			dialog.ShowError(fmt.Errorf("confidence must be a percentage from 0 to 100"), app.myWindow)
+			return
+		}
+		minConfidence := confidencePercent / 100
+		fadeMS, err := strconv.ParseFloat(strings.TrimSpace(fadeEntry.Text), 64)
+		if err != nil || fadeMS < 0 {
+			dialog.ShowError(fmt.Errorf("fade must be zero or a positive number of milliseconds"), app.myWindow)
+			return
+		}
+		threads, err := strconv.Atoi(strings.TrimSpace(threadsEntry.Text))
+		if err != nil || threads < 0 {
+			dialog.ShowError(fmt.Errorf("threads must be zero or a positive whole number"), app.myWindow)
+			return
+		}
+		beam, err := strconv.Atoi(strings.TrimSpace(beamEntry.Text))
+		if err != nil || beam < 0 {
+			dialog.ShowError(fmt.Errorf("beam size must be zero or a positive whole number"), app.myWindow)
+			return
+		}
+		cacheAge, errAge := strconv.ParseFloat(strings.TrimSpace(cacheAgeEntry.Text), 64)
+		cacheSize, errSize := strconv.ParseFloat(strings.TrimSpace(cacheSizeEntry.Text), 64)
+		if errAge != nil || errSize != nil || cacheAge < 0 || cacheSize < 0 {
+			dialog.ShowError(fmt.Errorf("cache limits must be zero or positive numbers"), app.myWindow)
+			return
+		}
+		swearURL := strings.TrimSpace(swearURLEntry.Text)
+		if swearURL != "" && !swearkiller.IsWordListURL(swearURL) {
+			dialog.ShowError(fmt.Errorf("the shared list URL must start with http:// or https://"), app.myWindow)
+			return
+		}
+		nameTemplate := strings.TrimSpace(nameTemplateEntry.Text)
+		if err := swearkiller.NameTemplate(nameTemplate).Validate(); err != nil {
+			dialog.ShowError(err, app.myWindow)
+			return
+		}
+		notify := notifyConfig()
+		if err := notify.Validate(); err != nil {
+			dialog.ShowError(err, app.myWindow)
+			return
+		}
+		refresh := swearkiller.LibraryRefresh{
+			Plex:        strings.TrimSpace(plexEntry.Text),
+			PlexToken:   strings.TrimSpace(plexTokenEntry.Text),
+			Jellyfin:    strings.TrimSpace(jellyfinEntry.Text),
+			JellyfinKey: strings.TrimSpace(jellyfinKeyEntry.Text),
+			PathMap:     parseWordLines(mediaPathMapEntry.Text),
+		}
+		if err := refresh.Validate(); err != nil {
+			dialog.ShowError(err, app.myWindow)
+			return
+		}
+
+		// Parse the text areas and update the word lists
+		app.swears = parseWordLines(swearText.Text)
+		app.settings.Allowlist = parseWordLines(allowText.Text)
+		if slices.Equal(app.settings.Allowlist, swearkiller.DefaultAllowlist) {
+			app.settings.Allowlist = nil // Keep following the built-in list as it grows
+		}
+
+		autoLang := autoLangCheck.Checked
+		app.settings.AutoLanguage = &autoLang
+		app.settings.PhraseGap = &phraseGap
+		app.settings.MinConfidence = &minConfidence
+		app.settings.Deobfuscate = deobfuscateCheck.Checked
+		app.settings.WholeWords = wholeWordsCheck.Checked
+		app.settings.Fuzzy = fuzzyCheck.Checked
+		app.settings.VerifyOutput = verifyCheck.Checked
+		app.settings.CheckOutput = checkOutputCheck.Checked
+		app.settings.DeleteCorrupt = deleteCorruptCheck.Checked && checkOutputCheck.Checked
+		app.settings.DesktopNotify = desktopNotifyCheck.Checked
+		app.settings.Notify = notify
+		app.settings.Refresh = refresh
+		app.settings.SwearListURL = swearURL
+		app.settings.Hooks = swearkiller.Hooks{Before: strings.TrimSpace(beforeHookEntry.Text), After: strings.TrimSpace(afterHookEntry.Text)}
+		app.settings.CensorDescriptions = descriptionsCheck.Checked
+		app.settings.Fade = fadeMS / 1000
+		app.settings.KeepOriginal = keepOriginalCheck.Checked
+		app.settings.Stash = stashCheck.Checked
+		app.settings.SkipCleaned = skipCleanedCheck.Checked
+		app.settings.NameTemplate = nameTemplate
+		for policy, label := range overwriteLabels {
+			if label == overwriteSelect.Selected {
+				app.settings.Overwrite = policy
+			}
+		}
+		app.settings.LowPriority = lowPriorityCheck.Checked
+		app.settings.Threads = threads
+		swearkiller.SetLowPriority(app.settings.LowPriority)
+		app.settings.RapidReview = rapidReviewCheck.Checked
+		app.settings.EditSegments = editSegmentsCheck.Checked
+		app.settings.Profile = ""
+		if profileSelect.Selected != customProfile {
+			app.settings.Profile = profileSelect.Selected
+		}
+		app.settings.WhisperModel = modelSizeSelect.Selected
+		app.settings.WhisperModelDir = strings.TrimSpace(modelDirEntry.Text)
+		app.settings.WhisperDevice = deviceSelect.Selected
+		app.settings.WhisperLanguage = strings.TrimSpace(whisperLangEntry.Text)
+		app.settings.WhisperBeam = beam
+		app.settings.OCRLanguage = strings.TrimSpace(ocrLangEntry.Text)
+		app.settings.CacheMaxAgeDays = &cacheAge
+		app.settings.CacheMaxSizeGB = &cacheSize
+		if logFile := strings.TrimSpace(logFileEntry.Text); logFile != app.settings.LogFile {
+			app.settings.LogFile = logFile
+			app.openLogFile()
+		}
+		app.settings.ExtraLanguages = nil
+		for _, code := range swearkiller.BuiltinLanguages() {
+			if check, ok := languageChecks[code]; ok && check.Checked {
+				app.settings.ExtraLanguages = append(app.settings.ExtraLanguages, code)
 			}
 		}
 
+		if app.autoOutput.Checked {
+			app.generateAutoOutputPath() // The template or profile may have changed
+		}
+
 		// Save to file
 		if err := app.saveSettings(); err != nil {
 			dialog.ShowError(err, app.myWindow)
@@ -917,9 +4810,10 @@ func (app *SwearKillerApp) showSettings() {
 	})
 
 	resetBtn := widget.NewButton("Reset to Defaults", func() {
-		// Reset to default swear words
-		app.swears = []string{"asshole", "cunt", "shit", "fuck", "fucker", "mother fucker", "bullshit", "fucking", "shithead", "cock", "jesus", "christ", "jesus christ", "goddammit", "goddamn", "god damn", "bitch", "dickhead"}
+		// Reset to default swear words and allowlist
+		app.swears = append([]string{}, swearkiller.DefaultSwears...)
 		swearText.SetText(strings.Join(app.swears, "\n"))
+		allowText.SetText(strings.Join(swearkiller.DefaultAllowlist, "\n"))
 	})
 
 	cancelBtn := widget.NewButton("Cancel", func() {
@@ -929,33 +4823,89 @@ func (app *SwearKillerApp) showSettings() {
 	buttonContainer := container.NewHBox(saveBtn, resetBtn, cancelBtn)
 
 	content := container.NewVBox(
-		instructions,
-		scroll,
+		lists,
+		sharedList,
+		autoLangCheck,
+		languageRow,
+		deobfuscateCheck,
+		wholeWordsCheck,
+		fuzzyCheck,
+		verifyCheck,
+		checkOutputCheck,
+		container.NewPadded(deleteCorruptCheck),
+		descriptionsCheck,
+		fadeRow,
+		keepOriginalCheck,
+		stashCheck,
+		rapidReviewCheck,
+		editSegmentsCheck,
+		skipCleanedCheck,
+		nameTemplateRow,
+		overwriteRow,
+		lowPriorityCheck,
+		threadsRow,
+		phraseGapRow,
+		confidenceRow,
+		profileRow,
+		logFileRow,
+		transcription,
+		ocrSettings,
+		notifications,
+		hooks,
+		mediaServers,
+		caches,
 		buttonContainer,
 	)
 
 	// Create and show dialog
 	settingsDialog := dialog.NewCustom("Swear Words Settings", "Close", content, app.myWindow)
-	settingsDialog.Resize(fyne.NewSize(500, 450))
+	settingsDialog.Resize(fyne.NewSize(800, 640))
 	settingsDialog.Show()
 }
 
 func main() {
+	// Parsed leniently, as some desktops start apps with arguments of their own
+	flags := flag.NewFlagSet("swear-killer-gui", flag.ContinueOnError)
+	settingsFlag := flags.String("settings", "", "Settings file to use instead of the usual one (also "+swearkiller.SettingsEnv+")")
+	if err := flags.Parse(os.Args[1:]); err == flag.ErrHelp {
+		return
+	}
+
 	myApp := app.NewWithID("com.swear-killer.app")
 	myApp.SetIcon(nil) // You can add an icon later
 
 	myWindow := myApp.NewWindow("Swear Killer")
-	myWindow.Resize(fyne.NewSize(700, 750)) // Make window narrower but taller
 
 	// Initialize app state
 	swearApp := &SwearKillerApp{
 		// Default swear words
-		swears:   []string{"asshole", "cunt", "shit", "fuck", "fucker", "mother fucker", "bullshit", "fucking", "shithead", "cock", "jesus", "christ", "jesus christ", "goddammit", "goddamn", "god damn", "bitch", "dickhead"},
+		swears:   append([]string{}, swearkiller.DefaultSwears...),
 		myWindow: myWindow,
+		logger:   swearkiller.NewLogger(nil, swearkiller.LevelDebug),
+
+		settingsFile: swearkiller.FindSettings(*settingsFlag),
 	}
 
 	// Load saved settings (will override defaults if settings file exists)
 	swearApp.loadSettings()
+	swearApp.openLogFile()
+	swearkiller.SetLowPriority(swearApp.settings.LowPriority)
+	defer swearApp.logger.Close()
+
+	// Restore the last window size
+	if swearApp.settings.WindowWidth > 0 && swearApp.settings.WindowHeight > 0 {
+		myWindow.Resize(fyne.NewSize(swearApp.settings.WindowWidth, swearApp.settings.WindowHeight))
+	} else {
+		myWindow.Resize(fyne.NewSize(700, 750)) // Make window narrower but taller
+	}
+	myWindow.SetCloseIntercept(func() {
+		size := myWindow.Canvas().Size()
+		swearApp.settings.WindowWidth = size.Width
+		swearApp.settings.WindowHeight = size.Height
+		swearApp.persistSettings()
+		swearkiller.ResumeEncodes() // Never leave an encode suspended with nothing to resume it
+		myWindow.Close()
+	})
 
 	// Create UI elements
 	title := widget.NewLabelWithStyle("Swear Killer", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
@@ -963,34 +4913,48 @@ func main() {
 	// SRT file selection (initially hidden)
 	swearApp.srtLabel = widget.NewLabel("Subtitle source will be determined after video selection")
 	swearApp.srtButton = widget.NewButton("Select SRT File", func() {
-		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		swearApp.showFileOpen(swearApp.settings.LastSubtitleDir, func(reader fyne.URIReadCloser, err error) {
 			if err != nil || reader == nil {
 				return
 			}
 			defer reader.Close()
 			swearApp.srtPath = reader.URI().Path()
+			swearApp.srtLanguage = swearkiller.LanguageFromFilename(swearApp.srtPath)
+			swearApp.srtEmbedded = false
 			swearApp.srtLabel.SetText(fmt.Sprintf("SRT: %s", reader.URI().Name()))
+			if swearkiller.IsForcedSubtitle(swearApp.srtPath) {
+				swearApp.log("⚠️ Warning: This looks like a forced track, which only covers foreign-language dialogue. Pick the full subtitle to search everything; its forced track is then searched too.")
+			}
+			for _, forced := range swearkiller.WithForcedSubtitles(swearApp.subtitlePaths())[len(swearApp.extraSRTs)+1:] {
+				swearApp.extraSRTs = append(swearApp.extraSRTs, forced)
+				swearApp.log(fmt.Sprintf("Also searching the forced track: %s", filepath.Base(forced)))
+			}
+			swearApp.updateExtraSubtitleLabel()
+			swearApp.rememberDir(&swearApp.settings.LastSubtitleDir, swearApp.srtPath)
 			swearApp.updateProcessButton()
-		}, myWindow)
+		})
 	})
 	swearApp.srtButton.Hide() // Initially hidden
+	swearApp.transcribeBtn = widget.NewButton("Transcribe Audio (no subtitle)", swearApp.transcribeAudio)
+	swearApp.transcribeBtn.Hide()
 
 	// Video file selection
 	swearApp.videoLabel = widget.NewLabel("No video file selected")
 	swearApp.videoButton = widget.NewButton("Select Video File", func() {
-		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+		swearApp.showFileOpen(swearApp.settings.LastVideoDir, func(reader fyne.URIReadCloser, err error) {
 			if err != nil || reader == nil {
 				return
 			}
 			defer reader.Close()
+			swearApp.rememberDir(&swearApp.settings.LastVideoDir, reader.URI().Path())
 			swearApp.handleVideoSelection(reader.URI().Path())
-		}, myWindow)
+		})
 	})
 
 	// Output file selection
 	swearApp.outputLabel = widget.NewLabel("Output will be auto-generated")
 	outputButton := widget.NewButton("Select Output Location", func() {
-		dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+		swearApp.newFileSave(swearApp.settings.LastOutputDir, func(writer fyne.URIWriteCloser, err error) {
 			if err != nil || writer == nil {
 				return
 			}
@@ -998,11 +4962,10 @@ func main() {
 			outputPath := writer.URI().Path()
 
 			// Ensure the output file has a proper extension
-			validExtensions := []string{".mp4", ".mkv", ".avi", ".mov", ".webm", ".flv", ".wmv", ".m4v", ".3gp"}
 			hasValidExtension := false
 			lowerPath := strings.ToLower(outputPath)
 
-			for _, ext := range validExtensions {
+			for _, ext := range append(slices.Clone(swearkiller.VideoExtensions), swearkiller.AudioExtensions...) {
 				if strings.HasSuffix(lowerPath, ext) {
 					hasValidExtension = true
 					break
@@ -1010,17 +4973,23 @@ func main() {
 			}
 
 			if !hasValidExtension {
-				outputPath += ".mp4" // Default to MP4 if no valid extension
+				// Use the chosen container's type, falling back to MP4
+				ext, err := swearkiller.ContainerExt(swearApp.videoPath, swearApp.settings.Container)
+				if err != nil {
+					ext = ".mp4"
+				}
+				outputPath += ext
 			}
 
 			swearApp.outputPath = outputPath
 			swearApp.outputLabel.SetText(fmt.Sprintf("Output: %s", writer.URI().Name()))
+			swearApp.rememberDir(&swearApp.settings.LastOutputDir, outputPath)
 			swearApp.updateProcessButton()
-		}, myWindow)
+		}).Show()
 	})
 
 	// Auto output checkbox (defined after outputButton)
-	swearApp.autoOutput = widget.NewCheck("Auto-generate output filename (adds '-CLEAN.mp4')", func(checked bool) {
+	swearApp.autoOutput = widget.NewCheck("Auto-generate output filename (named by the template in Settings)", func(checked bool) {
 		if checked {
 			outputButton.Disable()
 			swearApp.outputLabel.SetText("Output will be auto-generated")
@@ -1029,15 +4998,61 @@ func main() {
 			swearApp.outputLabel.SetText("No output file selected")
 			swearApp.outputPath = ""
 		}
+		if saved := swearApp.settings.AutoOutput == nil || *swearApp.settings.AutoOutput; saved != checked {
+			swearApp.settings.AutoOutput = &checked
+			swearApp.persistSettings()
+		}
 		swearApp.updateProcessButton()
 	})
-	swearApp.autoOutput.SetChecked(true) // Default to auto-generate
-	outputButton.Disable()               // Start disabled since auto-generate is default
+	// Default to auto-generate unless the user turned it off last time
+	autoOutput := true
+	if swearApp.settings.AutoOutput != nil {
+		autoOutput = *swearApp.settings.AutoOutput
+	}
+	swearApp.autoOutput.SetChecked(autoOutput)
+	if autoOutput {
+		outputButton.Disable()
+	} else {
+		swearApp.outputLabel.SetText("No output file selected")
+	}
+
+	// Output container, which also sets the auto-generated name's extension
+	containerLabels := map[string]string{
+		swearkiller.ContainerAuto: "Auto (MKV stays MKV, otherwise MP4)",
+		swearkiller.ContainerMP4:  "MP4",
+		swearkiller.ContainerMKV:  "MKV (keeps every subtitle and audio track)",
+		swearkiller.ContainerSame: "Same as input",
+	}
+	var containerOptions []string
+	for _, kind := range swearkiller.Containers {
+		containerOptions = append(containerOptions, containerLabels[kind])
+	}
+	swearApp.containerSelect = widget.NewSelect(containerOptions, func(selected string) {
+		for kind, label := range containerLabels {
+			if label != selected || kind == cmp.Or(swearApp.settings.Container, swearkiller.ContainerAuto) {
+				continue
+			}
+			swearApp.settings.Container = kind
+			swearApp.persistSettings()
+			if swearApp.autoOutput.Checked {
+				swearApp.generateAutoOutputPath()
+			} else if swearApp.outputPath != "" && swearApp.videoPath != "" {
+				if outputPath, err := swearkiller.ApplyContainer(swearApp.videoPath, swearApp.outputPath, kind); err == nil {
+					swearApp.outputPath = outputPath
+					swearApp.outputLabel.SetText(fmt.Sprintf("Output: %s", filepath.Base(outputPath)))
+				}
+			}
+		}
+	})
+	swearApp.containerSelect.SetSelected(containerLabels[cmp.Or(swearApp.settings.Container, swearkiller.ContainerAuto)])
 
 	// Offset control
 	offsetLabel := widget.NewLabel("Time Offset (seconds):")
 	swearApp.offsetEntry = widget.NewEntry()
 	swearApp.offsetEntry.SetPlaceHolder("0.0 (negative = earlier, positive = later)")
+	if swearApp.settings.LastOffset != 0 {
+		swearApp.offsetEntry.SetText(strconv.FormatFloat(swearApp.settings.LastOffset, 'f', -1, 64))
+	}
 
 	// Process button
 	swearApp.processBtn = widget.NewButton("Generate FFmpeg Command", swearApp.processVideo)
@@ -1047,6 +5062,59 @@ func main() {
 	swearApp.executeBtn = widget.NewButton("Execute FFmpeg", swearApp.executeFFmpeg)
 	swearApp.executeBtn.Disable()
 
+	// Bleep tone muting for already-censored TV edits
+	swearApp.muteBleepsCheck = widget.NewCheck("Also censor existing bleep tones (scans the audio, slower)", nil)
+	swearApp.bleepActionSelect = widget.NewSelect([]string{bleepActionSilence, bleepActionTone}, nil)
+	swearApp.bleepActionSelect.SetSelected(bleepActionSilence)
+	swearApp.edlRemapSelect = widget.NewSelect([]string{edlRemapOff, edlRemapCut, edlRemapInsert}, nil)
+	swearApp.edlRemapSelect.SetSelected(edlRemapOff)
+	swearApp.remapLabel = widget.NewLabel("No remap table")
+	remapButton := widget.NewButton("Remap Table...", swearApp.chooseRemapFile)
+	remapClearButton := widget.NewButton("Clear", swearApp.clearRemapFile)
+	swearApp.extraSRTLabel = widget.NewLabel("No extra tracks")
+	extraSRTButton := widget.NewButton("Add Track...", swearApp.addExtraSubtitle)
+	extraSRTClearButton := widget.NewButton("Clear", swearApp.clearExtraSubtitles)
+	swearApp.skipAdsCheck = widget.NewCheck("Skip commercial breaks (DVR recordings; uses a Comskip .edl next to the video if there is one)", nil)
+
+	// Preview controls
+	swearApp.previewEntry = widget.NewEntry()
+	swearApp.previewEntry.SetText("2")
+	swearApp.previewBtn = widget.NewButton("Render Preview", swearApp.renderPreview)
+	swearApp.previewBtn.Disable()
+
+	// Preview player, for checking the offset once a command is generated
+	swearApp.playerBtn = widget.NewButton("Preview Player", swearApp.showPlayer)
+	swearApp.playerBtn.Disable()
+
+	// Spreadsheet of every match, as an audit trail
+	swearApp.exportMatchesBtn = widget.NewButton("Export Matches", swearApp.exportMatches)
+	swearApp.exportMatchesBtn.Disable()
+
+	// What the built-in profiles would mute, from the last scan
+	swearApp.compareBtn = widget.NewButton("Compare Profiles", swearApp.showProfileComparison)
+	swearApp.compareBtn.Disable()
+
+	// Every block of the last scan, to search and mute by hand
+	swearApp.blocksBtn = widget.NewButton("Subtitle Blocks", swearApp.showSubtitleBlocks)
+	swearApp.blocksBtn.Disable()
+
+	// Add, delete and retime segments by hand
+	swearApp.editSegmentsBtn = widget.NewButton("Edit Segments", swearApp.editSegments)
+	swearApp.editSegmentsBtn.Disable()
+
+	// Save the session to pick it up later, or open one saved before
+	swearApp.saveProjectBtn = widget.NewButton("Save Project", swearApp.saveProject)
+	swearApp.saveProjectBtn.Disable()
+	openProjectBtn := widget.NewButton("Open Project", swearApp.openProject)
+
+	// Add to queue button
+	swearApp.addToQueueBtn = widget.NewButton("Add to Queue", swearApp.addCurrentToQueue)
+	swearApp.addToQueueBtn.Disable()
+
+	// Content advisory button
+	swearApp.advisoryBtn = widget.NewButton("Content Advisory", swearApp.showAdvisory)
+	swearApp.advisoryBtn.Disable()
+
 	// Settings button
 	swearApp.settingsBtn = widget.NewButton("Settings", swearApp.showSettings)
 
@@ -1061,36 +5129,75 @@ func main() {
 	swearApp.progressLabel = widget.NewLabel("")
 	swearApp.progressLabel.Hide()
 
+	// Stops the encode under way and removes its unfinished output
+	swearApp.cancelBtn = widget.NewButton("Cancel", swearApp.cancelRunningEncode)
+	swearApp.cancelBtn.Hide()
+
+	// Segment timeline, shown once a command is generated
+	swearApp.timeline = newSegmentTimeline(swearApp.hoverTimeline, swearApp.playSegment)
+	swearApp.timelineLabel = widget.NewLabel("")
+	swearApp.timelineLabel.Wrapping = fyne.TextWrapWord
+	swearApp.timeline.Hide()
+	swearApp.timelineLabel.Hide()
+
 	// Log text area
 	swearApp.logText = widget.NewMultiLineEntry()
 	swearApp.logText.SetPlaceHolder("Process log will appear here...")
 	swearApp.logText.Wrapping = fyne.TextWrapWord // Enable word wrapping to prevent horizontal scroll
 	logScroll := container.NewScroll(swearApp.logText)
 	logScroll.SetMinSize(fyne.NewSize(500, 400)) // Narrower width, taller height
+	swearApp.logLevelSelect = widget.NewSelect(logLevels, func(selected string) {
+		swearApp.settings.LogLevel = selected
+		swearApp.refreshLog()
+	})
+	if slices.Contains(logLevels, swearApp.settings.LogLevel) {
+		swearApp.logLevelSelect.Selected = swearApp.settings.LogLevel
+	} else {
+		swearApp.logLevelSelect.Selected = "Info"
+	}
 
 	// Layout
 	fileSection := container.NewVBox(
 		swearApp.videoButton, swearApp.videoLabel,
-		swearApp.srtButton, swearApp.srtLabel,
+		swearApp.srtButton, swearApp.transcribeBtn, swearApp.srtLabel,
+		container.NewHBox(widget.NewLabel("Extra subtitle tracks:"), extraSRTButton, extraSRTClearButton, swearApp.extraSRTLabel),
 		swearApp.autoOutput,
+		container.NewHBox(widget.NewLabel("Container:"), swearApp.containerSelect),
 		outputButton, swearApp.outputLabel,
 	)
 
 	offsetSection := container.NewVBox(
 		offsetLabel,
 		swearApp.offsetEntry,
+		container.NewHBox(swearApp.muteBleepsCheck, widget.NewLabel("Replace with:"), swearApp.bleepActionSelect),
+		swearApp.skipAdsCheck,
+		container.NewHBox(widget.NewLabel("Comskip EDL timing:"), swearApp.edlRemapSelect),
+		container.NewHBox(widget.NewLabel("Edited cut:"), remapButton, remapClearButton, swearApp.remapLabel),
+		container.NewBorder(nil, nil, widget.NewLabel("Preview length (minutes):"), swearApp.previewBtn, swearApp.previewEntry),
 	)
 
 	buttonSection := container.NewHBox(
 		swearApp.processBtn,
 		swearApp.executeBtn,
+		swearApp.newPauseButton(),
+		swearApp.playerBtn,
+		swearApp.exportMatchesBtn,
+		swearApp.compareBtn,
+		swearApp.blocksBtn,
+		swearApp.editSegmentsBtn,
+		swearApp.saveProjectBtn,
+		openProjectBtn,
+		swearApp.addToQueueBtn,
+		swearApp.advisoryBtn,
 		swearApp.settingsBtn,
 	)
 
 	progressSection := container.NewVBox(
 		swearApp.progressBar,
 		swearApp.realProgressBar,
-		swearApp.progressLabel,
+		container.NewHBox(swearApp.progressLabel, swearApp.cancelBtn),
+		swearApp.timeline,
+		swearApp.timelineLabel,
 	)
 
 	content := container.NewVBox(
@@ -1103,10 +5210,23 @@ func main() {
 		buttonSection,
 		progressSection,
 		widget.NewSeparator(),
-		widget.NewLabel("Output Log:"),
+		container.NewHBox(widget.NewLabel("Output Log:"), widget.NewLabel("Show:"), swearApp.logLevelSelect),
 		logScroll,
 	)
 
-	myWindow.SetContent(container.NewPadded(content))
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Clean Video", content),
+		container.NewTabItem("Job Queue", swearApp.buildQueuePanel()),
+		container.NewTabItem("History", swearApp.buildHistoryPanel()),
+		container.NewTabItem("Library", swearApp.buildLibraryPanel()),
+	)
+
+	myWindow.SetContent(container.NewPadded(tabs))
+	swearApp.logAt(swearkiller.LevelDebug, fmt.Sprintf("Settings: %s (%s)", swearApp.settingsFile.Path, swearApp.settingsFile.Source))
+	if swearApp.settingsNote != "" {
+		swearApp.log(swearApp.settingsNote)
+	}
+	swearApp.pruneCaches()
+	swearApp.syncSwearList()
 	myWindow.ShowAndRun()
 }
@@ -1,237 +1,3478 @@
 package main
 
 import (
-	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
-	"regexp"
-	"sort"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
+
+	"swear-killer/swearkiller"
 )
 
-// Segment represents a time range for muting audio
-type Segment struct {
-	Start float64 // Start time in seconds
-	End   float64 // End time in seconds
-}
-
-// parseSRTTime converts SRT timestamp (e.g., "00:01:23,456") to seconds
-func parseSRTTime(srtTime string) (float64, error) {
-	// Replace comma with period for parsing milliseconds
-	srtTime = strings.Replace(srtTime, ",", ".", 1)
-	// Parse as duration (HH:MM:SS.sss)
-	d, err := time.Parse("15:04:05.000", srtTime)
-	if err != nil {
-		return 0, fmt.Errorf("failed to parse SRT time %s: %v", srtTime, err)
-	}
-	// Convert to seconds
-	seconds := float64(d.Hour()*3600+d.Minute()*60+d.Second()) + float64(d.Nanosecond())/1e9
-	return seconds, nil
-}
-
-// findSwearTimestamps searches an SRT file for swear words and returns mute segments
-func findSwearTimestamps(srtPath string, swears []string, offset float64) ([]Segment, error) {
-	file, err := os.Open(srtPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open SRT file: %v", err)
-	}
-	defer file.Close()
-
-	var segments []Segment
-	var currentStart, currentEnd float64
-	var inSubtitleBlock bool
-	var subtitleText strings.Builder
-	srtTimePattern := regexp.MustCompile(`(\d{2}:\d{2}:\d{2},\d{3})\s*-->\s*(\d{2}:\d{2}:\d{2},\d{3})`)
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			// End of a subtitle block
-			if inSubtitleBlock {
-				// Check for swears in the collected subtitle text
-				text := strings.ToLower(subtitleText.String())
-				for _, swear := range swears {
-					lowerSwear := strings.ToLower(swear)
-					if strings.Contains(text, lowerSwear) {
-						// Apply offset to timestamps
-						adjustedStart := currentStart + offset
-						adjustedEnd := currentEnd + offset
-						// Ensure timestamps are non-negative
-						if adjustedStart < 0 || adjustedEnd < 0 {
-							fmt.Printf("Warning: Offset %f makes segment (%f, %f) negative, skipping\n", offset, currentStart, currentEnd)
-							continue
-						}
-						segments = append(segments, Segment{Start: adjustedStart, End: adjustedEnd})
-						break
-					}
-				}
-				inSubtitleBlock = false
-				subtitleText.Reset()
-			}
-			continue
+// logger reports progress, warnings and errors; results like the FFmpeg command and
+// reports are printed directly so they stay on stdout however quiet the log is
+var logger = swearkiller.NewLogger(os.Stdout, swearkiller.LevelInfo)
+
+// logFlags are the logging options shared by the CLI, serve and headless modes
+type logFlags struct {
+	verbose *bool
+	quiet   *bool
+	file    *string
+}
+
+// addLogFlags registers --verbose, --quiet and --log-file on fs
+func addLogFlags(fs *flag.FlagSet) logFlags {
+	return logFlags{
+		verbose: fs.Bool("verbose", false, "Also show debug messages"),
+		quiet:   fs.Bool("quiet", false, "Only show warnings and errors (results are still printed)"),
+		file:    fs.String("log-file", "", "Also append every message, including debug ones, to this file"),
+	}
+}
+
+// apply sets up the logger from the flags
+func (f logFlags) apply() error {
+	switch {
+	case *f.verbose:
+		logger.SetLevel(swearkiller.LevelDebug)
+	case *f.quiet:
+		logger.SetLevel(swearkiller.LevelWarn)
+	}
+	if *f.file != "" {
+		return logger.OpenFile(*f.file, swearkiller.LevelDebug)
+	}
+	return nil
+}
+
+// notifier gets a notification when a job or batch finishes, as the notification flags say
+var notifier swearkiller.NotifyConfig
+
+// notifyFlags are the notification options shared by the CLI, headless, batch, serve and
+// watch modes
+type notifyFlags struct {
+	webhook, ntfy               *string
+	pushoverToken, pushoverUser *string
+	emailTo, emailFrom          *string
+	smtpServer, smtpUser        *string
+}
+
+// addNotifyFlags registers the notification flags on fs. The SMTP password is only read
+// from the environment, so it doesn't show up in process listings.
+func addNotifyFlags(fs *flag.FlagSet) notifyFlags {
+	return notifyFlags{
+		webhook:       fs.String("notify-webhook", "", "POST a JSON notification to this URL when done"),
+		ntfy:          fs.String("notify-ntfy", "", "Send a notification to this ntfy topic URL when done, like https://ntfy.sh/my-encodes"),
+		pushoverToken: fs.String("notify-pushover-token", "", "Pushover application token, to get a Pushover notification when done"),
+		pushoverUser:  fs.String("notify-pushover-user", "", "Pushover user key to notify"),
+		emailTo:       fs.String("notify-email", "", "Email these addresses (separated by commas) when done"),
+		emailFrom:     fs.String("email-from", "", "Sender address for --notify-email (default: --smtp-user)"),
+		smtpServer:    fs.String("smtp-server", "", "Mail server for --notify-email as host:port; the password is read from "+envName("smtp-password")),
+		smtpUser:      fs.String("smtp-user", "", "Login for the mail server"),
+	}
+}
+
+// apply sets up the notifier from the flags, keeping what base already sets for the flags
+// left empty
+func (f notifyFlags) apply(base swearkiller.NotifyConfig) error {
+	notifier = base
+	for _, setting := range []struct {
+		flag  *string
+		value *string
+	}{
+		{f.webhook, &notifier.Webhook}, {f.ntfy, &notifier.Ntfy},
+		{f.pushoverToken, &notifier.PushoverToken}, {f.pushoverUser, &notifier.PushoverUser},
+		{f.emailTo, &notifier.EmailTo}, {f.emailFrom, &notifier.EmailFrom},
+		{f.smtpServer, &notifier.SMTPServer}, {f.smtpUser, &notifier.SMTPUser},
+	} {
+		if *setting.flag != "" {
+			*setting.value = *setting.flag
 		}
-		if srtTimePattern.MatchString(line) && !inSubtitleBlock {
-			// Parse timestamp line
-			matches := srtTimePattern.FindStringSubmatch(line)
-			if len(matches) != 3 {
-				continue
-			}
-			start, err := parseSRTTime(matches[1])
-			if err != nil {
-				return nil, err
-			}
-			end, err := parseSRTTime(matches[2])
-			if err != nil {
-				return nil, err
+	}
+	if password := os.Getenv(envName("smtp-password")); password != "" {
+		notifier.SMTPPassword = password
+	}
+	return notifier.Validate()
+}
+
+// notify sends n where the notification flags say, logging a failure as a warning since
+// the work itself is done
+func notify(n swearkiller.Notification) {
+	if !notifier.Enabled() {
+		return
+	}
+	if err := notifier.Send(context.Background(), n); err != nil {
+		logger.Warnf("Could not send the notification: %v", err)
+	}
+}
+
+// addHookFlags registers --before-hook and --after-hook on fs
+func addHookFlags(fs *flag.FlagSet) *swearkiller.Hooks {
+	var hooks swearkiller.Hooks
+	fs.StringVar(&hooks.Before, "before-hook", "", "Shell command to run before each video; if it fails, the video isn't cleaned (it gets SK_INPUT, SK_SUBTITLE and SK_OUTPUT)")
+	fs.StringVar(&hooks.After, "after-hook", "", "Shell command to run after each video, like moving the output into a library (it also gets SK_STATUS, SK_SEGMENTS and SK_ERROR)")
+	return &hooks
+}
+
+// library holds the media servers told about each clean video, as the media server flags say
+var library swearkiller.LibraryRefresh
+
+// refreshFlags are the media server options shared by the CLI, headless, batch, serve and
+// watch modes
+type refreshFlags struct {
+	plex, plexToken       *string
+	jellyfin, jellyfinKey *string
+	pathMap               *pathList
+}
+
+// addRefreshFlags registers the Plex and Jellyfin flags on fs. The Plex token and Jellyfin
+// key are read from the environment, so they don't show up in process listings; their
+// flags are deprecated.
+func addRefreshFlags(fs *flag.FlagSet) refreshFlags {
+	f := refreshFlags{
+		plex:        fs.String("plex-url", "", "Refresh the Plex library holding each clean video, like http://plex:32400; the X-Plex-Token is read from "+envName("plex-token")),
+		plexToken:   fs.String("plex-token", "", "Deprecated: set "+envName("plex-token")+" instead"),
+		jellyfin:    fs.String("jellyfin-url", "", "Tell Jellyfin about each clean video, like http://jellyfin:8096; the API key is read from "+envName("jellyfin-key")),
+		jellyfinKey: fs.String("jellyfin-key", "", "Deprecated: set "+envName("jellyfin-key")+" instead"),
+		pathMap:     &pathList{},
+	}
+	fs.Var(f.pathMap, "media-path-map", "Where a folder on this machine is for the media server, like /mnt/media=/data (repeat for more)")
+	return f
+}
+
+// apply sets up library from the flags and the environment, keeping what base already
+// sets for the ones left empty
+func (f refreshFlags) apply(base swearkiller.LibraryRefresh) error {
+	library = base
+	for _, secret := range []struct {
+		flag  *string
+		name  string
+		value *string
+	}{
+		{f.plexToken, "plex-token", &library.PlexToken},
+		{f.jellyfinKey, "jellyfin-key", &library.JellyfinKey},
+	} {
+		env := os.Getenv(envName(secret.name))
+		if env != "" {
+			*secret.value = env
+		}
+		if *secret.flag != "" && *secret.flag != env {
+			logger.Warnf("--%s is deprecated, as anyone on this machine can see it in the process list; set %s instead", secret.name, envName(secret.name))
+		}
+	}
+	for _, setting := range []struct {
+		flag  *string
+		value *string
+	}{
+		{f.plex, &library.Plex}, {f.plexToken, &library.PlexToken},
+		{f.jellyfin, &library.Jellyfin}, {f.jellyfinKey, &library.JellyfinKey},
+	} {
+		if *setting.flag != "" {
+			*setting.value = *setting.flag
+		}
+	}
+	if len(*f.pathMap) > 0 {
+		library.PathMap = *f.pathMap
+	}
+	return library.Validate()
+}
+
+// refreshLibrary tells the media servers the flags set about a clean video, logging a
+// failure as a warning since the video is saved either way
+func refreshLibrary(path string) {
+	if !library.Enabled() {
+		return
+	}
+	if err := library.Refresh(context.Background(), path); err != nil {
+		logger.Warnf("Could not refresh the library: %v", err)
+		return
+	}
+	logger.Infof("Refreshed the media server library")
+}
+
+// readWordsFromFile reads a word list from a text file (one word per line) in any encoding
+// swearkiller.DecodeText handles; kind names the list in error messages. A URL is synced to
+// a local copy first (see swearkiller.SyncWordList). The list is checked first (see
+// checkWordList).
+func readWordsFromFile(filePath, kind, trustedKey string) ([]string, error) {
+	if swearkiller.IsWordListURL(filePath) {
+		sync, err := swearkiller.SyncWordList(context.Background(), filePath, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the %s list: %v", kind, err)
+		}
+		switch {
+		case sync.Offline != nil:
+			logger.Warnf("Using the %s list saved last time, as it couldn't be checked: %v", kind, sync.Offline)
+		case sync.Updated:
+			logger.Infof("Downloaded the latest %s list from %s", kind, filePath)
+		default:
+			logger.Debugf("The saved %s list from %s is current", kind, filePath)
+		}
+		filePath = sync.Path
+	}
+	if err := checkWordList(filePath, trustedKey); err != nil {
+		return nil, err
+	}
+	text, charset, err := swearkiller.ReadTextFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s file: %v", kind, err)
+	}
+	if charset != swearkiller.CharsetUTF8 {
+		logger.Debugf("Read %s file as %s", kind, charset)
+	}
+
+	var swears []string
+	for _, line := range strings.Split(text, "\n") {
+		swear := strings.TrimSpace(line)
+		if swear != "" {
+			swears = append(swears, swear)
+		}
+	}
+	return swears, nil
+}
+
+// checkWordList verifies a shared word list before it is used: against its .sha256 checksum
+// when there is one, and against its signature when a trusted public key is given
+func checkWordList(filePath, trustedKey string) error {
+	if err := swearkiller.VerifyChecksumFile(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if trustedKey == "" {
+		return nil
+	}
+	key, err := swearkiller.ReadPublicKeyFile(trustedKey)
+	if err != nil {
+		return err
+	}
+	comment, err := swearkiller.VerifyFile(key, filePath)
+	if err != nil {
+		return err
+	}
+	logger.Infof("%s is signed by key %s (%s)", filePath, key, strings.ReplaceAll(comment, "\t", ", "))
+	return nil
+}
+
+// resolveLanguages turns the --lang flag into the list of subtitle languages to add built-in
+// lists for. Auto-detection also uses the language in each subtitle's file name.
+func resolveLanguages(lang string, srtPaths []string, cues []swearkiller.Cue) ([]string, error) {
+	switch strings.ToLower(strings.TrimSpace(lang)) {
+	case "", "none":
+		return nil, nil
+	case "auto":
+		languages := swearkiller.SubtitleLanguages(cues, swearkiller.LanguageFromFilename(srtPaths[0]))
+		for _, path := range srtPaths[1:] {
+			if code := swearkiller.NormalizeLanguage(swearkiller.LanguageFromFilename(path)); code != "" && !slices.Contains(languages, code) {
+				languages = append(languages, code)
 			}
-			currentStart = start
-			currentEnd = end
-			inSubtitleBlock = true
-			continue
 		}
-		if inSubtitleBlock {
-			// Collect subtitle text
-			subtitleText.WriteString(line + " ")
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading SRT file: %v", err)
-	}
-	// Process the last subtitle block if it exists
-	if inSubtitleBlock {
-		text := strings.ToLower(subtitleText.String())
-		for _, swear := range swears {
-			lowerSwear := strings.ToLower(swear)
-			if strings.Contains(text, lowerSwear) {
-				// Apply offset to timestamps
-				adjustedStart := currentStart + offset
-				adjustedEnd := currentEnd + offset
-				if adjustedStart >= 0 && adjustedEnd >= 0 {
-					segments = append(segments, Segment{Start: adjustedStart, End: adjustedEnd})
-				} else {
-					fmt.Printf("Warning: Offset %f makes segment (%f, %f) negative, skipping\n", offset, currentStart, currentEnd)
-				}
-				break
+		if len(languages) > 0 {
+			var names []string
+			for _, code := range languages {
+				names = append(names, swearkiller.LanguageName(code))
 			}
+			logger.Infof("Detected subtitle language(s): %s", strings.Join(names, ", "))
+		}
+		return languages, nil
+	}
+
+	var languages []string
+	for _, part := range strings.Split(lang, ",") {
+		code := swearkiller.NormalizeLanguage(part)
+		if code == "" {
+			return nil, fmt.Errorf("no built-in swear list for language %q (available: %s)", strings.TrimSpace(part), strings.Join(swearkiller.BuiltinLanguages(), ", "))
+		}
+		languages = append(languages, code)
+	}
+	return languages, nil
+}
+
+// writeAdvisory writes a content advisory for the video to advisoryPath ("-" for stdout)
+func writeAdvisory(ctx context.Context, advisoryPath, videoPath string, cues []swearkiller.Cue, matches []swearkiller.Match, breaks []swearkiller.Break, offset float64) error {
+	// Prefer the real video runtime; fall back to the end of the last subtitle
+	runtime, err := swearkiller.ProbeDuration(ctx, videoPath)
+	if err != nil && len(cues) > 0 {
+		runtime = cues[len(cues)-1].End + offset
+	}
+
+	// Describe the program alone, without any commercial breaks
+	runtime = swearkiller.ProgramTime(runtime, breaks)
+	matches = swearkiller.ProgramMatches(matches, breaks, offset)
+
+	title := strings.TrimSuffix(filepath.Base(videoPath), filepath.Ext(videoPath))
+	advisory := swearkiller.BuildAdvisory(title, matches, runtime).String()
+
+	if advisoryPath == "-" {
+		fmt.Println(advisory)
+		return nil
+	}
+	if err := os.WriteFile(advisoryPath, []byte(advisory), 0644); err != nil {
+		return err
+	}
+	logger.Infof("Content advisory written to %s", advisoryPath)
+	return nil
+}
+
+// writeMatchesExport writes every match and whether it was muted as CSV to exportPath
+// ("-" for stdout)
+func writeMatchesExport(exportPath string, items []swearkiller.ReviewItem) error {
+	if exportPath == "-" {
+		return swearkiller.WriteMatchesCSV(os.Stdout, items)
+	}
+	file, err := os.Create(exportPath)
+	if err != nil {
+		return err
+	}
+	if err := swearkiller.WriteMatchesCSV(file, items); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	logger.Infof("Matches exported to %s", exportPath)
+	return nil
+}
+
+// readCaptions reads the first readable captions in the video, merged with any extra
+// subtitle tracks
+func readCaptions(ctx context.Context, videoPath string, extraSRT []string) ([]swearkiller.Cue, error) {
+	sources, err := swearkiller.FindCaptions(ctx, videoPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no closed captions or DVB subtitles found in %s", videoPath)
+	}
+	if !sources[0].Readable() {
+		return nil, swearkiller.ErrImageCaptions
+	}
+	logger.Infof("Extracting %s from the video...", sources[0])
+	cues, report, err := swearkiller.ReadCaptions(ctx, videoPath, sources[0])
+	if err != nil {
+		return nil, err
+	}
+	if report.Skipped > 0 {
+		logger.Warnf("%s", report)
+	}
+	logger.Infof("Read %d caption line(s)", len(cues))
+	if len(extraSRT) == 0 {
+		return cues, nil
+	}
+	extra, _, err := swearkiller.ReadSubtitleTracks(extraSRT)
+	if err != nil {
+		return nil, err
+	}
+	return swearkiller.MergeTracks(cues, extra), nil
+}
+
+// readOCR reads one of the video's image subtitle tracks with OCR; track -1 picks the first
+func readOCR(ctx context.Context, videoPath string, track int, lang string) ([]swearkiller.Cue, error) {
+	if track < 0 {
+		tracks, err := swearkiller.ImageSubtitleTracks(ctx, videoPath)
+		if err != nil {
+			return nil, err
+		}
+		if len(tracks) == 0 {
+			return nil, fmt.Errorf("no PGS or VobSub subtitles found in %s", videoPath)
 		}
+		track = tracks[0].Index
+		logger.Infof("Using subtitle stream %d (%s, %s)", track, tracks[0].Codec, cmp.Or(tracks[0].Language, "unknown language"))
+	}
+	cues, err := swearkiller.OCRSubtitles(ctx, videoPath, track, swearkiller.OCROptions{Language: lang}, logger.Func(swearkiller.LevelInfo))
+	if err != nil {
+		return nil, fmt.Errorf("error reading image subtitles: %v", err)
+	}
+	logger.Infof("Read %d subtitle line(s) with OCR; check the matches, as OCR can misread words", len(cues))
+	return cues, nil
+}
+
+// remapCues moves subtitle timestamps onto the video's timeline using a Comskip EDL
+func remapCues(cues []swearkiller.Cue, videoPath, edlPath string, mode swearkiller.RemapMode) ([]swearkiller.Cue, error) {
+	if edlPath == "" {
+		edlPath = swearkiller.EDLPathFor(videoPath)
+	}
+	if edlPath == "" {
+		return nil, fmt.Errorf("no EDL file found; pass one with --edl")
+	}
+	breaks, err := swearkiller.ReadEDLFile(edlPath)
+	if err != nil {
+		return nil, err
+	}
+	remapped := swearkiller.RemapCues(cues, breaks, mode)
+	logger.Infof("Remapped subtitle timestamps around %d commercial break(s) from %s", len(breaks), edlPath)
+	if dropped := len(cues) - len(remapped); dropped > 0 {
+		logger.Infof("Dropped %d subtitle line(s) that were inside the cut commercials", dropped)
+	}
+	return remapped, nil
+}
+
+// pathList is a flag that can be given more than once, collecting a path each time
+type pathList []string
+
+func (p *pathList) String() string     { return strings.Join(*p, ", ") }
+func (p *pathList) Set(v string) error { *p = append(*p, v); return nil }
+func (p *pathList) Get() any           { return []string(*p) }
+
+// explicitFlags returns the names of the flags given on the command line
+func explicitFlags(fs *flag.FlagSet) map[string]bool {
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	return explicit
+}
+
+// addTimingFlags adds the flags for subtitles that drift out of sync, which
+// applyTimingFlags reads
+func addTimingFlags(fs *flag.FlagSet) (offsetStart, offsetEnd *float64, fpsRatio *string) {
+	offsetStart = fs.Float64("offset-start", 0, "Offset in seconds at the first subtitle line, for subtitles that drift (use with --offset-end instead of --offset)")
+	offsetEnd = fs.Float64("offset-end", 0, "Offset in seconds at the last subtitle line; offsets in between are interpolated")
+	fpsRatio = fs.String("fps-ratio", "", "Frame rate the subtitle was timed for and the video's, like '23.976:25' or '24000/1001:25', to rescale every timestamp")
+	return offsetStart, offsetEnd, fpsRatio
+}
+
+// applyTimingFlags checks --offset-start and --offset-end, which go together instead of
+// --offset. When given, the offset becomes the start one and the drift between them is
+// returned.
+func applyTimingFlags(fs *flag.FlagSet, offset, offsetStart, offsetEnd *float64) (drift float64, set bool, err error) {
+	explicit := explicitFlags(fs)
+	if !explicit["offset-start"] && !explicit["offset-end"] {
+		return 0, false, nil
+	}
+	if !explicit["offset-start"] || !explicit["offset-end"] {
+		return 0, false, fmt.Errorf("--offset-start and --offset-end must be given together")
 	}
-	return segments, nil
+	if explicit["offset"] {
+		return 0, false, fmt.Errorf("use either --offset or --offset-start and --offset-end, not both")
+	}
+	*offset = *offsetStart
+	return *offsetEnd - *offsetStart, true, nil
 }
 
-// mergeSegments combines overlapping or close segments (within 1 second)
-func mergeSegments(segments []Segment) []Segment {
-	if len(segments) == 0 {
-		return segments
+// applyConfig sets every flag not in explicit from a config file. The config's keys are
+// the flag names with underscores instead of dashes.
+func applyConfig(fs *flag.FlagSet, path string, explicit map[string]bool) error {
+	schema := swearkiller.ConfigSchema{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" {
+			return
+		}
+		typ := swearkiller.ConfigString
+		switch f.Value.(flag.Getter).Get().(type) {
+		case bool:
+			typ = swearkiller.ConfigBool
+		case float64, int:
+			typ = swearkiller.ConfigNumber
+		}
+		schema[strings.ReplaceAll(f.Name, "-", "_")] = typ
+	})
+
+	entries, err := swearkiller.LoadConfig(path, schema)
+	if err != nil {
+		return err
+	}
+
+	var problems swearkiller.ConfigErrors
+	for _, entry := range entries {
+		name := strings.ReplaceAll(entry.Key, "_", "-")
+		if explicit[name] {
+			continue
+		}
+		if err := fs.Set(name, entry.Value); err != nil {
+			problems = append(problems, swearkiller.ConfigError{Path: path, Line: entry.Line,
+				Message: fmt.Sprintf("invalid value %q for option %q", entry.Value, entry.Key)})
+		}
+	}
+	if len(problems) > 0 {
+		return problems
 	}
-	// Sort segments by start time
-	sort.Slice(segments, func(i, j int) bool {
-		return segments[i].Start < segments[j].Start
+	return nil
+}
+
+// envPrefix starts the environment variables that set options in headless mode
+const envPrefix = "SWEAR_KILLER_"
+
+// envName returns the environment variable for a flag, e.g. SWEAR_KILLER_MIN_CONFIDENCE
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets every flag not in explicit from its environment variable, if set
+func applyEnv(fs *flag.FlagSet, explicit map[string]bool) error {
+	var problems []string
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || explicit[f.Name] {
+			return
+		}
+		if err := fs.Set(f.Name, value); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid value %q in %s", value, envName(f.Name)))
+		}
 	})
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// verifyOutput checks the encoded output is silent during every muted segment and exits
+// with an error if any isn't
+func verifyOutput(ctx context.Context, outputVideo string, segments []swearkiller.Segment, opts swearkiller.EncodeOptions) {
+	if _, err := os.Stat(outputVideo); err != nil {
+		logger.Errorf("output video not found; run the FFmpeg command first: %v", err)
+		os.Exit(exitFailed)
+	}
+	logger.Infof("Verifying muted segments in %s...", outputVideo)
+	results, err := swearkiller.VerifyOutput(ctx, outputVideo, segments, opts)
+	exitIfInterrupted(ctx, "verifying the output")
+	if err != nil {
+		logger.Errorf("Error verifying output: %v", err)
+		os.Exit(exitFailed)
+	}
+	failed := swearkiller.FailedVerifications(results)
+	for _, result := range failed {
+		logger.Errorf("  %s", result)
+	}
+	if len(failed) > 0 {
+		logger.Errorf("Verification failed: %d of %d muted segment(s) still have audio", len(failed), len(results))
+		os.Exit(exitFailed)
+	}
+	logger.Infof("Verified: all %d muted segment(s) are silent", len(results))
+}
+
+// runCapabilities handles `swearkiller capabilities`, which reports what this machine supports
+func runCapabilities(args []string) {
+	fs := flag.NewFlagSet("capabilities", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the capabilities as JSON for front-ends and scripts")
+	fs.Parse(args)
+
+	caps := swearkiller.DetectCapabilities()
+	if *asJSON {
+		data, err := json.MarshalIndent(caps, "", "  ")
+		if err != nil {
+			logger.Errorf("Error encoding capabilities: %v", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
 
-	var merged []Segment
-	current := segments[0]
-	for i := 1; i < len(segments); i++ {
-		if segments[i].Start <= current.End+1.0 {
-			// Merge if segments overlap or are within 1 second
-			if segments[i].End > current.End {
-				current.End = segments[i].End
+	printFeatures := func(heading string, features []swearkiller.Feature) {
+		fmt.Println(heading + ":")
+		for _, f := range features {
+			status := "yes"
+			if !f.Available {
+				status = "no (needs " + strings.Join(f.Requires, ", ") + ")"
 			}
+			fmt.Printf("  %-14s %s - %s\n", f.Name, status, f.Description)
+		}
+	}
+	fmt.Println("Tools:")
+	for _, tool := range caps.Tools {
+		if tool.Available {
+			fmt.Printf("  %-14s %s\n", tool.Name, tool.Path)
 		} else {
-			merged = append(merged, current)
-			current = segments[i]
+			fmt.Printf("  %-14s not found\n", tool.Name)
+		}
+	}
+	printFeatures("Detectors", caps.Detectors)
+	printFeatures("Integrations", caps.Integrations)
+	var actions []string
+	for _, action := range caps.Actions {
+		actions = append(actions, string(action))
+	}
+	fmt.Printf("Actions: %s\n", strings.Join(actions, ", "))
+	fmt.Printf("Subtitle files: %s\n", strings.Join(caps.SubtitleFormats, ", "))
+	fmt.Printf("Embedded subtitles: %s\n", strings.Join(caps.EmbeddedFormats, ", "))
+	fmt.Printf("Swear list languages: %s\n", strings.Join(caps.Languages, ", "))
+	if len(caps.HardwareEncoders) > 0 {
+		fmt.Printf("Hardware encoders: %s\n", strings.Join(caps.HardwareEncoders, ", "))
+	} else {
+		fmt.Println("Hardware encoders: none")
+	}
+}
+
+// runDoctor handles `swearkiller doctor`, which checks the environment and prints a checklist
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	dirs := fs.String("dir", "", "Comma-separated extra directories that must be writable (e.g. the output folder or the server's --dir)")
+	urls := fs.String("url", "", "Comma-separated API endpoints that must be reachable")
+	asJSON := fs.Bool("json", false, "Print the checklist as JSON")
+	fs.Parse(args)
+
+	// The current directory gets the default output; the home directory holds the state file,
+	// and the settings file's folder is checked once it exists
+	var opts swearkiller.DoctorOptions
+	if cwd, err := os.Getwd(); err == nil {
+		opts.Dirs = append(opts.Dirs, cwd)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		opts.Dirs = append(opts.Dirs, home)
+	}
+	settings := swearkiller.FindSettings("")
+	if _, err := os.Stat(filepath.Dir(settings.Path)); err == nil && !slices.Contains(opts.Dirs, filepath.Dir(settings.Path)) {
+		opts.Dirs = append(opts.Dirs, filepath.Dir(settings.Path))
+	}
+	if dir := lastOutputDir(settings.Path); dir != "" {
+		opts.Dirs = append(opts.Dirs, dir)
+	}
+	opts.Dirs = append(opts.Dirs, splitList(*dirs)...)
+	opts.URLs = splitList(*urls)
+
+	checks := swearkiller.RunDoctor(opts)
+	if *asJSON {
+		data, _ := json.MarshalIndent(checks, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		for _, check := range checks {
+			line := fmt.Sprintf("[%s] %s", check.Status, check.Name)
+			if check.Detail != "" {
+				line += ": " + check.Detail
+			}
+			fmt.Println(line)
+		}
+	}
+	if !swearkiller.DoctorPassed(checks) {
+		if !*asJSON {
+			fmt.Println("Some checks failed")
 		}
+		os.Exit(1)
+	}
+	if !*asJSON {
+		fmt.Println("All checks passed")
 	}
-	merged = append(merged, current)
-	return merged
 }
 
-// generateFFmpegCommand creates an FFmpeg command to mute audio for the given segments
-func generateFFmpegCommand(inputVideo, outputVideo string, segments []Segment) string {
-	if len(segments) == 0 {
-		return fmt.Sprintf("No segments to mute. Copying input to output: ffmpeg -i %q -c copy %q", inputVideo, outputVideo)
+// runBenchmark handles `swearkiller benchmark`, which runs a sample through each pipeline
+// strategy and compares how long each takes, what it writes and how the picture holds up
+func runBenchmark(args []string) {
+	fs := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	video := fs.String("video", "", "Sample video or audio file")
+	srt := fs.String("srt", "", "Subtitle for the sample, to mute its real swears (default: a one-second mute every ten seconds)")
+	seconds := fs.Float64("seconds", swearkiller.DefaultBenchmarkSeconds, "How many seconds from the start to run through each strategy")
+	videoCodec := fs.String("video-codec", swearkiller.DefaultBenchmarkVideoCodec, "Encoder for the full re-encode, like libx264, libx265 or h264_nvenc")
+	logging := addLogFlags(fs)
+	fs.Parse(args)
+	if err := logging.apply(); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	if *video == "" {
+		logger.Errorf("A sample is required (--video)")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *seconds <= 0 {
+		logger.Errorf("The sample length must be positive (--seconds)")
+		os.Exit(1)
 	}
 
-	var enableConditions []string
-	for _, seg := range segments {
-		enableConditions = append(enableConditions, fmt.Sprintf("between(t,%.3f,%.3f)", seg.Start, seg.End))
+	var segments []swearkiller.Segment
+	if *srt != "" {
+		req := swearkiller.JobRequest{Video: *video, Subtitle: *srt}
+		cues, _, err := swearkiller.ReadSubtitleTracks([]string{*srt})
+		if err != nil {
+			logger.Errorf("Error reading subtitle: %v", err)
+			os.Exit(1)
+		}
+		_, matches, _, err := swearkiller.MatchJob(req, cues, swearkiller.DefaultSwears)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+		segments = swearkiller.MergeSegments(swearkiller.MatchSegments(matches, 0, logger.Func(swearkiller.LevelDebug)))
+		if len(swearkiller.LimitSegments(segments, *seconds)) == 0 {
+			logger.Warnf("No swears in the first %gs of the subtitle; the strategies only copy the sample", *seconds)
+		}
+	} else {
+		segments = swearkiller.BenchmarkSegments(*seconds)
 	}
-	// Combine conditions with '+' for a single volume filter
-	enableExpr := strings.Join(enableConditions, "+")
-	filter := fmt.Sprintf("volume=enable='%s':volume=0", enableExpr)
 
-	return fmt.Sprintf("ffmpeg -i %q -af %q -c:v copy -c:a aac %q", inputVideo, filter, outputVideo)
+	ctx, stop := interruptContext()
+	defer stop()
+	opts := swearkiller.BenchmarkOptions{Seconds: *seconds, VideoCodec: *videoCodec}
+	results, sampled, err := swearkiller.Benchmark(ctx, *video, segments, opts, logger.Func(swearkiller.LevelInfo))
+	if err != nil {
+		logger.Errorf("Benchmark stopped: %v", err)
+		os.Exit(1)
+	}
+	runtime, _ := swearkiller.ProbeDuration(ctx, *video)
+	fmt.Println(swearkiller.FormatBenchmark(results, sampled, runtime))
+	fmt.Println("Smart render is what a normal run does. A full re-encode (--video-codec) only pays off to shrink the file or change its format; an EDL (--emit-edl) is instant but only players like Kodi and MPlayer follow it.")
 }
 
-// readSwearsFromFile reads swear words from a text file (one word per line)
-func readSwearsFromFile(filePath string) ([]string, error) {
-	file, err := os.Open(filePath)
+// runExplain handles `swearkiller explain`, which says why a moment of a video was or wasn't
+// muted
+func runExplain(args []string) {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	at := fs.String("at", "", "Time to explain, as HH:MM:SS, MM:SS or seconds, like 00:42:13")
+	srt := fs.String("srt", "", "Path to the SRT subtitle file")
+	var extraSRT pathList
+	fs.Var(&extraSRT, "extra-srt", "Another subtitle track for the same video, searched as well (repeat for more)")
+	video := fs.String("video", "", "Video the subtitle is for; only needed to follow ordered chapters")
+	offset := fs.Float64("offset", 0, "Time offset in seconds to adjust subtitle timestamps")
+	offsetStart, offsetEnd, fpsRatio := addTimingFlags(fs)
+	lang := fs.String("lang", "auto", "Swear list languages: 'auto', 'none' or codes like 'es,fr'")
+	swearFile := fs.String("swears", "", "Path to a file containing swear words (one per line), or the URL of a shared list")
+	allowFile := fs.String("allow", "", "Path to a file of harmless words that contain swears (one per line)")
+	deobfuscate := fs.Bool("deobfuscate", false, "Also match disguised spellings like 'f*ck'")
+	fuzzy := fs.Bool("fuzzy", false, "Also hold words one letter away from a swear for review")
+	wholeWords := fs.Bool("whole-words", false, "Only match swears standing on their own as words")
+	phraseGap := fs.Float64("phrase-gap", swearkiller.DefaultPhraseGap, "Match phrases split across subtitle blocks up to this many seconds apart")
+	minConfidence := fs.Float64("min-confidence", swearkiller.DefaultMinConfidence, "Only mute matches at least this confident (0-1)")
+	logging := addLogFlags(fs)
+	fs.Parse(args)
+	if err := logging.apply(); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	if *at == "" || *srt == "" {
+		logger.Errorf("A time and a subtitle are required (--at, --srt)")
+		fs.Usage()
+		os.Exit(1)
+	}
+	seconds, err := swearkiller.ParseTimestamp(*at)
+	if err != nil {
+		logger.Errorf("%v (--at)", err)
+		os.Exit(1)
+	}
+	drift, drifting, err := applyTimingFlags(fs, offset, offsetStart, offsetEnd)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open swear file: %v", err)
+		logger.Errorf("%v", err)
+		os.Exit(1)
 	}
-	defer file.Close()
 
-	var swears []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		swear := strings.TrimSpace(scanner.Text())
-		if swear != "" {
-			swears = append(swears, swear)
+	req := swearkiller.JobRequest{
+		Video:          *video,
+		Subtitle:       *srt,
+		ExtraSubtitles: extraSRT,
+		Offset:         *offset,
+		FrameRateRatio: *fpsRatio,
+		Lang:           *lang,
+		Deobfuscate:    *deobfuscate,
+		WholeWords:     *wholeWords,
+		Fuzzy:          *fuzzy,
+		PhraseGap:      phraseGap,
+		MinConfidence:  minConfidence,
+	}
+	if drifting {
+		end := *offset + drift
+		req.OffsetEnd = &end
+	}
+	swears := swearkiller.DefaultSwears
+	if *swearFile != "" {
+		if swears, err = readWordsFromFile(*swearFile, "swear", ""); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading swear file: %v", err)
+	if *allowFile != "" {
+		if req.Allow, err = readWordsFromFile(*allowFile, "allowlist", ""); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
 	}
-	return swears, nil
+
+	explanation, err := swearkiller.ExplainJob(req, swears, seconds)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	fmt.Println(explanation)
 }
 
-func main() {
-	// Command-line flags
-	srtFile := flag.String("srt", "", "Path to the SRT subtitle file")
-	inputVideo := flag.String("video", "input.mp4", "Path to the input video file")
-	outputVideo := flag.String("output", "output.mp4", "Path to the output video file")
-	swearFile := flag.String("swears", "", "Path to a file containing swear words (one per line)")
-	offset := flag.Float64("offset", 0.0, "Time offset in seconds to adjust SRT timestamps (positive = subtitles too early, negative = subtitles too late)")
-	flag.Parse()
+// runRestore handles `swearkiller restore`, which undoes the censoring of a clean output
+// with its stash or the original audio track kept in it
+func runRestore(args []string) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	video := fs.String("video", "", "Clean output to restore")
+	stash := fs.String("stash", "", "Stash file saved with it (--stash); leave out if it kept the original audio (--keep-original-audio)")
+	output := fs.String("output", "", "Path for the restored file (default: <video>-RESTORED next to it)")
+	logging := addLogFlags(fs)
+	fs.Parse(args)
+	if err := logging.apply(); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	if *video == "" {
+		logger.Errorf("The clean output to restore is required (--video)")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *output == "" {
+		ext := filepath.Ext(*video)
+		*output = strings.TrimSuffix(*video, ext) + "-RESTORED" + ext
+	}
 
-	// Validate required flags
-	if *srtFile == "" {
-		fmt.Println("Error: SRT file path is required (--srt)")
-		flag.Usage()
+	ctx, stop := interruptContext()
+	defer stop()
+	restoreArgs, err := swearkiller.RestoreArgs(ctx, *video, *stash, *output)
+	if err != nil {
+		logger.Errorf("%v", err)
 		os.Exit(1)
 	}
-	if *inputVideo == "" || *outputVideo == "" {
-		fmt.Println("Error: Input and output video paths are required (--video, --output)")
-		flag.Usage()
+	logger.Infof("Restoring %s...", *output)
+	if err := swearkiller.RunFFmpegToFile(ctx, restoreArgs, 0, nil); err != nil {
+		if ctx.Err() != nil {
+			logger.Errorf("Stopped while restoring; removed the unfinished output")
+			stop()
+			os.Exit(exitInterrupted)
+		}
+		if output := swearkiller.FFmpegOutput(err); output != "" {
+			logger.Debugf("FFmpeg output:\n%s", strings.TrimSpace(output))
+		}
+		logger.Errorf("Restore failed: %v", err)
 		os.Exit(1)
 	}
+	logger.Infof("Restored file saved to %s", *output)
+}
 
-	// Default swear words (if no file provided)
-	swears := []string{"asshole", "cunt", "shit", "fuck", "fucker", "mother fucker", "bullshit", "fucking", "shithead", "cock", "jesus", "Jesus", "Christ", "christ", "Jesus Christ", "jesus christ", "Goddammit", "goddammit", "Goddamn", "goddamn", "God damn", "god damn", "bitch", "dickhead"}
+// lastOutputDir reads the GUI's last output folder from its settings file, if there is one
+func lastOutputDir(settingsPath string) string {
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		return ""
+	}
+	var settings struct {
+		LastOutputDir string `json:"last_output_dir"`
+	}
+	json.Unmarshal(data, &settings)
+	return settings.LastOutputDir
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// runServe handles `swearkiller serve`, which runs the HTTP API for submitting and tracking jobs
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	port := fs.Int("port", 8080, "Port to listen on")
+	host := fs.String("host", "127.0.0.1", "Address to listen on; the API has no login, so only use 0.0.0.0 (all interfaces) on a network you trust")
+	workDir := fs.String("dir", "swear-killer-jobs", "Directory for uploads and clean videos")
+	swearFile := fs.String("swears", "", "Swear words for jobs that don't send their own: a file (one per line) or the URL of a shared list")
+	workers := fs.Int("jobs", 1, "Number of videos to encode at the same time")
+	reviewers := fs.String("reviewers", "", "Comma-separated names held-back matches can be assigned to for review, like 'mom,dad' (empty = any name)")
+	trustedKey := fs.String("trusted-key", "", "Public key file; --swears must then carry a valid signature from it (see 'swear-killer sign')")
+	lowPriority := fs.Bool("low-priority", false, "Run FFmpeg at low CPU priority, so the machine stays responsive for other work")
+	arrReplace := fs.Bool("arr-replace", false, "Replace movies and episodes imported by Radarr or Sonarr with the clean version, instead of writing it beside them")
+	arrForce := fs.Bool("arr-force", false, "Clean Radarr and Sonarr imports even if the quality check fails")
+	arrProfile := fs.String("arr-profile", "", "Built-in profile ("+strings.Join(swearkiller.ProfileNames(), ", ")+") for Radarr and Sonarr imports")
+	var roots pathList
+	fs.Var(&roots, "root", "A library folder jobs may read videos and subtitles from and write clean videos to, besides --dir (repeat for more)")
+	var arrPathMap pathList
+	fs.Var(&arrPathMap, "arr-path-map", "Where a folder Radarr or Sonarr reports is on this machine, like /movies=/mnt/media/movies (repeat for more)")
+	hooks := addHookFlags(fs)
+	detecting := addDetectorFlags(fs)
+	logging := addLogFlags(fs)
+	notifying := addNotifyFlags(fs)
+	refreshing := addRefreshFlags(fs)
+	fs.Parse(args)
+	swearkiller.SetLowPriority(*lowPriority)
+
+	if err := logging.apply(); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	if err := notifying.apply(swearkiller.NotifyConfig{}); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	if err := refreshing.apply(swearkiller.LibraryRefresh{}); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
 
+	var swears []string
 	if *swearFile != "" {
 		var err error
-		swears, err = readSwearsFromFile(*swearFile)
+		swears, err = readWordsFromFile(*swearFile, "swear", *trustedKey)
 		if err != nil {
-			fmt.Printf("Error reading swear file: %v\n", err)
+			logger.Errorf("%v", err)
 			os.Exit(1)
 		}
 	}
 
-	// Find timestamps of swears in SRT with offset
-	segments, err := findSwearTimestamps(*srtFile, swears, *offset)
+	detectors, err := detecting.settings()
 	if err != nil {
-		fmt.Printf("Error processing SRT file: %v\n", err)
+		logger.Errorf("%v", err)
 		os.Exit(1)
 	}
-
-	// Merge overlapping or close segments
-	mergedSegments := mergeSegments(segments)
-
-	// Generate and print FFmpeg command
-	ffmpegCmd := generateFFmpegCommand(*inputVideo, *outputVideo, mergedSegments)
-	fmt.Println("Generated FFmpeg command:")
-	fmt.Println(ffmpegCmd)
+	server, err := swearkiller.NewServer(swearkiller.ServerOptions{
+		WorkDir:   *workDir,
+		Swears:    swears,
+		Workers:   *workers,
+		Logger:    logger,
+		Reviewers: splitList(*reviewers),
+		Notify:    notifier,
+		Hooks:     *hooks,
+		Detectors: detectors,
+		Refresh:   library,
+		Arr:       swearkiller.ArrOptions{Replace: *arrReplace, Force: *arrForce, Profile: *arrProfile, PathMap: arrPathMap},
+		Roots:     roots,
+	})
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	addr := net.JoinHostPort(*host, strconv.Itoa(*port))
+	displayHost := *host
+	if displayHost == "" || displayHost == "0.0.0.0" || displayHost == "::" {
+		displayHost = "localhost"
+		logger.Warnf("Listening on every network interface; anyone who can reach this machine can submit jobs and download clean videos")
+	}
+	logger.Infof("Serving the Swear Killer web UI and API on http://%s/ (jobs in %s)", net.JoinHostPort(displayHost, strconv.Itoa(*port)), *workDir)
+	if err := http.ListenAndServe(addr, server.Handler()); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+}
+
+// Exit codes for the commands that scan or clean videos: scan, mute, cut, render, subs,
+// batch and headless, so scripts can branch on the outcome with one table. The other
+// commands exit with 0 on success and 1 on failure.
+const (
+	exitClean        = 0   // Nothing was found to censor (headless and batch: the work is done)
+	exitCensored     = 1   // Swears were found and censored (or, for scan, would be)
+	exitParse        = 2   // Invalid flags, environment variables or config file, or a subtitle that can't be parsed
+	exitEncodeFailed = 3   // FFmpeg failed or isn't installed, or the output failed --check-output
+	exitNoSubtitle   = 4   // The subtitle, captions or image subtitles are missing or empty, or the video is missing
+	exitFailed       = 5   // Anything else, like an unreadable word list or a failed quality check
+	exitInterrupted  = 130 // Stopped by SIGINT or SIGTERM
+)
+
+// subtitleExitCode returns the exit code for a subtitle that couldn't be read:
+// exitNoSubtitle if it isn't there, exitParse if it is but can't be parsed
+func subtitleExitCode(err error) int {
+	if errors.Is(err, os.ErrNotExist) {
+		return exitNoSubtitle
+	}
+	return exitParse
+}
+
+// interruptContext returns a context cancelled by Ctrl+C or SIGTERM, so the work under way
+// can stop cleanly and remove its unfinished files. A second Ctrl+C exits at once.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	return ctx, stop
+}
+
+// exitIfInterrupted exits with exitInterrupted if ctx was stopped, saying what was under way
+func exitIfInterrupted(ctx context.Context, doing string) {
+	if ctx.Err() != nil {
+		logger.Errorf("Stopped while %s", doing)
+		os.Exit(exitInterrupted)
+	}
+}
+
+// failureExitCodes maps job failure classes to exit codes
+var failureExitCodes = map[swearkiller.FailureClass]int{
+	swearkiller.FailureConfig:    exitParse,
+	swearkiller.FailureInput:     exitNoSubtitle,
+	swearkiller.FailureParse:     exitParse,
+	swearkiller.FailureFFmpeg:    exitEncodeFailed,
+	swearkiller.FailureCancelled: exitInterrupted,
+}
+
+// writeScript writes a script that runs FFmpeg with args, quoted for the shell its
+// extension names, so the encode can be run on another machine
+func writeScript(path string, args []string, segments int) error {
+	shell := swearkiller.ScriptShell(path)
+	comment := fmt.Sprintf("Generated by Swear Killer: censors %d segment(s)", segments)
+	script := swearkiller.Script(shell, comment, []string{swearkiller.FFmpegCommandLine(shell, args)})
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		return err
+	}
+	if shell == swearkiller.ShellBash {
+		return os.Chmod(path, 0755) // Runnable as ./script.sh
+	}
+	return nil
+}
+
+// writeMuteEDL writes the segments as an EDL for the player, leaving the video alone
+func writeMuteEDL(path string, segments []swearkiller.Segment) {
+	file, err := os.Create(path)
+	if err == nil {
+		err = swearkiller.WriteMuteEDL(file, segments)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		logger.Errorf("Error writing EDL: %v", err)
+		os.Exit(exitFailed)
+	}
+	logger.Infof("EDL with %d segment(s) written to %s; name it like the video with .edl for Kodi to pick it up", len(segments), path)
+}
+
+// terminalProgress is the CLI's progress bar. It logs every 10% of an encode, rather than
+// every update, so logs kept by containers and terminals stay short.
+type terminalProgress struct {
+	lastPercent int
+}
+
+// logProgress returns a terminalProgress for one encode
+func logProgress() *terminalProgress {
+	return &terminalProgress{lastPercent: -1}
+}
+
+func (p *terminalProgress) SegmentsFound(count int) {
+	logger.Debugf("Censoring %d segment(s)", count)
+}
+
+func (p *terminalProgress) Encoding(fraction float64, eta time.Duration) {
+	percent := int(fraction*10) * 10
+	if percent <= p.lastPercent {
+		return
+	}
+	p.lastPercent = percent
+	bar := strings.Repeat("#", percent/10) + strings.Repeat("-", 10-percent/10)
+	if eta > 0 && percent < 100 {
+		logger.Infof("Encoding: [%s] %d%% (about %s left)", bar, percent, eta.Round(time.Second))
+	} else {
+		logger.Infof("Encoding: [%s] %d%%", bar, percent)
+	}
+}
+
+// runHeadless handles `swearkiller headless`, which cleans one video configured by
+// SWEAR_KILLER_* environment variables and an optional config file, so it runs in a
+// container without any flags. The exit code says what went wrong, and SIGTERM stops
+// FFmpeg and removes the unfinished output.
+func runHeadless(args []string) {
+	fs := flag.NewFlagSet("headless", flag.ExitOnError)
+	video := fs.String("video", "", "Path to the input video file")
+	subtitle := fs.String("subtitle", "", "Path to the SRT subtitle file")
+	var extraSubtitles pathList
+	fs.Var(&extraSubtitles, "extra-subtitle", "Another subtitle track for the same video, searched as well (repeat for more)")
+	output := fs.String("output", "", "Path to the output video file (default: named by name-template next to the video)")
+	nameTemplate := fs.String("name-template", string(swearkiller.DefaultNameTemplate), "How the output is named without an output path, with {name}, {ext}, {profile}, {severity} and {date}")
+	offset := fs.Float64("offset", 0, "Time offset in seconds to adjust subtitle timestamps")
+	offsetStart, offsetEnd, fpsRatio := addTimingFlags(fs)
+	lang := fs.String("lang", "auto", "Swear list languages: 'auto', 'none' or codes like 'es,fr'")
+	swearFile := fs.String("swears", "", "Path to a file containing swear words (one per line), or the URL of a shared list")
+	allowFile := fs.String("allow", "", "Path to a file of harmless words that contain swears (one per line)")
+	trustedKey := fs.String("trusted-key", "", "Public key file; --swears and --allow must then carry a valid signature from it")
+	deobfuscate := fs.Bool("deobfuscate", false, "Also match disguised spellings like 'f*ck'")
+	fuzzy := fs.Bool("fuzzy", false, "Also hold words one letter away from a swear for review")
+	wholeWords := fs.Bool("whole-words", false, "Only match swears standing on their own as words")
+	phraseGap := fs.Float64("phrase-gap", swearkiller.DefaultPhraseGap, "Match phrases split across subtitle blocks up to this many seconds apart")
+	minConfidence := fs.Float64("min-confidence", swearkiller.DefaultMinConfidence, "Only mute matches at least this confident (0-1)")
+	muteBleeps := fs.Bool("mute-bleeps", false, "Also censor existing bleep tones")
+	bleepAction := fs.String("bleep-action", "mute", "How to censor bleep tones: 'mute', 'tone', 'beep' or 'blur'")
+	skipCommercials := fs.Bool("skip-commercials", false, "Leave commercial breaks alone")
+	censorDescriptions := fs.Bool("censor-descriptions", false, "Also censor audio description tracks")
+	fade := fs.Float64("fade", 0, "Seconds to ramp the volume down and up around each mute (0 = cut hard)")
+	keepOriginal := fs.Bool("keep-original-audio", false, "Keep the uncensored audio as an extra track (.mkv outputs only)")
+	stash := fs.Bool("stash", false, "Save the censored audio next to the output, for 'swear-killer restore'")
+	skipCleaned := fs.Bool("skip-cleaned", false, "Exit without doing anything if the video is already a clean output, for scripts run over a whole folder")
+	lowPriority := fs.Bool("low-priority", false, "Run FFmpeg at low CPU priority, so other programs stay responsive")
+	threads := fs.Int("threads", 0, "CPU threads FFmpeg may use (0 = no limit)")
+	checkOutput := fs.Bool("check-output", false, "After encoding, check the output decodes cleanly and lasts as long as the video")
+	deleteCorrupt := fs.Bool("delete-corrupt", false, "With --check-output, delete an output that fails the check")
+	containerFlag := fs.String("container", "", "Output container: 'mkv', 'mp4', 'same' or 'auto'")
+	sanitizeMetadata := fs.Bool("sanitize-metadata", false, "Mask swears in the output's file name, title, comment and chapter names")
+	overwrite := fs.String("overwrite", string(swearkiller.OverwriteReplace), "If the output exists: 'overwrite' it, 'fail', 'rename' the new one or 'skip' the video")
+	replace := fs.Bool("replace", false, "Once the output passes --check-output (turned on by this), put it in place of the original video")
+	backup := fs.String("backup", string(swearkiller.BackupNone), "With --replace, what to do with the original: 'none', 'orig' or 'trash'")
+	force := fs.Bool("force", false, "Proceed even if the quality check fails")
+	profileName := fs.String("profile", "", "Use a built-in profile ("+strings.Join(swearkiller.ProfileNames(), ", ")+") instead of --deobfuscate, --whole-words and --min-confidence")
+	configFile := fs.String("config", "", "Read options from a YAML, TOML or JSON file")
+	hooks := addHookFlags(fs)
+	detecting := addDetectorFlags(fs)
+	logging := addLogFlags(fs)
+	notifying := addNotifyFlags(fs)
+	refreshing := addRefreshFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: swear-killer headless\n\n")
+		fmt.Fprintf(fs.Output(), "Every option can be set with an environment variable (%s), a config file or a flag; flags win over environment variables, which win over the config file.\n\n", envName("min-confidence"))
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nExit codes: %d done, %d bad configuration or unparseable subtitle, %d FFmpeg failed, %d missing video or subtitle, %d other error, %d interrupted\n",
+			exitClean, exitParse, exitEncodeFailed, exitNoSubtitle, exitFailed, exitInterrupted)
+	}
+	fs.Parse(args)
+
+	explicit := explicitFlags(fs)
+	if !explicit["config"] {
+		*configFile = os.Getenv(envName("config"))
+	}
+	if *configFile != "" {
+		if err := applyConfig(fs, *configFile, explicit); err != nil {
+			logger.Errorf("Error in config file:\n%v", err)
+			os.Exit(exitParse)
+		}
+	}
+	if err := applyEnv(fs, explicit); err != nil {
+		logger.Errorf("Error in environment:\n%v", err)
+		os.Exit(exitParse)
+	}
+	if err := logging.apply(); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	if err := notifying.apply(swearkiller.NotifyConfig{}); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	if err := refreshing.apply(swearkiller.LibraryRefresh{}); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	drift, drifting, err := applyTimingFlags(fs, offset, offsetStart, offsetEnd)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+
+	req := swearkiller.JobRequest{
+		Video:           *video,
+		Subtitle:        *subtitle,
+		ExtraSubtitles:  extraSubtitles,
+		Output:          *output,
+		Offset:          *offset,
+		FrameRateRatio:  *fpsRatio,
+		Lang:            *lang,
+		Deobfuscate:     *deobfuscate,
+		WholeWords:      *wholeWords,
+		Fuzzy:           *fuzzy,
+		PhraseGap:       phraseGap,
+		MinConfidence:   minConfidence,
+		MuteBleeps:      *muteBleeps,
+		BleepAction:     swearkiller.Action(*bleepAction),
+		SkipCommercials: *skipCommercials,
+		Force:           *force,
+
+		CensorDescriptions: *censorDescriptions,
+		Fade:               *fade,
+		KeepOriginalAudio:  *keepOriginal,
+		Stash:              *stash,
+		Threads:            *threads,
+
+		CheckOutput:   *checkOutput || *replace,
+		DeleteCorrupt: *deleteCorrupt,
+		Overwrite:     swearkiller.OverwritePolicy(*overwrite),
+
+		SanitizeMetadata: *sanitizeMetadata,
+
+		Hooks:   *hooks,
+		Refresh: library,
+		Replace: *replace,
+		Backup:  swearkiller.Backup(*backup),
+	}
+	if err := applyProfileFlag(&req, *profileName); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	if req.Detectors, err = detecting.settings(); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	if drifting {
+		end := *offset + drift
+		req.OffsetEnd = &end
+	}
+	if req.Video == "" || req.Subtitle == "" {
+		logger.Errorf("set the video and subtitle with %s and %s (or in the config file)", envName("video"), envName("subtitle"))
+		os.Exit(exitParse)
+	}
+	if req.Output == "" {
+		template := swearkiller.NameTemplate(*nameTemplate)
+		if err := template.Validate(); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(exitParse)
+		}
+		req.Output = filepath.Join(filepath.Dir(req.Video), template.Name(req.Video, req.Profile))
+	}
+	if *containerFlag != "" {
+		if req.Output, err = swearkiller.ApplyContainer(req.Video, req.Output, *containerFlag); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(exitParse)
+		}
+	}
+	swears := swearkiller.DefaultSwears
+	if *swearFile != "" {
+		if swears, err = readWordsFromFile(*swearFile, "swear", *trustedKey); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(exitFailed)
+		}
+	}
+	if *allowFile != "" {
+		if req.Allow, err = readWordsFromFile(*allowFile, "allowlist", *trustedKey); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(exitFailed)
+		}
+	}
+
+	swearkiller.SetLowPriority(*lowPriority)
+	ctx, stop := interruptContext()
+	defer stop()
+	if *skipCleaned {
+		if warning := swearkiller.CleanedWarning(ctx, req.Video); warning != "" {
+			logger.Infof("Skipped: %s", warning)
+			return
+		}
+	}
+
+	result, err := swearkiller.ProcessJob(ctx, req, swears, logger.Func(swearkiller.LevelInfo), logProgress())
+	for _, m := range result.Review {
+		logger.Warnf("Left for review: %s", m)
+	}
+	if err != nil {
+		logger.Errorf("%v", err)
+		code, ok := failureExitCodes[swearkiller.JobFailureClass(err)]
+		if !ok {
+			code = exitFailed
+		}
+		if code != exitInterrupted {
+			notify(swearkiller.JobNotification(req.Video, req.Output, 0, err))
+		}
+		stop()
+		os.Exit(code)
+	}
+	if result.Skipped {
+		return
+	}
+	logger.Infof("Done: muted %d segment(s)", len(result.Segments))
+	notify(swearkiller.JobNotification(req.Video, result.Output, len(result.Segments), nil))
+}
+
+// runBatch handles `swearkiller batch`, which cleans every video in folders or a list of
+// files, several at a time, using the subtitle beside each
+func runBatch(args []string) {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	workers := fs.Int("jobs", 1, "Number of videos to encode at the same time")
+	outputDir := fs.String("output-dir", "", "Folder for the clean videos (default: next to each video)")
+	nameTemplate := fs.String("name-template", string(swearkiller.DefaultNameTemplate), "How the clean videos are named, with {name}, {ext}, {profile}, {severity} and {date}")
+	lang := fs.String("lang", "auto", "Swear list languages: 'auto', 'none' or codes like 'es,fr'")
+	swearFile := fs.String("swears", "", "Path to a file containing swear words (one per line), or the URL of a shared list")
+	allowFile := fs.String("allow", "", "Path to a file of harmless words that contain swears (one per line)")
+	trustedKey := fs.String("trusted-key", "", "Public key file; --swears and --allow must then carry a valid signature from it")
+	deobfuscate := fs.Bool("deobfuscate", false, "Also match disguised spellings like 'f*ck'")
+	fuzzy := fs.Bool("fuzzy", false, "Also hold words one letter away from a swear for review")
+	wholeWords := fs.Bool("whole-words", false, "Only match swears standing on their own as words")
+	phraseGap := fs.Float64("phrase-gap", swearkiller.DefaultPhraseGap, "Match phrases split across subtitle blocks up to this many seconds apart")
+	minConfidence := fs.Float64("min-confidence", swearkiller.DefaultMinConfidence, "Only mute matches at least this confident (0-1)")
+	muteBleeps := fs.Bool("mute-bleeps", false, "Also censor existing bleep tones")
+	skipCommercials := fs.Bool("skip-commercials", false, "Leave commercial breaks alone")
+	censorDescriptions := fs.Bool("censor-descriptions", false, "Also censor audio description tracks")
+	fade := fs.Float64("fade", 0, "Seconds to ramp the volume down and up around each mute (0 = cut hard)")
+	stash := fs.Bool("stash", false, "Save the censored audio next to each output, for 'swear-killer restore'")
+	skipCleaned := fs.Bool("skip-cleaned", false, "Leave out videos that are already clean outputs instead of only warning")
+	lowPriority := fs.Bool("low-priority", false, "Run FFmpeg at low CPU priority, so other programs stay responsive")
+	threads := fs.Int("threads", 0, "CPU threads each encode may use (0 = no limit)")
+	checkOutput := fs.Bool("check-output", false, "After encoding, check each output decodes cleanly and lasts as long as its video")
+	deleteCorrupt := fs.Bool("delete-corrupt", false, "With --check-output, delete outputs that fail the check")
+	overwrite := fs.String("overwrite", string(swearkiller.OverwriteReplace), "If an output exists: 'overwrite' it, 'fail' that video, 'rename' the new one or 'skip' the video")
+	sanitizeMetadata := fs.Bool("sanitize-metadata", false, "Mask swears in each output's file name, title, comment and chapter names")
+	replace := fs.Bool("replace", false, "Once each output passes --check-output (turned on by this), put it in place of its original video")
+	backup := fs.String("backup", string(swearkiller.BackupNone), "With --replace, what to do with each original: 'none', 'orig' or 'trash'")
+	force := fs.Bool("force", false, "Proceed even if the quality check fails")
+	profileName := fs.String("profile", "", "Use a built-in profile ("+strings.Join(swearkiller.ProfileNames(), ", ")+") instead of --deobfuscate, --whole-words and --min-confidence")
+	hooks := addHookFlags(fs)
+	detecting := addDetectorFlags(fs)
+	logging := addLogFlags(fs)
+	notifying := addNotifyFlags(fs)
+	refreshing := addRefreshFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: swear-killer batch [flags] FOLDER-OR-VIDEO...\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if err := logging.apply(); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	if err := notifying.apply(swearkiller.NotifyConfig{}); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	if err := refreshing.apply(swearkiller.LibraryRefresh{}); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if *workers < 1 {
+		logger.Errorf("At least one job must run at a time (--jobs)")
+		os.Exit(exitParse)
+	}
+	if *threads < 0 {
+		logger.Errorf("Threads cannot be negative (--threads)")
+		os.Exit(exitParse)
+	}
+	if *deleteCorrupt && !*checkOutput {
+		logger.Errorf("--delete-corrupt needs --check-output")
+		os.Exit(exitParse)
+	}
+	if *outputDir != "" {
+		if err := os.MkdirAll(*outputDir, 0755); err != nil {
+			logger.Errorf("Error creating the output folder: %v", err)
+			os.Exit(exitFailed)
+		}
+	}
+
+	base := swearkiller.JobRequest{
+		Lang:            *lang,
+		Deobfuscate:     *deobfuscate,
+		WholeWords:      *wholeWords,
+		Fuzzy:           *fuzzy,
+		PhraseGap:       phraseGap,
+		MinConfidence:   minConfidence,
+		MuteBleeps:      *muteBleeps,
+		SkipCommercials: *skipCommercials,
+		Force:           *force,
+
+		CensorDescriptions: *censorDescriptions,
+		Fade:               *fade,
+		Stash:              *stash,
+		Threads:            *threads,
+
+		CheckOutput:   *checkOutput || *replace,
+		DeleteCorrupt: *deleteCorrupt,
+
+		SanitizeMetadata: *sanitizeMetadata,
+
+		Hooks:   *hooks,
+		Refresh: library,
+		Replace: *replace,
+	}
+	policy, err := swearkiller.ParseOverwritePolicy(*overwrite)
+	if err != nil {
+		logger.Errorf("%v (--overwrite)", err)
+		os.Exit(exitParse)
+	}
+	base.Overwrite = policy
+	if base.Backup, err = swearkiller.ParseBackup(*backup); err != nil {
+		logger.Errorf("%v (--backup)", err)
+		os.Exit(exitParse)
+	}
+	if base.Detectors, err = detecting.settings(); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	if err := applyProfileFlag(&base, *profileName); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	swears := swearkiller.DefaultSwears
+	if *swearFile != "" {
+		if swears, err = readWordsFromFile(*swearFile, "swear", *trustedKey); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(exitFailed)
+		}
+	}
+	if *allowFile != "" {
+		if base.Allow, err = readWordsFromFile(*allowFile, "allowlist", *trustedKey); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(exitFailed)
+		}
+	}
+
+	template := swearkiller.NameTemplate(*nameTemplate)
+	if err := template.Validate(); err != nil {
+		logger.Errorf("%v (--name-template)", err)
+		os.Exit(exitParse)
+	}
+	items, err := swearkiller.FindBatchItems(fs.Args(), *outputDir, template, base.Profile)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitFailed)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	skipped := 0
+	if *skipCleaned {
+		kept := items[:0]
+		for _, item := range items {
+			if warning := swearkiller.CleanedWarning(ctx, item.Video); warning != "" {
+				logger.Infof("Skipped %s: %s", filepath.Base(item.Video), warning)
+				skipped++
+				continue
+			}
+			kept = append(kept, item)
+		}
+		items = kept
+		exitIfInterrupted(ctx, "looking for clean outputs")
+	}
+	if len(items) == 0 {
+		logger.Infof("No videos to clean")
+		return
+	}
+	logger.Infof("Cleaning %d file(s), %d at a time", len(items), min(*workers, len(items)))
+	swearkiller.SetLowPriority(*lowPriority)
+
+	lastPercent := -1
+	results := swearkiller.RunBatch(ctx, base, items, swears, *workers,
+		func(item swearkiller.BatchItem, message string) {
+			logger.Infof("[%s] %s", filepath.Base(item.Video), message)
+		},
+		func(progress float64) {
+			if percent := int(progress*10) * 10; percent > lastPercent {
+				lastPercent = percent
+				logger.Infof("Batch: %d%%", percent)
+			}
+		})
+
+	failed, existing := 0, 0
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+			logger.Errorf("Failed %s: %v", r.Item.Video, r.Err)
+		case r.Result.Skipped:
+			existing++
+			logger.Infof("Skipped %s: %s already exists", r.Item.Video, r.Item.Output)
+		default:
+			logger.Infof("Cleaned %s: muted %d segment(s), %d left for review -> %s", r.Item.Video, len(r.Result.Segments), len(r.Result.Review), r.Result.Output)
+		}
+	}
+	logger.Infof("Batch finished: %d cleaned, %d failed, %d skipped", len(results)-failed-existing, failed, skipped+existing)
+	if ctx.Err() != nil {
+		stop()
+		os.Exit(exitInterrupted)
+	}
+	notify(swearkiller.BatchNotification(len(results)-failed-existing, failed, skipped+existing))
+	if failed > 0 {
+		os.Exit(exitFailed)
+	}
+}
+
+// applyProfileFlag sets the job's profile from --profile, which takes the place of the
+// matching flags
+func applyProfileFlag(req *swearkiller.JobRequest, profile string) error {
+	if profile == "" {
+		return nil
+	}
+	if req.Deobfuscate || req.WholeWords || (req.MinConfidence != nil && *req.MinConfidence != swearkiller.DefaultMinConfidence) {
+		return fmt.Errorf("--profile sets --deobfuscate, --whole-words and --min-confidence; don't pass them as well")
+	}
+	if _, err := swearkiller.FindProfile(profile); err != nil {
+		return fmt.Errorf("%v (--profile)", err)
+	}
+	req.Profile, req.MinConfidence = profile, nil
+	return nil
+}
+
+// detectorFlags pick the detectors for headless, batch and serve modes
+type detectorFlags struct {
+	use, mode, patterns, classifier, whisperModel *string
+}
+
+// addDetectorFlags registers --detectors and the options of each detector on fs
+func addDetectorFlags(fs *flag.FlagSet) detectorFlags {
+	return detectorFlags{
+		use:          fs.String("detectors", "", "Comma-separated detectors to run: "+strings.Join(swearkiller.DetectorNames, ", ")+" (default: keywords, the swear list)"),
+		mode:         fs.String("detector-mode", string(swearkiller.ChainUnion), "How several detectors combine: 'union' censors what any finds, 'intersect' only what all find"),
+		patterns:     fs.String("patterns", "", "File of regular expressions for the regex detector (one per line)"),
+		classifier:   fs.String("classifier", "", "Shell command for the classifier detector; it reads the lines as JSON on stdin and prints the ones to censor"),
+		whisperModel: fs.String("whisper-model", "", "Path to the whisper.cpp model file for the transcript detector (default: the base model in "+swearkiller.WhisperModelDir()+")"),
+	}
+}
+
+// settings returns the detector settings the flags give, reading the patterns file
+func (f detectorFlags) settings() (swearkiller.DetectorSettings, error) {
+	settings := swearkiller.DetectorSettings{
+		Use:        splitList(*f.use),
+		Mode:       swearkiller.ChainMode(*f.mode),
+		Classifier: *f.classifier,
+		Transcribe: swearkiller.TranscribeOptions{Model: *f.whisperModel},
+	}
+	if *f.patterns != "" {
+		data, err := os.ReadFile(*f.patterns)
+		if err != nil {
+			return settings, fmt.Errorf("failed to read patterns: %v", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				settings.Patterns = append(settings.Patterns, line)
+			}
+		}
+	}
+	if err := settings.Validate(); err != nil {
+		return settings, fmt.Errorf("%v (--detectors)", err)
+	}
+	return settings, nil
+}
+
+// runWatch handles `swearkiller watch`, which runs until stopped, cleaning videos as they
+// appear in the folders of a config file with each folder's options
+func runWatch(args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	configFile := fs.String("config", "", "YAML, TOML or JSON file mapping folders to their options (default: the first watch.yaml, .toml or .json found in the user's and then the system's swear-killer config folder)")
+	swearFile := fs.String("swears", "", "Swear words for folders that don't set their own: a file (one per line) or the URL of a shared list")
+	trustedKey := fs.String("trusted-key", "", "Public key file; --swears must then carry a valid signature from it (see 'swear-killer sign')")
+	workers := fs.Int("jobs", 0, "Videos to encode at the same time (overrides the config's jobs)")
+	listen := fs.String("listen", "", "Address to serve the status endpoint on, like 127.0.0.1:8091 (overrides the config's listen)")
+	nameTemplate := fs.String("name-template", "", "How the clean videos are named, with {name}, {ext}, {profile}, {severity} and {date} (overrides the config's name_template)")
+	lowPriority := fs.Bool("low-priority", false, "Run FFmpeg at low CPU priority, so other programs stay responsive")
+	hooks := addHookFlags(fs)
+	logging := addLogFlags(fs)
+	notifying := addNotifyFlags(fs)
+	refreshing := addRefreshFlags(fs)
+	fs.Parse(args)
+	if err := logging.apply(); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	configPath, err := swearkiller.ResolveWatchConfig(*configFile)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	config, err := swearkiller.LoadWatchConfig(configPath)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	if *workers < 0 {
+		logger.Errorf("At least one job must run at a time (--jobs)")
+		os.Exit(1)
+	}
+	if *workers > 0 {
+		config.Jobs = *workers
+	}
+	if *listen != "" {
+		config.Listen = *listen
+	}
+	if *nameTemplate != "" {
+		config.NameTemplate = swearkiller.NameTemplate(*nameTemplate)
+		if err := config.NameTemplate.Validate(); err != nil {
+			logger.Errorf("%v (--name-template)", err)
+			os.Exit(1)
+		}
+	}
+	if err := notifying.apply(config.Notify); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	config.Notify = notifier
+	if err := refreshing.apply(config.Refresh); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	config.Refresh = library
+	config.Hooks = hooks.Merge(config.Hooks)
+	var swears []string
+	if *swearFile != "" {
+		if swears, err = readWordsFromFile(*swearFile, "swear", *trustedKey); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+	}
+	swearkiller.SetLowPriority(*lowPriority)
+
+	watcher := swearkiller.NewWatcher(config, swears, logger.Func(swearkiller.LevelInfo))
+	if config.Listen != "" {
+		listener, err := net.Listen("tcp", config.Listen)
+		if err != nil {
+			logger.Errorf("Error serving the status endpoint: %v", err)
+			os.Exit(1)
+		}
+		logger.Infof("Status at http://%s/status", listener.Addr())
+		go http.Serve(listener, watcher.Handler())
+	}
+	logger.Infof("Using %s", configPath)
+	for _, folder := range config.Folders {
+		profile := folder.Profile
+		if profile == "" {
+			profile = "no profile"
+		}
+		logger.Infof("Watching %s (%s)", folder.Path, profile)
+	}
+
+	// Started by the Windows service manager, the watcher runs until the service is stopped
+	if service, err := swearkiller.RunAsService(swearkiller.DefaultServiceName, watcher.Run); service {
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+		logger.Infof("Stopped watching")
+		return
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	if err := watcher.Run(ctx); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	logger.Infof("Stopped watching")
+}
+
+// runService handles `swearkiller service install|uninstall|status`, which sets up the
+// watch command as a systemd unit or Windows service
+func runService(args []string) {
+	usage := "usage: swear-killer service install|uninstall|status [flags]"
+	if len(args) == 0 {
+		logger.Errorf("%s", usage)
+		os.Exit(1)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("service "+action, flag.ExitOnError)
+	name := fs.String("name", swearkiller.DefaultServiceName, "Name of the service")
+	configFile := fs.String("config", "", "Watch config for install (default: the first watch.yaml, .toml or .json found in the user's and then the system's swear-killer config folder)")
+	logFile := fs.String("log-file", "", "For install, also log to this file (Windows default: swear-killer-watch.log beside the config)")
+	user := fs.Bool("user", false, "Linux: a systemd user service, run while you're logged in, rather than a system-wide one (needs no sudo)")
+	runAs := fs.String("run-as", "", "Linux: the user a system-wide service runs as (default root)")
+	fs.Parse(args[1:])
+	opts := swearkiller.ServiceOptions{Name: *name, Config: *configFile, LogFile: *logFile, UserService: *user, RunAs: *runAs}
+
+	switch action {
+	case "install":
+		if err := swearkiller.InstallService(opts); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+		logger.Infof("Installed and started %s", *name)
+		printServiceState(opts)
+	case "uninstall":
+		if err := swearkiller.UninstallService(opts); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+		logger.Infof("Stopped and removed %s", *name)
+	case "status":
+		printServiceState(opts)
+	default:
+		logger.Errorf("unknown service command %q; %s", action, usage)
+		os.Exit(1)
+	}
+}
+
+// printServiceState prints whether the service is installed, enabled and running
+func printServiceState(opts swearkiller.ServiceOptions) {
+	state, err := swearkiller.QueryService(opts)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	if !state.Installed {
+		fmt.Printf("%s isn't installed\n", opts.Name)
+		return
+	}
+	enabled := "no"
+	if state.Enabled {
+		enabled = "yes"
+	}
+	fmt.Printf("Service:   %s\n", opts.Name)
+	fmt.Printf("Status:    %s\n", state.Status)
+	fmt.Printf("Automatic: %s\n", enabled)
+	fmt.Printf("Installed: %s\n", state.Location)
+	if state.Logs != "" {
+		fmt.Printf("Logs:      %s\n", state.Logs)
+	}
+}
+
+// runKeygen handles `swearkiller keygen`, which creates a key pair for signing word lists
+func runKeygen(args []string) {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	keyPath := fs.String("key", "swear-killer.key", "Where to save the secret key; the public key is saved next to it with a .pub extension")
+	force := fs.Bool("force", false, "Overwrite an existing key")
+	fs.Parse(args)
+
+	pubPath := strings.TrimSuffix(*keyPath, filepath.Ext(*keyPath)) + ".pub"
+	for _, path := range []string{*keyPath, pubPath} {
+		if _, err := os.Stat(path); err == nil && !*force {
+			logger.Errorf("%s already exists; use --force to replace it (files signed with the old key won't verify with the new one)", path)
+			os.Exit(1)
+		}
+	}
+	pub, secret, err := swearkiller.GenerateKey()
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*keyPath, secret.Encode(), 0600); err != nil {
+		logger.Errorf("Error saving secret key: %v", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(pubPath, pub.Encode(), 0644); err != nil {
+		logger.Errorf("Error saving public key: %v", err)
+		os.Exit(1)
+	}
+	logger.Infof("Secret key saved to %s; keep it private", *keyPath)
+	logger.Infof("Public key %s saved to %s; share it with the people who will import your files", pub, pubPath)
+}
+
+// runSign handles `swearkiller sign`, which writes a signature and a checksum next to each file
+func runSign(args []string) {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	keyPath := fs.String("key", "swear-killer.key", "Secret key to sign with (from 'swear-killer keygen' or 'minisign -G -W')")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		logger.Errorf("usage: swear-killer sign [--key file] FILE...")
+		os.Exit(1)
+	}
+
+	key, err := swearkiller.ReadSecretKeyFile(*keyPath)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	for _, path := range fs.Args() {
+		if err := swearkiller.SignFile(key, path); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+		logger.Infof("Signed %s (wrote %s and %s)", path, filepath.Base(path+swearkiller.SignatureExt), filepath.Base(path+swearkiller.ChecksumExt))
+	}
+}
+
+// runVerifySignature handles `swearkiller verify-signature`, which checks files against their
+// checksums and, given a public key, their signatures
+func runVerifySignature(args []string) {
+	fs := flag.NewFlagSet("verify-signature", flag.ExitOnError)
+	pubPath := fs.String("pubkey", "", "Public key of the person who signed the files (leave out to only check checksums)")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		logger.Errorf("usage: swear-killer verify-signature [--pubkey file] FILE...")
+		os.Exit(1)
+	}
+
+	var key swearkiller.PublicKey
+	if *pubPath != "" {
+		var err error
+		if key, err = swearkiller.ReadPublicKeyFile(*pubPath); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+	}
+	failed := false
+	for _, path := range fs.Args() {
+		var results []string
+		err := swearkiller.VerifyChecksumFile(path)
+		switch {
+		case err == nil:
+			results = append(results, "checksum matches")
+		case !os.IsNotExist(err):
+			fmt.Printf("[FAIL] %v\n", err)
+			failed = true
+			continue
+		}
+		if *pubPath != "" {
+			comment, err := swearkiller.VerifyFile(key, path)
+			if err != nil {
+				fmt.Printf("[FAIL] %v\n", err)
+				failed = true
+				continue
+			}
+			results = append(results, fmt.Sprintf("signed by key %s (%s)", key, strings.ReplaceAll(comment, "\t", ", ")))
+		}
+		if len(results) == 0 {
+			fmt.Printf("[FAIL] %s has no %s file to check; pass --pubkey to check its signature\n", path, swearkiller.ChecksumExt)
+			failed = true
+			continue
+		}
+		fmt.Printf("[OK] %s: %s\n", path, strings.Join(results, ", "))
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// readPlanForVideo reads a plan file and checks it was made for the video, exiting if it
+// can't be used. A plan for another release is only used with --force.
+func readPlanForVideo(planPath, videoPath string, force bool) swearkiller.Plan {
+	plan, err := swearkiller.ReadPlanFile(planPath)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitFailed)
+	}
+	checkPlanForVideo(plan, videoPath, force)
+	return plan
+}
+
+// checkPlanForVideo checks a plan was made for the video, exiting if it wasn't unless
+// force is set, and warns if nobody reviewed it
+func checkPlanForVideo(plan swearkiller.Plan, videoPath string, force bool) {
+	if err := plan.CheckVideo(videoPath); err != nil {
+		if !force {
+			logger.Errorf("%v; its times may not line up. Pass --force to use it anyway", err)
+			os.Exit(exitFailed)
+		}
+		logger.Warnf("%v; using it anyway (--force)", err)
+	}
+	logger.Infof("Using plan %s", plan)
+	if !plan.Reviewed {
+		logger.Warnf("Nobody has checked this plan by hand; verify the result before relying on it")
+	}
+}
+
+// repositoryFlags are the plan repository options shared by publish-plan and fetch-plan
+type repositoryFlags struct {
+	repo  *string
+	token *string
+}
+
+// addRepositoryFlags registers --repo and --token on fs
+func addRepositoryFlags(fs *flag.FlagSet) repositoryFlags {
+	return repositoryFlags{
+		repo:  fs.String("repo", "", "Plan repository: an http(s) URL or a shared folder (or set "+envName("repo")+")"),
+		token: fs.String("token", "", "Access token for an http(s) repository that needs one (or set "+envName("token")+")"),
+	}
+}
+
+// repository returns the plan repository from the flags and environment, exiting if none is set
+func (f repositoryFlags) repository(fs *flag.FlagSet) swearkiller.PlanRepository {
+	if err := applyEnv(fs, explicitFlags(fs)); err != nil {
+		logger.Errorf("Error in environment:\n%v", err)
+		os.Exit(1)
+	}
+	if *f.repo == "" {
+		logger.Errorf("No plan repository set; pass --repo or set %s", envName("repo"))
+		os.Exit(1)
+	}
+	return swearkiller.PlanRepository{Location: *f.repo, Token: *f.token}
+}
+
+// runPublishPlan handles `swearkiller publish-plan`, which shares plan files with a community
+// repository so others with the same release can reuse them
+func runPublishPlan(args []string) {
+	fs := flag.NewFlagSet("publish-plan", flag.ExitOnError)
+	repoFlags := addRepositoryFlags(fs)
+	reviewed := fs.Bool("reviewed", false, "Mark the plans as checked by hand, so others know they can use them as they are")
+	preview := fs.Bool("preview", false, "Print exactly what would be shared for each plan instead of publishing it")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		logger.Errorf("usage: swear-killer publish-plan [--repo location] [--reviewed] [--preview] PLAN...")
+		os.Exit(1)
+	}
+	var repo swearkiller.PlanRepository
+	if !*preview {
+		repo = repoFlags.repository(fs)
+	}
+
+	for _, path := range fs.Args() {
+		plan, err := swearkiller.ReadPlanFile(path)
+		if err != nil {
+			logger.Errorf("%s: %v", path, err)
+			os.Exit(1)
+		}
+		if *reviewed {
+			plan.Reviewed = true
+		}
+		shared, notes := plan.Anonymize()
+		if *preview {
+			data, err := swearkiller.SharedPlanJSON(plan)
+			if err != nil {
+				logger.Errorf("%s: %v", path, err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s would be shared as:\n%s", path, data)
+			if len(notes) > 0 {
+				fmt.Printf("Left out or changed: %s\n", strings.Join(notes, ", "))
+			}
+			continue
+		}
+		if len(notes) > 0 {
+			logger.Infof("%s: left out or changed %s", path, strings.Join(notes, ", "))
+		}
+		if err := repo.Publish(plan); err != nil {
+			logger.Errorf("%s: %v", path, err)
+			os.Exit(1)
+		}
+		logger.Infof("Published %s (source hash %s)", shared, shared.SourceHash[:12])
+	}
+}
+
+// runFetchPlan handles `swearkiller fetch-plan`, which downloads the community plan for each
+// video, if someone has published one
+func runFetchPlan(args []string) {
+	fs := flag.NewFlagSet("fetch-plan", flag.ExitOnError)
+	repoFlags := addRepositoryFlags(fs)
+	output := fs.String("output", "", "Where to save the plan (default: next to the video, as <video>"+swearkiller.PlanExt+"); only with a single video")
+	fs.Parse(args)
+	if fs.NArg() == 0 || (*output != "" && fs.NArg() > 1) {
+		logger.Errorf("usage: swear-killer fetch-plan [--repo location] [--output file] VIDEO...")
+		os.Exit(1)
+	}
+	repo := repoFlags.repository(fs)
+
+	missing := false
+	for _, video := range fs.Args() {
+		hash, err := swearkiller.QuickHash(video)
+		if err != nil {
+			logger.Errorf("Error hashing %s: %v", video, err)
+			os.Exit(1)
+		}
+		plan, err := repo.Fetch(hash)
+		if errors.Is(err, swearkiller.ErrPlanNotFound) {
+			logger.Warnf("%s: nobody has published a plan for this release yet", video)
+			missing = true
+			continue
+		}
+		if err != nil {
+			logger.Errorf("%s: %v", video, err)
+			os.Exit(1)
+		}
+		path := *output
+		if path == "" {
+			path = swearkiller.PlanPath(video)
+		}
+		if err := swearkiller.WritePlanFile(path, plan); err != nil {
+			logger.Errorf("Error saving plan: %v", err)
+			os.Exit(1)
+		}
+		logger.Infof("Saved plan to %s (%s)", path, plan)
+		logger.Infof("Use it with: swear-killer mute --video %s --plan %s --output <clean video>", video, path)
+	}
+	if missing {
+		os.Exit(1)
+	}
+}
+
+// runHistory handles `swearkiller history`, which lists the videos the GUI's job queue has
+// processed, with their notes and ratings, or with "note" annotates one
+func runHistory(args []string) {
+	if len(args) > 0 && args[0] == "note" {
+		runHistoryNote(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "export" {
+		runHistoryExport(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "list" {
+		args = args[1:]
+	}
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	search := fs.String("search", "", "Only list titles whose path or notes contain every one of these words")
+	statePath := fs.String("state", swearkiller.DefaultStatePath(), "History file to read")
+	fs.Parse(args)
+
+	state, err := swearkiller.LoadProcessedState(*statePath)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	entries := state.History(*search)
+	if len(entries) == 0 {
+		logger.Infof("No processed titles found")
+		return
+	}
+	for _, entry := range entries {
+		fmt.Printf("%s  %s  %s\n", entry.ProcessedAt.Local().Format("2006-01-02 15:04"), swearkiller.RatingStars(entry.Rating), entry.Title())
+		fmt.Printf("    %s -> %s\n", entry.Video, entry.OutputPath)
+		if entry.Notes != "" {
+			fmt.Printf("    Notes: %s\n", strings.ReplaceAll(entry.Notes, "\n", "\n           "))
+		}
+	}
+}
+
+// runHistoryExport handles `swearkiller history export`, which writes the whole processing
+// history as CSV or JSON for spreadsheets
+func runHistoryExport(args []string) {
+	fs := flag.NewFlagSet("history export", flag.ExitOnError)
+	output := fs.String("output", "-", "File to write, or '-' for stdout")
+	formatName := fs.String("format", "", "csv or json (default: from the --output extension, otherwise csv)")
+	search := fs.String("search", "", "Only export titles whose path or notes contain every one of these words")
+	statePath := fs.String("state", swearkiller.DefaultStatePath(), "History file to read")
+	fs.Parse(args)
+
+	if *formatName == "" {
+		*formatName = string(swearkiller.HistoryCSV)
+		if strings.EqualFold(filepath.Ext(*output), ".json") {
+			*formatName = string(swearkiller.HistoryJSON)
+		}
+	}
+	format, err := swearkiller.ParseHistoryFormat(*formatName)
+	if err != nil {
+		logger.Errorf("%v (--format)", err)
+		os.Exit(1)
+	}
+	state, err := swearkiller.LoadProcessedState(*statePath)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	entries := state.History(*search)
+
+	if *output == "-" {
+		if err := swearkiller.WriteHistory(os.Stdout, entries, format); err != nil {
+			logger.Errorf("Error exporting history: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		logger.Errorf("Error exporting history: %v", err)
+		os.Exit(1)
+	}
+	if err := swearkiller.WriteHistory(file, entries, format); err != nil {
+		file.Close()
+		logger.Errorf("Error exporting history: %v", err)
+		os.Exit(1)
+	}
+	if err := file.Close(); err != nil {
+		logger.Errorf("Error exporting history: %v", err)
+		os.Exit(1)
+	}
+	logger.Infof("Exported %d title(s) to %s", len(entries), *output)
+}
+
+// runHistoryNote handles `swearkiller history note`, which sets a processed title's notes
+// and rating
+func runHistoryNote(args []string) {
+	fs := flag.NewFlagSet("history note", flag.ExitOnError)
+	notes := fs.String("notes", "", "Notes on the clean edit, like 'still too many gaps' (replaces earlier notes)")
+	rating := fs.Int("rating", -1, fmt.Sprintf("Rating from 1 to %d stars, or 0 to clear it (default: keep the current rating)", swearkiller.MaxRating))
+	statePath := fs.String("state", swearkiller.DefaultStatePath(), "History file to update")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		logger.Errorf("usage: swear-killer history note [--notes text] [--rating n] VIDEO")
+		os.Exit(1)
+	}
+
+	state, err := swearkiller.LoadProcessedState(*statePath)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	video := fs.Arg(0)
+	current, _ := state.Lookup(video)
+	if !explicitFlags(fs)["notes"] {
+		*notes = current.Notes
+	}
+	if *rating < 0 {
+		*rating = current.Rating
+	}
+	if err := state.Annotate(video, *notes, *rating); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	logger.Infof("Saved notes for %s (%s)", filepath.Base(video), swearkiller.RatingStars(*rating))
+}
+
+// runCache handles `swearkiller cache`, which lists the cached transcripts and leftover
+// temporary folders, and `swearkiller cache prune`, which removes them by age and size
+func runCache(args []string) {
+	prune := len(args) > 0 && args[0] == "prune"
+	if len(args) > 0 && (args[0] == "prune" || args[0] == "list") {
+		args = args[1:]
+	}
+	fs := flag.NewFlagSet("cache", flag.ExitOnError)
+	dir := fs.String("dir", swearkiller.TranscriptCacheDir(), "Transcript cache folder")
+	maxAgeDays := fs.Float64("max-age-days", swearkiller.DefaultCachePolicy.MaxAge.Hours()/24, "With prune, remove items not used for this many days (0 = no limit)")
+	maxSizeGB := fs.Float64("max-size-gb", float64(swearkiller.DefaultCachePolicy.MaxBytes)/(1<<30), "With prune, then remove the least recently used items until the caches fit in this many GB (0 = no limit)")
+	dryRun := fs.Bool("dry-run", false, "With prune, list what would be removed without removing it")
+	fs.Parse(args)
+	if *maxAgeDays < 0 || *maxSizeGB < 0 {
+		logger.Errorf("--max-age-days and --max-size-gb cannot be negative")
+		os.Exit(1)
+	}
+
+	if !prune {
+		entries, err := swearkiller.CacheEntries(*dir)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+		var total int64
+		for _, entry := range entries {
+			fmt.Printf("%s  %9s  %-10s  %s\n", entry.Modified.Local().Format("2006-01-02 15:04"), swearkiller.FormatBytes(entry.Size), entry.Kind, entry.Path)
+			total += entry.Size
+		}
+		logger.Infof("%d cached item(s) using %s", len(entries), swearkiller.FormatBytes(total))
+		return
+	}
+
+	policy := swearkiller.CachePolicy{
+		MaxAge:   time.Duration(*maxAgeDays * 24 * float64(time.Hour)),
+		MaxBytes: int64(*maxSizeGB * (1 << 30)),
+	}
+	report, err := swearkiller.PruneCaches(*dir, policy, *dryRun)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	for _, entry := range report.Removed {
+		verb := "Removed"
+		if *dryRun {
+			verb = "Would remove"
+		}
+		logger.Infof("%s %s (%s, last used %s)", verb, entry.Path, swearkiller.FormatBytes(entry.Size), entry.Modified.Local().Format("2006-01-02"))
+	}
+	for _, err := range report.Failed {
+		logger.Warnf("%v", err)
+	}
+	if *dryRun {
+		logger.Infof("Dry run: %d item(s) using %s would be removed; nothing was changed", len(report.Removed), swearkiller.FormatBytes(report.Freed))
+		return
+	}
+	logger.Infof("%s", report)
+}
+
+// runWords handles `swearkiller words import`, which adds the words of public swear lists,
+// from files or URLs, to a swear list
+func runWords(args []string) {
+	usage := "usage: swear-killer words import [--into FILE] [--min-severity N] FILE-OR-URL..."
+	if len(args) == 0 || args[0] != "import" {
+		logger.Errorf("%s", usage)
+		os.Exit(1)
+	}
+	fs := flag.NewFlagSet("words import", flag.ExitOnError)
+	into := fs.String("into", "", "Swear list to add the words to, created if it doesn't exist (default: print the imported words)")
+	minSeverity := fs.Float64("min-severity", 0, "Leave out CSV words rated below this, on the list's own scale; mild, moderate and severe count as 1, 2 and 3")
+	dryRun := fs.Bool("dry-run", false, "With --into, report what would be added without changing the list")
+	fs.Parse(args[1:])
+	if fs.NArg() == 0 {
+		logger.Errorf("%s", usage)
+		os.Exit(1)
+	}
+	if *into == "" || *dryRun {
+		logger.SetOutput(os.Stderr) // The words go to stdout
+	}
+
+	var existing []string
+	if *into != "" {
+		if _, err := os.Stat(*into); err == nil {
+			var err error
+			if existing, err = readWordsFromFile(*into, "swear", ""); err != nil {
+				logger.Errorf("%v", err)
+				os.Exit(1)
+			}
+		}
+	}
+	merged := existing
+	for _, source := range fs.Args() {
+		text, err := swearkiller.ReadWordListSource(context.Background(), source)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(1)
+		}
+		list, err := swearkiller.ParseWordList(source, text, swearkiller.ImportOptions{MinSeverity: *minSeverity})
+		if err != nil {
+			logger.Errorf("%s: %v", source, err)
+			os.Exit(1)
+		}
+		var added int
+		merged, added = swearkiller.MergeWords(merged, list.Words)
+		message := fmt.Sprintf("%s: %d word(s), %d new", source, len(list.Words), added)
+		if list.Dropped > 0 {
+			message += fmt.Sprintf(", %d below the minimum severity left out", list.Dropped)
+		}
+		logger.Infof("%s", message)
+	}
+
+	if *into == "" {
+		for _, word := range merged {
+			fmt.Println(word)
+		}
+		return
+	}
+	added := len(merged) - len(existing)
+	if *dryRun {
+		for _, word := range merged[len(existing):] {
+			fmt.Println(word)
+		}
+		logger.Infof("Dry run: %d word(s) would be added to %s", added, *into)
+		return
+	}
+	if added == 0 {
+		logger.Infof("%s already has every word", *into)
+		return
+	}
+	if err := swearkiller.WriteWordList(*into, merged); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+	logger.Infof("Added %d word(s) to %s (%d in all)", added, *into, len(merged))
+	for _, ext := range []string{swearkiller.SignatureExt, swearkiller.ChecksumExt} {
+		if _, err := os.Stat(*into + ext); err == nil {
+			logger.Warnf("%s no longer matches its %s file; sign it again with 'swear-killer sign'", *into, ext)
+		}
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "scan":
+		runScan(os.Args[2:])
+		return
+	case "mute":
+		runMute(os.Args[2:])
+		return
+	case "cut":
+		runCut(os.Args[2:])
+		return
+	case "subs":
+		runSubs(os.Args[2:])
+		return
+	case "render":
+		runRender(os.Args[2:])
+		return
+	case "capabilities":
+		runCapabilities(os.Args[2:])
+		return
+	case "serve":
+		runServe(os.Args[2:])
+		return
+	case "doctor":
+		runDoctor(os.Args[2:])
+		return
+	case "benchmark":
+		runBenchmark(os.Args[2:])
+		return
+	case "explain":
+		runExplain(os.Args[2:])
+		return
+	case "restore":
+		runRestore(os.Args[2:])
+		return
+	case "batch":
+		runBatch(os.Args[2:])
+		return
+	case "watch":
+		runWatch(os.Args[2:])
+		return
+	case "service":
+		runService(os.Args[2:])
+		return
+	case "headless":
+		runHeadless(os.Args[2:])
+		return
+	case "keygen":
+		runKeygen(os.Args[2:])
+		return
+	case "sign":
+		runSign(os.Args[2:])
+		return
+	case "verify-signature":
+		runVerifySignature(os.Args[2:])
+		return
+	case "publish-plan":
+		runPublishPlan(os.Args[2:])
+		return
+	case "fetch-plan":
+		runFetchPlan(os.Args[2:])
+		return
+	case "history":
+		runHistory(os.Args[2:])
+		return
+	case "cache":
+		runCache(os.Args[2:])
+		return
+	case "words":
+		runWords(os.Args[2:])
+		return
+	case "help", "-h", "-help", "--help":
+		printUsage()
+		return
+	}
+	// Flags without a command are the mute command, as the CLI had no commands before
+	if strings.HasPrefix(os.Args[1], "-") {
+		runMute(os.Args[1:])
+		return
+	}
+	logger.Errorf("Unknown command %q", os.Args[1])
+	printUsage()
+	os.Exit(2)
+}
+
+// commands are the subcommands listed by printUsage, with what each does
+var commands = []struct{ name, summary string }{
+	{"scan", "Search a video's subtitle for swears and report them, without encoding"},
+	{"mute", "Mute the swears and save a clean video"},
+	{"cut", "Cut the swears out of the video altogether"},
+	{"subs", "Save the subtitle a video is searched with as SRT, with its timing fixed"},
+	{"render", "Encode segments saved in a project, plan or JSON file, without searching again"},
+	{"batch", "Clean every video in folders, using the subtitles beside them"},
+	{"watch", "Clean videos as they appear in watched folders"},
+	{"serve", "Run the HTTP server and web UI"},
+	{"words", "Add the words of public swear lists to a swear list"},
+	{"headless", "Clean one video configured by environment variables, for containers"},
+	{"service", "Install, remove or check watch as a systemd unit or Windows service"},
+	{"explain", "Say why a moment of a video was or wasn't muted"},
+	{"restore", "Undo the censoring of a clean video"},
+	{"history", "List, annotate and export the processed titles"},
+	{"cache", "List and prune cached transcripts and leftover temporary folders"},
+	{"publish-plan", "Share plan files with a community repository"},
+	{"fetch-plan", "Download the community plan for each video"},
+	{"keygen", "Create a key pair for signing word lists"},
+	{"sign", "Sign a word list"},
+	{"verify-signature", "Check a word list's signature"},
+	{"doctor", "Check the environment and print a checklist"},
+	{"benchmark", "Compare the encoding strategies on a sample"},
+	{"capabilities", "Report what this machine supports"},
+}
+
+// printUsage lists the subcommands
+func printUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: swear-killer COMMAND [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-17s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(out, "\nRun 'swear-killer COMMAND --help' for a command's flags. Flags without a command run mute, as earlier versions did.\n")
+}
+
+// commandUsage sets fs's help to a usage line and what the command does, then its flags
+func commandUsage(fs *flag.FlagSet, usage, summary string) {
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: swear-killer %s\n\n%s\n\n", usage, summary)
+		fs.PrintDefaults()
+	}
+}
+
+// sourceFlags say where the subtitle to search comes from and how to line it up with the
+// video; scan, mute, cut and subs share them
+type sourceFlags struct {
+	srt      *string
+	extraSRT *pathList
+	video    *string
+	offset   *float64
+	config   *string
+
+	offsetStart, offsetEnd *float64
+	fpsRatio               *string
+	edl, edlRemap, remap   *string
+
+	transcribe, captions, ocr *bool
+	ocrTrack                  *int
+	ocrLang                   *string
+
+	whisperModel, whisperModelSize, whisperModelDir, whisperDevice, whisperLang *string
+	whisperBeam, whisperThreads                                                 *int
+
+	// Set by check
+	drift, frameScale float64
+	remapMode         swearkiller.RemapMode
+}
+
+// addSourceFlags registers the subtitle source and timing flags on fs
+func addSourceFlags(fs *flag.FlagSet) *sourceFlags {
+	f := &sourceFlags{extraSRT: &pathList{}}
+	f.srt = fs.String("srt", "", "Path to the SRT subtitle file")
+	fs.Var(f.extraSRT, "extra-srt", "Another subtitle track for the same video, like a forced track or another language, searched as well (repeat for more)")
+	f.video = fs.String("video", "input.mp4", "Path to the input video file")
+	f.offset = fs.Float64("offset", 0.0, "Time offset in seconds to adjust SRT timestamps (positive = subtitles too early, negative = subtitles too late)")
+	f.offsetStart, f.offsetEnd, f.fpsRatio = addTimingFlags(fs)
+	f.edl = fs.String("edl", "", "Path to a Comskip EDL file listing commercial breaks (implies --skip-commercials unless --edl-remap is 'cut')")
+	f.edlRemap = fs.String("edl-remap", "", "Remap subtitle timestamps with the EDL: 'cut' if the subtitle is from the broadcast but the video has the commercials cut out, 'insert' for the reverse")
+	f.remap = fs.String("remap", "", "Path to a remap table of 'source_start source_end -> target_start target_end' lines that moves subtitle times onto an edited cut of the video")
+	f.config = fs.String("config", "", "Read options from a YAML, TOML or JSON file; keys are the option names with underscores (phrase_gap: 2) and command-line flags take precedence")
+	f.transcribe = fs.Bool("transcribe", false, "Find swears in the video's speech with Whisper instead of a subtitle (resumes from cached chunks if interrupted)")
+	f.captions = fs.Bool("captions", false, "Use the video's own captions instead of a subtitle file: closed captions (CEA-608/708) or DVB teletext in TV recordings")
+	f.ocr = fs.Bool("ocr", false, "Read the video's image subtitles (Blu-ray PGS or DVD VobSub) with Tesseract OCR instead of using a subtitle file")
+	f.ocrTrack = fs.Int("ocr-track", -1, "Which subtitle stream to read with --ocr, counting from 0 among all subtitle streams (default: the first image subtitle)")
+	f.ocrLang = fs.String("ocr-lang", swearkiller.DefaultOCRLanguage, "Tesseract language for --ocr, like 'eng' or 'eng+spa'")
+	f.whisperModel = fs.String("whisper-model", "", "Path to the whisper.cpp model file for --transcribe (overrides --whisper-model-size)")
+	f.whisperModelSize = fs.String("whisper-model-size", swearkiller.DefaultModelSize, "Whisper model for --transcribe: "+strings.Join(swearkiller.WhisperModelSizes, ", ")+" (bigger is slower and more accurate)")
+	f.whisperModelDir = fs.String("whisper-model-dir", "", "Folder with whisper.cpp ggml-<size>.bin model files (default: "+swearkiller.WhisperModelDir()+")")
+	f.whisperDevice = fs.String("whisper-device", "auto", "Where Whisper runs: 'auto', 'cpu', or 'gpu:N' for the Nth GPU")
+	f.whisperLang = fs.String("whisper-lang", "auto", "Spoken language for --transcribe, like 'en' or 'es' ('auto' to detect)")
+	f.whisperBeam = fs.Int("whisper-beam", 0, "Beam size for --transcribe; larger is slower and a little more accurate (0 = Whisper's default)")
+	f.whisperThreads = fs.Int("whisper-threads", 0, "CPU threads for --transcribe (0 = half the CPUs)")
+	return f
+}
+
+// quiet is set by --quiet for scan, mute, cut and subs: only errors are logged, and the
+// result line is printed at the end (see printResult)
+var quiet bool
+
+// resultOutput is where printResult writes, which is stderr when stdout holds a command
+var resultOutput = os.Stdout
+
+// printResult prints a command's final result line, which is all --quiet shows
+func printResult(format string, args ...any) {
+	if quiet {
+		fmt.Fprintf(resultOutput, format+"\n", args...)
+		return
+	}
+	logger.Infof(format, args...)
+}
+
+// exitWithResult prints the result line for segments and exits with exitCensored if
+// there were any, or exitClean if not. saved is the file written, if any.
+func exitWithResult(verb string, segments []swearkiller.Segment, saved string) {
+	result := "Result: clean, nothing to censor"
+	code := exitClean
+	if len(segments) > 0 {
+		result = fmt.Sprintf("Result: %s %d segment(s), %.1fs", verb, len(segments), swearkiller.SegmentsDuration(segments))
+		code = exitCensored
+	}
+	if saved != "" {
+		result += "; saved " + saved
+	}
+	printResult("%s", result)
+	os.Exit(code)
+}
+
+// parseCommand parses a command's flags and applies its --config file, then the logging
+// flags. If stderr is given and set, messages go to stderr so stdout holds only results.
+func parseCommand(fs *flag.FlagSet, args []string, config *string, logging logFlags, stderr *bool) {
+	fs.Parse(args)
+	if *config != "" {
+		if err := applyConfig(fs, *config, explicitFlags(fs)); err != nil {
+			logger.Errorf("Error in config file:\n%v", err)
+			os.Exit(exitParse)
+		}
+	}
+	if stderr != nil && *stderr {
+		logger.SetOutput(os.Stderr)
+		resultOutput = os.Stderr
+	}
+	if err := logging.apply(); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	if quiet = *logging.quiet; quiet {
+		logger.SetLevel(swearkiller.LevelError)
+	}
+	logger.Debugf("Swear Killer started with: %s", strings.Join(os.Args[1:], " "))
+}
+
+// fromVideo reports whether the subtitle comes from the video itself, so it can't be the
+// wrong one
+func (f *sourceFlags) fromVideo() bool {
+	return *f.transcribe || *f.captions || *f.ocr
+}
+
+// check validates the source and timing flags, exiting on a mistake. The subtitle source
+// is only required if required is set.
+func (f *sourceFlags) check(fs *flag.FlagSet, required bool) {
+	if required && *f.srt == "" && !f.fromVideo() {
+		logger.Errorf("SRT file path is required (--srt), or pass --captions or --ocr to use the video's own subtitles, or --transcribe to use its speech")
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	sources := 0
+	for _, set := range []bool{*f.srt != "", *f.transcribe, *f.captions, *f.ocr} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		logger.Errorf("Choose one subtitle source: --srt, --transcribe, --captions or --ocr")
+		os.Exit(exitParse)
+	}
+	var err error
+	if f.remapMode, err = swearkiller.ParseRemapMode(*f.edlRemap); err != nil {
+		logger.Errorf("%v (--edl-remap)", err)
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if f.drift, _, err = applyTimingFlags(fs, f.offset, f.offsetStart, f.offsetEnd); err != nil {
+		logger.Errorf("%v", err)
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if f.frameScale, err = swearkiller.ParseFrameRateRatio(*f.fpsRatio); err != nil {
+		logger.Errorf("%v (--fps-ratio)", err)
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if *f.video == "" {
+		logger.Errorf("Input video path is required (--video)")
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+}
+
+// read reads the subtitle from the chosen source and moves it onto the video's timeline,
+// exiting on an error or once ctx is stopped. The --offset isn't applied, as matches take
+// it into account.
+func (f *sourceFlags) read(ctx context.Context) []swearkiller.Cue {
+	var cues []swearkiller.Cue
+	var err error
+	if *f.transcribe {
+		opts := swearkiller.TranscribeOptions{
+			Model:     *f.whisperModel,
+			ModelSize: *f.whisperModelSize,
+			ModelDir:  *f.whisperModelDir,
+			Device:    *f.whisperDevice,
+			Language:  *f.whisperLang,
+			BeamSize:  *f.whisperBeam,
+			Threads:   *f.whisperThreads,
+		}
+		if err := opts.Validate(); err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(exitParse)
+		}
+		logger.Infof("Transcribing %s with Whisper (%s)...", *f.video, filepath.Base(opts.ModelPath()))
+		if duration, err := swearkiller.ProbeDuration(ctx, *f.video); err == nil && *f.whisperModel == "" {
+			logger.Infof("Estimated time: about %s (first run only; finished chunks are cached)", swearkiller.EstimateTranscription(duration, *f.whisperModelSize, *f.whisperDevice))
+		}
+		cues, err = swearkiller.Transcribe(ctx, *f.video, opts, logger.Func(swearkiller.LevelInfo), nil)
+		if ctx.Err() != nil {
+			logger.Errorf("Stopped while transcribing; finished chunks are cached, so run the same command again to continue")
+			os.Exit(exitInterrupted)
+		}
+		if err != nil {
+			logger.Errorf("Error transcribing audio: %v", err)
+			logger.Infof("Finished chunks are cached; run the same command again to continue")
+			os.Exit(exitFailed)
+		}
+		logger.Infof("Transcribed %d line(s)", len(cues))
+	} else if *f.captions {
+		cues, err = readCaptions(ctx, *f.video, *f.extraSRT)
+		exitIfInterrupted(ctx, "extracting the captions")
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(exitNoSubtitle)
+		}
+	} else if *f.ocr {
+		cues, err = readOCR(ctx, *f.video, *f.ocrTrack, *f.ocrLang)
+		exitIfInterrupted(ctx, "reading the image subtitles")
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(exitNoSubtitle)
+		}
+	} else {
+		// A forced track only has the foreign-language dialogue, so it's searched alongside
+		// the full track rather than in place of it
+		if swearkiller.IsForcedSubtitle(*f.srt) {
+			logger.Warnf("%s looks like a forced track, which only covers foreign-language dialogue; pass the full subtitle to --srt and its forced track is searched too", filepath.Base(*f.srt))
+		}
+		extraSRT := *f.extraSRT
+		tracks := swearkiller.WithForcedSubtitles(append([]string{*f.srt}, extraSRT...))
+		for _, forced := range tracks[len(extraSRT)+1:] {
+			logger.Infof("Also searching the forced track %s", filepath.Base(forced))
+		}
+		*f.extraSRT = tracks[1:]
+		var report swearkiller.SRTReport
+		if cues, report, err = swearkiller.ReadSubtitleTracks(tracks); err != nil {
+			logger.Errorf("Error processing SRT file: %v", err)
+			os.Exit(subtitleExitCode(err))
+		}
+		if len(*f.extraSRT) > 0 {
+			logger.Infof("Merged %d subtitle tracks into %d line(s)", len(*f.extraSRT)+1, len(cues))
+		} else {
+			logger.Debugf("Read %d subtitle line(s) from %s", len(cues), *f.srt)
+		}
+		if report.Skipped > 0 {
+			logger.Warnf("%s", report)
+		}
+		if report.Overlapping > 0 {
+			logger.Debugf("%d subtitle line(s) overlap the line before", report.Overlapping)
+		}
+	}
+	// Silence transcribes to nothing, but a subtitle without lines is the wrong file
+	if len(cues) == 0 && !*f.transcribe {
+		logger.Errorf("The subtitle has no lines to search")
+		os.Exit(exitNoSubtitle)
+	}
+	if f.frameScale != 1 || f.drift != 0 {
+		cues = swearkiller.CorrectTiming(cues, f.frameScale, f.drift)
+		logger.Infof("Corrected subtitle timing (frame rate factor %.5f, drift %+.3fs from first to last line)", f.frameScale, f.drift)
+	}
+	if !f.fromVideo() {
+		// A subtitle follows playback, which an MKV with ordered chapters doesn't do front to back
+		timeline, err := swearkiller.PlaybackTimeline(*f.video)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(exitFailed)
+		}
+		if timeline != nil {
+			cues = timeline.Apply(cues)
+			logger.Infof("The video has %d ordered chapter(s); moved the subtitle from playback order onto the file's timeline", len(timeline))
+		}
+	}
+	if f.remapMode != swearkiller.RemapNone {
+		cues, err = remapCues(cues, *f.video, *f.edl, f.remapMode)
+		if err != nil {
+			logger.Errorf("Error remapping subtitle timestamps: %v", err)
+			os.Exit(exitParse)
+		}
+	}
+	if *f.remap != "" {
+		table, err := swearkiller.ReadRemapFile(*f.remap)
+		if err != nil {
+			logger.Errorf("%v", err)
+			os.Exit(exitParse)
+		}
+		remapped := table.Apply(cues)
+		logger.Infof("Remapped subtitle timestamps with %d range(s) from %s", len(table), *f.remap)
+		if dropped := len(cues) - len(remapped); dropped > 0 {
+			logger.Infof("Dropped %d subtitle line(s) that fall outside every range", dropped)
+		}
+		cues = remapped
+	}
+	return cues
+}
+
+// matchFlags say which words to look for and what to report about them; scan, mute and
+// cut share them
+type matchFlags struct {
+	swears, allow, trustedKey, lang *string
+	deobfuscate, wholeWords, fuzzy  *bool
+	phraseGap, minConfidence        *float64
+	profile                         *string
+	muteBleeps, skipCommercials     *bool
+	force                           *bool
+
+	compareProfiles, listMatches *bool
+	advisory, exportCSV          *string
+	savePlan                     *string
+
+	// Set by check
+	swearList, allowList []string
+	chosen               *swearkiller.Profile
+
+	// Set by find
+	languages []string
+}
+
+// addMatchFlags registers the word, detection and report flags on fs
+func addMatchFlags(fs *flag.FlagSet) *matchFlags {
+	f := &matchFlags{}
+	f.swears = fs.String("swears", "", "Path to a file containing swear words (one per line), or the URL of a shared list")
+	f.allow = fs.String("allow", "", "Path to a file of harmless words that contain swears, like 'Scunthorpe' (one per line, added to the built-in allowlist)")
+	f.trustedKey = fs.String("trusted-key", "", "Public key file; --swears and --allow must then carry a valid signature from it (see 'swear-killer sign')")
+	f.lang = fs.String("lang", "auto", "Swear list languages: 'auto' to detect from the subtitle, 'none' for only your own list, or codes like 'es,fr'")
+	f.muteBleeps = fs.Bool("mute-bleeps", false, "Also detect existing 1 kHz bleep tones in the video's audio and censor them")
+	f.deobfuscate = fs.Bool("deobfuscate", false, "Also match disguised spellings like 'f*ck', 'sh1t' and 'f u c k' (may cause more false positives)")
+	f.fuzzy = fs.Bool("fuzzy", false, "Also match words one letter away from a swear of five letters or more, to catch OCR and transcription typos like 'fuckinq'; they are always held for review")
+	f.wholeWords = fs.Bool("whole-words", false, "Only match swears standing on their own as words, not inside longer words (suffixes and compounds count as words in languages like Finnish, Turkish and German)")
+	f.phraseGap = fs.Float64("phrase-gap", swearkiller.DefaultPhraseGap, "Match phrases split across subtitle blocks up to this many seconds apart (0 = only within a block)")
+	f.skipCommercials = fs.Bool("skip-commercials", false, "Ignore commercial breaks in DVR recordings, using the video's Comskip .edl file or black-frame/silence detection")
+	f.minConfidence = fs.Float64("min-confidence", swearkiller.DefaultMinConfidence, "Only censor matches at least this confident (0-1); less certain ones are listed for review instead")
+	f.force = fs.Bool("force", false, "Proceed even if the quality check suspects the subtitle doesn't belong to the video")
+	f.profile = fs.String("profile", "", "Use a built-in profile ("+strings.Join(swearkiller.ProfileNames(), ", ")+") instead of --deobfuscate, --whole-words and --min-confidence; '"+swearkiller.DefaultProfile+"' is a good start")
+	f.compareProfiles = fs.Bool("compare-profiles", false, "Print what each profile would censor, side by side, and stop")
+	f.listMatches = fs.Bool("list-matches", false, "Print each matched subtitle line (with formatting markup removed) and the words found in it")
+	f.advisory = fs.String("advisory", "", "Write a shareable content advisory (no quotes) to this file, or '-' for stdout")
+	f.exportCSV = fs.String("export-csv", "", "Write every match (times, words, subtitle text, whether it was censored) to this CSV file, or '-' for stdout")
+	f.savePlan = fs.String("save-plan", "", "Also save the segments to censor as a plan file, which 'swear-killer publish-plan' can share")
+	return f
+}
+
+// check validates the match flags and reads the word lists, exiting on a mistake
+func (f *matchFlags) check(fs *flag.FlagSet) {
+	if *f.minConfidence < 0 || *f.minConfidence > 1 {
+		logger.Errorf("Minimum confidence must be between 0 and 1 (--min-confidence)")
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if *f.profile != "" {
+		explicit := explicitFlags(fs)
+		if explicit["deobfuscate"] || explicit["whole-words"] || explicit["min-confidence"] {
+			logger.Errorf("--profile sets --deobfuscate, --whole-words and --min-confidence; don't pass them as well")
+			fs.Usage()
+			os.Exit(exitParse)
+		}
+		p, err := swearkiller.FindProfile(*f.profile)
+		if err != nil {
+			logger.Errorf("%v (--profile)", err)
+			fs.Usage()
+			os.Exit(exitParse)
+		}
+		f.chosen = &p
+		*f.minConfidence = p.MinConfidence
+	}
+	if *f.phraseGap < 0 {
+		logger.Errorf("Phrase gap cannot be negative (--phrase-gap)")
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+
+	// Default swear words (if no file provided)
+	f.swearList = swearkiller.DefaultSwears
+	if *f.swears != "" {
+		var err error
+		if f.swearList, err = readWordsFromFile(*f.swears, "swear", *f.trustedKey); err != nil {
+			logger.Errorf("Error reading swear file: %v", err)
+			os.Exit(exitFailed)
+		}
+	}
+	f.allowList = swearkiller.DefaultAllowlist
+	if *f.allow != "" {
+		extra, err := readWordsFromFile(*f.allow, "allowlist", *f.trustedKey)
+		if err != nil {
+			logger.Errorf("Error reading allowlist file: %v", err)
+			os.Exit(exitFailed)
+		}
+		f.allowList = swearkiller.CombineLists(f.allowList, extra)
+	}
+}
+
+// find searches the subtitle for swears and returns the merged segments to censor, after
+// writing the reports asked for. It returns false if there's nothing more to do, as after
+// --compare-profiles. Detected bleep tones get bleepAction. It exits once ctx is stopped.
+func (f *matchFlags) find(ctx context.Context, source *sourceFlags, cues []swearkiller.Cue, bleepAction swearkiller.Action) ([]swearkiller.Segment, bool) {
+	video, offset := *source.video, *source.offset
+	languages, err := resolveLanguages(*f.lang, append([]string{*source.srt}, *source.extraSRT...), cues)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitFailed)
+	}
+	f.languages = languages
+	swears := swearkiller.ExpandSwears(f.swearList, languages)
+	logger.Debugf("Matching %d swear word(s) and %d allowlisted word(s) (languages: %s)", len(swears), len(f.allowList), strings.Join(append([]string{"en"}, languages...), ", "))
+
+	// Refuse to continue with a subtitle that looks wrong for the video unless forced.
+	// A transcript, captions or OCR come from the video itself, so they can't be the wrong one.
+	if !source.fromVideo() {
+		report := swearkiller.CheckVideoQuality(ctx, cues, video, append([]string{"en"}, languages...))
+		exitIfInterrupted(ctx, "checking the subtitle")
+		if !report.Passed() {
+			logger.Warnf("Quality check: the subtitle may not match this video:\n%s", report)
+			if !*f.force {
+				logger.Errorf("Stopping before any work is done. Check the subtitle, or pass --force to continue anyway")
+				os.Exit(exitFailed)
+			}
+			logger.Warnf("Continuing anyway (--force)")
+		}
+	}
+
+	matchOpts := swearkiller.MatchOptions{Deobfuscate: *f.deobfuscate, PhraseGap: *f.phraseGap, Allow: f.allowList, WholeWords: *f.wholeWords, Fuzzy: *f.fuzzy, Languages: languages}
+	if *f.compareProfiles {
+		// One broad scan covers every profile
+		broad := swearkiller.FindMatches(cues, swears, swearkiller.BroadMatchOptions(matchOpts))
+		runtime, _ := swearkiller.ProbeDuration(ctx, video)
+		fmt.Println(swearkiller.FormatProfileComparison(swearkiller.CompareProfiles(broad, offset), runtime))
+		fmt.Println()
+		for _, p := range swearkiller.Profiles {
+			fmt.Printf("%s: %s\n", p.Name, p.Description)
+		}
+		return nil, false
+	}
+	var matches []swearkiller.Match
+	if f.chosen != nil {
+		matches = f.chosen.Apply(swearkiller.FindMatches(cues, swears, swearkiller.BroadMatchOptions(matchOpts)))
+		logger.Debugf("Using the %s profile: %s", f.chosen.Name, f.chosen.Description)
+	} else {
+		matches = swearkiller.FindMatches(cues, swears, matchOpts)
+	}
+	if *f.listMatches {
+		fmt.Printf("Found %d matching subtitle line(s):\n", len(matches))
+		for _, match := range matches {
+			fmt.Println("  " + match.String())
+		}
+	}
+
+	// Uncertain matches are left for the user to check rather than censored
+	matches, review := swearkiller.SplitByConfidence(matches, *f.minConfidence)
+	if len(matches) == 0 && len(review) == 0 {
+		subtitle := *source.srt
+		if source.fromVideo() {
+			subtitle = ""
+		}
+		logger.Infof("%s", swearkiller.DiagnoseZeroMatches(subtitle, cues, swears, matchOpts))
+	} else if warning := swearkiller.LanguageMismatch(swearkiller.GuessLanguage(cues), append([]string{"en"}, languages...)); warning != "" {
+		logger.Warnf("%s (--lang, --swears)", warning)
+	}
+	if len(review) > 0 {
+		logger.Warnf("%d uncertain match(es) below %.0f%% confidence were NOT censored; review them and lower --min-confidence to include them:", len(review), *f.minConfidence*100)
+		for _, match := range review {
+			logger.Warnf("  %s", match)
+		}
+	}
+	segments := swearkiller.MatchSegments(matches, offset, logger.Func(swearkiller.LevelInfo))
+	if f.chosen != nil {
+		segments = f.chosen.Shape(segments)
+	}
+
+	// Warn when the subtitle looks like it came from an already-censored TV edit
+	if report := swearkiller.DetectTVEdit(cues); report.Likely() {
+		logger.Warnf("This may already be a censored TV edit: %d subtitle line(s) contain bleeped or starred-out words (first at %s)",
+			len(report.Cues), swearkiller.FormatTimestamp(report.Cues[0].Start))
+		if !*f.muteBleeps {
+			logger.Infof("Tip: Use --mute-bleeps to also silence the existing bleep tones")
+		}
+	}
+
+	if *f.muteBleeps {
+		logger.Infof("Scanning audio for bleep tones...")
+		tones, err := swearkiller.DetectTones(ctx, video, swearkiller.DefaultToneOptions)
+		exitIfInterrupted(ctx, "scanning for bleep tones")
+		if err != nil {
+			logger.Errorf("Error detecting bleep tones: %v", err)
+			os.Exit(exitFailed)
+		}
+		logger.Infof("Found %d bleep tone(s)", len(tones))
+		segments = append(segments, swearkiller.WithAction(tones, bleepAction)...)
+	}
+
+	// Leave commercial breaks alone so only the program itself is censored and reported on
+	var breaks []swearkiller.Break
+	if *f.skipCommercials || (*source.edl != "" && source.remapMode != swearkiller.RemapCut) {
+		breaks, err = swearkiller.FindCommercialBreaks(ctx, video, *source.edl, swearkiller.DefaultCommercialOptions, logger.Func(swearkiller.LevelInfo))
+		exitIfInterrupted(ctx, "finding commercial breaks")
+		if err != nil {
+			logger.Errorf("Error finding commercial breaks: %v", err)
+			os.Exit(exitFailed)
+		}
+		logger.Infof("Found %d commercial break(s) totalling %s", len(breaks), swearkiller.FormatTimestamp(swearkiller.BreaksDuration(breaks)))
+		segments = swearkiller.ExcludeBreaks(segments, breaks)
+	}
+
+	// Merge overlapping or close segments
+	mergedSegments := swearkiller.MergeSegments(segments)
+
+	// Summarize what was found, to help decide whether the title is worth cleaning
+	runtime, _ := swearkiller.ProbeDuration(ctx, video)
+	logger.Infof("%s", swearkiller.BuildMatchStats(matches, review, mergedSegments, runtime))
+
+	if *f.savePlan != "" {
+		plan, err := swearkiller.NewPlan(ctx, video, mergedSegments)
+		exitIfInterrupted(ctx, "saving the plan")
+		if err != nil {
+			logger.Errorf("Error saving plan: %v", err)
+			os.Exit(exitFailed)
+		}
+		plan.Subtitle, plan.Offset, plan.Languages = *source.srt, offset, languages
+		if err := swearkiller.WritePlanFile(*f.savePlan, plan); err != nil {
+			logger.Errorf("Error saving plan: %v", err)
+			os.Exit(exitFailed)
+		}
+		logger.Infof("Plan saved to %s", *f.savePlan)
+	}
+
+	if *f.advisory != "" {
+		if err := writeAdvisory(ctx, *f.advisory, video, cues, matches, breaks, offset); err != nil {
+			logger.Errorf("Error writing advisory: %v", err)
+			os.Exit(exitFailed)
+		}
+	}
+	if *f.exportCSV != "" {
+		items := swearkiller.NewReviewItems(append(append([]swearkiller.Match{}, matches...), review...), offset, *f.minConfidence)
+		if err := writeMatchesExport(*f.exportCSV, items); err != nil {
+			logger.Errorf("Error exporting matches: %v", err)
+			os.Exit(exitFailed)
+		}
+	}
+	return mergedSegments, true
+}
+
+// sanitizer returns what --sanitize-metadata masks the swears with: the same list and
+// options as the subtitle search
+func (f *matchFlags) sanitizer() swearkiller.TextSanitizer {
+	opts := swearkiller.MatchOptions{Deobfuscate: *f.deobfuscate, Allow: f.allowList, WholeWords: *f.wholeWords, Languages: f.languages}
+	return swearkiller.TextSanitizer{Swears: swearkiller.ExpandSwears(f.swearList, f.languages), Options: opts, MinConfidence: *f.minConfidence}
+}
+
+// encodeFlags say where the clean video goes and how it is encoded; mute, cut and render
+// share them
+type encodeFlags struct {
+	output, container *string
+	nameTemplate      *string
+	overwrite         *string
+	plan              *string
+	preview           *float64
+	videoCodec        *string
+	lowPriority       *bool
+	threads           *int
+	checkOutput       *bool
+	deleteCorrupt     *bool
+	replace           *bool
+	backup            *string
+	sanitizeMetadata  *bool
+
+	printOnly  *bool
+	shell      *string
+	emitScript *string
+	emitEDL    *string
+
+	notifying  notifyFlags
+	refreshing refreshFlags
+
+	// Set by check
+	shellKind  swearkiller.Shell
+	policy     swearkiller.OverwritePolicy
+	backupKind swearkiller.Backup
+}
+
+// addEncodeFlags registers the output and encoding flags on fs
+func addEncodeFlags(fs *flag.FlagSet) *encodeFlags {
+	f := &encodeFlags{}
+	f.output = fs.String("output", "", "Path to the output video file (default: named by --name-template, next to the video)")
+	f.nameTemplate = fs.String("name-template", string(swearkiller.DefaultNameTemplate), "How the output is named without --output, with {name}, {ext}, {profile}, {severity} and {date}")
+	f.container = fs.String("container", "", "Output container: 'mkv', 'mp4', 'same' as the input or 'auto' (MKV for MKV inputs, otherwise MP4); changes the --output extension to match")
+	f.overwrite = fs.String("overwrite", string(swearkiller.OverwriteReplace), "If the output exists: 'overwrite' it, 'fail', 'rename' the new one (like movie-CLEAN (2).mp4) or 'skip' encoding")
+	f.plan = fs.String("plan", "", "Censor the segments in this plan file (from --save-plan or 'swear-killer fetch-plan') instead of searching a subtitle")
+	f.preview = fs.Float64("preview", 0, "Only encode the first N minutes so you can check the result quickly (0 = whole video)")
+	f.videoCodec = fs.String("video-codec", "", "Re-encode the picture with this FFmpeg encoder, like libx264, instead of copying it (slower; see swear-killer benchmark)")
+	f.lowPriority = fs.Bool("low-priority", false, "Run FFmpeg at low CPU priority, so the computer stays responsive while encoding")
+	f.threads = fs.Int("threads", 0, "CPU threads FFmpeg may use; fewer run cooler and quieter (0 = no limit)")
+	f.checkOutput = fs.Bool("check-output", false, "After encoding, check the output with ffprobe and a quick decode: its streams last as long as the video and it decodes without errors")
+	f.deleteCorrupt = fs.Bool("delete-corrupt", false, "With --check-output, delete an output that fails the check instead of leaving it for a look")
+	f.replace = fs.Bool("replace", false, "Once the output passes --check-output (turned on by this), put it in place of the original video")
+	f.backup = fs.String("backup", string(swearkiller.BackupNone), "With --replace, what to do with the original: 'none' deletes it, 'orig' keeps it as <video>.orig, 'trash' moves it to the trash")
+	f.sanitizeMetadata = fs.Bool("sanitize-metadata", false, "Mask swears in the output's file name, title, comment and chapter names, which some releases fill with crude jokes")
+	f.printOnly = fs.Bool("print-only", false, "Print the FFmpeg command instead of running it (messages go to stderr, so stdout holds only the command)")
+	f.shell = fs.String("shell", string(swearkiller.DefaultShell()), "Shell to quote the --print-only command for: bash, powershell, cmd or bat")
+	f.emitScript = fs.String("emit-script", "", "Write a ready-to-run script with the FFmpeg command to this file instead of running it; .sh for bash, .ps1 for PowerShell, .bat or .cmd for Windows batch")
+	f.emitEDL = fs.String("emit-edl", "", "Write the segments as an EDL to this file instead of encoding, for players like Kodi and MPlayer to censor during playback (--output isn't needed)")
+	f.notifying = addNotifyFlags(fs)
+	f.refreshing = addRefreshFlags(fs)
+	return f
+}
+
+// check validates the encoding flags and sets up notifications and library refreshes,
+// exiting on a mistake. Without --output the output is named here by --name-template for
+// profile, and --container changes its extension.
+func (f *encodeFlags) check(fs *flag.FlagSet, video, profile string) {
+	if err := f.notifying.apply(swearkiller.NotifyConfig{}); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	if err := f.refreshing.apply(swearkiller.LibraryRefresh{}); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitParse)
+	}
+	if *f.preview < 0 {
+		logger.Errorf("Preview length cannot be negative (--preview)")
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	var err error
+	if f.shellKind, err = swearkiller.ParseShell(*f.shell); err != nil {
+		logger.Errorf("%v (--shell)", err)
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if f.policy, err = swearkiller.ParseOverwritePolicy(*f.overwrite); err != nil {
+		logger.Errorf("%v (--overwrite)", err)
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if *f.threads < 0 {
+		logger.Errorf("Threads cannot be negative (--threads)")
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if *f.deleteCorrupt && !*f.checkOutput && !*f.replace {
+		logger.Errorf("--delete-corrupt needs --check-output")
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if f.backupKind, err = swearkiller.ParseBackup(*f.backup); err != nil {
+		logger.Errorf("%v (--backup)", err)
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if *f.replace {
+		if *f.printOnly || *f.emitScript != "" || *f.emitEDL != "" || *f.preview > 0 {
+			logger.Errorf("--replace needs a full encode; it can't be used with --print-only, --emit-script, --emit-edl or --preview")
+			fs.Usage()
+			os.Exit(exitParse)
+		}
+		*f.checkOutput = true // Only a whole output may replace the original
+	} else if explicitFlags(fs)["backup"] {
+		logger.Errorf("--backup needs --replace")
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	swearkiller.SetLowPriority(*f.lowPriority)
+	template := swearkiller.NameTemplate(*f.nameTemplate)
+	if err := template.Validate(); err != nil {
+		logger.Errorf("%v (--name-template)", err)
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if *f.output == "" && *f.emitEDL == "" {
+		*f.output = filepath.Join(filepath.Dir(video), template.Name(video, profile))
+	}
+	if *f.container != "" && *f.output != "" {
+		output, err := swearkiller.ApplyContainer(video, *f.output, *f.container)
+		if err != nil {
+			logger.Errorf("%v (--container)", err)
+			os.Exit(exitParse)
+		}
+		if output != *f.output {
+			logger.Infof("Writing %s for --container %s", output, *f.container)
+			*f.output = output
+		}
+	}
+}
+
+// options returns the encode options the flags set
+func (f *encodeFlags) options() swearkiller.EncodeOptions {
+	return swearkiller.EncodeOptions{MaxDuration: *f.preview * 60, VideoCodec: *f.videoCodec, Threads: *f.threads}
+}
+
+// encode writes the EDL, script or clean video the flags ask for and returns which, or ""
+// if the command was printed
+func (f *encodeFlags) encode(ctx context.Context, video string, segments []swearkiller.Segment, opts swearkiller.EncodeOptions) string {
+	if *f.preview > 0 {
+		logger.Infof("Preview mode: only the first %g minute(s) will be encoded", *f.preview)
+	}
+	if len(swearkiller.LimitSegments(segments, opts.MaxDuration)) == 0 {
+		logger.Infof("No segments to censor; the video will be copied unchanged")
+	}
+	logger.Debugf("Censoring %d merged segment(s)", len(segments))
+	if *f.emitEDL != "" {
+		writeMuteEDL(*f.emitEDL, segments)
+		return *f.emitEDL
+	}
+	if !*f.printOnly && *f.emitScript == "" {
+		f.resolveOutput()
+	}
+	var replace *swearkiller.Backup
+	if *f.replace {
+		replace = &f.backupKind
+	}
+	saved := runEncode(ctx, video, *f.output, segments, opts, f.shellKind, *f.printOnly, *f.emitScript, *f.checkOutput, *f.deleteCorrupt, replace)
+	switch {
+	case *f.printOnly:
+		return ""
+	case *f.emitScript != "":
+		return *f.emitScript
+	}
+	return saved
+}
+
+// sanitize masks the swears words finds in the output's name and the video's metadata,
+// for --sanitize-metadata, exiting if the metadata can't be read
+func (f *encodeFlags) sanitize(ctx context.Context, video string, words swearkiller.TextSanitizer, opts *swearkiller.EncodeOptions) {
+	if !*f.sanitizeMetadata {
+		return
+	}
+	if *f.output != "" {
+		if output := words.Filename(*f.output); output != *f.output {
+			logger.Infof("Naming the output %s to keep the swears out of its name", filepath.Base(output))
+			*f.output = output
+		}
+	}
+	meta, err := swearkiller.ProbeMetadata(ctx, video)
+	exitIfInterrupted(ctx, "reading the metadata")
+	if err != nil {
+		logger.Errorf("Error reading the metadata to sanitize: %v", err)
+		os.Exit(exitFailed)
+	}
+	opts.Metadata = words.Metadata(meta)
+	if n := opts.Metadata.Count(); n > 0 {
+		logger.Infof("Masking swears in %d metadata value(s)", n)
+	}
+}
+
+// resolveOutput applies --overwrite to an output that already exists, exiting if it fails
+// or skips the video
+func (f *encodeFlags) resolveOutput() {
+	output, skip, err := f.policy.ResolveOutput(*f.output)
+	switch {
+	case err != nil:
+		logger.Errorf("%v (--overwrite)", err)
+		os.Exit(exitFailed)
+	case skip:
+		printResult("Result: skipped, %s already exists", *f.output)
+		os.Exit(exitClean)
+	case output != *f.output:
+		logger.Infof("%s already exists; writing %s instead", *f.output, filepath.Base(output))
+		*f.output = output
+	default:
+		if _, err := os.Stat(output); err == nil {
+			logger.Infof("Replacing %s", output)
+		}
+	}
+}
+
+// runScan handles `swearkiller scan`, which searches a video's subtitle and reports what
+// would be censored without encoding anything
+func runScan(args []string) {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	source := addSourceFlags(fs)
+	match := addMatchFlags(fs)
+	logging := addLogFlags(fs)
+	commandUsage(fs, "scan [flags]", "Searches the subtitle for swears and reports them: the summary, and with the flags below each line, an advisory, a CSV or a plan. Nothing is encoded.")
+	parseCommand(fs, args, source.config, logging, nil)
+	source.check(fs, true)
+	match.check(fs)
+	if !explicitFlags(fs)["list-matches"] && !quiet && *match.advisory != "-" && *match.exportCSV != "-" {
+		*match.listMatches = true // Listing the lines is what a scan is for, unless stdout holds a report
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	cues := source.read(ctx)
+	if segments, ok := match.find(ctx, source, cues, swearkiller.ActionMute); ok {
+		exitWithResult("found", segments, "")
+	}
+}
+
+// runMute handles `swearkiller mute`, which mutes the swears (or covers them with a tone)
+// and saves a clean video. It is also what runs when the CLI gets flags without a command.
+func runMute(args []string) {
+	fs := flag.NewFlagSet("mute", flag.ExitOnError)
+	source := addSourceFlags(fs)
+	match := addMatchFlags(fs)
+	enc := addEncodeFlags(fs)
+	muting := addMuteFlags(fs)
+	bleepAction := fs.String("bleep-action", "mute", "How to censor detected bleep tones with --mute-bleeps: 'mute', 'tone' (replace with a softer tone) or another --action")
+	verify := fs.Bool("verify", false, "Instead of encoding, check the already-encoded --output file is silent during every muted segment")
+	logging := addLogFlags(fs)
+	commandUsage(fs, "mute [flags]", "Mutes every swear found in the subtitle, or covers it with a tone, and saves a clean copy of the video.")
+	parseCommand(fs, args, source.config, logging, enc.printOnly)
+	source.check(fs, *enc.plan == "")
+	match.check(fs)
+	muting.check(fs)
+	if _, err := parseCensorAction(*bleepAction, "--bleep-action"); err != nil {
+		logger.Errorf("%v", err)
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	enc.check(fs, *source.video, *match.profile)
+	muting.checkOutput(*enc.output)
+	ctx, stop := interruptContext()
+	defer stop()
+	if warning := swearkiller.CleanedWarning(ctx, *source.video); warning != "" && !*verify {
+		logger.Warnf("%s", warning)
+	}
+
+	// A plan already lists the segments, so there's no subtitle to search
+	var segments []swearkiller.Segment
+	if *enc.plan != "" {
+		segments = readPlanForVideo(*enc.plan, *source.video, *match.force).Segments
+	} else {
+		var ok bool
+		if segments, ok = match.find(ctx, source, source.read(ctx), swearkiller.Action(*bleepAction)); !ok {
+			return
+		}
+	}
+	muting.apply(segments)
+	encodeOpts := enc.options()
+	muting.options(&encodeOpts)
+	enc.sanitize(ctx, *source.video, match.sanitizer(), &encodeOpts)
+	if *verify {
+		verifyOutput(ctx, *enc.output, segments, encodeOpts)
+		return
+	}
+	exitWithResult("censored", segments, enc.encode(ctx, *source.video, segments, encodeOpts))
+}
+
+// muteFlags say how mute and render censor each segment that isn't cut
+type muteFlags struct {
+	action, replacementAudio *string
+	censorDescriptions       *bool
+	fade                     *float64
+	keepOriginal             *bool
+	stash                    *string
+
+	// Set by check
+	swearAction swearkiller.Action
+}
+
+// addMuteFlags registers the censor style and muting flags on fs
+func addMuteFlags(fs *flag.FlagSet) *muteFlags {
+	f := &muteFlags{}
+	f.action = fs.String("action", string(swearkiller.ActionMute), "How to censor each swear: 'mute', 'tone' (a soft tone), 'beep' (a TV bleep), 'replace' (with --replacement-audio) or 'blur' (the picture instead of the sound)")
+	f.replacementAudio = fs.String("replacement-audio", "", "Sound file played, looped, over swears censored with --action replace")
+	f.censorDescriptions = fs.Bool("censor-descriptions", false, "Also censor the video's audio description tracks, whose narration often repeats the dialogue; without it they're kept as they are")
+	f.fade = fs.Float64("fade", 0, fmt.Sprintf("Ramp the volume down before each mute and back up after it over this many seconds, so the cuts don't pop (try %g; 0 = cut hard)", swearkiller.DefaultFade))
+	f.keepOriginal = fs.Bool("keep-original-audio", false, "Keep the uncensored audio as an extra track that isn't played by default, so the censoring can be undone (.mkv outputs only)")
+	f.stash = fs.String("stash", "", "Also save the censored-out audio to this file (like movie"+swearkiller.StashExt+"), so 'swear-killer restore' can undo the censoring without the original")
+	return f
+}
+
+// check validates the muting flags, exiting on a mistake
+func (f *muteFlags) check(fs *flag.FlagSet) {
+	var err error
+	if f.swearAction, err = parseCensorAction(*f.action, "--action"); err != nil {
+		logger.Errorf("%v", err)
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if f.swearAction == swearkiller.ActionReplace && *f.replacementAudio == "" {
+		logger.Errorf("--action replace needs a sound to play (--replacement-audio)")
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if *f.fade < 0 {
+		logger.Errorf("Fade cannot be negative (--fade)")
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+}
+
+// checkOutput exits if the output can't hold what the flags ask for
+func (f *muteFlags) checkOutput(output string) {
+	if *f.keepOriginal && output != "" && !strings.EqualFold(filepath.Ext(output), ".mkv") {
+		logger.Errorf("Only .mkv outputs can keep the original audio (--keep-original-audio); use --stash for other formats")
+		os.Exit(exitParse)
+	}
+}
+
+// apply gives --action to the segments without an action of their own
+func (f *muteFlags) apply(segments []swearkiller.Segment) {
+	for i := range segments {
+		if segments[i].Action == "" && f.swearAction != swearkiller.ActionMute { // Not already given one by the profile, the plan or --bleep-action
+			segments[i].Action = f.swearAction
+		}
+	}
+}
+
+// options sets the encode options the flags ask for
+func (f *muteFlags) options(opts *swearkiller.EncodeOptions) {
+	opts.CensorDescriptions, opts.Fade, opts.KeepOriginal, opts.Stash = *f.censorDescriptions, *f.fade, *f.keepOriginal, *f.stash
+	opts.ReplacementAudio = *f.replacementAudio
+}
+
+// parseCensorAction reads a censor action from flag, which can't be a cut: cutting has a
+// command of its own
+func parseCensorAction(name, flag string) (swearkiller.Action, error) {
+	action, err := swearkiller.ParseAction(name)
+	if err != nil {
+		return "", fmt.Errorf("%v (%s)", err, flag)
+	}
+	if action == swearkiller.ActionCut {
+		return "", fmt.Errorf("use 'swear-killer cut' to cut swears out (%s)", flag)
+	}
+	return action, nil
+}
+
+// runCut handles `swearkiller cut`, which takes the swears out of the video altogether,
+// picture and all, instead of muting them
+func runCut(args []string) {
+	fs := flag.NewFlagSet("cut", flag.ExitOnError)
+	source := addSourceFlags(fs)
+	match := addMatchFlags(fs)
+	enc := addEncodeFlags(fs)
+	logging := addLogFlags(fs)
+	commandUsage(fs, "cut [flags]", "Cuts every swear found in the subtitle out of the video, so it is skipped instead of muted. The picture is re-encoded (with "+
+		swearkiller.CutVideoCodec+" unless --video-codec says otherwise), and only the main audio track is kept; the subtitles are left out, as they no longer fit.")
+	parseCommand(fs, args, source.config, logging, enc.printOnly)
+	source.check(fs, *enc.plan == "")
+	match.check(fs)
+	enc.check(fs, *source.video, *match.profile)
+	ctx, stop := interruptContext()
+	defer stop()
+	if warning := swearkiller.CleanedWarning(ctx, *source.video); warning != "" {
+		logger.Warnf("%s", warning)
+	}
+
+	var segments []swearkiller.Segment
+	if *enc.plan != "" {
+		segments = readPlanForVideo(*enc.plan, *source.video, *match.force).Segments
+	} else {
+		var ok bool
+		if segments, ok = match.find(ctx, source, source.read(ctx), swearkiller.ActionMute); !ok {
+			return
+		}
+	}
+	// Joined again as cuts, so a tone overlapping a mute isn't cut twice
+	segments = swearkiller.MergeSegments(swearkiller.WithAction(segments, swearkiller.ActionCut))
+	encodeOpts := enc.options()
+	enc.sanitize(ctx, *source.video, match.sanitizer(), &encodeOpts)
+	exitWithResult("cut", segments, enc.encode(ctx, *source.video, segments, encodeOpts))
+}
+
+// runRender handles `swearkiller render`, which encodes segments saved earlier, in a
+// project, a plan or a JSON file, without searching a subtitle again, so the encoding can
+// be tried with other settings as often as needed
+func runRender(args []string) {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	segmentsFile := fs.String("segments", "", "Project file (.skproj), plan, or JSON file of segments to censor")
+	video := fs.String("video", "", "Path to the input video file (default: the one the segments file names)")
+	force := fs.Bool("force", false, "Use a plan made for a different release of the video")
+	config := fs.String("config", "", "Read options from a YAML, TOML or JSON file; keys are the option names with underscores and command-line flags take precedence")
+	enc := addEncodeFlags(fs)
+	muting := addMuteFlags(fs)
+	logging := addLogFlags(fs)
+	commandUsage(fs, "render --segments FILE [flags]", "Encodes the segments in a project, a plan or a JSON file of segments without searching a subtitle, "+
+		"so the same censoring can be encoded again with other settings. A segment that is a cut makes every segment a cut, as 'swear-killer cut' does.")
+	parseCommand(fs, args, config, logging, enc.printOnly)
+	if *segmentsFile == "" {
+		*segmentsFile = *enc.plan // A plan is one of the files --segments takes
+	}
+	if *segmentsFile == "" {
+		logger.Errorf("The segments to encode are required (--segments)")
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	saved, err := swearkiller.ReadSegmentsFile(*segmentsFile)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitFailed)
+	}
+	if *video == "" {
+		*video = saved.Video
+	}
+	if *video == "" {
+		logger.Errorf("%s doesn't say which video it is for (--video)", *segmentsFile)
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	if _, err := os.Stat(*video); err != nil {
+		logger.Errorf("Video file not found: %s", *video)
+		os.Exit(exitFailed)
+	}
+	muting.check(fs)
+	enc.check(fs, *video, saved.Profile)
+	muting.checkOutput(*enc.output)
+	if saved.Plan != nil {
+		checkPlanForVideo(*saved.Plan, *video, *force)
+	}
+	if saved.Undecided > 0 {
+		logger.Warnf("%d match(es) in the project were never reviewed and aren't censored; open it in the GUI to review them", saved.Undecided)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	if warning := swearkiller.CleanedWarning(ctx, *video); warning != "" {
+		logger.Warnf("%s", warning)
+	}
+
+	segments := saved.Segments
+	swearkiller.SortSegments(segments)
+	verb := "censored"
+	if slices.ContainsFunc(segments, func(seg swearkiller.Segment) bool { return seg.Action == swearkiller.ActionCut }) {
+		logger.Infof("The segments include cuts, so every segment is cut out")
+		segments = swearkiller.MergeSegments(swearkiller.WithAction(segments, swearkiller.ActionCut))
+		verb = "cut"
+	} else {
+		muting.apply(segments)
+	}
+	logger.Infof("Encoding %d saved segment(s) from %s", len(segments), *segmentsFile)
+	encodeOpts := enc.options()
+	muting.options(&encodeOpts)
+	enc.sanitize(ctx, *video, swearkiller.TextSanitizer{Swears: swearkiller.DefaultSwears, MinConfidence: swearkiller.DefaultMinConfidence}, &encodeOpts)
+	exitWithResult(verb, segments, enc.encode(ctx, *video, segments, encodeOpts))
+}
+
+// runSubs handles `swearkiller subs`, which saves the subtitle the other commands would
+// search as an SRT file, after fixing its timing, so it can be checked in a player or kept
+// beside the clean video
+func runSubs(args []string) {
+	fs := flag.NewFlagSet("subs", flag.ExitOnError)
+	source := addSourceFlags(fs)
+	output := fs.String("output", "", "Path for the SRT file")
+	logging := addLogFlags(fs)
+	commandUsage(fs, "subs --output FILE [flags]", "Saves the subtitle as SRT: a subtitle file and its --extra-srt tracks merged, or the video's captions, image subtitles or speech. "+
+		"Its timing is fixed with --offset, the drift and frame rate flags, the remap flags and the video's ordered chapters, as the other commands do before searching it.")
+	parseCommand(fs, args, source.config, logging, nil)
+	source.check(fs, true)
+	if *output == "" {
+		logger.Errorf("The SRT file to write is required (--output)")
+		fs.Usage()
+		os.Exit(exitParse)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	cues := source.read(ctx)
+	if *source.offset != 0 {
+		shifted := swearkiller.ShiftCues(cues, *source.offset)
+		logger.Infof("Moved the subtitle %+.3fs", *source.offset)
+		if dropped := len(cues) - len(shifted); dropped > 0 {
+			logger.Infof("Dropped %d subtitle line(s) that would end before the video starts", dropped)
+		}
+		cues = shifted
+	}
+	if err := swearkiller.WriteSRTFile(*output, cues); err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitFailed)
+	}
+	printResult("Subtitle with %d line(s) saved to %s", len(cues), *output)
+}
+
+// runEncode prints, scripts or runs the FFmpeg command that censors segments, as the
+// --print-only and --emit-script flags ask, then checks the output if checkOutput is set
+// and puts it in place of the input if replace says how to back the input up. It returns
+// where the clean video or script went, or "" if the command was printed.
+func runEncode(ctx context.Context, inputVideo, outputVideo string, segments []swearkiller.Segment, encodeOpts swearkiller.EncodeOptions, shell swearkiller.Shell, printOnly bool, emitScript string,
+	checkOutput, deleteCorrupt bool, replace *swearkiller.Backup) string {
+	warnings, err := swearkiller.CheckContainer(ctx, inputVideo, outputVideo)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(exitFailed)
+	}
+	for _, warning := range warnings {
+		logger.Warnf("%s", warning)
+	}
+	encodeOpts = encodeOpts.WithStreams(ctx, inputVideo, outputVideo)
+	exitIfInterrupted(ctx, "reading the video's streams")
+	for _, track := range encodeOpts.Audio {
+		if track.Description && encodeOpts.CensorDescriptions {
+			logger.Infof("Censoring the audio description track %d too", track.Index+1)
+		} else if track.Description {
+			logger.Infof("Keeping the audio description track %d as it is (use --censor-descriptions to censor it too)", track.Index+1)
+		}
+	}
+	// Print the command for the user's shell, or run FFmpeg directly with an argument list
+	// so no shell ever sees the file names
+	args := swearkiller.BuildFFmpegArgs(inputVideo, outputVideo, segments, encodeOpts)
+	if printOnly {
+		fmt.Println(swearkiller.FFmpegCommandLine(shell, args))
+		if stash := swearkiller.BuildStashArgs(inputVideo, encodeOpts.Stash, segments, encodeOpts); encodeOpts.Stash != "" && stash != nil {
+			fmt.Println(swearkiller.FFmpegCommandLine(shell, stash))
+		}
+		return ""
+	}
+	if emitScript != "" && encodeOpts.Stash != "" {
+		logger.Warnf("The script doesn't save the stash (--stash); run without --emit-script for that")
+	}
+	if emitScript != "" && checkOutput {
+		logger.Warnf("The script doesn't check the output (--check-output); run without --emit-script for that")
+	}
+	if emitScript != "" {
+		if err := writeScript(emitScript, args, len(segments)); err != nil {
+			logger.Errorf("Error writing script: %v", err)
+			os.Exit(exitFailed)
+		}
+		logger.Infof("Script written to %s; run it where FFmpeg can reach the video at %s", emitScript, inputVideo)
+		return emitScript
+	}
+	logger.Infof("Encoding %s...", outputVideo)
+	err = swearkiller.EncodeVideo(ctx, inputVideo, outputVideo, segments, encodeOpts, logger.Func(swearkiller.LevelDebug), logProgress())
+	if err != nil {
+		exitIfInterrupted(ctx, "encoding; removed the unfinished output")
+		logger.Errorf("%v", err)
+		notify(swearkiller.JobNotification(inputVideo, outputVideo, len(segments), err))
+		os.Exit(exitEncodeFailed)
+	}
+	if checkOutput {
+		checkEncodedOutput(ctx, inputVideo, outputVideo, segments, encodeOpts, deleteCorrupt)
+		exitIfInterrupted(ctx, "checking the output")
+	}
+	if replace != nil {
+		replaced, err := swearkiller.ReplaceOriginal(inputVideo, outputVideo, *replace)
+		if err != nil {
+			logger.Errorf("%v", err)
+			notify(swearkiller.JobNotification(inputVideo, outputVideo, len(segments), err))
+			os.Exit(exitFailed)
+		}
+		logger.Infof("Replaced %s with the clean video%s", inputVideo, replace.Note(inputVideo))
+		outputVideo = replaced
+	} else {
+		logger.Infof("Clean video saved to %s", outputVideo)
+	}
+	refreshLibrary(outputVideo)
+	notify(swearkiller.JobNotification(inputVideo, outputVideo, len(segments), nil))
+	return outputVideo
+}
+
+// checkEncodedOutput checks the output is whole and exits with an error if it isn't,
+// deleting it first if deleteCorrupt is set
+func checkEncodedOutput(ctx context.Context, inputVideo, outputVideo string, segments []swearkiller.Segment, encodeOpts swearkiller.EncodeOptions, deleteCorrupt bool) {
+	logger.Infof("Checking %s...", outputVideo)
+	report, err := swearkiller.CheckIntegrity(ctx, inputVideo, outputVideo, segments, encodeOpts)
+	if err != nil {
+		return
+	}
+	if report.OK() {
+		logger.Infof("Output checked: it decodes cleanly and its streams are whole")
+		return
+	}
+	for _, problem := range report.Problems {
+		logger.Errorf("  %s", problem)
+	}
+	if deleteCorrupt {
+		os.Remove(outputVideo)
+		logger.Errorf("The output failed its check and was deleted")
+	} else {
+		logger.Errorf("The output failed its check; it was left in place for a look")
+	}
+	notify(swearkiller.JobNotification(inputVideo, outputVideo, 0, fmt.Errorf("the output failed its check: %s", strings.Join(report.Problems, "; "))))
+	os.Exit(exitEncodeFailed)
 }
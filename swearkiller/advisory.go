@@ -0,0 +1,199 @@
+package swearkiller
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// Category groups swear words for reporting
+type Category string
+
+const (
+	CategoryStrong    Category = "Strong language"
+	CategoryModerate  Category = "Moderate language"
+	CategoryBlasphemy Category = "Blasphemy"
+	CategorySexual    Category = "Sexual references"
+	CategorySlur      Category = "Slurs"
+	CategoryOther     Category = "Other language"
+)
+
+// Categories lists every category in report order
+var Categories = []Category{
+	CategoryStrong,
+	CategoryModerate,
+	CategoryBlasphemy,
+	CategorySexual,
+	CategorySlur,
+	CategoryOther,
+}
+
+// categoryRoots maps word roots to categories; the first root found in a word wins
+var categoryRoots = []struct {
+	root     string
+	category Category
+}{
+	{"fuck", CategoryStrong},
+	{"cunt", CategoryStrong},
+	{"goddam", CategoryBlasphemy},
+	{"god damn", CategoryBlasphemy},
+	{"jesus", CategoryBlasphemy},
+	{"christ", CategoryBlasphemy},
+	{"nigg", CategorySlur},
+	{"fag", CategorySlur},
+	{"retard", CategorySlur},
+	{"dickhead", CategoryModerate},
+	{"cock", CategorySexual},
+	{"dick", CategorySexual},
+	{"pussy", CategorySexual},
+	{"whore", CategorySexual},
+	{"slut", CategorySexual},
+	{"shit", CategoryModerate},
+	{"bitch", CategoryModerate},
+	{"bastard", CategoryModerate},
+	{"ass", CategoryModerate},
+	{"piss", CategoryModerate},
+	{"damn", CategoryModerate},
+	{"crap", CategoryModerate},
+}
+
+// CategorizeWord returns the reporting category for a swear word
+func CategorizeWord(word string) Category {
+	lower := strings.ToLower(word)
+	for _, r := range categoryRoots {
+		if strings.Contains(lower, r.root) {
+			return r.category
+		}
+	}
+	return CategoryOther
+}
+
+// Advisory is a spoiler-light summary of matches per category and quarter of runtime.
+// It never contains dialogue, so it is safe to share with other families.
+type Advisory struct {
+	Title   string
+	Runtime float64 // Runtime in seconds
+	Counts  map[Category][4]int
+}
+
+// BuildAdvisory counts matched subtitle lines per category for each quarter of the runtime.
+// A cue with words from several categories counts once in each of them.
+func BuildAdvisory(title string, matches []Match, runtime float64) Advisory {
+	if runtime <= 0 {
+		// Fall back to the end of the last match when the runtime is unknown
+		for _, m := range matches {
+			if m.Cue.End > runtime {
+				runtime = m.Cue.End
+			}
+		}
+	}
+
+	advisory := Advisory{Title: title, Runtime: runtime, Counts: map[Category][4]int{}}
+	for _, m := range matches {
+		quarter := 0
+		if runtime > 0 {
+			quarter = int(m.Cue.Start / (runtime / 4))
+		}
+		if quarter < 0 {
+			quarter = 0
+		} else if quarter > 3 {
+			quarter = 3
+		}
+
+		seen := map[Category]bool{}
+		for _, word := range m.Words {
+			category := CategorizeWord(word)
+			if seen[category] {
+				continue
+			}
+			seen[category] = true
+			counts := advisory.Counts[category]
+			counts[quarter]++
+			advisory.Counts[category] = counts
+		}
+	}
+	return advisory
+}
+
+// Total returns the number of counted lines across all quarters for a category
+func (a Advisory) Total(category Category) int {
+	total := 0
+	for _, n := range a.Counts[category] {
+		total += n
+	}
+	return total
+}
+
+// String formats the advisory as a plain-text table ready to paste into a message
+func (a Advisory) String() string {
+	var b strings.Builder
+	title := a.Title
+	if title == "" {
+		title = "Untitled"
+	}
+	fmt.Fprintf(&b, "CONTENT ADVISORY: %s\n", title)
+	if a.Runtime > 0 {
+		fmt.Fprintf(&b, "Runtime: %s\n", formatRuntime(a.Runtime))
+	}
+	b.WriteString("\n")
+
+	var grandTotal int
+	for _, category := range Categories {
+		grandTotal += a.Total(category)
+	}
+	if grandTotal == 0 {
+		b.WriteString("No listed language was found in the subtitles.\n")
+		return b.String()
+	}
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "Category\t")
+	for q := 0; q < 4; q++ {
+		fmt.Fprintf(w, "%s\t", a.quarterLabel(q))
+	}
+	fmt.Fprint(w, "Total\n")
+
+	var quarterTotals [4]int
+	for _, category := range Categories {
+		total := a.Total(category)
+		if total == 0 {
+			continue
+		}
+		counts := a.Counts[category]
+		fmt.Fprintf(w, "%s\t", category)
+		for q, n := range counts {
+			fmt.Fprintf(w, "%d\t", n)
+			quarterTotals[q] += n
+		}
+		fmt.Fprintf(w, "%d\n", total)
+	}
+	fmt.Fprint(w, "All\t")
+	for _, n := range quarterTotals {
+		fmt.Fprintf(w, "%d\t", n)
+	}
+	fmt.Fprintf(w, "%d\n", grandTotal)
+	w.Flush()
+
+	b.WriteString("\nCounts are subtitle lines containing listed language, grouped by quarter of the runtime.\n")
+	b.WriteString("No dialogue is quoted.\n")
+	return b.String()
+}
+
+// quarterLabel names a quarter of the runtime, including its time range when known
+func (a Advisory) quarterLabel(q int) string {
+	names := []string{"1st qtr", "2nd qtr", "3rd qtr", "4th qtr"}
+	if a.Runtime <= 0 {
+		return names[q]
+	}
+	quarter := a.Runtime / 4
+	return fmt.Sprintf("%s (%d-%dm)", names[q], int(quarter*float64(q)/60), int(quarter*float64(q+1)/60))
+}
+
+// formatRuntime formats seconds like "1h 52m"
+func formatRuntime(seconds float64) string {
+	minutes := int(seconds/60 + 0.5)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
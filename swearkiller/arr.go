@@ -0,0 +1,210 @@
+package swearkiller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArrOptions says how the server cleans the movies and episodes Radarr and Sonarr report
+// importing (see Server.handleArr)
+type ArrOptions struct {
+	Replace bool     // Replace the imported file with the clean version instead of writing it beside it
+	Force   bool     // Clean imports even if the quality check fails
+	Profile string   // Built-in profile for these jobs (see Profiles), if any
+	PathMap []string // "from=to" path prefixes turning Radarr's and Sonarr's paths into this machine's
+}
+
+// arrEvent is the part of a Radarr or Sonarr webhook notification the server uses
+type arrEvent struct {
+	EventType string `json:"eventType"` // "Download" for an import, "Test" from the settings page
+	Movie     *struct {
+		FolderPath string `json:"folderPath"`
+	} `json:"movie"`
+	MovieFile *arrFile `json:"movieFile"`
+	Series    *struct {
+		Path string `json:"path"`
+	} `json:"series"`
+	EpisodeFile  *arrFile  `json:"episodeFile"`
+	EpisodeFiles []arrFile `json:"episodeFiles"` // Sent instead of episodeFile for some multi-file imports
+}
+
+// arrFile is an imported file
+type arrFile struct {
+	Path         string `json:"path"`
+	RelativePath string `json:"relativePath"` // From the movie or series folder
+}
+
+// videos returns the imported files' paths as Radarr or Sonarr sees them
+func (e arrEvent) videos() []string {
+	var root string
+	var files []arrFile
+	switch {
+	case e.MovieFile != nil:
+		files = []arrFile{*e.MovieFile}
+		if e.Movie != nil {
+			root = e.Movie.FolderPath
+		}
+	case e.EpisodeFile != nil || len(e.EpisodeFiles) > 0:
+		files = e.EpisodeFiles
+		if e.EpisodeFile != nil {
+			files = []arrFile{*e.EpisodeFile}
+		}
+		if e.Series != nil {
+			root = e.Series.Path
+		}
+	}
+	var paths []string
+	for _, f := range files {
+		switch {
+		case f.Path != "":
+			paths = append(paths, f.Path)
+		case root != "" && f.RelativePath != "":
+			paths = append(paths, root+"/"+f.RelativePath)
+		}
+	}
+	return paths
+}
+
+// validate checks the path map, so a mistake shows up when the server starts
+func (o ArrOptions) validate() error {
+	if err := validatePathMap(o.PathMap); err != nil {
+		return err
+	}
+	if o.Profile != "" {
+		if _, err := FindProfile(o.Profile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePathMap checks every entry of a path map is "from=to"
+func validatePathMap(pathMap []string) error {
+	for _, mapping := range pathMap {
+		if from, to, ok := strings.Cut(mapping, "="); !ok || from == "" || to == "" {
+			return fmt.Errorf("path map %q must be like /movies=/mnt/media/movies", mapping)
+		}
+	}
+	return nil
+}
+
+// mapPath replaces the longest "from=to" prefix of pathMap that path starts with, or
+// returns path as it is if none does. The rest of the path takes the separators of the
+// side it is mapped to, so paths can be mapped between Windows and other systems.
+func mapPath(pathMap []string, path string) string {
+	best, bestFrom := path, ""
+	for _, mapping := range pathMap {
+		from, to, _ := strings.Cut(mapping, "=")
+		from = strings.TrimRight(from, `/\`)
+		rest, ok := strings.CutPrefix(path, from)
+		if !ok || (rest != "" && rest[0] != '/' && rest[0] != '\\') || len(from) <= len(bestFrom) {
+			continue
+		}
+		if strings.Contains(to, `\`) && !strings.Contains(to, "/") {
+			rest = strings.ReplaceAll(rest, "/", `\`)
+		} else {
+			rest = strings.ReplaceAll(rest, `\`, "/")
+		}
+		best, bestFrom = strings.TrimRight(to, `/\`)+rest, from
+	}
+	return best
+}
+
+// handleArr receives Radarr's and Sonarr's webhook notifications and queues a job for
+// every movie or episode imported, using the subtitle beside it. Other events are
+// acknowledged and ignored.
+func (s *Server) handleArr(w http.ResponseWriter, r *http.Request) {
+	var event arrEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid notification: %v", err)
+		return
+	}
+	if event.EventType == "Test" {
+		writeJSON(w, http.StatusOK, map[string]string{"message": "Swear Killer is listening"})
+		return
+	}
+	if event.EventType != "Download" {
+		writeJSON(w, http.StatusOK, map[string]string{"message": fmt.Sprintf("ignored the %s event", event.EventType)})
+		return
+	}
+	videos := event.videos()
+	if len(videos) == 0 {
+		writeError(w, http.StatusBadRequest, "the notification names no imported file")
+		return
+	}
+
+	// Check every file first, so one bad file doesn't leave the import half queued
+	var requests []JobRequest
+	for _, reported := range videos {
+		video := filepath.FromSlash(mapPath(s.opts.Arr.PathMap, reported))
+		// The subtitle and the output are beside the video, so checking it covers them
+		if err := s.checkPaths(JobRequest{Video: video}); err != nil {
+			writeError(w, http.StatusForbidden, "%v", err)
+			return
+		}
+		if _, err := os.Stat(video); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "can't read %s (reported as %s); map the path with --arr-path-map if Radarr or Sonarr sees it elsewhere", video, reported)
+			return
+		}
+		if warning := CleanedWarning(r.Context(), video); warning != "" {
+			continue // Our own replaced output being imported again
+		}
+		subtitle := SubtitleFor(video)
+		if subtitle == "" {
+			writeError(w, http.StatusUnprocessableEntity, "no subtitle beside %s (looked for %s)", video, strings.Join(SubtitleExtensions, ", "))
+			return
+		}
+		output, err := ApplyContainer(video, filepath.Join(filepath.Dir(video), CleanOutputName(video)), "same")
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "%v", err)
+			return
+		}
+		req := JobRequest{Video: video, Subtitle: subtitle, Output: output, Profile: s.opts.Arr.Profile, Force: s.opts.Arr.Force}
+		// The original is only replaced by an output known to be whole
+		req.CheckOutput = s.opts.Arr.Replace
+		if err := validateJobRequest(&req); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "%v", err)
+			return
+		}
+		requests = append(requests, req)
+	}
+	if len(requests) == 0 {
+		writeJSON(w, http.StatusOK, map[string]string{"message": "already clean; nothing to do"})
+		return
+	}
+
+	var queued []ServerJob
+	for _, req := range requests {
+		job := &ServerJob{ID: s.newJobID(), Request: req, Status: ServerJobQueued, Log: []string{}, Created: time.Now()}
+		if s.opts.Arr.Replace {
+			job.Replaces = req.Video
+		}
+		if !s.enqueue(job) {
+			writeError(w, http.StatusServiceUnavailable, "the job queue is full; try again later")
+			return
+		}
+		s.mu.Lock()
+		queued = append(queued, *job)
+		s.mu.Unlock()
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"jobs": queued})
+}
+
+// replaceOriginal moves a finished job's output over the file it replaces
+func (s *Server) replaceOriginal(job *ServerJob, logFn func(string)) error {
+	s.mu.Lock()
+	output, original := job.Request.Output, job.Replaces
+	s.mu.Unlock()
+	replaced, err := ReplaceOriginal(original, output, BackupNone)
+	if err != nil {
+		return fmt.Errorf("failed to replace %s with the clean version: %v", original, err)
+	}
+	s.update(job, func() { job.Request.Output = replaced })
+	logFn("Replaced " + original + " with the clean version")
+	return nil
+}
@@ -0,0 +1,146 @@
+package swearkiller
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+)
+
+// ReviewStatus is how far the review of a job's held-back matches has got, so a large
+// library can be split between several reviewers
+type ReviewStatus string
+
+const (
+	ReviewNone       ReviewStatus = ""            // Nothing was held back for review
+	ReviewPending    ReviewStatus = "pending"     // Waiting for a reviewer
+	ReviewInProgress ReviewStatus = "in_progress" // Some matches have been decided
+	ReviewDone       ReviewStatus = "done"        // Every held-back match has been decided
+)
+
+// ReviewUpdate changes a job's review over the HTTP API; fields left out stay as they are
+type ReviewUpdate struct {
+	Assignee  *string                `json:"assignee"`  // Reviewer to assign, or "" to unassign
+	Status    *ReviewStatus          `json:"status"`    // Usually left out; it follows the decisions
+	Decisions map[int]ReviewDecision `json:"decisions"` // By index into the job's matches
+}
+
+// handleReviewers lists the reviewers jobs can be assigned to; empty means any name
+func (s *Server) handleReviewers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, append([]string{}, s.opts.Reviewers...))
+}
+
+// handleReview assigns a job's review and records decisions. When the review is done, a
+// finished job is encoded again with the held-back matches the reviewer chose to mute.
+func (s *Server) handleReview(w http.ResponseWriter, r *http.Request) {
+	var update ReviewUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid review: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "no job %s", r.PathValue("id"))
+		return
+	}
+	if err := s.applyReview(job, update); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if job.ReviewStatus == ReviewDone && job.Status == ServerJobDone {
+		if reviewed := reviewedStarts(job.Matches); !slices.Equal(reviewed, job.Request.Reviewed) {
+			select {
+			case s.queue <- job:
+				job.Request.Reviewed = reviewed
+				job.Status = ServerJobQueued
+				job.Progress = 0
+				job.Log = append(job.Log, fmt.Sprintf("Review done; encoding again with %d reviewed match(es) muted", len(reviewed)))
+			default:
+				writeError(w, http.StatusServiceUnavailable, "the review is saved, but the job queue is full; mark it done again later")
+				return
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, *job)
+}
+
+// applyReview checks and applies an update to a job's review; the caller holds the lock
+func (s *Server) applyReview(job *ServerJob, update ReviewUpdate) error {
+	if update.Assignee != nil && *update.Assignee != "" && len(s.opts.Reviewers) > 0 && !slices.Contains(s.opts.Reviewers, *update.Assignee) {
+		return fmt.Errorf("unknown reviewer %q", *update.Assignee)
+	}
+	if (update.Status != nil || len(update.Decisions) > 0) && job.ReviewStatus == ReviewNone {
+		return fmt.Errorf("job %s has nothing to review", job.ID)
+	}
+	for i := range update.Decisions {
+		if i < 0 || i >= len(job.Matches) {
+			return fmt.Errorf("job %s has no match %d", job.ID, i)
+		}
+		if m := job.Matches[i]; m.Muted && m.Decision == ReviewUndecided {
+			return fmt.Errorf("match %d was muted without review", i)
+		}
+	}
+	undecided := 0
+	for i, m := range job.Matches {
+		if decision, ok := update.Decisions[i]; ok {
+			m.Decision = decision
+		}
+		if !m.Muted && m.Decision == ReviewUndecided {
+			undecided++
+		}
+	}
+	if update.Status != nil {
+		switch *update.Status {
+		case ReviewPending, ReviewInProgress:
+		case ReviewDone:
+			if undecided > 0 {
+				return fmt.Errorf("%d held-back match(es) are still undecided", undecided)
+			}
+		default:
+			return fmt.Errorf("review status must be pending, in_progress or done")
+		}
+	}
+
+	if update.Assignee != nil {
+		job.Assignee = *update.Assignee
+	}
+	for i, decision := range update.Decisions {
+		job.Matches[i].Decision = decision
+	}
+	switch {
+	case update.Status != nil:
+		job.ReviewStatus = *update.Status
+	case len(update.Decisions) > 0 && undecided == 0:
+		job.ReviewStatus = ReviewDone
+	case len(update.Decisions) > 0:
+		job.ReviewStatus = ReviewInProgress
+	}
+	return nil
+}
+
+// reviewedStarts returns the start times of the matches a reviewer chose to mute
+func reviewedStarts(matches []ServerMatch) []float64 {
+	var starts []float64
+	for _, m := range matches {
+		if m.Decision == ReviewAccepted {
+			starts = append(starts, m.Start)
+		}
+	}
+	return starts
+}
+
+// keepDecisions copies review decisions onto a job's matches after it is run again,
+// matching them up by start time
+func keepDecisions(matches, previous []ServerMatch) {
+	for i := range matches {
+		for _, p := range previous {
+			if p.Decision != ReviewUndecided && math.Abs(p.Start-matches[i].Start) < 0.001 {
+				matches[i].Decision = p.Decision
+			}
+		}
+	}
+}
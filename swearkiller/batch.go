@@ -0,0 +1,172 @@
+package swearkiller
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SubtitleExtensions are the subtitle file types a batch looks for beside each video
+var SubtitleExtensions = []string{".srt", ".vtt", ".ass", ".ssa"}
+
+// BatchItem is one file of a batch and where its subtitle and clean output are
+type BatchItem struct {
+	Video    string
+	Subtitle string // "" if none was found beside the video
+	Output   string
+}
+
+// BatchResult is how one item of a batch went
+type BatchResult struct {
+	Item   BatchItem
+	Result JobResult
+	Err    error // nil if it was cleaned
+}
+
+// SubtitleFor returns the subtitle beside video, like "movie.srt" or "movie.en.srt" for
+// "movie.mkv", or "" if there is none. Forced tracks are left out, as ProcessJob adds them
+// to the full track itself.
+func SubtitleFor(video string) string {
+	stem := strings.TrimSuffix(video, filepath.Ext(video))
+	for _, ext := range SubtitleExtensions {
+		if _, err := os.Stat(stem + ext); err == nil {
+			return stem + ext
+		}
+	}
+	// Then a track named for its language, like "movie.en.srt"
+	entries, _ := os.ReadDir(filepath.Dir(video))
+	prefix := filepath.Base(stem) + "."
+	for _, ext := range SubtitleExtensions {
+		for _, entry := range entries {
+			name := entry.Name()
+			if strings.HasPrefix(name, prefix) && strings.EqualFold(filepath.Ext(name), ext) && !IsForcedSubtitle(name) {
+				return filepath.Join(filepath.Dir(video), name)
+			}
+		}
+	}
+	return ""
+}
+
+// FindBatchItems lists the videos and audio files in paths, which are files or folders
+// searched with their subfolders, with the subtitle beside each and its clean output:
+// named by template for profile, next to it or in outputDir if set. Clean outputs are
+// left out, and the items are sorted by path.
+func FindBatchItems(paths []string, outputDir string, template NameTemplate, profile string) ([]BatchItem, error) {
+	var videos []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		if !info.IsDir() {
+			videos = append(videos, path)
+			continue
+		}
+		filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip what can't be read rather than giving up on the whole batch
+			}
+			if d.IsDir() {
+				if file != path && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if (IsVideoFile(file) || IsAudioFile(file)) && !IsCleanName(file) && !template.Matches(file) && !IsPartialOutput(file) {
+				videos = append(videos, file)
+			}
+			return nil
+		})
+	}
+	sort.Strings(videos)
+	videos = slices.Compact(videos)
+
+	items := make([]BatchItem, len(videos))
+	outputs := map[string]bool{}
+	for i, video := range videos {
+		dir := filepath.Dir(video)
+		if outputDir != "" {
+			dir = outputDir
+		}
+		output := filepath.Join(dir, template.Name(video, profile))
+		if outputs[output] {
+			// Like movie.mkv beside movie.avi; the second keeps its own type
+			output = filepath.Join(dir, template.name(video, strings.TrimPrefix(filepath.Ext(video), "."), profile, time.Now()))
+		}
+		outputs[output] = true
+		items[i] = BatchItem{Video: video, Subtitle: SubtitleFor(video), Output: output}
+	}
+	return items, nil
+}
+
+// RunBatch cleans the items with ProcessJob, up to workers at a time. Every job takes its
+// options from base. A failed item, even one that panics, doesn't stop the others; items
+// without a subtitle fail straight away. logFn gets each item's messages, and onProgress
+// how far the whole batch is from 0 to 1. When ctx is cancelled the running jobs are
+// stopped and the rest not started. The results are in the items' order.
+func RunBatch(ctx context.Context, base JobRequest, items []BatchItem, swears []string, workers int,
+	logFn func(item BatchItem, message string), onProgress func(progress float64)) []BatchResult {
+	workers = max(1, min(workers, len(items)))
+	results := make([]BatchResult, len(items))
+	progress := make([]float64, len(items))
+	var mu sync.Mutex
+	report := func(i int, p float64) {
+		mu.Lock()
+		defer mu.Unlock()
+		progress[i] = p
+		total := 0.0
+		for _, p := range progress {
+			total += p
+		}
+		onProgress(total / float64(len(items)))
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = runBatchItem(ctx, base, items[i], swears, func(message string) { logFn(items[i], message) },
+					func(p float64) { report(i, p) })
+				report(i, 1)
+			}
+		}()
+	}
+	for i := range items {
+		if ctx.Err() != nil {
+			results[i] = BatchResult{Item: items[i], Err: jobErrorf(FailureCancelled, "stopped before starting")}
+			continue
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// runBatchItem cleans one item of a batch, turning a panic into its failure
+func runBatchItem(ctx context.Context, base JobRequest, item BatchItem, swears []string, logFn func(string), onProgress func(float64)) (result BatchResult) {
+	result.Item = item
+	defer func() {
+		if r := recover(); r != nil {
+			result.Err = fmt.Errorf("crashed: %v", r)
+		}
+	}()
+	if item.Subtitle == "" {
+		result.Err = jobErrorf(FailureInput, "no subtitle beside the video (looked for %s)", strings.Join(SubtitleExtensions, ", "))
+		return result
+	}
+	req := base
+	req.Video, req.Subtitle, req.Output = item.Video, item.Subtitle, item.Output
+	result.Result, result.Err = ProcessJob(ctx, req, swears, logFn, ProgressFunc(onProgress))
+	return result
+}
@@ -0,0 +1,184 @@
+package swearkiller
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Pipeline strategies the benchmark compares
+const (
+	StrategyReencode = "Full re-encode" // Picture and sound both re-encoded
+	StrategySmart    = "Smart render"   // Picture copied, only the sound re-encoded (what a normal run does)
+	StrategyEDL      = "EDL only"       // A mute list the player follows; the video isn't touched
+)
+
+// DefaultBenchmarkSeconds is how much of the sample each strategy runs through
+const DefaultBenchmarkSeconds = 60.0
+
+// DefaultBenchmarkVideoCodec is the encoder the full re-encode uses
+const DefaultBenchmarkVideoCodec = "libx264"
+
+// BenchmarkOptions configures a benchmark
+type BenchmarkOptions struct {
+	Seconds    float64 // Length of the sample to run (0 = DefaultBenchmarkSeconds)
+	VideoCodec string  // Encoder for the full re-encode (empty = DefaultBenchmarkVideoCodec)
+}
+
+// BenchmarkResult is how one strategy did on the sample
+type BenchmarkResult struct {
+	Strategy string
+	Elapsed  time.Duration
+	Size     int64   // Bytes written
+	SSIM     float64 // Picture similarity to the source from 0 to 1, 1 if untouched, 0 if it couldn't be measured
+	Err      error
+}
+
+// Speed returns how many times faster than real time the strategy ran on seconds of video
+func (r BenchmarkResult) Speed(seconds float64) float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return seconds / r.Elapsed.Seconds()
+}
+
+// BenchmarkSegments returns a mute of one second every ten seconds, standing in for a
+// sample's real plan when it has no subtitle
+func BenchmarkSegments(seconds float64) []Segment {
+	var segments []Segment
+	for start := 5.0; start+1 <= seconds; start += 10 {
+		segments = append(segments, Segment{Start: start, End: start + 1})
+	}
+	return segments
+}
+
+// Benchmark runs the first seconds of video through each strategy with the same segments,
+// timing each and measuring what it writes. The outputs go to a temporary folder that is
+// removed afterwards. Audio files skip the full re-encode, as they have no picture.
+func Benchmark(ctx context.Context, video string, segments []Segment, opts BenchmarkOptions, logFn func(string)) ([]BenchmarkResult, float64, error) {
+	seconds := cmp.Or(opts.Seconds, DefaultBenchmarkSeconds)
+	if duration, err := ProbeDuration(ctx, video); err == nil && duration > 0 && duration < seconds {
+		seconds = duration
+	}
+	segments = LimitSegments(segments, seconds)
+	dir, err := os.MkdirTemp("", tempPrefix+"benchmark-")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	ext := ".mkv" // Holds whatever the sample has
+	if IsAudioFile(video) {
+		ext = filepath.Ext(video)
+	}
+	encodeOpts := EncodeOptions{MaxDuration: seconds}.WithStreams(ctx, video, "sample"+ext)
+
+	var results []BenchmarkResult
+	strategies := []string{StrategyReencode, StrategySmart}
+	if IsAudioFile(video) {
+		strategies = strategies[1:]
+	}
+	for _, strategy := range strategies {
+		logFn(fmt.Sprintf("Running the first %.0fs through: %s", seconds, strategy))
+		output := filepath.Join(dir, strings.ReplaceAll(strings.ToLower(strategy), " ", "-")+ext)
+		strategyOpts := encodeOpts
+		if strategy == StrategyReencode {
+			strategyOpts.VideoCodec = cmp.Or(opts.VideoCodec, DefaultBenchmarkVideoCodec)
+		}
+		start := time.Now()
+		err := RunFFmpegContext(ctx, BuildFFmpegArgs(video, output, segments, strategyOpts), 0, nil)
+		result := BenchmarkResult{Strategy: strategy, Elapsed: time.Since(start), SSIM: 1}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, 0, ctx.Err()
+			}
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		if info, err := os.Stat(output); err == nil {
+			result.Size = info.Size()
+		}
+		if strategy == StrategyReencode {
+			logFn("Comparing the re-encoded picture with the source...")
+			if result.SSIM, err = MeasureSSIM(ctx, output, video, seconds); err != nil {
+				logFn(fmt.Sprintf("Warning: Could not compare the picture: %v", err))
+			}
+		}
+		results = append(results, result)
+	}
+
+	var edl bytes.Buffer
+	start := time.Now()
+	err = WriteMuteEDL(&edl, segments)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "sample.edl"), edl.Bytes(), 0644)
+	}
+	results = append(results, BenchmarkResult{Strategy: StrategyEDL, Elapsed: time.Since(start), Size: int64(edl.Len()), SSIM: 1, Err: err})
+	return results, seconds, nil
+}
+
+// ssimRe picks the overall score out of FFmpeg's ssim filter summary
+var ssimRe = regexp.MustCompile(`SSIM .*All:([0-9.]+)`)
+
+// MeasureSSIM compares the first seconds of two videos' pictures, returning FFmpeg's SSIM
+// score: 1 for identical, and above about 0.98 hard to tell apart
+func MeasureSSIM(ctx context.Context, video, reference string, seconds float64) (float64, error) {
+	var stderr bytes.Buffer
+	limit := fmt.Sprintf("%.3f", seconds)
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-nostats", "-t", limit, "-i", video, "-t", limit, "-i", reference,
+		"-lavfi", "[0:v][1:v]ssim", "-f", "null", "-")
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("%v %s", err, lastLines(stderr.String(), 2))
+	}
+	m := ssimRe.FindStringSubmatch(stderr.String())
+	if m == nil {
+		return 0, fmt.Errorf("FFmpeg didn't report a score")
+	}
+	return strconv.ParseFloat(m[1], 64)
+}
+
+// FormatBenchmark lays the results out as a table, with each strategy's estimated time for
+// the whole video when its runtime is known
+func FormatBenchmark(results []BenchmarkResult, seconds, runtime float64) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Strategy\tTime\tSpeed\tWhole video\tSize\tPicture")
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(w, "%s\tfailed: %v\n", r.Strategy, r.Err)
+			continue
+		}
+		speed, whole := "-", "-"
+		if r.Strategy != StrategyEDL {
+			speed = fmt.Sprintf("%.1fx", r.Speed(seconds))
+			if estimate := runtime / r.Speed(seconds); runtime > 0 && estimate < 60 {
+				whole = fmt.Sprintf("~%.0fs", estimate)
+			} else if runtime > 0 {
+				whole = "~" + formatRuntime(estimate)
+			}
+		}
+		picture := "identical"
+		switch {
+		case r.Strategy == StrategyEDL:
+			picture = "untouched; the player mutes"
+		case r.SSIM == 0:
+			picture = "not measured"
+		case r.SSIM < 1:
+			picture = fmt.Sprintf("SSIM %.4f", r.SSIM)
+		}
+		fmt.Fprintf(w, "%s\t%.1fs\t%s\t%s\t%s\t%s\n", r.Strategy, r.Elapsed.Seconds(), speed, whole, FormatBytes(r.Size), picture)
+	}
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,181 @@
+package swearkiller
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CachePolicy limits what the caches keep. A zero limit means no limit.
+type CachePolicy struct {
+	MaxAge   time.Duration // Remove items not used for this long
+	MaxBytes int64         // Then remove the least recently used items until the caches fit
+}
+
+// DefaultCachePolicy keeps a month of cached items, up to 20 GB
+var DefaultCachePolicy = CachePolicy{MaxAge: 30 * 24 * time.Hour, MaxBytes: 20 << 30}
+
+// staleTempAge is how old a temporary folder must be before it is treated as left behind by
+// a run that crashed; a newer one may belong to a run still going
+const staleTempAge = 24 * time.Hour
+
+// tempPrefix starts the name of every temporary folder this package creates
+const tempPrefix = "swear-killer-"
+
+// Kinds of cache entry
+const (
+	CacheTranscript = "transcript"
+	CacheTemp       = "temp"
+)
+
+// CacheEntry is one item that can be pruned: a video's cached transcript or a temporary
+// folder left behind by a crashed run
+type CacheEntry struct {
+	Kind     string // CacheTranscript or CacheTemp
+	Path     string
+	Size     int64
+	Modified time.Time // When it was last used
+}
+
+// CacheEntries lists what is in the transcript cache and the leftover temporary folders,
+// least recently used first. An empty transcriptDir means TranscriptCacheDir().
+func CacheEntries(transcriptDir string) ([]CacheEntry, error) {
+	if transcriptDir == "" {
+		transcriptDir = TranscriptCacheDir()
+	}
+	transcripts, err := scanCacheDir(transcriptDir, CacheTranscript, "")
+	if err != nil {
+		return nil, err
+	}
+	temps, err := scanCacheDir(os.TempDir(), CacheTemp, tempPrefix)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	entries := transcripts
+	for _, entry := range temps {
+		if now.Sub(entry.Modified) >= staleTempAge {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Modified.Before(entries[j].Modified) })
+	return entries, nil
+}
+
+// scanCacheDir lists the folders in dir whose names start with prefix, each as one entry.
+// A missing dir is empty.
+func scanCacheDir(dir, kind, prefix string) ([]CacheEntry, error) {
+	items, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache folder: %v", err)
+	}
+	var entries []CacheEntry
+	for _, item := range items {
+		if !item.IsDir() || !strings.HasPrefix(item.Name(), prefix) {
+			continue
+		}
+		entry := CacheEntry{Kind: kind, Path: filepath.Join(dir, item.Name())}
+		filepath.WalkDir(entry.Path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				if !d.IsDir() {
+					entry.Size += info.Size()
+				}
+				if info.ModTime().After(entry.Modified) {
+					entry.Modified = info.ModTime()
+				}
+			}
+			return nil
+		})
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// SelectPrune returns the entries the policy removes at the given time: leftover temporary
+// folders and those older than MaxAge, then the least recently used until the rest fit in
+// MaxBytes. Entries must be least recently used first, as CacheEntries returns them.
+func SelectPrune(entries []CacheEntry, policy CachePolicy, now time.Time) (remove, keep []CacheEntry) {
+	var total int64
+	for _, entry := range entries {
+		if entry.Kind == CacheTemp || (policy.MaxAge > 0 && now.Sub(entry.Modified) > policy.MaxAge) {
+			remove = append(remove, entry)
+			continue
+		}
+		keep = append(keep, entry)
+		total += entry.Size
+	}
+	for policy.MaxBytes > 0 && total > policy.MaxBytes && len(keep) > 0 {
+		total -= keep[0].Size
+		remove, keep = append(remove, keep[0]), keep[1:]
+	}
+	return remove, keep
+}
+
+// CacheReport says what pruning removed and what it kept
+type CacheReport struct {
+	Removed   []CacheEntry
+	Freed     int64
+	Kept      int
+	KeptBytes int64
+	Failed    []error // Items that couldn't be removed
+}
+
+// String summarizes the report in one line
+func (r CacheReport) String() string {
+	summary := fmt.Sprintf("Removed %d item(s), freeing %s; kept %d item(s) using %s",
+		len(r.Removed), FormatBytes(r.Freed), r.Kept, FormatBytes(r.KeptBytes))
+	if len(r.Failed) > 0 {
+		summary += fmt.Sprintf(" (%d could not be removed)", len(r.Failed))
+	}
+	return summary
+}
+
+// PruneCaches applies a policy to the caches. With dryRun set nothing is removed, but the
+// report still lists what would be.
+func PruneCaches(transcriptDir string, policy CachePolicy, dryRun bool) (CacheReport, error) {
+	entries, err := CacheEntries(transcriptDir)
+	if err != nil {
+		return CacheReport{}, err
+	}
+	remove, keep := SelectPrune(entries, policy, time.Now())
+	var report CacheReport
+	for _, entry := range keep {
+		report.Kept++
+		report.KeptBytes += entry.Size
+	}
+	for _, entry := range remove {
+		if !dryRun {
+			if err := os.RemoveAll(entry.Path); err != nil {
+				report.Failed = append(report.Failed, err)
+				continue
+			}
+		}
+		report.Removed = append(report.Removed, entry)
+		report.Freed += entry.Size
+	}
+	return report, nil
+}
+
+// FormatBytes formats a size like "1.5 GB"
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, 0
+	for value >= unit && suffix < 3 {
+		value /= unit
+		suffix++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "KMGT"[suffix])
+}
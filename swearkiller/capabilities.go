@@ -0,0 +1,145 @@
+package swearkiller
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strings"
+)
+
+// Tool is an external program Swear Killer can use
+type Tool struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+	Path      string `json:"path,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+// Feature is a detector or integration and whether it works on this machine
+type Feature struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Requires    []string `json:"requires,omitempty"` // Tools the feature needs
+	Available   bool     `json:"available"`
+}
+
+// Capabilities describes what this build can do on this machine so front-ends and
+// scripts can offer only the options that will work
+type Capabilities struct {
+	Detectors        []Feature `json:"detectors"`
+	Actions          []Action  `json:"actions"`
+	SubtitleFormats  []string  `json:"subtitle_formats"`          // Subtitle files that can be read directly
+	EmbeddedFormats  []string  `json:"embedded_subtitle_formats"` // Embedded subtitle codecs that can be extracted
+	Languages        []string  `json:"languages"`                 // Built-in swear list languages
+	HardwareEncoders []string  `json:"hardware_encoders"`         // Hardware video encoders FFmpeg offers
+	Integrations     []Feature `json:"integrations"`
+	Tools            []Tool    `json:"tools"`
+}
+
+// hardwareEncoderSuffixes identify FFmpeg's hardware video encoders by name
+var hardwareEncoderSuffixes = []string{"_nvenc", "_qsv", "_vaapi", "_videotoolbox", "_amf", "_v4l2m2m", "_mf", "_omx"}
+
+// findTool looks up an external program on the PATH and reads its version line, if it
+// has a way to print one
+func findTool(name string, versionArgs ...string) Tool {
+	tool := Tool{Name: name}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return tool
+	}
+	tool.Available = true
+	tool.Path = path
+	if len(versionArgs) == 0 {
+		return tool
+	}
+	output, _ := exec.Command(path, versionArgs...).CombinedOutput()
+	if line, _, _ := strings.Cut(string(output), "\n"); line != "" {
+		tool.Version = strings.TrimSpace(line)
+	}
+	return tool
+}
+
+// HardwareEncoders lists the hardware video encoders the installed FFmpeg offers
+func HardwareEncoders() []string {
+	output, err := exec.Command("ffmpeg", "-hide_banner", "-encoders").Output()
+	if err != nil {
+		return nil
+	}
+	return parseHardwareEncoders(output)
+}
+
+// parseHardwareEncoders picks hardware video encoders out of `ffmpeg -encoders` output
+func parseHardwareEncoders(output []byte) []string {
+	encoders := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// Encoder lines look like " V....D h264_nvenc  NVIDIA NVENC H.264 encoder"
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "V") || len(fields[0]) != 6 {
+			continue
+		}
+		for _, suffix := range hardwareEncoderSuffixes {
+			if strings.HasSuffix(fields[1], suffix) {
+				encoders = append(encoders, fields[1])
+				break
+			}
+		}
+	}
+	return encoders
+}
+
+// DetectCapabilities checks which tools are installed and reports what can be used
+func DetectCapabilities() Capabilities {
+	tools := []Tool{
+		findTool("ffmpeg", "-version"),
+		findTool("ffprobe", "-version"),
+		findTool("ffplay", "-version"),
+		findTool(DefaultWhisperCommand),
+		findTool("ccextractor"),
+		findTool(DefaultOCRCommand, "--version"),
+	}
+	installed := map[string]bool{}
+	for _, tool := range tools {
+		installed[tool.Name] = tool.Available
+	}
+	feature := func(name, description string, requires ...string) Feature {
+		f := Feature{Name: name, Description: description, Requires: requires, Available: true}
+		for _, tool := range requires {
+			f.Available = f.Available && installed[tool]
+		}
+		return f
+	}
+
+	caps := Capabilities{
+		Detectors: []Feature{
+			feature("subtitle", "Swear words in subtitle text"),
+			feature("phrases", "Phrases split across lines and subtitle blocks"),
+			feature("obfuscation", "Disguised spellings like f*ck and sh1t"),
+			feature("tv-edit", "Subtitles from an already-censored TV edit"),
+			feature("bleep-tone", "Existing 1 kHz bleep tones in the audio", "ffmpeg"),
+			feature("commercials", "Commercial breaks from black frames and silence", "ffmpeg"),
+			feature("language", "Subtitle language, to add built-in swear lists"),
+			feature("quality", "Subtitles that don't match the video", "ffprobe"),
+			feature("transcription", "Swears in the video's speech, for videos without subtitles", "ffmpeg", "ffprobe", DefaultWhisperCommand),
+			feature("ocr", "Blu-ray and DVD image subtitles, read with OCR", "ffmpeg", "ffprobe", DefaultOCRCommand),
+		},
+		Actions:          CensorActions(),
+		SubtitleFormats:  []string{"srt", "vtt", "ass", "ssa"},
+		EmbeddedFormats:  []string{"subrip", "ass", "ssa", "webvtt", "mov_text", CaptionsCEA608, CaptionsTeletext},
+		Languages:        BuiltinLanguages(),
+		HardwareEncoders: []string{},
+		Integrations: []Feature{
+			feature("ffmpeg", "Encoding the cleaned video", "ffmpeg"),
+			feature("ffprobe", "Video duration, audio language and embedded subtitles", "ffprobe"),
+			feature("comskip-edl", "Reading Comskip EDL files for commercial breaks"),
+			feature("ffplay", "Playing each match's audio during rapid review", "ffplay"),
+			feature("captions", "Reading closed captions and DVB teletext from TV recordings", "ffmpeg", "ffprobe"),
+			feature("ccextractor", "Reading closed captions FFmpeg can't", "ccextractor"),
+		},
+		Tools: tools,
+	}
+	if installed["ffmpeg"] {
+		caps.HardwareEncoders = HardwareEncoders()
+	}
+	return caps
+}
@@ -0,0 +1,159 @@
+package swearkiller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Kinds of caption a TV recording can carry
+const (
+	CaptionsCEA608   = "cea-608"      // Closed captions (CEA-608/708) hidden in the video stream
+	CaptionsTeletext = "dvb_teletext" // DVB teletext subtitles, as text
+	CaptionsDVB      = "dvb_subtitle" // DVB subtitles, drawn as images
+)
+
+// ErrImageCaptions is returned for captions that are pictures rather than text
+var ErrImageCaptions = errors.New("DVB subtitles are images and can't be read as text; use a separate subtitle or transcribe the audio instead")
+
+// CaptionSource is one set of captions found in a recording
+type CaptionSource struct {
+	Kind     string // One of the Captions* kinds
+	Stream   int    // Index of the subtitle stream among the subtitle streams; unused for CEA-608
+	Language string
+}
+
+// Readable reports whether the captions are text that can be turned into a subtitle. DVB
+// subtitles are pictures and would need OCR.
+func (c CaptionSource) Readable() bool {
+	return c.Kind != CaptionsDVB
+}
+
+// String describes the captions, like "closed captions" or "teletext (deu)"
+func (c CaptionSource) String() string {
+	name := map[string]string{CaptionsCEA608: "closed captions", CaptionsTeletext: "teletext", CaptionsDVB: "DVB subtitles"}[c.Kind]
+	if c.Language != "" {
+		name += " (" + c.Language + ")"
+	}
+	return name
+}
+
+// FindCaptions lists the closed captions and DVB subtitles in a video, readable ones first
+func FindCaptions(ctx context.Context, video string) ([]CaptionSource, error) {
+	output, err := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-print_format", "json", "-show_streams", video).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video: %v", err)
+	}
+	var probe struct {
+		Streams []struct {
+			CodecType      string `json:"codec_type"`
+			CodecName      string `json:"codec_name"`
+			ClosedCaptions int    `json:"closed_captions"`
+			Tags           struct {
+				Language string `json:"language"`
+			} `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+
+	var readable, images []CaptionSource
+	subtitle, foundCC := 0, false
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			if stream.ClosedCaptions == 1 && !foundCC {
+				readable = append(readable, CaptionSource{Kind: CaptionsCEA608})
+				foundCC = true // The lavfi trick only reads the first video stream's captions
+			}
+		case "subtitle":
+			source := CaptionSource{Kind: stream.CodecName, Stream: subtitle, Language: stream.Tags.Language}
+			switch stream.CodecName {
+			case CaptionsTeletext:
+				readable = append(readable, source)
+			case CaptionsDVB:
+				images = append(images, source)
+			}
+			subtitle++
+		}
+	}
+	return append(readable, images...), nil
+}
+
+// ExtractCaptions converts a recording's captions to an SRT file. Closed captions are read
+// with FFmpeg's lavfi movie source, falling back to CCExtractor when it is installed;
+// teletext needs an FFmpeg built with libzvbi. If ctx is cancelled the extraction is
+// stopped and the partly written file removed.
+func ExtractCaptions(ctx context.Context, video string, source CaptionSource, srtPath string) error {
+	var err error
+	switch source.Kind {
+	case CaptionsCEA608:
+		err = runCaptionCommand(ctx, "ffmpeg", "-v", "error", "-f", "lavfi", "-i", "movie="+escapeFilterValue(video)+"[out0+subcc]",
+			"-map", "0:s", "-c:s", "srt", "-y", srtPath)
+		if err != nil && ctx.Err() == nil {
+			if _, lookErr := exec.LookPath("ccextractor"); lookErr == nil {
+				err = runCaptionCommand(ctx, "ccextractor", video, "-out=srt", "-o", srtPath)
+			}
+		}
+	case CaptionsTeletext:
+		err = runCaptionCommand(ctx, "ffmpeg", "-v", "error", "-txt_format", "text", "-i", video,
+			"-map", fmt.Sprintf("0:s:%d", source.Stream), "-c:s", "srt", "-y", srtPath)
+		if err != nil {
+			err = fmt.Errorf("%v (teletext needs an FFmpeg built with libzvbi)", err)
+		}
+	case CaptionsDVB:
+		return ErrImageCaptions
+	default:
+		return fmt.Errorf("unknown kind of captions %q", source.Kind)
+	}
+	if ctx.Err() != nil {
+		os.Remove(srtPath)
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %v", source, err)
+	}
+	if info, err := os.Stat(srtPath); err != nil || info.Size() == 0 {
+		return fmt.Errorf("no %s found in the video", source)
+	}
+	return nil
+}
+
+// ReadCaptions extracts a recording's captions and reads them, leaving no files behind
+func ReadCaptions(ctx context.Context, video string, source CaptionSource) ([]Cue, SRTReport, error) {
+	dir, err := os.MkdirTemp("", tempPrefix+"captions-")
+	if err != nil {
+		return nil, SRTReport{}, err
+	}
+	defer os.RemoveAll(dir)
+	srtPath := filepath.Join(dir, "captions.srt")
+	if err := ExtractCaptions(ctx, video, source, srtPath); err != nil {
+		return nil, SRTReport{}, err
+	}
+	return ReadSRTFileWithReport(srtPath)
+}
+
+// runCaptionCommand runs an extraction command, returning its error output on failure
+func runCaptionCommand(ctx context.Context, name string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// escapeFilterValue escapes a path for use as a filter option inside a filtergraph, which
+// FFmpeg unescapes twice: once for the option and once for the graph
+func escapeFilterValue(value string) string {
+	option := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(value)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `[`, `\[`, `]`, `\]`, `,`, `\,`, `;`, `\;`).Replace(option)
+}
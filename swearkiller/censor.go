@@ -0,0 +1,198 @@
+package swearkiller
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CensorAction draws one way of censoring as FFmpeg filters. Detection, profiles and plans
+// only decide which Action each segment gets; BuildFFmpegArgs asks each action for its part
+// of the filter graph, so a new censor style is one more CensorAction passed to
+// RegisterCensorAction.
+type CensorAction interface {
+	// AudioFilter returns a filter graph fragment that censors segments in the audio
+	// labeled input (like "[0:a]") and ends by labeling the result [output], or "" to leave
+	// the audio alone. Labels used inside start with output, so fragments can share a graph.
+	AudioFilter(segments []Segment, opts EncodeOptions, input, output string) string
+	// VideoFilter is AudioFilter for the picture
+	VideoFilter(segments []Segment, opts EncodeOptions, input, output string) string
+}
+
+// censorActions holds the built-in actions and any registered since
+var censorActions = map[Action]CensorAction{
+	ActionMute:    muteAction{},
+	ActionTone:    toneAction{},
+	ActionBeep:    beepAction{},
+	ActionReplace: replaceAction{},
+	ActionBlur:    blurAction{},
+	ActionCut:     cutAction{},
+}
+
+// RegisterCensorAction adds a censor style, or replaces the one with the same name. It
+// isn't safe to call while videos are being encoded.
+func RegisterCensorAction(name Action, action CensorAction) {
+	censorActions[name] = action
+}
+
+// LookupCensorAction returns the censor style for name, with "" meaning mute
+func LookupCensorAction(name Action) (CensorAction, bool) {
+	action, ok := censorActions[Segment{Action: name}.EffectiveAction()]
+	return action, ok
+}
+
+// CensorActions lists the names of every censor style, sorted
+func CensorActions() []Action {
+	var names []Action
+	for name := range censorActions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ParseAction checks name is a censor style, with "" meaning mute
+func ParseAction(name string) (Action, error) {
+	action := Segment{Action: Action(strings.ToLower(strings.TrimSpace(name)))}.EffectiveAction()
+	if _, ok := censorActions[action]; !ok {
+		names := make([]string, 0, len(censorActions))
+		for _, a := range CensorActions() {
+			names = append(names, string(a))
+		}
+		return "", fmt.Errorf("unknown censor action %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	return action, nil
+}
+
+// muteAction silences the audio, with fades if EncodeOptions.Fade is set
+type muteAction struct{}
+
+func (muteAction) AudioFilter(segments []Segment, opts EncodeOptions, input, output string) string {
+	return fmt.Sprintf("%s%s[%s]", input, opts.muteFilter(segments), output)
+}
+
+func (muteAction) VideoFilter(segments []Segment, opts EncodeOptions, input, output string) string {
+	return ""
+}
+
+// toneAction mutes the audio and plays a soft tone over it, at EncodeOptions.ToneFrequency
+// and ToneVolume
+type toneAction struct{}
+
+func (toneAction) AudioFilter(segments []Segment, opts EncodeOptions, input, output string) string {
+	source := fmt.Sprintf("sine=frequency=%g:sample_rate=48000", cmp.Or(opts.ToneFrequency, 440))
+	return overlayFilter(segments, opts, input, output, source, cmp.Or(opts.ToneVolume, 0.1))
+}
+
+func (toneAction) VideoFilter(segments []Segment, opts EncodeOptions, input, output string) string {
+	return ""
+}
+
+// beepAction is the loud 1 kHz bleep of broadcast TV
+type beepAction struct{}
+
+func (beepAction) AudioFilter(segments []Segment, opts EncodeOptions, input, output string) string {
+	return overlayFilter(segments, opts, input, output, "sine=frequency=1000:sample_rate=48000", 0.5)
+}
+
+func (beepAction) VideoFilter(segments []Segment, opts EncodeOptions, input, output string) string {
+	return ""
+}
+
+// replaceAction mutes the audio and plays EncodeOptions.ReplacementAudio over it, looped,
+// or the soft tone if there is none
+type replaceAction struct{}
+
+func (replaceAction) AudioFilter(segments []Segment, opts EncodeOptions, input, output string) string {
+	if opts.ReplacementAudio == "" {
+		return toneAction{}.AudioFilter(segments, opts, input, output)
+	}
+	source := fmt.Sprintf("amovie=%s:loop=0,aresample=48000", escapeFilterValue(opts.ReplacementAudio))
+	return overlayFilter(segments, opts, input, output, source, 1)
+}
+
+func (replaceAction) VideoFilter(segments []Segment, opts EncodeOptions, input, output string) string {
+	return ""
+}
+
+// blurAction blurs the whole picture and leaves the audio alone, for what is seen rather
+// than heard
+type blurAction struct{}
+
+func (blurAction) AudioFilter(segments []Segment, opts EncodeOptions, input, output string) string {
+	return ""
+}
+
+func (blurAction) VideoFilter(segments []Segment, opts EncodeOptions, input, output string) string {
+	return fmt.Sprintf("%sboxblur=luma_radius=20:luma_power=3:enable='%s'[%s]", input, enableExpression(segments), output)
+}
+
+// cutAction takes the segments out altogether. That changes the whole command rather than
+// one stream, so BuildFFmpegArgs hands cuts to BuildCutArgs and there are no filters here.
+type cutAction struct{}
+
+func (cutAction) AudioFilter(segments []Segment, opts EncodeOptions, input, output string) string {
+	return ""
+}
+
+func (cutAction) VideoFilter(segments []Segment, opts EncodeOptions, input, output string) string {
+	return ""
+}
+
+// overlayFilter mutes segments and mixes the audio from source in during them at volume
+func overlayFilter(segments []Segment, opts EncodeOptions, input, output, source string, volume float64) string {
+	muted, overlay := output+"_muted", output+"_overlay"
+	return fmt.Sprintf("%s%s[%s];"+
+		"%s,volume=volume='%g*gt(%s,0)':eval=frame[%s];"+
+		"[%s][%s]amix=inputs=2:duration=first:normalize=0[%s]",
+		input, opts.muteFilter(segments), muted, source, volume, enableExpression(segments), overlay, muted, overlay, output)
+}
+
+// censorChain runs the stream labeled input through each action's filter for its
+// segments, one after the other, labeling the result [output]. It returns "" if no action
+// touches the stream. Actions that aren't registered are muted.
+func censorChain(segments []Segment, opts EncodeOptions, input, output string, video bool) string {
+	var actions []Action
+	for _, seg := range segments {
+		if action := seg.EffectiveAction(); !slices.Contains(actions, action) {
+			actions = append(actions, action)
+		}
+	}
+	var fragments []string
+	current := input
+	for _, action := range actions {
+		censor, ok := LookupCensorAction(action)
+		if !ok {
+			censor = muteAction{}
+		}
+		var own []Segment
+		for _, seg := range segments {
+			if seg.EffectiveAction() == action {
+				own = append(own, seg)
+			}
+		}
+		label := output + "_" + string(action)
+		var fragment string
+		if video {
+			fragment = censor.VideoFilter(own, opts, current, label)
+		} else {
+			fragment = censor.AudioFilter(own, opts, current, label)
+		}
+		if fragment != "" {
+			fragments = append(fragments, fragment)
+			current = "[" + label + "]"
+		}
+	}
+	if len(fragments) == 0 {
+		return ""
+	}
+	last := len(fragments) - 1
+	fragments[last] = strings.TrimSuffix(fragments[last], current) + "[" + output + "]"
+	return strings.Join(fragments, ";")
+}
+
+// onlyMutes reports whether every segment is simply muted, which needs no filter graph
+func onlyMutes(segments []Segment) bool {
+	return !slices.ContainsFunc(segments, func(seg Segment) bool { return seg.EffectiveAction() != ActionMute })
+}
@@ -0,0 +1,106 @@
+package swearkiller
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Text file encodings DetectCharset recognizes
+const (
+	CharsetUTF8        = "UTF-8"
+	CharsetUTF16LE     = "UTF-16LE"
+	CharsetUTF16BE     = "UTF-16BE"
+	CharsetWindows1252 = "Windows-1252"
+)
+
+// DetectCharset guesses the encoding of a subtitle or word list: a byte order mark wins,
+// then UTF-16 without one (recognized by its zero bytes), then UTF-8 if the text is valid
+// UTF-8. Anything else is taken as Windows-1252, which most older Western subtitles use
+// and which reads Latin-1 text the same way.
+func DetectCharset(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return CharsetUTF8
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return CharsetUTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return CharsetUTF16BE
+	}
+	if charset := detectUTF16(data); charset != "" {
+		return charset
+	}
+	if utf8.Valid(data) {
+		return CharsetUTF8
+	}
+	return CharsetWindows1252
+}
+
+// detectUTF16 recognizes UTF-16 without a byte order mark: in mostly-ASCII text every
+// other byte is zero
+func detectUTF16(data []byte) string {
+	sample := data[:min(len(data), 4096)&^1]
+	if len(sample) < 4 {
+		return ""
+	}
+	var evenZeros, oddZeros int
+	for i := 0; i < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenZeros++
+		}
+		if sample[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	pairs := len(sample) / 2
+	switch {
+	case oddZeros*10 >= pairs*4 && evenZeros*10 < pairs:
+		return CharsetUTF16LE
+	case evenZeros*10 >= pairs*4 && oddZeros*10 < pairs:
+		return CharsetUTF16BE
+	}
+	return ""
+}
+
+// DecodeText converts text in any encoding DetectCharset recognizes to UTF-8, without a
+// byte order mark, and returns the encoding it was in
+func DecodeText(data []byte) (string, string, error) {
+	charset := DetectCharset(data)
+	var enc encoding.Encoding
+	switch charset {
+	case CharsetUTF16LE:
+		enc = unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+		if !bytes.HasPrefix(data, []byte{0xFF, 0xFE}) {
+			enc = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+		}
+	case CharsetUTF16BE:
+		enc = unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+		if !bytes.HasPrefix(data, []byte{0xFE, 0xFF}) {
+			enc = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+		}
+	case CharsetWindows1252:
+		enc = charmap.Windows1252
+	default:
+		return string(bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})), charset, nil
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", charset, fmt.Errorf("failed to decode %s text: %v", charset, err)
+	}
+	return string(decoded), charset, nil
+}
+
+// ReadTextFile reads a text file in any encoding DecodeText handles, returning UTF-8 text
+// and the encoding the file was in
+func ReadTextFile(path string) (string, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	return DecodeText(data)
+}
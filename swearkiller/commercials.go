@@ -0,0 +1,386 @@
+package swearkiller
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Break is a commercial break in a recording, in seconds of recording time
+type Break struct {
+	Start float64
+	End   float64
+}
+
+// CommercialOptions controls black-frame/silence commercial detection
+type CommercialOptions struct {
+	MaxSpotLength  float64 // Longest single commercial in seconds
+	MinBreakLength float64 // Shortest run of commercials that counts as a break
+	MinSpots       int     // Fewest commercials in a break
+	MaxDuration    float64 // Only scan this many seconds from the start (0 = whole file)
+}
+
+// DefaultCommercialOptions finds runs of at least two spots of up to 65 seconds that last a minute or more
+var DefaultCommercialOptions = CommercialOptions{MaxSpotLength: 65, MinBreakLength: 60, MinSpots: 2}
+
+// ParseEDL reads a Comskip/MPlayer edit decision list ("start end type" per line).
+// Cut (0) and commercial break (3) entries are returned; mute and scene markers are ignored.
+func ParseEDL(r io.Reader) ([]Break, error) {
+	var breaks []Break
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected start and end times", lineNum)
+		}
+		start, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid start time: %v", lineNum, err)
+		}
+		end, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid end time: %v", lineNum, err)
+		}
+		if len(fields) > 2 && fields[2] != "0" && fields[2] != "3" {
+			continue
+		}
+		if end <= start {
+			return nil, fmt.Errorf("line %d: end time %.2f is not after start time %.2f", lineNum, end, start)
+		}
+		breaks = append(breaks, Break{Start: start, End: end})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mergeBreaks(breaks), nil
+}
+
+// ReadEDLFile reads commercial breaks from a Comskip EDL file
+func ReadEDLFile(path string) ([]Break, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EDL file: %v", err)
+	}
+	defer file.Close()
+
+	breaks, err := ParseEDL(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse EDL file: %v", err)
+	}
+	return breaks, nil
+}
+
+// EDLPathFor returns the Comskip EDL next to a video (movie.edl for movie.ts), or "" if there is none
+func EDLPathFor(videoPath string) string {
+	edlPath := strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ".edl"
+	if info, err := os.Stat(edlPath); err == nil && !info.IsDir() {
+		return edlPath
+	}
+	return ""
+}
+
+var (
+	blackDetectRe  = regexp.MustCompile(`black_start:\s*([\d.]+)\s+black_end:\s*([\d.]+)`)
+	silenceStartRe = regexp.MustCompile(`silence_start:\s*(-?[\d.]+)`)
+	silenceEndRe   = regexp.MustCompile(`silence_end:\s*([\d.]+)`)
+)
+
+// boundaryGap merges boundaries closer than this many seconds into one
+const boundaryGap = 1.0
+
+// DetectCommercials finds commercial breaks in a recording by looking for runs of short
+// spots separated by moments that are both black and silent
+func DetectCommercials(ctx context.Context, videoPath string, opts CommercialOptions) ([]Break, error) {
+	args := []string{"-hide_banner", "-nostats", "-i", videoPath}
+	if opts.MaxDuration > 0 {
+		args = append(args, "-t", fmt.Sprintf("%.3f", opts.MaxDuration))
+	}
+	args = append(args,
+		"-vf", "blackdetect=d=0.1:pix_th=0.10",
+		"-af", "silencedetect=n=-50dB:d=0.1",
+		"-f", "null", "-")
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting FFmpeg: %v", err)
+	}
+
+	blacks, silences := parseBlackSilence(stderr)
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to scan for commercials: %v", err)
+	}
+	return findBreaks(breakBoundaries(blacks, silences), opts), nil
+}
+
+// parseBlackSilence reads blackdetect and silencedetect intervals from FFmpeg's log output
+func parseBlackSilence(r io.Reader) (blacks, silences []Break) {
+	scanner := bufio.NewScanner(r)
+	silenceStart := -1.0
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := blackDetectRe.FindStringSubmatch(line); m != nil {
+			start, _ := strconv.ParseFloat(m[1], 64)
+			end, _ := strconv.ParseFloat(m[2], 64)
+			blacks = append(blacks, Break{Start: start, End: end})
+		}
+		if m := silenceStartRe.FindStringSubmatch(line); m != nil {
+			silenceStart, _ = strconv.ParseFloat(m[1], 64)
+			if silenceStart < 0 {
+				silenceStart = 0
+			}
+		}
+		if m := silenceEndRe.FindStringSubmatch(line); m != nil && silenceStart >= 0 {
+			end, _ := strconv.ParseFloat(m[1], 64)
+			silences = append(silences, Break{Start: silenceStart, End: end})
+			silenceStart = -1
+		}
+	}
+	return blacks, silences
+}
+
+// breakBoundaries returns the midpoints of black frames that coincide with silence,
+// which is where programs and commercials meet
+func breakBoundaries(blacks, silences []Break) []float64 {
+	var boundaries []float64
+	for _, black := range blacks {
+		for _, silence := range silences {
+			if black.Start < silence.End && silence.Start < black.End {
+				boundaries = append(boundaries, (black.Start+black.End)/2)
+				break
+			}
+		}
+	}
+	sort.Float64s(boundaries)
+
+	// Several black frames in a row make up one boundary
+	var deduped []float64
+	for _, b := range boundaries {
+		if len(deduped) > 0 && b-deduped[len(deduped)-1] < boundaryGap {
+			continue
+		}
+		deduped = append(deduped, b)
+	}
+	return deduped
+}
+
+// findBreaks groups boundaries that are at most one spot apart into commercial breaks
+func findBreaks(boundaries []float64, opts CommercialOptions) []Break {
+	var breaks []Break
+	closeRun := func(run []float64) {
+		if len(run)-1 >= opts.MinSpots && run[len(run)-1]-run[0] >= opts.MinBreakLength {
+			breaks = append(breaks, Break{Start: run[0], End: run[len(run)-1]})
+		}
+	}
+
+	var run []float64
+	for _, b := range boundaries {
+		if len(run) > 0 && b-run[len(run)-1] > opts.MaxSpotLength {
+			closeRun(run)
+			run = nil
+		}
+		run = append(run, b)
+	}
+	if len(run) > 0 {
+		closeRun(run)
+	}
+	return breaks
+}
+
+// mergeBreaks sorts breaks and joins any that overlap
+func mergeBreaks(breaks []Break) []Break {
+	if len(breaks) == 0 {
+		return breaks
+	}
+	sort.Slice(breaks, func(i, j int) bool {
+		return breaks[i].Start < breaks[j].Start
+	})
+	merged := []Break{breaks[0]}
+	for _, b := range breaks[1:] {
+		last := &merged[len(merged)-1]
+		if b.Start <= last.End {
+			if b.End > last.End {
+				last.End = b.End
+			}
+			continue
+		}
+		merged = append(merged, b)
+	}
+	return merged
+}
+
+// BreaksDuration returns the total length of the breaks in seconds
+func BreaksDuration(breaks []Break) float64 {
+	total := 0.0
+	for _, b := range breaks {
+		total += b.End - b.Start
+	}
+	return total
+}
+
+// ExcludeBreaks removes the parts of segments that fall inside commercial breaks
+func ExcludeBreaks(segments []Segment, breaks []Break) []Segment {
+	if len(breaks) == 0 {
+		return segments
+	}
+	var result []Segment
+	for _, seg := range segments {
+		pieces := []Segment{seg}
+		for _, b := range breaks {
+			var next []Segment
+			for _, piece := range pieces {
+				if piece.End <= b.Start || piece.Start >= b.End {
+					next = append(next, piece)
+					continue
+				}
+				if piece.Start < b.Start {
+					before := piece
+					before.End = b.Start
+					next = append(next, before)
+				}
+				if piece.End > b.End {
+					after := piece
+					after.Start = b.End
+					next = append(next, after)
+				}
+			}
+			pieces = next
+		}
+		result = append(result, pieces...)
+	}
+	return result
+}
+
+// inBreak reports whether a time falls inside a commercial break
+func inBreak(t float64, breaks []Break) bool {
+	for _, b := range breaks {
+		if t >= b.Start && t < b.End {
+			return true
+		}
+	}
+	return false
+}
+
+// ProgramTime converts a recording time to program time by removing the commercial breaks before it
+func ProgramTime(t float64, breaks []Break) float64 {
+	program := t
+	for _, b := range breaks {
+		switch {
+		case t >= b.End:
+			program -= b.End - b.Start
+		case t > b.Start:
+			program -= t - b.Start
+		}
+	}
+	return program
+}
+
+// ProgramMatches drops matches whose cue is inside a commercial break and moves the rest
+// to program time, so reports describe the program alone. offset is added to cue times
+// first to line them up with the recording; the returned cue times are program times.
+func ProgramMatches(matches []Match, breaks []Break, offset float64) []Match {
+	var result []Match
+	for _, m := range matches {
+		start, end := m.Cue.Start+offset, m.Cue.End+offset
+		if inBreak((start+end)/2, breaks) {
+			continue
+		}
+		m.Cue.Start = ProgramTime(start, breaks)
+		m.Cue.End = ProgramTime(end, breaks)
+		result = append(result, m)
+	}
+	return result
+}
+
+// FindCommercialBreaks returns the commercial breaks for a recording from edlPath if given,
+// otherwise from a Comskip EDL next to the video, otherwise by scanning the video
+func FindCommercialBreaks(ctx context.Context, videoPath, edlPath string, opts CommercialOptions, logFn func(string)) ([]Break, error) {
+	if edlPath == "" {
+		edlPath = EDLPathFor(videoPath)
+	}
+	if edlPath != "" {
+		if logFn != nil {
+			logFn(fmt.Sprintf("Reading commercial breaks from %s", edlPath))
+		}
+		return ReadEDLFile(edlPath)
+	}
+	if logFn != nil {
+		logFn("Scanning for commercial breaks (black frames and silence)...")
+	}
+	return DetectCommercials(ctx, videoPath, opts)
+}
+
+// RecordingTime converts a program time to recording time by adding back the commercial
+// breaks before it. It is the inverse of ProgramTime outside the breaks.
+func RecordingTime(t float64, breaks []Break) float64 {
+	recording := t
+	for _, b := range breaks {
+		if recording >= b.Start {
+			recording += b.End - b.Start
+		}
+	}
+	return recording
+}
+
+// RemapMode says how an EDL relates the subtitle's timeline to the video's
+type RemapMode string
+
+const (
+	RemapNone   RemapMode = ""       // Subtitle and video share a timeline
+	RemapCut    RemapMode = "cut"    // Subtitle is from the broadcast; the video has the breaks cut out
+	RemapInsert RemapMode = "insert" // Video still has the breaks; the subtitle is from a copy without them
+)
+
+// ParseRemapMode checks a remap mode name, where "" and "none" mean no remapping
+func ParseRemapMode(name string) (RemapMode, error) {
+	switch mode := RemapMode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case RemapNone, "none":
+		return RemapNone, nil
+	case RemapCut, RemapInsert:
+		return mode, nil
+	default:
+		return RemapNone, fmt.Errorf("unknown remap mode %q (use 'cut' or 'insert')", name)
+	}
+}
+
+// RemapCues moves cue timestamps from the subtitle's timeline to the video's using the
+// breaks listed in an EDL. In cut mode, cues that fall inside a removed break are dropped.
+func RemapCues(cues []Cue, breaks []Break, mode RemapMode) []Cue {
+	if mode == RemapNone || len(breaks) == 0 {
+		return cues
+	}
+	var remapped []Cue
+	for _, cue := range cues {
+		switch mode {
+		case RemapCut:
+			if inBreak((cue.Start+cue.End)/2, breaks) {
+				continue
+			}
+			cue.Start = ProgramTime(cue.Start, breaks)
+			cue.End = ProgramTime(cue.End, breaks)
+		case RemapInsert:
+			// Shift the whole cue together so one that straddles a break isn't stretched across it
+			shift := RecordingTime(cue.Start, breaks) - cue.Start
+			cue.Start += shift
+			cue.End += shift
+		}
+		remapped = append(remapped, cue)
+	}
+	return remapped
+}
@@ -0,0 +1,154 @@
+package swearkiller
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MatchType says how a swear word was found in the subtitle text
+type MatchType string
+
+const (
+	MatchWholeWord  MatchType = "word"       // The swear stands on its own as a word or phrase
+	MatchSubstring  MatchType = "substring"  // The swear is part of a longer word
+	MatchObfuscated MatchType = "obfuscated" // A disguised spelling like "f*ck" (see MatchOptions.Deobfuscate)
+	MatchFuzzy      MatchType = "fuzzy"      // A word one letter away from the swear, like "fuckinq" (see MatchOptions.Fuzzy)
+	MatchMarked     MatchType = "marked"     // No swear was found; the user chose to mute the cue (see MarkedMatch)
+)
+
+// Hit is one swear word found in a cue
+type Hit struct {
+	Word       string    `json:"word,omitempty"` // "" for cues marked by hand
+	Type       MatchType `json:"type"`
+	Confidence float64   `json:"confidence"` // How sure we are this is really a swear, from 0 to 1
+}
+
+// DefaultMinConfidence is the confidence a match needs to be muted without review
+const DefaultMinConfidence = 0.5
+
+// matchTypeWeights scales confidence by how the word was found
+var matchTypeWeights = map[MatchType]float64{
+	MatchWholeWord:  1.0,
+	MatchSubstring:  0.6,
+	MatchObfuscated: 0.7,
+	MatchFuzzy:      0.25,
+}
+
+// categorySeverity is the base confidence for each category. Words like "Christ" have
+// legitimate uses far more often than "fuck" does.
+var categorySeverity = map[Category]float64{
+	CategoryStrong:    1.0,
+	CategorySlur:      1.0,
+	CategorySexual:    0.8,
+	CategoryModerate:  0.9,
+	CategoryBlasphemy: 0.7,
+	CategoryOther:     0.8,
+}
+
+// religiousContext lists words that suggest religious names are used reverently
+var religiousContext = []string{"pray", "church", "amen", "bible", "gospel", "savior", "saviour", "lord", "bless", "heaven", "worship", "faith", "crucif", "resurrect", "disciple", "christian"}
+
+// ScoreHits fills in each hit's confidence from how it was found, the word's severity and
+// the rest of the cue's prepared text. A swear of another kind in the same cue or an
+// exclamation makes a hit more likely to be real; religious context makes blasphemy less likely.
+func ScoreHits(hits []Hit, prepared string) []Hit {
+	scored := make([]Hit, len(hits))
+	exclaimed := strings.ContainsAny(prepared, "!?")
+	religious := false
+	for _, word := range religiousContext {
+		if strings.Contains(prepared, word) {
+			religious = true
+			break
+		}
+	}
+
+	for i, hit := range hits {
+		category := CategorizeWord(hit.Word)
+		confidence := categorySeverity[category] * matchTypeWeights[hit.Type]
+		for _, other := range hits {
+			if CategorizeWord(other.Word) != category {
+				confidence += 0.1
+				break
+			}
+		}
+		if category == CategoryBlasphemy {
+			if exclaimed {
+				confidence += 0.1
+			}
+			if religious {
+				confidence -= 0.3
+			}
+		}
+		if hit.Type == MatchFuzzy {
+			// A near miss is as likely a harmless word, so it is held for review however
+			// strong the swear, unless the minimum confidence is lowered to let it through
+			confidence = min(confidence, matchTypeWeights[MatchFuzzy])
+		}
+		hit.Confidence = clampConfidence(confidence)
+		scored[i] = hit
+	}
+	return scored
+}
+
+// clampConfidence keeps a confidence between 0 and 1, rounded to two decimals
+func clampConfidence(c float64) float64 {
+	return math.Round(math.Max(0, math.Min(1, c))*100) / 100
+}
+
+// SplitByConfidence separates matches that are sure enough to mute automatically from
+// those that should be reviewed first
+func SplitByConfidence(matches []Match, minConfidence float64) (confident, review []Match) {
+	for _, m := range matches {
+		if m.Confidence >= minConfidence {
+			confident = append(confident, m)
+		} else {
+			review = append(review, m)
+		}
+	}
+	return confident, review
+}
+
+// substringIndexes returns the byte ranges of every occurrence of substr in text
+func substringIndexes(text, substr string) [][]int {
+	var locs [][]int
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], substr)
+		if i < 0 {
+			break
+		}
+		start := offset + i
+		locs = append(locs, []int{start, start + len(substr)})
+		offset = start + len(substr)
+	}
+	return locs
+}
+
+// bestMatchType returns MatchWholeWord if any of the occurrences stands on its own as a word
+// by the given language rules
+func bestMatchType(text string, locs [][]int, rules WordRules) MatchType {
+	for _, loc := range locs {
+		if rules.wholeWord(isWordBoundary(text, loc[0], true), isWordBoundary(text, loc[1], false)) {
+			return MatchWholeWord
+		}
+	}
+	return MatchSubstring
+}
+
+// isWordBoundary reports whether there is no letter or digit just before (or at) index i
+func isWordBoundary(text string, i int, before bool) bool {
+	var r rune
+	if before {
+		if i == 0 {
+			return true
+		}
+		r, _ = utf8.DecodeLastRuneInString(text[:i])
+	} else {
+		if i >= len(text) {
+			return true
+		}
+		r, _ = utf8.DecodeRuneInString(text[i:])
+	}
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,297 @@
+package swearkiller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigType is the kind of value a config option takes
+type ConfigType string
+
+const (
+	ConfigString ConfigType = "string" // Text, or a list of strings joined with commas
+	ConfigBool   ConfigType = "bool"
+	ConfigNumber ConfigType = "number"
+)
+
+// ConfigSchema lists the options a config file may set and the type of each
+type ConfigSchema map[string]ConfigType
+
+// ConfigEntry is one option read from a config file, with its value as command-line text
+type ConfigEntry struct {
+	Key   string
+	Value string
+	Line  int // Line in the file, 0 if unknown
+}
+
+// ConfigError is a problem with one line of a config file
+type ConfigError struct {
+	Path    string
+	Line    int
+	Message string
+}
+
+func (e ConfigError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ConfigErrors collects every problem in a config file so they can be fixed in one go
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	var lines []string
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parserLineRe splits the line number out of YAML and TOML syntax errors
+var parserLineRe = regexp.MustCompile(`^(?:yaml|toml): line (\d+)(?: \(last key "[^"]*"\))?: (.*)`)
+
+// rawEntry is an option as decoded from YAML, TOML or JSON, before checking it against the schema
+type rawEntry struct {
+	key   string
+	value any
+	line  int
+}
+
+// LoadConfig reads a YAML, TOML or JSON config file (picked by extension) and checks every
+// option against the schema. Unknown options and values of the wrong type are reported
+// with their line and, for likely typos, the option that was probably meant.
+func LoadConfig(path string, schema ConfigSchema) ([]ConfigEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var raw []rawEntry
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		raw, err = decodeYAMLConfig(data)
+	case ".toml":
+		raw, err = decodeTOMLConfig(data)
+	case ".json":
+		raw, err = decodeJSONConfig(data)
+	default:
+		return nil, fmt.Errorf("unsupported config file type %q (use .yaml, .toml or .json)", ext)
+	}
+	if err != nil {
+		var configErr ConfigError
+		if errors.As(err, &configErr) {
+			configErr.Path = path
+			return nil, ConfigErrors{configErr}
+		}
+		if m := parserLineRe.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return nil, ConfigErrors{{Path: path, Line: line, Message: m[2]}}
+		}
+		return nil, ConfigErrors{{Path: path, Message: err.Error()}}
+	}
+
+	var entries []ConfigEntry
+	var problems ConfigErrors
+	for _, r := range raw {
+		typ, ok := schema[r.key]
+		if !ok {
+			message := fmt.Sprintf("unknown option %q", r.key)
+			if suggestion := suggestKey(r.key, schema); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %s?)", suggestion)
+			}
+			problems = append(problems, ConfigError{Path: path, Line: r.line, Message: message})
+			continue
+		}
+		value, ok := configValue(r.value, typ)
+		if !ok {
+			problems = append(problems, ConfigError{Path: path, Line: r.line,
+				Message: fmt.Sprintf("option %q must be a %s, not %s", r.key, typ, describeValue(r.value))})
+			continue
+		}
+		entries = append(entries, ConfigEntry{Key: r.key, Value: value, Line: r.line})
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return entries, nil
+}
+
+// decodeYAMLConfig reads the top-level mapping of a YAML file, keeping each key's line
+func decodeYAMLConfig(data []byte) ([]rawEntry, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, ConfigError{Line: root.Line, Message: "expected a mapping of option: value"}
+	}
+	var raw []rawEntry
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, valueNode := root.Content[i], root.Content[i+1]
+		var value any
+		if err := valueNode.Decode(&value); err != nil {
+			return nil, ConfigError{Line: valueNode.Line, Message: err.Error()}
+		}
+		raw = append(raw, rawEntry{key: key.Value, value: value, line: key.Line})
+	}
+	return raw, nil
+}
+
+// decodeTOMLConfig reads the top-level keys of a TOML file. The decoder doesn't report
+// key positions, so each key's line is found in the text.
+func decodeTOMLConfig(data []byte) ([]rawEntry, error) {
+	var values map[string]any
+	if _, err := toml.Decode(string(data), &values); err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	var raw []rawEntry
+	for key, value := range values {
+		keyRe := regexp.MustCompile(`^\s*["']?` + regexp.QuoteMeta(key) + `["']?\s*=`)
+		line := 0
+		for i, text := range lines {
+			if keyRe.MatchString(text) {
+				line = i + 1
+				break
+			}
+		}
+		raw = append(raw, rawEntry{key: key, value: value, line: line})
+	}
+	sort.Slice(raw, func(i, j int) bool { return raw[i].line < raw[j].line })
+	return raw, nil
+}
+
+// decodeJSONConfig reads the top-level object of a JSON file, keeping each key's line
+func decodeJSONConfig(data []byte) ([]rawEntry, error) {
+	lineAt := func(offset int64) int {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, ConfigError{Line: lineAt(dec.InputOffset()), Message: "expected an object of \"option\": value"}
+	}
+	var raw []rawEntry
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, ConfigError{Line: lineAt(dec.InputOffset()), Message: err.Error()}
+		}
+		line := lineAt(dec.InputOffset())
+		var value any
+		if err := dec.Decode(&value); err != nil {
+			return nil, ConfigError{Line: lineAt(dec.InputOffset()), Message: err.Error()}
+		}
+		raw = append(raw, rawEntry{key: tok.(string), value: value, line: line})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, ConfigError{Line: lineAt(dec.InputOffset()), Message: err.Error()}
+	}
+	return raw, nil
+}
+
+// configValue converts a decoded value to command-line text, reporting false if it has the wrong type
+func configValue(value any, typ ConfigType) (string, bool) {
+	switch typ {
+	case ConfigBool:
+		b, ok := value.(bool)
+		return strconv.FormatBool(b), ok
+	case ConfigNumber:
+		switch n := value.(type) {
+		case int:
+			return strconv.Itoa(n), true
+		case int64:
+			return strconv.FormatInt(n, 10), true
+		case float64:
+			return strconv.FormatFloat(n, 'f', -1, 64), true
+		}
+		return "", false
+	default:
+		switch v := value.(type) {
+		case string:
+			return v, true
+		case []any:
+			var parts []string
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return "", false
+				}
+				parts = append(parts, s)
+			}
+			return strings.Join(parts, ","), true
+		}
+		return "", false
+	}
+}
+
+// describeValue names the type of a decoded value for error messages
+func describeValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "empty"
+	case bool:
+		return fmt.Sprintf("the bool %v", v)
+	case int, int64, float64:
+		return fmt.Sprintf("the number %v", v)
+	case string:
+		return fmt.Sprintf("the string %q", v)
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "a table"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// suggestKey returns the schema option closest to an unknown key, or "" if none is close
+func suggestKey(key string, schema ConfigSchema) string {
+	normalized := strings.ReplaceAll(strings.ToLower(key), "-", "_")
+	best, bestDistance := "", 3 // Suggest options up to two edits away
+	for option := range schema {
+		if option == normalized {
+			return option
+		}
+		if d := editDistance(normalized, option); d < bestDistance || (d == bestDistance && option < best) {
+			best, bestDistance = option, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,110 @@
+package swearkiller
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Output containers to choose from
+const (
+	ContainerAuto = "auto" // MKV for MKV inputs, the same type for audio files, MP4 otherwise
+	ContainerMP4  = "mp4"
+	ContainerMKV  = "mkv"
+	ContainerSame = "same" // The input's own type
+)
+
+// Containers lists the container choices
+var Containers = []string{ContainerAuto, ContainerMP4, ContainerMKV, ContainerSame}
+
+// TextSubtitleCodecs are the subtitle codecs stored as text, which MP4 can hold once
+// converted to its own format
+var TextSubtitleCodecs = []string{"subrip", "ass", "ssa", "webvtt", "mov_text", "text"}
+
+// mp4VideoCodecs are the video codecs that can be copied into MP4 as they are
+var mp4VideoCodecs = []string{"h264", "hevc", "av1", "vp9", "mpeg4", "mpeg2video", "mpeg1video", "mjpeg", "png"}
+
+// subtitleCodecNames are readable names for image subtitle codecs
+var subtitleCodecNames = map[string]string{"hdmv_pgs_subtitle": "PGS", "dvd_subtitle": "VobSub", "dvb_subtitle": "DVB"}
+
+// isMP4Family reports whether path is an MP4-style file (MP4, M4V or MOV)
+func isMP4Family(path string) bool {
+	return slices.Contains([]string{".mp4", ".m4v", ".mov"}, strings.ToLower(filepath.Ext(path)))
+}
+
+// ContainerExt returns the output extension for input in the given container. Auto keeps
+// MKVs as MKV, since their subtitles and audio often don't fit MP4, keeps audio files as
+// they are and makes everything else MP4.
+func ContainerExt(input, container string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(input))
+	switch container {
+	case ContainerAuto, "":
+		if ext == ".mkv" || IsAudioFile(input) {
+			return ext, nil
+		}
+		return ".mp4", nil
+	case ContainerMP4, ContainerMKV:
+		return "." + container, nil
+	case ContainerSame:
+		if ext == "" {
+			return "", fmt.Errorf("the input has no file type to keep")
+		}
+		return ext, nil
+	}
+	return "", fmt.Errorf("unknown container %q (choose from %s)", container, strings.Join(Containers, ", "))
+}
+
+// ApplyContainer changes output's extension to the container chosen for input
+func ApplyContainer(input, output, container string) (string, error) {
+	ext, err := ContainerExt(input, container)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(output, filepath.Ext(output)) + ext, nil
+}
+
+// CheckContainer makes sure the video's streams fit the output's container. Video that
+// would need re-encoding is an error; subtitles that will be left out are warnings. Only
+// MP4-style outputs are checked, as Matroska holds nearly everything.
+func CheckContainer(ctx context.Context, video, output string) ([]string, error) {
+	if !isMP4Family(output) {
+		return nil, nil
+	}
+	data, err := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-print_format", "json", "-show_streams", video).Output()
+	if err != nil {
+		return nil, nil // Nothing to check against; FFmpeg will say what's wrong
+	}
+	var probe struct {
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			CodecName string `json:"codec_name"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+
+	var warnings []string
+	subtitle := 0
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			if !slices.Contains(mp4VideoCodecs, stream.CodecName) {
+				return nil, fmt.Errorf("the video is %s, which can't be copied into %s; choose MKV as the container instead",
+					stream.CodecName, strings.ToUpper(strings.TrimPrefix(filepath.Ext(output), ".")))
+			}
+		case "subtitle":
+			if !slices.Contains(TextSubtitleCodecs, stream.CodecName) {
+				name := cmp.Or(subtitleCodecNames[stream.CodecName], stream.CodecName)
+				warnings = append(warnings, fmt.Sprintf("%s subtitles (track %d) can't go into MP4 and are left out; choose MKV as the container to keep them", name, subtitle))
+			}
+			subtitle++
+		}
+	}
+	return warnings, nil
+}
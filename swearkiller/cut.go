@@ -0,0 +1,30 @@
+package swearkiller
+
+import (
+	"cmp"
+	"fmt"
+)
+
+// CutVideoCodec re-encodes the picture of cut and blurred outputs when
+// EncodeOptions.VideoCodec is empty, as a picture that was changed can't be copied
+const CutVideoCodec = "libx264"
+
+// BuildCutArgs creates the FFmpeg argument list that takes the segments out of the video
+// altogether, so the swears are skipped rather than muted. The picture and the main audio
+// are re-encoded without them. Other audio tracks and the subtitles are left out, as their
+// timing wouldn't fit the shorter video any more.
+func BuildCutArgs(inputVideo, outputVideo string, segments []Segment, opts EncodeOptions) []string {
+	args := append([]string{"-i", inputVideo}, opts.limitArgs()...)
+	args = append(args, opts.threadArgs()...)
+	mainStream, _ := opts.mainAudioInput()
+	keep := fmt.Sprintf("not(%s)", enableExpression(segments))
+	if !IsAudioFile(outputVideo) {
+		args = append(args, "-map", "0:v:0?", "-vf", fmt.Sprintf("select='%s',setpts=N/FRAME_RATE/TB", keep),
+			"-c:v", cmp.Or(opts.VideoCodec, CutVideoCodec))
+	}
+	args = append(args, "-map", mainStream, "-af", fmt.Sprintf("aselect='%s',asetpts=N/SR/TB", keep),
+		"-c:a", audioCodec(outputVideo), "-sn")
+	args = append(args, opts.Metadata.args()...)
+	args = append(args, segmentsTagArgs(outputVideo, segments)...)
+	return append(args, "-y", outputVideo)
+}
@@ -0,0 +1,328 @@
+package swearkiller
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Action says how a segment is censored (see CensorAction)
+type Action string
+
+const (
+	ActionMute    Action = "mute"    // Silence the audio
+	ActionTone    Action = "tone"    // Replace the audio with a soft tone
+	ActionBeep    Action = "beep"    // Replace the audio with a loud 1 kHz bleep, like TV
+	ActionReplace Action = "replace" // Replace the audio with EncodeOptions.ReplacementAudio
+	ActionBlur    Action = "blur"    // Blur the picture, leaving the audio alone
+	ActionCut     Action = "cut"     // Take the segment out of the video altogether (see BuildCutArgs)
+)
+
+// Segment represents a time range for muting audio
+type Segment struct {
+	Start  float64 `json:"start"`            // Start time in seconds
+	End    float64 `json:"end"`              // End time in seconds
+	Action Action  `json:"action,omitempty"` // How to censor the segment; empty means mute
+}
+
+// EffectiveAction returns the segment's action, defaulting to mute
+func (s Segment) EffectiveAction() Action {
+	if s.Action == "" {
+		return ActionMute
+	}
+	return s.Action
+}
+
+// WithAction returns a copy of segments with every action set to action
+func WithAction(segments []Segment, action Action) []Segment {
+	result := make([]Segment, len(segments))
+	for i, seg := range segments {
+		seg.Action = action
+		result[i] = seg
+	}
+	return result
+}
+
+// Match is a subtitle cue that contains one or more swear words
+type Match struct {
+	Cue        Cue
+	Words      []string // Swear words found in the cue, in swear list order
+	Hits       []Hit    // How each word was found and how sure the match is, in the same order
+	Confidence float64  // Highest confidence of any hit, from 0 to 1
+}
+
+// String formats the match for reports: its start time, the cleaned subtitle text, the words
+// found and the confidence
+func (m Match) String() string {
+	words := strings.Join(m.Words, ", ")
+	if words == "" {
+		words = "marked by hand"
+	}
+	return fmt.Sprintf("[%s] %s (%s; %.0f%% confidence)", FormatTimestamp(m.Cue.Start), m.Cue.Text, words, m.Confidence*100)
+}
+
+// MatchOptions controls how subtitle text is matched against swear words
+type MatchOptions struct {
+	// Deobfuscate also matches disguised spellings like "f*ck", "sh1t", "fvck" and "f u c k".
+	// It catches more swears but also more false positives.
+	Deobfuscate bool
+	// PhraseGap joins neighbouring subtitle blocks at most this many seconds apart so
+	// phrases split across them still match (0 = only match within a block)
+	PhraseGap float64
+	// Allow lists harmless words that contain swears, like "Scunthorpe" or "shiitake".
+	// Swears inside them are ignored.
+	Allow []string
+	// WholeWords only counts swears that stand on their own as words and ignores them
+	// inside longer words. What counts as a word depends on the language (see WordRules).
+	WholeWords bool
+	// Languages are the subtitle's languages; their word rules apply to swears that
+	// aren't from a built-in list
+	Languages []string
+	// Fuzzy also matches words one letter away from a swear of five letters or more, to
+	// catch OCR and transcription typos. Those matches are always held for review.
+	Fuzzy bool
+}
+
+// DefaultPhraseGap joins phrases split across blocks less than a second apart
+const DefaultPhraseGap = 1.0
+
+// DefaultMatchOptions is the matching used unless the user asks for something else
+var DefaultMatchOptions = MatchOptions{PhraseGap: DefaultPhraseGap, Allow: DefaultAllowlist}
+
+// matcher finds swear words in subtitle text
+type matcher struct {
+	swears     []string
+	normalized []string
+	phrases    []*regexp.Regexp      // Multi-word swears, nil for single words
+	patterns   []*obfuscationPattern // Obfuscated spellings, nil when not deobfuscating
+	allow      []string              // Normalized allowlist, longest first
+	rules      []WordRules           // Word rules for each swear
+	wholeWords bool
+	fuzzy      bool
+}
+
+// newMatcher prepares the swear list for matching
+func newMatcher(swears []string, opts MatchOptions) *matcher {
+	m := &matcher{swears: swears, normalized: normalizeAll(swears), wholeWords: opts.WholeWords, fuzzy: opts.Fuzzy}
+	m.phrases = newPhrasePatterns(m.normalized)
+	m.rules = swearWordRules(m.normalized, opts.Languages)
+	for _, word := range normalizeAll(opts.Allow) {
+		if word != "" {
+			m.allow = append(m.allow, word)
+		}
+	}
+	// Longer entries go first so "cocktails" is blanked out before "cocktail"
+	sort.SliceStable(m.allow, func(i, j int) bool {
+		return len(m.allow[i]) > len(m.allow[j])
+	})
+	if opts.Deobfuscate {
+		m.patterns = newObfuscationPatterns(m.normalized)
+	}
+	return m
+}
+
+// find reports whether and how the swear at index i occurs in prepared text
+func (m *matcher) find(i int, text string) (MatchType, bool) {
+	if m.normalized[i] == "" {
+		return "", false
+	}
+	var locs [][]int
+	if m.phrases[i] != nil {
+		locs = m.phrases[i].FindAllStringIndex(text, -1)
+	} else {
+		locs = substringIndexes(text, m.normalized[i])
+	}
+	if locs != nil {
+		matchType := bestMatchType(text, locs, m.rules[i])
+		if m.wholeWords && matchType == MatchSubstring {
+			return "", false
+		}
+		return matchType, true
+	}
+	if m.patterns != nil && m.patterns[i] != nil && m.patterns[i].matches(text) {
+		return MatchObfuscated, true
+	}
+	if m.fuzzy && nearWord(text, m.normalized[i]) {
+		return MatchFuzzy, true
+	}
+	return "", false
+}
+
+// matches reports whether the swear at index i occurs in prepared text
+func (m *matcher) matches(i int, text string) bool {
+	_, ok := m.find(i, text)
+	return ok
+}
+
+// prepare normalizes subtitle text for matching and blanks out allowlisted words
+func (m *matcher) prepare(text string) string {
+	prepared := prepareText(text)
+	for _, word := range m.allow {
+		prepared = strings.ReplaceAll(prepared, word, " ")
+	}
+	return prepared
+}
+
+// hitsIn returns the swear words found in prepared text, in swear list order
+func (m *matcher) hitsIn(prepared string) []Hit {
+	var hits []Hit
+	for i, swear := range m.swears {
+		if matchType, ok := m.find(i, prepared); ok {
+			hits = append(hits, Hit{Word: swear, Type: matchType})
+		}
+	}
+	return hits
+}
+
+// FindMatches returns every cue that contains at least one of the swear words.
+// Matching ignores case, accents and fullwidth forms (see NormalizeText), and phrases
+// still match when split by a line break, a hyphen or a short gap between blocks.
+// Each match is scored (see ScoreHits) so uncertain ones can be sent for review.
+func FindMatches(cues []Cue, swears []string, opts MatchOptions) []Match {
+	m := newMatcher(swears, opts)
+	var matches []Match
+	for i, cue := range cues {
+		prepared := m.prepare(cue.Text)
+		if hits := m.hitsIn(prepared); len(hits) > 0 {
+			matches = append(matches, newMatch(cue, hits, prepared))
+		}
+		if opts.PhraseGap > 0 && i+1 < len(cues) {
+			if match, ok := m.splitPhrase(cue, cues[i+1], opts.PhraseGap); ok {
+				matches = append(matches, match)
+			}
+		}
+	}
+	return matches
+}
+
+// newMatch scores the hits found in a cue's prepared text and builds the match
+func newMatch(cue Cue, hits []Hit, prepared string) Match {
+	match := Match{Cue: cue, Hits: ScoreHits(hits, prepared)}
+	for _, hit := range match.Hits {
+		match.Words = append(match.Words, hit.Word)
+		if hit.Confidence > match.Confidence {
+			match.Confidence = hit.Confidence
+		}
+	}
+	return match
+}
+
+// MatchSegments converts matches to mute segments shifted by offset seconds.
+// Segments that would start before zero are skipped with a warning sent to logFn.
+func MatchSegments(matches []Match, offset float64, logFn func(string)) []Segment {
+	var segments []Segment
+	for _, m := range matches {
+		// Apply offset to timestamps
+		adjustedStart := m.Cue.Start + offset
+		adjustedEnd := m.Cue.End + offset
+		// Ensure timestamps are non-negative
+		if adjustedStart < 0 || adjustedEnd < 0 {
+			if logFn != nil {
+				logFn(fmt.Sprintf("Warning: Offset %f makes segment (%f, %f) negative, skipping", offset, m.Cue.Start, m.Cue.End))
+			}
+			continue
+		}
+		segments = append(segments, Segment{Start: adjustedStart, End: adjustedEnd})
+	}
+	return segments
+}
+
+// FindSwearTimestamps searches an SRT file for swear words and returns mute segments
+func FindSwearTimestamps(srtPath string, swears []string, opts MatchOptions, offset float64, logFn func(string)) ([]Segment, error) {
+	cues, err := ReadSRTFile(srtPath)
+	if err != nil {
+		return nil, err
+	}
+	return MatchSegments(FindMatches(cues, swears, opts), offset, logFn), nil
+}
+
+// Options controls Detect. The zero value finds the default swears, with the built-in
+// lists for the languages detected in the subtitle, as the CLI does without flags.
+type Options struct {
+	Swears        []string // Replaces DefaultSwears
+	Allow         []string // Added to DefaultAllowlist
+	Lang          string   // "auto" (default), "none" or codes like "es,fr"
+	Filename      string   // Name the subtitle had, for language tags like "movie.es.srt"; it isn't opened
+	Deobfuscate   bool
+	WholeWords    bool
+	Fuzzy         bool     // Also finds words one letter away from a swear, always below MinConfidence's default
+	PhraseGap     *float64 // Defaults to DefaultPhraseGap
+	MinConfidence *float64 // Defaults to DefaultMinConfidence; 0 keeps every match
+	// Profile is a built-in profile (see Profiles) that picks the matches instead of
+	// Deobfuscate, WholeWords and MinConfidence
+	Profile string
+}
+
+// Detect reads a subtitle in any format and encoding ParseSRT handles from r and returns
+// the matches sure enough to censor, without touching the disk, so services can pass
+// subtitles from memory, request bodies or archives
+func Detect(r io.Reader, opts Options) ([]Match, error) {
+	req := JobRequest{Subtitle: opts.Filename, Lang: opts.Lang, Swears: opts.Swears, Allow: opts.Allow,
+		Deobfuscate: opts.Deobfuscate, WholeWords: opts.WholeWords, Fuzzy: opts.Fuzzy, PhraseGap: opts.PhraseGap,
+		MinConfidence: opts.MinConfidence, Profile: opts.Profile}
+	if err := validateJobOptions(&req); err != nil {
+		return nil, err
+	}
+	cues, err := ParseSRT(r)
+	if err != nil {
+		return nil, err
+	}
+	_, matches, _, err := MatchJob(req, cues, DefaultSwears)
+	return matches, err
+}
+
+// SegmentsDuration returns the total length of the segments in seconds
+func SegmentsDuration(segments []Segment) float64 {
+	total := 0.0
+	for _, seg := range segments {
+		total += seg.End - seg.Start
+	}
+	return total
+}
+
+// actionStrength orders the actions from lightest to heaviest; where segments with different
+// actions overlap, the heavier action is kept
+var actionStrength = map[Action]int{
+	ActionBlur:    0,
+	ActionMute:    1,
+	ActionTone:    2,
+	ActionBeep:    3,
+	ActionReplace: 4,
+	ActionCut:     5,
+}
+
+// MergeSegments combines overlapping segments, and segments with the same action within
+// 1 second of each other. Overlapping segments with different actions get the heavier one,
+// so a cut overlapping a mute becomes one cut.
+func MergeSegments(segments []Segment) []Segment {
+	if len(segments) == 0 {
+		return segments
+	}
+
+	// Sort segments by start time
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].Start < segments[j].Start
+	})
+
+	var merged []Segment
+	current := segments[0]
+	for _, seg := range segments[1:] {
+		sameAction := seg.EffectiveAction() == current.EffectiveAction()
+		if seg.Start < current.End || sameAction && seg.Start <= current.End+1.0 {
+			// Merge if segments overlap, or share an action and are within 1 second
+			if seg.End > current.End {
+				current.End = seg.End
+			}
+			if actionStrength[seg.EffectiveAction()] > actionStrength[current.EffectiveAction()] {
+				current.Action = seg.Action
+			}
+		} else {
+			merged = append(merged, current)
+			current = seg
+		}
+	}
+	merged = append(merged, current)
+	return merged
+}
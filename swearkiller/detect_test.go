@@ -0,0 +1,35 @@
+package swearkiller
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestMergeSegments checks that overlapping segments merge whatever their action, keeping
+// the heavier one, and close segments only merge when they share an action
+func TestMergeSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []Segment
+		want     []Segment
+	}{
+		{"none", nil, nil},
+		{"close mutes", []Segment{{Start: 5, End: 6}, {Start: 1, End: 4.5}}, []Segment{{Start: 1, End: 6}}},
+		{"far apart", []Segment{{Start: 1, End: 2}, {Start: 4, End: 5}}, []Segment{{Start: 1, End: 2}, {Start: 4, End: 5}}},
+		{"cut over a mute", []Segment{{Start: 1, End: 4}, {Start: 3, End: 6, Action: ActionCut}},
+			[]Segment{{Start: 1, End: 6, Action: ActionCut}}},
+		{"mute over a cut", []Segment{{Start: 1, End: 4, Action: ActionCut}, {Start: 2, End: 3, Action: ActionMute}},
+			[]Segment{{Start: 1, End: 4, Action: ActionCut}}},
+		{"beep over a mute", []Segment{{Start: 1, End: 3, Action: ActionMute}, {Start: 2, End: 5, Action: ActionBeep}},
+			[]Segment{{Start: 1, End: 5, Action: ActionBeep}}},
+		{"close but different", []Segment{{Start: 1, End: 2}, {Start: 2.5, End: 3, Action: ActionCut}},
+			[]Segment{{Start: 1, End: 2}, {Start: 2.5, End: 3, Action: ActionCut}}},
+		{"chain", []Segment{{Start: 1, End: 3}, {Start: 2, End: 4, Action: ActionCut}, {Start: 4.5, End: 5, Action: ActionCut}},
+			[]Segment{{Start: 1, End: 5, Action: ActionCut}}},
+	}
+	for _, tt := range tests {
+		if got := MergeSegments(tt.segments); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
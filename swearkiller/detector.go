@@ -0,0 +1,363 @@
+package swearkiller
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Detector finds the subtitle lines, or stretches of speech, to censor. The swear list
+// matcher is one; regular expressions, an outside classifier and a Whisper transcript are
+// others. Chain combines any of them, so a new backend plugs in without the pipeline
+// knowing about it.
+type Detector interface {
+	Name() string
+	Detect(ctx context.Context, cues []Cue) ([]Match, error)
+}
+
+// Detector names, for DetectorSettings
+const (
+	DetectorKeywords   = "keywords"
+	DetectorRegex      = "regex"
+	DetectorClassifier = "classifier"
+	DetectorTranscript = "transcript"
+)
+
+// DetectorNames lists the detectors DetectorSettings can pick
+var DetectorNames = []string{DetectorKeywords, DetectorRegex, DetectorClassifier, DetectorTranscript}
+
+// Match types for hits found by the other detectors than the swear list
+const (
+	MatchPattern    MatchType = "pattern"    // A RegexDetector pattern matched
+	MatchClassified MatchType = "classified" // A ClassifierDetector flagged the line
+)
+
+// KeywordDetector finds the words of a swear list (see FindMatches)
+type KeywordDetector struct {
+	Swears  []string
+	Options MatchOptions
+	Profile *Profile // If set, a broad scan is narrowed down to what the profile counts
+}
+
+func (d KeywordDetector) Name() string { return DetectorKeywords }
+
+func (d KeywordDetector) Detect(ctx context.Context, cues []Cue) ([]Match, error) {
+	if d.Profile != nil {
+		return d.Profile.Apply(FindMatches(cues, d.Swears, BroadMatchOptions(d.Options))), nil
+	}
+	return FindMatches(cues, d.Swears, d.Options), nil
+}
+
+// RegexDetector finds lines matching any of its regular expressions, for things a word
+// list can't say, like "god ?damn(ed|it)?". Its matches are fully confident.
+type RegexDetector struct {
+	Patterns []*regexp.Regexp
+}
+
+// NewRegexDetector compiles patterns (Go's RE2 syntax), ignoring case
+func NewRegexDetector(patterns []string) (RegexDetector, error) {
+	var d RegexDetector
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return d, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		d.Patterns = append(d.Patterns, re)
+	}
+	return d, nil
+}
+
+func (d RegexDetector) Name() string { return DetectorRegex }
+
+func (d RegexDetector) Detect(ctx context.Context, cues []Cue) ([]Match, error) {
+	var matches []Match
+	for _, cue := range cues {
+		var hits []Hit
+		for _, re := range d.Patterns {
+			for _, found := range re.FindAllString(cue.Text, -1) {
+				hits = append(hits, Hit{Word: strings.ToLower(found), Type: MatchPattern, Confidence: 1})
+			}
+		}
+		if len(hits) > 0 {
+			matches = append(matches, matchFromHits(cue, hits))
+		}
+	}
+	return matches, nil
+}
+
+// ClassifierDetector hands the lines to an outside program, like a machine learning model,
+// to decide which to censor. Command is run with the shell and gets one JSON object per
+// line on stdin, {"index":0,"start":1.5,"end":3,"text":"..."}, and prints one for each
+// line it flags, {"index":0,"score":0.9,"words":["..."]}. The score is the match's
+// confidence, so low scores are held back for review like other uncertain matches.
+type ClassifierDetector struct {
+	Command string
+}
+
+// classifierLine is a line as sent to a classifier
+type classifierLine struct {
+	Index int     `json:"index"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// classifierVerdict is a line a classifier flagged
+type classifierVerdict struct {
+	Index int      `json:"index"`
+	Score float64  `json:"score"`
+	Words []string `json:"words"`
+}
+
+func (d ClassifierDetector) Name() string { return DetectorClassifier }
+
+func (d ClassifierDetector) Detect(ctx context.Context, cues []Cue) ([]Match, error) {
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for i, cue := range cues {
+		encoder.Encode(classifierLine{Index: i, Start: cue.Start, End: cue.End, Text: cue.Text})
+	}
+	cmd := shellCommand(ctx, d.Command)
+	cmd.Stdin = &input
+	var output bytes.Buffer
+	var stderr tailBuffer
+	cmd.Stdout, cmd.Stderr = &output, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("classifier failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var matches []Match
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var verdict classifierVerdict
+		if err := json.Unmarshal([]byte(line), &verdict); err != nil {
+			return nil, fmt.Errorf("classifier printed %q: %v", line, err)
+		}
+		if verdict.Index < 0 || verdict.Index >= len(cues) {
+			return nil, fmt.Errorf("classifier flagged line %d, but there are only %d", verdict.Index, len(cues))
+		}
+		score := min(max(verdict.Score, 0), 1)
+		words := verdict.Words
+		if len(words) == 0 {
+			words = []string{DetectorClassifier}
+		}
+		var hits []Hit
+		for _, word := range words {
+			hits = append(hits, Hit{Word: strings.ToLower(word), Type: MatchClassified, Confidence: score})
+		}
+		matches = append(matches, matchFromHits(cues[verdict.Index], hits))
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Cue.Start < matches[j].Cue.Start })
+	return matches, nil
+}
+
+// TranscriptDetector ignores the subtitle and looks for swears in what is actually said,
+// by transcribing Video with Whisper and running Words over the transcript. It catches
+// swears the subtitle toned down or left out, and its matches are timed to the speech.
+type TranscriptDetector struct {
+	Video   string
+	Options TranscribeOptions
+	Words   Detector
+	LogFn   func(string) // Gets Whisper's progress; may be nil
+}
+
+func (d TranscriptDetector) Name() string { return DetectorTranscript }
+
+func (d TranscriptDetector) Detect(ctx context.Context, cues []Cue) ([]Match, error) {
+	logFn := d.LogFn
+	if logFn == nil {
+		logFn = func(string) {}
+	}
+	transcript, err := Transcribe(ctx, d.Video, d.Options, logFn, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transcribe the audio: %v", err)
+	}
+	return d.Words.Detect(ctx, transcript)
+}
+
+// ChainMode says how a Chain combines its detectors' matches
+type ChainMode string
+
+const (
+	ChainUnion     ChainMode = "union"     // Censor what any detector finds
+	ChainIntersect ChainMode = "intersect" // Censor only what every detector finds
+)
+
+// Chain runs several detectors and combines what they find. Matches of the same line are
+// merged, with the words of both; for ChainIntersect a match is kept only if every other
+// detector has one overlapping it in time, and is as confident as the least sure of them.
+type Chain struct {
+	Mode      ChainMode
+	Detectors []Detector
+}
+
+func (c Chain) Name() string {
+	var names []string
+	for _, d := range c.Detectors {
+		names = append(names, d.Name())
+	}
+	return strings.Join(names, "+")
+}
+
+func (c Chain) Detect(ctx context.Context, cues []Cue) ([]Match, error) {
+	var found [][]Match
+	for _, d := range c.Detectors {
+		matches, err := d.Detect(ctx, cues)
+		if err != nil {
+			return nil, fmt.Errorf("%s detector: %v", d.Name(), err)
+		}
+		found = append(found, matches)
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	if c.Mode != ChainIntersect {
+		return unionMatches(slices.Concat(found...)), nil
+	}
+
+	var kept []Match
+	for _, m := range found[0] {
+		agreed := true
+		for _, others := range found[1:] {
+			best := -1.0
+			for _, other := range others {
+				if m.Cue.Start < other.Cue.End && other.Cue.Start < m.Cue.End {
+					m = withHits(m, other.Hits)
+					best = max(best, other.Confidence)
+				}
+			}
+			if best < 0 {
+				agreed = false
+				break
+			}
+			m.Confidence = min(m.Confidence, best)
+		}
+		if agreed {
+			kept = append(kept, m)
+		}
+	}
+	return kept, nil
+}
+
+// unionMatches merges the matches of the same line and sorts them by time
+func unionMatches(matches []Match) []Match {
+	var merged []Match
+	for _, m := range matches {
+		i := slices.IndexFunc(merged, func(other Match) bool {
+			return other.Cue.Start == m.Cue.Start && other.Cue.End == m.Cue.End
+		})
+		if i < 0 {
+			merged = append(merged, m)
+			continue
+		}
+		merged[i] = withHits(merged[i], m.Hits)
+		merged[i].Confidence = max(merged[i].Confidence, m.Confidence)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Cue.Start < merged[j].Cue.Start })
+	return merged
+}
+
+// withHits adds the hits for words m doesn't have yet
+func withHits(m Match, hits []Hit) Match {
+	m.Words, m.Hits = slices.Clone(m.Words), slices.Clone(m.Hits)
+	for _, hit := range hits {
+		if !slices.Contains(m.Words, hit.Word) {
+			m.Words = append(m.Words, hit.Word)
+			m.Hits = append(m.Hits, hit)
+		}
+	}
+	return m
+}
+
+// matchFromHits builds a match from hits that are already scored
+func matchFromHits(cue Cue, hits []Hit) Match {
+	m := withHits(Match{Cue: cue}, hits)
+	for _, hit := range m.Hits {
+		m.Confidence = max(m.Confidence, hit.Confidence)
+	}
+	return m
+}
+
+// DetectorSettings picks the detectors a job runs. Like Hooks it is set by whoever runs the
+// jobs, never by API clients, since the classifier runs a command.
+type DetectorSettings struct {
+	Use        []string          // Detectors from DetectorNames, in order (empty = just keywords)
+	Mode       ChainMode         // How their matches combine (empty = ChainUnion)
+	Patterns   []string          // Regular expressions for "regex"
+	Classifier string            // Shell command for "classifier" (see ClassifierDetector)
+	Transcribe TranscribeOptions // Whisper settings for "transcript"
+}
+
+// Validate checks the settings name known detectors and give each what it needs
+func (s DetectorSettings) Validate() error {
+	if s.Mode != "" && s.Mode != ChainUnion && s.Mode != ChainIntersect {
+		return fmt.Errorf("unknown detector mode %q (available: union, intersect)", s.Mode)
+	}
+	for _, name := range s.Use {
+		switch name {
+		case DetectorKeywords:
+		case DetectorRegex:
+			if len(s.Patterns) == 0 {
+				return fmt.Errorf("the regex detector needs at least one pattern")
+			}
+			if _, err := NewRegexDetector(s.Patterns); err != nil {
+				return err
+			}
+		case DetectorClassifier:
+			if strings.TrimSpace(s.Classifier) == "" {
+				return fmt.Errorf("the classifier detector needs a command")
+			}
+		case DetectorTranscript:
+			if err := s.Transcribe.withDefaults().Validate(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown detector %q (available: %s)", name, strings.Join(DetectorNames, ", "))
+		}
+	}
+	return nil
+}
+
+// Detector builds the detector the settings pick for a video. keywords is the swear list
+// matcher, also used to search the transcript. With a single detector it is returned
+// as it is rather than in a Chain.
+func (s DetectorSettings) Detector(video string, keywords Detector, logFn func(string)) (Detector, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	chain := Chain{Mode: cmp.Or(s.Mode, ChainUnion)}
+	for _, name := range slices.Compact(slices.Clone(s.Use)) {
+		switch name {
+		case DetectorKeywords:
+			chain.Detectors = append(chain.Detectors, keywords)
+		case DetectorRegex:
+			d, _ := NewRegexDetector(s.Patterns) // Checked by Validate
+			chain.Detectors = append(chain.Detectors, d)
+		case DetectorClassifier:
+			chain.Detectors = append(chain.Detectors, ClassifierDetector{Command: s.Classifier})
+		case DetectorTranscript:
+			chain.Detectors = append(chain.Detectors, TranscriptDetector{Video: video, Options: s.Transcribe, Words: keywords, LogFn: logFn})
+		}
+	}
+	switch len(chain.Detectors) {
+	case 0:
+		return keywords, nil
+	case 1:
+		return chain.Detectors[0], nil
+	}
+	return chain, nil
+}
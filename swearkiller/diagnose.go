@@ -0,0 +1,153 @@
+package swearkiller
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ZeroMatchDiagnosis is what was searched when no swears were found, so a silent "nothing
+// to censor" can be told apart from a subtitle that couldn't have matched
+type ZeroMatchDiagnosis struct {
+	Encoding      string        // Text encoding of the subtitle file, "" if it didn't come from one
+	Blocks        int           // Subtitle blocks read
+	Language      LanguageGuess // Language most of the subtitle is in
+	ListLanguages []string      // Languages the swear list covers; the user's own list counts as English
+	Sample        []string      // A few lines from across the subtitle, as they were searched
+	NearMisses    []NearMiss    // Words one letter away from a swear, most frequent first
+}
+
+// NearMiss is a subtitle word one letter away from a swear: a misspelling, a disguise the
+// swear list doesn't catch, or just a harmless word that looks like one
+type NearMiss struct {
+	Word  string
+	Swear string
+	Count int // Times it appears
+}
+
+const (
+	diagnosisSampleLines = 5  // Lines shown from across the subtitle
+	diagnosisSampleWidth = 80 // Characters a sample line is cut to
+	diagnosisNearMisses  = 10 // Near misses listed
+	nearMissMinLength    = 4  // Shorter swears are one letter away from too many words
+)
+
+// DiagnoseZeroMatches looks into why no swears were found in cues: how the subtitle at path
+// was read (path may be "" for captions, OCR or transcripts), its language, what its text
+// looks like and which words almost matched swears
+func DiagnoseZeroMatches(path string, cues []Cue, swears []string, opts MatchOptions) ZeroMatchDiagnosis {
+	d := ZeroMatchDiagnosis{Blocks: len(cues), Language: GuessLanguage(cues), ListLanguages: CombineLists([]string{"en"}, opts.Languages)}
+	if path != "" {
+		if _, encoding, err := ReadTextFile(path); err == nil {
+			d.Encoding = encoding
+		}
+	}
+	for i := 1; i <= diagnosisSampleLines && len(cues) > 0; i++ {
+		line := []rune(cues[(len(cues)-1)*i/(diagnosisSampleLines+1)].Text)
+		if len(line) > diagnosisSampleWidth {
+			line = append(line[:diagnosisSampleWidth-1], '…')
+		}
+		if text := string(line); !slices.Contains(d.Sample, text) {
+			d.Sample = append(d.Sample, text)
+		}
+	}
+	d.NearMisses = findNearMisses(cues, swears, opts.Allow)
+	return d
+}
+
+// String lays the diagnosis out for a log, ending with what to try
+func (d ZeroMatchDiagnosis) String() string {
+	var b strings.Builder
+	b.WriteString("No swears were found. What was searched:\n")
+	subtitle := fmt.Sprintf("%d block(s)", d.Blocks)
+	if d.Encoding != "" {
+		subtitle += ", read as " + d.Encoding
+	}
+	fmt.Fprintf(&b, "- Subtitle: %s\n", subtitle)
+	var names []string
+	for _, code := range d.ListLanguages {
+		names = append(names, LanguageName(code))
+	}
+	language := "not recognised"
+	if d.Language.Code != "" {
+		language = "looks like " + d.Language.String()
+	}
+	fmt.Fprintf(&b, "- Language: %s; the swear list covers %s\n", language, strings.Join(names, ", "))
+	if len(d.Sample) > 0 {
+		b.WriteString("- Sample lines:\n")
+		for _, line := range d.Sample {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+	}
+	if len(d.NearMisses) > 0 {
+		b.WriteString("- Words one letter away from a swear:\n")
+		for _, miss := range d.NearMisses {
+			fmt.Fprintf(&b, "    %q, like %q (%d time(s))\n", miss.Word, miss.Swear, miss.Count)
+		}
+	} else {
+		b.WriteString("- No word is one letter away from a swear\n")
+	}
+
+	var hints []string
+	switch mismatch := LanguageMismatch(d.Language, d.ListLanguages); {
+	case d.Blocks == 0:
+		hints = append(hints, "The subtitle has no readable lines; check it opens in a player or text editor")
+	case mismatch != "":
+		hints = append(hints, mismatch)
+	case d.Language.Code == "" && d.Encoding == CharsetWindows1252:
+		hints = append(hints, "If the sample lines look garbled, the subtitle isn't in "+CharsetWindows1252+"; save it as UTF-8 and try again")
+	}
+	if len(d.NearMisses) > 0 {
+		hints = append(hints, "If one of those words is a swear, add it to your swear list")
+	}
+	if len(hints) == 0 {
+		hints = append(hints, "Nothing looks wrong, so the subtitle may simply be clean")
+	}
+	for _, hint := range hints {
+		fmt.Fprintf(&b, "- Hint: %s\n", hint)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// findNearMisses counts the words of cues one letter away from a single-word swear, leaving
+// out allowed words
+func findNearMisses(cues []Cue, swears, allow []string) []NearMiss {
+	var targets []string
+	for _, swear := range normalizeAll(swears) {
+		if len([]rune(swear)) >= nearMissMinLength && !strings.Contains(swear, " ") {
+			targets = append(targets, swear)
+		}
+	}
+	allowed := normalizeAll(allow)
+	found := map[string]*NearMiss{}
+	for _, cue := range cues {
+		words := strings.FieldsFunc(NormalizeText(cue.Text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("*@$", r)
+		})
+		for _, word := range words {
+			if miss, ok := found[word]; ok {
+				miss.Count++
+				continue
+			}
+			if slices.Contains(allowed, word) {
+				continue
+			}
+			for _, swear := range targets {
+				if word != swear && oneEditApart(word, swear) {
+					found[word] = &NearMiss{Word: word, Swear: swear, Count: 1}
+					break
+				}
+			}
+		}
+	}
+	var misses []NearMiss
+	for _, miss := range found {
+		misses = append(misses, *miss)
+	}
+	slices.SortFunc(misses, func(a, b NearMiss) int {
+		return cmp.Or(b.Count-a.Count, strings.Compare(a.Word, b.Word))
+	})
+	return misses[:min(len(misses), diagnosisNearMisses)]
+}
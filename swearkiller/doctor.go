@@ -0,0 +1,157 @@
+package swearkiller
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// CheckStatus is the outcome of one environment check
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "PASS"
+	CheckFail CheckStatus = "FAIL"
+	CheckSkip CheckStatus = "SKIP" // Nothing to check
+)
+
+// Check is one line of the doctor's checklist
+type Check struct {
+	Name   string      `json:"name"`
+	Status CheckStatus `json:"status"`
+	Detail string      `json:"detail,omitempty"`
+}
+
+// DoctorOptions lists what the doctor should check besides the tools
+type DoctorOptions struct {
+	Dirs []string // Directories that must be writable
+	URLs []string // API endpoints that must be reachable
+}
+
+// requiredFilters are the FFmpeg filters Swear Killer builds commands with
+var requiredFilters = []string{"volume", "sine", "amix", "volumedetect", "silencedetect", "blackdetect"}
+
+// requiredEncoders are the FFmpeg encoders for the clean audio and extracted subtitles
+var requiredEncoders = []string{"aac", "srt"}
+
+// RunDoctor checks the tools, FFmpeg features, directories and network endpoints Swear
+// Killer depends on and returns a checklist
+func RunDoctor(opts DoctorOptions) []Check {
+	var checks []Check
+
+	ffmpeg := findTool("ffmpeg", "-version")
+	checks = append(checks, toolCheck(ffmpeg, "install FFmpeg and make sure it's on your PATH"))
+	checks = append(checks, toolCheck(findTool("ffprobe", "-version"), "it comes with FFmpeg; make sure it's on your PATH"))
+	if ffmpeg.Available {
+		checks = append(checks,
+			featureCheck("FFmpeg filters", "-filters", requiredFilters),
+			featureCheck("FFmpeg encoders", "-encoders", requiredEncoders))
+	} else {
+		checks = append(checks,
+			Check{Name: "FFmpeg filters", Status: CheckSkip, Detail: "FFmpeg not found"},
+			Check{Name: "FFmpeg encoders", Status: CheckSkip, Detail: "FFmpeg not found"})
+	}
+
+	checked := map[string]bool{}
+	for _, dir := range opts.Dirs {
+		if !checked[dir] {
+			checked[dir] = true
+			checks = append(checks, writableCheck(dir))
+		}
+	}
+
+	if len(opts.URLs) == 0 {
+		checks = append(checks, Check{Name: "Network APIs", Status: CheckSkip, Detail: "none configured"})
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	for _, url := range opts.URLs {
+		checks = append(checks, reachableCheck(client, url))
+	}
+	return checks
+}
+
+// DoctorPassed reports whether no check failed
+func DoctorPassed(checks []Check) bool {
+	for _, c := range checks {
+		if c.Status == CheckFail {
+			return false
+		}
+	}
+	return true
+}
+
+// toolCheck reports whether an external program was found, with its version
+func toolCheck(tool Tool, hint string) Check {
+	if !tool.Available {
+		return Check{Name: tool.Name, Status: CheckFail, Detail: "not found; " + hint}
+	}
+	return Check{Name: tool.Name, Status: CheckPass, Detail: tool.Version}
+}
+
+// featureCheck lists FFmpeg's filters or encoders and reports any required ones that are missing
+func featureCheck(name, listArg string, required []string) Check {
+	output, err := exec.Command("ffmpeg", "-hide_banner", listArg).Output()
+	if err != nil {
+		return Check{Name: name, Status: CheckFail, Detail: fmt.Sprintf("could not list them: %v", err)}
+	}
+	available := parseFeatureList(output)
+	var missing []string
+	for _, feature := range required {
+		if !available[feature] {
+			missing = append(missing, feature)
+		}
+	}
+	if len(missing) > 0 {
+		return Check{Name: name, Status: CheckFail, Detail: "missing " + strings.Join(missing, ", ") + "; install a full FFmpeg build"}
+	}
+	return Check{Name: name, Status: CheckPass, Detail: strings.Join(required, ", ")}
+}
+
+// parseFeatureList reads the names from `ffmpeg -filters` or `ffmpeg -encoders` output,
+// where each entry is a flags column followed by the name
+func parseFeatureList(output []byte) map[string]bool {
+	names := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && strings.Trim(fields[0], ".ABCDFIJNSTVX") == "" {
+			names[fields[1]] = true
+		}
+	}
+	return names
+}
+
+// writableCheck reports whether a file can be created in a directory
+func writableCheck(dir string) Check {
+	name := "Write access: " + dir
+	info, err := os.Stat(dir)
+	if err != nil {
+		return Check{Name: name, Status: CheckFail, Detail: "directory not found"}
+	}
+	if !info.IsDir() {
+		return Check{Name: name, Status: CheckFail, Detail: "not a directory"}
+	}
+	file, err := os.CreateTemp(dir, ".swear-killer-doctor-*")
+	if err != nil {
+		return Check{Name: name, Status: CheckFail, Detail: "not writable"}
+	}
+	file.Close()
+	os.Remove(file.Name())
+	return Check{Name: name, Status: CheckPass}
+}
+
+// reachableCheck reports whether an API endpoint answers at all; any HTTP response counts
+func reachableCheck(client *http.Client, url string) Check {
+	name := "Reachable: " + url
+	resp, err := client.Head(url)
+	if err != nil {
+		return Check{Name: name, Status: CheckFail, Detail: err.Error()}
+	}
+	resp.Body.Close()
+	return Check{Name: name, Status: CheckPass, Detail: resp.Status}
+}
@@ -0,0 +1,108 @@
+package swearkiller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseFrameRateRatio reads a frame rate conversion like "23.976:25" (the frame rate the
+// subtitle was timed for, then the video's) and returns the factor subtitle times are
+// multiplied by. Either rate may be a fraction like "24000/1001". An empty ratio is 1.
+func ParseFrameRateRatio(ratio string) (float64, error) {
+	if strings.TrimSpace(ratio) == "" {
+		return 1, nil
+	}
+	from, to, ok := strings.Cut(ratio, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid frame rate ratio %q: expected subtitle:video, like 23.976:25", ratio)
+	}
+	subtitleRate, err := parseFrameRate(from)
+	if err != nil {
+		return 0, fmt.Errorf("invalid frame rate ratio %q: %v", ratio, err)
+	}
+	videoRate, err := parseFrameRate(to)
+	if err != nil {
+		return 0, fmt.Errorf("invalid frame rate ratio %q: %v", ratio, err)
+	}
+	return subtitleRate / videoRate, nil
+}
+
+// parseFrameRate reads a positive frame rate like "25" or "30000/1001"
+func parseFrameRate(rate string) (float64, error) {
+	rate = strings.TrimSpace(rate)
+	numerator, denominator, isFraction := strings.Cut(rate, "/")
+	value, err := strconv.ParseFloat(numerator, 64)
+	if err == nil && isFraction {
+		var d float64
+		if d, err = strconv.ParseFloat(denominator, 64); err == nil && d > 0 {
+			value /= d
+		}
+	}
+	if err != nil || !(value > 0 && value < 1000) {
+		return 0, fmt.Errorf("%q is not a frame rate", rate)
+	}
+	return value, nil
+}
+
+// ScaleCues returns cues with every time multiplied by scale, for a subtitle timed for a
+// different frame rate: one made for a 23.976 fps release falls further behind a 25 fps
+// PAL video with every minute.
+func ScaleCues(cues []Cue, scale float64) []Cue {
+	scaled := make([]Cue, len(cues))
+	for i, cue := range cues {
+		cue.Start *= scale
+		cue.End *= scale
+		scaled[i] = cue
+	}
+	return scaled
+}
+
+// DriftCues returns cues shifted by an amount that grows linearly from 0 at the first
+// cue's start to drift seconds at the last cue's start, for a subtitle that slowly drifts
+// out of sync. Offsets measured at the start and end of the video are applied by using the
+// start one as the usual offset and their difference as the drift.
+func DriftCues(cues []Cue, drift float64) []Cue {
+	if len(cues) == 0 || drift == 0 {
+		return cues
+	}
+	first, last := cues[0].Start, cues[0].Start
+	for _, cue := range cues {
+		first, last = min(first, cue.Start), max(last, cue.Start)
+	}
+	shifted := make([]Cue, len(cues))
+	for i, cue := range cues {
+		shift := drift
+		if last > first {
+			shift = drift * (cue.Start - first) / (last - first)
+		}
+		cue.Start += shift
+		cue.End += shift
+		shifted[i] = cue
+	}
+	return shifted
+}
+
+// ShiftCues returns cues moved offset seconds later (earlier if negative), dropping the ones
+// that would end before the start of the video and trimming the ones that would start
+// before it
+func ShiftCues(cues []Cue, offset float64) []Cue {
+	var shifted []Cue
+	for _, cue := range cues {
+		cue.Start, cue.End = max(cue.Start+offset, 0), cue.End+offset
+		if cue.End <= 0 {
+			continue
+		}
+		shifted = append(shifted, cue)
+	}
+	return shifted
+}
+
+// CorrectTiming applies a frame rate ratio, then a linear drift, to cues; see ScaleCues and
+// DriftCues. A ratio of 1 and a drift of 0 leave them as they are.
+func CorrectTiming(cues []Cue, scale, drift float64) []Cue {
+	if scale != 1 {
+		cues = ScaleCues(cues, scale)
+	}
+	return DriftCues(cues, drift)
+}
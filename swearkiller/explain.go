@@ -0,0 +1,188 @@
+package swearkiller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Explanation says why a moment of a video was or wasn't muted
+type Explanation struct {
+	At            float64
+	Offset        float64  // Offset added to the subtitle's times
+	Languages     []string // Languages whose built-in lists were searched
+	MinConfidence float64
+	Cues          []Cue    // Subtitle cues on screen at At
+	Matches       []Match  // Matches among those cues that are muted
+	Held          []Match  // Matches among those cues held back for review
+	Segment       *Segment // Final segment covering At, if any
+	Previous      *Cue     // Last cue before At, when none is on screen
+	Next          *Cue     // First cue after At, when none is on screen
+	Hints         []string // Options that would change the outcome for cues that didn't match
+}
+
+// ExplainJob works out what a job would do at one moment of the video (in seconds): which
+// subtitle cue covers it, whether and why it matched, and the segment that ends up muted.
+// Bleep tones and commercial breaks aren't looked at, as finding them means decoding the
+// video. The video is optional; without it ordered chapters aren't followed.
+func ExplainJob(req JobRequest, swears []string, at float64) (Explanation, error) {
+	if _, err := ParseFrameRateRatio(req.FrameRateRatio); err != nil {
+		return Explanation{}, err
+	}
+	cues, _, err := ReadSubtitleTracks(req.subtitles())
+	if err != nil {
+		return Explanation{}, err
+	}
+	if cues, err = correctJobTiming(req, cues); err != nil {
+		return Explanation{}, err
+	}
+	languages, matches, review, err := MatchJob(req, cues, swears)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	e := Explanation{At: at, Offset: req.Offset, Languages: languages, MinConfidence: DefaultMinConfidence}
+	if req.MinConfidence != nil {
+		e.MinConfidence = *req.MinConfidence
+	}
+	covers := func(cue Cue) bool { return cue.Start+req.Offset <= at && at <= cue.End+req.Offset }
+	for i, cue := range cues {
+		switch {
+		case covers(cue):
+			e.Cues = append(e.Cues, cue)
+		case cue.End+req.Offset < at:
+			e.Previous = &cues[i]
+		case e.Next == nil || cue.Start < e.Next.Start:
+			e.Next = &cues[i]
+		}
+	}
+	if len(e.Cues) > 0 {
+		e.Previous, e.Next = nil, nil
+	}
+	for _, m := range matches {
+		if covers(m.Cue) {
+			e.Matches = append(e.Matches, m)
+		}
+	}
+	for _, m := range review {
+		if covers(m.Cue) {
+			e.Held = append(e.Held, m)
+		}
+	}
+	for _, seg := range MergeSegments(MatchSegments(matches, req.Offset, nil)) {
+		if seg.Start <= at && at <= seg.End {
+			e.Segment = &seg
+			break
+		}
+	}
+	if len(e.Matches) == 0 && len(e.Held) == 0 && len(e.Cues) > 0 {
+		e.Hints = explainHints(req, e.Cues, swears, languages)
+	}
+	return e, nil
+}
+
+// explainHints tries the cues again with looser options, naming each one that would have
+// found a swear
+func explainHints(req JobRequest, cues []Cue, swears, languages []string) []string {
+	swears = req.swearList(swears, languages)
+	found := func(change func(*MatchOptions)) string {
+		opts := req.matchOptions(languages)
+		change(&opts)
+		var words []string
+		for _, m := range FindMatches(cues, swears, opts) {
+			for _, word := range m.Words {
+				if !slices.Contains(words, word) {
+					words = append(words, word)
+				}
+			}
+		}
+		return strings.Join(words, ", ")
+	}
+
+	var hints []string
+	if !req.Deobfuscate {
+		if words := found(func(o *MatchOptions) { o.Deobfuscate = true }); words != "" {
+			hints = append(hints, words+" would match as a disguised spelling with --deobfuscate")
+		}
+	}
+	if !req.Fuzzy {
+		if words := found(func(o *MatchOptions) { o.Fuzzy = true }); words != "" {
+			hints = append(hints, words+" is one letter away from a word here; --fuzzy would hold it for review")
+		}
+	}
+	if req.WholeWords {
+		if words := found(func(o *MatchOptions) { o.WholeWords = false }); words != "" {
+			hints = append(hints, words+" is inside a longer word, which --whole-words leaves alone")
+		}
+	}
+	if words := found(func(o *MatchOptions) { o.Allow = nil }); words != "" {
+		hints = append(hints, words+" is inside a word on the allowlist")
+	}
+	if strings.EqualFold(strings.TrimSpace(req.Lang), "none") {
+		hints = append(hints, "only your own list was searched (--lang none)")
+	}
+	return hints
+}
+
+// hitKinds are readable names for how a swear was found
+var hitKinds = map[MatchType]string{
+	MatchWholeWord:  "whole word",
+	MatchSubstring:  "inside a longer word",
+	MatchObfuscated: "disguised spelling",
+	MatchFuzzy:      "one letter away",
+	MatchPattern:    "regular expression",
+	MatchClassified: "flagged by the classifier",
+}
+
+// String lays the explanation out for the terminal
+func (e Explanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "At %s:\n", FormatSRTTime(e.At))
+	shifted := func(cue Cue) string {
+		text := fmt.Sprintf("subtitle #%d, %s --> %s", cue.Index, FormatSRTTime(cue.Start), FormatSRTTime(cue.End))
+		if e.Offset != 0 {
+			text += fmt.Sprintf(" (%s --> %s with the %+gs offset)", FormatSRTTime(cue.Start+e.Offset), FormatSRTTime(cue.End+e.Offset), e.Offset)
+		}
+		return text
+	}
+
+	if len(e.Cues) == 0 {
+		b.WriteString("- No subtitle is on screen\n")
+		if e.Previous != nil {
+			fmt.Fprintf(&b, "- Before: %s: %s\n", shifted(*e.Previous), e.Previous.Text)
+		}
+		if e.Next != nil {
+			fmt.Fprintf(&b, "- After: %s: %s\n", shifted(*e.Next), e.Next.Text)
+		}
+	}
+	for _, cue := range e.Cues {
+		fmt.Fprintf(&b, "- Cue: %s: %s\n", shifted(cue), cue.Text)
+	}
+	writeHits := func(m Match, outcome string) {
+		for _, hit := range m.Hits {
+			fmt.Fprintf(&b, "- Matched %q (%s, %.0f%% confident): %s\n", hit.Word, hitKinds[hit.Type], hit.Confidence*100, outcome)
+		}
+	}
+	for _, m := range e.Matches {
+		writeHits(m, "muted")
+	}
+	for _, m := range e.Held {
+		writeHits(m, fmt.Sprintf("held back for review, below the %.0f%% needed (--min-confidence)", e.MinConfidence*100))
+	}
+	if len(e.Cues) > 0 && len(e.Matches) == 0 && len(e.Held) == 0 {
+		searched := "the swear list"
+		if len(e.Languages) > 0 {
+			searched += " and the built-in lists for " + strings.Join(e.Languages, ", ")
+		}
+		fmt.Fprintf(&b, "- No swear found in %s\n", searched)
+		for _, hint := range e.Hints {
+			fmt.Fprintf(&b, "- Hint: %s\n", hint)
+		}
+	}
+	if e.Segment != nil {
+		fmt.Fprintf(&b, "- Segment: %s --> %s is censored (%s)", FormatSRTTime(e.Segment.Start), FormatSRTTime(e.Segment.End), e.Segment.EffectiveAction())
+	} else {
+		b.WriteString("- Segment: nothing is censored at this time")
+	}
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package swearkiller
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// matchColumns are the columns of a match export
+var matchColumns = []string{"start", "end", "duration_seconds", "words", "subtitle_text", "confidence", "decision", "muted"}
+
+// WriteMatchesCSV writes every match with its mute segment and whether it was muted, as an
+// audit trail for parents and reviewers. Times include the offset and any adjustments made
+// in review; the decision is "mute", "keep" or "undecided" (uncertain and not muted).
+func WriteMatchesCSV(w io.Writer, items []ReviewItem) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(matchColumns); err != nil {
+		return err
+	}
+	for _, item := range items {
+		muted := "no"
+		if item.Decision == ReviewAccepted {
+			muted = "yes"
+		}
+		row := []string{
+			formatSeconds(item.Segment.Start),
+			formatSeconds(item.Segment.End),
+			strconv.FormatFloat(roundMillis(item.Segment.End-item.Segment.Start), 'f', -1, 64),
+			strings.Join(item.Match.Words, ", "),
+			item.Match.Cue.Text,
+			strconv.FormatFloat(item.Match.Confidence, 'f', 2, 64),
+			item.Decision.String(),
+			muted,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteMuteEDL writes the segments as an EDL of mutes (action 1), which Kodi and MPlayer
+// follow during playback, so the video itself is left untouched. Cut segments are written
+// as skips (action 0).
+func WriteMuteEDL(w io.Writer, segments []Segment) error {
+	for _, seg := range segments {
+		action := 1
+		if seg.EffectiveAction() == ActionCut {
+			action = 0
+		}
+		if _, err := fmt.Fprintf(w, "%.3f\t%.3f\t%d\n", seg.Start, seg.End, action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
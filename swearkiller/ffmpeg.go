@@ -0,0 +1,703 @@
+package swearkiller
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncodeOptions tweaks how FFmpeg commands are built
+type EncodeOptions struct {
+	MaxDuration      float64 // Only encode this many seconds from the start (0 = whole video)
+	ToneFrequency    float64 // Frequency of the replacement tone for tone segments in Hz (0 = 440)
+	ToneVolume       float64 // Volume of the replacement tone from 0 to 1 (0 = 0.1)
+	ReplacementAudio string  // Sound file played, looped, over replace segments (empty = the tone)
+	VideoCodec       string  // Re-encode the picture with this FFmpeg encoder, like libx264 (empty = copy it)
+	Fade             float64 // Ramp the volume down and back up over this many seconds around each mute (0 = cut hard)
+
+	// Subtitle streams to copy into the output with their forced flags. Without them FFmpeg
+	// keeps just one subtitle stream, which can be the forced one instead of the full track.
+	Subtitles []SubtitleTrack
+
+	// The video's audio streams. Audio description tracks among them are kept, labeled as
+	// such, and the main audio is never taken from one.
+	Audio              []AudioTrack
+	CensorDescriptions bool // Censor audio description tracks too, as their narration often repeats the dialogue
+	DropSubtitles      bool // Leave the subtitles out, as none of them fit the output container
+
+	// KeepOriginal adds the uncensored main audio to MKV outputs as an extra track that
+	// isn't played by default (see OriginalAudioTitle). Stash, when set, is where
+	// EncodeVideo saves the censored audio afterwards (see WriteStash). Either makes the
+	// censoring reversible.
+	KeepOriginal bool
+	Stash        string
+
+	// Metadata rewrites container tags and chapter titles, like a title with the swears
+	// masked (see TextSanitizer)
+	Metadata MetadataEdits
+
+	Threads int // CPU threads FFmpeg may use for encoding and filters (0 = as many as it likes)
+}
+
+// threadArgs returns the FFmpeg options that hold it to Threads
+func (o EncodeOptions) threadArgs() []string {
+	if o.Threads <= 0 {
+		return nil
+	}
+	threads := strconv.Itoa(o.Threads)
+	return []string{"-threads", threads, "-filter_threads", threads, "-filter_complex_threads", threads}
+}
+
+// limitArgs returns the FFmpeg output options that stop encoding at MaxDuration
+func (o EncodeOptions) limitArgs() []string {
+	if o.MaxDuration <= 0 {
+		return nil
+	}
+	return []string{"-t", fmt.Sprintf("%.3f", o.MaxDuration)}
+}
+
+// mainAudio returns the index of the audio stream to censor, the first that isn't an audio
+// description, or -1 to leave the choice to FFmpeg
+func (o EncodeOptions) mainAudio() int {
+	for _, track := range o.Audio {
+		if !track.Description {
+			return track.Index
+		}
+	}
+	return -1
+}
+
+// descriptions returns the audio description tracks to keep beside the main audio
+func (o EncodeOptions) descriptions() []AudioTrack {
+	if o.mainAudio() < 0 {
+		return nil // Every track is a description, so there's nothing to tell apart
+	}
+	var descriptions []AudioTrack
+	for _, track := range o.Audio {
+		if track.Description {
+			descriptions = append(descriptions, track)
+		}
+	}
+	return descriptions
+}
+
+// mainAudioInput returns the main audio as a -map argument and as a filter graph input
+func (o EncodeOptions) mainAudioInput() (stream, label string) {
+	if index := o.mainAudio(); index >= 0 {
+		return fmt.Sprintf("0:a:%d", index), fmt.Sprintf("[0:a:%d]", index)
+	}
+	return "0:a:0?", "[0:a]"
+}
+
+// mapArgs maps the video unless output is audio-only, the given audio outputs and every
+// carried subtitle stream, marking each subtitle with its original flags so a forced track
+// stays forced and never becomes the default in place of the full track. video is the
+// picture to map, "" for the input's own.
+func (o EncodeOptions) mapArgs(output, video string, audio ...string) []string {
+	var args []string
+	if !IsAudioFile(output) {
+		args = append(args, "-map", cmp.Or(video, "0:v?"))
+	}
+	for _, stream := range audio {
+		args = append(args, "-map", stream)
+	}
+	if len(o.Subtitles) == 0 {
+		return args
+	}
+	for _, track := range o.Subtitles {
+		args = append(args, "-map", fmt.Sprintf("0:s:%d", track.Index))
+	}
+	args = append(args, "-c:s", subtitleCodec(output))
+	for i, track := range o.Subtitles {
+		var flags []string
+		if track.Default {
+			flags = append(flags, "default")
+		}
+		if track.Forced {
+			flags = append(flags, "forced")
+		}
+		disposition := "0"
+		if len(flags) > 0 {
+			disposition = strings.Join(flags, "+")
+		}
+		args = append(args, fmt.Sprintf("-disposition:s:%d", i), disposition)
+	}
+	return args
+}
+
+// descriptionArgs labels the audio outputs: the main audio first as the default, then each
+// description flagged for the visually impaired and named, since streams coming out of a
+// filter graph lose their tags
+func descriptionArgs(descriptions []AudioTrack) []string {
+	if len(descriptions) == 0 {
+		return nil
+	}
+	args := []string{"-disposition:a:0", "default"}
+	for i, track := range descriptions {
+		stream := fmt.Sprintf(":s:a:%d", i+1)
+		args = append(args, fmt.Sprintf("-disposition:a:%d", i+1), "visual_impaired+descriptions",
+			"-metadata"+stream, "title="+cmp.Or(track.Title, "Audio description"))
+		if track.Language != "" {
+			args = append(args, "-metadata"+stream, "language="+track.Language)
+		}
+	}
+	return args
+}
+
+// WithStreams fills in the subtitle and audio streams of video that output keeps (see
+// CarriedSubtitles and AudioTracks)
+func (o EncodeOptions) WithStreams(ctx context.Context, video, output string) EncodeOptions {
+	o.Subtitles = CarriedSubtitles(ctx, video, output)
+	o.Audio, _ = AudioTracks(ctx, video)
+	if len(o.Subtitles) == 0 && isMP4Family(output) {
+		// Only image subtitles, which MP4 can't hold; FFmpeg would fail trying to convert them
+		tracks, _ := SubtitleTracks(ctx, video)
+		o.DropSubtitles = len(tracks) > 0
+	}
+	return o
+}
+
+// CarriedSubtitles returns the video's subtitle streams to carry into output: all of them
+// for Matroska, and the text ones for MP4, converted to its own format. Other outputs keep
+// FFmpeg's usual choice.
+func CarriedSubtitles(ctx context.Context, video, output string) []SubtitleTrack {
+	mkv := strings.EqualFold(filepath.Ext(output), ".mkv")
+	if !mkv && !isMP4Family(output) {
+		return nil
+	}
+	tracks, err := SubtitleTracks(ctx, video)
+	if err != nil || mkv {
+		return tracks
+	}
+	var text []SubtitleTrack
+	for _, track := range tracks {
+		if slices.Contains(TextSubtitleCodecs, track.Codec) {
+			text = append(text, track)
+		}
+	}
+	return text
+}
+
+// subtitleCodec returns how carried subtitles are written to output: copied into Matroska,
+// converted for MP4
+func subtitleCodec(output string) string {
+	if isMP4Family(output) {
+		return "mov_text"
+	}
+	return "copy"
+}
+
+// GenerateFFmpegCommand creates an FFmpeg command to mute audio for the given segments,
+// quoted for this platform's default shell so it can be pasted into a terminal
+func GenerateFFmpegCommand(inputVideo, outputVideo string, segments []Segment, opts EncodeOptions) string {
+	command := FFmpegCommandLine(DefaultShell(), BuildFFmpegArgs(inputVideo, outputVideo, segments, opts))
+	if len(LimitSegments(segments, opts.MaxDuration)) == 0 {
+		return "No segments to mute. Copying input to output: " + command
+	}
+	return command
+}
+
+// FFmpegCommandLine quotes an FFmpeg argument list as a command for the given shell
+func FFmpegCommandLine(shell Shell, args []string) string {
+	return ShellCommand(shell, "ffmpeg", args)
+}
+
+// enableExpression creates an FFmpeg expression that is non-zero during any of the segments
+func enableExpression(segments []Segment) string {
+	var enableConditions []string
+	for _, seg := range segments {
+		enableConditions = append(enableConditions, fmt.Sprintf("between(t,%.3f,%.3f)", seg.Start, seg.End))
+	}
+	// Combine conditions with '+' for a single expression
+	return strings.Join(enableConditions, "+")
+}
+
+// volumeChunk is how many segments one volume filter mutes. Films with hundreds of matches
+// get a chain of filters, each with a short expression, rather than one huge one.
+const volumeChunk = 100
+
+// BuildVolumeFilter creates the audio filter that mutes every given segment
+func BuildVolumeFilter(segments []Segment) string {
+	var filters []string
+	for chunk := range slices.Chunk(segments, volumeChunk) {
+		filters = append(filters, fmt.Sprintf("volume=enable='%s':volume=0", enableExpression(chunk)))
+	}
+	return strings.Join(filters, ",")
+}
+
+// DefaultFade is the suggested ramp around mutes: long enough to stop the click of a hard
+// cut, short enough not to swallow the words either side
+const DefaultFade = 0.05
+
+// BuildFadeFilter mutes every segment like BuildVolumeFilter, but ramps the volume down over
+// fade seconds before each one and back up over fade seconds after, so the cuts don't pop.
+// It works in two passes: segments closer than two ramps apart are joined first, so the
+// volume doesn't bounce back up between them, then each gets its own volume envelope. The
+// ramps sit outside the segments, which stay silent from start to end.
+func BuildFadeFilter(segments []Segment, fade float64) string {
+	windows := slices.Clone(segments)
+	slices.SortFunc(windows, func(a, b Segment) int { return cmp.Compare(a.Start, b.Start) })
+	var joined []Segment
+	for _, seg := range windows {
+		if n := len(joined); n > 0 && seg.Start-joined[n-1].End < 2*fade {
+			joined[n-1].End = max(joined[n-1].End, seg.End)
+			continue
+		}
+		joined = append(joined, seg)
+	}
+
+	envelopes := make([]string, len(joined))
+	for i, seg := range joined {
+		envelopes[i] = fmt.Sprintf("volume=volume='clip(max(%.3f-t,t-%.3f)/%.3f,0,1)':eval=frame:enable='between(t,%.3f,%.3f)'",
+			seg.Start, seg.End, fade, max(seg.Start-fade, 0), seg.End+fade)
+	}
+	return strings.Join(envelopes, ",")
+}
+
+// muteFilter creates the audio filter that mutes every segment, with fades if Fade is set
+func (o EncodeOptions) muteFilter(segments []Segment) string {
+	if o.Fade > 0 {
+		return BuildFadeFilter(segments, o.Fade)
+	}
+	return BuildVolumeFilter(segments)
+}
+
+// BuildToneFilterGraph creates a filter graph that censors the main audio with each
+// segment's action (see CensorAction), such as mixing a soft sine tone into the tone
+// segments. The censored audio is labeled [aout].
+func BuildToneFilterGraph(segments []Segment, opts EncodeOptions) string {
+	_, input := opts.mainAudioInput()
+	return censorChain(segments, opts, input, "aout", false)
+}
+
+// BuildFFmpegArgs creates the FFmpeg argument list for censoring the given segments, each
+// drawn by its action (see CensorAction), or for cutting them out if any is a cut. A
+// blurred picture is re-encoded. With no segments the streams are copied unchanged. An audio-only output (see
+// AudioExtensions) gets just the censored audio, encoded for its file type. Either way the
+// output is tagged with the segments (see SegmentsTag).
+func BuildFFmpegArgs(inputVideo, outputVideo string, segments []Segment, opts EncodeOptions) []string {
+	args := append([]string{"-i", inputVideo}, opts.limitArgs()...)
+	args = append(args, opts.threadArgs()...)
+	audioOnly := IsAudioFile(outputVideo)
+	mainStream, mainLabel := opts.mainAudioInput()
+	descriptions := opts.descriptions()
+	segments = LimitSegments(segments, opts.MaxDuration)
+	if hasAction(segments, ActionCut) {
+		return BuildCutArgs(inputVideo, outputVideo, segments, opts)
+	}
+	keepOriginal := opts.KeepOriginal && len(segments) > 0 && strings.EqualFold(filepath.Ext(outputVideo), ".mkv")
+	explicit := len(opts.Subtitles) > 0 || len(descriptions) > 0 || keepOriginal
+
+	if len(segments) == 0 {
+		if explicit {
+			audio := []string{mainStream}
+			for _, track := range descriptions {
+				audio = append(audio, fmt.Sprintf("0:a:%d", track.Index))
+			}
+			args = append(args, opts.mapArgs(outputVideo, "", audio...)...)
+		}
+		if opts.DropSubtitles && len(opts.Subtitles) == 0 {
+			args = append(args, "-sn")
+		}
+		if audioOnly && !strings.EqualFold(filepath.Ext(inputVideo), filepath.Ext(outputVideo)) {
+			args = append(args, "-vn", "-c:a", audioCodec(outputVideo)) // A different file type can't take the audio as it is
+		} else {
+			args = append(args, "-c", "copy")
+		}
+		if opts.VideoCodec != "" && !audioOnly {
+			args = append(args, "-c:v", opts.VideoCodec)
+		}
+		args = append(args, descriptionArgs(descriptions)...)
+		args = append(args, opts.Metadata.args()...)
+		args = append(args, segmentsTagArgs(outputVideo, segments)...)
+		return append(args, "-y", outputVideo)
+	}
+
+	// Audio descriptions are copied as they are unless CensorDescriptions is set, when they
+	// are censored like the main audio. Per-stream options come after -c:a so they win.
+	var streamArgs []string
+	var audio []string
+	video := ""
+	if !onlyMutes(segments) {
+		var graph []string
+		audio = []string{mainStream}
+		if chain := censorChain(segments, opts, mainLabel, "aout", false); chain != "" {
+			graph = append(graph, chain)
+			audio = []string{"[aout]"}
+		}
+		for i, track := range descriptions {
+			input := fmt.Sprintf("[0:a:%d]", track.Index)
+			label := fmt.Sprintf("ad%d", i+1)
+			chain := ""
+			if opts.CensorDescriptions {
+				chain = censorChain(segments, opts, input, label, false)
+			}
+			if chain != "" {
+				graph = append(graph, chain)
+				audio = append(audio, "["+label+"]")
+			} else {
+				audio = append(audio, fmt.Sprintf("0:a:%d", track.Index))
+				streamArgs = append(streamArgs, fmt.Sprintf("-c:a:%d", i+1), "copy")
+			}
+		}
+		if !audioOnly {
+			if chain := censorChain(segments, opts, "[0:v:0]", "vout", true); chain != "" {
+				graph = append(graph, chain)
+				video = "[vout]"
+				explicit = true // A filtered picture has to be mapped by name
+			}
+		}
+		if len(graph) > 0 {
+			args = append(args, "-filter_complex", strings.Join(graph, ";"))
+			explicit = true // A labeled graph output is left unconnected unless it is mapped
+		}
+	} else if len(descriptions) > 0 {
+		filter := opts.muteFilter(segments)
+		audio = []string{mainStream}
+		streamArgs = append(streamArgs, "-filter:a:0", filter)
+		for i, track := range descriptions {
+			audio = append(audio, fmt.Sprintf("0:a:%d", track.Index))
+			if opts.CensorDescriptions {
+				streamArgs = append(streamArgs, fmt.Sprintf("-filter:a:%d", i+1), filter)
+			} else {
+				streamArgs = append(streamArgs, fmt.Sprintf("-c:a:%d", i+1), "copy")
+			}
+		}
+	} else {
+		audio = []string{mainStream}
+		if keepOriginal {
+			// -af would censor the original copy too, so the filter is set per stream
+			streamArgs = append(streamArgs, "-filter:a:0", opts.muteFilter(segments))
+		} else {
+			args = append(args, "-af", opts.muteFilter(segments))
+		}
+	}
+	if keepOriginal {
+		original := len(audio)
+		audio = append(audio, mainStream)
+		streamArgs = append(streamArgs, fmt.Sprintf("-c:a:%d", original), "copy",
+			"-disposition:a:0", "default", fmt.Sprintf("-disposition:a:%d", original), "0",
+			fmt.Sprintf("-metadata:s:a:%d", original), "title="+OriginalAudioTitle)
+	}
+	if explicit {
+		args = append(args, opts.mapArgs(outputVideo, video, audio...)...)
+	}
+	if opts.DropSubtitles && len(opts.Subtitles) == 0 {
+		args = append(args, "-sn")
+	}
+	if audioOnly {
+		// Cover art would otherwise be picked up as a video stream to encode
+		args = append(args, "-vn", "-c:a", audioCodec(outputVideo))
+	} else if video != "" {
+		audioCodec := "aac"
+		if audio[0] == mainStream {
+			audioCodec = "copy" // Only the picture is censored
+		}
+		args = append(args, "-c:v", cmp.Or(opts.VideoCodec, CutVideoCodec), "-c:a", audioCodec)
+	} else {
+		args = append(args, "-c:v", cmp.Or(opts.VideoCodec, "copy"), "-c:a", "aac")
+	}
+	args = append(args, streamArgs...)
+	args = append(args, descriptionArgs(descriptions)...)
+	args = append(args, opts.Metadata.args()...)
+	args = append(args, segmentsTagArgs(outputVideo, segments)...)
+	return append(args,
+		"-y", // Overwrite output file if it exists
+		outputVideo,
+	)
+}
+
+// audioCodec returns the FFmpeg encoder for an audio-only output's file type
+func audioCodec(output string) string {
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".mp3":
+		return "libmp3lame"
+	case ".flac":
+		return "flac"
+	}
+	return "aac"
+}
+
+// hasAction reports whether any segment uses the given action
+func hasAction(segments []Segment, action Action) bool {
+	for _, seg := range segments {
+		if seg.EffectiveAction() == action {
+			return true
+		}
+	}
+	return false
+}
+
+// LimitSegments drops segments that start after maxDuration seconds and trims the one that crosses it.
+// A maxDuration of 0 keeps every segment.
+func LimitSegments(segments []Segment, maxDuration float64) []Segment {
+	if maxDuration <= 0 {
+		return segments
+	}
+	var limited []Segment
+	for _, seg := range segments {
+		if seg.Start >= maxDuration {
+			continue
+		}
+		if seg.End > maxDuration {
+			seg.End = maxDuration
+		}
+		limited = append(limited, seg)
+	}
+	return limited
+}
+
+// progressTimeRe matches the output time FFmpeg reports with -progress, in microseconds
+var progressTimeRe = regexp.MustCompile(`out_time_us=(\d+)`)
+
+// ffmpegStopTimeout is how long FFmpeg gets to finish up after being asked to stop
+// before it is killed
+const ffmpegStopTimeout = 10 * time.Second
+
+// RunFFmpeg runs FFmpeg with the given arguments and blocks until it exits.
+// When duration is known, onProgress receives the current output time in seconds.
+func RunFFmpeg(args []string, duration float64, onProgress func(currentTime float64)) error {
+	return RunFFmpegContext(context.Background(), args, duration, onProgress)
+}
+
+// maxInlineFilter is the longest filter passed on the command line. Longer ones are read
+// from a script file, as Windows caps a whole command line at 32,767 characters.
+const maxInlineFilter = 8000
+
+// scriptOption returns the option that reads the same filter from a file instead, or ""
+// if option doesn't take a filter
+func scriptOption(option string) string {
+	switch {
+	case option == "-af":
+		return "-filter_script:a"
+	case option == "-vf":
+		return "-filter_script:v"
+	case option == "-filter_complex":
+		return "-filter_complex_script"
+	case option == "-filter" || strings.HasPrefix(option, "-filter:"):
+		return "-filter_script" + strings.TrimPrefix(option, "-filter")
+	}
+	return ""
+}
+
+// withFilterScripts moves filters longer than maxInlineFilter out of args into script files
+// in a temporary folder. The returned function removes the folder.
+func withFilterScripts(args []string) ([]string, func(), error) {
+	dir := ""
+	cleanup := func() {
+		if dir != "" {
+			os.RemoveAll(dir)
+		}
+	}
+	scripted := slices.Clone(args)
+	for i := 0; i+1 < len(scripted); i++ {
+		option := scriptOption(scripted[i])
+		if option == "" || len(scripted[i+1]) <= maxInlineFilter {
+			continue
+		}
+		if dir == "" {
+			var err error
+			if dir, err = os.MkdirTemp("", tempPrefix+"filters-"); err != nil {
+				return nil, cleanup, fmt.Errorf("failed to create a folder for filter scripts: %v", err)
+			}
+		}
+		path := filepath.Join(dir, fmt.Sprintf("filter%d.txt", i))
+		if err := os.WriteFile(path, []byte(scripted[i+1]), 0644); err != nil {
+			return nil, cleanup, fmt.Errorf("failed to write filter script: %v", err)
+		}
+		scripted[i], scripted[i+1] = option, path
+		i++
+	}
+	return scripted, cleanup, nil
+}
+
+// RunFFmpegContext is RunFFmpeg that stops FFmpeg when ctx is cancelled. FFmpeg is sent an
+// interrupt so it can close its files, and killed if it hasn't exited after ten seconds.
+// Filters too long for the command line are passed in script files (see maxInlineFilter),
+// and the encode follows SetLowPriority and PauseEncodes. A failure is an FFmpegError.
+func RunFFmpegContext(ctx context.Context, args []string, duration float64, onProgress func(currentTime float64)) error {
+	args, cleanup, err := withFilterScripts(args)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	// Ask FFmpeg to report progress on stdout, just before the output file
+	progressArgs := make([]string, 0, len(args)+2)
+	progressArgs = append(progressArgs, args[:len(args)-1]...)
+	progressArgs = append(progressArgs, "-progress", "pipe:1")
+	progressArgs = append(progressArgs, args[len(args)-1])
+	cmd := ffmpegCommand(ctx, progressArgs)
+	cmd.Cancel = func() error {
+		resumeProcess(cmd.Process) // A paused FFmpeg can't act on the interrupt
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			return cmd.Process.Kill() // Interrupts aren't supported on Windows
+		}
+		return nil
+	}
+	cmd.WaitDelay = ffmpegStopTimeout
+	var stderr tailBuffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("error setting up progress pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error starting FFmpeg: %v", err)
+	}
+	defer trackFFmpeg(cmd.Process)()
+
+	// Always drain stdout so FFmpeg never blocks on a full pipe
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if duration <= 0 || onProgress == nil {
+			continue
+		}
+		if currentTime, found := parseFFmpegProgress(scanner.Text()); found {
+			onProgress(currentTime)
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		output := stderr.String()
+		return &FFmpegError{Err: err, Output: output, Diagnosis: diagnoseFFmpeg(output)}
+	}
+	return nil
+}
+
+// partialPath is where the output is encoded before being renamed into place, so an
+// interrupted encode never leaves a truncated file under the real name. It is in the
+// output's folder, so the rename never has to copy across drives.
+func partialPath(output string) string {
+	ext := filepath.Ext(output)
+	name := strings.TrimSuffix(filepath.Base(output), ext)
+	return filepath.Join(filepath.Dir(output), "."+name+".partial"+ext)
+}
+
+// IsPartialOutput reports whether path is named like the partial file of an encode, which
+// is only left behind if Swear Killer itself was killed mid-encode
+func IsPartialOutput(path string) bool {
+	base := filepath.Base(path)
+	return strings.HasPrefix(base, ".") && strings.HasSuffix(strings.TrimSuffix(base, filepath.Ext(base)), ".partial")
+}
+
+// sameFile reports whether a and b are the same existing file, however they are spelled
+// or linked
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	return err == nil && os.SameFile(infoA, infoB)
+}
+
+// checkOutputNotInput refuses an output that is one of the inputs. FFmpeg refuses that on
+// its own, but not once it writes to a partial file that is renamed over the output.
+func checkOutputNotInput(output string, inputs ...string) error {
+	for _, input := range inputs {
+		if input != "" && sameFile(input, output) {
+			return fmt.Errorf("the output %s is the input %s; choose another output name", output, input)
+		}
+	}
+	return nil
+}
+
+// ffmpegInputs returns the files FFmpeg args read with -i
+func ffmpegInputs(args []string) []string {
+	var inputs []string
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "-i" {
+			inputs = append(inputs, args[i+1])
+		}
+	}
+	return inputs
+}
+
+// RunFFmpegToFile runs FFmpeg like RunFFmpegContext with args that end with the output
+// file, but has it write a hidden partial file beside the output instead, renamed into
+// place once FFmpeg finishes. The partial file is removed if FFmpeg fails or ctx is
+// cancelled, so a run that is stopped never leaves a half-written file that looks done.
+// An output that is one of the inputs is refused before FFmpeg starts.
+func RunFFmpegToFile(ctx context.Context, args []string, duration float64, onProgress func(currentTime float64)) error {
+	output := args[len(args)-1]
+	if err := checkOutputNotInput(output, ffmpegInputs(args)...); err != nil {
+		return err
+	}
+	partial := partialPath(output)
+	args = append(slices.Clone(args[:len(args)-1]), partial)
+	if err := RunFFmpegContext(ctx, args, duration, onProgress); err != nil {
+		os.Remove(partial)
+		return err
+	}
+	if err := os.Rename(partial, output); err != nil {
+		os.Remove(partial)
+		return fmt.Errorf("failed to move the output into place: %v", err)
+	}
+	return nil
+}
+
+// EncodeVideo runs FFmpeg to censor the segments and write the clean video to output. It
+// encodes to a hidden partial file that is renamed into place once FFmpeg finishes, and
+// removed if FFmpeg fails or ctx is cancelled (ctx.Err() is returned then). progress, if
+// not nil, hears how the encode is going. An output that is the input is refused.
+func EncodeVideo(ctx context.Context, input, output string, segments []Segment, opts EncodeOptions, logFn func(string), progress ProgressReporter) error {
+	if err := checkOutputNotInput(output, input); err != nil {
+		return err
+	}
+	duration, _ := ProbeDuration(ctx, input)
+	if opts.MaxDuration > 0 && (duration <= 0 || opts.MaxDuration < duration) {
+		duration = opts.MaxDuration
+	}
+	if opts.Subtitles == nil {
+		opts.Subtitles = CarriedSubtitles(ctx, input, output)
+	}
+	if opts.Audio == nil {
+		opts.Audio, _ = AudioTracks(ctx, input)
+	}
+	args := BuildFFmpegArgs(input, output, segments, opts)
+	if logFn != nil {
+		logFn("Running: ffmpeg " + strings.Join(args, " "))
+	}
+	if err := RunFFmpegToFile(ctx, args, duration, EncodeProgress(duration, progress)); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if output := FFmpegOutput(err); output != "" && logFn != nil {
+			logFn("FFmpeg output:\n" + strings.TrimSpace(output))
+		}
+		return fmt.Errorf("error executing FFmpeg: %v", err)
+	}
+	if opts.Stash != "" {
+		if logFn != nil {
+			logFn("Stashing the censored audio in " + opts.Stash)
+		}
+		return WriteStash(ctx, input, opts.Stash, segments, opts)
+	}
+	return nil
+}
+
+// parseFFmpegProgress returns the current output time in seconds from a progress line.
+// out_time_ms is skipped because FFmpeg actually puts microseconds there too.
+func parseFFmpegProgress(line string) (float64, bool) {
+	matches := progressTimeRe.FindStringSubmatch(line)
+	if len(matches) != 2 {
+		return 0, false
+	}
+	microseconds, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return float64(microseconds) / 1000000.0, true
+}
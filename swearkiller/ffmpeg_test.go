@@ -0,0 +1,69 @@
+package swearkiller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestBuildFFmpegArgsMapsGraphOutput checks that a filter graph's audio is mapped even
+// when nothing else needs an explicit map, as FFmpeg rejects an unconnected graph output
+func TestBuildFFmpegArgsMapsGraphOutput(t *testing.T) {
+	for _, action := range []Action{ActionTone, ActionBeep, ActionReplace} {
+		segments := []Segment{{Start: 1, End: 2, Action: action}}
+		args := BuildFFmpegArgs("in.mp4", "out.mp4", segments, EncodeOptions{})
+		if !slices.Contains(args, "-filter_complex") {
+			t.Fatalf("%s: no filter graph in %q", action, args)
+		}
+		for _, want := range [][]string{{"-map", "0:v?"}, {"-map", "[aout]"}} {
+			i := slices.Index(args, want[1])
+			if i < 1 || args[i-1] != want[0] {
+				t.Errorf("%s: missing %s %s in %q", action, want[0], want[1], args)
+			}
+		}
+	}
+}
+
+// TestBuildFFmpegArgsMuteNeedsNoMap checks that a plain mute keeps FFmpeg's own stream choice
+func TestBuildFFmpegArgsMuteNeedsNoMap(t *testing.T) {
+	args := BuildFFmpegArgs("in.mp4", "out.mp4", []Segment{{Start: 1, End: 2}}, EncodeOptions{})
+	if slices.Contains(args, "-map") {
+		t.Errorf("unexpected -map in %q", args)
+	}
+}
+
+// TestRunFFmpegToFileRefusesInput checks that an output naming the input, directly or
+// through another path to the same file, is refused before the original can be replaced
+func TestRunFFmpegToFileRefusesInput(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "movie.mkv")
+	if err := os.WriteFile(input, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputs := []string{input, filepath.Join(dir, ".", "movie.mkv")}
+	if err := os.Symlink(input, filepath.Join(dir, "link.mkv")); err == nil {
+		outputs = append(outputs, filepath.Join(dir, "link.mkv"))
+	}
+	for _, output := range outputs {
+		args := BuildFFmpegArgs(input, output, []Segment{{Start: 1, End: 2}}, EncodeOptions{})
+		if err := RunFFmpegToFile(context.Background(), args, 0, nil); err == nil {
+			t.Errorf("%s: writing over the input was allowed", output)
+		}
+		if err := EncodeVideo(context.Background(), input, output, nil, EncodeOptions{}, nil, nil); err == nil {
+			t.Errorf("%s: EncodeVideo allowed writing over the input", output)
+		}
+	}
+	if data, err := os.ReadFile(input); err != nil || string(data) != "original" {
+		t.Errorf("the input was changed: %q, %v", data, err)
+	}
+	if _, err := os.Stat(partialPath(input)); !os.IsNotExist(err) {
+		t.Errorf("a partial file was left behind")
+	}
+
+	req := JobRequest{Video: input, Subtitle: input, Output: input}
+	if err := validateJobRequest(&req); JobFailureClass(err) != FailureConfig {
+		t.Errorf("validateJobRequest with the output as the video: got %v", err)
+	}
+}
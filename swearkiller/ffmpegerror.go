@@ -0,0 +1,129 @@
+package swearkiller
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FFmpegError is a failed FFmpeg run, with the end of what it printed and, for failures
+// it recognizes, what went wrong in plain words
+type FFmpegError struct {
+	Err       error  // How FFmpeg exited
+	Output    string // The end of FFmpeg's error output
+	Diagnosis string // What a known failure means and what to do about it, or ""
+}
+
+func (e *FFmpegError) Error() string {
+	if e.Diagnosis != "" {
+		return e.Diagnosis
+	}
+	if last := lastLines(e.Output, 1); last != "" {
+		return fmt.Sprintf("%v: %s", e.Err, last)
+	}
+	return e.Err.Error()
+}
+
+func (e *FFmpegError) Unwrap() error {
+	return e.Err
+}
+
+// FFmpegOutput returns what FFmpeg printed before failing with err, or "" if err isn't an
+// FFmpegError
+func FFmpegOutput(err error) string {
+	var ffmpegErr *FFmpegError
+	if errors.As(err, &ffmpegErr) {
+		return ffmpegErr.Output
+	}
+	return ""
+}
+
+// ffmpegDiagnoses are the failures diagnoseFFmpeg recognizes: a pattern in FFmpeg's output
+// and the explanation, which gets the pattern's first group, or for file errors the file's
+// name, as %[1]s
+var ffmpegDiagnoses = []struct {
+	pattern *regexp.Regexp
+	file    bool // The error is about a file, named the way fileInError finds
+	explain string
+}{
+	{regexp.MustCompile(`No space left on device`), false,
+		"the disk is full; free up space where the output is written, or pick another folder"},
+	{regexp.MustCompile(`Permission denied`), true,
+		"FFmpeg isn't allowed to open %[1]s; check the file and its folder can be read and written, and that no other program has it locked"},
+	{regexp.MustCompile(`No such file or directory`), true,
+		"%[1]s doesn't exist; it may have been moved or renamed, or be on a drive that isn't connected"},
+	{regexp.MustCompile(`Unknown encoder '([^']+)'`), false,
+		"this FFmpeg has no %[1]s encoder; install a full FFmpeg build (see 'swear-killer doctor') or pick another codec"},
+	{regexp.MustCompile(`Encoder \(codec (\S+)\) not found`), false,
+		"this FFmpeg has no encoder for %[1]s; install a full FFmpeg build (see 'swear-killer doctor')"},
+	{regexp.MustCompile(`Decoder \(codec (\S+)\) not found`), false,
+		"this FFmpeg can't decode the video's %[1]s stream; install a full FFmpeg build (see 'swear-killer doctor')"},
+	{regexp.MustCompile(`No such filter: '([^']+)'`), false,
+		"this FFmpeg lacks the %[1]s filter censoring needs; install a full FFmpeg build (see 'swear-killer doctor')"},
+	{regexp.MustCompile(`[Cc]ould not find tag for codec (\S+) in stream`), false,
+		"the output's container can't hold the %[1]s stream; write an MKV instead (--container mkv)"},
+	{regexp.MustCompile(`Stream map '([^']*)' matches no streams`), false,
+		"the video has no stream matching %[1]s; check it has the audio track the options expect"},
+	{regexp.MustCompile(`Invalid data found when processing input`), true,
+		"%[1]s isn't a file FFmpeg can read, or it is damaged; check it plays"},
+	{regexp.MustCompile(`Cannot allocate memory`), false,
+		"FFmpeg ran out of memory; close other programs or run fewer jobs at once"},
+}
+
+// openErrorRe finds the file FFmpeg 7 and later name when they can't open one, as in
+// "Error opening input file movie.mkv."
+var openErrorRe = regexp.MustCompile(`(?m)Error opening (?:input|output) file (.+?)\.?\s*$`)
+
+// fileInError returns the file an error in FFmpeg's output is about, or "a file" if it
+// isn't named. reason is the text of the error, which older FFmpeg versions put after the
+// file's name, as in "movie.mkv: Permission denied".
+func fileInError(output, reason string) string {
+	if m := openErrorRe.FindStringSubmatch(output); m != nil {
+		return m[1]
+	}
+	if m := regexp.MustCompile(`(?m)^([^\[\n].*?): ` + regexp.QuoteMeta(reason)).FindStringSubmatch(output); m != nil {
+		return m[1]
+	}
+	return "a file"
+}
+
+// diagnoseFFmpeg explains the first known failure in FFmpeg's output, or returns "" if it
+// recognizes none
+func diagnoseFFmpeg(output string) string {
+	for _, d := range ffmpegDiagnoses {
+		m := d.pattern.FindStringSubmatch(output)
+		switch {
+		case m == nil:
+			continue
+		case d.file:
+			return fmt.Sprintf(d.explain, fileInError(output, m[0]))
+		case len(m) > 1:
+			return fmt.Sprintf(d.explain, m[1])
+		}
+		return d.explain
+	}
+	return ""
+}
+
+// maxFFmpegOutput is how much of the end of FFmpeg's error output is kept
+const maxFFmpegOutput = 32 << 10
+
+// tailBuffer keeps the last maxFFmpegOutput bytes written to it, as FFmpeg prints its
+// error last and a long encode can print a lot before it
+type tailBuffer struct {
+	data []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if extra := len(b.data) - maxFFmpegOutput; extra > 0 {
+		b.data = append(b.data[:0], b.data[extra:]...)
+	}
+	return len(p), nil
+}
+
+// String returns what was kept, one line per status update
+func (b *tailBuffer) String() string {
+	return strings.ReplaceAll(string(b.data), "\r", "\n")
+}
@@ -0,0 +1,131 @@
+package swearkiller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ProbeDuration uses ffprobe to get the duration of a media file in seconds
+func ProbeDuration(ctx context.Context, mediaPath string) (float64, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-show_entries", "format=duration", "-of", "csv=p=0", mediaPath)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+
+	durationStr := strings.TrimSpace(string(output))
+	duration, err := strconv.ParseFloat(durationStr, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return duration, nil
+}
+
+// SubtitleTrack is a subtitle stream of a video
+type SubtitleTrack struct {
+	Index    int // Among the video's subtitle streams, as in FFmpeg's 0:s:N
+	Codec    string
+	Language string
+	Title    string
+	Default  bool
+	Forced   bool // Only the foreign-language dialogue, shown even with subtitles off
+}
+
+// SubtitleTracks lists the video's subtitle streams. A track is forced if its forced flag
+// is set or its title says so, as many rips only name it.
+func SubtitleTracks(ctx context.Context, video string) ([]SubtitleTrack, error) {
+	output, err := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-print_format", "json", "-show_streams", "-select_streams", "s", video).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video: %v", err)
+	}
+	var probe struct {
+		Streams []struct {
+			CodecName   string `json:"codec_name"`
+			Disposition struct {
+				Default int `json:"default"`
+				Forced  int `json:"forced"`
+			} `json:"disposition"`
+			Tags struct {
+				Language string `json:"language"`
+				Title    string `json:"title"`
+			} `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+	tracks := make([]SubtitleTrack, len(probe.Streams))
+	for i, stream := range probe.Streams {
+		tracks[i] = SubtitleTrack{
+			Index:    i,
+			Codec:    stream.CodecName,
+			Language: stream.Tags.Language,
+			Title:    stream.Tags.Title,
+			Default:  stream.Disposition.Default == 1,
+			Forced:   stream.Disposition.Forced == 1 || strings.Contains(strings.ToLower(stream.Tags.Title), "forced"),
+		}
+	}
+	return tracks, nil
+}
+
+// AudioTrack is an audio stream of a video
+type AudioTrack struct {
+	Index       int // Among the video's audio streams, as in FFmpeg's 0:a:N
+	Language    string
+	Title       string
+	Description bool // Audio description: a narrator describes the picture between the lines
+}
+
+// AudioTracks lists the video's audio streams. A track is an audio description if it is
+// flagged for the visually impaired or its title says so, as many rips only name it.
+func AudioTracks(ctx context.Context, video string) ([]AudioTrack, error) {
+	output, err := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-print_format", "json", "-show_streams", "-select_streams", "a", video).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video: %v", err)
+	}
+	var probe struct {
+		Streams []struct {
+			Disposition struct {
+				VisualImpaired int `json:"visual_impaired"`
+				Descriptions   int `json:"descriptions"`
+			} `json:"disposition"`
+			Tags struct {
+				Language string `json:"language"`
+				Title    string `json:"title"`
+			} `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+	tracks := make([]AudioTrack, len(probe.Streams))
+	for i, stream := range probe.Streams {
+		tracks[i] = AudioTrack{
+			Index:    i,
+			Language: stream.Tags.Language,
+			Title:    stream.Tags.Title,
+			Description: stream.Disposition.VisualImpaired == 1 || stream.Disposition.Descriptions == 1 ||
+				describesAudio(stream.Tags.Title),
+		}
+	}
+	return tracks, nil
+}
+
+// describesAudio reports whether an audio track's title names it an audio description, like
+// "English AD" or "Descriptive Video Service"
+func describesAudio(title string) bool {
+	title = strings.ToLower(title)
+	if strings.Contains(title, "description") || strings.Contains(title, "descriptive") {
+		return true
+	}
+	return slices.ContainsFunc(strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}), func(word string) bool { return word == "ad" || word == "dvs" })
+}
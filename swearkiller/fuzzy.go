@@ -0,0 +1,46 @@
+package swearkiller
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// fuzzyMinLength is the shortest swear fuzzy matching looks for; shorter ones are one
+// letter away from too many harmless words
+const fuzzyMinLength = 5
+
+// nearWord reports whether a word of prepared text is one letter away from swear without
+// being it, like the OCR misread "fuckinq" or the transcription typo "bullshlt"
+func nearWord(prepared, swear string) bool {
+	if utf8.RuneCountInString(swear) < fuzzyMinLength || strings.Contains(swear, " ") {
+		return false
+	}
+	for _, word := range strings.FieldsFunc(prepared, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if word != swear && oneEditApart(word, swear) {
+			return true
+		}
+	}
+	return false
+}
+
+// oneEditApart reports whether a and b differ by at most one inserted, deleted or changed
+// letter
+func oneEditApart(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) > len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(rb)-len(ra) > 1 {
+		return false
+	}
+	i := 0
+	for i < len(ra) && ra[i] == rb[i] {
+		i++
+	}
+	if len(ra) == len(rb) {
+		return i == len(ra) || slices.Equal(ra[i+1:], rb[i+1:]) // One letter changed
+	}
+	return slices.Equal(ra[i:], rb[i+1:]) // One letter more in b
+}
@@ -0,0 +1,61 @@
+package swearkiller
+
+import "testing"
+
+// TestOneEditApart checks which words count as one letter away from each other
+func TestOneEditApart(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"same", "shit", "shit", true},
+		{"substitution", "fuckinq", "fucking", true},
+		{"first letter changed", "bitch", "pitch", true},
+		{"insertion", "shiit", "shit", true},
+		{"insertion at the end", "bitchy", "bitch", true},
+		{"deletion", "bulshit", "bullshit", true},
+		{"deletion at the start", "itch", "bitch", true},
+		{"two substitutions", "fuckinf", "fackinq", false},
+		{"two letters longer", "shitty", "shit", false},
+		{"swapped letters", "btich", "bitch", false},
+		{"non-ASCII letter", "scheiße", "scheisse", false},
+		{"non-ASCII substitution", "scheiße", "scheiбe", true},
+	}
+	for _, tt := range tests {
+		if got := oneEditApart(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+		if got := oneEditApart(tt.b, tt.a); got != tt.want {
+			t.Errorf("%s, swapped: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestNearWord checks that fuzzy matching finds misspelled swears in a line but leaves
+// exact matches, short swears and phrases to the other matchers
+func TestNearWord(t *testing.T) {
+	tests := []struct {
+		name     string
+		prepared string
+		swear    string
+		want     bool
+	}{
+		{"substitution", "what the fuckinq hell", "fucking", true},
+		{"insertion", "that's bullshiit", "bullshit", true},
+		{"deletion", "that's bulshit", "bullshit", true},
+		{"next to punctuation", "oh, bitchh!", "bitch", true},
+		{"exact match", "that's bullshit", "bullshit", false},
+		{"exact match beside a near one", "bullshit, bullshlt", "bullshit", true},
+		{"four letters", "well damm", "damn", false},
+		{"five letters", "you bitcj", "bitch", true},
+		{"phrase", "son of a bitcj", "son of a bitch", false},
+		{"two letters off", "fuckinqq around", "fucking", false},
+		{"a different word", "a bastion of calm", "bastard", false},
+	}
+	for _, tt := range tests {
+		if got := nearWord(tt.prepared, tt.swear); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package swearkiller
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoryFormat is a file format the history can be exported in
+type HistoryFormat string
+
+const (
+	HistoryCSV  HistoryFormat = "csv"
+	HistoryJSON HistoryFormat = "json"
+)
+
+// ParseHistoryFormat reads a history export format name
+func ParseHistoryFormat(name string) (HistoryFormat, error) {
+	switch format := HistoryFormat(strings.ToLower(strings.TrimSpace(name))); format {
+	case HistoryCSV, HistoryJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown export format %q (use csv or json)", name)
+}
+
+// historyColumns are the CSV columns before the settings, which follow in name order
+var historyColumns = []string{
+	"title", "video", "output", "processed_at", "rating", "notes", "subtitle",
+	"duration_seconds", "segments", "muted_seconds", "muted_percent",
+}
+
+// historyExport is one entry of the JSON export
+type historyExport struct {
+	Title        string            `json:"title"`
+	Video        string            `json:"video"`
+	Output       string            `json:"output"`
+	ProcessedAt  time.Time         `json:"processed_at"`
+	Rating       int               `json:"rating"`
+	Notes        string            `json:"notes"`
+	Subtitle     string            `json:"subtitle"`
+	Duration     float64           `json:"duration_seconds"`
+	Segments     int               `json:"segments"`
+	MutedSeconds float64           `json:"muted_seconds"`
+	MutedPercent float64           `json:"muted_percent"`
+	Settings     map[string]string `json:"settings"`
+}
+
+// mutedPercent returns how much of the video was muted, rounded to a hundredth of a percent,
+// or 0 if its length isn't known
+func (e HistoryEntry) mutedPercent() float64 {
+	if e.Duration <= 0 {
+		return 0
+	}
+	return float64(int(e.MutedSeconds/e.Duration*10000+0.5)) / 100
+}
+
+// WriteHistory exports history entries for spreadsheets and scripts. CSV has one row per
+// title with a "setting:<name>" column for every setting any title used; JSON is a list of
+// objects with the settings nested.
+func WriteHistory(w io.Writer, entries []HistoryEntry, format HistoryFormat) error {
+	if format == HistoryJSON {
+		exports := make([]historyExport, len(entries))
+		for i, e := range entries {
+			settings := e.Settings
+			if settings == nil {
+				settings = map[string]string{}
+			}
+			exports[i] = historyExport{
+				Title: e.Title(), Video: e.Video, Output: e.OutputPath, ProcessedAt: e.ProcessedAt,
+				Rating: e.Rating, Notes: e.Notes, Subtitle: e.Subtitle, Duration: e.Duration,
+				Segments: e.Segments, MutedSeconds: e.MutedSeconds, MutedPercent: e.mutedPercent(),
+				Settings: settings,
+			}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(exports)
+	}
+
+	var settingNames []string
+	seen := map[string]bool{}
+	for _, e := range entries {
+		for name := range e.Settings {
+			if !seen[name] {
+				seen[name] = true
+				settingNames = append(settingNames, name)
+			}
+		}
+	}
+	sort.Strings(settingNames)
+
+	writer := csv.NewWriter(w)
+	header := append([]string{}, historyColumns...)
+	for _, name := range settingNames {
+		header = append(header, "setting:"+name)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	number := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	for _, e := range entries {
+		row := []string{
+			e.Title(), e.Video, e.OutputPath, e.ProcessedAt.Format(time.RFC3339), strconv.Itoa(e.Rating), e.Notes, e.Subtitle,
+			number(e.Duration), strconv.Itoa(e.Segments), number(e.MutedSeconds), number(e.mutedPercent()),
+		}
+		for _, name := range settingNames {
+			row = append(row, e.Settings[name])
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,84 @@
+package swearkiller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Hooks are shell commands run before and after each job, so cleaning can fit into a
+// media pipeline: renaming the output, moving it into a library, starting a library scan.
+// Each command is told about the job through SK_ environment variables (see HookJob).
+type Hooks struct {
+	Before string `json:"before,omitempty"` // Runs before the job; if it fails, the job doesn't run and fails
+	After  string `json:"after,omitempty"`  // Runs after the job, whether it worked or not
+}
+
+// HookJob is what a hook is told about its job. The command gets it as SK_STAGE
+// ("before" or "after"), SK_INPUT, SK_SUBTITLE, SK_OUTPUT, and after the job
+// SK_STATUS ("done" or "failed"), SK_SEGMENTS and SK_ERROR.
+type HookJob struct {
+	Video    string
+	Subtitle string
+	Output   string
+	Segments int   // Segments censored in the output
+	Err      error // Why the job failed, nil if it worked
+}
+
+// Merge returns h with the hooks it doesn't set taken from fallback
+func (h Hooks) Merge(fallback Hooks) Hooks {
+	if h.Before == "" {
+		h.Before = fallback.Before
+	}
+	if h.After == "" {
+		h.After = fallback.After
+	}
+	return h
+}
+
+// RunBefore runs the before hook, if set
+func (h Hooks) RunBefore(ctx context.Context, job HookJob, logFn func(string)) error {
+	return runHook(ctx, "before", h.Before, job, logFn)
+}
+
+// RunAfter runs the after hook, if set
+func (h Hooks) RunAfter(ctx context.Context, job HookJob, logFn func(string)) error {
+	return runHook(ctx, "after", h.After, job, logFn)
+}
+
+// runHook runs command with the shell, logging what it prints
+func runHook(ctx context.Context, stage, command string, job HookJob, logFn func(string)) error {
+	if strings.TrimSpace(command) == "" {
+		return nil
+	}
+	env := []string{
+		"SK_STAGE=" + stage,
+		"SK_INPUT=" + job.Video,
+		"SK_SUBTITLE=" + job.Subtitle,
+		"SK_OUTPUT=" + job.Output,
+	}
+	if stage == "after" {
+		status, message := "done", ""
+		if job.Err != nil {
+			status, message = "failed", job.Err.Error()
+		}
+		env = append(env, "SK_STATUS="+status, "SK_SEGMENTS="+strconv.Itoa(job.Segments), "SK_ERROR="+message)
+	}
+	logFn(fmt.Sprintf("Running the %s hook: %s", stage, command))
+	cmd := shellCommand(ctx, command)
+	cmd.Env = append(os.Environ(), env...)
+	var output tailBuffer
+	cmd.Stdout, cmd.Stderr = &output, &output
+	err := cmd.Run()
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			logFn(fmt.Sprintf("%s hook: %s", stage, line))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("the %s hook failed: %v", stage, err)
+	}
+	return nil
+}
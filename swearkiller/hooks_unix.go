@@ -0,0 +1,13 @@
+//go:build unix
+
+package swearkiller
+
+import (
+	"context"
+	"os/exec"
+)
+
+// shellCommand runs command with sh, so hooks can use pipes and variables like $SK_OUTPUT
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
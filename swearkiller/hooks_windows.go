@@ -0,0 +1,18 @@
+//go:build windows
+
+package swearkiller
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand runs command with cmd.exe, so hooks can use pipes and variables like
+// %SK_OUTPUT%. The command line is passed as it is, since cmd.exe doesn't follow the
+// quoting rules Go escapes arguments for.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "cmd.exe")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `cmd.exe /S /C "` + command + `"`}
+	return cmd
+}
@@ -0,0 +1,171 @@
+package swearkiller
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxDecodeErrors is how many of the decoder's complaints an IntegrityReport keeps
+const maxDecodeErrors = 5
+
+// IntegrityReport is what CheckIntegrity found in an encoded output
+type IntegrityReport struct {
+	Expected float64          // Seconds the output should last, or 0 if the input's length is unknown
+	Streams  []StreamDuration // The output's video and audio streams
+	Problems []string         // What is wrong with the output; empty if nothing is
+}
+
+// StreamDuration is how long one stream of a file lasts
+type StreamDuration struct {
+	Index    int
+	Type     string // "video" or "audio"
+	Duration float64
+}
+
+// OK reports whether the output passed the check
+func (r IntegrityReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// CheckIntegrity checks an encoded output is whole: that ffprobe can read it, that its
+// video and audio streams last as long as the input (or the preview, with
+// opts.MaxDuration) less any segments cut out, and that its audio and the picture's
+// keyframes decode without errors. Decoding just the keyframes keeps the check to a small
+// part of the encode's time. The error is only for a check that couldn't be finished, like
+// one ctx stopped.
+func CheckIntegrity(ctx context.Context, input, output string, segments []Segment, opts EncodeOptions) (IntegrityReport, error) {
+	var report IntegrityReport
+	if duration, err := ProbeDuration(ctx, input); err == nil {
+		report.Expected = duration
+		if opts.MaxDuration > 0 {
+			report.Expected = min(duration, opts.MaxDuration)
+		}
+		report.Expected -= cutDuration(segments, report.Expected)
+	}
+
+	total, streams, err := probeStreamDurations(ctx, output)
+	if err != nil {
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+		report.Problems = append(report.Problems, fmt.Sprintf("ffprobe can't read the output: %v", err))
+		return report, nil
+	}
+	report.Streams = streams
+	expected := report.Expected
+	if expected == 0 {
+		expected = total // Still catch one stream cut short of the others
+	}
+	if len(streams) == 0 {
+		report.Problems = append(report.Problems, "the output has no video or audio streams")
+	}
+	for _, s := range streams {
+		if math.Abs(s.Duration-expected) > durationTolerance(expected) {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s stream %d lasts %s, but should last %s",
+				s.Type, s.Index, FormatTimestamp(s.Duration), FormatTimestamp(expected)))
+		}
+	}
+
+	cmd := ffmpegCommand(ctx, []string{"-hide_banner", "-nostats", "-v", "error", "-skip_frame", "nokey",
+		"-i", output, "-map", "0:v?", "-map", "0:a?", "-f", "null", "-"})
+	out, err := runTracked(cmd, true)
+	if ctx.Err() != nil {
+		return report, ctx.Err()
+	}
+	var decodeErrors []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			decodeErrors = append(decodeErrors, line)
+		}
+	}
+	if len(decodeErrors) > maxDecodeErrors {
+		decodeErrors = append(decodeErrors[:maxDecodeErrors], fmt.Sprintf("...and %d more", len(decodeErrors)-maxDecodeErrors))
+	}
+	for _, line := range decodeErrors {
+		report.Problems = append(report.Problems, "decode error: "+line)
+	}
+	if err != nil && len(decodeErrors) == 0 {
+		report.Problems = append(report.Problems, fmt.Sprintf("decoding the output failed: %v", err))
+	}
+	return report, nil
+}
+
+// cutDuration returns how many of the first length seconds a cut takes out. As with
+// BuildFFmpegArgs, one cut segment makes every segment a cut. Overlaps count once.
+func cutDuration(segments []Segment, length float64) float64 {
+	if !hasAction(segments, ActionCut) {
+		return 0
+	}
+	sorted := slices.Clone(segments)
+	slices.SortFunc(sorted, func(a, b Segment) int { return cmp.Compare(a.Start, b.Start) })
+	total, reached := 0.0, 0.0
+	for _, seg := range sorted {
+		start, end := max(seg.Start, reached, 0), min(seg.End, length)
+		if end > start {
+			total += end - start
+			reached = end
+		}
+	}
+	return total
+}
+
+// durationTolerance is how far a stream's length may be from the expected one, allowing
+// for the audio encoder's padding and the picture ending on a whole frame
+func durationTolerance(expected float64) float64 {
+	return max(1, expected*0.005)
+}
+
+// probeStreamDurations returns how long a file lasts and each of its video and audio
+// streams. Cover art is left out. MKV streams that have no duration of their own take it
+// from their DURATION tag, or failing that from the file's.
+func probeStreamDurations(ctx context.Context, path string) (float64, []StreamDuration, error) {
+	output, err := runTracked(ffprobeCommand(ctx, []string{"-v", "error", "-print_format", "json",
+		"-show_entries", "format=duration:stream=index,codec_type,duration:stream_tags=DURATION:stream_disposition=attached_pic",
+		path}), false)
+	if err != nil {
+		return 0, nil, err
+	}
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+		Streams []struct {
+			Index       int    `json:"index"`
+			CodecType   string `json:"codec_type"`
+			Duration    string `json:"duration"`
+			Disposition struct {
+				AttachedPic int `json:"attached_pic"`
+			} `json:"disposition"`
+			Tags struct {
+				Duration string `json:"DURATION"`
+			} `json:"tags"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return 0, nil, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+	total, err := strconv.ParseFloat(probe.Format.Duration, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("the output has no duration")
+	}
+	var streams []StreamDuration
+	for _, s := range probe.Streams {
+		if (s.CodecType != "video" && s.CodecType != "audio") || s.Disposition.AttachedPic == 1 {
+			continue
+		}
+		duration, err := strconv.ParseFloat(s.Duration, 64)
+		if err != nil {
+			if duration, err = ParseTimestamp(s.Tags.Duration); err != nil {
+				duration = total
+			}
+		}
+		streams = append(streams, StreamDuration{Index: s.Index, Type: s.CodecType, Duration: duration})
+	}
+	return total, streams, nil
+}
@@ -0,0 +1,25 @@
+package swearkiller
+
+import "testing"
+
+// TestCutDuration checks the time a cut takes out of the output, counting overlaps once
+// and nothing past the end
+func TestCutDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []Segment
+		length   float64
+		want     float64
+	}{
+		{"mutes only", []Segment{{Start: 1, End: 3}}, 60, 0},
+		{"one cut", []Segment{{Start: 1, End: 3, Action: ActionCut}}, 60, 2},
+		{"a cut makes mutes cuts", []Segment{{Start: 1, End: 3, Action: ActionCut}, {Start: 10, End: 11}}, 60, 3},
+		{"overlaps count once", []Segment{{Start: 5, End: 8, Action: ActionCut}, {Start: 1, End: 6, Action: ActionCut}}, 60, 7},
+		{"past the end", []Segment{{Start: 50, End: 70, Action: ActionCut}, {Start: 80, End: 90, Action: ActionCut}}, 60, 10},
+	}
+	for _, tt := range tests {
+		if got := cutDuration(tt.segments, tt.length); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,548 @@
+package swearkiller
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// JobRequest describes a video to clean, as submitted to the HTTP API or configured for
+// headless mode
+type JobRequest struct {
+	Video           string    `json:"video"`                // Path on the server, filled in for uploads
+	Subtitle        string    `json:"subtitle"`             // Path on the server, filled in for uploads
+	Output          string    `json:"output,omitempty"`     // Defaults to <video>-CLEAN.mp4 in the job's directory
+	Offset          float64   `json:"offset,omitempty"`     // Seconds to shift the subtitle by
+	OffsetEnd       *float64  `json:"offset_end,omitempty"` // Offset at the last line, if it drifts; Offset is then the offset at the first
+	FrameRateRatio  string    `json:"fps_ratio,omitempty"`  // Frame rate the subtitle was timed for and the video's, like "23.976:25"
+	Lang            string    `json:"lang,omitempty"`       // "auto" (default), "none" or codes like "es,fr"
+	Swears          []string  `json:"swears,omitempty"`     // Replaces the server's swear list
+	Allow           []string  `json:"allow,omitempty"`      // Added to the built-in allowlist
+	Deobfuscate     bool      `json:"deobfuscate,omitempty"`
+	WholeWords      bool      `json:"whole_words,omitempty"`
+	Fuzzy           bool      `json:"fuzzy,omitempty"` // Also hold words one letter away from a swear for review
+	PhraseGap       *float64  `json:"phrase_gap,omitempty"`
+	MinConfidence   *float64  `json:"min_confidence,omitempty"`
+	MuteBleeps      bool      `json:"mute_bleeps,omitempty"`
+	BleepAction     Action    `json:"bleep_action,omitempty"`
+	SkipCommercials bool      `json:"skip_commercials,omitempty"`
+	Force           bool      `json:"force,omitempty"`           // Run even if the quality check fails
+	Reviewed        []float64 `json:"reviewed,omitempty"`        // Start times of held-back matches a reviewer chose to mute
+	VideoUpload     string    `json:"video_upload,omitempty"`    // ID of a finished resumable upload to use as the video
+	SubtitleUpload  string    `json:"subtitle_upload,omitempty"` // ID of a finished resumable upload to use as the subtitle
+	ExtraSubtitles  []string  `json:"extra_subtitles,omitempty"` // More subtitle tracks for the video, searched too (see MergeTracks)
+
+	CensorDescriptions bool    `json:"censor_descriptions,omitempty"` // Censor audio description tracks too
+	Fade               float64 `json:"fade,omitempty"`                // Seconds to ramp the volume down and up around each mute (0 = cut hard)
+	KeepOriginalAudio  bool    `json:"keep_original_audio,omitempty"` // Keep the uncensored audio as an extra track; .mkv outputs only
+	Stash              bool    `json:"stash,omitempty"`               // Save the censored audio next to the output (see StashPath)
+	Threads            int     `json:"threads,omitempty"`             // CPU threads FFmpeg may use (0 = no limit)
+
+	CheckOutput   bool `json:"check_output,omitempty"`   // Check the output is whole after encoding (see CheckIntegrity)
+	DeleteCorrupt bool `json:"delete_corrupt,omitempty"` // Delete an output that fails the check
+
+	Overwrite OverwritePolicy `json:"overwrite,omitempty"` // What to do if the output already exists (default OverwriteReplace)
+
+	// SanitizeMetadata masks swears in the output's name, container tags and chapter titles
+	// (see TextSanitizer)
+	SanitizeMetadata bool `json:"sanitize_metadata,omitempty"`
+
+	// Profile is a built-in profile (see Profiles) that picks the matches to censor instead
+	// of deobfuscate, whole_words and min_confidence
+	Profile string `json:"profile,omitempty"`
+
+	// Hooks run before and after the job. They are set by whoever runs the jobs, never by
+	// API clients, since they run commands.
+	Hooks Hooks `json:"-"`
+
+	// Refresh names the media servers told about the output once the job succeeds. Like
+	// Hooks, it is set by whoever runs the jobs.
+	Refresh LibraryRefresh `json:"-"`
+
+	// Replace puts the clean video in place of the original once it has passed its check
+	// (see ReplaceOriginal), backing the original up as Backup says. It is set by whoever
+	// runs the jobs, never by API clients, since it gives up the original.
+	Replace bool   `json:"-"`
+	Backup  Backup `json:"-"`
+
+	// Detectors picks the detectors run besides, or instead of, the swear list. Like Hooks,
+	// it is set by whoever runs the jobs.
+	Detectors DetectorSettings `json:"-"`
+}
+
+// FailureClass groups job failures by cause, so callers like headless mode can report
+// each kind differently
+type FailureClass string
+
+const (
+	FailureConfig    FailureClass = "config"    // Invalid options
+	FailureInput     FailureClass = "input"     // The video or subtitle is missing or unreadable
+	FailureParse     FailureClass = "parse"     // The subtitle can't be parsed
+	FailureQuality   FailureClass = "quality"   // The subtitle doesn't seem to belong to the video
+	FailureFFmpeg    FailureClass = "ffmpeg"    // FFmpeg failed or isn't installed
+	FailureCancelled FailureClass = "cancelled" // The job was stopped before it finished
+	FailureHook      FailureClass = "hook"      // The before or after hook failed
+	FailureReplace   FailureClass = "replace"   // The clean video couldn't take the original's place
+)
+
+// JobError is a job failure with its class
+type JobError struct {
+	Class FailureClass
+	Err   error
+}
+
+func (e *JobError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *JobError) Unwrap() error {
+	return e.Err
+}
+
+// jobErrorf formats a JobError of the given class
+func jobErrorf(class FailureClass, format string, args ...any) error {
+	return &JobError{Class: class, Err: fmt.Errorf(format, args...)}
+}
+
+// JobFailureClass returns the class of a job error, or "" if it isn't a JobError
+func JobFailureClass(err error) FailureClass {
+	var jobErr *JobError
+	if errors.As(err, &jobErr) {
+		return jobErr.Class
+	}
+	return ""
+}
+
+// JobResult is what a job found, filled in as far as the job got
+type JobResult struct {
+	Languages []string
+	Matches   []Match   // Matches that are muted
+	Review    []Match   // Matches below the confidence threshold, left alone
+	Segments  []Segment // Merged segments censored in the output
+	Subtitle  SRTReport // Subtitle blocks that couldn't be read
+	Output    string    // Where the clean video was written, which OverwriteRename may change
+	Skipped   bool      // The output already existed and OverwriteSkip left it alone
+}
+
+// validateJobRequest checks a job before it is queued so mistakes are reported right away
+func validateJobRequest(req *JobRequest) error {
+	if req.Video == "" || req.Subtitle == "" {
+		return jobErrorf(FailureConfig, "both a video and a subtitle are required")
+	}
+	for _, path := range append([]string{req.Video}, req.subtitles()...) {
+		if _, err := os.Stat(path); err != nil {
+			return jobErrorf(FailureInput, "file not found: %s", path)
+		}
+	}
+	if req.Output != "" {
+		if err := checkOutputNotInput(req.Output, append([]string{req.Video}, req.subtitles()...)...); err != nil {
+			return &JobError{Class: FailureConfig, Err: err}
+		}
+	}
+	return validateJobOptions(req)
+}
+
+// validateJobOptions checks a job's options, leaving its files alone, and fills in the
+// bleep action's default
+func validateJobOptions(req *JobRequest) error {
+	if req.BleepAction == "" {
+		req.BleepAction = ActionMute
+	}
+	action, err := ParseAction(string(req.BleepAction))
+	if err != nil || action == ActionCut || action == ActionReplace {
+		return jobErrorf(FailureConfig, "bleep_action must be 'mute', 'tone', 'beep' or 'blur'")
+	}
+	req.BleepAction = action
+	if req.MinConfidence != nil && (*req.MinConfidence < 0 || *req.MinConfidence > 1) {
+		return jobErrorf(FailureConfig, "min_confidence must be between 0 and 1")
+	}
+	if req.PhraseGap != nil && *req.PhraseGap < 0 {
+		return jobErrorf(FailureConfig, "phrase_gap must be zero or positive")
+	}
+	if req.Fade < 0 {
+		return jobErrorf(FailureConfig, "fade must be zero or positive")
+	}
+	if req.Threads < 0 {
+		return jobErrorf(FailureConfig, "threads must be zero or positive")
+	}
+	if req.DeleteCorrupt && !req.CheckOutput {
+		return jobErrorf(FailureConfig, "delete_corrupt needs check_output")
+	}
+	policy, err := ParseOverwritePolicy(string(req.Overwrite))
+	if err != nil {
+		return &JobError{Class: FailureConfig, Err: err}
+	}
+	req.Overwrite = policy
+	if req.Backup, err = ParseBackup(string(req.Backup)); err != nil {
+		return &JobError{Class: FailureConfig, Err: err}
+	}
+	if req.Replace && !req.CheckOutput {
+		return jobErrorf(FailureConfig, "replacing the original needs check_output, so only a whole output replaces it")
+	}
+	if req.Profile != "" {
+		if req.Deobfuscate || req.WholeWords || req.MinConfidence != nil {
+			return jobErrorf(FailureConfig, "profile sets deobfuscate, whole_words and min_confidence; don't send them as well")
+		}
+		if _, err := FindProfile(req.Profile); err != nil {
+			return &JobError{Class: FailureConfig, Err: err}
+		}
+	}
+	if _, err := jobLanguages(req.Lang, nil, ""); err != nil {
+		return &JobError{Class: FailureConfig, Err: err}
+	}
+	if _, err := ParseFrameRateRatio(req.FrameRateRatio); err != nil {
+		return &JobError{Class: FailureConfig, Err: err}
+	}
+	if err := req.Detectors.Validate(); err != nil {
+		return &JobError{Class: FailureConfig, Err: err}
+	}
+	return nil
+}
+
+// subtitles returns the paths of every subtitle track of the job, with any forced track
+// found beside them (see WithForcedSubtitles)
+func (req JobRequest) subtitles() []string {
+	return WithForcedSubtitles(append([]string{req.Subtitle}, req.ExtraSubtitles...))
+}
+
+// correctJobTiming applies the job's frame rate ratio and drift to the subtitle's cues,
+// then moves them onto the file's timeline if the video has ordered chapters
+func correctJobTiming(req JobRequest, cues []Cue) ([]Cue, error) {
+	scale, _ := ParseFrameRateRatio(req.FrameRateRatio) // Checked by validateJobRequest
+	drift := 0.0
+	if req.OffsetEnd != nil {
+		drift = *req.OffsetEnd - req.Offset
+	}
+	cues = CorrectTiming(cues, scale, drift)
+	if req.Video == "" {
+		return cues, nil
+	}
+	timeline, err := PlaybackTimeline(req.Video)
+	if err != nil || timeline == nil {
+		return cues, err
+	}
+	return timeline.Apply(cues), nil
+}
+
+// jobLanguages turns a job's lang option into the subtitle languages to add built-in lists for
+func jobLanguages(lang string, cues []Cue, srtPath string) ([]string, error) {
+	switch strings.ToLower(strings.TrimSpace(lang)) {
+	case "", "auto":
+		return SubtitleLanguages(cues, LanguageFromFilename(srtPath)), nil
+	case "none":
+		return nil, nil
+	}
+	var languages []string
+	for _, part := range strings.Split(lang, ",") {
+		code := NormalizeLanguage(part)
+		if code == "" {
+			return nil, fmt.Errorf("no built-in swear list for language %q (available: %s)", strings.TrimSpace(part), strings.Join(BuiltinLanguages(), ", "))
+		}
+		languages = append(languages, code)
+	}
+	return languages, nil
+}
+
+// MatchJob matches a job's subtitle cues with its options, splitting the matches into ones
+// to mute and ones below the confidence threshold. swears is used unless the job brings
+// its own list.
+func MatchJob(req JobRequest, cues []Cue, swears []string) (languages []string, matches, review []Match, err error) {
+	return MatchJobContext(context.Background(), req, cues, swears, nil)
+}
+
+// MatchJobContext is MatchJob that runs the job's detectors (see DetectorSettings) until
+// ctx is cancelled. logFn, which may be nil, gets what the detectors report.
+func MatchJobContext(ctx context.Context, req JobRequest, cues []Cue, swears []string, logFn func(string)) (languages []string, matches, review []Match, err error) {
+	languages, err = jobLanguages(req.Lang, cues, req.Subtitle)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	minConfidence := DefaultMinConfidence
+	if req.MinConfidence != nil {
+		minConfidence = *req.MinConfidence
+	}
+	keywords := KeywordDetector{Swears: req.swearList(swears, languages), Options: req.matchOptions(languages)}
+	if req.Profile != "" {
+		profile, err := FindProfile(req.Profile)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		keywords.Profile = &profile
+		minConfidence = profile.MinConfidence
+	}
+	detector, err := req.Detectors.Detector(req.Video, keywords, logFn)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if matches, err = detector.Detect(ctx, cues); err != nil {
+		return nil, nil, nil, err
+	}
+	matches, review = SplitByConfidence(matches, minConfidence)
+	matches, review = promoteReviewed(matches, review, req.Reviewed)
+	return languages, matches, review, nil
+}
+
+// swearList returns the job's own swear list, or swears if it has none, with the built-in
+// lists for languages added
+func (req JobRequest) swearList(swears, languages []string) []string {
+	if len(req.Swears) > 0 {
+		swears = req.Swears
+	}
+	return ExpandSwears(swears, languages)
+}
+
+// matchOptions returns the job's matching options
+func (req JobRequest) matchOptions(languages []string) MatchOptions {
+	opts := DefaultMatchOptions
+	opts.Deobfuscate = req.Deobfuscate
+	opts.WholeWords = req.WholeWords
+	opts.Fuzzy = req.Fuzzy
+	opts.Languages = languages
+	opts.Allow = CombineLists(DefaultAllowlist, req.Allow)
+	if req.PhraseGap != nil {
+		opts.PhraseGap = *req.PhraseGap
+	}
+	return opts
+}
+
+// sanitizer returns the TextSanitizer for the job's sanitize_metadata, which masks the
+// swears it would censor
+func (req JobRequest) sanitizer(swears, languages []string) TextSanitizer {
+	s := TextSanitizer{Swears: req.swearList(swears, languages), Options: req.matchOptions(languages), MinConfidence: DefaultMinConfidence}
+	if req.MinConfidence != nil {
+		s.MinConfidence = *req.MinConfidence
+	}
+	if profile, err := FindProfile(req.Profile); req.Profile != "" && err == nil {
+		s.MinConfidence = profile.MinConfidence
+	}
+	return s
+}
+
+// promoteReviewed moves the held-back matches a reviewer chose to mute, identified by
+// their subtitle start times, in with the matches to mute
+func promoteReviewed(matches, review []Match, reviewed []float64) ([]Match, []Match) {
+	if len(reviewed) == 0 {
+		return matches, review
+	}
+	var held []Match
+	for _, m := range review {
+		if slices.ContainsFunc(reviewed, func(start float64) bool { return math.Abs(start-m.Cue.Start) < 0.001 }) {
+			matches = append(matches, m)
+		} else {
+			held = append(held, m)
+		}
+	}
+	return matches, held
+}
+
+// ProcessJob detects swears for a job and encodes the clean video to req.Output, or
+// wherever its overwrite policy says if that exists already. Errors are JobErrors saying
+// what kind of problem stopped the job. When ctx is cancelled FFmpeg is stopped and the
+// partly written output removed. The request's hooks run around it, and its media servers
+// are refreshed after it succeeds. progress, if not nil, hears how many segments were found
+// and how the encode is going.
+func ProcessJob(ctx context.Context, req JobRequest, swears []string, logFn func(string), progress ProgressReporter) (JobResult, error) {
+	var result JobResult
+	if err := validateJobRequest(&req); err != nil {
+		return result, err
+	}
+	if req.Output == "" {
+		return result, jobErrorf(FailureConfig, "an output path is required")
+	}
+	if req.KeepOriginalAudio && !strings.EqualFold(filepath.Ext(req.Output), ".mkv") {
+		return result, jobErrorf(FailureConfig, "keep_original_audio needs an .mkv output")
+	}
+	if req.SanitizeMetadata {
+		// The subtitle's languages aren't known yet, but a name is short enough for any list
+		languages, _ := jobLanguages(req.Lang, nil, req.Subtitle)
+		if output := req.sanitizer(swears, languages).Filename(req.Output); output != req.Output {
+			logFn("Naming the output " + filepath.Base(output) + " to keep the swears out of its name")
+			req.Output = output
+		}
+	}
+	_, statErr := os.Stat(req.Output)
+	output, skip, err := req.Overwrite.ResolveOutput(req.Output)
+	switch {
+	case err != nil:
+		return result, &JobError{Class: FailureConfig, Err: err}
+	case skip:
+		logFn("Skipped: " + req.Output + " already exists")
+		result.Output, result.Skipped = req.Output, true
+		return result, nil
+	case output != req.Output:
+		logFn(fmt.Sprintf("%s already exists; writing %s instead", req.Output, filepath.Base(output)))
+		req.Output = output
+	case statErr == nil:
+		logFn("Replacing " + output)
+	}
+
+	hookJob := HookJob{Video: req.Video, Subtitle: req.Subtitle, Output: req.Output}
+	if err := req.Hooks.RunBefore(ctx, hookJob, logFn); err != nil {
+		return result, &JobError{Class: FailureHook, Err: err}
+	}
+	result, err = processJob(ctx, req, swears, logFn, progress)
+	result.Output = cmp.Or(result.Output, req.Output)
+	if ctx.Err() != nil {
+		return result, err // Stopped, so there is nothing for the after hook to do
+	}
+	hookJob.Output, hookJob.Segments, hookJob.Err = result.Output, len(result.Segments), err
+	if hookErr := req.Hooks.RunAfter(ctx, hookJob, logFn); hookErr != nil {
+		if err != nil {
+			logFn("Error: " + hookErr.Error())
+			return result, err
+		}
+		return result, &JobError{Class: FailureHook, Err: hookErr}
+	}
+	if err == nil {
+		req.Refresh.refreshLogged(ctx, result.Output, logFn)
+	}
+	return result, err
+}
+
+// processJob does the work of ProcessJob once the request is checked
+func processJob(ctx context.Context, req JobRequest, swears []string, logFn func(string), progress ProgressReporter) (JobResult, error) {
+	var result JobResult
+	if warning := CleanedWarning(ctx, req.Video); warning != "" {
+		logFn("Warning: " + warning)
+	}
+	cues, report, err := ReadSubtitleTracks(req.subtitles())
+	if err != nil {
+		class := FailureParse
+		if errors.Is(err, os.ErrNotExist) {
+			class = FailureInput
+		}
+		return result, &JobError{Class: class, Err: err}
+	}
+	result.Subtitle = report
+	if report.Skipped > 0 {
+		logFn("Warning: " + report.String())
+	}
+	if cues, err = correctJobTiming(req, cues); err != nil {
+		return result, &JobError{Class: FailureInput, Err: err}
+	}
+	languages, matches, review, err := MatchJobContext(ctx, req, cues, swears, logFn)
+	if ctx.Err() != nil {
+		return result, jobErrorf(FailureCancelled, "stopped while detecting swears")
+	}
+	if err != nil {
+		return result, &JobError{Class: FailureConfig, Err: err}
+	}
+	result.Languages, result.Matches, result.Review = languages, matches, review
+	if len(matches) == 0 && len(review) == 0 {
+		logFn(DiagnoseZeroMatches(req.Subtitle, cues, req.swearList(swears, languages), req.matchOptions(languages)).String())
+	} else if warning := LanguageMismatch(GuessLanguage(cues), append([]string{"en"}, languages...)); warning != "" {
+		logFn("Warning: " + warning)
+	}
+
+	if report := CheckVideoQuality(ctx, cues, req.Video, append([]string{"en"}, languages...)); !report.Passed() {
+		if !req.Force {
+			return result, jobErrorf(FailureQuality, "the subtitle may not match the video; run again with force if it's right:\n%s", report)
+		}
+		logFn("Quality check problems (continuing because force is set):\n" + report.String())
+	}
+	segments := MatchSegments(matches, req.Offset, logFn)
+	if req.Profile != "" {
+		profile, _ := FindProfile(req.Profile) // Checked by validateJobRequest
+		logFn(fmt.Sprintf("Using the %s profile: %s", profile.Name, profile.Description))
+		segments = profile.Shape(segments)
+	}
+
+	if req.MuteBleeps {
+		tones, err := DetectTones(ctx, req.Video, DefaultToneOptions)
+		if ctx.Err() != nil {
+			return result, jobErrorf(FailureCancelled, "stopped while detecting bleep tones")
+		}
+		if err != nil {
+			return result, jobErrorf(FailureFFmpeg, "error detecting bleep tones: %v", err)
+		}
+		logFn(fmt.Sprintf("Found %d bleep tone(s)", len(tones)))
+		segments = append(segments, WithAction(tones, req.BleepAction)...)
+	}
+	if req.SkipCommercials {
+		breaks, err := FindCommercialBreaks(ctx, req.Video, "", DefaultCommercialOptions, logFn)
+		if ctx.Err() != nil {
+			return result, jobErrorf(FailureCancelled, "stopped while finding commercial breaks")
+		}
+		if err != nil {
+			return result, jobErrorf(FailureFFmpeg, "error finding commercial breaks: %v", err)
+		}
+		segments = ExcludeBreaks(segments, breaks)
+	}
+	result.Segments = MergeSegments(segments)
+	runtime, _ := ProbeDuration(ctx, req.Video)
+	logFn(BuildMatchStats(matches, review, result.Segments, runtime).String())
+	if ctx.Err() != nil {
+		return result, jobErrorf(FailureCancelled, "stopped before encoding")
+	}
+	if progress != nil {
+		progress.SegmentsFound(len(result.Segments))
+	}
+
+	opts := EncodeOptions{CensorDescriptions: req.CensorDescriptions, Fade: req.Fade, KeepOriginal: req.KeepOriginalAudio, Threads: req.Threads}
+	if req.Stash {
+		opts.Stash = StashPath(req.Output)
+	}
+	if req.SanitizeMetadata {
+		meta, err := ProbeMetadata(ctx, req.Video)
+		if ctx.Err() != nil {
+			return result, jobErrorf(FailureCancelled, "stopped while reading the metadata")
+		}
+		if err != nil {
+			return result, jobErrorf(FailureFFmpeg, "error reading the metadata to sanitize: %v", err)
+		}
+		opts.Metadata = req.sanitizer(swears, languages).Metadata(meta)
+		if n := opts.Metadata.Count(); n > 0 {
+			logFn(fmt.Sprintf("Masking swears in %d metadata value(s)", n))
+		}
+	}
+	if err := EncodeVideo(ctx, req.Video, req.Output, result.Segments, opts, logFn, progress); err != nil {
+		if ctx.Err() != nil {
+			return result, jobErrorf(FailureCancelled, "stopped while encoding; removed the unfinished output")
+		}
+		return result, &JobError{Class: FailureFFmpeg, Err: err}
+	}
+	if req.CheckOutput {
+		if err := checkJobOutput(ctx, req, result.Segments, opts, logFn); err != nil {
+			return result, err
+		}
+	}
+	if req.Replace {
+		replaced, err := ReplaceOriginal(req.Video, req.Output, req.Backup)
+		if err != nil {
+			return result, &JobError{Class: FailureReplace, Err: err}
+		}
+		result.Output = replaced
+		logFn(fmt.Sprintf("Replaced %s with the clean video%s", req.Video, req.Backup.Note(req.Video)))
+		return result, nil
+	}
+	logFn("Clean video saved to " + req.Output)
+	return result, nil
+}
+
+// checkJobOutput runs CheckIntegrity on a job's output, deleting it if it fails and the
+// job asks for that
+func checkJobOutput(ctx context.Context, req JobRequest, segments []Segment, opts EncodeOptions, logFn func(string)) error {
+	logFn("Checking the output...")
+	report, err := CheckIntegrity(ctx, req.Video, req.Output, segments, opts)
+	if err != nil {
+		return jobErrorf(FailureCancelled, "stopped while checking the output")
+	}
+	if report.OK() {
+		logFn("Output checked: it decodes cleanly and its streams are whole")
+		return nil
+	}
+	for _, problem := range report.Problems {
+		logFn("  " + problem)
+	}
+	if req.DeleteCorrupt {
+		os.Remove(req.Output)
+		return jobErrorf(FailureFFmpeg, "the output failed its check and was deleted: %s", report.Problems[0])
+	}
+	return jobErrorf(FailureFFmpeg, "the output failed its check: %s", report.Problems[0])
+}
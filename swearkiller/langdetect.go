@@ -0,0 +1,200 @@
+package swearkiller
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// languageSamples are frequent words of each language GuessLanguage tells apart by their
+// letter trigrams, including languages without a built-in swear list
+var languageSamples = map[string]string{
+	"en": "the be to of and a in that have it for not on with he as you do at this but his by from they we say her she or an will my one all would there " +
+		"their what so up out if about who get which go me when make can like time no just him know take people into year your good some could them see " +
+		"other than then now look only come its over think also back after use two how our work first well way even new want because any these give day most us",
+	"es": "de la que el en y a los se del las un por con no una su para es al lo como más pero sus le ya o este sí porque esta entre cuando muy sin sobre " +
+		"también me hasta hay donde quien desde todo nos durante todos uno les ni contra otros ese eso ante ellos esto mí antes algunos qué unos yo otro " +
+		"otras otra él tanto esa estos mucho quienes nada muchos cual poco ella estar estas algo nosotros mi mis tú te ti tu tus estoy está bien aquí ahora vamos",
+	"fr": "de la le et les des en un du une que est pour qui dans par pas plus sur ne se au avec il ce sont je vous nous mais ou son sa ses on elle tout très " +
+		"bien ça oui non moi toi lui leur été être avoir fait faire comme aussi même encore quoi où alors là ici c'est j'ai suis sommes êtes vais va allez peut " +
+		"dit rien veux sais faut voilà merci pourquoi quand chez",
+	"de": "der die und in den von zu das mit sich des auf für ist im dem nicht ein eine als auch es an werden aus er hat dass sie nach wird bei einer um am " +
+		"sind noch wie einem über einen so zum war haben nur oder aber vor zur bis mehr durch man ich du wir ihr mich dich mir dir ja nein was wo warum hier " +
+		"jetzt gut bin bist kann muss weiß will danke bitte schon doch",
+	"it": "di che è e la il un a per non in una sono mi ho ma lo ha le si ti con cosa da se io come no questo qui hai bene sei del tu gli perché più ci al " +
+		"lei me della c'è ora solo fatto lui niente anche tutto allora voglio così sta grazie dove quando fare essere siamo questa quello dobbiamo andiamo",
+	"pt": "de que não o a e é do da em um para eu com uma os no se na por mais as dos como mas foi ao ele das tem à seu sua ou ser quando muito há nos já " +
+		"está também só pelo pela até isso ela entre era depois sem mesmo aos ter seus quem nas me esse eles estão você tinha foram essa nem suas meu minha " +
+		"nós tenho lhe deles este dele tu te vocês nosso nossa então sim obrigado aqui agora vamos",
+	"fi": "ja on ei se että mitä minä sinä hän me te he tämä mutta kun jos niin oli olen olet ole en et hyvä nyt vain kaikki mikä missä miksi kuka tässä " +
+		"siellä täällä tiedän mennä täytyy voi vielä sitten myös jo kiitos anteeksi joo kyllä meidän teidän heidän tuo sen sitä tätä mitään jotain",
+	"tr": "bir ve bu ben sen için çok değil var yok ama şey benim neden nasıl şimdi tamam evet hayır o da de ne mi mı mu mü gibi daha sonra kadar çünkü " +
+		"ile burada orada onu bunu şu biz siz onlar beni seni bana sana iyi güzel lütfen teşekkürler bilmiyorum istiyorum geliyor gidelim",
+	"nl": "de het een en van ik te dat die in is je niet zijn op aan met als voor had er maar om hem dan zou of wat mijn men dit zo door over ze zich bij " +
+		"ook tot jij mij uit daar haar naar heb hoe heeft hebben deze want nog zal zij nu geen omdat iets worden toch al waren veel meer doen toen moet ben " +
+		"zonder kan hun dus alles onder ja eens hier wie werd altijd wordt kunnen ons zelf tegen niets iemand geweest goed weet",
+	"sv": "och det att i en jag hon som han på den med var sig för så till är men ett om hade de av mig du henne då sin nu har inte hans honom skulle " +
+		"hennes där min man vid kunde något från ut när efter upp vi dem vara vad över än dig kan sina här ha mot alla under någon eller allt mycket sedan " +
+		"denna själv detta utan varit hur ingen mitt ni bli blev oss din dessa några deras blir vår varför ja nej bra tack",
+	"da": "og i jeg det at en den til er som på de med han af for ikke der var mig sig men et har om vi min havde ham hun nu over da fra du ud sin dem os " +
+		"op man hans hvor eller hvad skal selv her alle vil blev kunne ind når være dog noget ville jo deres efter ned skulle denne end dette mit også under " +
+		"have dig anden hende mine alt meget sit sine mod disse hvis din nogle hos blive mange bliver hendes været jer sådan nej godt tak",
+	"pl": "i w nie na z się to że do jest co a o jak ale po tak za od mnie mi już jego czy tylko może był ja ty on ona my wy oni tu tam teraz dobrze wiem " +
+		"chcę jestem jesteś być było będzie dla przez ten ta te tego tej go ci cię mu jej nic coś kto gdzie dlaczego proszę dziękuję wszystko musimy",
+	"ro": "și în de la nu a să cu o ce pe un care din mai este sunt pentru ca dar se eu tu el ea noi voi ei am ai are fi fost asta acum aici acolo bine " +
+		"da foarte doar ceva nimic cine unde când cum vreau știu poate trebuie mulțumesc mea meu tău ta lui ei nostru vostru",
+	"cs": "a se na je že to v o ale jsem jsi jsme já ty on ona my vy oni co jak tak už jen nebo když by bylo byl byla být mám máš má tady tam teď dobře " +
+		"vím chci musím prosím děkuji ano ne proč kde kdo něco nic s z do pro mě mi tě ti jsou všechno jsme ještě",
+	"hu": "a az és hogy nem is egy meg de csak van ez el ki még már volt vagy én te ő ti ők itt ott most jó igen tudom akarom kell lehet köszönöm kérem " +
+		"miért hol mit mint azt ezt neki nekem neked vagyok vagyunk semmi valami minden nagyon mindig",
+}
+
+// scriptLanguages tells languages apart by their alphabet alone. Japanese comes before
+// Chinese so kana with kanji is read as Japanese.
+var scriptLanguages = []struct {
+	code   string
+	tables []*unicode.RangeTable
+}{
+	{"ru", []*unicode.RangeTable{unicode.Cyrillic}},
+	{"el", []*unicode.RangeTable{unicode.Greek}},
+	{"ar", []*unicode.RangeTable{unicode.Arabic}},
+	{"he", []*unicode.RangeTable{unicode.Hebrew}},
+	{"ko", []*unicode.RangeTable{unicode.Hangul}},
+	{"ja", []*unicode.RangeTable{unicode.Hiragana, unicode.Katakana}},
+	{"zh", []*unicode.RangeTable{unicode.Han}},
+	{"th", []*unicode.RangeTable{unicode.Thai}},
+	{"hi", []*unicode.RangeTable{unicode.Devanagari}},
+}
+
+// trigramModel is how often each letter trigram occurs in a language's sample
+type trigramModel struct {
+	counts map[string]int
+	total  int
+}
+
+// trigramModels are the models of languageSamples
+var trigramModels = buildTrigramModels(languageSamples)
+
+// buildTrigramModels counts the trigrams of each sample
+func buildTrigramModels(samples map[string]string) map[string]trigramModel {
+	models := make(map[string]trigramModel, len(samples))
+	for code, sample := range samples {
+		model := trigramModel{counts: map[string]int{}}
+		for _, trigram := range letterTrigrams(sample) {
+			model.counts[trigram]++
+			model.total++
+		}
+		models[code] = model
+	}
+	return models
+}
+
+// letterTrigrams returns the trigrams of each word of text, lowercased and padded with
+// spaces so word starts and ends count, as in " th", "the" and "he "
+func letterTrigrams(text string) []string {
+	var trigrams []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			trigrams = append(trigrams, string(runes[i:i+3]))
+		}
+	}
+	return trigrams
+}
+
+// guessLineLanguage returns the language of one subtitle line, or "" if it can't be told:
+// by its alphabet if that isn't Latin, otherwise by the language whose sample its trigrams
+// are most common in
+func guessLineLanguage(text string) string {
+	latin, scripts := 0, make([]int, len(scriptLanguages))
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for i, script := range scriptLanguages {
+			if unicode.IsOneOf(script.tables, r) {
+				scripts[i]++
+				break
+			}
+		}
+	}
+	for i, n := range scripts {
+		if n > latin {
+			return scriptLanguages[i].code
+		}
+	}
+
+	trigrams := letterTrigrams(text)
+	if len(trigrams) < 4 {
+		return ""
+	}
+	best, bestScore, tie := "", 0.0, false
+	for code, model := range trigramModels {
+		hits := 0
+		for _, trigram := range trigrams {
+			hits += model.counts[trigram]
+		}
+		switch score := float64(hits) / float64(model.total); {
+		case score > bestScore:
+			best, bestScore, tie = code, score, false
+		case score == bestScore:
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return best
+}
+
+// LanguageGuess is the language most of a subtitle's lines are in
+type LanguageGuess struct {
+	Code  string  // ISO 639-1 code, "" if no language stands out
+	Share float64 // Share of the lines whose language could be told that are in it, from 0 to 1
+}
+
+// String names the language and how much of the subtitle is in it
+func (g LanguageGuess) String() string {
+	return fmt.Sprintf("%s (%.0f%% of its lines)", LanguageName(g.Code), g.Share*100)
+}
+
+// GuessLanguage finds the language most of the subtitle's lines are in, whether or not it
+// has a built-in swear list. Lines in other alphabets go by their alphabet, and lines in
+// Latin letters by the letter trigrams of each language's most common words. A language
+// needs at least five lines and half of those told apart.
+func GuessLanguage(cues []Cue) LanguageGuess {
+	counts := map[string]int{}
+	classified := 0
+	for _, cue := range cues {
+		if code := guessLineLanguage(cue.Text); code != "" {
+			counts[code]++
+			classified++
+		}
+	}
+	var guess LanguageGuess
+	for code, n := range counts {
+		if n >= 5 && float64(n) >= float64(classified)/2 && float64(n)/float64(classified) > guess.Share {
+			guess = LanguageGuess{Code: code, Share: float64(n) / float64(classified)}
+		}
+	}
+	return guess
+}
+
+// LanguageMismatch warns when the subtitle's language isn't one the swear list covers, the
+// usual reason no swears are found, or returns "" when it is or can't be told. listLanguages
+// are the list's languages, with the user's own list counting as English.
+func LanguageMismatch(guess LanguageGuess, listLanguages []string) string {
+	if guess.Code == "" || containsString(listLanguages, guess.Code) {
+		return ""
+	}
+	if _, ok := BuiltinLists[guess.Code]; ok {
+		return fmt.Sprintf("The subtitle looks like %s, but the %s swear list isn't in use; choose %s or automatic language detection, or few swears will be found",
+			guess, LanguageName(guess.Code), LanguageName(guess.Code))
+	}
+	return fmt.Sprintf("The subtitle looks like %s, which has no built-in swear list; only your own words can match, so add %s ones or few swears will be found",
+		guess, LanguageName(guess.Code))
+}
@@ -0,0 +1,235 @@
+package swearkiller
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultSwears is the built-in English swear list
+var DefaultSwears = []string{"asshole", "cunt", "shit", "fuck", "fucker", "mother fucker", "bullshit", "fucking", "shithead", "cock", "jesus", "christ", "jesus christ", "goddammit", "goddamn", "god damn", "bitch", "dickhead"}
+
+// DefaultAllowlist holds harmless words that contain swears from the built-in lists
+var DefaultAllowlist = []string{"scunthorpe", "shiitake", "cocktail", "peacock", "hitchcock", "hancock", "cockpit", "cockroach", "cockatoo", "cockney", "christmas", "christian", "christopher", "christine", "christina", "christie"}
+
+// BuiltinLists maps ISO 639-1 language codes to built-in swear lists.
+// Short words that commonly appear inside harmless words (like Spanish "puta" in "computadora") are
+// only listed as part of longer phrases.
+var BuiltinLists = map[string][]string{
+	"en": DefaultSwears,
+	"es": {"mierda", "joder", "jodido", "jodida", "coño", "cabrón", "cabrona", "hijo de puta", "puta madre", "gilipollas", "pendejo", "pendeja", "carajo", "chingar", "chingada", "chingado", "verga", "culero", "mamón", "hostia", "me cago en"},
+	"fr": {"merde", "putain", "bordel", "connard", "connasse", "salope", "enculé", "foutre", "niquer", "nique ta", "couilles", "fils de pute", "sale pute", "salaud", "chier", "bâtard", "ta gueule"},
+	"de": {"scheiße", "scheisse", "scheiß", "scheiss", "verdammt", "arschloch", "arsch", "ficken", "fick dich", "verfickt", "wichser", "hurensohn", "fotze", "schlampe", "miststück", "gottverdammt"},
+	"it": {"cazzo", "merda", "vaffanculo", "fanculo", "stronzo", "stronza", "puttana", "figlio di puttana", "minchia", "coglione", "porco dio", "bastardo", "incazzato"},
+	"fi": {"vittu", "vitun", "voi vittu", "perkele", "perkeleen", "saatana", "saatanan", "helvetti", "helvetin", "jumalauta", "paska", "haista paska", "kusipää", "mulkku", "runkkari", "huora", "huoran"},
+	"tr": {"siktir", "siktir git", "hassiktir", "sikeyim", "sikerim", "orospu", "orospu çocuğu", "amına koyayım", "amına koyduğum", "yarrak", "yavşak", "kahpe", "şerefsiz", "pezevenk", "kahrolası", "lanet olsun"},
+	"pt": {"merda", "porra", "caralho", "foda", "foder", "fodido", "fodida", "filho da puta", "puta que pariu", "cacete", "buceta", "desgraçado", "cuzão", "arrombado"},
+}
+
+// languageAliases maps ISO 639-2 and common names to the codes used by BuiltinLists
+var languageAliases = map[string]string{
+	"en": "en", "eng": "en", "english": "en",
+	"es": "es", "spa": "es", "spanish": "es", "español": "es",
+	"fr": "fr", "fre": "fr", "fra": "fr", "french": "fr", "français": "fr",
+	"de": "de", "ger": "de", "deu": "de", "german": "de", "deutsch": "de",
+	"it": "it", "ita": "it", "italian": "it", "italiano": "it",
+	"pt": "pt", "por": "pt", "portuguese": "pt", "português": "pt",
+	"fi": "fi", "fin": "fi", "finnish": "fi", "suomi": "fi",
+	"tr": "tr", "tur": "tr", "turkish": "tr", "türkçe": "tr",
+}
+
+// languageNames gives display names for the languages in BuiltinLists and the others
+// GuessLanguage can tell
+var languageNames = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+	"it": "Italian",
+	"pt": "Portuguese",
+	"fi": "Finnish",
+	"tr": "Turkish",
+
+	"nl": "Dutch",
+	"sv": "Swedish",
+	"da": "Danish",
+	"pl": "Polish",
+	"ro": "Romanian",
+	"cs": "Czech",
+	"hu": "Hungarian",
+	"ru": "Russian",
+	"el": "Greek",
+	"ar": "Arabic",
+	"he": "Hebrew",
+	"ko": "Korean",
+	"ja": "Japanese",
+	"zh": "Chinese",
+	"th": "Thai",
+	"hi": "Hindi",
+}
+
+// stopwords are very common words used to guess the language of subtitle text
+var stopwords = map[string][]string{
+	"en": {"the", "and", "you", "that", "what", "is", "it", "this", "with", "have", "not", "are", "don't", "i'm", "was", "he", "she", "we"},
+	"es": {"el", "los", "las", "que", "y", "es", "por", "qué", "está", "pero", "para", "una", "lo", "yo", "eso", "muy", "tú", "estoy"},
+	"fr": {"le", "les", "et", "est", "je", "vous", "pas", "une", "des", "c'est", "il", "nous", "ne", "tu", "ça", "suis", "oui", "mais"},
+	"de": {"der", "die", "das", "und", "ist", "ich", "nicht", "sie", "du", "ein", "eine", "wir", "zu", "mit", "was", "ja", "auch", "bin"},
+	"it": {"il", "che", "di", "non", "è", "per", "sono", "mi", "ti", "ma", "questo", "cosa", "gli", "sei", "ho", "perché", "della", "bene"},
+	"pt": {"o", "os", "não", "é", "um", "uma", "você", "com", "eu", "está", "isso", "muito", "então", "sim", "ele", "ela", "mas", "tá"},
+	"fi": {"ja", "on", "ei", "se", "että", "mitä", "minä", "sinä", "hän", "me", "te", "tämä", "mutta", "kun", "jos", "niin", "oli", "olen"},
+	"tr": {"bir", "ve", "bu", "ben", "sen", "için", "çok", "değil", "var", "yok", "ama", "şey", "benim", "neden", "nasıl", "şimdi", "tamam", "evet"},
+}
+
+// NormalizeLanguage converts a language code or name (e.g. "spa", "es-MX", "Spanish") to a BuiltinLists code.
+// It returns "" for languages without a built-in list.
+func NormalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if code, ok := languageAliases[lang]; ok {
+		return code
+	}
+	// Strip region suffixes like "es-MX" or "pt_BR"
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		return languageAliases[lang[:i]]
+	}
+	return ""
+}
+
+// LanguageName returns the display name of a BuiltinLists language code
+func LanguageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return strings.ToUpper(code)
+}
+
+// BuiltinLanguages returns the codes of all built-in lists, English first
+func BuiltinLanguages() []string {
+	var codes []string
+	for code := range BuiltinLists {
+		if code != "en" {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return append([]string{"en"}, codes...)
+}
+
+// LanguageFromFilename reads a language tag from subtitle names like "movie.es.srt" or "movie.spa.forced.srt"
+func LanguageFromFilename(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	parts := strings.Split(name, ".")
+	// Skip the title itself and look at the dotted tags after it, last first
+	for i := len(parts) - 1; i > 0; i-- {
+		if code := NormalizeLanguage(parts[i]); code != "" {
+			return code
+		}
+	}
+	return ""
+}
+
+// DetectLanguages guesses which built-in languages appear in the subtitle text.
+// Each cue is classified on its own so mixed-language subtitles report every language
+// that covers at least a tenth of the classified cues.
+func DetectLanguages(cues []Cue) []string {
+	lookup := map[string][]string{}
+	for code, words := range stopwords {
+		for _, word := range words {
+			lookup[word] = append(lookup[word], code)
+		}
+	}
+
+	counts := map[string]int{}
+	classified := 0
+	for _, cue := range cues {
+		scores := map[string]int{}
+		tokens := strings.FieldsFunc(strings.ToLower(cue.Text), func(r rune) bool {
+			return !unicode.IsLetter(r) && r != '\''
+		})
+		for _, token := range tokens {
+			for _, code := range lookup[token] {
+				scores[code]++
+			}
+		}
+
+		best, bestScore, tie := "", 0, false
+		for code, score := range scores {
+			if score > bestScore {
+				best, bestScore, tie = code, score, false
+			} else if score == bestScore {
+				tie = true
+			}
+		}
+		if bestScore < 2 || tie {
+			continue
+		}
+		counts[best]++
+		classified++
+	}
+
+	var languages []string
+	for code, n := range counts {
+		if n >= 3 && n*10 >= classified {
+			languages = append(languages, code)
+		}
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		return counts[languages[i]] > counts[languages[j]]
+	})
+	return languages
+}
+
+// SubtitleLanguages combines a language hint (container tag or file name) with languages
+// detected in the text, by their common words and by GuessLanguage
+func SubtitleLanguages(cues []Cue, hint string) []string {
+	var languages []string
+	if code := NormalizeLanguage(hint); code != "" {
+		languages = append(languages, code)
+	}
+	for _, code := range append(DetectLanguages(cues), NormalizeLanguage(GuessLanguage(cues).Code)) {
+		if code != "" && !containsString(languages, code) {
+			languages = append(languages, code)
+		}
+	}
+	return languages
+}
+
+// ExpandSwears adds the built-in lists for the given languages to the user's own list.
+// The user's list stands in for English, so the built-in English list is never added on top of it.
+func ExpandSwears(base []string, languages []string) []string {
+	lists := [][]string{base}
+	for _, code := range languages {
+		if code == "en" {
+			continue
+		}
+		lists = append(lists, BuiltinLists[code])
+	}
+	return CombineLists(lists...)
+}
+
+// CombineLists merges swear lists, dropping duplicates (ignoring case and accents) and keeping first-seen order
+func CombineLists(lists ...[]string) []string {
+	seen := map[string]bool{}
+	var combined []string
+	for _, list := range lists {
+		for _, word := range list {
+			key := NormalizeText(strings.TrimSpace(word))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			combined = append(combined, word)
+		}
+	}
+	return combined
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
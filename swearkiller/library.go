@@ -0,0 +1,147 @@
+package swearkiller
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// VideoExtensions are the file types treated as videos
+var VideoExtensions = []string{".mp4", ".mkv", ".avi", ".mov", ".webm", ".flv", ".wmv", ".m4v", ".3gp"}
+
+// AudioExtensions are the audio-only file types, like podcasts and audiobooks, that can be
+// cleaned the same way
+var AudioExtensions = []string{".mp3", ".m4a", ".flac"}
+
+// LibraryStatus is where a video in the library stands
+type LibraryStatus string
+
+const (
+	LibraryClean   LibraryStatus = "clean"   // A clean version has been made and is still there
+	LibraryReview  LibraryStatus = "review"  // A plan is waiting to be reviewed
+	LibraryFailed  LibraryStatus = "failed"  // The last run failed
+	LibraryPending LibraryStatus = "pending" // Not processed yet
+)
+
+// LibraryStatuses lists the statuses in the order the library groups them
+var LibraryStatuses = []LibraryStatus{LibraryFailed, LibraryReview, LibraryPending, LibraryClean}
+
+// Label describes the status for a badge
+func (s LibraryStatus) Label() string {
+	switch s {
+	case LibraryClean:
+		return "Clean available"
+	case LibraryReview:
+		return "Plan pending review"
+	case LibraryFailed:
+		return "Failed"
+	}
+	return "Not processed"
+}
+
+// LibraryItem is one video found in the library
+type LibraryItem struct {
+	Video   string // Absolute path
+	Status  LibraryStatus
+	Record  ProcessedRecord // The last successful run, if any
+	Failure FailedRecord    // The last failed run, if it came after any success
+	Plan    string          // Path of the plan waiting for review
+}
+
+// Title returns the video's file name without its extension
+func (item LibraryItem) Title() string {
+	name := filepath.Base(item.Video)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// ScanLibrary lists the videos under roots and every video in the state, with where each
+// stands. Clean versions the program wrote are left out. The items are sorted by path.
+func ScanLibrary(roots []string, state *ProcessedState) ([]LibraryItem, error) {
+	videos, outputs := state.knownVideos()
+	for _, root := range roots {
+		if _, err := os.Stat(root); err != nil {
+			return nil, fmt.Errorf("failed to read library folder: %v", err)
+		}
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip what can't be read rather than giving up on the whole library
+			}
+			if d.IsDir() {
+				if path != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !IsVideoFile(path) || IsCleanName(path) || IsPartialOutput(path) {
+				return nil
+			}
+			videos = append(videos, stateKey(path))
+			return nil
+		})
+	}
+	sort.Strings(videos)
+	videos = slices.Compact(videos)
+
+	var items []LibraryItem
+	for _, video := range videos {
+		if slices.Contains(outputs, video) {
+			continue
+		}
+		if _, err := os.Stat(video); err != nil {
+			continue // Moved or deleted since it was processed
+		}
+		items = append(items, libraryItem(video, state))
+	}
+	return items, nil
+}
+
+// libraryItem works out where a video stands
+func libraryItem(video string, state *ProcessedState) LibraryItem {
+	item := LibraryItem{Video: video, Status: LibraryPending}
+	record, processed := state.Lookup(video)
+	item.Record = record
+	if failure, failed := state.Failure(video); failed {
+		item.Failure, item.Status = failure, LibraryFailed
+		return item
+	}
+	if processed {
+		if _, err := os.Stat(record.OutputPath); err == nil {
+			item.Status = LibraryClean
+			return item
+		}
+	}
+	if plan, err := ReadPlanFile(PlanPath(video)); err == nil && !plan.Reviewed {
+		item.Status, item.Plan = LibraryReview, PlanPath(video)
+	}
+	return item
+}
+
+// IsVideoFile reports whether path has one of the VideoExtensions
+func IsVideoFile(path string) bool {
+	return slices.Contains(VideoExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// IsAudioFile reports whether path has one of the AudioExtensions
+func IsAudioFile(path string) bool {
+	return slices.Contains(AudioExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// CleanOutputName returns the file name of the clean version of input with the default
+// template: "<name>-CLEAN.mp4" for a video, and the same type for an audio file, like
+// "<name>-CLEAN.mp3"
+func CleanOutputName(input string) string {
+	return DefaultNameTemplate.Name(input, "")
+}
+
+// CountLibrary counts the items with each status
+func CountLibrary(items []LibraryItem) map[LibraryStatus]int {
+	counts := map[LibraryStatus]int{}
+	for _, item := range items {
+		counts[item.Status]++
+	}
+	return counts
+}
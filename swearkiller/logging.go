@@ -0,0 +1,177 @@
+package swearkiller
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel is how important a log message is
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota // Details for tracking down problems
+	LevelInfo                  // Progress and results
+	LevelWarn                  // Something looks wrong, but work continues
+	LevelError                 // Work failed
+)
+
+// levelNames are the names of the levels, as written in log files
+var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// levelPrefixes start console messages of each level; info messages are shown as they are
+var levelPrefixes = []string{"Debug: ", "", "Warning: ", "Error: "}
+
+func (l LogLevel) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("LEVEL(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLogLevel reads a level name like "debug", "info", "warn" or "error"
+func ParseLogLevel(s string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", s)
+}
+
+// MessageLevel works out the level of a message passed as a plain string, as the
+// package's logFn callbacks do: "Error"/"❌" and "Warning"/"⚠️" prefixes raise it above fallback
+func MessageLevel(message string, fallback LogLevel) LogLevel {
+	trimmed := strings.ToLower(strings.TrimSpace(message))
+	switch {
+	case strings.HasPrefix(trimmed, "error"), strings.HasPrefix(trimmed, "❌"):
+		return max(fallback, LevelError)
+	case strings.HasPrefix(trimmed, "warning"), strings.HasPrefix(trimmed, "⚠️"):
+		return max(fallback, LevelWarn)
+	}
+	return fallback
+}
+
+// LogEntry is one logged message
+type LogEntry struct {
+	Time    time.Time
+	Level   LogLevel
+	Message string
+}
+
+// String formats the entry for log files: time, level and message
+func (e LogEntry) String() string {
+	return fmt.Sprintf("%s %-5s %s", e.Time.Format("2006-01-02 15:04:05"), e.Level, e.Message)
+}
+
+// Logger sends messages to the console and an optional log file, each with its own
+// minimum level. It is safe for concurrent use.
+type Logger struct {
+	mu           sync.Mutex
+	console      io.Writer
+	consoleLevel LogLevel
+	file         *os.File
+	fileLevel    LogLevel
+}
+
+// NewLogger creates a logger that writes messages of at least level to console (nil for none)
+func NewLogger(console io.Writer, level LogLevel) *Logger {
+	return &Logger{console: console, consoleLevel: level}
+}
+
+// SetOutput changes where console messages are written (nil for nowhere)
+func (l *Logger) SetOutput(console io.Writer) {
+	l.mu.Lock()
+	l.console = console
+	l.mu.Unlock()
+}
+
+// SetLevel changes the minimum level shown on the console
+func (l *Logger) SetLevel(level LogLevel) {
+	l.mu.Lock()
+	l.consoleLevel = level
+	l.mu.Unlock()
+}
+
+// OpenFile appends messages of at least level to a log file, replacing any open one
+func (l *Logger) OpenFile(path string, level LogLevel) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file, l.fileLevel = file, level
+	return nil
+}
+
+// Close closes the log file, if any
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// Log records a message at the given level and returns the entry, for callers that also
+// keep their own history (like the GUI's log view)
+func (l *Logger) Log(level LogLevel, message string) LogEntry {
+	entry := LogEntry{Time: time.Now(), Level: level, Message: message}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.console != nil && level >= l.consoleLevel {
+		fmt.Fprintln(l.console, consoleText(entry))
+	}
+	if l.file != nil && level >= l.fileLevel {
+		fmt.Fprintln(l.file, entry.String())
+	}
+	return entry
+}
+
+// consoleText formats an entry for the console, adding the level's prefix unless the
+// message already starts with one
+func consoleText(entry LogEntry) string {
+	if entry.Level < LevelDebug || entry.Level > LevelError {
+		return entry.Message
+	}
+	prefix := levelPrefixes[entry.Level]
+	if prefix == "" || MessageLevel(entry.Message, LevelInfo) == entry.Level {
+		return entry.Message
+	}
+	return prefix + entry.Message
+}
+
+// Debugf logs a formatted debug message
+func (l *Logger) Debugf(format string, args ...any) { l.Log(LevelDebug, fmt.Sprintf(format, args...)) }
+
+// Infof logs a formatted info message
+func (l *Logger) Infof(format string, args ...any) { l.Log(LevelInfo, fmt.Sprintf(format, args...)) }
+
+// Warnf logs a formatted warning
+func (l *Logger) Warnf(format string, args ...any) { l.Log(LevelWarn, fmt.Sprintf(format, args...)) }
+
+// Errorf logs a formatted error
+func (l *Logger) Errorf(format string, args ...any) { l.Log(LevelError, fmt.Sprintf(format, args...)) }
+
+// Func returns a logFn callback for the package's functions that logs at level, or higher
+// for messages that start with "Warning" or "Error" (see MessageLevel)
+func (l *Logger) Func(level LogLevel) func(string) {
+	return func(message string) {
+		l.Log(MessageLevel(message, level), message)
+	}
+}
@@ -0,0 +1,165 @@
+package swearkiller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// refreshTimeout is how long telling a media server about a new file may take
+const refreshTimeout = 30 * time.Second
+
+// LibraryRefresh says which media servers to tell about each clean output, so it shows up
+// in their library straight away instead of at the next scheduled scan
+type LibraryRefresh struct {
+	Plex      string `json:"plex,omitempty"`       // Plex server URL, like http://plex:32400
+	PlexToken string `json:"plex_token,omitempty"` // X-Plex-Token for it
+
+	Jellyfin    string `json:"jellyfin,omitempty"`     // Jellyfin server URL, like http://jellyfin:8096
+	JellyfinKey string `json:"jellyfin_key,omitempty"` // API key from Jellyfin's dashboard
+
+	// PathMap holds "local=server" path prefixes, for media servers that see the files at
+	// other paths, like one in a container
+	PathMap []string `json:"path_map,omitempty"`
+}
+
+// Enabled reports whether any media server is set
+func (r LibraryRefresh) Enabled() bool {
+	return r.Plex != "" || r.Jellyfin != ""
+}
+
+// Validate checks each server has a URL and its token
+func (r LibraryRefresh) Validate() error {
+	for _, server := range []struct{ name, url, token, tokenName string }{
+		{"Plex", r.Plex, r.PlexToken, "a token"},
+		{"Jellyfin", r.Jellyfin, r.JellyfinKey, "an API key"},
+	} {
+		if server.url == "" && server.token == "" {
+			continue
+		}
+		if parsed, err := url.Parse(server.url); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%s URL %q must be an http or https URL", server.name, server.url)
+		}
+		if server.token == "" {
+			return fmt.Errorf("%s needs %s", server.name, server.tokenName)
+		}
+	}
+	return validatePathMap(r.PathMap)
+}
+
+// Refresh tells each media server that path was added. Plex rescans the folder it is in,
+// within the library section holding it; Jellyfin works out the library itself.
+func (r LibraryRefresh) Refresh(ctx context.Context, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+	path = mapPath(r.PathMap, path)
+	if r.Plex != "" {
+		if err := r.refreshPlex(ctx, path); err != nil {
+			return fmt.Errorf("Plex: %v", err)
+		}
+	}
+	if r.Jellyfin != "" {
+		if err := r.refreshJellyfin(ctx, path); err != nil {
+			return fmt.Errorf("Jellyfin: %v", err)
+		}
+	}
+	return nil
+}
+
+// refreshLogged refreshes the libraries if any media server is set, logging the outcome.
+// The clean video is saved either way, so a media server being down is only a warning.
+func (r LibraryRefresh) refreshLogged(ctx context.Context, path string, logFn func(string)) {
+	if !r.Enabled() {
+		return
+	}
+	if err := r.Refresh(ctx, path); err != nil {
+		logFn("Warning: could not refresh the library: " + err.Error())
+		return
+	}
+	logFn("Refreshed the media server library")
+}
+
+// refreshPlex finds the library section whose folders hold path and scans just path's
+// folder
+func (r LibraryRefresh) refreshPlex(ctx context.Context, path string) error {
+	var sections struct {
+		Directories []struct {
+			Key       string `xml:"key,attr"`
+			Title     string `xml:"title,attr"`
+			Locations []struct {
+				Path string `xml:"path,attr"`
+			} `xml:"Location"`
+		} `xml:"Directory"`
+	}
+	body, err := mediaServerRequest(ctx, http.MethodGet, strings.TrimRight(r.Plex, "/")+"/library/sections", nil,
+		map[string]string{"X-Plex-Token": r.PlexToken, "Accept": "application/xml"})
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(body, &sections); err != nil {
+		return fmt.Errorf("failed to read the library sections: %v", err)
+	}
+	dir := path[:max(strings.LastIndexAny(path, `/\`), 0)]
+	for _, section := range sections.Directories {
+		for _, location := range section.Locations {
+			if !pathWithin(dir, location.Path) {
+				continue
+			}
+			target := fmt.Sprintf("%s/library/sections/%s/refresh?path=%s", strings.TrimRight(r.Plex, "/"), url.PathEscape(section.Key), url.QueryEscape(dir))
+			_, err := mediaServerRequest(ctx, http.MethodGet, target, nil, map[string]string{"X-Plex-Token": r.PlexToken})
+			return err
+		}
+	}
+	return fmt.Errorf("no library holds %s; add its folder to a library, or map the path if Plex sees it elsewhere", dir)
+}
+
+// refreshJellyfin reports path as created, which Jellyfin scans in the library holding it
+func (r LibraryRefresh) refreshJellyfin(ctx context.Context, path string) error {
+	body, _ := json.Marshal(map[string]any{
+		"Updates": []map[string]string{{"Path": path, "UpdateType": "Created"}},
+	})
+	_, err := mediaServerRequest(ctx, http.MethodPost, strings.TrimRight(r.Jellyfin, "/")+"/Library/Media/Updated", body, map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("MediaBrowser Token=%q", r.JellyfinKey),
+	})
+	return err
+}
+
+// mediaServerRequest sends a request and returns the reply's body, failing unless it is a
+// success
+func mediaServerRequest(ctx context.Context, method, target string, body []byte, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var reply bytes.Buffer
+	reply.ReadFrom(resp.Body)
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("the server refused the token")
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("the server answered %s", resp.Status)
+	}
+	return reply.Bytes(), nil
+}
+
+// pathWithin reports whether path is dir or inside it. Both sides may be Windows paths, as
+// the media server may run on another machine.
+func pathWithin(path, dir string) bool {
+	path, dir = strings.ReplaceAll(path, `\`, "/"), strings.TrimRight(strings.ReplaceAll(dir, `\`, "/"), "/")
+	return dir != "" && (path == dir || strings.HasPrefix(path, dir+"/"))
+}
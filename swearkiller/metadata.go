@@ -0,0 +1,149 @@
+package swearkiller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MediaMetadata is the text a video's container carries besides the picture and sound: its
+// tags, like the title and comment, and its chapter titles
+type MediaMetadata struct {
+	Tags     map[string]string
+	Chapters []string // Chapter titles in order, "" for untitled chapters
+}
+
+// textTags are the container tags players and media servers show. The others, like the
+// encoder and creation time, aren't written by people and are left alone.
+var textTags = []string{"title", "subtitle", "comment", "description", "synopsis", "summary",
+	"show", "album", "artist", "album_artist", "genre", "keywords"}
+
+// ProbeMetadata reads a video's container tags and chapter titles with ffprobe
+func ProbeMetadata(ctx context.Context, video string) (MediaMetadata, error) {
+	data, err := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-print_format", "json", "-show_format", "-show_chapters", video).Output()
+	if err != nil {
+		return MediaMetadata{}, fmt.Errorf("failed to read metadata: %v", err)
+	}
+	var probe struct {
+		Format struct {
+			Tags map[string]string `json:"tags"`
+		} `json:"format"`
+		Chapters []struct {
+			Tags struct {
+				Title string `json:"title"`
+			} `json:"tags"`
+		} `json:"chapters"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return MediaMetadata{}, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+	meta := MediaMetadata{Tags: probe.Format.Tags}
+	for _, chapter := range probe.Chapters {
+		meta.Chapters = append(meta.Chapters, chapter.Tags.Title)
+	}
+	return meta, nil
+}
+
+// MetadataEdits are container tags and chapter titles written over the input's in the output
+type MetadataEdits struct {
+	Tags     map[string]string // By tag name, as ffprobe reports it
+	Chapters map[int]string    // By chapter index, from 0
+}
+
+// Count returns how many values are edited
+func (e MetadataEdits) Count() int {
+	return len(e.Tags) + len(e.Chapters)
+}
+
+// args returns the FFmpeg options that write the edits, in a stable order
+func (e MetadataEdits) args() []string {
+	var args []string
+	for _, key := range slices.Sorted(maps.Keys(e.Tags)) {
+		args = append(args, "-metadata", key+"="+e.Tags[key])
+	}
+	for _, i := range slices.Sorted(maps.Keys(e.Chapters)) {
+		args = append(args, fmt.Sprintf("-metadata:c:%d", i), "title="+e.Chapters[i])
+	}
+	return args
+}
+
+// TextSanitizer masks swears in short texts like titles, chapter names and file names,
+// which some releases fill with crude jokes
+type TextSanitizer struct {
+	Swears        []string
+	Options       MatchOptions
+	MinConfidence float64 // Swears found less surely than this are left alone
+}
+
+// sanitizeWordRe finds the words TextSanitizer masks, disguises like "f*ck" and "sh1t" included
+var sanitizeWordRe = regexp.MustCompile(`[\pL\pN*@$]+`)
+
+// Censor masks each word of text that is or contains a swear with mask, keeping its first
+// letter, so "Shit Happens" becomes "S*** Happens". Every word of a swear phrase is masked
+// once the phrase is found.
+func (s TextSanitizer) Censor(text string, mask rune) string {
+	m := newMatcher(s.Swears, s.Options)
+	found := s.find(m, text)
+	if len(found) == 0 {
+		return text
+	}
+	var phraseWords []string
+	for _, swear := range found {
+		if words := strings.Fields(NormalizeText(swear)); len(words) > 1 {
+			phraseWords = append(phraseWords, words...)
+		}
+	}
+	return sanitizeWordRe.ReplaceAllStringFunc(text, func(word string) string {
+		if !slices.Contains(phraseWords, NormalizeText(word)) && len(s.find(m, word)) == 0 {
+			return word
+		}
+		runes := []rune(word)
+		return string(runes[0]) + strings.Repeat(string(mask), len(runes)-1)
+	})
+}
+
+// find returns the swears m finds in text at least MinConfidence sure
+func (s TextSanitizer) find(m *matcher, text string) []string {
+	prepared := m.prepare(text)
+	var swears []string
+	for _, hit := range ScoreHits(m.hitsIn(prepared), prepared) {
+		if hit.Confidence >= s.MinConfidence {
+			swears = append(swears, hit.Word)
+		}
+	}
+	return swears
+}
+
+// Metadata returns the edits that mask the swears in meta's text tags and chapter titles
+// with asterisks
+func (s TextSanitizer) Metadata(meta MediaMetadata) MetadataEdits {
+	edits := MetadataEdits{Tags: map[string]string{}, Chapters: map[int]string{}}
+	for key, value := range meta.Tags {
+		if !slices.Contains(textTags, strings.ToLower(key)) {
+			continue
+		}
+		if clean := s.Censor(value, '*'); clean != value {
+			edits.Tags[key] = clean
+		}
+	}
+	for i, title := range meta.Chapters {
+		if clean := s.Censor(title, '*'); clean != title {
+			edits.Chapters[i] = clean
+		}
+	}
+	return edits
+}
+
+// Filename masks the swears in the name of the file at path with dashes, which every file
+// system allows, leaving its folder and extension alone
+func (s TextSanitizer) Filename(path string) string {
+	dir, name := filepath.Split(path)
+	ext := filepath.Ext(name)
+	return dir + s.Censor(strings.TrimSuffix(name, ext), '-') + ext
+}
@@ -0,0 +1,288 @@
+package swearkiller
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Matroska element IDs, with their length markers, as the spec writes them
+const (
+	ebmlHeaderID        = 0x1A45DFA3
+	ebmlDocTypeID       = 0x4282
+	mkvSegmentID        = 0x18538067
+	mkvInfoID           = 0x1549A966
+	mkvSegmentUIDID     = 0x73A4
+	mkvChaptersID       = 0x1043A770
+	mkvEditionID        = 0x45B9
+	mkvEditionOrdered   = 0x45DD
+	mkvEditionDefault   = 0x45DB
+	mkvChapterAtomID    = 0xB6
+	mkvChapterStart     = 0x91
+	mkvChapterEnd       = 0x92
+	mkvChapterEnabled   = 0x4598
+	mkvChapterSegmentID = 0x6E67
+)
+
+// ebmlUnknownSize marks an element whose size wasn't written, like a live stream's segment
+const ebmlUnknownSize = ^uint64(0)
+
+// maxMKVHeaderElement caps the metadata elements read into memory, so a damaged size can't
+// exhaust it
+const maxMKVHeaderElement = 16 << 20
+
+// ChapterSpan is a stretch of a file that an ordered chapter plays
+type ChapterSpan struct {
+	Start      float64 // Seconds into the file
+	End        float64
+	SegmentUID string // Hex UID of the file the chapter plays from, if it isn't this one
+}
+
+// OrderedChapters describes a Matroska file, common among anime releases, whose chapters
+// are played in a set order, possibly pulling in parts of other files, instead of from
+// start to end. Subtitles are timed for that playback order.
+type OrderedChapters struct {
+	Spans  []ChapterSpan // In playback order
+	Linked bool          // Some chapters play from other files
+}
+
+// ReadOrderedChapters reads the default edition of a Matroska file's chapters. It returns
+// nil when the file isn't Matroska or plays from start to end.
+func ReadOrderedChapters(path string) (*OrderedChapters, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := &fileByteReader{file: file}
+
+	id, size, err := readElementHeader(r)
+	if err != nil || id != ebmlHeaderID {
+		return nil, nil // Too short or not EBML, so not Matroska
+	}
+	header, err := readElementBody(file, size)
+	if err != nil {
+		return nil, err
+	}
+	if docType := string(findChild(header, ebmlDocTypeID)); docType != "matroska" && docType != "webm" {
+		return nil, nil
+	}
+	if id, _, err = readElementHeader(r); err != nil || id != mkvSegmentID {
+		return nil, fmt.Errorf("damaged Matroska file: no segment after the header")
+	}
+
+	// Walk the segment's top-level elements, skipping the big ones, until the chapters
+	// and the segment's own UID are found
+	var segmentUID, chapters []byte
+	for chapters == nil || segmentUID == nil {
+		id, size, err := readElementHeader(r)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || size == ebmlUnknownSize {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Matroska file: %v", err)
+		}
+		switch id {
+		case mkvInfoID, mkvChaptersID:
+			body, err := readElementBody(file, size)
+			if err != nil {
+				return nil, err
+			}
+			if id == mkvInfoID {
+				segmentUID = append([]byte{}, findChild(body, mkvSegmentUIDID)...)
+			} else {
+				chapters = body
+			}
+		default:
+			if _, err := file.Seek(int64(size), io.SeekCurrent); err != nil {
+				return nil, fmt.Errorf("failed to read Matroska file: %v", err)
+			}
+		}
+	}
+	if chapters == nil {
+		return nil, nil
+	}
+	return parseOrderedChapters(chapters, segmentUID)
+}
+
+// parseOrderedChapters reads the default edition from the body of a Chapters element
+func parseOrderedChapters(chapters, segmentUID []byte) (*OrderedChapters, error) {
+	var edition []byte
+	for _, child := range ebmlChildren(chapters) {
+		if child.id != mkvEditionID {
+			continue
+		}
+		if edition == nil || ebmlUint(findChild(child.body, mkvEditionDefault)) == 1 {
+			edition = child.body
+		}
+	}
+	if edition == nil || ebmlUint(findChild(edition, mkvEditionOrdered)) != 1 {
+		return nil, nil
+	}
+
+	ordered := &OrderedChapters{}
+	for _, atom := range ebmlChildren(edition) {
+		if atom.id != mkvChapterAtomID {
+			continue
+		}
+		if enabled := findChild(atom.body, mkvChapterEnabled); enabled != nil && ebmlUint(enabled) == 0 {
+			continue
+		}
+		end := findChild(atom.body, mkvChapterEnd)
+		if end == nil {
+			return nil, fmt.Errorf("damaged Matroska file: an ordered chapter has no end time")
+		}
+		span := ChapterSpan{
+			Start: float64(ebmlUint(findChild(atom.body, mkvChapterStart))) / 1e9,
+			End:   float64(ebmlUint(end)) / 1e9,
+		}
+		if uid := findChild(atom.body, mkvChapterSegmentID); len(uid) > 0 && !bytes.Equal(uid, segmentUID) {
+			span.SegmentUID = hex.EncodeToString(uid)
+			ordered.Linked = true
+		}
+		ordered.Spans = append(ordered.Spans, span)
+	}
+	return ordered, nil
+}
+
+// Timeline returns the table moving playback times onto the file's timeline. Chapters
+// from other files have no place in this one, so linked files are refused.
+func (o OrderedChapters) Timeline() (RemapTable, error) {
+	if o.Linked {
+		return nil, fmt.Errorf("this MKV uses ordered chapters that play parts of other files (linked segments, common with anime releases), " +
+			"so what you see isn't the file from start to end and the mutes would land at the wrong times. " +
+			"Join the parts into one file in playback order first (mkvmerge can append them), then run again")
+	}
+	var table RemapTable
+	playback := 0.0
+	for _, span := range o.Spans {
+		if span.End <= span.Start {
+			continue
+		}
+		length := span.End - span.Start
+		table = append(table, RemapRange{SourceStart: playback, SourceEnd: playback + length, TargetStart: span.Start, TargetEnd: span.End})
+		playback += length
+	}
+	return table, nil
+}
+
+// PlaybackTimeline returns the table moving a subtitle's times, which follow playback, onto
+// the video file when it is an MKV with ordered chapters. It returns nil for a video that
+// plays from start to end, and an error for one whose chapters play other files.
+func PlaybackTimeline(video string) (RemapTable, error) {
+	ordered, err := ReadOrderedChapters(video)
+	if err != nil || ordered == nil {
+		return nil, err
+	}
+	return ordered.Timeline()
+}
+
+// fileByteReader reads a file a byte at a time without buffering, so the file can be
+// seeked between reads
+type fileByteReader struct {
+	file *os.File
+	buf  [1]byte
+}
+
+func (r *fileByteReader) ReadByte() (byte, error) {
+	_, err := io.ReadFull(r.file, r.buf[:])
+	return r.buf[0], err
+}
+
+// readVint reads an EBML variable-length integer. IDs keep their length marker; sizes
+// drop it, and a size with every bit set is unknown.
+func readVint(r io.ByteReader, keepMarker bool) (uint64, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	length := 1
+	for mask := byte(0x80); length <= 8 && first&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 {
+		return 0, fmt.Errorf("invalid EBML number")
+	}
+	value := uint64(first)
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+	allOnes := value == uint64(0xFF>>length)
+	for i := 1; i < length; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+		value = value<<8 | uint64(b)
+		allOnes = allOnes && b == 0xFF
+	}
+	if !keepMarker && allOnes {
+		return ebmlUnknownSize, nil
+	}
+	return value, nil
+}
+
+// readElementHeader reads an element's ID and size
+func readElementHeader(r io.ByteReader) (id, size uint64, err error) {
+	if id, err = readVint(r, true); err != nil {
+		return 0, 0, err
+	}
+	size, err = readVint(r, false)
+	return id, size, err
+}
+
+// readElementBody reads a metadata element into memory
+func readElementBody(r io.Reader, size uint64) ([]byte, error) {
+	if size > maxMKVHeaderElement {
+		return nil, fmt.Errorf("damaged Matroska file: a header element is %d bytes", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("failed to read Matroska file: %v", err)
+	}
+	return body, nil
+}
+
+// ebmlElement is a child element parsed from a body in memory
+type ebmlElement struct {
+	id   uint64
+	body []byte
+}
+
+// ebmlChildren splits a body into its child elements, stopping at anything damaged
+func ebmlChildren(body []byte) []ebmlElement {
+	var children []ebmlElement
+	r := bytes.NewReader(body)
+	for r.Len() > 0 {
+		id, size, err := readElementHeader(r)
+		if err != nil || size > uint64(r.Len()) {
+			break
+		}
+		start := len(body) - r.Len()
+		children = append(children, ebmlElement{id: id, body: body[start : start+int(size)]})
+		r.Seek(int64(size), io.SeekCurrent)
+	}
+	return children
+}
+
+// findChild returns the body of the first child with the given ID, or nil
+func findChild(body []byte, id uint64) []byte {
+	for _, child := range ebmlChildren(body) {
+		if child.id == id {
+			return child.body
+		}
+	}
+	return nil
+}
+
+// ebmlUint decodes a big-endian unsigned integer element
+func ebmlUint(body []byte) uint64 {
+	var value uint64
+	for _, b := range body {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
@@ -0,0 +1,48 @@
+package swearkiller
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeText folds text for matching: compatibility decomposition (NFKD) turns fullwidth
+// and ligature characters into plain ones, diacritics are dropped and case is folded.
+// "Püta", "MERDÉ" and "ｆｕｃｋ" become "puta", "merde" and "fuck"; "ß" folds to "ss".
+// Turkish dotless "ı" has no decomposition and folds to plain "i" so "AMINA" matches "amına".
+func NormalizeText(s string) string {
+	if isASCII(s) {
+		// Nothing to decompose, and folding ASCII is lowercasing
+		return strings.ToLower(s)
+	}
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ReplaceAll(cases.Fold().String(folded), "ı", "i")
+}
+
+// isASCII reports whether s is plain ASCII
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeAll applies NormalizeText to every word in a list
+func normalizeAll(words []string) []string {
+	normalized := make([]string, len(words))
+	for i, word := range words {
+		normalized[i] = NormalizeText(word)
+	}
+	return normalized
+}
@@ -0,0 +1,192 @@
+package swearkiller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// notifyTimeout is how long each notification may take to send
+const notifyTimeout = 15 * time.Second
+
+// pushoverURL is Pushover's message API
+const pushoverURL = "https://api.pushover.net/1/messages.json"
+
+// NotifyConfig says where to send a notification when a job or batch finishes. Every
+// destination that is set gets it.
+type NotifyConfig struct {
+	Webhook string `json:"webhook,omitempty"` // URL a Notification is POSTed to as JSON
+	Ntfy    string `json:"ntfy,omitempty"`    // ntfy topic URL, like https://ntfy.sh/my-encodes
+
+	PushoverToken string `json:"pushover_token,omitempty"` // Pushover application token
+	PushoverUser  string `json:"pushover_user,omitempty"`  // Pushover user or group key
+
+	EmailTo      string `json:"email_to,omitempty"`      // Addresses to email, separated by commas
+	EmailFrom    string `json:"email_from,omitempty"`    // Sender (default: SMTPUser)
+	SMTPServer   string `json:"smtp_server,omitempty"`   // host:port of the mail server, like smtp.example.com:587
+	SMTPUser     string `json:"smtp_user,omitempty"`     // Login, if the server needs one
+	SMTPPassword string `json:"smtp_password,omitempty"` // Password for SMTPUser
+}
+
+// Notification is what a finished job or batch reports
+type Notification struct {
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Success bool      `json:"success"`
+	Video   string    `json:"video,omitempty"`  // The job's video; empty for a batch
+	Output  string    `json:"output,omitempty"` // Where the clean video was saved
+	Time    time.Time `json:"time"`
+}
+
+// JobNotification describes a job that cleaned video into output, muting segments, or
+// failed with err
+func JobNotification(video, output string, segments int, err error) Notification {
+	n := Notification{Video: video, Time: time.Now(), Success: err == nil}
+	name := filepath.Base(video)
+	if err != nil {
+		n.Title = "Swear Killer failed: " + name
+		n.Message = err.Error()
+		return n
+	}
+	n.Title = "Swear Killer cleaned " + name
+	n.Message = fmt.Sprintf("Censored %d segment(s); saved to %s", segments, output)
+	n.Output = output
+	return n
+}
+
+// BatchNotification describes a finished batch
+func BatchNotification(done, failed, skipped int) Notification {
+	n := Notification{Title: "Swear Killer batch finished", Time: time.Now(), Success: failed == 0}
+	n.Message = fmt.Sprintf("%d cleaned, %d failed", done, failed)
+	if skipped > 0 {
+		n.Message += fmt.Sprintf(", %d skipped", skipped)
+	}
+	return n
+}
+
+// Enabled reports whether any destination is set
+func (c NotifyConfig) Enabled() bool {
+	return c.Webhook != "" || c.Ntfy != "" || c.PushoverToken != "" || c.EmailTo != ""
+}
+
+// Validate checks the destinations are complete, so a typo shows up before a long encode
+// rather than after it
+func (c NotifyConfig) Validate() error {
+	for _, u := range []string{c.Webhook, c.Ntfy} {
+		if u == "" {
+			continue
+		}
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("notification URL %q must be an http or https URL", u)
+		}
+	}
+	if (c.PushoverToken == "") != (c.PushoverUser == "") {
+		return fmt.Errorf("Pushover needs both an application token and a user key")
+	}
+	if c.EmailTo != "" {
+		if c.SMTPServer == "" {
+			return fmt.Errorf("email notifications need an SMTP server")
+		}
+		if _, _, err := net.SplitHostPort(c.SMTPServer); err != nil {
+			return fmt.Errorf("SMTP server %q must be host:port, like smtp.example.com:587", c.SMTPServer)
+		}
+		if c.EmailFrom == "" && c.SMTPUser == "" {
+			return fmt.Errorf("email notifications need a sender address or an SMTP user")
+		}
+	}
+	return nil
+}
+
+// Send sends n to every destination that is set. A destination that fails doesn't stop
+// the others; their errors are joined.
+func (c NotifyConfig) Send(ctx context.Context, n Notification) error {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	var errs []error
+	if c.Webhook != "" {
+		body, _ := json.Marshal(n)
+		errs = append(errs, postNotification(ctx, "webhook", c.Webhook, "application/json", body, nil))
+	}
+	if c.Ntfy != "" {
+		tag := "white_check_mark"
+		if !n.Success {
+			tag = "x"
+		}
+		errs = append(errs, postNotification(ctx, "ntfy", c.Ntfy, "text/plain", []byte(n.Message),
+			map[string]string{"Title": mime.QEncoding.Encode("utf-8", headerSafe(n.Title)), "Tags": tag}))
+	}
+	if c.PushoverToken != "" {
+		form := url.Values{"token": {c.PushoverToken}, "user": {c.PushoverUser}, "title": {n.Title}, "message": {n.Message}}
+		errs = append(errs, postNotification(ctx, "Pushover", pushoverURL, "application/x-www-form-urlencoded", []byte(form.Encode()), nil))
+	}
+	if c.EmailTo != "" {
+		errs = append(errs, c.sendEmail(n))
+	}
+	return errors.Join(errs...)
+}
+
+// postNotification POSTs a notification, failing unless the reply is a success
+func postNotification(ctx context.Context, name, target, contentType string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s notification: %v", name, err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s notification: %v", name, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s notification: the server answered %s", name, resp.Status)
+	}
+	return nil
+}
+
+// sendEmail emails n through the SMTP server, using STARTTLS when the server offers it
+func (c NotifyConfig) sendEmail(n Notification) error {
+	from := c.EmailFrom
+	if from == "" {
+		from = c.SMTPUser
+	}
+	var to []string
+	for _, address := range strings.Split(c.EmailTo, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			to = append(to, address)
+		}
+	}
+	var auth smtp.Auth
+	if c.SMTPUser != "" {
+		host, _, _ := net.SplitHostPort(c.SMTPServer)
+		auth = smtp.PlainAuth("", c.SMTPUser, c.SMTPPassword, host)
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerSafe(n.Title)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Message, "\n", "\r\n") + "\r\n")
+	if err := smtp.SendMail(c.SMTPServer, auth, from, to, []byte(msg.String())); err != nil {
+		return fmt.Errorf("email notification: %v", err)
+	}
+	return nil
+}
+
+// headerSafe keeps a value on one header line, as a file name could hold a line break
+func headerSafe(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
@@ -0,0 +1,93 @@
+package swearkiller
+
+import (
+	"regexp"
+	"strings"
+)
+
+// leetSubstitutions lists the characters commonly typed in place of each letter
+var leetSubstitutions = map[rune]string{
+	'a': "4@",
+	'b': "8",
+	'e': "3",
+	'g': "96",
+	'i': "1!|l",
+	'l': "1|",
+	'o': "0",
+	's': "5$",
+	't': "7+",
+	'u': "v",
+}
+
+// obfuscationSeparators are the characters used to space out letters, as in "f u c k" or "f.u.c.k"
+const obfuscationSeparators = `[ .\-_]`
+
+// obfuscationPattern matches obfuscated spellings of one swear word
+type obfuscationPattern struct {
+	compact *regexp.Regexp // "f*ck", "sh1t", "fvck"
+	spaced  *regexp.Regexp // "f u c k", "s.h.i.t"
+}
+
+// letterClass returns a regexp character class for a normalized letter and its look-alikes.
+// Letters after the first may also be starred out.
+func letterClass(r rune, first bool) string {
+	chars := string(r) + leetSubstitutions[r]
+	if !first {
+		chars += "*"
+	}
+	return "[" + regexp.QuoteMeta(chars) + "]"
+}
+
+// newObfuscationPattern builds the patterns for a normalized swear word, or nil if the word
+// is too short to match obfuscated spellings without flooding the results
+func newObfuscationPattern(swear string) *obfuscationPattern {
+	var letters []string
+	words := strings.Fields(swear)
+	for w, word := range words {
+		if w > 0 {
+			letters = append(letters, " ")
+		}
+		for i, r := range word {
+			letters = append(letters, letterClass(r, w == 0 && i == 0))
+		}
+	}
+	if len([]rune(strings.Join(words, ""))) < 3 {
+		return nil
+	}
+
+	var compact, spaced strings.Builder
+	for i, class := range letters {
+		if class == " " {
+			compact.WriteString(`\s+`)
+			spaced.WriteString(obfuscationSeparators + "+")
+			continue
+		}
+		if i > 0 && letters[i-1] != " " {
+			spaced.WriteString(obfuscationSeparators)
+		}
+		compact.WriteString(class)
+		spaced.WriteString(class)
+	}
+	return &obfuscationPattern{
+		compact: regexp.MustCompile(compact.String()),
+		// Spaced-out letters must start a word so "wash it" doesn't read as "s h i t"
+		spaced: regexp.MustCompile(`(?:^|[^\pL\pN])` + spaced.String()),
+	}
+}
+
+// matches reports whether normalized text contains an obfuscated spelling of the word
+func (p *obfuscationPattern) matches(text string) bool {
+	return p.compact.MatchString(text) || p.spaced.MatchString(text)
+}
+
+// newObfuscationPatterns builds obfuscation patterns for a list of normalized swear words.
+// Entries are nil for words that are too short.
+func newObfuscationPatterns(swears []string) []*obfuscationPattern {
+	patterns := make([]*obfuscationPattern, len(swears))
+	for i, swear := range swears {
+		if swear != "" {
+			patterns[i] = newObfuscationPattern(swear)
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,201 @@
+package swearkiller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultOCRCommand is the Tesseract program used to read image subtitles
+const DefaultOCRCommand = "tesseract"
+
+// DefaultOCRLanguage is the Tesseract language used when none is given
+const DefaultOCRLanguage = "eng"
+
+// ocrLastLineSeconds is how long the last subtitle image is assumed to stay up when
+// nothing clears it
+const ocrLastLineSeconds = 4.0
+
+// ImageSubtitleCodecs are the subtitle codecs drawn as pictures: Blu-ray PGS and DVD VobSub
+var ImageSubtitleCodecs = []string{"hdmv_pgs_subtitle", "dvd_subtitle"}
+
+// OCROptions configures reading image subtitles as text
+type OCROptions struct {
+	Command  string // Tesseract program (empty = DefaultOCRCommand)
+	Language string // Tesseract language like "eng" or "eng+spa" (empty = DefaultOCRLanguage)
+}
+
+// ImageSubtitleTracks lists the video's PGS and VobSub subtitle streams
+func ImageSubtitleTracks(ctx context.Context, video string) ([]SubtitleTrack, error) {
+	tracks, err := SubtitleTracks(ctx, video)
+	if err != nil {
+		return nil, err
+	}
+	var images []SubtitleTrack
+	for _, track := range tracks {
+		if slices.Contains(ImageSubtitleCodecs, track.Codec) {
+			images = append(images, track)
+		}
+	}
+	return images, nil
+}
+
+// showinfoRe picks the frame number and time out of FFmpeg's showinfo filter log lines
+var showinfoRe = regexp.MustCompile(`\bn:\s*(\d+)\b.*\bpts_time:\s*([0-9.]+)`)
+
+// OCRSubtitles reads an image subtitle track as text. FFmpeg renders a picture each time the
+// subtitle changes, and Tesseract reads each one; blank pictures end the line before them.
+func OCRSubtitles(ctx context.Context, video string, track int, opts OCROptions, logFn func(string)) ([]Cue, error) {
+	if opts.Command == "" {
+		opts.Command = DefaultOCRCommand
+	}
+	if opts.Language == "" {
+		opts.Language = DefaultOCRLanguage
+	}
+	if _, err := exec.LookPath(opts.Command); err != nil {
+		return nil, fmt.Errorf("OCR needs Tesseract (%s), which isn't installed", opts.Command)
+	}
+	dir, err := os.MkdirTemp("", tempPrefix+"ocr-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-v", "info", "-nostats", "-i", video,
+		"-filter_complex", fmt.Sprintf("[0:s:%d]showinfo[out]", track), "-map", "[out]",
+		"-fps_mode", "passthrough", filepath.Join(dir, "%06d.png"))
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to render subtitle images: %v %s", err, lastLines(stderr.String(), 3))
+	}
+	var times []float64
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		if m := showinfoRe.FindStringSubmatch(line); m != nil {
+			t, _ := strconv.ParseFloat(m[2], 64)
+			times = append(times, t)
+		}
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("no subtitle images found in track %d", track)
+	}
+	logFn(fmt.Sprintf("Reading %d subtitle image(s) with Tesseract...", len(times)))
+
+	var cues []Cue
+	for i, start := range times {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if i > 0 && i%100 == 0 {
+			logFn(fmt.Sprintf("Read %d of %d subtitle image(s)", i, len(times)))
+		}
+		text, err := ocrImage(ctx, filepath.Join(dir, fmt.Sprintf("%06d.png", i+1)), opts)
+		if err != nil {
+			return nil, err
+		}
+		end := start + ocrLastLineSeconds
+		if i+1 < len(times) {
+			end = times[i+1]
+		}
+		if text == "" || end <= start {
+			continue
+		}
+		// The same picture can be rendered twice in a row; keep it as one line
+		if n := len(cues); n > 0 && cues[n-1].Text == text && cues[n-1].End >= start {
+			cues[n-1].End = end
+			continue
+		}
+		cues = append(cues, Cue{Index: len(cues) + 1, Start: start, End: end, Text: text, Raw: text})
+	}
+	return cues, nil
+}
+
+// ocrImage reads the text in one rendered subtitle image, or "" for a blank one
+func ocrImage(ctx context.Context, path string, opts OCROptions) (string, error) {
+	prepared, ok, err := prepareOCRImage(path)
+	if err != nil || !ok {
+		return "", err
+	}
+	output, err := exec.CommandContext(ctx, opts.Command, prepared, "stdout", "-l", opts.Language, "--psm", "6").Output()
+	if err != nil {
+		return "", fmt.Errorf("tesseract failed on %s: %v", filepath.Base(path), err)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " "), nil
+}
+
+// prepareOCRImage crops a subtitle image to its text and turns the light, outlined text on
+// a transparent background into dark text on white, which Tesseract reads best. It reports
+// false for a blank image.
+func prepareOCRImage(path string) (string, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	img, err := png.Decode(file)
+	file.Close()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read subtitle image: %v", err)
+	}
+
+	bounds := img.Bounds()
+	box := image.Rectangle{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a > 0 {
+				box = box.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if box.Empty() {
+		return "", false, nil
+	}
+	const padding = 10
+	box = box.Inset(-padding).Intersect(bounds)
+
+	out := image.NewGray(image.Rect(0, 0, box.Dx(), box.Dy()))
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			// Premultiplied, so transparent pixels count as black
+			r, g, b, _ := img.At(x, y).RGBA()
+			lum := (299*r + 587*g + 114*b) / 1000 >> 8
+			out.SetGray(x-box.Min.X, y-box.Min.Y, color.Gray{Y: 255 - uint8(lum)})
+		}
+	}
+	prepared := strings.TrimSuffix(path, ".png") + "-ocr.png"
+	outFile, err := os.Create(prepared)
+	if err != nil {
+		return "", false, err
+	}
+	defer outFile.Close()
+	if err := png.Encode(outFile, out); err != nil {
+		return "", false, err
+	}
+	return prepared, true, nil
+}
+
+// lastLines returns the last n non-empty lines of s, joined with spaces
+func lastLines(s string, n int) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines[max(len(lines)-n, 0):], " ")
+}
@@ -0,0 +1,130 @@
+package swearkiller
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// NameTemplate names clean outputs after their input, like "{name} [clean].{ext}". Its
+// tokens are
+//
+//	{name}      the input's file name without its extension
+//	{ext}       the output's extension: mp4 for videos, the input's own for audio files
+//	{profile}   the profile it is cleaned with, or "custom" without one
+//	{severity}  the mildest language the profile censors: "all", "moderate" or "strong"
+//	{date}      the day it is named, like 2026-10-16
+//
+// A template without an extension gets ".{ext}". An empty one is DefaultNameTemplate.
+type NameTemplate string
+
+// DefaultNameTemplate names outputs like "movie-CLEAN.mp4", as before templates
+const DefaultNameTemplate NameTemplate = "{name}-CLEAN.{ext}"
+
+// nameTokenRe finds the tokens in a template
+var nameTokenRe = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// nameTokens maps each token to a pattern its values match, for Matches
+var nameTokens = map[string]string{
+	"name":     `.+`,
+	"ext":      `[A-Za-z0-9]+`,
+	"profile":  `[A-Za-z0-9_-]+`,
+	"severity": `[a-z]+`,
+	"date":     `\d{4}-\d{2}-\d{2}`,
+}
+
+// template returns the template, with the default for an empty one and ".{ext}" added if
+// it names no extension
+func (t NameTemplate) template() string {
+	template := string(cmp.Or(t, DefaultNameTemplate))
+	tail := template[strings.LastIndex(template, "}")+1:]
+	if !strings.HasSuffix(template, "{ext}") && !strings.Contains(tail, ".") {
+		template += ".{ext}"
+	}
+	return template
+}
+
+// Validate checks the template uses only known tokens and names a file beside the input
+// that can't be the input itself
+func (t NameTemplate) Validate() error {
+	template := t.template()
+	for _, match := range nameTokenRe.FindAllStringSubmatch(template, -1) {
+		if _, ok := nameTokens[match[1]]; !ok {
+			names := make([]string, 0, len(nameTokens))
+			for name := range nameTokens {
+				names = append(names, "{"+name+"}")
+			}
+			slices.Sort(names)
+			return fmt.Errorf("name template %q has an unknown token {%s} (available: %s)", t, match[1], strings.Join(names, ", "))
+		}
+	}
+	if !strings.Contains(template, "{name}") {
+		return fmt.Errorf("name template %q needs {name}, or every output would get the same name", t)
+	}
+	if strings.ContainsAny(template, `/\`) {
+		return fmt.Errorf("name template %q must name a file, not a folder; pick the folder with the output folder option", t)
+	}
+	if literal := nameTokenRe.ReplaceAllString(template, ""); strings.Trim(literal, ".") == "" {
+		return fmt.Errorf("name template %q must add something to {name}, or outputs could overwrite their inputs", t)
+	}
+	return nil
+}
+
+// Name returns the file name of input's clean output when cleaned with profile ("" for
+// none)
+func (t NameTemplate) Name(input, profile string) string {
+	ext := "mp4"
+	if IsAudioFile(input) {
+		ext = strings.ToLower(strings.TrimPrefix(filepath.Ext(input), "."))
+	}
+	return t.name(input, ext, profile, time.Now())
+}
+
+// name fills in the template for input with the given extension and date
+func (t NameTemplate) name(input, ext, profile string, now time.Time) string {
+	base := filepath.Base(input)
+	values := map[string]string{
+		"name":     strings.TrimSuffix(base, filepath.Ext(base)),
+		"ext":      ext,
+		"profile":  cmp.Or(profile, "custom"),
+		"severity": profileSeverity(profile),
+		"date":     now.Format(time.DateOnly),
+	}
+	return nameTokenRe.ReplaceAllStringFunc(t.template(), func(token string) string {
+		return values[strings.Trim(token, "{}")]
+	})
+}
+
+// Matches reports whether path is named like an output of the template, including one
+// renamed by OverwriteRename, so batches and watched folders don't clean their own outputs
+// again
+func (t NameTemplate) Matches(path string) bool {
+	path = withoutRenameSuffix(path)
+	var pattern strings.Builder
+	template := t.template()
+	last := 0
+	for _, loc := range nameTokenRe.FindAllStringSubmatchIndex(template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		pattern.WriteString("(?:" + cmp.Or(nameTokens[template[loc[2]:loc[3]]], ".*") + ")")
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	re, err := regexp.Compile("(?i)^" + pattern.String() + "$")
+	return err == nil && re.MatchString(filepath.Base(path))
+}
+
+// profileSeverity is the {severity} of a profile: the mildest language it censors
+func profileSeverity(profile string) string {
+	p, err := FindProfile(profile)
+	switch {
+	case profile == "" || err != nil || p.Categories == nil:
+		return "all"
+	case slices.Contains(p.Categories, CategoryModerate):
+		return "moderate"
+	}
+	return "strong"
+}
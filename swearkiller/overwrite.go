@@ -0,0 +1,74 @@
+package swearkiller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// OverwritePolicy says what happens when a clean output already exists
+type OverwritePolicy string
+
+const (
+	OverwriteReplace OverwritePolicy = "overwrite" // Replace it, with a note in the log (the default)
+	OverwriteFail    OverwritePolicy = "fail"      // Stop with an error
+	OverwriteRename  OverwritePolicy = "rename"    // Write beside it as "movie-CLEAN (2).mp4", and so on
+	OverwriteSkip    OverwritePolicy = "skip"      // Leave it alone and don't clean the video
+)
+
+// OverwritePolicies lists the policies, for flags and settings
+var OverwritePolicies = []OverwritePolicy{OverwriteFail, OverwriteReplace, OverwriteRename, OverwriteSkip}
+
+// ParseOverwritePolicy parses a policy name, with "" meaning OverwriteReplace
+func ParseOverwritePolicy(name string) (OverwritePolicy, error) {
+	if name == "" {
+		return OverwriteReplace, nil
+	}
+	for _, p := range OverwritePolicies {
+		if strings.EqualFold(name, string(p)) {
+			return p, nil
+		}
+	}
+	names := make([]string, len(OverwritePolicies))
+	for i, p := range OverwritePolicies {
+		names[i] = string(p)
+	}
+	return "", fmt.Errorf("unknown overwrite policy %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// ResolveOutput returns where to write output under the policy: output itself if nothing
+// is there yet or it is replaced, or the first free "name (N).ext" beside it for
+// OverwriteRename. skip is true if OverwriteSkip leaves an existing output alone.
+func (p OverwritePolicy) ResolveOutput(output string) (path string, skip bool, err error) {
+	if _, err := os.Stat(output); err != nil {
+		return output, false, nil
+	}
+	switch p {
+	case OverwriteFail:
+		return output, false, fmt.Errorf("%s already exists; choose another output or overwrite policy", output)
+	case OverwriteSkip:
+		return output, true, nil
+	case OverwriteRename:
+		ext := filepath.Ext(output)
+		stem := strings.TrimSuffix(output, ext)
+		for n := 2; ; n++ {
+			path = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+			if _, err := os.Stat(path); err != nil {
+				return path, false, nil
+			}
+		}
+	}
+	return output, false, nil
+}
+
+// renameSuffixRe finds the " (N)" OverwriteRename adds before the extension
+var renameSuffixRe = regexp.MustCompile(` \(\d+\)$`)
+
+// withoutRenameSuffix returns path without the " (N)" OverwriteRename may have added, so
+// renamed outputs are still recognised by their names
+func withoutRenameSuffix(path string) string {
+	ext := filepath.Ext(path)
+	return renameSuffixRe.ReplaceAllString(strings.TrimSuffix(path, ext), "") + ext
+}
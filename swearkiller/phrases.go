@@ -0,0 +1,68 @@
+package swearkiller
+
+import (
+	"regexp"
+	"strings"
+)
+
+// lineHyphenRe finds a word hyphenated across a line break ("fuc-" + "king"), which
+// ParseSRT leaves as "fuc- king"
+var lineHyphenRe = regexp.MustCompile(`(\pL)- +(\pL)`)
+
+// prepareText normalizes subtitle text for matching and rejoins words hyphenated across lines
+func prepareText(text string) string {
+	normalized := NormalizeText(text)
+	if !strings.Contains(normalized, "- ") {
+		return normalized
+	}
+	return lineHyphenRe.ReplaceAllString(normalized, "$1$2")
+}
+
+// newPhrasePatterns builds patterns for the multi-word entries of a normalized swear list.
+// The words of a phrase may be separated by any spaces, line breaks or hyphens, or run together.
+// Entries are nil for single words.
+func newPhrasePatterns(swears []string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(swears))
+	for i, swear := range swears {
+		words := strings.Fields(swear)
+		if len(words) < 2 {
+			continue
+		}
+		for j, word := range words {
+			words[j] = regexp.QuoteMeta(word)
+		}
+		patterns[i] = regexp.MustCompile(strings.Join(words, `[\s\-]*`))
+	}
+	return patterns
+}
+
+// splitPhrase looks for phrases that only match once cue and the following block are joined.
+// The returned match spans both blocks.
+func (m *matcher) splitPhrase(cue, next Cue, maxGap float64) (Match, bool) {
+	if next.Start-cue.End > maxGap {
+		return Match{}, false
+	}
+	first, second := m.prepare(cue.Text), m.prepare(next.Text)
+	joined := m.prepare(cue.Text + " " + next.Text)
+
+	var hits []Hit
+	for i, swear := range m.swears {
+		if m.phrases[i] == nil || m.matches(i, first) || m.matches(i, second) {
+			continue
+		}
+		if matchType, ok := m.find(i, joined); ok {
+			hits = append(hits, Hit{Word: swear, Type: matchType})
+		}
+	}
+	if len(hits) == 0 {
+		return Match{}, false
+	}
+	spanning := Cue{
+		Index: cue.Index,
+		Start: cue.Start,
+		End:   next.End,
+		Text:  cue.Text + " " + next.Text,
+		Raw:   cue.Raw + " " + next.Raw,
+	}
+	return newMatch(spanning, hits, joined), true
+}
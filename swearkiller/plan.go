@@ -0,0 +1,338 @@
+package swearkiller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// PlanVersion is the plan file format written by this version
+const PlanVersion = 1
+
+// PlanExt is the extension of plan files saved next to a video
+const PlanExt = ".plan.json"
+
+// Plan is the censoring plan for one release of a video: the segments that were censored in
+// it. Plans are keyed by the video's QuickHash, so a family who cleaned a release can share
+// the work with others who own the same one.
+type Plan struct {
+	Version    int       `json:"version"`
+	SourceHash string    `json:"source_hash"`        // QuickHash of the video the plan is for
+	Title      string    `json:"title,omitempty"`    // Video file name without its extension
+	Video      string    `json:"video,omitempty"`    // Path of the video the plan was made from
+	Subtitle   string    `json:"subtitle,omitempty"` // Path of the subtitle it was made from
+	Duration   float64   `json:"duration,omitempty"` // Length of the video in seconds, if known
+	Offset     float64   `json:"offset,omitempty"`   // Subtitle offset already applied to the segments
+	Languages  []string  `json:"languages,omitempty"`
+	Segments   []Segment `json:"segments"`
+	Reviewed   bool      `json:"reviewed"` // Someone checked the segments by hand
+	Created    time.Time `json:"created"`
+}
+
+// ErrPlanNotFound is returned when a repository has no plan for a video
+var ErrPlanNotFound = errors.New("no plan for this video in the repository")
+
+// sourceHashRe matches a QuickHash, which is what plans are filed under
+var sourceHashRe = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// NewPlan starts a plan for a video, hashing it and probing its length
+func NewPlan(ctx context.Context, videoPath string, segments []Segment) (Plan, error) {
+	hash, err := QuickHash(videoPath)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to hash video: %v", err)
+	}
+	plan := Plan{
+		Version:    PlanVersion,
+		SourceHash: hash,
+		Title:      strings.TrimSuffix(filepath.Base(videoPath), filepath.Ext(videoPath)),
+		Video:      videoPath,
+		Segments:   MergeSegments(append([]Segment{}, segments...)),
+		Created:    time.Now().UTC(),
+	}
+	if plan.Segments == nil {
+		plan.Segments = []Segment{}
+	}
+	if duration, err := ProbeDuration(ctx, videoPath); err == nil {
+		plan.Duration = duration
+	}
+	return plan, nil
+}
+
+// Validate checks a plan read from a file or repository can be used
+func (p Plan) Validate() error {
+	if p.Version < 1 || p.Version > PlanVersion {
+		return fmt.Errorf("unsupported plan version %d (this version reads up to %d)", p.Version, PlanVersion)
+	}
+	if !sourceHashRe.MatchString(p.SourceHash) {
+		return fmt.Errorf("plan has an invalid source hash %q", p.SourceHash)
+	}
+	for i, seg := range p.Segments {
+		if seg.Start < 0 || seg.End < seg.Start {
+			return fmt.Errorf("plan segment %d has bad timing %.3f-%.3f", i+1, seg.Start, seg.End)
+		}
+		if _, ok := LookupCensorAction(seg.Action); !ok || seg.Action == ActionCut {
+			return fmt.Errorf("plan segment %d has unknown action %q", i+1, seg.Action)
+		}
+	}
+	return nil
+}
+
+// CheckVideo returns an error unless the plan was made for this release of the video
+func (p Plan) CheckVideo(videoPath string) error {
+	hash, err := QuickHash(videoPath)
+	if err != nil {
+		return fmt.Errorf("failed to hash video: %v", err)
+	}
+	if hash != p.SourceHash {
+		return fmt.Errorf("the plan was made for a different release of this video (source hash %s, this video is %s)", p.SourceHash[:12], hash[:12])
+	}
+	return nil
+}
+
+// String summarizes the plan in one line
+func (p Plan) String() string {
+	reviewed := "not reviewed"
+	if p.Reviewed {
+		reviewed = "reviewed"
+	}
+	title := p.Title
+	if title == "" {
+		title = p.SourceHash[:min(len(p.SourceHash), 12)]
+	}
+	return fmt.Sprintf("%s: %d segment(s), %s", title, len(p.Segments), reviewed)
+}
+
+// Anonymize returns the plan as it is shared: without local paths or personal settings,
+// dated to the day, and with its segments in video time rounded to the millisecond and cut
+// off at the video's length. The notes say what was left out or changed.
+func (p Plan) Anonymize() (Plan, []string) {
+	var notes []string
+	shared := Plan{
+		Version:    p.Version,
+		SourceHash: p.SourceHash,
+		Title:      p.Title[strings.LastIndexAny(p.Title, `/\`)+1:],
+		Duration:   roundMillis(p.Duration),
+		Reviewed:   p.Reviewed,
+		Created:    p.Created.UTC().Truncate(24 * time.Hour),
+	}
+	if shared.Title != p.Title {
+		notes = append(notes, "the folders in the title")
+	}
+	if p.Video != "" {
+		notes = append(notes, "the video's path")
+	}
+	if p.Subtitle != "" {
+		notes = append(notes, "the subtitle's path")
+	}
+	if p.Offset != 0 {
+		// The segments already have it applied, so they're in video time without it
+		notes = append(notes, fmt.Sprintf("the subtitle offset (%gs, already applied to the segments)", p.Offset))
+	}
+	if len(p.Languages) > 0 {
+		notes = append(notes, "the swear list languages")
+	}
+	if !p.Created.IsZero() && !p.Created.Equal(shared.Created) {
+		notes = append(notes, "the time of day it was made")
+	}
+
+	dropped := 0
+	for _, seg := range p.Segments {
+		seg.Start, seg.End = roundMillis(seg.Start), roundMillis(seg.End)
+		if shared.Duration > 0 {
+			if seg.Start >= shared.Duration {
+				dropped++
+				continue
+			}
+			seg.End = min(seg.End, shared.Duration)
+		}
+		shared.Segments = append(shared.Segments, seg)
+	}
+	if dropped > 0 {
+		notes = append(notes, fmt.Sprintf("%d segment(s) past the end of the video", dropped))
+	}
+	shared.Segments = MergeSegments(shared.Segments)
+	if shared.Segments == nil {
+		shared.Segments = []Segment{}
+	}
+	return shared, notes
+}
+
+// roundMillis rounds seconds to the millisecond, so shared plans don't carry float noise
+func roundMillis(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
+
+// SharedPlanJSON returns exactly what Publish sends for a plan: the anonymized plan as
+// indented JSON
+func SharedPlanJSON(plan Plan) ([]byte, error) {
+	shared, _ := plan.Anonymize()
+	data, err := json.MarshalIndent(shared, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ParsePlan reads and validates a plan from JSON
+func ParsePlan(data []byte) (Plan, error) {
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return Plan{}, fmt.Errorf("failed to parse plan: %v", err)
+	}
+	return plan, plan.Validate()
+}
+
+// ReadPlanFile reads and validates a plan file
+func ReadPlanFile(path string) (Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to read plan: %v", err)
+	}
+	return ParsePlan(data)
+}
+
+// WritePlanFile saves a plan as indented JSON
+func WritePlanFile(path string, plan Plan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// PlanPath returns where a video's plan is saved by default: next to it, with PlanExt
+func PlanPath(videoPath string) string {
+	return strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + PlanExt
+}
+
+// PlanRepository is a community collection of plans, with one <source hash>.json file per
+// release. Location is an http(s) URL, published to with PUT and fetched with GET, or a
+// folder such as a shared drive or a git checkout.
+type PlanRepository struct {
+	Location string
+	Token    string // Sent as a bearer token to http(s) repositories, if set
+	Client   *http.Client
+}
+
+// isRemote reports whether the repository is reached over HTTP
+func (r PlanRepository) isRemote() bool {
+	return strings.HasPrefix(r.Location, "http://") || strings.HasPrefix(r.Location, "https://")
+}
+
+// planLocation returns the URL or path of the plan for a source hash
+func (r PlanRepository) planLocation(hash string) (string, error) {
+	if !sourceHashRe.MatchString(hash) {
+		return "", fmt.Errorf("invalid source hash %q", hash)
+	}
+	if r.isRemote() {
+		return strings.TrimSuffix(r.Location, "/") + "/" + hash + ".json", nil
+	}
+	return filepath.Join(r.Location, hash+".json"), nil
+}
+
+// request sends an HTTP request to the repository
+func (r PlanRepository) request(method, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+	client := r.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return client.Do(req)
+}
+
+// Publish adds a plan to the repository, anonymized (see Plan.Anonymize), replacing any
+// earlier plan for the same release
+func (r PlanRepository) Publish(plan Plan) error {
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+	location, err := r.planLocation(plan.SourceHash)
+	if err != nil {
+		return err
+	}
+	data, err := SharedPlanJSON(plan)
+	if err != nil {
+		return err
+	}
+	if !r.isRemote() {
+		if err := os.MkdirAll(r.Location, 0755); err != nil {
+			return fmt.Errorf("failed to create repository folder: %v", err)
+		}
+		return os.WriteFile(location, data, 0644)
+	}
+
+	resp, err := r.request(http.MethodPut, location, data)
+	if err != nil {
+		return fmt.Errorf("failed to publish plan: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("repository refused the plan: %s %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// Fetch gets the plan for a release from the repository, returning ErrPlanNotFound if
+// nobody has published one
+func (r PlanRepository) Fetch(hash string) (Plan, error) {
+	location, err := r.planLocation(hash)
+	if err != nil {
+		return Plan{}, err
+	}
+	var data []byte
+	if r.isRemote() {
+		resp, err := r.request(http.MethodGet, location, nil)
+		if err != nil {
+			return Plan{}, fmt.Errorf("failed to fetch plan: %v", err)
+		}
+		defer resp.Body.Close()
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			return Plan{}, ErrPlanNotFound
+		case resp.StatusCode/100 != 2:
+			return Plan{}, fmt.Errorf("failed to fetch plan: %s", resp.Status)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, maxPlanSize)); err != nil {
+			return Plan{}, fmt.Errorf("failed to fetch plan: %v", err)
+		}
+	} else {
+		if data, err = os.ReadFile(location); err != nil {
+			if os.IsNotExist(err) {
+				return Plan{}, ErrPlanNotFound
+			}
+			return Plan{}, fmt.Errorf("failed to read plan: %v", err)
+		}
+	}
+
+	plan, err := ParsePlan(data)
+	if err != nil {
+		return Plan{}, err
+	}
+	if plan.SourceHash != hash {
+		return Plan{}, fmt.Errorf("the repository returned a plan for a different release (%s)", plan.SourceHash[:12])
+	}
+	return plan, nil
+}
+
+// maxPlanSize bounds how much of a repository's response is read
+const maxPlanSize = 16 << 20
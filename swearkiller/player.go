@@ -0,0 +1,67 @@
+package swearkiller
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// PlayerClipLength is how many seconds the preview player plays from the chosen position
+const PlayerClipLength = 8.0
+
+// PlayerArgs returns ffplay arguments that play length seconds of a video from start, with
+// its picture. With censored set the segments are muted as they would be in the output
+// (tone segments too, since ffplay can't mix in the tone), so the timing can be checked by
+// ear before encoding.
+func PlayerArgs(video string, start, length float64, segments []Segment, censored bool) []string {
+	start = max(start, 0)
+	args := []string{
+		"-autoexit", "-hide_banner", "-loglevel", "error",
+		"-window_title", "Swear Killer - " + FormatTimestamp(start),
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-t", fmt.Sprintf("%.3f", length),
+	}
+	// ffplay keeps the file's timestamps after seeking, so segments stay in video time
+	if censored {
+		var inClip []Segment
+		for _, seg := range segments {
+			if seg.End > start && seg.Start < start+length {
+				inClip = append(inClip, seg)
+			}
+		}
+		if len(inClip) > 0 {
+			args = append(args, "-af", BuildVolumeFilter(inClip))
+		}
+	}
+	return append(args, video)
+}
+
+// FrameArgs returns FFmpeg arguments that write the frame of a video at the given time,
+// scaled to width pixels wide, to stdout as PNG
+func FrameArgs(video string, at float64, width int) []string {
+	return []string{
+		"-hide_banner", "-loglevel", "error",
+		"-ss", fmt.Sprintf("%.3f", max(at, 0)),
+		"-i", video,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=%d:-2", width),
+		"-f", "image2pipe", "-c:v", "png",
+		"pipe:1",
+	}
+}
+
+// ExtractFrame grabs the frame of a video at the given time as PNG data, for scrubbing
+func ExtractFrame(video string, at float64, width int) ([]byte, error) {
+	cmd := exec.Command("ffmpeg", FrameArgs(video, at, width)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract frame: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no picture at %s", FormatTimestamp(at))
+	}
+	return data, nil
+}
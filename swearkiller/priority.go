@@ -0,0 +1,110 @@
+package swearkiller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// ffmpegRuns tracks the FFmpeg encodes RunFFmpegContext has running, and the checks
+// runTracked runs on their outputs, so they can be paused and resumed together, and
+// whether new ones start at low priority
+var ffmpegRuns = struct {
+	sync.Mutex
+	low     bool
+	paused  bool
+	running map[*os.Process]bool
+}{running: map[*os.Process]bool{}}
+
+// SetLowPriority makes encodes started from now on run at low CPU priority (nice on Linux
+// and macOS, below normal on Windows), so the computer stays responsive while they run.
+// They take longer only when something else wants the CPU.
+func SetLowPriority(low bool) {
+	ffmpegRuns.Lock()
+	defer ffmpegRuns.Unlock()
+	ffmpegRuns.low = low
+}
+
+// PauseEncodes suspends every running encode, and any started later, until ResumeEncodes.
+// A paused encode uses no CPU but keeps its memory and files.
+func PauseEncodes() error {
+	ffmpegRuns.Lock()
+	defer ffmpegRuns.Unlock()
+	ffmpegRuns.paused = true
+	var errs []error
+	for process := range ffmpegRuns.running {
+		errs = append(errs, suspendProcess(process))
+	}
+	return errors.Join(errs...)
+}
+
+// ResumeEncodes carries on the encodes PauseEncodes suspended
+func ResumeEncodes() error {
+	ffmpegRuns.Lock()
+	defer ffmpegRuns.Unlock()
+	ffmpegRuns.paused = false
+	var errs []error
+	for process := range ffmpegRuns.running {
+		errs = append(errs, resumeProcess(process))
+	}
+	return errors.Join(errs...)
+}
+
+// EncodesPaused reports whether encodes are paused
+func EncodesPaused() bool {
+	ffmpegRuns.Lock()
+	defer ffmpegRuns.Unlock()
+	return ffmpegRuns.paused
+}
+
+// ffmpegCommand creates the command that runs FFmpeg, at low priority if that is set
+func ffmpegCommand(ctx context.Context, args []string) *exec.Cmd {
+	ffmpegRuns.Lock()
+	low := ffmpegRuns.low
+	ffmpegRuns.Unlock()
+	return lowPriorityCommand(ctx, low, "ffmpeg", args)
+}
+
+// ffprobeCommand creates the command that runs FFprobe, at low priority if that is set
+func ffprobeCommand(ctx context.Context, args []string) *exec.Cmd {
+	ffmpegRuns.Lock()
+	low := ffmpegRuns.low
+	ffmpegRuns.Unlock()
+	return lowPriorityCommand(ctx, low, "ffprobe", args)
+}
+
+// runTracked runs a command from ffmpegCommand or ffprobeCommand and returns its output,
+// with what it logs as well if combined is set. It is tracked like an encode, so the
+// passes that decode a whole output to check it pause and resume with the encodes.
+func runTracked(cmd *exec.Cmd, combined bool) ([]byte, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	if combined {
+		cmd.Stderr = &output
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	defer trackFFmpeg(cmd.Process)()
+	err := cmd.Wait()
+	return output.Bytes(), err
+}
+
+// trackFFmpeg records a started encode, suspending it straight away if encodes are paused.
+// The returned function forgets it once it has exited.
+func trackFFmpeg(process *os.Process) func() {
+	ffmpegRuns.Lock()
+	defer ffmpegRuns.Unlock()
+	ffmpegRuns.running[process] = true
+	if ffmpegRuns.paused {
+		suspendProcess(process)
+	}
+	return func() {
+		ffmpegRuns.Lock()
+		defer ffmpegRuns.Unlock()
+		delete(ffmpegRuns.running, process)
+	}
+}
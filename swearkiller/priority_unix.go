@@ -0,0 +1,32 @@
+//go:build unix
+
+package swearkiller
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// lowPriorityCommand runs name through nice when low is set, so the process and every
+// thread it starts get the lower priority from the outset
+func lowPriorityCommand(ctx context.Context, low bool, name string, args []string) *exec.Cmd {
+	if low {
+		if nice, err := exec.LookPath("nice"); err == nil {
+			// nice replaces itself with the program, so signals still reach it
+			return exec.CommandContext(ctx, nice, append([]string{"-n", "10", name}, args...)...)
+		}
+	}
+	return exec.CommandContext(ctx, name, args...)
+}
+
+// suspendProcess stops a process until resumeProcess
+func suspendProcess(process *os.Process) error {
+	return process.Signal(syscall.SIGSTOP)
+}
+
+// resumeProcess carries on a process suspendProcess stopped
+func resumeProcess(process *os.Process) error {
+	return process.Signal(syscall.SIGCONT)
+}
@@ -0,0 +1,56 @@
+//go:build windows
+
+package swearkiller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+const (
+	belowNormalPriorityClass = 0x00004000 // Process creation flag
+	processSuspendResume     = 0x0800     // Access right
+)
+
+// Windows has no signal to pause a process; ntdll's suspend and resume calls do it for
+// all of its threads at once
+var (
+	ntdll            = syscall.NewLazyDLL("ntdll.dll")
+	ntSuspendProcess = ntdll.NewProc("NtSuspendProcess")
+	ntResumeProcess  = ntdll.NewProc("NtResumeProcess")
+)
+
+// lowPriorityCommand starts name in the below normal priority class when low is set
+func lowPriorityCommand(ctx context.Context, low bool, name string, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if low {
+		cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: belowNormalPriorityClass}
+	}
+	return cmd
+}
+
+// suspendProcess stops a process until resumeProcess
+func suspendProcess(process *os.Process) error {
+	return callOnProcess(ntSuspendProcess, process)
+}
+
+// resumeProcess carries on a process suspendProcess stopped
+func resumeProcess(process *os.Process) error {
+	return callOnProcess(ntResumeProcess, process)
+}
+
+// callOnProcess calls an ntdll function taking a process handle
+func callOnProcess(proc *syscall.LazyProc, process *os.Process) error {
+	handle, err := syscall.OpenProcess(processSuspendResume, false, uint32(process.Pid))
+	if err != nil {
+		return err
+	}
+	defer syscall.CloseHandle(handle)
+	if status, _, _ := proc.Call(uintptr(handle)); status != 0 {
+		return fmt.Errorf("%s failed with status %#x", proc.Name, status)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package swearkiller
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Profile is a named level of strictness. It decides which matches of a broad scan (see
+// BroadMatchOptions) are muted, so several profiles can be compared on one scan.
+type Profile struct {
+	Name          string
+	Description   string
+	Categories    []Category  // Categories it mutes (nil = all)
+	Types         []MatchType // How a word may be found for it to count
+	MinConfidence float64     // Matches below this are left for review
+	Padding       float64     // Seconds added before and after each segment
+	Action        Action      // How its segments are censored (empty = mute)
+}
+
+// DefaultProfile is the profile the GUI starts new users on. The command line has no first
+// run to tell apart, so it keeps the legacy matching unless --profile is passed, and the
+// same command gives the same result in existing scripts.
+const DefaultProfile = "safe"
+
+// Profiles are the built-in profiles, strictest first
+var Profiles = []Profile{
+	{
+		Name:          "strict",
+		Description:   "every listed word, including disguised spellings and uncertain matches",
+		Types:         []MatchType{MatchWholeWord, MatchSubstring, MatchObfuscated},
+		MinConfidence: 0.3,
+	},
+	{
+		Name:          "legacy",
+		Description:   "the settings from before profiles: every listed word, even inside longer words like 'class'",
+		Types:         []MatchType{MatchWholeWord, MatchSubstring},
+		MinConfidence: DefaultMinConfidence,
+	},
+	{
+		Name:          "safe",
+		Description:   "whole words from the moderate list, padded by 0.3s and covered with a beep; where new users start",
+		Categories:    []Category{CategoryStrong, CategoryModerate, CategorySlur, CategorySexual},
+		Types:         []MatchType{MatchWholeWord},
+		MinConfidence: DefaultMinConfidence,
+		Padding:       0.3,
+		Action:        ActionBeep,
+	},
+	{
+		Name:          "mild",
+		Description:   "only strong language, slurs and sexual references, as whole words",
+		Categories:    []Category{CategoryStrong, CategorySlur, CategorySexual},
+		Types:         []MatchType{MatchWholeWord},
+		MinConfidence: 0.7,
+	},
+}
+
+// ProfileNames lists the built-in profile names
+func ProfileNames() []string {
+	names := make([]string, len(Profiles))
+	for i, p := range Profiles {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// profileAliases maps old profile names to the profiles that replaced them
+var profileAliases = map[string]string{"default": "legacy"}
+
+// FindProfile returns the built-in profile with the given name
+func FindProfile(name string) (Profile, error) {
+	if alias, ok := profileAliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+		name = alias
+	}
+	for _, p := range Profiles {
+		if strings.EqualFold(p.Name, strings.TrimSpace(name)) {
+			return p, nil
+		}
+	}
+	return Profile{}, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(ProfileNames(), ", "))
+}
+
+// BroadMatchOptions returns opts widened to find everything any profile mutes: disguised
+// spellings and swears inside longer words
+func BroadMatchOptions(opts MatchOptions) MatchOptions {
+	opts.Deobfuscate = true
+	opts.WholeWords = false
+	return opts
+}
+
+// Apply keeps the hits of a broad scan the profile counts and returns the matches left,
+// each with the confidence of its best remaining hit. Split them with the profile's
+// MinConfidence to get the ones it mutes.
+func (p Profile) Apply(matches []Match) []Match {
+	var kept []Match
+	for _, m := range matches {
+		filtered := Match{Cue: m.Cue}
+		for _, hit := range m.Hits {
+			// Fuzzy hits are only there when asked for and are held for review anyway
+			if !slices.Contains(p.Types, hit.Type) && hit.Type != MatchFuzzy {
+				continue
+			}
+			if p.Categories != nil && !slices.Contains(p.Categories, CategorizeWord(hit.Word)) {
+				continue
+			}
+			filtered.Words = append(filtered.Words, hit.Word)
+			filtered.Hits = append(filtered.Hits, hit)
+			filtered.Confidence = max(filtered.Confidence, hit.Confidence)
+		}
+		if len(filtered.Hits) > 0 {
+			kept = append(kept, filtered)
+		}
+	}
+	return kept
+}
+
+// Shape pads the segments and sets their action as the profile says
+func (p Profile) Shape(segments []Segment) []Segment {
+	if p.Padding <= 0 && p.Action == "" {
+		return segments
+	}
+	shaped := make([]Segment, len(segments))
+	for i, seg := range segments {
+		shaped[i] = Segment{Start: max(seg.Start-p.Padding, 0), End: seg.End + p.Padding, Action: cmp.Or(p.Action, seg.Action)}
+	}
+	return shaped
+}
+
+// ProfileResult is what a profile would censor in a title
+type ProfileResult struct {
+	Name         string
+	Lines        int // Subtitle lines muted
+	Review       int // Lines left for review
+	Segments     int // Merged segments
+	MutedSeconds float64
+}
+
+// Simulate works out what the profile would censor from a broad scan's matches, without
+// reading the subtitle or the video again
+func (p Profile) Simulate(matches []Match, offset float64) ProfileResult {
+	muted, review := SplitByConfidence(p.Apply(matches), p.MinConfidence)
+	segments := MergeSegments(p.Shape(MatchSegments(muted, offset, nil)))
+	return ProfileResult{Name: p.Name, Lines: len(muted), Review: len(review), Segments: len(segments), MutedSeconds: SegmentsDuration(segments)}
+}
+
+// CompareProfiles simulates every built-in profile on a broad scan's matches
+func CompareProfiles(matches []Match, offset float64) []ProfileResult {
+	results := make([]ProfileResult, len(Profiles))
+	for i, p := range Profiles {
+		results[i] = p.Simulate(matches, offset)
+	}
+	return results
+}
+
+// FormatProfileComparison lays results out side by side, one column per profile. The share
+// of the runtime muted is left out when runtime is unknown.
+func FormatProfileComparison(results []ProfileResult, runtime float64) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	row := func(label string, cell func(ProfileResult) string) {
+		fmt.Fprint(w, label)
+		for _, r := range results {
+			fmt.Fprint(w, "\t"+cell(r))
+		}
+		fmt.Fprintln(w)
+	}
+	row("", func(r ProfileResult) string { return r.Name })
+	row("Lines muted", func(r ProfileResult) string { return fmt.Sprint(r.Lines) })
+	row("Left for review", func(r ProfileResult) string { return fmt.Sprint(r.Review) })
+	row("Segments", func(r ProfileResult) string { return fmt.Sprint(r.Segments) })
+	row("Muted time", func(r ProfileResult) string { return fmt.Sprintf("%.1fs", r.MutedSeconds) })
+	if runtime > 0 {
+		row("Of runtime", func(r ProfileResult) string { return fmt.Sprintf("%.2f%%", r.MutedSeconds/runtime*100) })
+	}
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,41 @@
+package swearkiller
+
+import "time"
+
+// ProgressReporter is told how cleaning a video is going, so the GUI, the CLI and the
+// HTTP API can each show it their own way without knowing how FFmpeg reports it
+type ProgressReporter interface {
+	// SegmentsFound is called once the segments to censor are known, before encoding
+	SegmentsFound(count int)
+	// Encoding is called as FFmpeg encodes, with the fraction done from 0 to 1 and how
+	// long the rest should take at the pace so far (0 until there is a pace to go on)
+	Encoding(fraction float64, eta time.Duration)
+}
+
+// ProgressFunc is a ProgressReporter for callers that only want the fraction encoded
+type ProgressFunc func(fraction float64)
+
+// SegmentsFound does nothing
+func (f ProgressFunc) SegmentsFound(count int) {}
+
+// Encoding calls f with the fraction encoded
+func (f ProgressFunc) Encoding(fraction float64, eta time.Duration) { f(fraction) }
+
+// EncodeProgress returns an onProgress callback for RunFFmpegContext and RunFFmpegToFile
+// that tells reporter the fraction of duration encoded and the time left. It returns nil,
+// which asks for no progress, if reporter is nil or the duration isn't known.
+func EncodeProgress(duration float64, reporter ProgressReporter) func(currentTime float64) {
+	if reporter == nil || duration <= 0 {
+		return nil
+	}
+	start := time.Now()
+	return func(currentTime float64) {
+		fraction := min(max(currentTime/duration, 0), 1)
+		var eta time.Duration
+		if fraction > 0 {
+			elapsed := time.Since(start)
+			eta = time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+		}
+		reporter.Encoding(fraction, eta)
+	}
+}
@@ -0,0 +1,226 @@
+package swearkiller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ProjectVersion is the project file format written by this version
+const ProjectVersion = 1
+
+// ProjectExt is the extension of project files
+const ProjectExt = ".skproj"
+
+// Project is a censoring session saved to pick up later: the files, the offset, the
+// segments as detected and as edited by hand, and what was decided about each match. Unlike
+// a Plan it keeps everything needed to carry on, local paths included, and isn't shared.
+type Project struct {
+	Version        int           `json:"version"`
+	Video          string        `json:"video"`
+	Subtitle       string        `json:"subtitle,omitempty"`
+	Language       string        `json:"language,omitempty"` // Subtitle's language, "" to detect it
+	Embedded       bool          `json:"embedded,omitempty"` // The subtitle came out of the video, so it follows the file's own timeline
+	ExtraSubtitles []string      `json:"extra_subtitles,omitempty"`
+	Output         string        `json:"output,omitempty"`
+	Offset         float64       `json:"offset"`
+	Profile        string        `json:"profile,omitempty"` // Profile the matches were found with, if any
+	Detected       []Segment     `json:"detected"`          // Segments as detection found them
+	Segments       []Segment     `json:"segments"`          // Segments to censor, after review and edits by hand
+	Review         []ProjectItem `json:"review,omitempty"`  // Every match and what was decided about it
+	Saved          time.Time     `json:"saved"`
+}
+
+// ProjectItem is one match in a project and what was decided about it
+type ProjectItem struct {
+	Index      int            `json:"index"` // Subtitle block number
+	Start      float64        `json:"start"` // Subtitle time in seconds, before the offset
+	End        float64        `json:"end"`
+	Text       string         `json:"text"`
+	Hits       []Hit          `json:"hits"`
+	Confidence float64        `json:"confidence"`
+	Segment    Segment        `json:"segment"` // Segment it is censored with, offset and adjustments included
+	Decision   ReviewDecision `json:"decision"`
+}
+
+// ProjectItems turns review items into the project's form
+func ProjectItems(items []ReviewItem) []ProjectItem {
+	var projectItems []ProjectItem
+	for _, item := range items {
+		m := item.Match
+		projectItems = append(projectItems, ProjectItem{Index: m.Cue.Index, Start: m.Cue.Start, End: m.Cue.End, Text: m.Cue.Text,
+			Hits: m.Hits, Confidence: m.Confidence, Segment: item.Segment, Decision: item.Decision})
+	}
+	return projectItems
+}
+
+// ReviewItems returns the project's matches as review items, decisions and all
+func (p Project) ReviewItems() []ReviewItem {
+	var items []ReviewItem
+	for _, item := range p.Review {
+		m := Match{Cue: Cue{Index: item.Index, Start: item.Start, End: item.End, Text: item.Text}, Hits: item.Hits, Confidence: item.Confidence}
+		for _, hit := range item.Hits {
+			if hit.Word != "" {
+				m.Words = append(m.Words, hit.Word)
+			}
+		}
+		items = append(items, ReviewItem{Match: m, Segment: item.Segment, Decision: item.Decision})
+	}
+	return items
+}
+
+// Validate checks a project read from a file can be used
+func (p Project) Validate() error {
+	if p.Version < 1 || p.Version > ProjectVersion {
+		return fmt.Errorf("unsupported project version %d (this version reads up to %d)", p.Version, ProjectVersion)
+	}
+	if p.Video == "" {
+		return fmt.Errorf("project has no video")
+	}
+	return checkSegments("project", p.Segments)
+}
+
+// checkSegments checks segments read from a file have sound timing and known actions;
+// what names the file in errors
+func checkSegments(what string, segments []Segment) error {
+	for i, seg := range segments {
+		if seg.Start < 0 || seg.End < seg.Start {
+			return fmt.Errorf("%s segment %d has bad timing %.3f-%.3f", what, i+1, seg.Start, seg.End)
+		}
+		if _, ok := LookupCensorAction(seg.Action); !ok {
+			return fmt.Errorf("%s segment %d has unknown action %q", what, i+1, seg.Action)
+		}
+	}
+	return nil
+}
+
+// ReadProjectFile reads and validates a project file. Paths in it are relative to the
+// folder it is in.
+func ReadProjectFile(path string) (Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Project{}, fmt.Errorf("failed to read project: %v", err)
+	}
+	var project Project
+	if err := json.Unmarshal(data, &project); err != nil {
+		return Project{}, fmt.Errorf("failed to parse project: %v", err)
+	}
+	if err := project.Validate(); err != nil {
+		return Project{}, err
+	}
+	project.mapPaths(func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(filepath.Dir(path), filepath.FromSlash(p))
+	})
+	return project, nil
+}
+
+// WriteProjectFile saves a project as indented JSON, stamped with the time. Files in the
+// project file's folder or below are written relative to it, so the folder can be moved
+// or opened from another computer as a whole.
+func WriteProjectFile(path string, project Project) error {
+	project.Version = ProjectVersion
+	project.Saved = time.Now().UTC()
+	if project.Detected == nil {
+		project.Detected = []Segment{}
+	}
+	if project.Segments == nil {
+		project.Segments = []Segment{}
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	project.mapPaths(func(p string) string {
+		rel, err := filepath.Rel(dir, p)
+		if p == "" || !filepath.IsAbs(p) || err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return p
+		}
+		return filepath.ToSlash(rel)
+	})
+	data, err := json.MarshalIndent(project, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// mapPaths replaces each of the project's file paths with fn's result
+func (p *Project) mapPaths(fn func(string) string) {
+	p.Video, p.Subtitle, p.Output = fn(p.Video), fn(p.Subtitle), fn(p.Output)
+	p.ExtraSubtitles = slices.Clone(p.ExtraSubtitles) // Don't change the caller's copy
+	for i, extra := range p.ExtraSubtitles {
+		p.ExtraSubtitles[i] = fn(extra)
+	}
+}
+
+// ProjectPath returns where a video's project is saved by default: next to it, with ProjectExt
+func ProjectPath(videoPath string) string {
+	return strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ProjectExt
+}
+
+// SavedSegments are segments read back from a file to encode again without searching the
+// subtitle (see ReadSegmentsFile)
+type SavedSegments struct {
+	Video     string // Video the file names, "" if it names none
+	Profile   string // Profile the segments were found with, if the file says
+	Segments  []Segment
+	Plan      *Plan // Plan the segments came from, so its release can be checked; nil for other files
+	Undecided int   // Matches of a project nobody decided about, which aren't censored
+}
+
+// ReadSegmentsFile reads the segments to censor from a project file, a plan, or a JSON file
+// of segments: an array of them, as in the segments tag (see SegmentsTag), or an object with
+// a "segments" array. A project's segments are kept as they are, edits by hand included.
+func ReadSegmentsFile(path string) (SavedSegments, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SavedSegments{}, fmt.Errorf("failed to read segments: %v", err)
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var segments []Segment
+		if err := json.Unmarshal(trimmed, &segments); err != nil {
+			return SavedSegments{}, fmt.Errorf("failed to parse segments: %v", err)
+		}
+		return SavedSegments{Segments: segments}, checkSegments("file", segments)
+	}
+	var probe struct {
+		Version    int        `json:"version"`
+		SourceHash string     `json:"source_hash"`
+		Video      string     `json:"video"`
+		Segments   *[]Segment `json:"segments"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return SavedSegments{}, fmt.Errorf("failed to parse segments: %v", err)
+	}
+	switch {
+	case probe.SourceHash != "":
+		plan, err := ParsePlan(data)
+		if err != nil {
+			return SavedSegments{}, err
+		}
+		return SavedSegments{Video: plan.Video, Segments: plan.Segments, Plan: &plan}, nil
+	case probe.Version != 0 && probe.Video != "":
+		project, err := ReadProjectFile(path)
+		if err != nil {
+			return SavedSegments{}, err
+		}
+		saved := SavedSegments{Video: project.Video, Profile: project.Profile, Segments: project.Segments}
+		for _, item := range project.Review {
+			if item.Decision == ReviewUndecided {
+				saved.Undecided++
+			}
+		}
+		return saved, nil
+	case probe.Segments != nil:
+		return SavedSegments{Video: probe.Video, Segments: *probe.Segments}, checkSegments("file", *probe.Segments)
+	}
+	return SavedSegments{}, fmt.Errorf("%s isn't a project, a plan or a list of segments", filepath.Base(path))
+}
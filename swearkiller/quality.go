@@ -0,0 +1,122 @@
+package swearkiller
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// QualityInput is what the quality gate looks at before a long encode
+type QualityInput struct {
+	Cues          []Cue
+	VideoDuration float64  // Video length in seconds, 0 if unknown
+	AudioLanguage string   // Language tag of the video's first audio track, "" if unknown
+	ListLanguages []string // Languages the swear list covers; the user's own list counts as English
+}
+
+// QualityReport lists reasons to suspect the subtitle doesn't belong to the video
+type QualityReport struct {
+	Problems []string
+}
+
+// Passed reports whether the subtitle looks right for the video
+func (r QualityReport) Passed() bool {
+	return len(r.Problems) == 0
+}
+
+// String lists the problems one per line
+func (r QualityReport) String() string {
+	return "- " + strings.Join(r.Problems, "\n- ")
+}
+
+const (
+	qualityOverrun     = 120.0 // Seconds a subtitle may run past the end of the video
+	qualityMinCoverage = 0.5   // Share of the video the subtitle must reach
+	qualityMinDensity  = 1.0   // Subtitle lines per minute of video below which it looks partial
+	qualityMinGap      = 600.0 // A silent stretch this long (and a quarter of the video) looks like missing lines
+)
+
+// CheckQuality compares the subtitle with the video to catch the wrong subtitle file before
+// hours are spent encoding: a length that doesn't match, too few lines or a long stretch
+// without any, and a language the swear list or the audio doesn't share
+func CheckQuality(in QualityInput) QualityReport {
+	var report QualityReport
+	add := func(format string, args ...any) {
+		report.Problems = append(report.Problems, fmt.Sprintf(format, args...))
+	}
+
+	if len(in.Cues) == 0 {
+		add("The subtitle has no lines")
+		return report
+	}
+
+	lastEnd := 0.0
+	for _, cue := range in.Cues {
+		if cue.End > lastEnd {
+			lastEnd = cue.End
+		}
+	}
+
+	if duration := in.VideoDuration; duration > 0 {
+		if lastEnd > duration+qualityOverrun {
+			add("The subtitle runs until %s but the video is only %s long; it may be for a longer cut or a different video",
+				FormatTimestamp(lastEnd), FormatTimestamp(duration))
+		} else if lastEnd < duration*qualityMinCoverage {
+			add("The subtitle ends at %s, only %.0f%% into the %s video; it may be incomplete or for a different video",
+				FormatTimestamp(lastEnd), lastEnd/duration*100, FormatTimestamp(duration))
+		}
+
+		minutes := duration / 60
+		if minutes >= 10 && float64(len(in.Cues))/minutes < qualityMinDensity {
+			add("Only %d subtitle lines for a %s video; this may be a forced (foreign parts only) subtitle",
+				len(in.Cues), FormatTimestamp(duration))
+		}
+
+		gapStart, gap := 0.0, in.Cues[0].Start
+		for i := 1; i < len(in.Cues); i++ {
+			if g := in.Cues[i].Start - in.Cues[i-1].End; g > gap {
+				gapStart, gap = in.Cues[i-1].End, g
+			}
+		}
+		if gap >= qualityMinGap && gap >= duration/4 {
+			add("There are no subtitle lines for %s from %s; part of the subtitle may be missing",
+				FormatTimestamp(gap), FormatTimestamp(gapStart))
+		}
+	}
+
+	detected := DetectLanguages(in.Cues)
+	for _, code := range detected {
+		if !containsString(in.ListLanguages, code) {
+			add("The subtitle looks like %s, which the swear list doesn't cover", LanguageName(code))
+		}
+	}
+	if audio := NormalizeLanguage(in.AudioLanguage); audio != "" && len(detected) > 0 && !containsString(detected, audio) {
+		add("The subtitle looks like %s but the audio is tagged %s", LanguageName(detected[0]), LanguageName(audio))
+	}
+	return report
+}
+
+// CheckVideoQuality probes the video with ffprobe and runs CheckQuality. Details that
+// can't be probed just skip the checks that need them.
+func CheckVideoQuality(ctx context.Context, cues []Cue, videoPath string, listLanguages []string) QualityReport {
+	duration, _ := ProbeDuration(ctx, videoPath)
+	audioLanguage, _ := ProbeAudioLanguage(ctx, videoPath)
+	return CheckQuality(QualityInput{
+		Cues:          cues,
+		VideoDuration: duration,
+		AudioLanguage: audioLanguage,
+		ListLanguages: listLanguages,
+	})
+}
+
+// ProbeAudioLanguage uses ffprobe to read the language tag of a video's first audio track
+func ProbeAudioLanguage(ctx context.Context, videoPath string) (string, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-select_streams", "a:0",
+		"-show_entries", "stream_tags=language", "-of", "csv=p=0", videoPath)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
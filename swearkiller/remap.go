@@ -0,0 +1,173 @@
+package swearkiller
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// RemapRange maps one stretch of the subtitle's timeline onto the video's. Times in
+// between are interpolated linearly, so a range can also correct a frame rate difference.
+type RemapRange struct {
+	SourceStart float64
+	SourceEnd   float64
+	TargetStart float64
+	TargetEnd   float64
+}
+
+// RemapTable is a piecewise-linear timestamp mapping, for example from an extended
+// edition's subtitle to the theatrical cut. Subtitle times outside every range have no
+// place in the video.
+type RemapTable []RemapRange
+
+// ParseTimestamp reads a time given as seconds ("83.5") or as [HH:]MM:SS[.mmm] ("1:23.5"),
+// with either a period or a comma before the fraction
+func ParseTimestamp(s string) (float64, error) {
+	s = strings.Replace(strings.TrimSpace(s), ",", ".", 1)
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	total := 0.0
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 || (i > 0 && value >= 60) {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		total = total*60 + value
+	}
+	return total, nil
+}
+
+// ParseRemapTable reads a remap table with one range per line:
+//
+//	source_start source_end -> target_start target_end
+//
+// The arrow is optional, blank lines and lines starting with # are skipped, and the
+// table is validated before it is returned.
+func ParseRemapTable(r io.Reader) (RemapTable, error) {
+	var table RemapTable
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.ReplaceAll(line, "->", " "))
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: expected 'source_start source_end -> target_start target_end'", lineNum)
+		}
+		var times [4]float64
+		for i, field := range fields {
+			t, err := ParseTimestamp(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+			times[i] = t
+		}
+		table = append(table, RemapRange{SourceStart: times[0], SourceEnd: times[1], TargetStart: times[2], TargetEnd: times[3]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// ReadRemapFile reads and validates a remap table file
+func ReadRemapFile(path string) (RemapTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open remap file: %v", err)
+	}
+	defer file.Close()
+
+	table, err := ParseRemapTable(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remap file: %v", err)
+	}
+	return table, nil
+}
+
+// Validate checks that every range moves forward and that the ranges are in order and
+// don't overlap on either timeline, so the mapping never runs backwards
+func (t RemapTable) Validate() error {
+	if len(t) == 0 {
+		return fmt.Errorf("remap table has no ranges")
+	}
+	for i, r := range t {
+		if r.SourceEnd <= r.SourceStart {
+			return fmt.Errorf("range %d: source end %s is not after its start %s", i+1, formatSeconds(r.SourceEnd), formatSeconds(r.SourceStart))
+		}
+		if r.TargetEnd <= r.TargetStart {
+			return fmt.Errorf("range %d: target end %s is not after its start %s", i+1, formatSeconds(r.TargetEnd), formatSeconds(r.TargetStart))
+		}
+		if i == 0 {
+			continue
+		}
+		prev := t[i-1]
+		if r.SourceStart < prev.SourceEnd {
+			return fmt.Errorf("range %d: source start %s is before the end of range %d (%s); ranges must be in order without overlapping",
+				i+1, formatSeconds(r.SourceStart), i, formatSeconds(prev.SourceEnd))
+		}
+		if r.TargetStart < prev.TargetEnd {
+			return fmt.Errorf("range %d: target start %s is before the end of range %d (%s); ranges must be in order without overlapping",
+				i+1, formatSeconds(r.TargetStart), i, formatSeconds(prev.TargetEnd))
+		}
+	}
+	return nil
+}
+
+// formatSeconds formats seconds as HH:MM:SS.mmm for error messages and exports
+func formatSeconds(seconds float64) string {
+	millis := int(seconds*1000 + 0.5)
+	return fmt.Sprintf("%s.%03d", FormatTimestamp(float64(millis/1000)), millis%1000)
+}
+
+// find returns the range that contains a source time
+func (t RemapTable) find(source float64) (RemapRange, bool) {
+	for _, r := range t {
+		if source >= r.SourceStart && source < r.SourceEnd {
+			return r, true
+		}
+	}
+	return RemapRange{}, false
+}
+
+// scale returns how much the range stretches time
+func (r RemapRange) scale() float64 {
+	return (r.TargetEnd - r.TargetStart) / (r.SourceEnd - r.SourceStart)
+}
+
+// Map converts a subtitle time to video time. It reports false for times outside every range.
+func (t RemapTable) Map(source float64) (float64, bool) {
+	r, ok := t.find(source)
+	if !ok {
+		return 0, false
+	}
+	return r.TargetStart + (source-r.SourceStart)*r.scale(), true
+}
+
+// Apply moves cues onto the video's timeline. Cues that start outside every range are
+// dropped; a cue that runs past the end of its range keeps its (scaled) length.
+func (t RemapTable) Apply(cues []Cue) []Cue {
+	var remapped []Cue
+	for _, cue := range cues {
+		r, ok := t.find(cue.Start)
+		if !ok {
+			continue
+		}
+		start, _ := t.Map(cue.Start)
+		cue.End = start + (cue.End-cue.Start)*r.scale()
+		cue.Start = start
+		remapped = append(remapped, cue)
+	}
+	return remapped
+}
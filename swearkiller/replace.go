@@ -0,0 +1,157 @@
+package swearkiller
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Backup says what happens to the original when ReplaceOriginal puts the clean file in
+// its place
+type Backup string
+
+const (
+	BackupNone  Backup = "none"  // Delete it
+	BackupOrig  Backup = "orig"  // Keep it beside the clean file as "movie.mkv.orig"
+	BackupTrash Backup = "trash" // Move it to the trash or recycle bin
+)
+
+// Backups lists the backup choices, for flags
+var Backups = []Backup{BackupNone, BackupOrig, BackupTrash}
+
+// OrigExt is added to the original's name by BackupOrig
+const OrigExt = ".orig"
+
+// ParseBackup parses a backup choice, with "" meaning BackupNone
+func ParseBackup(name string) (Backup, error) {
+	if name == "" {
+		return BackupNone, nil
+	}
+	for _, b := range Backups {
+		if strings.EqualFold(name, string(b)) {
+			return b, nil
+		}
+	}
+	names := make([]string, len(Backups))
+	for i, b := range Backups {
+		names[i] = string(b)
+	}
+	return "", fmt.Errorf("unknown backup %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// ReplaceOriginal puts the clean file in place of the original and returns where it went:
+// the original's path, or the same name with the clean file's extension if the two
+// differ. The clean file takes the original's permissions, and its owner where the system
+// allows it. It is first moved or, from another drive, copied beside the original under a
+// hidden partial name, so the original is only given up once the clean file is there in
+// full; if backing up the original fails, the original is left as it was. If the clean
+// file then can't be renamed into place, the original is moved back from its backup or
+// the trash (on Windows the error says to restore it from the recycle bin) and the clean
+// file goes back where it was.
+func ReplaceOriginal(original, clean string, backup Backup) (string, error) {
+	info, err := os.Stat(original)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the original: %v", err)
+	}
+	replaced := strings.TrimSuffix(original, filepath.Ext(original)) + filepath.Ext(clean)
+	if _, err := os.Stat(replaced); err == nil && replaced != original {
+		return "", fmt.Errorf("%s already exists beside the original; move it away first so it isn't overwritten", replaced)
+	}
+	partial := partialPath(replaced)
+	if err := moveFile(clean, partial); err != nil {
+		return "", fmt.Errorf("failed to move the clean file beside the original: %v", err)
+	}
+	// On failure the clean file goes back where it was, as it took a whole encode to make
+	undo := func() { moveFile(partial, clean) }
+	if err := os.Chmod(partial, info.Mode().Perm()); err != nil {
+		undo()
+		return "", fmt.Errorf("failed to give the clean file the original's permissions: %v", err)
+	}
+	keepOwner(partial, info)
+
+	var backedUp, trashed string
+	switch backup {
+	case BackupOrig:
+		backedUp = original + OrigExt
+		if _, err := os.Stat(backedUp); err == nil {
+			undo()
+			return "", fmt.Errorf("%s already exists; move it away first so it isn't overwritten", backedUp)
+		}
+		err = os.Rename(original, backedUp)
+	case BackupTrash:
+		trashed, err = moveToTrash(original)
+	}
+	if err != nil {
+		undo()
+		return "", fmt.Errorf("failed to back up the original: %v", err)
+	}
+
+	if err := renameIntoPlace(partial, replaced); err != nil {
+		undo()
+		switch backup {
+		case BackupOrig:
+			os.Rename(backedUp, original)
+		case BackupTrash:
+			if restoreErr := restoreFromTrash(original, trashed); restoreErr != nil {
+				return "", fmt.Errorf("failed to put the clean file in place of the original: %v; the original is in the trash and couldn't be put back: %v", err, restoreErr)
+			}
+		}
+		return "", fmt.Errorf("failed to put the clean file in place of the original: %v", err)
+	}
+	if replaced != original && backup == BackupNone {
+		if err := os.Remove(original); err != nil {
+			return replaced, fmt.Errorf("the clean file is in place as %s, but the original couldn't be deleted: %v", replaced, err)
+		}
+	}
+	return replaced, nil
+}
+
+// renameIntoPlace is the last step of ReplaceOriginal, a variable so tests can make it fail
+var renameIntoPlace = os.Rename
+
+// Note says where the original went, for a message after ReplaceOriginal
+func (b Backup) Note(original string) string {
+	switch b {
+	case BackupOrig:
+		return "; the original is kept as " + filepath.Base(original) + OrigExt
+	case BackupTrash:
+		return "; the original is in the trash"
+	}
+	return ""
+}
+
+// moveFile renames from to to, copying it instead when they're on different drives
+func moveFile(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	if err := copyFile(from, to); err != nil {
+		os.Remove(to)
+		return err
+	}
+	return os.Remove(from)
+}
+
+// copyFile copies from to to, making sure it is written to disk before returning
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,74 @@
+//go:build unix
+
+package swearkiller
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReplaceOriginalRollsBack checks that when the clean file can't be renamed into
+// place, the original is back where it was, whatever the backup, and the clean file too
+func TestReplaceOriginalRollsBack(t *testing.T) {
+	defer func(rename func(string, string) error) { renameIntoPlace = rename }(renameIntoPlace)
+	renameIntoPlace = func(from, to string) error { return errors.New("disk full") }
+
+	for _, backup := range Backups {
+		dir := t.TempDir()
+		t.Setenv("HOME", filepath.Join(dir, "home"))
+		t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "home", "data"))
+		original, clean := filepath.Join(dir, "movie.mkv"), filepath.Join(dir, "movie-CLEAN.mkv")
+		os.WriteFile(original, []byte("original"), 0644)
+		os.WriteFile(clean, []byte("clean"), 0644)
+
+		if _, err := ReplaceOriginal(original, clean, backup); err == nil {
+			t.Errorf("%s: no error when the clean file couldn't be put in place", backup)
+		}
+		for path, want := range map[string]string{original: "original", clean: "clean"} {
+			if data, err := os.ReadFile(path); err != nil || string(data) != want {
+				t.Errorf("%s: %s holds %q, %v; want %q", backup, filepath.Base(path), data, err, want)
+			}
+		}
+		for _, left := range []string{original + OrigExt, partialPath(original)} {
+			if _, err := os.Stat(left); err == nil {
+				t.Errorf("%s: %s was left behind", backup, filepath.Base(left))
+			}
+		}
+		if backup == BackupTrash {
+			infos, _ := os.ReadDir(filepath.Join(dir, "home", "data", "Trash", "info"))
+			files, _ := os.ReadDir(filepath.Join(dir, "home", "data", "Trash", "files"))
+			if len(infos) != 0 || len(files) != 0 {
+				t.Errorf("trash: %d info and %d file(s) left in the trash", len(infos), len(files))
+			}
+		}
+	}
+}
+
+// TestReplaceOriginalTrash checks that a successful replacement leaves the original in the
+// trash and the clean file in its place
+func TestReplaceOriginalTrash(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", filepath.Join(dir, "home"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "home", "data"))
+	original, clean := filepath.Join(dir, "movie.mkv"), filepath.Join(dir, "movie-CLEAN.mkv")
+	os.WriteFile(original, []byte("original"), 0644)
+	os.WriteFile(clean, []byte("clean"), 0644)
+
+	replaced, err := ReplaceOriginal(original, clean, BackupTrash)
+	if err != nil || replaced != original {
+		t.Fatalf("got %s, %v", replaced, err)
+	}
+	if data, _ := os.ReadFile(original); string(data) != "clean" {
+		t.Errorf("the original's path holds %q", data)
+	}
+	files, _ := os.ReadDir(filepath.Join(dir, "home", "data", "Trash", "files"))
+	if len(files) == 0 {
+		// macOS keeps the trash in ~/.Trash
+		files, _ = os.ReadDir(filepath.Join(dir, "home", ".Trash"))
+	}
+	if len(files) != 1 {
+		t.Fatalf("%d file(s) in the trash, want 1", len(files))
+	}
+}
@@ -0,0 +1,140 @@
+//go:build unix
+
+package swearkiller
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// keepOwner gives path the owner and group in info. Only root may give files away, so
+// others keep the file as their own, with the original's group if they belong to it.
+func keepOwner(path string, info os.FileInfo) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	if os.Chown(path, int(stat.Uid), int(stat.Gid)) != nil {
+		os.Chown(path, -1, int(stat.Gid))
+	}
+}
+
+// moveToTrash moves path to the trash of the drive it is on: the home trash when it is on
+// the home folder's drive, otherwise the drive's own (.Trashes/<uid> on macOS, .Trash-<uid>
+// elsewhere), as file managers do, and returns where it went
+func moveToTrash(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	uid := strconv.Itoa(os.Getuid())
+	if runtime.GOOS == "darwin" {
+		trash := filepath.Join(home, ".Trash")
+		if !sameDrive(path, home) {
+			trash = filepath.Join(driveRoot(path), ".Trashes", uid)
+		}
+		if err := os.MkdirAll(trash, 0700); err != nil {
+			return "", err
+		}
+		trashed := freeName(trash, filepath.Base(path), "")
+		return trashed, os.Rename(path, trashed)
+	}
+
+	// The freedesktop.org trash, with an info file saying where each file came from
+	trash := filepath.Join(home, ".local", "share", "Trash")
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		trash = filepath.Join(dataHome, "Trash")
+	}
+	if !sameDrive(path, filepath.Dir(trash)) {
+		trash = filepath.Join(driveRoot(path), ".Trash-"+uid)
+	}
+	files, infos := filepath.Join(trash, "files"), filepath.Join(trash, "info")
+	for _, dir := range []string{files, infos} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", fmt.Errorf("failed to create the trash: %v", err)
+		}
+	}
+	name := filepath.Base(freeName(files, filepath.Base(path), ".trashinfo"))
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: path}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+	if err := os.WriteFile(filepath.Join(infos, name+".trashinfo"), []byte(info), 0600); err != nil {
+		return "", fmt.Errorf("failed to write the trash info: %v", err)
+	}
+	if err := os.Rename(path, filepath.Join(files, name)); err != nil {
+		os.Remove(filepath.Join(infos, name+".trashinfo"))
+		return "", err
+	}
+	return filepath.Join(files, name), nil
+}
+
+// restoreFromTrash moves a file moveToTrash put at trashed back to path, along with its
+// trash info file if it has one
+func restoreFromTrash(path, trashed string) error {
+	if err := os.Rename(trashed, path); err != nil {
+		return err
+	}
+	os.Remove(filepath.Join(filepath.Dir(filepath.Dir(trashed)), "info", filepath.Base(trashed)+".trashinfo"))
+	return nil
+}
+
+// freeName returns a path in dir for name, numbered like "name 2" if it is taken. With
+// infoExt, the name must also be free in the info folder beside dir.
+func freeName(dir, name, infoExt string) string {
+	ext := filepath.Ext(name)
+	stem := name[:len(name)-len(ext)]
+	candidate := name
+	for n := 2; ; n++ {
+		_, errFile := os.Lstat(filepath.Join(dir, candidate))
+		_, errInfo := os.Lstat(filepath.Join(filepath.Dir(dir), "info", candidate+infoExt))
+		if errFile != nil && (infoExt == "" || errInfo != nil) {
+			return filepath.Join(dir, candidate)
+		}
+		candidate = fmt.Sprintf("%s %d%s", stem, n, ext)
+	}
+}
+
+// sameDrive reports whether a and b are on the same drive, so one can be renamed into the
+// other
+func sameDrive(a, b string) bool {
+	return deviceOf(a) == deviceOf(b) && deviceOf(a) != 0
+}
+
+// driveRoot returns the top folder of the drive path is on
+func driveRoot(path string) string {
+	device := deviceOf(path)
+	dir := filepath.Dir(path)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir || deviceOf(parent) != device {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// deviceOf returns the device path is on, or the device of its nearest existing folder if
+// it doesn't exist yet, or 0 if neither can be read
+func deviceOf(path string) uint64 {
+	info, err := os.Stat(path)
+	for err != nil && filepath.Dir(path) != path {
+		path = filepath.Dir(path)
+		info, err = os.Stat(path)
+	}
+	if err != nil {
+		return 0
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Dev)
+	}
+	return 0
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package swearkiller
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// keepOwner does nothing on Windows, where the clean file takes its permissions from the
+// folder it is in, as the original did
+func keepOwner(path string, info os.FileInfo) {}
+
+// moveToTrash sends path to the recycle bin through PowerShell, which reaches the shell's
+// own recycling; the path goes in an environment variable so it is never quoted. The
+// recycle bin doesn't say where it put the file, so "" is returned for where it went.
+func moveToTrash(path string) (string, error) {
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+		"Add-Type -AssemblyName Microsoft.VisualBasic; "+
+			"[Microsoft.VisualBasic.FileIO.FileSystem]::DeleteFile($env:SK_TRASH, 'OnlyErrorDialogs', 'SendToRecycleBin')")
+	cmd.Env = append(os.Environ(), "SK_TRASH="+path)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to move it to the recycle bin: %v %s", err, strings.TrimSpace(string(output)))
+	}
+	return "", nil
+}
+
+// restoreFromTrash can't take a file back out of the recycle bin, as moveToTrash doesn't
+// know where it went, so it says how to do it by hand
+func restoreFromTrash(path, trashed string) error {
+	return fmt.Errorf("restore %s from the recycle bin", path)
+}
@@ -0,0 +1,183 @@
+package swearkiller
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ReviewDecision is what the reviewer decided about a match
+type ReviewDecision int
+
+const (
+	ReviewUndecided ReviewDecision = iota // Not looked at yet; left unmuted
+	ReviewAccepted                        // Mute it
+	ReviewRejected                        // Leave it unmuted
+)
+
+func (d ReviewDecision) String() string {
+	switch d {
+	case ReviewAccepted:
+		return "mute"
+	case ReviewRejected:
+		return "keep"
+	}
+	return "undecided"
+}
+
+// MarshalText writes the decision as "mute", "keep" or "undecided"
+func (d ReviewDecision) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText reads a decision written by MarshalText
+func (d *ReviewDecision) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "mute":
+		*d = ReviewAccepted
+	case "keep":
+		*d = ReviewRejected
+	case "undecided", "":
+		*d = ReviewUndecided
+	default:
+		return fmt.Errorf("unknown review decision %q (use mute, keep or undecided)", text)
+	}
+	return nil
+}
+
+// ReviewStep is how far one press of an adjust key moves a segment's start or end, in seconds
+const ReviewStep = 0.1
+
+// SnippetPadding is how much audio plays on either side of a segment while it is reviewed,
+// so the reviewer hears the line in context
+const SnippetPadding = 1.0
+
+// ReviewItem is one match in a rapid review, with its mute segment as adjusted so far
+type ReviewItem struct {
+	Match    Match
+	Segment  Segment
+	Decision ReviewDecision
+}
+
+// NewReviewItems turns matches into review items in timeline order. Matches at least
+// minConfidence confident start out accepted, like they would be without a review; the
+// rest start undecided. Matches the offset would move before the start are left out.
+func NewReviewItems(matches []Match, offset, minConfidence float64) []ReviewItem {
+	var items []ReviewItem
+	for _, m := range matches {
+		segments := MatchSegments([]Match{m}, offset, nil)
+		if len(segments) == 0 {
+			continue
+		}
+		decision := ReviewUndecided
+		if m.Confidence >= minConfidence {
+			decision = ReviewAccepted
+		}
+		items = append(items, ReviewItem{Match: m, Segment: segments[0], Decision: decision})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Segment.Start < items[j].Segment.Start })
+	return items
+}
+
+// Adjust moves the segment's start and end by the given seconds, keeping it at least
+// ReviewStep long and not before the start of the video
+func (item *ReviewItem) Adjust(startDelta, endDelta float64) {
+	start := max(item.Segment.Start+startDelta, 0)
+	end := item.Segment.End + endDelta
+	if end-start < ReviewStep {
+		if startDelta != 0 {
+			start = end - ReviewStep
+		} else {
+			end = start + ReviewStep
+		}
+	}
+	item.Segment.Start, item.Segment.End = max(start, 0), end
+}
+
+// String describes the item for the review window and logs
+func (item ReviewItem) String() string {
+	return fmt.Sprintf("[%s-%s] %s: %s", FormatTimestamp(item.Segment.Start), FormatTimestamp(item.Segment.End), item.Decision, item.Match.Cue.Text)
+}
+
+// MarkedMatch is a match for a cue the user chose to mute though no swear was found in it
+func MarkedMatch(cue Cue) Match {
+	return Match{Cue: cue, Hits: []Hit{{Type: MatchMarked, Confidence: 1}}, Confidence: 1}
+}
+
+// SearchCues returns the cues whose text contains query, ignoring case, accents and
+// fullwidth forms as swear matching does. An empty query returns every cue.
+func SearchCues(cues []Cue, query string) []Cue {
+	query = strings.TrimSpace(NormalizeText(query))
+	if query == "" {
+		return cues
+	}
+	var found []Cue
+	for _, cue := range cues {
+		if strings.Contains(NormalizeText(cue.Text), query) {
+			found = append(found, cue)
+		}
+	}
+	return found
+}
+
+// CueWords returns the words of a cue's text, lowercased, once each and in the order they
+// first appear, for picking one to add to the swear list
+func CueWords(cue Cue) []string {
+	var words []string
+	fields := strings.FieldsFunc(strings.ToLower(cue.Text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	for _, word := range fields {
+		if word = strings.Trim(word, "'"); word != "" && !slices.Contains(words, word) {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// ReviewedSegments returns the segments of the accepted items
+func ReviewedSegments(items []ReviewItem) []Segment {
+	var segments []Segment
+	for _, item := range items {
+		if item.Decision == ReviewAccepted {
+			segments = append(segments, item.Segment)
+		}
+	}
+	return segments
+}
+
+// ReviewCounts returns how many items are accepted, rejected and undecided
+func ReviewCounts(items []ReviewItem) (accepted, rejected, undecided int) {
+	for _, item := range items {
+		switch item.Decision {
+		case ReviewAccepted:
+			accepted++
+		case ReviewRejected:
+			rejected++
+		default:
+			undecided++
+		}
+	}
+	return accepted, rejected, undecided
+}
+
+// SnippetArgs returns ffplay arguments that play a segment's audio, with padding either
+// side, and exit when it ends
+func SnippetArgs(video string, seg Segment, padding float64) []string {
+	start := max(seg.Start-padding, 0)
+	return []string{
+		"-nodisp", "-autoexit", "-hide_banner", "-loglevel", "error",
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-t", fmt.Sprintf("%.3f", seg.End+padding-start),
+		video,
+	}
+}
+
+// SegmentPreviewArgs returns ffplay arguments that play a segment with its picture, padded
+// either side, in a window titled with its start time, and exit when it ends
+func SegmentPreviewArgs(video string, seg Segment, padding float64) []string {
+	args := SnippetArgs(video, seg, padding)[1:] // Everything but -nodisp
+	return append([]string{"-window_title", "Swear Killer - " + FormatTimestamp(seg.Start)}, args...)
+}
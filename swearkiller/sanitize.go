@@ -0,0 +1,46 @@
+package swearkiller
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	htmlTagRe     = regexp.MustCompile(`</?[a-zA-Z][^>]*>`) // <i>, </b>, <font color="#fff">
+	assOverrideRe = regexp.MustCompile(`\{\\[^}]*\}`)       // {\an8}, {\i1}
+	speakerDashRe = regexp.MustCompile(`^[-‐–—]+\s*`)       // "- Hello" at the start of a line
+)
+
+// CleanLine strips formatting markup from one subtitle line: HTML-style tags, ASS override
+// codes, HTML entities and a leading speaker dash. Whitespace is collapsed. Plain lines,
+// which are most of them, are returned without running the patterns.
+func CleanLine(line string) string {
+	if strings.IndexByte(line, '<') >= 0 {
+		line = htmlTagRe.ReplaceAllString(line, "")
+	}
+	if strings.IndexByte(line, '{') >= 0 {
+		line = assOverrideRe.ReplaceAllString(line, "")
+	}
+	if strings.IndexByte(line, '&') >= 0 {
+		line = html.UnescapeString(line)
+	}
+	line = collapseSpaces(line)
+	if first, _ := utf8.DecodeRuneInString(line); strings.ContainsRune("-‐–—", first) {
+		line = speakerDashRe.ReplaceAllString(line, "")
+	}
+	return line
+}
+
+// collapseSpaces trims s and turns each run of whitespace into a single space, returning s
+// itself when it's already like that
+func collapseSpaces(s string) string {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= utf8.RuneSelf || (c <= ' ' && (c != ' ' || i == 0 || i == len(s)-1 || s[i+1] == ' ')) {
+			return strings.Join(strings.Fields(s), " ")
+		}
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+package swearkiller
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// SegmentEdge is the start or end of a segment, as dragged in the GUI's segment editor
+type SegmentEdge int
+
+const (
+	EdgeStart SegmentEdge = iota
+	EdgeEnd
+)
+
+// ParseSegment reads a segment typed in by hand, with start and end as HH:MM:SS,mmm,
+// MM:SS or seconds
+func ParseSegment(start, end string, action Action) (Segment, error) {
+	from, err := ParseTimestamp(start)
+	if err != nil {
+		return Segment{}, fmt.Errorf("%v (start)", err)
+	}
+	to, err := ParseTimestamp(end)
+	if err != nil {
+		return Segment{}, fmt.Errorf("%v (end)", err)
+	}
+	if to-from < ReviewStep {
+		return Segment{}, fmt.Errorf("the end (%s) must be at least %gs after the start (%s)", FormatSRTTime(to), ReviewStep, FormatSRTTime(from))
+	}
+	return Segment{Start: from, End: to, Action: action}, nil
+}
+
+// MoveEdge returns seg with one edge moved to t seconds, kept at least ReviewStep from the
+// other edge and not before the start of the video
+func MoveEdge(seg Segment, edge SegmentEdge, t float64) Segment {
+	if edge == EdgeStart {
+		seg.Start = max(min(t, seg.End-ReviewStep), 0)
+	} else {
+		seg.End = max(t, seg.Start+ReviewStep)
+	}
+	return seg
+}
+
+// EdgeAt returns the segment and edge nearest t seconds, or -1 if none is within tolerance
+// seconds
+func EdgeAt(segments []Segment, t, tolerance float64) (int, SegmentEdge) {
+	found, edge, best := -1, EdgeStart, tolerance
+	for i, seg := range segments {
+		if distance := math.Abs(t - seg.Start); distance < best || (found < 0 && distance <= best) {
+			found, edge, best = i, EdgeStart, distance
+		}
+		if distance := math.Abs(t - seg.End); distance < best || (found < 0 && distance <= best) {
+			found, edge, best = i, EdgeEnd, distance
+		}
+	}
+	return found, edge
+}
+
+// SortSegments puts segments edited by hand in time order without merging them, so gaps
+// left on purpose stay
+func SortSegments(segments []Segment) {
+	slices.SortStableFunc(segments, func(a, b Segment) int { return cmp.Compare(a.Start, b.Start) })
+}
@@ -0,0 +1,634 @@
+package swearkiller
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server job statuses
+const (
+	ServerJobQueued  = "queued"
+	ServerJobRunning = "running"
+	ServerJobDone    = "done"
+	ServerJobFailed  = "failed"
+)
+
+// ServerJob is a job's status as reported by the HTTP API
+type ServerJob struct {
+	ID       string        `json:"id"`
+	Request  JobRequest    `json:"request"`
+	Status   string        `json:"status"`
+	Progress float64       `json:"progress"`          // 0 to 1 while encoding
+	ETA      float64       `json:"eta,omitempty"`     // Seconds the encode should still take
+	Segments int           `json:"segments"`          // Muted segments in the output
+	Skipped  int           `json:"skipped,omitempty"` // Ill-formed subtitle blocks left out
+	Review   []string      `json:"review,omitempty"`
+	Matches  []ServerMatch `json:"matches,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Log      []string      `json:"log"`
+	Created  time.Time     `json:"created"`
+
+	ReviewStatus ReviewStatus `json:"review_status,omitempty"`
+	Assignee     string       `json:"assignee,omitempty"` // Reviewer the held-back matches are assigned to
+
+	Replaces string `json:"replaces,omitempty"` // File the clean output is moved over once done (Radarr and Sonarr imports)
+}
+
+// ServerMatch is a matched subtitle line as reported by the HTTP API
+type ServerMatch struct {
+	Start      float64        `json:"start"` // Subtitle time in seconds, before the job's offset
+	End        float64        `json:"end"`
+	Text       string         `json:"text"`
+	Words      []string       `json:"words"`
+	Confidence float64        `json:"confidence"`
+	Muted      bool           `json:"muted"`              // False for matches held back for review
+	Decision   ReviewDecision `json:"decision,omitempty"` // What a reviewer decided about a held-back match
+}
+
+// Preview is the result of matching a subtitle without encoding anything
+type Preview struct {
+	Languages []string      `json:"languages"`
+	Matches   []ServerMatch `json:"matches"`
+	Skipped   []string      `json:"skipped,omitempty"` // Ill-formed subtitle blocks left out, and why
+}
+
+// webUI is the browser front-end served at /
+//
+//go:embed web/index.html
+var webUI []byte
+
+// ServerOptions configures the HTTP API server
+type ServerOptions struct {
+	WorkDir   string   // Uploads and default outputs are kept here, one directory per job
+	Swears    []string // Swear list for jobs that don't bring their own
+	Workers   int      // Jobs encoded at the same time (at least 1)
+	MaxQueue  int      // Jobs that may wait before submissions are refused (0 = 100)
+	Logger    *Logger  // Also gets every job's log, tagged with the job ID (optional)
+	Reviewers []string // Names reviews can be assigned to (empty = any name)
+
+	Notify  NotifyConfig   // Where to send a notification as each job finishes (optional)
+	Hooks   Hooks          // Commands run before and after each job (optional)
+	Refresh LibraryRefresh // Media servers told about each clean video (optional)
+	Arr     ArrOptions     // How movies and episodes imported by Radarr and Sonarr are cleaned
+
+	Detectors DetectorSettings // Run for every job besides, or instead of, the swear list (optional)
+
+	// Roots are the library folders jobs may read videos and subtitles from and write
+	// outputs to, besides WorkDir. A job naming a file anywhere else is refused, so a
+	// client can't overwrite or read back files the server can reach (see Server.checkPaths).
+	// The folders of Arr.PathMap count as roots.
+	Roots []string
+}
+
+// Server runs cleaning jobs submitted over HTTP, for NAS and home-server setups
+type Server struct {
+	opts   ServerOptions
+	mu     sync.Mutex
+	jobs   map[string]*ServerJob
+	order  []string
+	nextID int
+	queue  chan *ServerJob
+
+	uploading map[string]bool // Resumable uploads receiving a chunk right now
+	roots     []string        // WorkDir and Roots as absolute paths with symlinks resolved
+}
+
+// NewServer creates a server and starts its workers
+func NewServer(opts ServerOptions) (*Server, error) {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.MaxQueue <= 0 {
+		opts.MaxQueue = 100
+	}
+	if len(opts.Swears) == 0 {
+		opts.Swears = DefaultSwears
+	}
+	if err := opts.Arr.validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(opts.WorkDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create work directory: %v", err)
+	}
+
+	s := &Server{opts: opts, jobs: map[string]*ServerJob{}, queue: make(chan *ServerJob, opts.MaxQueue), uploading: map[string]bool{}}
+	roots := append([]string{opts.WorkDir}, opts.Roots...)
+	for _, mapping := range opts.Arr.PathMap {
+		_, to, _ := strings.Cut(mapping, "=")
+		roots = append(roots, filepath.FromSlash(to))
+	}
+	for _, root := range roots {
+		resolved, err := realPath(root)
+		if err != nil {
+			return nil, fmt.Errorf("invalid root %s: %v", root, err)
+		}
+		s.roots = append(s.roots, resolved)
+	}
+	if err := os.MkdirAll(s.uploadsDir(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create uploads directory: %v", err)
+	}
+	s.cleanStaleUploads()
+	for i := 0; i < opts.Workers; i++ {
+		go func() {
+			for job := range s.queue {
+				s.run(job)
+			}
+		}()
+	}
+	return s, nil
+}
+
+// Handler returns the web UI and the HTTP API:
+//
+//	GET  /                     the web UI
+//	POST /api/preview          match a subtitle without encoding (same body as a job; only
+//	                           the subtitle is needed)
+//	POST /api/jobs             submit a job (JSON JobRequest, or multipart with "video" and
+//	                           "subtitle" files and an "options" JobRequest field)
+//	OPTIONS /api/uploads       resumable uploads, following the tus 1.0 protocol: create
+//	POST   /api/uploads        one, send it in chunks with PATCH, check how much arrived
+//	HEAD   /api/uploads/{id}   with HEAD after a disconnect and resume from there, then
+//	GET    /api/uploads/{id}   submit a job with video_upload/subtitle_upload set to its ID
+//	PATCH  /api/uploads/{id}
+//	DELETE /api/uploads/{id}
+//	GET  /api/jobs             list all jobs (filter with ?assignee=name and ?review=status)
+//	GET  /api/jobs/{id}        a job's status, progress and log
+//	GET  /api/jobs/{id}/output download the clean video once the job is done
+//	POST /api/jobs/{id}/review assign the job's review and record decisions (ReviewUpdate)
+//	GET  /api/reviewers        the names reviews can be assigned to
+//	GET  /api/capabilities     what this machine supports
+//	POST /api/arr              Radarr and Sonarr webhook: clean each imported movie or
+//	                           episode with the subtitle beside it (see ArrOptions)
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(webUI)
+	})
+	mux.HandleFunc("POST /api/preview", s.handlePreview)
+	mux.HandleFunc("POST /api/jobs", s.handleSubmit)
+	mux.HandleFunc("GET /api/jobs", s.handleList)
+	mux.HandleFunc("GET /api/jobs/{id}", s.handleStatus)
+	mux.HandleFunc("GET /api/jobs/{id}/output", s.handleOutput)
+	mux.HandleFunc("POST /api/jobs/{id}/review", s.handleReview)
+	mux.HandleFunc("GET /api/reviewers", s.handleReviewers)
+	mux.HandleFunc("OPTIONS /api/uploads", s.handleUploadOptions)
+	mux.HandleFunc("POST /api/uploads", s.handleCreateUpload)
+	mux.HandleFunc("HEAD /api/uploads/{id}", s.handleUploadOffset)
+	mux.HandleFunc("GET /api/uploads/{id}", s.handleUploadStatus)
+	mux.HandleFunc("PATCH /api/uploads/{id}", s.handleUploadChunk)
+	mux.HandleFunc("DELETE /api/uploads/{id}", s.handleDeleteUpload)
+	mux.HandleFunc("GET /api/capabilities", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, DetectCapabilities())
+	})
+	mux.HandleFunc("POST /api/arr", s.handleArr)
+	return mux
+}
+
+// writeJSON sends v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeError sends an error as a JSON response
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+// handleSubmit queues a new job
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	id := s.newJobID()
+	jobDir := filepath.Join(s.opts.WorkDir, id)
+
+	req, err := decodeJobRequest(r, jobDir)
+	if err != nil {
+		os.RemoveAll(jobDir)
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	release, err := s.claimUploads(&req, jobDir)
+	if err != nil {
+		os.RemoveAll(jobDir)
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if err := s.checkPaths(req); err != nil {
+		release()
+		os.RemoveAll(jobDir)
+		writeError(w, http.StatusForbidden, "%v", err)
+		return
+	}
+	if err := validateJobRequest(&req); err != nil {
+		release()
+		os.RemoveAll(jobDir)
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if req.Output == "" {
+		if err := os.MkdirAll(jobDir, 0755); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to create job directory: %v", err)
+			return
+		}
+		req.Output = filepath.Join(jobDir, CleanOutputName(req.Video))
+	}
+
+	job := &ServerJob{ID: id, Request: req, Status: ServerJobQueued, Log: []string{}, Created: time.Now()}
+	if !s.enqueue(job) {
+		release()
+		os.RemoveAll(jobDir)
+		writeError(w, http.StatusServiceUnavailable, "the job queue is full; try again later")
+		return
+	}
+	s.forgetUploads(req)
+	s.mu.Lock()
+	snapshot := *job
+	s.mu.Unlock()
+
+	w.Header().Set("Location", "/api/jobs/"+id)
+	writeJSON(w, http.StatusAccepted, snapshot)
+}
+
+// checkPaths refuses a job whose video, subtitles or output are outside the server's work
+// directory and roots. Symlinks are followed first, so a link can't lead out of them.
+func (s *Server) checkPaths(req JobRequest) error {
+	for _, path := range append([]string{req.Video, req.Output}, req.subtitles()...) {
+		if path == "" {
+			continue
+		}
+		resolved, err := realPath(path)
+		if err != nil {
+			return fmt.Errorf("invalid path %s: %v", path, err)
+		}
+		if !slices.ContainsFunc(s.roots, func(root string) bool { return withinDir(root, resolved) }) {
+			return fmt.Errorf("%s is outside the server's folders; add its library folder to the server with --root", path)
+		}
+	}
+	return nil
+}
+
+// realPath returns path made absolute with its symlinks resolved. A file that doesn't
+// exist yet, like an output, is resolved through its folder.
+func realPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+	if err != nil {
+		return abs, nil // Nothing there to lead elsewhere; the job fails on it later
+	}
+	return filepath.Join(dir, filepath.Base(abs)), nil
+}
+
+// withinDir reports whether path is dir or inside it
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// newJobID returns the next job's ID
+func (s *Server) newJobID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	return strconv.Itoa(s.nextID)
+}
+
+// enqueue adds a job to the list and the queue, reporting false if the queue is full
+func (s *Server) enqueue(job *ServerJob) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case s.queue <- job:
+	default:
+		return false
+	}
+	s.jobs[job.ID] = job
+	s.order = append(s.order, job.ID)
+	return true
+}
+
+// decodeJobRequest reads a job from a JSON body, or from a multipart upload whose files are
+// saved in dir
+func decodeJobRequest(r *http.Request, dir string) (JobRequest, error) {
+	var req JobRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := receiveUploads(r, dir, &req); err != nil {
+			return req, fmt.Errorf("invalid upload: %v", err)
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, fmt.Errorf("invalid job: %v", err)
+	}
+	return req, nil
+}
+
+// handlePreview matches a subtitle against the job's options and returns every match, so
+// they can be reviewed before a job is started
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	dir, err := os.MkdirTemp(s.opts.WorkDir, "preview-")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create upload directory: %v", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	req, err := decodeJobRequest(r, dir)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if req.Subtitle == "" {
+		writeError(w, http.StatusBadRequest, "a subtitle is required")
+		return
+	}
+	if err := s.checkPaths(JobRequest{Subtitle: req.Subtitle, ExtraSubtitles: req.ExtraSubtitles}); err != nil {
+		writeError(w, http.StatusForbidden, "%v", err)
+		return
+	}
+	for _, path := range req.subtitles() {
+		if _, err := os.Stat(path); err != nil {
+			writeError(w, http.StatusBadRequest, "file not found: %s", path)
+			return
+		}
+	}
+	if req.MinConfidence != nil && (*req.MinConfidence < 0 || *req.MinConfidence > 1) {
+		writeError(w, http.StatusBadRequest, "min_confidence must be between 0 and 1")
+		return
+	}
+
+	if _, err := ParseFrameRateRatio(req.FrameRateRatio); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	cues, report, err := ReadSubtitleTracks(req.subtitles())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if cues, err = correctJobTiming(req, cues); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	languages, matches, review, err := MatchJob(req, cues, s.opts.Swears)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, Preview{Languages: append([]string{}, languages...), Matches: serverMatches(matches, review), Skipped: report.Problems})
+}
+
+// serverMatches lists muted and held-back matches together in subtitle order
+func serverMatches(matches, review []Match) []ServerMatch {
+	result := []ServerMatch{}
+	for _, group := range []struct {
+		matches []Match
+		muted   bool
+	}{{matches, true}, {review, false}} {
+		for _, m := range group.matches {
+			result = append(result, ServerMatch{Start: m.Cue.Start, End: m.Cue.End, Text: m.Cue.Text,
+				Words: m.Words, Confidence: m.Confidence, Muted: group.muted})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Start < result[j].Start })
+	return result
+}
+
+// receiveUploads streams the uploaded video and subtitle into the job's directory and
+// reads the job options, without holding whole videos in memory
+func receiveUploads(r *http.Request, jobDir string, req *JobRequest) error {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(jobDir, 0755); err != nil {
+		return err
+	}
+	var video, subtitle string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch part.FormName() {
+		case "options":
+			if err := json.NewDecoder(part).Decode(req); err != nil {
+				return fmt.Errorf("invalid options: %v", err)
+			}
+		case "video", "subtitle":
+			name, ok := uploadFileName(part.FileName())
+			if !ok {
+				return fmt.Errorf("the %s upload has no file name", part.FormName())
+			}
+			path := filepath.Join(jobDir, name)
+			if err := saveUpload(part, path); err != nil {
+				return err
+			}
+			if part.FormName() == "video" {
+				video = path
+			} else {
+				subtitle = path
+			}
+		}
+		part.Close()
+	}
+	// Uploaded files take the place of any paths in the options
+	if video != "" {
+		req.Video = video
+	}
+	if subtitle != "" {
+		req.Subtitle = subtitle
+	}
+	return nil
+}
+
+// saveUpload writes an uploaded file to disk
+func saveUpload(r io.Reader, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to save %s: %v", filepath.Base(path), err)
+	}
+	return file.Close()
+}
+
+// handleList returns every job, oldest first, optionally only those assigned to a reviewer
+// or with a review status
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	jobs := make([]ServerJob, 0, len(s.order))
+	for _, id := range s.order {
+		job := s.jobs[id]
+		if query.Has("assignee") && job.Assignee != query.Get("assignee") {
+			continue
+		}
+		if query.Has("review") && string(job.ReviewStatus) != query.Get("review") {
+			continue
+		}
+		jobs = append(jobs, *job)
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+// handleStatus returns one job
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job, ok := s.jobs[r.PathValue("id")]
+	var snapshot ServerJob
+	if ok {
+		snapshot = *job
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no job %s", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+// handleOutput sends a finished job's clean video
+func (s *Server) handleOutput(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job, ok := s.jobs[r.PathValue("id")]
+	var status, output string
+	if ok {
+		status, output = job.Status, job.Request.Output
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no job %s", r.PathValue("id"))
+		return
+	}
+	if status != ServerJobDone {
+		writeError(w, http.StatusConflict, "job %s is %s, not done", r.PathValue("id"), status)
+		return
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(output)}))
+	http.ServeFile(w, r, output)
+}
+
+// update changes a job while holding the lock
+func (s *Server) update(job *ServerJob, change func()) {
+	s.mu.Lock()
+	change()
+	s.mu.Unlock()
+}
+
+// run detects swears for a job and encodes the clean video
+func (s *Server) run(job *ServerJob) {
+	logFn := func(message string) {
+		s.update(job, func() { job.Log = append(job.Log, message) })
+		if s.opts.Logger != nil {
+			s.opts.Logger.Log(MessageLevel(message, LevelInfo), message+" (job "+job.ID+")")
+		}
+	}
+	s.update(job, func() { job.Status = ServerJobRunning })
+
+	req := job.Request
+	req.Hooks = s.opts.Hooks
+	req.Detectors = s.opts.Detectors
+	if job.Replaces == "" {
+		req.Refresh = s.opts.Refresh // Otherwise refreshed once the original is replaced
+	}
+	result, err := ProcessJob(context.Background(), req, s.opts.Swears, logFn, serverProgress{s, job})
+	var reviewLines []string
+	for _, m := range result.Review {
+		reviewLines = append(reviewLines, m.String())
+	}
+	if result.Output != "" {
+		req.Output = result.Output
+	}
+	s.update(job, func() {
+		job.Request.Output = req.Output
+		job.ETA = 0
+		job.Segments = len(result.Segments)
+		job.Skipped = result.Subtitle.Skipped
+		job.Review = reviewLines
+		previous := job.Matches
+		job.Matches = serverMatches(result.Matches, result.Review)
+		keepDecisions(job.Matches, previous)
+	})
+	if err != nil {
+		logFn("Error: " + err.Error())
+		s.update(job, func() {
+			job.Status = ServerJobFailed
+			job.Error = err.Error()
+		})
+		s.notify(JobNotification(req.Video, req.Output, 0, err), logFn)
+		return
+	}
+	if job.Replaces != "" {
+		if err := s.replaceOriginal(job, logFn); err != nil {
+			logFn("Error: " + err.Error())
+			s.update(job, func() {
+				job.Status = ServerJobFailed
+				job.Error = err.Error()
+			})
+			s.notify(JobNotification(req.Video, req.Output, 0, err), logFn)
+			return
+		}
+		req.Output = job.Replaces
+		s.opts.Refresh.refreshLogged(context.Background(), req.Output, logFn)
+	}
+	s.update(job, func() {
+		job.Progress = 1
+		job.Status = ServerJobDone
+		if job.ReviewStatus == ReviewNone && len(result.Review) > 0 {
+			job.ReviewStatus = ReviewPending
+		}
+	})
+	s.notify(JobNotification(req.Video, req.Output, len(result.Segments), nil), logFn)
+}
+
+// serverProgress reports a job's progress in its status
+type serverProgress struct {
+	s   *Server
+	job *ServerJob
+}
+
+func (p serverProgress) SegmentsFound(count int) {
+	p.s.update(p.job, func() { p.job.Segments = count })
+}
+
+func (p serverProgress) Encoding(fraction float64, eta time.Duration) {
+	p.s.update(p.job, func() { p.job.Progress, p.job.ETA = fraction, eta.Round(time.Second).Seconds() })
+}
+
+// notify sends n as the server's Notify option says, logging a failure to the job
+func (s *Server) notify(n Notification, logFn func(string)) {
+	if !s.opts.Notify.Enabled() {
+		return
+	}
+	if err := s.opts.Notify.Send(context.Background(), n); err != nil {
+		logFn("Warning: could not send the notification: " + err.Error())
+	}
+}
@@ -0,0 +1,65 @@
+package swearkiller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestServerCheckPaths checks that jobs can only name files in the work directory and the
+// library roots, however the path is spelled
+func TestServerCheckPaths(t *testing.T) {
+	base := t.TempDir()
+	library := filepath.Join(base, "library")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{library, outside} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(library, "link")); err != nil {
+		t.Skipf("can't make a symlink: %v", err)
+	}
+	s, err := NewServer(ServerOptions{WorkDir: filepath.Join(base, "jobs"), Roots: []string{library}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		req  JobRequest
+		ok   bool
+	}{
+		{"in the library", JobRequest{Video: filepath.Join(library, "movie.mkv"), Subtitle: filepath.Join(library, "movie.srt")}, true},
+		{"output in the work directory", JobRequest{Output: filepath.Join(base, "jobs", "1", "movie-CLEAN.mkv")}, true},
+		{"output outside", JobRequest{Video: filepath.Join(library, "movie.mkv"), Output: filepath.Join(outside, "movie.mkv")}, false},
+		{"subtitle outside", JobRequest{Subtitle: filepath.Join(outside, "passwords.srt")}, false},
+		{"dot dot", JobRequest{Video: filepath.Join(library, "..", "outside", "movie.mkv")}, false},
+		{"symlink out", JobRequest{Output: filepath.Join(library, "link", "movie.mkv")}, false},
+		{"the root's neighbour", JobRequest{Video: library + "-old" + string(filepath.Separator) + "movie.mkv"}, false},
+	}
+	for _, tt := range tests {
+		if err := s.checkPaths(tt.req); (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want allowed %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+// TestUploadFileName checks that client file names can't name the upload's folder
+func TestUploadFileName(t *testing.T) {
+	for name, want := range map[string]string{
+		"movie.mkv":        "movie.mkv",
+		"../../etc/passwd": "passwd",
+		"/media/movie.srt": "movie.srt",
+		"":                 "",
+		".":                "",
+		"..":               "",
+		"/":                "",
+		"subs/..":          "",
+	} {
+		got, ok := uploadFileName(name)
+		if got != want || ok != (want != "") {
+			t.Errorf("uploadFileName(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package swearkiller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DefaultServiceName is the name the watch service is installed under
+const DefaultServiceName = "swear-killer"
+
+// ServiceOptions says how to install the watch-folder service (see InstallService)
+type ServiceOptions struct {
+	Name       string // Service or unit name (default DefaultServiceName)
+	Executable string // Path of the swear-killer program (default: this one)
+	Config     string // The watch config (see LoadWatchConfig)
+	LogFile    string // Also log to this file; on Windows, where services have no console, it defaults to one beside the config
+
+	UserService bool   // systemd: install for the current user rather than system-wide
+	RunAs       string // systemd: the user a system-wide service runs as (default root)
+}
+
+// ServiceState is what QueryService found out about the installed service
+type ServiceState struct {
+	Installed bool
+	Enabled   bool   // Starts with the computer (at login for systemd user services)
+	Running   bool   // Started and not stopped
+	Status    string // The service manager's word for its state, like "active" or "stopped"
+	Location  string // The unit file, or on Windows the command line the service runs
+	Logs      string // Where its messages go
+}
+
+// prepare fills in the defaults and makes the paths absolute, since a service doesn't
+// start in the folder it was installed from. The config is loaded to check it, so a
+// broken one isn't installed.
+func (o *ServiceOptions) prepare() error {
+	if o.Name == "" {
+		o.Name = DefaultServiceName
+	}
+	if o.Executable == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to find this program: %v", err)
+		}
+		if o.Executable, err = filepath.EvalSymlinks(exe); err != nil {
+			return fmt.Errorf("failed to find this program: %v", err)
+		}
+	}
+	config, err := ResolveWatchConfig(o.Config)
+	if err != nil {
+		return err
+	}
+	if _, err := LoadWatchConfig(config); err != nil {
+		return err
+	}
+	o.Config = config
+	if runtime.GOOS == "windows" && o.LogFile == "" {
+		o.LogFile = filepath.Join(filepath.Dir(o.Config), "swear-killer-watch.log")
+	}
+	if o.LogFile != "" {
+		if o.LogFile, err = filepath.Abs(o.LogFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchArgs returns the arguments the service runs swear-killer with
+func (o ServiceOptions) watchArgs() []string {
+	args := []string{"watch", "--config", o.Config}
+	if o.LogFile != "" {
+		args = append(args, "--log-file", o.LogFile)
+	}
+	return args
+}
+
+// WatchConfigPaths lists where ResolveWatchConfig looks for a watch config that wasn't
+// given: watch.yaml, .yml, .toml or .json in the user's config folder (like
+// ~/.config/swear-killer), then in the system-wide one (/etc/swear-killer, or
+// %ProgramData%\swear-killer on Windows)
+func WatchConfigPaths() []string {
+	var dirs []string
+	if dir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(dir, "swear-killer"))
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			dirs = append(dirs, filepath.Join(dir, "swear-killer"))
+		}
+	} else {
+		dirs = append(dirs, "/etc/swear-killer")
+	}
+	var paths []string
+	for _, dir := range dirs {
+		for _, name := range []string{"watch.yaml", "watch.yml", "watch.toml", "watch.json"} {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	return paths
+}
+
+// ResolveWatchConfig returns the absolute path of the watch config: path if given,
+// otherwise the first of WatchConfigPaths that exists
+func ResolveWatchConfig(path string) (string, error) {
+	if path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return "", fmt.Errorf("watch config not found: %s", abs)
+		}
+		return abs, nil
+	}
+	for _, candidate := range WatchConfigPaths() {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no watch config given and none found in the usual places:\n  %s", strings.Join(WatchConfigPaths(), "\n  "))
+}
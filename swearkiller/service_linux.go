@@ -0,0 +1,171 @@
+//go:build linux
+
+package swearkiller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// InstallService installs the watch-folder service as a systemd unit, system-wide or for
+// the current user, then enables and starts it. Its messages go to the journal, and to
+// opts.LogFile too if set.
+func InstallService(opts ServiceOptions) error {
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+	path, err := systemdUnitPath(opts)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s is already installed (%s); uninstall it first", opts.Name, path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(systemdUnit(opts)), 0644); err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return fmt.Errorf("no permission to write %s; run with sudo, or install for yourself with --user", path)
+		}
+		return fmt.Errorf("failed to write the unit file: %v", err)
+	}
+	if _, err = systemctl(opts, "daemon-reload"); err == nil {
+		_, err = systemctl(opts, "enable", "--now", opts.Name+".service")
+	}
+	if err != nil {
+		os.Remove(path) // So installing again isn't refused
+		return err
+	}
+	return nil
+}
+
+// UninstallService stops and disables the service and removes its unit file
+func UninstallService(opts ServiceOptions) error {
+	if opts.Name == "" {
+		opts.Name = DefaultServiceName
+	}
+	path, err := systemdUnitPath(opts)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("%s isn't installed (no %s)", opts.Name, path)
+	}
+	if _, err := systemctl(opts, "disable", "--now", opts.Name+".service"); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return fmt.Errorf("no permission to remove %s; run with sudo", path)
+		}
+		return err
+	}
+	_, err = systemctl(opts, "daemon-reload")
+	return err
+}
+
+// QueryService reports whether the service is installed, enabled and running
+func QueryService(opts ServiceOptions) (ServiceState, error) {
+	if opts.Name == "" {
+		opts.Name = DefaultServiceName
+	}
+	path, err := systemdUnitPath(opts)
+	if err != nil {
+		return ServiceState{}, err
+	}
+	state := ServiceState{Location: path}
+	if _, err := os.Stat(path); err != nil {
+		return state, nil
+	}
+	state.Installed = true
+	// Both exit with an error for a unit that is disabled or stopped, printing the state anyway
+	enabled, _ := systemctl(opts, "is-enabled", opts.Name+".service")
+	active, _ := systemctl(opts, "is-active", opts.Name+".service")
+	state.Enabled = enabled == "enabled"
+	state.Status = active
+	state.Running = active == "active" || active == "reloading"
+	state.Logs = "journalctl -u " + opts.Name
+	if opts.UserService {
+		state.Logs = "journalctl --user -u " + opts.Name
+	}
+	return state, nil
+}
+
+// RunAsService does nothing on Linux, as systemd runs the service like any program and
+// stops it with SIGTERM
+func RunAsService(name string, run func(ctx context.Context) error) (bool, error) {
+	return false, nil
+}
+
+// systemdUnitPath returns where the service's unit file goes
+func systemdUnitPath(opts ServiceOptions) (string, error) {
+	if opts.UserService {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "systemd", "user", opts.Name+".service"), nil
+	}
+	return filepath.Join("/etc/systemd/system", opts.Name+".service"), nil
+}
+
+// systemdUnit returns the unit file that runs the watcher, restarting it if it fails
+func systemdUnit(opts ServiceOptions) string {
+	command := []string{systemdQuote(opts.Executable)}
+	for _, arg := range opts.watchArgs() {
+		command = append(command, systemdQuote(arg))
+	}
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Swear Killer watch folders\n")
+	b.WriteString("After=local-fs.target remote-fs.target\n\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=simple\n")
+	b.WriteString("ExecStart=" + strings.Join(command, " ") + "\n")
+	if opts.RunAs != "" && !opts.UserService {
+		b.WriteString("User=" + opts.RunAs + "\n")
+	}
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=10\n\n")
+	b.WriteString("[Install]\n")
+	if opts.UserService {
+		b.WriteString("WantedBy=default.target\n")
+	} else {
+		b.WriteString("WantedBy=multi-user.target\n")
+	}
+	return b.String()
+}
+
+// systemdQuote quotes an ExecStart argument if it needs it. Percent and dollar signs are
+// doubled in any case, as systemd expands them even in quotes.
+func systemdQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	arg = strings.ReplaceAll(arg, "$", "$$")
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;") {
+		return arg
+	}
+	arg = strings.ReplaceAll(arg, `\`, `\\`)
+	arg = strings.ReplaceAll(arg, `"`, `\"`)
+	return `"` + arg + `"`
+}
+
+// systemctl runs systemctl, for the user's manager if the service is a user one, and
+// returns its trimmed output
+func systemctl(opts ServiceOptions, args ...string) (string, error) {
+	if opts.UserService {
+		args = append([]string{"--user"}, args...)
+	}
+	output, err := exec.Command("systemctl", args...).CombinedOutput()
+	text := strings.TrimSpace(string(output))
+	if err != nil {
+		return text, fmt.Errorf("systemctl %s failed: %v: %s", strings.Join(args, " "), err, text)
+	}
+	return text, nil
+}
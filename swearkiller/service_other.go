@@ -0,0 +1,32 @@
+//go:build !linux && !windows
+
+package swearkiller
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// errNoServices is returned where there is neither systemd nor the Windows service manager
+var errNoServices = fmt.Errorf("installing a service isn't supported on %s; run 'swear-killer watch' from launchd or your init system instead", runtime.GOOS)
+
+// InstallService is only supported on Linux and Windows
+func InstallService(opts ServiceOptions) error {
+	return errNoServices
+}
+
+// UninstallService is only supported on Linux and Windows
+func UninstallService(opts ServiceOptions) error {
+	return errNoServices
+}
+
+// QueryService is only supported on Linux and Windows
+func QueryService(opts ServiceOptions) (ServiceState, error) {
+	return ServiceState{}, errNoServices
+}
+
+// RunAsService does nothing here, as services are stopped with a signal
+func RunAsService(name string, run func(ctx context.Context) error) (bool, error) {
+	return false, nil
+}
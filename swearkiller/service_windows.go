@@ -0,0 +1,212 @@
+//go:build windows
+
+package swearkiller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// serviceStopTimeout is how long UninstallService waits for the service to stop
+const serviceStopTimeout = 30 * time.Second
+
+// serviceStates names the states QueryService reports
+var serviceStates = map[svc.State]string{
+	svc.Stopped:         "stopped",
+	svc.StartPending:    "starting",
+	svc.StopPending:     "stopping",
+	svc.Running:         "running",
+	svc.ContinuePending: "resuming",
+	svc.PausePending:    "pausing",
+	svc.Paused:          "paused",
+}
+
+// InstallService registers the watch-folder service with the Windows service manager,
+// starting automatically with the computer and restarted if it fails, and starts it. It
+// runs as Local System, which can't reach mapped network drives; use UNC paths in the
+// config. Its messages go to opts.LogFile.
+func InstallService(opts ServiceOptions) error {
+	if err := opts.prepare(); err != nil {
+		return err
+	}
+	m, err := connectServices()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	if s, err := m.OpenService(opts.Name); err == nil {
+		s.Close()
+		return fmt.Errorf("%s is already installed; uninstall it first", opts.Name)
+	}
+	s, err := m.CreateService(opts.Name, opts.Executable, mgr.Config{
+		DisplayName: "Swear Killer watch folders",
+		Description: "Cleans videos dropped into the folders of " + opts.Config,
+		StartType:   mgr.StartAutomatic,
+	}, opts.watchArgs()...)
+	if err != nil {
+		return fmt.Errorf("failed to install the service: %v", err)
+	}
+	defer s.Close()
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: 10 * time.Second}}, 24*60*60); err != nil {
+		return fmt.Errorf("failed to set the service to restart after failures: %v", err)
+	}
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("installed, but the service failed to start: %v", err)
+	}
+	return nil
+}
+
+// UninstallService stops the service and removes it from the service manager
+func UninstallService(opts ServiceOptions) error {
+	if opts.Name == "" {
+		opts.Name = DefaultServiceName
+	}
+	m, err := connectServices()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(opts.Name)
+	if err != nil {
+		return fmt.Errorf("%s isn't installed", opts.Name)
+	}
+	defer s.Close()
+	if status, err := s.Query(); err == nil && status.State != svc.Stopped {
+		if _, err := s.Control(svc.Stop); err != nil {
+			return fmt.Errorf("failed to stop the service: %v", err)
+		}
+		for deadline := time.Now().Add(serviceStopTimeout); status.State != svc.Stopped; {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("the service didn't stop within %v", serviceStopTimeout)
+			}
+			time.Sleep(300 * time.Millisecond)
+			if status, err = s.Query(); err != nil {
+				return fmt.Errorf("failed to check the service stopped: %v", err)
+			}
+		}
+	}
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("failed to remove the service: %v", err)
+	}
+	return nil
+}
+
+// QueryService reports whether the service is installed, starts automatically and is
+// running
+func QueryService(opts ServiceOptions) (ServiceState, error) {
+	if opts.Name == "" {
+		opts.Name = DefaultServiceName
+	}
+	var state ServiceState
+	m, err := mgr.Connect()
+	if err != nil {
+		return state, fmt.Errorf("failed to connect to the service manager: %v", err)
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(opts.Name)
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to open the service: %v", err)
+	}
+	defer s.Close()
+	state.Installed = true
+	config, err := s.Config()
+	if err != nil {
+		return state, fmt.Errorf("failed to read the service's settings: %v", err)
+	}
+	status, err := s.Query()
+	if err != nil {
+		return state, fmt.Errorf("failed to query the service: %v", err)
+	}
+	state.Enabled = config.StartType == mgr.StartAutomatic
+	state.Running = status.State == svc.Running
+	state.Status = serviceStates[status.State]
+	state.Location = config.BinaryPathName
+	state.Logs = logFileArg(config.BinaryPathName)
+	return state, nil
+}
+
+// RunAsService runs run under the Windows service manager if it started this program,
+// until the service is stopped or the computer shuts down, and reports whether it did
+func RunAsService(name string, run func(ctx context.Context) error) (bool, error) {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return false, err
+	}
+	handler := &serviceHandler{run: run}
+	if err := svc.Run(name, handler); err != nil {
+		return true, err
+	}
+	return true, handler.err
+}
+
+// serviceHandler answers the service manager while run does the work
+type serviceHandler struct {
+	run func(ctx context.Context) error
+	err error
+}
+
+func (h *serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- h.run(ctx) }()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case h.err = <-done:
+			if h.err != nil {
+				return false, 1 // Counts as a failure, so the recovery actions restart it
+			}
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				status <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+				h.err = <-done
+				return false, 0
+			}
+		}
+	}
+}
+
+// connectServices connects to the service manager, which needs an administrator
+func connectServices() (*mgr.Mgr, error) {
+	m, err := mgr.Connect()
+	if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+		return nil, fmt.Errorf("installing a service needs an administrator; run this from an administrator prompt")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the service manager: %v", err)
+	}
+	return m, nil
+}
+
+// logFileArg returns the --log-file in a service's command line, or "" if it has none
+func logFileArg(commandLine string) string {
+	args, err := windows.DecomposeCommandLine(commandLine)
+	if err != nil {
+		return ""
+	}
+	for i, arg := range args {
+		if arg == "--log-file" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, ok := strings.CutPrefix(arg, "--log-file="); ok {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package swearkiller
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// PortableSettingsName is the settings file that, beside the executable, makes the GUI
+// portable: it keeps its settings there instead of in the user's folders, for running
+// from a USB stick
+const PortableSettingsName = "swear-killer-settings.json"
+
+// SettingsEnv overrides where the GUI's settings file is, like --settings
+const SettingsEnv = "SWEAR_KILLER_SETTINGS"
+
+// Where a settings file was found, from the first one looked at to the last
+const (
+	SettingsFromFlag     = "--settings"
+	SettingsFromEnv      = SettingsEnv
+	SettingsFromPortable = "portable"
+	SettingsFromLegacy   = "home folder"
+	SettingsFromConfig   = "config folder"
+)
+
+// SettingsLocation is where the GUI's settings file is and why
+type SettingsLocation struct {
+	Path   string
+	Source string // One of the SettingsFrom constants
+}
+
+// FindSettings picks the GUI's settings file: override if given (the --settings flag),
+// then SettingsEnv, then PortableSettingsName beside the executable if it exists, then
+// ~/.swear-killer-settings.json if it exists, as versions before the config folder used
+// it. Otherwise it is settings.json in the user's config folder: $XDG_CONFIG_HOME or
+// ~/.config on Linux, %AppData% on Windows and ~/Library/Application Support on macOS.
+func FindSettings(override string) SettingsLocation {
+	if override != "" {
+		return SettingsLocation{Path: absPath(override), Source: SettingsFromFlag}
+	}
+	if path := os.Getenv(SettingsEnv); path != "" {
+		return SettingsLocation{Path: absPath(path), Source: SettingsFromEnv}
+	}
+	if path := PortableSettingsPath(); path != "" {
+		if _, err := os.Stat(path); err == nil {
+			return SettingsLocation{Path: path, Source: SettingsFromPortable}
+		}
+	}
+	home, _ := os.UserHomeDir()
+	legacy := filepath.Join(home, ".swear-killer-settings.json")
+	if _, err := os.Stat(legacy); err == nil {
+		return SettingsLocation{Path: legacy, Source: SettingsFromLegacy}
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return SettingsLocation{Path: legacy, Source: SettingsFromLegacy}
+	}
+	return SettingsLocation{Path: filepath.Join(dir, "swear-killer", "settings.json"), Source: SettingsFromConfig}
+}
+
+// PortableSettingsPath returns where the portable settings file goes, beside the
+// executable, or "" if the executable can't be found
+func PortableSettingsPath() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return filepath.Join(filepath.Dir(exe), PortableSettingsName)
+}
+
+// absPath makes path absolute, leaving it as it is if that fails
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,173 @@
+package swearkiller
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Shell is a command-line shell that printed commands are quoted for
+type Shell string
+
+const (
+	ShellBash       Shell = "bash"       // Also sh, zsh and other POSIX shells
+	ShellPowerShell Shell = "powershell" // Windows PowerShell and PowerShell 7
+	ShellCmd        Shell = "cmd"        // The Windows command prompt
+	ShellBatch      Shell = "bat"        // Windows batch files, which treat % differently
+)
+
+// Shells lists the shells commands can be quoted for
+var Shells = []Shell{ShellBash, ShellPowerShell, ShellCmd, ShellBatch}
+
+// DefaultShell is the shell commands are quoted for unless another is chosen:
+// PowerShell on Windows and bash everywhere else
+func DefaultShell() Shell {
+	if runtime.GOOS == "windows" {
+		return ShellPowerShell
+	}
+	return ShellBash
+}
+
+// ParseShell reads a shell name, accepting a few common aliases
+func ParseShell(name string) (Shell, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "":
+		return DefaultShell(), nil
+	case "bash", "sh", "zsh", "posix":
+		return ShellBash, nil
+	case "powershell", "pwsh", "ps":
+		return ShellPowerShell, nil
+	case "cmd", "cmd.exe":
+		return ShellCmd, nil
+	case "bat", "batch":
+		return ShellBatch, nil
+	}
+	return "", fmt.Errorf("unknown shell %q (use bash, powershell, cmd or bat)", name)
+}
+
+// safeArgRe matches arguments every shell reads literally, which are left unquoted
+var safeArgRe = regexp.MustCompile(`^[A-Za-z0-9_@+=:,./\\-]+$`)
+
+// posixSafeArgRe is safeArgRe without the backslash, which POSIX shells treat as an escape
+var posixSafeArgRe = regexp.MustCompile(`^[A-Za-z0-9_@+=:,./-]+$`)
+
+// QuoteArg quotes an argument so the shell passes it to the program unchanged
+func QuoteArg(shell Shell, arg string) string {
+	switch shell {
+	case ShellPowerShell:
+		if arg != "" && safeArgRe.MatchString(arg) {
+			return arg
+		}
+		// Inside single quotes only the quote itself is special; PowerShell also treats
+		// typographic single quotes as quotes, so those are doubled too
+		var b strings.Builder
+		b.WriteByte('\'')
+		for _, r := range arg {
+			switch r {
+			case '\'', '‘', '’', '‚', '‛':
+				b.WriteRune(r)
+			}
+			b.WriteRune(r)
+		}
+		b.WriteByte('\'')
+		return b.String()
+	case ShellCmd, ShellBatch:
+		if arg != "" && safeArgRe.MatchString(arg) {
+			return arg
+		}
+		return quoteCmdArg(arg, shell == ShellBatch)
+	default:
+		if arg != "" && posixSafeArgRe.MatchString(arg) {
+			return arg
+		}
+		return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+}
+
+// quoteCmdArg double-quotes an argument for the Windows command prompt or a batch file.
+// Quotes are backslash-escaped the way programs split their command line. cmd expands
+// %VARIABLES% even inside quotes, so % is doubled in batch files and, at the prompt, moved
+// outside the quotes and escaped with ^.
+func quoteCmdArg(arg string, batch bool) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	backslashes := 0
+	for _, r := range arg {
+		switch r {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			b.WriteString(strings.Repeat(`\`, 2*backslashes+1))
+		case '%':
+			if batch {
+				b.WriteString(strings.Repeat(`\`, backslashes))
+				b.WriteString("%%")
+			} else {
+				// The quote closed here would be escaped by the backslashes before it
+				b.WriteString(strings.Repeat(`\`, 2*backslashes))
+				b.WriteString(`"^%"`)
+			}
+			backslashes = 0
+			continue
+		default:
+			b.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		backslashes = 0
+		b.WriteRune(r)
+	}
+	// Backslashes before the closing quote would escape it
+	b.WriteString(strings.Repeat(`\`, 2*backslashes))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// ShellCommand joins a program and its arguments into a command line for the shell
+func ShellCommand(shell Shell, program string, args []string) string {
+	parts := []string{QuoteArg(shell, program)}
+	for _, arg := range args {
+		parts = append(parts, QuoteArg(shell, arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ScriptShell picks the shell a script is written for from its file extension: .ps1 for
+// PowerShell, .bat or .cmd for a batch file and anything else for bash
+func ScriptShell(path string) Shell {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ps1":
+		return ShellPowerShell
+	case ".bat", ".cmd":
+		return ShellBatch
+	}
+	return ShellBash
+}
+
+// Script builds a ready-to-run script that runs each command line in turn and stops at the
+// first one that fails. The command lines must already be quoted for the shell; comment
+// should be a single line of plain text.
+func Script(shell Shell, comment string, commands []string) string {
+	var lines []string
+	switch shell {
+	case ShellPowerShell:
+		// The byte order mark makes Windows PowerShell read the file as UTF-8, so
+		// non-English file names survive
+		lines = append(lines, "\uFEFF# "+comment, "$ErrorActionPreference = 'Stop'")
+		for _, command := range commands {
+			lines = append(lines, command, "if ($LASTEXITCODE -ne 0) { exit $LASTEXITCODE }")
+		}
+	case ShellCmd, ShellBatch:
+		// Switch the console to UTF-8 so non-English file names survive
+		lines = append(lines, "@echo off", "REM "+comment, "chcp 65001 >nul")
+		for _, command := range commands {
+			lines = append(lines, command, "if errorlevel 1 exit /b 1")
+		}
+		return strings.Join(lines, "\r\n") + "\r\n"
+	default:
+		lines = append(lines, "#!/usr/bin/env bash", "# "+comment, "set -e")
+		lines = append(lines, commands...)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
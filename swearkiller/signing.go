@@ -0,0 +1,309 @@
+package swearkiller
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Shared word lists (and anything else households pass around) can be signed so the
+// receiver knows who made them and that nothing changed on the way. Keys and signatures use
+// the minisign format (https://jedisct1.github.io/minisign/), so files signed here verify
+// with minisign and the other way round. A SHA-256 checksum file is written alongside for
+// people who only want to catch corrupted downloads.
+
+const (
+	SignatureExt = ".minisig" // Added to a file's name for its signature
+	ChecksumExt  = ".sha256"  // Added to a file's name for its checksum, in sha256sum format
+)
+
+var (
+	sigAlgPure     = []byte("Ed") // Signs the file itself
+	sigAlgHashed   = []byte("ED") // Signs the file's BLAKE2b-512 hash; minisign's default
+	kdfNone        = []byte{0, 0} // Secret key stored unencrypted
+	checksumAlgB2  = []byte("B2")
+	keyIDLength    = 8
+	secretKeyBytes = 2 + 2 + 2 + 32 + 8 + 8 + 8 + ed25519.PrivateKeySize + 32
+)
+
+// ErrBadSignature means a file doesn't match its signature, or was signed by another key
+var ErrBadSignature = errors.New("the signature doesn't match; the file was changed or signed by someone else")
+
+// PublicKey verifies signatures
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// SecretKey makes signatures; keep it private
+type SecretKey struct {
+	ID  [8]byte
+	Key ed25519.PrivateKey
+}
+
+// keyIDString formats a key ID the way minisign prints it
+func keyIDString(id [8]byte) string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id[:]))
+}
+
+// String returns the key's ID, which identifies it in signatures
+func (k PublicKey) String() string { return keyIDString(k.ID) }
+
+// GenerateKey creates a new key pair
+func GenerateKey() (PublicKey, SecretKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return PublicKey{}, SecretKey{}, fmt.Errorf("failed to generate key: %v", err)
+	}
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return PublicKey{}, SecretKey{}, fmt.Errorf("failed to generate key ID: %v", err)
+	}
+	return PublicKey{ID: id, Key: pub}, SecretKey{ID: id, Key: priv}, nil
+}
+
+// Public returns the public key that verifies this key's signatures
+func (k SecretKey) Public() PublicKey {
+	return PublicKey{ID: k.ID, Key: k.Key.Public().(ed25519.PublicKey)}
+}
+
+// Encode writes the public key as a minisign public key file
+func (k PublicKey) Encode() []byte {
+	data := append(append(append([]byte{}, sigAlgPure...), k.ID[:]...), k.Key...)
+	return []byte(fmt.Sprintf("untrusted comment: minisign public key %s\n%s\n", k, base64.StdEncoding.EncodeToString(data)))
+}
+
+// Encode writes the secret key as an unencrypted minisign secret key file
+// (like `minisign -G -W` makes)
+func (k SecretKey) Encode() []byte {
+	data := make([]byte, 0, secretKeyBytes)
+	data = append(data, sigAlgPure...)
+	data = append(data, kdfNone...)
+	data = append(data, checksumAlgB2...)
+	data = append(data, make([]byte, 32+8+8)...) // No key derivation: salt and limits unused
+	data = append(data, k.ID[:]...)
+	data = append(data, k.Key...)
+	data = append(data, secretKeyChecksum(k.ID, k.Key)...)
+	return []byte("untrusted comment: minisign secret key\n" + base64.StdEncoding.EncodeToString(data) + "\n")
+}
+
+// secretKeyChecksum is minisign's check that a secret key decoded correctly
+func secretKeyChecksum(id [8]byte, key []byte) []byte {
+	h, _ := blake2b.New256(nil)
+	h.Write(sigAlgPure)
+	h.Write(id[:])
+	h.Write(key)
+	return h.Sum(nil)
+}
+
+// keyFileData returns the base64 line of a minisign key or signature file, which may also
+// be given on its own
+func keyFileData(text string) ([]byte, error) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+		return base64.StdEncoding.DecodeString(line)
+	}
+	return nil, fmt.Errorf("no key found")
+}
+
+// ParsePublicKey reads a minisign public key file, or just the key line from one
+func ParsePublicKey(text string) (PublicKey, error) {
+	var k PublicKey
+	data, err := keyFileData(text)
+	if err != nil || len(data) != 2+keyIDLength+ed25519.PublicKeySize || !bytes.Equal(data[:2], sigAlgPure) {
+		return k, fmt.Errorf("not a minisign public key")
+	}
+	copy(k.ID[:], data[2:])
+	k.Key = ed25519.PublicKey(data[2+keyIDLength:])
+	return k, nil
+}
+
+// ParseSecretKey reads an unencrypted minisign secret key file
+func ParseSecretKey(text string) (SecretKey, error) {
+	var k SecretKey
+	data, err := keyFileData(text)
+	if err != nil || len(data) != secretKeyBytes || !bytes.Equal(data[:2], sigAlgPure) {
+		return k, fmt.Errorf("not a minisign secret key")
+	}
+	if !bytes.Equal(data[2:4], kdfNone) {
+		return k, fmt.Errorf("password-protected secret keys aren't supported; create one with `swear-killer keygen` or `minisign -G -W`")
+	}
+	keyNum := data[2+2+2+32+8+8:]
+	copy(k.ID[:], keyNum)
+	k.Key = ed25519.PrivateKey(keyNum[keyIDLength : keyIDLength+ed25519.PrivateKeySize])
+	if subtle.ConstantTimeCompare(secretKeyChecksum(k.ID, k.Key), keyNum[keyIDLength+ed25519.PrivateKeySize:]) != 1 {
+		return k, fmt.Errorf("the secret key is damaged (checksum mismatch)")
+	}
+	return k, nil
+}
+
+// ReadPublicKeyFile reads a minisign public key file
+func ReadPublicKeyFile(path string) (PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("failed to read public key: %v", err)
+	}
+	key, err := ParsePublicKey(string(data))
+	if err != nil {
+		return key, fmt.Errorf("%s: %v", path, err)
+	}
+	return key, nil
+}
+
+// ReadSecretKeyFile reads an unencrypted minisign secret key file
+func ReadSecretKeyFile(path string) (SecretKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SecretKey{}, fmt.Errorf("failed to read secret key: %v", err)
+	}
+	key, err := ParseSecretKey(string(data))
+	if err != nil {
+		return key, fmt.Errorf("%s: %v", path, err)
+	}
+	return key, nil
+}
+
+// Sign signs data and returns the signature file. The trusted comment is covered by the
+// signature, so it can say who signed the file and when.
+func Sign(key SecretKey, data []byte, trustedComment string) []byte {
+	hash := blake2b.Sum512(data)
+	signature := ed25519.Sign(key.Key, hash[:])
+	globalSignature := ed25519.Sign(key.Key, append(append([]byte{}, signature...), trustedComment...))
+	sig := append(append(append([]byte{}, sigAlgHashed...), key.ID[:]...), signature...)
+	return []byte(fmt.Sprintf("untrusted comment: signature from swear-killer secret key %s\n%s\ntrusted comment: %s\n%s\n",
+		keyIDString(key.ID), base64.StdEncoding.EncodeToString(sig), trustedComment, base64.StdEncoding.EncodeToString(globalSignature)))
+}
+
+// Verify checks a minisign signature file against data and returns its trusted comment
+func Verify(key PublicKey, data, signatureFile []byte) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(string(signatureFile)), "\r\n", "\n"), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", fmt.Errorf("not a minisign signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+keyIDLength+ed25519.SignatureSize {
+		return "", fmt.Errorf("not a minisign signature")
+	}
+	globalSignature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSignature) != ed25519.SignatureSize {
+		return "", fmt.Errorf("not a minisign signature")
+	}
+	var id [8]byte
+	copy(id[:], sig[2:])
+	if id != key.ID {
+		return "", fmt.Errorf("signed with key %s, not %s", keyIDString(id), key)
+	}
+
+	signed := data
+	switch {
+	case bytes.Equal(sig[:2], sigAlgHashed):
+		hash := blake2b.Sum512(data)
+		signed = hash[:]
+	case !bytes.Equal(sig[:2], sigAlgPure):
+		return "", fmt.Errorf("unknown signature algorithm %q", sig[:2])
+	}
+	signature := sig[2+keyIDLength:]
+	if !ed25519.Verify(key.Key, signed, signature) {
+		return "", ErrBadSignature
+	}
+	trustedComment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	if !ed25519.Verify(key.Key, append(append([]byte{}, signature...), trustedComment...), globalSignature) {
+		return "", fmt.Errorf("the trusted comment was changed after signing")
+	}
+	return trustedComment, nil
+}
+
+// SignFile writes a signature (<path>.minisig) and a checksum (<path>.sha256) next to a file
+func SignFile(key SecretKey, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	comment := fmt.Sprintf("timestamp:%d\tfile:%s\thashed", time.Now().Unix(), filepath.Base(path))
+	if err := os.WriteFile(path+SignatureExt, Sign(key, data, comment), 0644); err != nil {
+		return fmt.Errorf("failed to write signature: %v", err)
+	}
+	return WriteChecksumFile(path)
+}
+
+// VerifyFile checks a file against its signature (<path>.minisig) and returns the
+// signature's trusted comment
+func VerifyFile(key PublicKey, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	signature, err := os.ReadFile(path + SignatureExt)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("%s is not signed (no %s file)", path, filepath.Base(path+SignatureExt))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read signature: %v", err)
+	}
+	comment, err := Verify(key, data, signature)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return comment, nil
+}
+
+// FileChecksum returns the SHA-256 checksum of a file in hex
+func FileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteChecksumFile writes <path>.sha256 in the format `sha256sum -c` checks
+func WriteChecksumFile(path string) error {
+	sum, err := FileChecksum(path)
+	if err != nil {
+		return fmt.Errorf("failed to checksum %s: %v", path, err)
+	}
+	if err := os.WriteFile(path+ChecksumExt, []byte(sum+"  "+filepath.Base(path)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write checksum: %v", err)
+	}
+	return nil
+}
+
+// VerifyChecksumFile checks a file against <path>.sha256. A missing checksum file is
+// reported with an error satisfying os.IsNotExist.
+func VerifyChecksumFile(path string) error {
+	data, err := os.ReadFile(path + ChecksumExt)
+	if err != nil {
+		return err
+	}
+	want, _, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	got, err := FileChecksum(path)
+	if err != nil {
+		return fmt.Errorf("failed to checksum %s: %v", path, err)
+	}
+	if !strings.EqualFold(want, got) {
+		return fmt.Errorf("%s doesn't match its checksum; it was changed or damaged", path)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package swearkiller
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSignVerify checks that a signed word list verifies with its key, keys survive being
+// written out and read back, and a changed list, comment or key is refused
+func TestSignVerify(t *testing.T) {
+	public, secret, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed, err := ParsePublicKey(string(public.Encode())); err != nil || parsed.ID != public.ID || !parsed.Key.Equal(public.Key) {
+		t.Errorf("public key round trip: got %v, %v", parsed, err)
+	}
+	if parsed, err := ParseSecretKey(string(secret.Encode())); err != nil || parsed.ID != secret.ID || !parsed.Key.Equal(secret.Key) {
+		t.Errorf("secret key round trip: got %v, %v", parsed.ID, err)
+	}
+
+	list := []byte("damn\nhell\n")
+	signature := Sign(secret, list, "file:words.txt")
+	if comment, err := Verify(public, list, signature); err != nil || comment != "file:words.txt" {
+		t.Fatalf("round trip: got %q, %v", comment, err)
+	}
+
+	other, _, _ := GenerateKey()
+	sameID := other
+	sameID.ID = public.ID
+	tests := []struct {
+		name      string
+		key       PublicKey
+		data      []byte
+		signature []byte
+		bad       bool // Want ErrBadSignature rather than any error
+	}{
+		{"tampered list", public, []byte("damn\nheck\n"), signature, true},
+		{"word added", public, append([]byte("crap\n"), list...), signature, true},
+		{"wrong key", other, list, signature, false},
+		{"wrong key with the same ID", sameID, list, signature, true},
+		{"tampered comment", public, list, []byte(strings.Replace(string(signature), "file:words.txt", "file:other.txt", 1)), false},
+		{"not a signature", public, list, []byte("hello"), false},
+	}
+	for _, tt := range tests {
+		_, err := Verify(tt.key, tt.data, tt.signature)
+		if err == nil {
+			t.Errorf("%s: verified", tt.name)
+		} else if tt.bad && !errors.Is(err, ErrBadSignature) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, ErrBadSignature)
+		}
+	}
+}
+
+// TestSignFile checks the signature and checksum written next to a word list, and that
+// both catch the list being changed afterwards
+func TestSignFile(t *testing.T) {
+	public, secret, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "words.txt")
+	os.WriteFile(path, []byte("damn\nhell\n"), 0644)
+	if _, err := VerifyFile(public, path); err == nil {
+		t.Errorf("unsigned list verified")
+	}
+	if err := SignFile(secret, path); err != nil {
+		t.Fatal(err)
+	}
+	if comment, err := VerifyFile(public, path); err != nil || !strings.Contains(comment, "file:words.txt") {
+		t.Errorf("signed list: got %q, %v", comment, err)
+	}
+	if err := VerifyChecksumFile(path); err != nil {
+		t.Errorf("checksum: %v", err)
+	}
+
+	os.WriteFile(path, []byte("damn\nheck\n"), 0644)
+	if _, err := VerifyFile(public, path); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("tampered list: got %v, want %v", err, ErrBadSignature)
+	}
+	if err := VerifyChecksumFile(path); err == nil {
+		t.Errorf("tampered list matched its checksum")
+	}
+}
@@ -0,0 +1,196 @@
+package swearkiller
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"swear-killer/swearkiller/subtitle"
+)
+
+// Cue is a single timed subtitle block
+type Cue struct {
+	Index int     // Position of the cue in the file, starting at 1
+	Start float64 // Start time in seconds
+	End   float64 // End time in seconds
+	Text  string  // Subtitle text without markup (see CleanLine), lines joined by spaces
+	Raw   string  // Subtitle text as written in the file, lines joined by spaces
+}
+
+// SRTReport describes how well a subtitle file parsed
+type SRTReport = subtitle.Report
+
+// ParseSRT reads all subtitle cues from SRT, WebVTT or ASS/SSA input (see ParseSRTWithReport)
+func ParseSRT(r io.Reader) ([]Cue, error) {
+	cues, _, err := ParseSRTWithReport(r)
+	return cues, err
+}
+
+// readBuffers holds the buffers subtitle files are read into, so scanning a library doesn't
+// allocate a fresh one for every file
+var readBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// ParseSRTWithReport reads all subtitle cues from SRT, WebVTT or ASS/SSA input, in any
+// encoding DecodeText handles, and reports the blocks it couldn't read. The format is
+// detected from the contents, and ill-formed blocks are skipped rather than failing the
+// whole file (see the subtitle package).
+func ParseSRTWithReport(r io.Reader) ([]Cue, SRTReport, error) {
+	buf := readBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer readBuffers.Put(buf)
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, SRTReport{}, fmt.Errorf("error reading subtitle file: %v", err)
+	}
+	// DecodeText copies, so the buffer can go back to the pool
+	text, _, err := DecodeText(buf.Bytes())
+	if err != nil {
+		return nil, SRTReport{}, err
+	}
+
+	parsed, report := subtitle.Parse(text)
+	cues := make([]Cue, 0, len(parsed))
+	var cleaned []string
+	for _, p := range parsed {
+		// Keep the original text alongside the cleaned version
+		cleaned = cleaned[:0]
+		for _, line := range p.Lines {
+			if c := CleanLine(line); c != "" {
+				cleaned = append(cleaned, c)
+			}
+		}
+		cues = append(cues, Cue{Index: p.Index, Start: p.Start, End: p.End,
+			Text: strings.Join(cleaned, " "), Raw: strings.Join(p.Lines, " ")})
+	}
+	return cues, report, nil
+}
+
+// ReadSRTFile parses the subtitle file at the given path
+func ReadSRTFile(srtPath string) ([]Cue, error) {
+	cues, _, err := ReadSRTFileWithReport(srtPath)
+	return cues, err
+}
+
+// ReadSRTFileWithReport parses the subtitle file at the given path and reports the blocks
+// it couldn't read
+func ReadSRTFileWithReport(srtPath string) ([]Cue, SRTReport, error) {
+	file, err := os.Open(srtPath)
+	if err != nil {
+		return nil, SRTReport{}, fmt.Errorf("failed to open SRT file: %w", err)
+	}
+	defer file.Close()
+	return ParseSRTWithReport(file)
+}
+
+// FormatSRTTime formats seconds as an SRT timestamp like "00:01:23,456"
+func FormatSRTTime(seconds float64) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	ms := int(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// WriteSRTFile writes cues as an SRT file, using their raw text
+func WriteSRTFile(srtPath string, cues []Cue) error {
+	var b strings.Builder
+	for i, cue := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, FormatSRTTime(cue.Start), FormatSRTTime(cue.End), cue.Raw)
+	}
+	if err := os.WriteFile(srtPath, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write SRT file: %v", err)
+	}
+	return nil
+}
+
+// FormatTimestamp formats seconds as HH:MM:SS for display
+func FormatTimestamp(seconds float64) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	total := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total/60%60, total%60)
+}
+
+// MergeTracks combines the cues of several subtitle tracks for the same video, such as a
+// forced track and a full one, or two languages, so a swear in any of them is found. The
+// cues are put in time order and renumbered, and a line found in more than one track at
+// the same time is kept once.
+func MergeTracks(tracks ...[]Cue) []Cue {
+	type key struct {
+		start, end float64
+		text       string
+	}
+	seen := map[key]bool{}
+	var merged []Cue
+	for _, track := range tracks {
+		for _, cue := range track {
+			k := key{roundMillis(cue.Start), roundMillis(cue.End), strings.ToLower(cue.Text)}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			merged = append(merged, cue)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Start < merged[j].Start })
+	for i := range merged {
+		merged[i].Index = i + 1
+	}
+	return merged
+}
+
+// IsForcedSubtitle reports whether a subtitle file is named as a forced track, like
+// "movie.en.forced.srt", which holds only the foreign-language dialogue
+func IsForcedSubtitle(path string) bool {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return slices.Contains(strings.Split(strings.ToLower(name), "."), "forced")
+}
+
+// WithForcedSubtitles adds the forced track sitting beside each full one, like
+// "movie.en.forced.srt" next to "movie.en.srt", so its lines are searched too. Forced
+// tracks only add to the full track and never stand in for it.
+func WithForcedSubtitles(paths []string) []string {
+	all := slices.Clone(paths)
+	for _, path := range paths {
+		if path == "" || IsForcedSubtitle(path) {
+			continue
+		}
+		ext := filepath.Ext(path)
+		forced := strings.TrimSuffix(path, ext) + ".forced" + ext
+		if _, err := os.Stat(forced); err == nil && !slices.Contains(all, forced) {
+			all = append(all, forced)
+		}
+	}
+	return all
+}
+
+// ReadSubtitleTracks reads one or more subtitle files for the same video and merges them
+// (see MergeTracks). The report adds up what each file skipped, naming the file in each
+// problem when there is more than one.
+func ReadSubtitleTracks(paths []string) ([]Cue, SRTReport, error) {
+	if len(paths) == 1 {
+		return ReadSRTFileWithReport(paths[0])
+	}
+	var tracks [][]Cue
+	var total SRTReport
+	for _, path := range paths {
+		cues, report, err := ReadSRTFileWithReport(path)
+		if err != nil {
+			return nil, SRTReport{}, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		tracks = append(tracks, cues)
+		total.Cues += report.Cues
+		total.Skipped += report.Skipped
+		total.Overlapping += report.Overlapping
+		for _, problem := range report.Problems {
+			total.Problems = append(total.Problems, filepath.Base(path)+": "+problem)
+		}
+	}
+	return MergeTracks(tracks...), total, nil
+}
@@ -0,0 +1,124 @@
+package swearkiller
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// OriginalAudioTitle names the uncensored audio track KeepOriginal adds to MKV outputs
+const OriginalAudioTitle = "Original audio (uncensored)"
+
+// StashExt is the extension of stash files, which hold the audio censored out of a video
+const StashExt = ".stash.mka"
+
+// StashPath returns where the stash for a clean output goes by default: next to it, with
+// StashExt
+func StashPath(output string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output)) + StashExt
+}
+
+// snippetChains cuts each segment's audio out of input and labels the pieces [<prefix>N].
+// at gives where each piece starts in input. The chains go in a filter graph.
+func snippetChains(input, prefix string, segments []Segment, at func(i int) float64) string {
+	var graph strings.Builder
+	fmt.Fprintf(&graph, "%sasplit=%d", input, len(segments))
+	for i := range segments {
+		fmt.Fprintf(&graph, "[%scut%d]", prefix, i)
+	}
+	for i, seg := range segments {
+		start := at(i)
+		fmt.Fprintf(&graph, ";[%scut%d]atrim=start=%.3f:end=%.3f,asetpts=PTS-STARTPTS[%s%d]", prefix, i, start, start+seg.End-seg.Start, prefix, i)
+	}
+	return graph.String()
+}
+
+// BuildStashArgs creates the FFmpeg arguments that save the original audio of every
+// segment, one after another, losslessly in a stash file. The segments are listed in the
+// stash's metadata (see SegmentsTag), so RestoreArgs knows where each piece goes back. It
+// returns nil if there is nothing to stash.
+func BuildStashArgs(video, stash string, segments []Segment, opts EncodeOptions) []string {
+	var rounded []Segment
+	for _, seg := range MergeSegments(LimitSegments(segments, opts.MaxDuration)) {
+		// Cut at the times the tag lists, so no error builds up along the stash
+		rounded = append(rounded, Segment{Start: roundMillis(seg.Start), End: roundMillis(seg.End), Action: seg.Action})
+	}
+	if len(rounded) == 0 {
+		return nil
+	}
+	_, input := opts.mainAudioInput()
+	graph := snippetChains(input, "s", rounded, func(i int) float64 { return rounded[i].Start })
+	graph += ";"
+	for i := range rounded {
+		graph += fmt.Sprintf("[s%d]", i)
+	}
+	graph += fmt.Sprintf("concat=n=%d:v=0:a=1[stash]", len(rounded))
+	args := []string{"-i", video, "-filter_complex", graph, "-map", "[stash]", "-c:a", "flac"}
+	args = append(args, segmentsTagArgs(stash, rounded)...)
+	return append(args, "-y", stash)
+}
+
+// WriteStash saves the original audio of the segments to a stash file, so the censoring
+// can be undone later without keeping the whole original (see RestoreArgs)
+func WriteStash(ctx context.Context, video, stash string, segments []Segment, opts EncodeOptions) error {
+	args := BuildStashArgs(video, stash, segments, opts)
+	if args == nil {
+		return nil
+	}
+	if err := RunFFmpegToFile(ctx, args, 0, nil); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to stash the censored audio: %v", err)
+	}
+	return nil
+}
+
+// RestoreArgs creates the FFmpeg arguments that undo the censoring of a clean output. With
+// a stash, the stashed audio is put back over each segment of the main audio; without
+// one, the original audio track KeepOriginal added becomes the only audio. The picture and
+// subtitles are copied, and the SegmentsTag is dropped as the result isn't censored.
+func RestoreArgs(ctx context.Context, video, stash, output string) ([]string, error) {
+	if stash == "" {
+		tracks, err := AudioTracks(ctx, video)
+		if err != nil {
+			return nil, err
+		}
+		for _, track := range tracks {
+			if track.Title == OriginalAudioTitle {
+				return []string{"-i", video, "-map", "0:v?", "-map", fmt.Sprintf("0:a:%d", track.Index), "-map", "0:s?",
+					"-c", "copy", "-disposition:a:0", "default", "-metadata:s:a:0", "title=", "-metadata", SegmentsTag + "=", "-y", output}, nil
+			}
+		}
+		return nil, fmt.Errorf("the video has no %q track; pass the stash file saved with it", OriginalAudioTitle)
+	}
+
+	segments, found, err := EmbeddedSegments(ctx, stash)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s isn't a stash file", stash)
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("the stash is empty; nothing was censored")
+	}
+	offsets := make([]float64, len(segments))
+	for i := 1; i < len(segments); i++ {
+		offsets[i] = offsets[i-1] + segments[i-1].End - segments[i-1].Start
+	}
+	graph := "[0:a:0]" + BuildVolumeFilter(segments) + "[base];" + snippetChains("[1:a]", "r", segments, func(i int) float64 { return offsets[i] })
+	mix := "[base]"
+	for i, seg := range segments {
+		ms := int(seg.Start*1000 + 0.5)
+		graph += fmt.Sprintf(";[r%d]adelay=delays=%d:all=1[back%d]", i, ms, i)
+		mix += fmt.Sprintf("[back%d]", i)
+	}
+	graph += fmt.Sprintf(";%samix=inputs=%d:duration=first:normalize=0[aout]", mix, len(segments)+1)
+	args := []string{"-i", video, "-i", stash, "-filter_complex", graph, "-metadata", SegmentsTag + "="}
+	if IsAudioFile(output) {
+		return append(args, "-map", "[aout]", "-vn", "-c:a", audioCodec(output), "-y", output), nil
+	}
+	return append(args, "-map", "0:v?", "-map", "[aout]", "-map", "0:s?", "-c", "copy", "-c:a", "aac", "-y", output), nil
+}
@@ -0,0 +1,292 @@
+package swearkiller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// quickHashChunk is how much of each end of a file QuickHash reads
+const quickHashChunk = 1 << 20
+
+// ProcessedRecord remembers how a video was last processed
+type ProcessedRecord struct {
+	VideoHash   string    `json:"video_hash"`
+	Fingerprint string    `json:"fingerprint"` // Hash of the subtitle and every setting that affects the result
+	OutputPath  string    `json:"output_path"`
+	ProcessedAt time.Time `json:"processed_at"`
+	Notes       string    `json:"notes,omitempty"`  // The user's notes on the clean edit, like "still too many gaps"
+	Rating      int       `json:"rating,omitempty"` // The user's rating from 1 to MaxRating; 0 is unrated
+
+	// What was done, for the history export; records from older versions leave these out
+	Subtitle     string            `json:"subtitle,omitempty"`
+	Settings     map[string]string `json:"settings,omitempty"` // The options that affect the result, by name
+	Segments     int               `json:"segments,omitempty"` // Segments censored in the output
+	MutedSeconds float64           `json:"muted_seconds,omitempty"`
+	Duration     float64           `json:"duration,omitempty"` // Length of the video in seconds
+}
+
+// MaxRating is the highest rating a processed title can be given
+const MaxRating = 5
+
+// RatingStars shows a rating as stars, like "★★★☆☆", or "unrated"
+func RatingStars(rating int) string {
+	if rating <= 0 {
+		return "unrated"
+	}
+	rating = min(rating, MaxRating)
+	return strings.Repeat("★", rating) + strings.Repeat("☆", MaxRating-rating)
+}
+
+// ProcessedState records which videos have been processed, so re-running a batch skips
+// the ones whose video, subtitle and settings haven't changed. It is safe for concurrent use.
+type ProcessedState struct {
+	mu    sync.Mutex
+	path  string
+	Files map[string]ProcessedRecord `json:"files"` // Keyed by absolute video path
+
+	// Videos whose last run failed, keyed the same way; a later success removes them
+	Failures map[string]FailedRecord `json:"failures,omitempty"`
+}
+
+// FailedRecord remembers why a video's last run failed
+type FailedRecord struct {
+	Reason   string    `json:"reason"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// DefaultStatePath returns where the record of processed videos is kept: in the home directory
+func DefaultStatePath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".swear-killer-state.json")
+}
+
+// LoadProcessedState reads the state file at path; a missing file gives an empty state
+func LoadProcessedState(path string) (*ProcessedState, error) {
+	state := &ProcessedState{path: path, Files: map[string]ProcessedRecord{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read state file: %v", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return state, fmt.Errorf("invalid state file: %v", err)
+	}
+	if state.Files == nil {
+		state.Files = map[string]ProcessedRecord{}
+	}
+	return state, nil
+}
+
+// stateKey returns the key a video is recorded under
+func stateKey(videoPath string) string {
+	if abs, err := filepath.Abs(videoPath); err == nil {
+		return abs
+	}
+	return videoPath
+}
+
+// AlreadyProcessed reports whether the video was processed with the same content and
+// fingerprint and its output is still there
+func (s *ProcessedState) AlreadyProcessed(videoPath, videoHash, fingerprint string) (ProcessedRecord, bool) {
+	s.mu.Lock()
+	record, ok := s.Files[stateKey(videoPath)]
+	s.mu.Unlock()
+	if !ok || record.VideoHash != videoHash || record.Fingerprint != fingerprint {
+		return record, false
+	}
+	if _, err := os.Stat(record.OutputPath); err != nil {
+		return record, false
+	}
+	return record, true
+}
+
+// Record remembers a successfully processed video and saves the state file. Notes and a
+// rating given to an earlier run are kept.
+func (s *ProcessedState) Record(videoPath string, record ProcessedRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := stateKey(videoPath)
+	if old, ok := s.Files[key]; ok && record.Notes == "" && record.Rating == 0 {
+		record.Notes, record.Rating = old.Notes, old.Rating
+	}
+	s.Files[key] = record
+	delete(s.Failures, key)
+	return s.save()
+}
+
+// RecordFailure remembers that a run on the video failed and saves the state file. The
+// record of an earlier success is kept, though its output may be stale.
+func (s *ProcessedState) RecordFailure(videoPath, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Failures == nil {
+		s.Failures = map[string]FailedRecord{}
+	}
+	s.Failures[stateKey(videoPath)] = FailedRecord{Reason: reason, FailedAt: time.Now()}
+	return s.save()
+}
+
+// Failure returns why the video's last run failed, if it did
+func (s *ProcessedState) Failure(videoPath string) (FailedRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	failure, ok := s.Failures[stateKey(videoPath)]
+	return failure, ok
+}
+
+// knownVideos lists every video in the state and the outputs written for them
+func (s *ProcessedState) knownVideos() (videos, outputs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for video, record := range s.Files {
+		videos = append(videos, video)
+		outputs = append(outputs, stateKey(record.OutputPath))
+	}
+	for video := range s.Failures {
+		videos = append(videos, video)
+	}
+	return videos, outputs
+}
+
+// SourceOf returns the video a recorded output was made from, if path is one
+func (s *ProcessedState) SourceOf(path string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := stateKey(path)
+	for video, record := range s.Files {
+		if stateKey(record.OutputPath) == key {
+			return video, true
+		}
+	}
+	return "", false
+}
+
+// Lookup returns the record of a processed video
+func (s *ProcessedState) Lookup(videoPath string) (ProcessedRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.Files[stateKey(videoPath)]
+	return record, ok
+}
+
+// Annotate sets the notes and rating of a processed video and saves the state file
+func (s *ProcessedState) Annotate(videoPath, notes string, rating int) error {
+	if rating < 0 || rating > MaxRating {
+		return fmt.Errorf("rating must be between 0 (unrated) and %d", MaxRating)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := stateKey(videoPath)
+	record, ok := s.Files[key]
+	if !ok {
+		return fmt.Errorf("%s hasn't been processed", filepath.Base(videoPath))
+	}
+	record.Notes, record.Rating = strings.TrimSpace(notes), rating
+	s.Files[key] = record
+	return s.save()
+}
+
+// HistoryEntry is one processed video in the history
+type HistoryEntry struct {
+	Video string // Absolute path of the video
+	ProcessedRecord
+}
+
+// Title returns the video's file name without its extension
+func (e HistoryEntry) Title() string {
+	name := filepath.Base(e.Video)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// Matches reports whether the entry's path or notes contain every word of query, ignoring case
+func (e HistoryEntry) Matches(query string) bool {
+	text := strings.ToLower(e.Video + "\n" + e.Notes)
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// History returns the processed videos matching query (all of them for ""), most recently
+// processed first
+func (s *ProcessedState) History(query string) []HistoryEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []HistoryEntry
+	for video, record := range s.Files {
+		entry := HistoryEntry{Video: video, ProcessedRecord: record}
+		if entry.Matches(query) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].ProcessedAt.Equal(entries[j].ProcessedAt) {
+			return entries[i].ProcessedAt.After(entries[j].ProcessedAt)
+		}
+		return entries[i].Video < entries[j].Video
+	})
+	return entries
+}
+
+// save writes the state file; the caller holds s.mu
+func (s *ProcessedState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// QuickHash identifies a file's content from its size and SHA-256 of its first and last
+// megabyte. Reading whole videos would take longer than processing them, and a re-encode
+// or different release changes both ends.
+func QuickHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n", info.Size())
+	if _, err := io.CopyN(hash, file, quickHashChunk); err != nil && err != io.EOF {
+		return "", err
+	}
+	if info.Size() > 2*quickHashChunk {
+		if _, err := file.Seek(-quickHashChunk, io.SeekEnd); err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(hash, file); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Fingerprint hashes any JSON-encodable description of the settings, so a changed word
+// list or option invalidates earlier results
+func Fingerprint(settings any) (string, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
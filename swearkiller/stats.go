@@ -0,0 +1,94 @@
+package swearkiller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// TopWordsShown is how many words the summary lists
+const TopWordsShown = 10
+
+// WordCount is how many subtitle lines a swear word was found in
+type WordCount struct {
+	Word  string
+	Count int
+}
+
+// MatchStats summarizes what was found in a title, to help decide whether it's worth
+// cleaning for family night
+type MatchStats struct {
+	Lines        int         // Subtitle lines that will be muted
+	Uncertain    int         // Lines held back for review
+	Words        []WordCount // Every word found, most frequent first
+	Segments     int         // Merged segments censored
+	MutedSeconds float64
+	Runtime      float64 // Seconds, or 0 if unknown
+}
+
+// BuildMatchStats counts the words in the muted matches and how much of the runtime the
+// segments censor. With runtime unknown, the end of the last match stands in for it.
+func BuildMatchStats(matches, review []Match, segments []Segment, runtime float64) MatchStats {
+	stats := MatchStats{
+		Lines:        len(matches),
+		Uncertain:    len(review),
+		Segments:     len(segments),
+		MutedSeconds: SegmentsDuration(segments),
+		Runtime:      runtime,
+	}
+	counts := map[string]int{}
+	for _, m := range matches {
+		for _, word := range m.Words {
+			counts[strings.ToLower(word)]++
+		}
+		if runtime <= 0 {
+			stats.Runtime = max(stats.Runtime, m.Cue.End)
+		}
+	}
+	for word, n := range counts {
+		stats.Words = append(stats.Words, WordCount{Word: word, Count: n})
+	}
+	sort.Slice(stats.Words, func(i, j int) bool {
+		if stats.Words[i].Count != stats.Words[j].Count {
+			return stats.Words[i].Count > stats.Words[j].Count
+		}
+		return stats.Words[i].Word < stats.Words[j].Word
+	})
+	return stats
+}
+
+// MutedPercent returns how much of the runtime is muted, from 0 to 100
+func (s MatchStats) MutedPercent() float64 {
+	if s.Runtime <= 0 {
+		return 0
+	}
+	return min(s.MutedSeconds/s.Runtime*100, 100)
+}
+
+// String formats the summary: totals, then the most frequent words with their counts
+func (s MatchStats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Summary: %d line(s) to mute in %d segment(s), %.1fs muted", s.Lines, s.Segments, s.MutedSeconds)
+	if s.Runtime > 0 {
+		fmt.Fprintf(&b, " (%.2f%% of %s)", s.MutedPercent(), formatRuntime(s.Runtime))
+	}
+	if s.Uncertain > 0 {
+		fmt.Fprintf(&b, "; %d uncertain line(s) left for review", s.Uncertain)
+	}
+	if len(s.Words) == 0 {
+		return b.String()
+	}
+
+	shown := s.Words[:min(len(s.Words), TopWordsShown)]
+	fmt.Fprintf(&b, "\nTop %d word(s):\n", len(shown))
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for i, wc := range shown {
+		fmt.Fprintf(w, "  %d.\t%s\t%d\n", i+1, wc.Word, wc.Count)
+	}
+	w.Flush()
+	if rest := len(s.Words) - len(shown); rest > 0 {
+		fmt.Fprintf(&b, "...and %d more word(s)\n", rest)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,95 @@
+package subtitle
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultASSFormat is the field order of an ASS [Events] section without a Format line
+var defaultASSFormat = []string{"layer", "start", "end", "style", "name", "marginl", "marginr", "marginv", "effect", "text"}
+
+// assDrawingRe matches vector drawings ({\p1}m 0 0 l 10 0...), which are shapes, not text
+var assDrawingRe = regexp.MustCompile(`\{[^}]*\\p[1-9][^}]*\}[^{]*`)
+
+// ParseASS reads the Dialogue lines of an ASS or SSA file's [Events] section. Comments and
+// other sections are passed over, and a Dialogue line with too few fields or unreadable
+// times is skipped. Line breaks (\N) split a cue's lines; override tags like {\i1} are kept,
+// but vector drawings are dropped.
+func ParseASS(text string) ([]Cue, Report) {
+	var b builder
+	inEvents := false
+	format := defaultASSFormat
+
+	for i, raw := range splitLines(text) {
+		lineNumber := i + 1
+		line := strings.TrimSpace(raw)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inEvents = strings.EqualFold(line, "[events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "format":
+			format = nil
+			for _, field := range strings.Split(value, ",") {
+				format = append(format, strings.ToLower(strings.TrimSpace(field)))
+			}
+		case "dialogue":
+			cue, err := parseDialogue(value, format)
+			if err != nil {
+				b.skip(lineNumber, "%v", err)
+				continue
+			}
+			cue.Line = lineNumber
+			b.add(cue)
+		}
+	}
+	return b.finish(FormatASS)
+}
+
+// parseDialogue reads one Dialogue line; the text is the last field and may contain commas
+func parseDialogue(value string, format []string) (Cue, error) {
+	var cue Cue
+	fields := strings.SplitN(value, ",", len(format))
+	if len(fields) < len(format) {
+		return cue, fmt.Errorf("a Dialogue line with too few fields")
+	}
+	var startText, endText, text string
+	foundStart, foundEnd, foundText := false, false, false
+	for i, name := range format {
+		switch name {
+		case "start":
+			startText, foundStart = fields[i], true
+		case "end":
+			endText, foundEnd = fields[i], true
+		case "text":
+			text, foundText = fields[i], true
+		}
+	}
+	if !foundStart || !foundEnd || !foundText {
+		return cue, fmt.Errorf("the Format line has no Start, End or Text field")
+	}
+	var err error
+	if cue.Start, err = ParseTimestamp(startText); err != nil {
+		return cue, err
+	}
+	if cue.End, err = ParseTimestamp(endText); err != nil {
+		return cue, err
+	}
+
+	text = assDrawingRe.ReplaceAllString(text, "")
+	text = strings.NewReplacer(`\n`, `\N`, `\h`, " ").Replace(text)
+	for _, line := range strings.Split(text, `\N`) {
+		if line = strings.TrimSpace(line); line != "" {
+			cue.Lines = append(cue.Lines, line)
+		}
+	}
+	return cue, nil
+}
@@ -0,0 +1,54 @@
+package subtitle
+
+import "testing"
+
+// fuzzSeeds are starting points for the fuzzers: valid files in each format and the kinds
+// of damage seen in downloaded subtitles
+var fuzzSeeds = []string{
+	"1\n00:00:01,000 --> 00:00:02,000\nHello\n\n2\n00:00:03,000 --> 00:00:04,000\nBye\n",
+	"\uFEFF1\r\n100:00:01,000 --> 100:00:02,5\r\nHello\r\n2\r\n00:00:03.000 --> 00:00:02,000\r\n",
+	"1\n00:61:00,000 --> 00:00:00,000\n\n-->\n--> -->\n00:00:01,000 -->\nstray\n",
+	"WEBVTT\n\nNOTE hi\n\nid\n00:01.000 --> 00:02.000 align:start\n<c>Hi</c>\n\n00:03.000 --> 00:04.000\n",
+	"WEBVTT\n\n\n-->\n\nid\n\n99:59.999 --> 00:00.000\n",
+	"[Script Info]\n[Events]\nFormat: Layer, Start, End, Style, Text\nDialogue: 0,0:00:01.00,0:00:02.00,Default,Hi\\Nthere\n",
+	"[Events]\nFormat: Text\nDialogue: ,,,\nFormat:\nDialogue:\nDialogue: 0,9:99:99.99,0:00:00.00,x,{\\p1}m 0 0{\\p0}\n",
+	"",
+}
+
+// FuzzParse checks that Parse never panics and only returns sane cues, whatever it's fed
+func FuzzParse(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		cues, report := Parse(text)
+		checkCues(t, cues, report)
+	})
+}
+
+// FuzzParseFormats runs each format's parser on input meant for the others, since a file
+// can be misdetected
+func FuzzParseFormats(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		for _, parse := range []func(string) ([]Cue, Report){ParseSRT, ParseVTT, ParseASS} {
+			cues, report := parse(text)
+			checkCues(t, cues, report)
+		}
+	})
+}
+
+// FuzzParseTimestamp checks that timestamps either parse to a time in range or fail
+func FuzzParseTimestamp(f *testing.F) {
+	for _, seed := range []string{"00:00:01,000", "100:00:01.5", "02:03.456", "0:00:01.50", "-1:00:00,000", "9:99:99"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, timestamp string) {
+		seconds, err := ParseTimestamp(timestamp)
+		if err == nil && (seconds < 0 || seconds > maxHours*3600+3599.999) {
+			t.Errorf("ParseTimestamp(%q) = %v, out of range", timestamp, seconds)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package subtitle
+
+import "strings"
+
+// isCueNumber reports whether line is the cue number that starts an SRT block
+func isCueNumber(line string) bool {
+	if line == "" {
+		return false
+	}
+	for i := 0; i < len(line); i++ {
+		if line[i] < '0' || line[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSRT reads SubRip cues. Missing blank lines between blocks, long timestamps and
+// milliseconds written with a dot are read as they are. A block with a broken timing line,
+// one that ends before it starts, or text outside any block is skipped.
+func ParseSRT(text string) ([]Cue, Report) {
+	var b builder
+	var current Cue
+	inBlock, skipping := false, false
+
+	finishBlock := func() {
+		inBlock = false
+		b.add(current)
+	}
+
+	for i, raw := range splitLines(text) {
+		lineNumber := i + 1
+		line := strings.TrimSpace(raw)
+		if strings.Contains(line, "-->") {
+			// A timing line starts a new block even without a blank line before it; the
+			// previous block's last line is then this block's cue number
+			if inBlock {
+				if n := len(current.Lines); n > 0 && isCueNumber(current.Lines[n-1]) {
+					current.Lines = current.Lines[:n-1]
+				}
+				finishBlock()
+			}
+			skipping = false
+			start, end, err := parseTiming(line)
+			if err != nil {
+				b.skip(lineNumber, "%v", err)
+				skipping = true
+				continue
+			}
+			current = Cue{Start: start, End: end, Line: lineNumber}
+			inBlock = true
+			continue
+		}
+		switch {
+		case line == "":
+			// End of a subtitle block
+			if inBlock {
+				finishBlock()
+			}
+			skipping = false
+		case skipping:
+		case inBlock:
+			current.Lines = append(current.Lines, line)
+		case !isCueNumber(line):
+			// Anything but the cue number outside a block is stray text
+			b.skip(lineNumber, "text outside a subtitle block: %q", line)
+			skipping = true
+		}
+	}
+	// Keep the last subtitle block if the file doesn't end with a blank line
+	if inBlock {
+		finishBlock()
+	}
+	return b.finish(FormatSRT)
+}
@@ -0,0 +1,239 @@
+// Package subtitle reads subtitle files (SRT, WebVTT and ASS/SSA) into timed cues. The
+// parsers are forgiving about real-world files and never fail outright: a block they can't
+// read is skipped and noted in the Report, and every cue they return starts at or after 0
+// and ends at or after its start.
+package subtitle
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Cue is one timed subtitle
+type Cue struct {
+	Index int      // Position among the cues read, starting at 1
+	Start float64  // Start time in seconds
+	End   float64  // End time in seconds
+	Lines []string // Text lines as written in the file, markup included
+	Line  int      // Line of the file the cue's timing is on
+}
+
+// Format is a subtitle file format
+type Format string
+
+const (
+	FormatSRT Format = "srt" // SubRip
+	FormatVTT Format = "vtt" // WebVTT
+	FormatASS Format = "ass" // Advanced SubStation Alpha, and the older SSA
+)
+
+// Report describes how well a subtitle file parsed
+type Report struct {
+	Format      Format
+	Cues        int      // Cues read
+	Skipped     int      // Ill-formed blocks left out
+	Overlapping int      // Cues that start before an earlier one ends (kept as they are)
+	Problems    []string // Why each block was skipped, with its line number
+}
+
+// String summarizes the skipped blocks, listing the first few problems
+func (r Report) String() string {
+	const shown = 5
+	summary := fmt.Sprintf("Skipped %d ill-formed subtitle block(s)", r.Skipped)
+	if len(r.Problems) == 0 {
+		return summary
+	}
+	summary += ":\n- " + strings.Join(r.Problems[:min(len(r.Problems), shown)], "\n- ")
+	if len(r.Problems) > shown {
+		summary += fmt.Sprintf("\n- ...and %d more", len(r.Problems)-shown)
+	}
+	return summary
+}
+
+// DetectFormat tells the format from a file's contents: WebVTT files start with "WEBVTT"
+// and ASS/SSA files have a [Script Info] or [Events] section. Anything else is read as SRT.
+func DetectFormat(text string) Format {
+	trimmed := strings.TrimLeft(text, "\uFEFF \t\r\n")
+	if strings.HasPrefix(trimmed, "WEBVTT") {
+		return FormatVTT
+	}
+	lower := strings.ToLower(text)
+	if strings.HasPrefix(trimmed, "[") && (strings.Contains(lower, "[script info]") || strings.Contains(lower, "[events]")) {
+		return FormatASS
+	}
+	return FormatSRT
+}
+
+// Parse reads cues from decoded subtitle text in any supported format
+func Parse(text string) ([]Cue, Report) {
+	switch DetectFormat(text) {
+	case FormatVTT:
+		return ParseVTT(text)
+	case FormatASS:
+		return ParseASS(text)
+	}
+	return ParseSRT(text)
+}
+
+// splitLines splits text into lines, accepting LF, CRLF and CR line endings and dropping
+// byte order marks, which files joined together can carry in the middle
+func splitLines(text string) []string {
+	// Most files need none of these, so skip the copies they'd make
+	if strings.IndexByte(text, '\r') >= 0 {
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+		text = strings.ReplaceAll(text, "\r", "\n")
+	}
+	if strings.Contains(text, "\uFEFF") {
+		text = strings.ReplaceAll(text, "\uFEFF", "")
+	}
+	return strings.Split(text, "\n")
+}
+
+// maxHours bounds timestamps so the arithmetic can't overflow on garbage input
+const maxHours = 100000
+
+// ParseTimestamp converts a subtitle timestamp to seconds. It takes SRT's "01:02:03,456",
+// WebVTT's "01:02:03.456" or "02:03.456" and ASS's "1:02:03.45": hours may have any number
+// of digits, and a fraction of one to three digits is read as a decimal ("5" is 500 ms).
+func ParseTimestamp(timestamp string) (float64, error) {
+	clock, fraction, _ := strings.Cut(strings.Replace(strings.TrimSpace(timestamp), ",", ".", 1), ".")
+	parts := strings.Split(clock, ":")
+	if len(parts) < 2 || len(parts) > 3 || len(fraction) > 3 {
+		return 0, fmt.Errorf("failed to parse time %s: expected HH:MM:SS,mmm", timestamp)
+	}
+	if len(parts) == 2 {
+		parts = append([]string{"0"}, parts...)
+	}
+	var fields [4]int
+	for i, part := range append(parts, (fraction + "000")[:3]) {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part == "" || part[0] == '+' {
+			return 0, fmt.Errorf("failed to parse time %s: %q is not a number", timestamp, part)
+		}
+		fields[i] = n
+	}
+	if fields[0] > maxHours {
+		return 0, fmt.Errorf("failed to parse time %s: more than %d hours", timestamp, maxHours)
+	}
+	if fields[1] > 59 || fields[2] > 59 {
+		return 0, fmt.Errorf("failed to parse time %s: minutes and seconds must be below 60", timestamp)
+	}
+	ms := ((fields[0]*60+fields[1])*60+fields[2])*1000 + fields[3]
+	return float64(ms) / 1000, nil
+}
+
+// formatTime formats seconds like an SRT timestamp, for problem reports
+func formatTime(seconds float64) string {
+	ms := int(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// parseTiming reads an SRT or WebVTT timing line's start and end; anything after the end
+// time (like SRT position coordinates or WebVTT cue settings) is ignored
+func parseTiming(line string) (start, end float64, err error) {
+	from, to, ok := splitTiming(line)
+	if !ok {
+		return 0, 0, fmt.Errorf("unreadable timing %q", line)
+	}
+	if start, err = ParseTimestamp(from); err != nil {
+		return 0, 0, err
+	}
+	if end, err = ParseTimestamp(to); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// splitTiming picks the two timestamps out of a timing line without a regular expression,
+// since the parsers try it on every line that might start a cue
+func splitTiming(line string) (from, to string, ok bool) {
+	n := timestampLength(line)
+	if n == 0 {
+		return "", "", false
+	}
+	from, rest := line[:n], strings.TrimLeft(line[n:], " \t")
+	rest, found := strings.CutPrefix(rest, "-->")
+	if !found {
+		return "", "", false
+	}
+	rest = strings.TrimLeft(rest, " \t")
+	if n = timestampLength(rest); n == 0 {
+		return "", "", false
+	}
+	if n < len(rest) && rest[n] != ' ' && rest[n] != '\t' {
+		return "", "", false
+	}
+	return from, rest[:n], true
+}
+
+// timestampLength returns how many bytes at the start of s look like a timestamp: digits,
+// one or two groups of ":" and one or two digits, then an optional fraction of up to three
+// digits after "," or ".". It returns 0 if s doesn't start with one.
+func timestampLength(s string) int {
+	i := digitRun(s, 0, len(s))
+	if i == 0 {
+		return 0
+	}
+	groups := 0
+	for groups < 2 && i < len(s) && s[i] == ':' {
+		j := digitRun(s, i+1, 2)
+		if j == i+1 {
+			break
+		}
+		i = j
+		groups++
+	}
+	if groups == 0 {
+		return 0
+	}
+	if i < len(s) && (s[i] == ',' || s[i] == '.') {
+		if j := digitRun(s, i+1, 3); j > i+1 {
+			i = j
+		}
+	}
+	return i
+}
+
+// digitRun returns the index after at most limit ASCII digits starting at s[from]
+func digitRun(s string, from, limit int) int {
+	i := from
+	for i < len(s) && i-from < limit && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+// builder collects cues and the report as a parser goes
+type builder struct {
+	cues    []Cue
+	report  Report
+	lastEnd float64
+}
+
+// skip records a block that couldn't be read
+func (b *builder) skip(line int, format string, args ...any) {
+	b.report.Skipped++
+	b.report.Problems = append(b.report.Problems, fmt.Sprintf("line %d: ", line)+fmt.Sprintf(format, args...))
+}
+
+// add keeps a cue unless it ends before it starts
+func (b *builder) add(cue Cue) {
+	if cue.End < cue.Start {
+		b.skip(cue.Line, "the cue ends (%s) before it starts (%s)", formatTime(cue.End), formatTime(cue.Start))
+		return
+	}
+	if len(b.cues) > 0 && cue.Start < b.lastEnd {
+		b.report.Overlapping++
+	}
+	b.lastEnd = max(b.lastEnd, cue.End)
+	cue.Index = len(b.cues) + 1
+	b.cues = append(b.cues, cue)
+}
+
+// finish returns the cues and the report
+func (b *builder) finish(format Format) ([]Cue, Report) {
+	b.report.Format = format
+	b.report.Cues = len(b.cues)
+	return b.cues, b.report
+}
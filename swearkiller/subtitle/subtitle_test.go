@@ -0,0 +1,250 @@
+package subtitle
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// checkCues fails the test unless every cue is sane: numbered in order, starting at or
+// after 0 and ending at or after its start, with no empty lines
+func checkCues(t *testing.T, cues []Cue, report Report) {
+	t.Helper()
+	for i, cue := range cues {
+		if cue.Index != i+1 {
+			t.Errorf("cue %d has index %d", i+1, cue.Index)
+		}
+		if math.IsNaN(cue.Start) || math.IsNaN(cue.End) || cue.Start < 0 || cue.End < cue.Start {
+			t.Errorf("cue %d has bad timing %v --> %v", cue.Index, cue.Start, cue.End)
+		}
+		if cue.Start > maxHours*3600+3599.999 || cue.End > maxHours*3600+3599.999 {
+			t.Errorf("cue %d runs past %d hours: %v --> %v", cue.Index, maxHours, cue.Start, cue.End)
+		}
+		for _, line := range cue.Lines {
+			if strings.TrimSpace(line) == "" || line != strings.TrimSpace(line) {
+				t.Errorf("cue %d has an empty or untrimmed line %q", cue.Index, line)
+			}
+		}
+	}
+	if report.Cues != len(cues) {
+		t.Errorf("report counts %d cues, got %d", report.Cues, len(cues))
+	}
+	if report.Skipped != len(report.Problems) {
+		t.Errorf("report counts %d skipped blocks but lists %d problems", report.Skipped, len(report.Problems))
+	}
+}
+
+// wantCue is the part of a cue the table tests compare
+type wantCue struct {
+	start, end float64
+	lines      []string
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{"00:00:01,000", 1, false},
+		{"01:02:03,456", 3723.456, false},
+		{"100:00:01,000", 360001, false},
+		{"00:00:01.5", 1.5, false},
+		{"0:00:01.50", 1.5, false},
+		{"02:03.456", 123.456, false},
+		{"00:00:01", 1, false},
+		{" 00:00:01,000 ", 1, false},
+		{"00:60:00,000", 0, true},
+		{"00:00:60,000", 0, true},
+		{"00:00:01,0000", 0, true},
+		{"1", 0, true},
+		{"", 0, true},
+		{"::", 0, true},
+		{"aa:bb:cc,ddd", 0, true},
+		{"-1:00:00,000", 0, true},
+		{"+1:00:00,000", 0, true},
+		{"1:2:3:4", 0, true},
+		{"99999999999999999999:00:00,000", 0, true},
+		{"100001:00:00,000", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimestamp(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTimestamp(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("ParseTimestamp(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Format
+	}{
+		{"1\n00:00:01,000 --> 00:00:02,000\nHello\n", FormatSRT},
+		{"WEBVTT\n\n00:01.000 --> 00:02.000\nHello\n", FormatVTT},
+		{"\uFEFFWEBVTT - title\n", FormatVTT},
+		{"[Script Info]\nTitle: x\n\n[Events]\n", FormatASS},
+		{"\n[Events]\nDialogue: 0,0:00:01.00,0:00:02.00,,,0,0,0,,Hi\n", FormatASS},
+		{"[Music plays]\n00:00:01,000 --> 00:00:02,000\n", FormatSRT},
+		{"", FormatSRT},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat(tt.in); got != tt.want {
+			t.Errorf("DetectFormat(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		want        []wantCue
+		skipped     int
+		overlapping int
+	}{
+		{
+			name: "srt",
+			in:   "1\n00:00:01,000 --> 00:00:02,000\nHello\nthere\n\n2\n00:00:03,000 --> 00:00:04,000\nBye\n",
+			want: []wantCue{{1, 2, []string{"Hello", "there"}}, {3, 4, []string{"Bye"}}},
+		},
+		{
+			name: "srt with CRLF, BOM and no final newline",
+			in:   "\uFEFF1\r\n00:00:01,000 --> 00:00:02,000\r\nHello\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\nBye",
+			want: []wantCue{{1, 2, []string{"Hello"}}, {3, 4, []string{"Bye"}}},
+		},
+		{
+			name: "srt with old Mac line endings",
+			in:   "1\r00:00:01,000 --> 00:00:02,000\rHello\r\r",
+			want: []wantCue{{1, 2, []string{"Hello"}}},
+		},
+		{
+			name: "srt without blank lines between blocks",
+			in:   "1\n00:00:01,000 --> 00:00:02,000\nHello\n2\n00:00:03,000 --> 00:00:04,000\nBye\n",
+			want: []wantCue{{1, 2, []string{"Hello"}}, {3, 4, []string{"Bye"}}},
+		},
+		{
+			name: "srt with a long timestamp, dotted milliseconds and position coordinates",
+			in:   "1\n100:00:01.5 --> 100:00:02,000 X1:10 X2:20\nHello\n",
+			want: []wantCue{{360001.5, 360002, []string{"Hello"}}},
+		},
+		{
+			name:    "srt with a broken timing line",
+			in:      "1\n00:00:01,000 -> 00:00:02,000\nLost\n\n2\n00:00:03,000 --> 00:00:04,000\nKept\n",
+			want:    []wantCue{{3, 4, []string{"Kept"}}},
+			skipped: 1,
+		},
+		{
+			name:    "srt with an impossible time",
+			in:      "1\n00:61:00,000 --> 00:62:00,000\nLost\n\n2\n00:00:03,000 --> 00:00:04,000\nKept\n",
+			want:    []wantCue{{3, 4, []string{"Kept"}}},
+			skipped: 1,
+		},
+		{
+			name:    "srt cue that ends before it starts",
+			in:      "1\n00:00:09,000 --> 00:00:08,000\nBackwards\n\n2\n00:00:10,000 --> 00:00:11,000\nKept\n",
+			want:    []wantCue{{10, 11, []string{"Kept"}}},
+			skipped: 1,
+		},
+		{
+			name:    "srt with stray text",
+			in:      "Subtitles by someone\nwww.example.com\n\n1\n00:00:01,000 --> 00:00:02,000\nHello\n",
+			want:    []wantCue{{1, 2, []string{"Hello"}}},
+			skipped: 1,
+		},
+		{
+			name:        "srt with overlapping cues",
+			in:          "1\n00:00:01,000 --> 00:00:05,000\nOne\n\n2\n00:00:02,000 --> 00:00:03,000\nTwo\n\n3\n00:00:04,000 --> 00:00:06,000\nThree\n",
+			want:        []wantCue{{1, 5, []string{"One"}}, {2, 3, []string{"Two"}}, {4, 6, []string{"Three"}}},
+			overlapping: 2,
+		},
+		{
+			name: "srt with an empty cue",
+			in:   "1\n00:00:01,000 --> 00:00:02,000\n\n2\n00:00:03,000 --> 00:00:04,000\nHi\n",
+			want: []wantCue{{1, 2, nil}, {3, 4, []string{"Hi"}}},
+		},
+		{
+			name: "empty file",
+			in:   "",
+		},
+		{
+			name: "vtt",
+			in:   "WEBVTT - a title\nKind: captions\n\nNOTE this is a comment\nover two lines\n\nSTYLE\n::cue { color: red }\n\nintro\n00:01.000 --> 00:02.500 align:start position:10%\n<v Bob>Hello</v>\n\n01:00:03.000 --> 01:00:04.000\nBye\n",
+			want: []wantCue{{1, 2.5, []string{"<v Bob>Hello</v>"}}, {3603, 3604, []string{"Bye"}}},
+		},
+		{
+			name:    "vtt with a block missing its timing",
+			in:      "WEBVTT\n\nid\nno timing here\n\n00:03.000 --> 00:04.000\nKept\n",
+			want:    []wantCue{{3, 4, []string{"Kept"}}},
+			skipped: 1,
+		},
+		{
+			name:    "vtt with a broken timing",
+			in:      "WEBVTT\n\n00:xx.000 --> 00:02.000\nLost\n\n00:03.000 --> 00:02.000\nBackwards\n\n00:05.000 --> 00:06.000\nKept\n",
+			want:    []wantCue{{5, 6, []string{"Kept"}}},
+			skipped: 2,
+		},
+		{
+			name: "ass",
+			in: "[Script Info]\nTitle: Test\nScriptType: v4.00+\n\n[V4+ Styles]\nFormat: Name, Fontname\nStyle: Default,Arial\n\n[Events]\n" +
+				"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+				"Comment: 0,0:00:00.00,0:00:01.00,Default,,0,0,0,,Not shown\n" +
+				"Dialogue: 0,0:00:01.00,0:00:02.50,Default,,0,0,0,,{\\i1}Hello{\\i0}, you\\Nthere\n" +
+				"Dialogue: 0,0:00:03.00,0:00:04.00,Default,,0,0,0,,{\\p1}m 0 0 l 100 0 100 100{\\p0}Sign\\htext\n",
+			want: []wantCue{{1, 2.5, []string{"{\\i1}Hello{\\i0}, you", "there"}}, {3, 4, []string{"{\\p0}Sign text"}}},
+		},
+		{
+			name: "ssa with a reordered format",
+			in:   "[Script Info]\n\n[Events]\nFormat: Marked, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\nDialogue: Marked=0,0:00:01.00,0:00:02.00,*Default,NTP,0000,0000,0000,,Hello\n",
+			want: []wantCue{{1, 2, []string{"Hello"}}},
+		},
+		{
+			name:    "ass with broken dialogue lines",
+			in:      "[Script Info]\n[Events]\nFormat: Layer, Start, End, Style, Text\nDialogue: 0,0:00:01.00\nDialogue: 0,bad,0:00:02.00,Default,Lost\nDialogue: 0,0:00:03.00,0:00:04.00,Default,Kept\n",
+			want:    []wantCue{{3, 4, []string{"Kept"}}},
+			skipped: 2,
+		},
+		{
+			name:    "ass with a format missing the text field",
+			in:      "[Script Info]\n[Events]\nFormat: Layer, Start, End\nDialogue: 0,0:00:01.00,0:00:02.00\n",
+			skipped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cues, report := Parse(tt.in)
+			checkCues(t, cues, report)
+			if len(cues) != len(tt.want) {
+				t.Fatalf("got %d cues, want %d: %+v", len(cues), len(tt.want), cues)
+			}
+			for i, want := range tt.want {
+				got := cues[i]
+				if got.Start != want.start || got.End != want.end || !slices.Equal(got.Lines, want.lines) {
+					t.Errorf("cue %d = %v --> %v %q, want %v --> %v %q", i+1, got.Start, got.End, got.Lines, want.start, want.end, want.lines)
+				}
+			}
+			if report.Skipped != tt.skipped {
+				t.Errorf("skipped %d block(s), want %d: %v", report.Skipped, tt.skipped, report.Problems)
+			}
+			if report.Overlapping != tt.overlapping {
+				t.Errorf("%d overlapping cue(s), want %d", report.Overlapping, tt.overlapping)
+			}
+		})
+	}
+}
+
+func TestReportString(t *testing.T) {
+	report := Report{Skipped: 7}
+	for i := range 7 {
+		report.Problems = append(report.Problems, "line "+string(rune('1'+i))+": bad")
+	}
+	got := report.String()
+	if !strings.HasPrefix(got, "Skipped 7 ill-formed subtitle block(s):") || !strings.HasSuffix(got, "...and 2 more") {
+		t.Errorf("unexpected summary:\n%s", got)
+	}
+}
@@ -0,0 +1,61 @@
+package subtitle
+
+import "strings"
+
+// ParseVTT reads WebVTT cues. NOTE, STYLE and REGION blocks are passed over, cue
+// identifiers and settings are ignored, and a block without a readable timing line is
+// skipped.
+func ParseVTT(text string) ([]Cue, Report) {
+	var b builder
+	var block []string
+	blockStart := 0
+	header := true
+
+	finishBlock := func() {
+		lines := block
+		block = nil
+		if len(lines) == 0 {
+			return
+		}
+		if header {
+			// The WEBVTT line and any header text that follows it
+			header = false
+			if strings.HasPrefix(lines[0], "WEBVTT") {
+				return
+			}
+		}
+		first := strings.Fields(lines[0])
+		if len(first) > 0 && (first[0] == "NOTE" || first[0] == "STYLE" || first[0] == "REGION") {
+			return
+		}
+		// The timing is on the first line, or the second after a cue identifier
+		timing := 0
+		if !strings.Contains(lines[0], "-->") {
+			timing = 1
+		}
+		if timing >= len(lines) || !strings.Contains(lines[timing], "-->") {
+			b.skip(blockStart, "no timing line in block starting %q", lines[0])
+			return
+		}
+		start, end, err := parseTiming(lines[timing])
+		if err != nil {
+			b.skip(blockStart+timing, "%v", err)
+			return
+		}
+		b.add(Cue{Start: start, End: end, Lines: lines[timing+1:], Line: blockStart + timing})
+	}
+
+	for i, raw := range splitLines(text) {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			finishBlock()
+			continue
+		}
+		if len(block) == 0 {
+			blockStart = i + 1
+		}
+		block = append(block, line)
+	}
+	finishBlock()
+	return b.finish(FormatVTT)
+}
@@ -0,0 +1,85 @@
+package swearkiller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// SegmentsTag is the metadata tag clean outputs carry listing the segments censored in
+// them, as JSON like [{"start":12.5,"end":13.2}]
+const SegmentsTag = "SWEAR_KILLER_SEGMENTS"
+
+// segmentsTagArgs returns the FFmpeg options that write the segments into output's
+// metadata. MP4-style files only keep tags FFmpeg knows unless told otherwise.
+func segmentsTagArgs(output string, segments []Segment) []string {
+	rounded := make([]Segment, len(segments))
+	for i, seg := range segments {
+		rounded[i] = Segment{Start: roundMillis(seg.Start), End: roundMillis(seg.End), Action: seg.Action}
+	}
+	data, err := json.Marshal(rounded)
+	if err != nil {
+		return nil
+	}
+	args := []string{"-metadata", SegmentsTag + "=" + string(data)}
+	if isMP4Family(output) || strings.EqualFold(filepath.Ext(output), ".m4a") {
+		args = append(args, "-movflags", "+use_metadata_tags")
+	}
+	return args
+}
+
+// EmbeddedSegments reads the segments a clean output says were censored in it. It reports
+// false for files Swear Killer didn't write.
+func EmbeddedSegments(ctx context.Context, video string) ([]Segment, bool, error) {
+	data, err := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-print_format", "json", "-show_entries", "format_tags", video).Output()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read metadata: %v", err)
+	}
+	var probe struct {
+		Format struct {
+			Tags map[string]string `json:"tags"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, false, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+	// Matroska keeps tag names as written, MP4 and ID3 may change their case
+	for key, value := range probe.Format.Tags {
+		if !strings.EqualFold(key, SegmentsTag) {
+			continue
+		}
+		var segments []Segment
+		if err := json.Unmarshal([]byte(value), &segments); err != nil {
+			return nil, true, fmt.Errorf("unreadable %s tag: %v", SegmentsTag, err)
+		}
+		return segments, true, nil
+	}
+	return nil, false, nil
+}
+
+// IsCleanName reports whether path is named like a clean output or a preview of one, as
+// in "movie-CLEAN.mp4", "movie-CLEAN (2).mp4" or "movie-CLEAN-PREVIEW.mp4"
+func IsCleanName(path string) bool {
+	path = withoutRenameSuffix(path)
+	stem := strings.ToUpper(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	return strings.HasSuffix(stem, "-CLEAN") || strings.HasSuffix(stem, "-CLEAN-PREVIEW")
+}
+
+// CleanedWarning returns a warning if video is already a clean output, so it isn't cleaned
+// twice by mistake, or "" if it isn't. The segments tag is checked first, then the name,
+// which catches outputs of older versions and files whose tags were stripped.
+func CleanedWarning(ctx context.Context, video string) string {
+	segments, found, err := EmbeddedSegments(ctx, video)
+	switch {
+	case found && err != nil:
+		return "This video was already cleaned by Swear Killer; you may want to clean the original instead"
+	case found:
+		return fmt.Sprintf("This video was already cleaned by Swear Killer (%d segment(s) censored); you may want to clean the original instead", len(segments))
+	case IsCleanName(video):
+		return "This video is named like a clean output (-CLEAN), so it may already be cleaned; you may want to clean the original instead"
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package swearkiller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TimelineMark is one censored segment on the GUI timeline, with the matches that caused it
+type TimelineMark struct {
+	Segment Segment
+	Matches []Match // Subtitle matches inside the segment; none for bleeps found in the audio
+}
+
+// Label describes the mark for the timeline's hover text: its time, action, the words
+// matched and the subtitle lines they were found in
+func (m TimelineMark) Label() string {
+	label := fmt.Sprintf("%s - %s (%s)", FormatTimestamp(m.Segment.Start), FormatTimestamp(m.Segment.End), m.Segment.EffectiveAction())
+	if len(m.Matches) == 0 {
+		return label + ": found in the audio, not the subtitle"
+	}
+	var words, lines []string
+	for _, match := range m.Matches {
+		for _, word := range match.Words {
+			if !slices.Contains(words, word) {
+				words = append(words, word)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%q", match.Cue.Text))
+	}
+	return label + ": " + strings.Join(words, ", ") + "\n" + strings.Join(lines, "\n")
+}
+
+// TimelineMarks pairs each segment with the matches that fall inside it once offset is
+// applied, in segment order
+func TimelineMarks(segments []Segment, matches []Match, offset float64) []TimelineMark {
+	marks := make([]TimelineMark, len(segments))
+	for i, seg := range segments {
+		marks[i].Segment = seg
+		for _, m := range matches {
+			start, end := m.Cue.Start+offset, m.Cue.End+offset
+			if start < seg.End && end > seg.Start {
+				marks[i].Matches = append(marks[i].Matches, m)
+			}
+		}
+	}
+	return marks
+}
+
+// MarkAt returns the index of the mark at t seconds, or -1 if there is none. Marks within
+// tolerance seconds count too, so very short segments can still be pointed at; the
+// nearest wins.
+func MarkAt(marks []TimelineMark, t, tolerance float64) int {
+	found, best := -1, tolerance
+	for i, mark := range marks {
+		var distance float64
+		switch {
+		case t < mark.Segment.Start:
+			distance = mark.Segment.Start - t
+		case t > mark.Segment.End:
+			distance = t - mark.Segment.End
+		}
+		if distance <= best {
+			found, best = i, distance
+		}
+	}
+	return found
+}
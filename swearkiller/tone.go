@@ -0,0 +1,116 @@
+package swearkiller
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+)
+
+// ToneOptions controls bleep tone detection
+type ToneOptions struct {
+	Frequency   float64 // Bleep frequency in Hz (broadcast bleeps are usually 1000 Hz)
+	MinDuration float64 // Ignore tones shorter than this many seconds
+	MaxDuration float64 // Only scan this many seconds from the start (0 = whole file)
+}
+
+// DefaultToneOptions detects standard 1 kHz bleeps of at least 150 ms
+var DefaultToneOptions = ToneOptions{Frequency: 1000, MinDuration: 0.15}
+
+const (
+	toneSampleRate = 8000
+	toneWindow     = 0.02 // Seconds of audio analyzed at a time
+	toneMinRatio   = 0.5  // Share of the window's energy that must sit at the bleep frequency
+	toneMinLevel   = 0.01 // Minimum RMS level (about -40 dBFS) so near-silence isn't counted
+)
+
+// DetectTones decodes the first audio track of a video with FFmpeg and returns segments
+// where a steady tone at the bleep frequency is playing
+func DetectTones(ctx context.Context, videoPath string, opts ToneOptions) ([]Segment, error) {
+	args := []string{"-v", "error", "-i", videoPath}
+	if opts.MaxDuration > 0 {
+		args = append(args, "-t", fmt.Sprintf("%.3f", opts.MaxDuration))
+	}
+	args = append(args, "-map", "0:a:0", "-ac", "1", "-ar", fmt.Sprint(toneSampleRate), "-f", "s16le", "-")
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting FFmpeg: %v", err)
+	}
+
+	segments, scanErr := detectTonesPCM(bufio.NewReader(stdout), toneSampleRate, opts)
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %v", err)
+	}
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	return segments, nil
+}
+
+// detectTonesPCM scans 16-bit little-endian mono PCM for tone segments using the Goertzel algorithm
+func detectTonesPCM(r io.Reader, sampleRate int, opts ToneOptions) ([]Segment, error) {
+	windowSize := int(float64(sampleRate) * toneWindow)
+	coeff := 2 * math.Cos(2*math.Pi*opts.Frequency/float64(sampleRate))
+	buf := make([]byte, windowSize*2)
+
+	var segments []Segment
+	var toneStart float64
+	inTone := false
+	window := 0
+
+	closeTone := func(end float64) {
+		if end-toneStart >= opts.MinDuration {
+			// Pad by one window on each side so the edges of the bleep are covered too
+			start := toneStart - toneWindow
+			if start < 0 {
+				start = 0
+			}
+			segments = append(segments, Segment{Start: start, End: end + toneWindow})
+		}
+		inTone = false
+	}
+
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, fmt.Errorf("error reading audio: %v", err)
+		}
+
+		var s1, s2, energy float64
+		for i := 0; i < windowSize; i++ {
+			x := float64(int16(binary.LittleEndian.Uint16(buf[i*2:]))) / 32768.0
+			energy += x * x
+			s0 := x + coeff*s1 - s2
+			s2, s1 = s1, s0
+		}
+		power := s1*s1 + s2*s2 - coeff*s1*s2
+
+		// For a pure tone, power is about (N/2) times the window energy
+		isTone := false
+		if rms := math.Sqrt(energy / float64(windowSize)); rms >= toneMinLevel {
+			isTone = power/(energy*float64(windowSize)/2) >= toneMinRatio
+		}
+
+		at := float64(window) * toneWindow
+		if isTone && !inTone {
+			toneStart = at
+			inTone = true
+		} else if !isTone && inTone {
+			closeTone(at)
+		}
+		window++
+	}
+	if inTone {
+		closeTone(float64(window) * toneWindow)
+	}
+	return segments, nil
+}
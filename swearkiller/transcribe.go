@@ -0,0 +1,322 @@
+package swearkiller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultWhisperCommand is the whisper.cpp command-line program used for transcription
+const DefaultWhisperCommand = "whisper-cli"
+
+// DefaultChunkSeconds is how much audio is transcribed at a time. Each finished chunk is
+// cached, so a crash loses at most one chunk's work.
+const DefaultChunkSeconds = 300.0
+
+// WhisperModelSizes are the whisper.cpp models from fastest to most accurate
+var WhisperModelSizes = []string{"tiny", "base", "small", "medium", "large-v3"}
+
+// DefaultModelSize is a good balance of speed and accuracy for dialogue
+const DefaultModelSize = "base"
+
+// whisperRealtimeFactors are rough transcription times per second of audio for each model
+// on a recent desktop CPU; a GPU is about ten times faster
+var whisperRealtimeFactors = map[string]float64{
+	"tiny":     0.04,
+	"base":     0.08,
+	"small":    0.25,
+	"medium":   0.7,
+	"large-v3": 1.5,
+}
+
+// gpuSpeedup is roughly how much faster Whisper runs on a GPU than on the CPU
+const gpuSpeedup = 10.0
+
+// TranscribeOptions configures speech-to-text for videos without subtitles
+type TranscribeOptions struct {
+	Command      string  // whisper.cpp program (empty = DefaultWhisperCommand)
+	Model        string  // Path to a ggml model file; overrides ModelSize and ModelDir
+	ModelSize    string  // One of WhisperModelSizes (empty = DefaultModelSize)
+	ModelDir     string  // Folder with ggml-<size>.bin model files (empty = WhisperModelDir())
+	Device       string  // "auto" (GPU if whisper.cpp was built with one), "cpu", or "gpu:N" for the Nth GPU
+	Language     string  // Spoken language code like "en", or "auto" to let Whisper detect it (empty = auto)
+	BeamSize     int     // Beam search width; larger is slower and a little more accurate (0 = Whisper's default)
+	Threads      int     // CPU threads for Whisper (0 = half the CPUs, leaving room for other work)
+	ChunkSeconds float64 // Audio transcribed at a time (0 = DefaultChunkSeconds)
+	CacheDir     string  // Where finished chunks are kept (empty = TranscriptCacheDir())
+}
+
+// WhisperModelDir returns the default folder for whisper.cpp model files
+func WhisperModelDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "swear-killer", "models")
+}
+
+// ModelPath returns the model file the options select
+func (o TranscribeOptions) ModelPath() string {
+	if o.Model != "" {
+		return o.Model
+	}
+	size, dir := o.ModelSize, o.ModelDir
+	if size == "" {
+		size = DefaultModelSize
+	}
+	if dir == "" {
+		dir = WhisperModelDir()
+	}
+	return filepath.Join(dir, "ggml-"+size+".bin")
+}
+
+// Validate checks the model size, device and beam size
+func (o TranscribeOptions) Validate() error {
+	if o.Model == "" && o.ModelSize != "" {
+		if _, ok := whisperRealtimeFactors[o.ModelSize]; !ok {
+			return fmt.Errorf("unknown Whisper model size %q (available: %s)", o.ModelSize, strings.Join(WhisperModelSizes, ", "))
+		}
+	}
+	if _, _, err := parseWhisperDevice(o.Device); err != nil {
+		return err
+	}
+	if o.BeamSize < 0 {
+		return fmt.Errorf("beam size must be zero or positive")
+	}
+	return nil
+}
+
+// parseWhisperDevice reads a device setting, reporting whether it is CPU-only and which GPU to use
+func parseWhisperDevice(device string) (cpu bool, gpu int, err error) {
+	device = strings.ToLower(strings.TrimSpace(device))
+	switch device {
+	case "", "auto", "gpu":
+		return false, -1, nil
+	case "cpu":
+		return true, -1, nil
+	}
+	if index, ok := strings.CutPrefix(device, "gpu:"); ok {
+		if n, err := strconv.Atoi(index); err == nil && n >= 0 {
+			return false, n, nil
+		}
+	}
+	return false, -1, fmt.Errorf("invalid Whisper device %q (use auto, cpu or gpu:N)", device)
+}
+
+// whisperArgs returns the whisper.cpp options for the model, device, language and beam size
+func (o TranscribeOptions) whisperArgs() []string {
+	language := o.Language
+	if language == "" {
+		language = "auto"
+	}
+	args := []string{"-m", o.ModelPath(), "-l", language, "-t", strconv.Itoa(o.Threads)}
+	if o.BeamSize > 0 {
+		args = append(args, "-bs", strconv.Itoa(o.BeamSize))
+	}
+	if cpu, gpu, _ := parseWhisperDevice(o.Device); cpu {
+		args = append(args, "-ng")
+	} else if gpu >= 0 {
+		args = append(args, "-dev", strconv.Itoa(gpu))
+	}
+	return args
+}
+
+// EstimateTranscription roughly estimates how long a model takes to transcribe audio of
+// the given length on the given device. Real times vary a lot between machines.
+func EstimateTranscription(duration float64, modelSize, device string) time.Duration {
+	factor, ok := whisperRealtimeFactors[modelSize]
+	if !ok {
+		factor = whisperRealtimeFactors[DefaultModelSize]
+	}
+	if cpu, _, _ := parseWhisperDevice(device); !cpu && device != "" && !strings.EqualFold(device, "auto") {
+		factor /= gpuSpeedup
+	}
+	return time.Duration(duration * factor * float64(time.Second)).Round(time.Second)
+}
+
+// TranscriptCacheDir returns the default directory for cached transcription chunks
+func TranscriptCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "swear-killer", "transcripts")
+}
+
+// withDefaults fills in the defaults for unset options
+func (o TranscribeOptions) withDefaults() TranscribeOptions {
+	if o.Command == "" {
+		o.Command = DefaultWhisperCommand
+	}
+	if o.Threads <= 0 {
+		o.Threads = max(runtime.NumCPU()/2, 1)
+	}
+	if o.ModelSize == "" {
+		o.ModelSize = DefaultModelSize
+	}
+	if o.ChunkSeconds <= 0 {
+		o.ChunkSeconds = DefaultChunkSeconds
+	}
+	if o.CacheDir == "" {
+		o.CacheDir = TranscriptCacheDir()
+	}
+	return o
+}
+
+// transcriptCachePath returns the directory holding a media file's cached chunks. It is
+// keyed by the file's content and every option that changes the text, so a different
+// model, language, beam size or chunk size starts over.
+func transcriptCachePath(mediaPath string, opts TranscribeOptions) (string, error) {
+	videoHash, err := QuickHash(mediaPath)
+	if err != nil {
+		return "", err
+	}
+	settings, err := Fingerprint(struct {
+		Video        string
+		Model        string
+		Language     string
+		BeamSize     int
+		ChunkSeconds float64
+	}{videoHash, filepath.Base(opts.ModelPath()), opts.Language, opts.BeamSize, opts.ChunkSeconds})
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(opts.CacheDir, settings[:16]), nil
+}
+
+// Transcribe turns a media file's speech into cues with Whisper, so swears can be found in
+// videos without subtitles. The audio is transcribed in chunks, and each finished chunk is
+// cached: running again after a crash or Ctrl+C picks up at the first unfinished chunk.
+// onProgress, if set, receives the fraction of the audio done after each chunk.
+func Transcribe(ctx context.Context, mediaPath string, opts TranscribeOptions, logFn func(string), onProgress func(float64)) ([]Cue, error) {
+	opts = opts.withDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(opts.ModelPath()); err != nil {
+		return nil, fmt.Errorf("Whisper model not found at %s; download it from the whisper.cpp models page", opts.ModelPath())
+	}
+	if _, err := exec.LookPath(opts.Command); err != nil {
+		return nil, fmt.Errorf("%s not found; install whisper.cpp to transcribe audio", opts.Command)
+	}
+	duration, err := ProbeDuration(ctx, mediaPath)
+	if err != nil || duration <= 0 {
+		return nil, fmt.Errorf("failed to get the media duration: %v", err)
+	}
+	cacheDir, err := transcriptCachePath(mediaPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash media file: %v", err)
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create transcript cache: %v", err)
+	}
+	// Mark the transcript as used, so pruning the cache removes the ones not used for longest
+	now := time.Now()
+	os.Chtimes(cacheDir, now, now)
+
+	var cues []Cue
+	chunks := int((duration + opts.ChunkSeconds - 1) / opts.ChunkSeconds)
+	for i := 0; i < chunks; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		start := float64(i) * opts.ChunkSeconds
+		length := min(opts.ChunkSeconds, duration-start)
+		chunkPath := filepath.Join(cacheDir, "chunk-"+strconv.Itoa(i)+".json")
+
+		chunkCues, err := readCachedChunk(chunkPath)
+		if err == nil {
+			if logFn != nil {
+				logFn(fmt.Sprintf("Chunk %d/%d: using cached transcript", i+1, chunks))
+			}
+		} else {
+			if logFn != nil {
+				logFn(fmt.Sprintf("Chunk %d/%d: transcribing %s-%s", i+1, chunks, FormatTimestamp(start), FormatTimestamp(start+length)))
+			}
+			if chunkCues, err = transcribeChunk(ctx, mediaPath, start, length, opts); err != nil {
+				return nil, fmt.Errorf("chunk %d/%d: %v", i+1, chunks, err)
+			}
+			if err := writeCachedChunk(chunkPath, chunkCues); err != nil {
+				return nil, fmt.Errorf("failed to cache chunk %d: %v", i+1, err)
+			}
+		}
+		cues = append(cues, chunkCues...)
+		if onProgress != nil {
+			onProgress(float64(i+1) / float64(chunks))
+		}
+	}
+	for i := range cues {
+		cues[i].Index = i + 1
+	}
+	return cues, nil
+}
+
+// transcribeChunk extracts one stretch of audio as 16 kHz mono WAV, the format whisper.cpp
+// reads, and transcribes it. Cue times are shifted to the start of the chunk.
+func transcribeChunk(ctx context.Context, mediaPath string, start, length float64, opts TranscribeOptions) ([]Cue, error) {
+	tempDir, err := os.MkdirTemp("", "swear-killer-chunk-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	wavPath := filepath.Join(tempDir, "chunk.wav")
+	extract := exec.CommandContext(ctx, "ffmpeg", "-v", "error",
+		"-ss", fmt.Sprintf("%.3f", start), "-t", fmt.Sprintf("%.3f", length), "-i", mediaPath,
+		"-vn", "-ac", "1", "-ar", "16000", "-c:a", "pcm_s16le", "-y", wavPath)
+	if output, err := extract.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to extract audio: %v: %s", err, output)
+	}
+
+	outBase := filepath.Join(tempDir, "chunk")
+	args := append(opts.whisperArgs(), "-f", wavPath, "-osrt", "-of", outBase)
+	whisper := exec.CommandContext(ctx, opts.Command, args...)
+	if output, err := whisper.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("whisper failed: %v: %s", err, output)
+	}
+	cues, err := ReadSRTFile(outBase + ".srt")
+	if err != nil {
+		return nil, err
+	}
+	for i := range cues {
+		cues[i].Start += start
+		cues[i].End += start
+	}
+	return cues, nil
+}
+
+// readCachedChunk loads a finished chunk's cues
+func readCachedChunk(path string) ([]Cue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cues []Cue
+	if err := json.Unmarshal(data, &cues); err != nil {
+		return nil, err
+	}
+	return cues, nil
+}
+
+// writeCachedChunk saves a finished chunk's cues. The file is written under a temporary
+// name first so a crash mid-write never leaves a chunk that looks finished.
+func writeCachedChunk(path string, cues []Cue) error {
+	if cues == nil {
+		cues = []Cue{} // A silent chunk is still finished
+	}
+	data, err := json.Marshal(cues)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,39 @@
+package swearkiller
+
+import "regexp"
+
+// censorMarkerPatterns match signs that a broadcaster already censored the dialogue
+var censorMarkerPatterns = []*regexp.Regexp{
+	// [bleep], (bleeping), [censored], (expletive)
+	regexp.MustCompile(`(?i)[\[\(]\s*(bleep\w*|beep\w*|censored|expletive\w*|muted|inaudible curse)\s*[\]\)]`),
+	// Starred-out words like f***, s**t, a**hole
+	regexp.MustCompile(`(?i)\b[a-z]+\*+[a-z]*|\b[a-z]\*{2,}`),
+	// Grawlix like #$@%
+	regexp.MustCompile(`[#$@%&!*]{4,}`),
+	// A bare "bleep" standing in for a word
+	regexp.MustCompile(`(?i)\bbleep(ed|ing|s)?\b`),
+}
+
+// TVEditReport describes signs of a broadcast edit found in a subtitle
+type TVEditReport struct {
+	Cues []Cue // Cues containing censor markers
+}
+
+// Likely reports whether the subtitle looks like it came from an already-censored source
+func (r TVEditReport) Likely() bool {
+	return len(r.Cues) >= 2
+}
+
+// DetectTVEdit scans subtitle cues for starred-out words and bleep tokens
+func DetectTVEdit(cues []Cue) TVEditReport {
+	var report TVEditReport
+	for _, cue := range cues {
+		for _, pattern := range censorMarkerPatterns {
+			if pattern.MatchString(cue.Text) {
+				report.Cues = append(report.Cues, cue)
+				break
+			}
+		}
+	}
+	return report
+}
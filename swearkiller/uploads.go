@@ -0,0 +1,324 @@
+package swearkiller
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resumable uploads follow the core tus 1.0 protocol (https://tus.io) with the creation and
+// termination extensions, so a video can be pushed over flaky Wi-Fi in chunks and resumed
+// from where it stopped, even after the server restarts.
+
+// tusVersion is the tus protocol version the upload endpoints speak
+const tusVersion = "1.0.0"
+
+// staleUploadAge is how long an unfinished upload is kept before the server deletes it
+const staleUploadAge = 7 * 24 * time.Hour
+
+// uploadIDRe matches upload IDs, which keeps them from naming files outside the uploads directory
+var uploadIDRe = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Upload is a resumable upload as reported by the HTTP API
+type Upload struct {
+	ID       string    `json:"id"`
+	Filename string    `json:"filename"`
+	Length   int64     `json:"length"` // Total size in bytes
+	Offset   int64     `json:"offset"` // Bytes received so far
+	Complete bool      `json:"complete"`
+	Created  time.Time `json:"created"`
+}
+
+// uploadsDir is where unfinished and unclaimed uploads are kept
+func (s *Server) uploadsDir() string {
+	return filepath.Join(s.opts.WorkDir, "uploads")
+}
+
+// uploadPaths returns the data and info files of an upload
+func (s *Server) uploadPaths(id string) (data, info string) {
+	base := filepath.Join(s.uploadsDir(), id)
+	return base + ".bin", base + ".json"
+}
+
+// loadUpload reads an upload's info, with its offset taken from the data received so far
+func (s *Server) loadUpload(id string) (Upload, error) {
+	var upload Upload
+	if !uploadIDRe.MatchString(id) {
+		return upload, os.ErrNotExist
+	}
+	dataPath, infoPath := s.uploadPaths(id)
+	data, err := os.ReadFile(infoPath)
+	if err != nil {
+		return upload, err
+	}
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return upload, fmt.Errorf("corrupt upload info: %v", err)
+	}
+	stat, err := os.Stat(dataPath)
+	if err != nil {
+		return upload, err
+	}
+	upload.Offset = stat.Size()
+	upload.Complete = upload.Offset == upload.Length
+	return upload, nil
+}
+
+// cleanStaleUploads deletes uploads that haven't received anything for a week
+func (s *Server) cleanStaleUploads() {
+	infos, _ := filepath.Glob(filepath.Join(s.uploadsDir(), "*.json"))
+	for _, infoPath := range infos {
+		dataPath := strings.TrimSuffix(infoPath, ".json") + ".bin"
+		stat, err := os.Stat(dataPath)
+		if err != nil || time.Since(stat.ModTime()) > staleUploadAge {
+			os.Remove(dataPath)
+			os.Remove(infoPath)
+		}
+	}
+}
+
+// tusHeaders marks a response as speaking the tus protocol
+func tusHeaders(w http.ResponseWriter) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+// uploadFileName returns the last element of a file name sent by a client, reporting false
+// if nothing usable is left, like for "", "." or "..", which would name the folder itself
+func uploadFileName(name string) (string, bool) {
+	name = filepath.Base(filepath.FromSlash(name))
+	switch name {
+	case ".", "..", string(filepath.Separator):
+		return "", false
+	}
+	return name, true
+}
+
+// handleUploadOptions tells tus clients what the server supports
+func (s *Server) handleUploadOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", "creation,termination")
+	tusHeaders(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCreateUpload starts an upload of Upload-Length bytes. The file name comes from the
+// "filename" key of Upload-Metadata.
+func (s *Server) handleCreateUpload(w http.ResponseWriter, r *http.Request) {
+	tusHeaders(w)
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		writeError(w, http.StatusBadRequest, "Upload-Length must be the size of the file in bytes")
+		return
+	}
+	filename, ok := uploadFileName(parseUploadMetadata(r.Header.Get("Upload-Metadata"))["filename"])
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Upload-Metadata needs a filename")
+		return
+	}
+
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create upload ID: %v", err)
+		return
+	}
+	upload := Upload{ID: hex.EncodeToString(idBytes), Filename: filename, Length: length, Created: time.Now()}
+	dataPath, infoPath := s.uploadPaths(upload.ID)
+	info, _ := json.Marshal(upload)
+	if err := os.WriteFile(dataPath, nil, 0644); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create upload: %v", err)
+		return
+	}
+	if err := os.WriteFile(infoPath, info, 0644); err != nil {
+		os.Remove(dataPath)
+		writeError(w, http.StatusInternalServerError, "failed to create upload: %v", err)
+		return
+	}
+	w.Header().Set("Location", "/api/uploads/"+upload.ID)
+	w.Header().Set("Upload-Offset", "0")
+	w.WriteHeader(http.StatusCreated)
+}
+
+// parseUploadMetadata reads tus Upload-Metadata: comma-separated keys, each followed by a
+// space and its base64-encoded value
+func parseUploadMetadata(header string) map[string]string {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if key != "" && err == nil {
+			metadata[key] = string(value)
+		}
+	}
+	return metadata
+}
+
+// handleUploadOffset reports how much of an upload has arrived, so a client can resume
+func (s *Server) handleUploadOffset(w http.ResponseWriter, r *http.Request) {
+	tusHeaders(w)
+	upload, err := s.loadUpload(r.PathValue("id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleUploadStatus returns an upload as JSON
+func (s *Server) handleUploadStatus(w http.ResponseWriter, r *http.Request) {
+	upload, err := s.loadUpload(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "no upload %s", r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, upload)
+}
+
+// handleUploadChunk appends a chunk at Upload-Offset. The body is streamed to disk as it
+// arrives, so whatever got through before a disconnect is kept and the client resumes
+// from there; a slow disk slows the sender down rather than filling memory.
+func (s *Server) handleUploadChunk(w http.ResponseWriter, r *http.Request) {
+	tusHeaders(w)
+	id := r.PathValue("id")
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		writeError(w, http.StatusUnsupportedMediaType, "chunks must be sent as application/offset+octet-stream")
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Upload-Offset must be a number of bytes")
+		return
+	}
+
+	// One chunk at a time per upload, or two connections could interleave their writes
+	s.mu.Lock()
+	if s.uploading[id] {
+		s.mu.Unlock()
+		writeError(w, http.StatusLocked, "upload %s is already receiving a chunk", id)
+		return
+	}
+	s.uploading[id] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.uploading, id)
+		s.mu.Unlock()
+	}()
+
+	upload, err := s.loadUpload(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "no upload %s", id)
+		return
+	}
+	if offset != upload.Offset {
+		w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		writeError(w, http.StatusConflict, "upload %s is at offset %d, not %d", id, upload.Offset, offset)
+		return
+	}
+
+	dataPath, _ := s.uploadPaths(id)
+	file, err := os.OpenFile(dataPath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to open upload: %v", err)
+		return
+	}
+	_, copyErr := io.Copy(file, http.MaxBytesReader(w, r.Body, upload.Length-upload.Offset))
+	if err := file.Close(); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save upload: %v", err)
+		return
+	}
+	if upload, err = s.loadUpload(id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read upload: %v", err)
+		return
+	}
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	var tooLarge *http.MaxBytesError
+	if errors.As(copyErr, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "the chunk runs past the upload's length of %d bytes", upload.Length)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeleteUpload abandons an upload
+func (s *Server) handleDeleteUpload(w http.ResponseWriter, r *http.Request) {
+	tusHeaders(w)
+	if _, err := s.loadUpload(r.PathValue("id")); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	dataPath, infoPath := s.uploadPaths(r.PathValue("id"))
+	os.Remove(dataPath)
+	os.Remove(infoPath)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// claimUploads moves a job's finished uploads into its directory and points the job at
+// them. The returned func puts them back, for when the job is refused after all.
+func (s *Server) claimUploads(req *JobRequest, jobDir string) (release func(), err error) {
+	type claimed struct{ from, to string }
+	var moved []claimed
+	release = func() {
+		for _, m := range moved {
+			os.Rename(m.to, m.from)
+		}
+	}
+	for _, claim := range []struct {
+		kind string
+		id   string
+		path *string
+	}{{"video", req.VideoUpload, &req.Video}, {"subtitle", req.SubtitleUpload, &req.Subtitle}} {
+		if claim.id == "" {
+			continue
+		}
+		upload, err := s.loadUpload(claim.id)
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("no %s upload %s", claim.kind, claim.id)
+		}
+		if !upload.Complete {
+			release()
+			return nil, fmt.Errorf("the %s upload is not finished (%d of %d bytes)", claim.kind, upload.Offset, upload.Length)
+		}
+		if err := os.MkdirAll(jobDir, 0755); err != nil {
+			release()
+			return nil, err
+		}
+		name, ok := uploadFileName(upload.Filename)
+		if !ok {
+			release()
+			return nil, fmt.Errorf("the %s upload has no usable file name", claim.kind)
+		}
+		dataPath, _ := s.uploadPaths(claim.id)
+		path := filepath.Join(jobDir, name)
+		if err := os.Rename(dataPath, path); err != nil {
+			release()
+			return nil, fmt.Errorf("failed to claim the %s upload: %v", claim.kind, err)
+		}
+		moved = append(moved, claimed{dataPath, path})
+		*claim.path = path
+	}
+	return release, nil
+}
+
+// forgetUploads deletes the info of uploads a job has claimed
+func (s *Server) forgetUploads(req JobRequest) {
+	for _, id := range []string{req.VideoUpload, req.SubtitleUpload} {
+		if uploadIDRe.MatchString(id) {
+			_, infoPath := s.uploadPaths(id)
+			os.Remove(infoPath)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package swearkiller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// VerifyMaxVolume is the loudest peak, in dB, a muted segment may have and still count as silent
+const VerifyMaxVolume = -60.0
+
+// verifyMargin is trimmed from both ends of a segment before measuring, so the AAC
+// encoder's smearing at the mute boundaries isn't mistaken for audio that got through
+const verifyMargin = 0.05
+
+var (
+	meanVolumeRe = regexp.MustCompile(`mean_volume:\s*(-?[\d.]+|-inf) dB`)
+	maxVolumeRe  = regexp.MustCompile(`max_volume:\s*(-?[\d.]+|-inf) dB`)
+)
+
+// VerifyResult is the loudness measured in the output during one muted segment
+type VerifyResult struct {
+	Segment    Segment
+	MeanVolume float64 // RMS loudness in dB
+	MaxVolume  float64 // Peak loudness in dB
+	Silent     bool
+}
+
+// String describes the result for logs
+func (r VerifyResult) String() string {
+	status := "silent"
+	if !r.Silent {
+		status = "NOT SILENT"
+	}
+	return fmt.Sprintf("[%s-%s] %s (mean %.1f dB, peak %.1f dB)",
+		FormatTimestamp(r.Segment.Start), FormatTimestamp(r.Segment.End), status, r.MeanVolume, r.MaxVolume)
+}
+
+// MeasureVolume uses FFmpeg's volumedetect filter to measure the RMS and peak loudness of
+// a stretch of a file's audio
+func MeasureVolume(ctx context.Context, mediaPath string, start, duration float64) (mean, max float64, err error) {
+	cmd := ffmpegCommand(ctx, []string{"-hide_banner", "-nostats",
+		"-ss", fmt.Sprintf("%.3f", start), "-t", fmt.Sprintf("%.3f", duration),
+		"-i", mediaPath, "-vn", "-af", "volumedetect", "-f", "null", "-"})
+	output, err := runTracked(cmd, true)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to measure volume: %v", err)
+	}
+	return parseVolumeDetect(string(output))
+}
+
+// parseVolumeDetect reads the mean and max volume from volumedetect's log output
+func parseVolumeDetect(output string) (mean, max float64, err error) {
+	meanMatch := meanVolumeRe.FindStringSubmatch(output)
+	maxMatch := maxVolumeRe.FindStringSubmatch(output)
+	if meanMatch == nil || maxMatch == nil {
+		return 0, 0, fmt.Errorf("no volume measurement in FFmpeg output (does the file have audio there?)")
+	}
+	return parseDecibels(meanMatch[1]), parseDecibels(maxMatch[1]), nil
+}
+
+// parseDecibels parses a volumedetect level, where "-inf" means digital silence
+func parseDecibels(s string) float64 {
+	if s == "-inf" {
+		return -91.0 // The quietest level volumedetect reports for 16-bit audio
+	}
+	value, _ := strconv.ParseFloat(s, 64)
+	return value
+}
+
+// VerifyOutput measures the output file during every muted segment and reports whether
+// each one is really silent, catching filter mistakes that would let a swear through.
+// Tone segments are skipped since they are meant to be audible; segments past the end of
+// a preview (opts.MaxDuration) are skipped too.
+func VerifyOutput(ctx context.Context, outputPath string, segments []Segment, opts EncodeOptions) ([]VerifyResult, error) {
+	var results []VerifyResult
+	for _, seg := range LimitSegments(MergeSegments(segments), opts.MaxDuration) {
+		if seg.EffectiveAction() != ActionMute {
+			continue
+		}
+		start, duration := seg.Start, seg.End-seg.Start
+		if duration > 4*verifyMargin {
+			start, duration = start+verifyMargin, duration-2*verifyMargin
+		}
+		mean, max, err := MeasureVolume(ctx, outputPath, start, duration)
+		if err != nil {
+			return results, fmt.Errorf("segment at %s: %v", FormatTimestamp(seg.Start), err)
+		}
+		results = append(results, VerifyResult{Segment: seg, MeanVolume: mean, MaxVolume: max, Silent: max <= VerifyMaxVolume})
+	}
+	return results, nil
+}
+
+// FailedVerifications returns the segments that weren't silent
+func FailedVerifications(results []VerifyResult) []VerifyResult {
+	var failed []VerifyResult
+	for _, r := range results {
+		if !r.Silent {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,526 @@
+package swearkiller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultWatchSettle is how many seconds a new file must go unchanged before the watcher
+// cleans it, so files still being copied in are left alone
+const DefaultWatchSettle = 30.0
+
+// maxWatchQueue is how many settled videos may wait for an encode; more wait to be queued
+const maxWatchQueue = 1000
+
+// WatchFolder is a folder the watcher cleans new videos in, subfolders included, and how.
+// The job options are a JobRequest's, like profile and fade; the video, subtitle and
+// output are filled in for each file.
+type WatchFolder struct {
+	Path      string `json:"path"`
+	OutputDir string `json:"output_dir,omitempty"` // Where clean outputs go, in the same subfolders (default: beside each video)
+	Hooks     Hooks  `json:"hooks,omitzero"`       // Commands run before and after each video (default: the config's)
+	JobRequest
+}
+
+// WatchConfig is the watcher's config: the folders and their options
+type WatchConfig struct {
+	Folders []WatchFolder `json:"folders"`
+	Jobs    int           `json:"jobs,omitempty"`   // Videos encoded at the same time (default 1)
+	Settle  float64       `json:"settle,omitempty"` // Seconds a file must go unchanged before it is cleaned (default DefaultWatchSettle)
+	Listen  string        `json:"listen,omitempty"` // Address of the status endpoint, like "127.0.0.1:8091" (empty = none)
+
+	NameTemplate NameTemplate `json:"name_template,omitempty"` // How clean outputs are named (default DefaultNameTemplate)
+
+	Notify NotifyConfig `json:"notify,omitzero"` // Where to send a notification as each video is cleaned or fails
+	Hooks  Hooks        `json:"hooks,omitzero"`  // Commands run before and after each video, for folders without their own
+
+	Refresh LibraryRefresh `json:"refresh,omitzero"` // Media servers told about each clean video
+}
+
+// LoadWatchConfig reads a YAML, TOML or JSON watch config (picked by extension), like
+//
+//	folders:
+//	  - path: /media/kids
+//	    profile: strict
+//	  - path: /media/tv
+//	    profile: mild
+//	    output_dir: /media/clean/tv
+//
+// and checks it, so mistakes stop the watcher before it starts rather than fail each job.
+func LoadWatchConfig(path string) (WatchConfig, error) {
+	var config WatchConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read watch config: %v", err)
+	}
+	// YAML and TOML are turned into JSON so the options keep their JSON names
+	var raw any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		var table map[string]any
+		_, err = toml.Decode(string(data), &table)
+		raw = table
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	default:
+		return config, fmt.Errorf("%s: unknown config format; use .yaml, .toml or .json", path)
+	}
+	if err == nil {
+		data, err = json.Marshal(raw)
+	}
+	if err != nil {
+		return config, fmt.Errorf("%s: %v", path, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return config, fmt.Errorf("%s: %s", path, strings.TrimPrefix(err.Error(), "json: "))
+	}
+	if err := config.validate(filepath.Dir(path)); err != nil {
+		return config, fmt.Errorf("%s: %v", path, err)
+	}
+	return config, nil
+}
+
+// validate checks the config and fills in its defaults. Relative folders are taken from
+// dir, the config's folder, so they mean the same to a service started elsewhere.
+func (c *WatchConfig) validate(dir string) error {
+	if len(c.Folders) == 0 {
+		return fmt.Errorf("no folders to watch")
+	}
+	if c.Jobs == 0 {
+		c.Jobs = 1
+	}
+	if c.Jobs < 1 {
+		return fmt.Errorf("jobs must be at least 1")
+	}
+	if c.Settle == 0 {
+		c.Settle = DefaultWatchSettle
+	}
+	if c.Settle < 0 {
+		return fmt.Errorf("settle must be zero or positive")
+	}
+	if err := c.NameTemplate.Validate(); err != nil {
+		return err
+	}
+	if err := c.Notify.Validate(); err != nil {
+		return fmt.Errorf("notify: %v", err)
+	}
+	if err := c.Refresh.Validate(); err != nil {
+		return fmt.Errorf("refresh: %v", err)
+	}
+	for i := range c.Folders {
+		f := &c.Folders[i]
+		if f.Path == "" {
+			return fmt.Errorf("folder %d has no path", i+1)
+		}
+		path, err := filepath.Abs(resolvePath(dir, f.Path))
+		if err != nil {
+			return fmt.Errorf("folder %s: %v", f.Path, err)
+		}
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return fmt.Errorf("folder %s doesn't exist", f.Path)
+		}
+		f.Path = path
+		if f.OutputDir != "" {
+			if f.OutputDir, err = filepath.Abs(resolvePath(dir, f.OutputDir)); err != nil {
+				return fmt.Errorf("folder %s: %v", f.Path, err)
+			}
+		}
+		if f.Video != "" || f.Subtitle != "" || f.Output != "" {
+			return fmt.Errorf("folder %s: video, subtitle and output are set for each file; use output_dir for where outputs go", f.Path)
+		}
+		if err := validateJobOptions(&f.JobRequest); err != nil {
+			return fmt.Errorf("folder %s: %v", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// resolvePath returns path, taken from dir if it is relative
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// WatchStatus is how far the watcher got with a video
+type WatchStatus string
+
+const (
+	WatchSettling WatchStatus = "settling" // Just arrived, or still being copied in
+	WatchWaiting  WatchStatus = "waiting"  // There's no subtitle beside it yet
+	WatchQueued   WatchStatus = "queued"
+	WatchRunning  WatchStatus = "running"
+	WatchDone     WatchStatus = "done"
+	WatchFailed   WatchStatus = "failed"
+)
+
+// WatchItem is a video the watcher has seen
+type WatchItem struct {
+	Video    string      `json:"video"`
+	Folder   string      `json:"folder"`
+	Profile  string      `json:"profile,omitempty"`
+	Output   string      `json:"output"`
+	Status   WatchStatus `json:"status"`
+	Progress float64     `json:"progress"`
+	Error    string      `json:"error,omitempty"`
+	Updated  time.Time   `json:"updated"`
+
+	folder  *WatchFolder
+	size    int64     // The video's size and modification time at the last look,
+	modTime time.Time // to tell when it has settled
+	changed time.Time // When the video last changed
+}
+
+// WatchReport is the watcher's status, as served by its status endpoint
+type WatchReport struct {
+	Started time.Time           `json:"started"`
+	Folders []WatchedFolder     `json:"folders"`
+	Counts  map[WatchStatus]int `json:"counts"`
+	Items   []WatchItem         `json:"items"` // Most recently updated first
+}
+
+// WatchedFolder is a watched folder as listed in a WatchReport
+type WatchedFolder struct {
+	Path      string `json:"path"`
+	OutputDir string `json:"output_dir,omitempty"`
+	Profile   string `json:"profile,omitempty"`
+}
+
+// Watcher cleans videos as they appear in the folders of a WatchConfig, each with its
+// folder's options
+type Watcher struct {
+	config  WatchConfig
+	swears  []string
+	logFn   func(string)
+	started time.Time
+
+	mu    sync.Mutex
+	items map[string]*WatchItem
+	queue chan *WatchItem
+}
+
+// NewWatcher creates a watcher for a config from LoadWatchConfig. swears is used for
+// folders that don't set their own list, and logFn gets every message.
+func NewWatcher(config WatchConfig, swears []string, logFn func(string)) *Watcher {
+	if len(swears) == 0 {
+		swears = DefaultSwears
+	}
+	return &Watcher{config: config, swears: swears, logFn: logFn, started: time.Now(),
+		items: map[string]*WatchItem{}, queue: make(chan *WatchItem, maxWatchQueue)}
+}
+
+// Run watches the folders until ctx is cancelled, then stops the running encodes, which
+// remove their unfinished outputs. Videos already in the folders without a clean output
+// are cleaned too, so nothing dropped in while the watcher was off is missed.
+func (w *Watcher) Run(ctx context.Context) error {
+	notify, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch folders: %v", err)
+	}
+	defer notify.Close()
+	for _, folder := range w.config.Folders {
+		if err := w.addTree(notify, folder.Path); err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	for range w.config.Jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case item := <-w.queue:
+					w.process(ctx, item)
+				}
+			}
+		}()
+	}
+	defer wg.Wait()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-notify.Events:
+			if !ok {
+				return nil
+			}
+			w.handleEvent(notify, event)
+		case err, ok := <-notify.Errors:
+			if !ok {
+				return nil
+			}
+			w.logFn(fmt.Sprintf("Warning: %v", err))
+		case <-ticker.C:
+			w.settle()
+		}
+	}
+}
+
+// addTree watches dir and its subfolders, hidden ones aside, and notices the files in
+// them, like the contents of a folder copied in
+func (w *Watcher) addTree(notify *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip what can't be read rather than stopping the watcher
+		}
+		if !d.IsDir() {
+			w.notice(path)
+			return nil
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if err := notify.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %v", path, err)
+		}
+		return nil
+	})
+}
+
+// handleEvent notices a file that was created, written or moved in
+func (w *Watcher) handleEvent(notify *fsnotify.Watcher, event fsnotify.Event) {
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		w.forget(event.Name)
+		return
+	}
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addTree(notify, event.Name); err != nil {
+				w.logFn("Warning: " + err.Error())
+			}
+			return
+		}
+	}
+	w.notice(event.Name)
+}
+
+// notice starts settling a video that arrived or changed. A new subtitle sends the videos
+// in its folder that were waiting for one back to settling.
+func (w *Watcher) notice(path string) {
+	if slices.Contains(SubtitleExtensions, strings.ToLower(filepath.Ext(path))) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		for _, item := range w.items {
+			if item.Status == WatchWaiting && filepath.Dir(item.Video) == filepath.Dir(path) {
+				item.Status, item.changed, item.Updated = WatchSettling, time.Now(), time.Now()
+			}
+		}
+		return
+	}
+	if !(IsVideoFile(path) || IsAudioFile(path)) || IsCleanName(path) || w.config.NameTemplate.Matches(path) || strings.HasPrefix(filepath.Base(path), ".") {
+		return
+	}
+	folder := w.folderFor(path)
+	if folder == nil {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	output := w.outputFor(folder, path)
+	if out, err := os.Stat(output); err == nil && out.ModTime().After(info.ModTime()) {
+		return // Cleaned already
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	item := w.items[path]
+	if item != nil && (item.Status == WatchQueued || item.Status == WatchRunning) {
+		return
+	}
+	if item == nil {
+		item = &WatchItem{Video: path, Folder: folder.Path, Profile: folder.Profile, Output: output, folder: folder}
+		w.items[path] = item
+	}
+	item.Status, item.Error, item.Progress = WatchSettling, "", 0
+	item.size, item.modTime = info.Size(), info.ModTime()
+	item.changed, item.Updated = time.Now(), time.Now()
+}
+
+// forget drops a video that was removed or moved away before it was queued
+func (w *Watcher) forget(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if item := w.items[path]; item != nil && (item.Status == WatchSettling || item.Status == WatchWaiting) {
+		delete(w.items, path)
+	}
+}
+
+// settle queues the videos that have gone unchanged for the settle time and have a
+// subtitle beside them
+func (w *Watcher) settle() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	settle := time.Duration(w.config.Settle * float64(time.Second))
+	for path, item := range w.items {
+		if item.Status != WatchSettling && item.Status != WatchWaiting {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			delete(w.items, path)
+			continue
+		}
+		if info.Size() != item.size || !info.ModTime().Equal(item.modTime) {
+			item.size, item.modTime, item.changed = info.Size(), info.ModTime(), time.Now()
+			item.Status = WatchSettling
+			continue
+		}
+		if time.Since(item.changed) < settle {
+			continue
+		}
+		subtitle := SubtitleFor(path)
+		if subtitle == "" {
+			if item.Status != WatchWaiting {
+				item.Status, item.Updated = WatchWaiting, time.Now()
+				w.logFn(fmt.Sprintf("[%s] Waiting for a subtitle beside it", filepath.Base(path)))
+			}
+			continue
+		}
+		if sub, err := os.Stat(subtitle); err != nil || time.Since(sub.ModTime()) < settle {
+			continue // The subtitle is still arriving
+		}
+		select {
+		case w.queue <- item:
+			item.Status, item.Updated = WatchQueued, time.Now()
+			w.logFn(fmt.Sprintf("[%s] Queued with the %s folder's options", filepath.Base(path), item.Folder))
+		default: // The queue is full; try again on the next tick
+		}
+	}
+}
+
+// process cleans one queued video with its folder's options
+func (w *Watcher) process(ctx context.Context, item *WatchItem) {
+	w.update(item, func() { item.Status = WatchRunning })
+	name := filepath.Base(item.Video)
+	logFn := func(message string) { w.logFn(fmt.Sprintf("[%s] %s", name, message)) }
+
+	req := item.folder.JobRequest
+	req.Video, req.Subtitle, req.Output = item.Video, SubtitleFor(item.Video), item.Output
+	req.Hooks = item.folder.Hooks.Merge(w.config.Hooks)
+	req.Refresh = w.config.Refresh
+	if req.Subtitle == "" {
+		w.update(item, func() { item.Status = WatchWaiting })
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(req.Output), 0755); err != nil {
+		w.update(item, func() { item.Status, item.Error = WatchFailed, fmt.Sprintf("failed to create output folder: %v", err) })
+		logFn("Failed: " + item.Error)
+		return
+	}
+	result, err := ProcessJob(ctx, req, w.swears, logFn, ProgressFunc(func(progress float64) {
+		w.update(item, func() { item.Progress = progress })
+	}))
+	if err != nil {
+		w.update(item, func() { item.Status, item.Error = WatchFailed, err.Error() })
+		logFn("Failed: " + err.Error())
+		if ctx.Err() == nil {
+			w.notify(JobNotification(req.Video, req.Output, 0, err), logFn)
+		}
+		return
+	}
+	w.update(item, func() { item.Status, item.Progress, item.Output = WatchDone, 1, result.Output })
+	if !result.Skipped {
+		w.notify(JobNotification(req.Video, result.Output, len(result.Segments), nil), logFn)
+	}
+}
+
+// notify sends n as the config's notify says, logging a failure
+func (w *Watcher) notify(n Notification, logFn func(string)) {
+	if !w.config.Notify.Enabled() {
+		return
+	}
+	if err := w.config.Notify.Send(context.Background(), n); err != nil {
+		logFn("Warning: could not send the notification: " + err.Error())
+	}
+}
+
+// update changes an item under the lock
+func (w *Watcher) update(item *WatchItem, change func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	change()
+	item.Updated = time.Now()
+}
+
+// folderFor returns the configured folder path is in, the innermost if they are nested
+func (w *Watcher) folderFor(path string) *WatchFolder {
+	var best *WatchFolder
+	for i := range w.config.Folders {
+		f := &w.config.Folders[i]
+		rel, err := filepath.Rel(f.Path, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if best == nil || len(f.Path) > len(best.Path) {
+			best = f
+		}
+	}
+	return best
+}
+
+// outputFor returns where a video's clean output goes: beside it, or in the folder's
+// output_dir in the same subfolder it has in the watched folder
+func (w *Watcher) outputFor(folder *WatchFolder, video string) string {
+	dir := filepath.Dir(video)
+	if folder.OutputDir != "" {
+		rel, _ := filepath.Rel(folder.Path, dir)
+		dir = filepath.Join(folder.OutputDir, rel)
+	}
+	return filepath.Join(dir, w.config.NameTemplate.Name(video, folder.Profile))
+}
+
+// Status returns what the watcher is doing
+func (w *Watcher) Status() WatchReport {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	report := WatchReport{Started: w.started, Counts: map[WatchStatus]int{}}
+	for _, f := range w.config.Folders {
+		report.Folders = append(report.Folders, WatchedFolder{Path: f.Path, OutputDir: f.OutputDir, Profile: f.Profile})
+	}
+	for _, item := range w.items {
+		report.Items = append(report.Items, *item)
+		report.Counts[item.Status]++
+	}
+	sort.Slice(report.Items, func(i, j int) bool { return report.Items[i].Updated.After(report.Items[j].Updated) })
+	return report
+}
+
+// Handler returns the watcher's status endpoint:
+//
+//	GET /status  the folders, a count of videos by status and every video seen (WatchReport)
+func (w *Watcher) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(rw http.ResponseWriter, r *http.Request) {
+		writeJSON(rw, http.StatusOK, w.Status())
+	})
+	return mux
+}
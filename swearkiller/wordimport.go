@@ -0,0 +1,240 @@
+package swearkiller
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// wordListTimeout is how long downloading a word list may take
+const wordListTimeout = 30 * time.Second
+
+// maxWordListSize stops a wrong URL from downloading something huge
+const maxWordListSize = 16 << 20
+
+// wordColumns and severityColumns are the CSV headers, lowercased, that name the word and
+// its severity in the public lists, in order of preference
+var (
+	wordColumns     = []string{"word", "text", "term", "profanity", "phrase"}
+	severityColumns = []string{"severity_rating", "severity", "severity_description", "level", "rating"}
+)
+
+// severityNames turns the severity words some lists use into the 1-3 scale others use
+var severityNames = map[string]float64{
+	"mild": 1, "low": 1,
+	"moderate": 2, "medium": 2, "strong": 2,
+	"severe": 3, "high": 3, "extreme": 3,
+}
+
+// ImportOptions says which words of an imported list to keep
+type ImportOptions struct {
+	// MinSeverity leaves out CSV words rated below it, on the list's own scale; severity
+	// words count as mild 1, moderate or strong 2 and severe 3. Lists without a severity
+	// column are kept whole.
+	MinSeverity float64
+}
+
+// ImportedList is a word list read from a public list
+type ImportedList struct {
+	Words   []string // In the list's order, without duplicates
+	Dropped int      // Words left out for being below the minimum severity
+}
+
+// ReadWordListSource reads a word list from a file or an http or https URL, in any
+// encoding DecodeText handles
+func ReadWordListSource(ctx context.Context, source string) (string, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		text, _, err := ReadTextFile(source)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %v", source, err)
+		}
+		return text, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, wordListTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %v", source, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: the server answered %s", source, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWordListSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %v", source, err)
+	}
+	if len(data) > maxWordListSize {
+		return "", fmt.Errorf("%s is larger than %s; is it a word list?", source, FormatBytes(maxWordListSize))
+	}
+	text, _, err := DecodeText(data)
+	return text, err
+}
+
+// ParseWordList reads a public word list. A name ending in .csv, or text whose first line
+// has a known word column, is read as CSV with a header; anything else as one word per
+// line, like the LDNOOBW lists, where lines starting with # are comments.
+func ParseWordList(name, text string, opts ImportOptions) (ImportedList, error) {
+	firstLine, _, _ := strings.Cut(text, "\n")
+	name, _, _ = strings.Cut(name, "?") // A URL's query
+	if strings.EqualFold(filepath.Ext(name), ".csv") || csvHeader(firstLine) {
+		return parseWordCSV(text, opts)
+	}
+	var list ImportedList
+	seen := map[string]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		word := strings.TrimSpace(line)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		list.add(word, seen)
+	}
+	if len(list.Words) == 0 {
+		return list, fmt.Errorf("%s holds no words", filepath.Base(name))
+	}
+	return list, nil
+}
+
+// csvHeader reports whether line looks like the header of a CSV word list
+func csvHeader(line string) bool {
+	if !strings.Contains(line, ",") {
+		return false
+	}
+	for _, column := range strings.Split(line, ",") {
+		for _, known := range wordColumns {
+			if strings.EqualFold(strings.Trim(strings.TrimSpace(column), `"`), known) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseWordCSV reads a CSV word list with a header, taking the word from the first known
+// word column (or the first column) and the severity from the first known severity column
+func parseWordCSV(text string, opts ImportOptions) (ImportedList, error) {
+	var list ImportedList
+	r := csv.NewReader(strings.NewReader(text))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return list, fmt.Errorf("failed to read the CSV: %v", err)
+	}
+	if len(rows) < 2 {
+		return list, fmt.Errorf("the CSV holds no words")
+	}
+	header := map[string]int{}
+	for i, column := range rows[0] {
+		header[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	wordCol, severityCol := 0, -1
+	for _, name := range wordColumns {
+		if i, ok := header[name]; ok {
+			wordCol = i
+			break
+		}
+	}
+	for _, name := range severityColumns {
+		if i, ok := header[name]; ok {
+			severityCol = i
+			break
+		}
+	}
+	if opts.MinSeverity > 0 && severityCol < 0 {
+		return list, fmt.Errorf("the CSV has no severity column (looked for %s)", strings.Join(severityColumns, ", "))
+	}
+
+	seen := map[string]bool{}
+	for n, row := range rows[1:] {
+		if wordCol >= len(row) || strings.TrimSpace(row[wordCol]) == "" {
+			continue
+		}
+		if severityCol >= 0 && opts.MinSeverity > 0 {
+			severity, err := parseSeverity(row, severityCol)
+			if err != nil {
+				return list, fmt.Errorf("row %d: %v", n+2, err)
+			}
+			if severity < opts.MinSeverity {
+				list.Dropped++
+				continue
+			}
+		}
+		list.add(strings.TrimSpace(row[wordCol]), seen)
+	}
+	if len(list.Words) == 0 {
+		return list, fmt.Errorf("no words in the CSV are severe enough to keep")
+	}
+	return list, nil
+}
+
+// parseSeverity reads a row's severity, as a number or a severity word
+func parseSeverity(row []string, col int) (float64, error) {
+	if col >= len(row) {
+		return 0, fmt.Errorf("no severity")
+	}
+	value := strings.ToLower(strings.TrimSpace(row[col]))
+	if severity, ok := severityNames[value]; ok {
+		return severity, nil
+	}
+	severity, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unknown severity %q", row[col])
+	}
+	return severity, nil
+}
+
+// add appends word unless a word that normalizes the same was already added
+func (l *ImportedList) add(word string, seen map[string]bool) {
+	key := NormalizeText(word)
+	if seen[key] {
+		return
+	}
+	seen[key] = true
+	l.Words = append(l.Words, word)
+}
+
+// MergeWords adds the imported words missing from existing to its end, comparing them
+// as they are matched (see NormalizeText), and returns the merged list and how many were
+// added
+func MergeWords(existing, imported []string) ([]string, int) {
+	merged := append([]string{}, existing...)
+	seen := map[string]bool{}
+	for _, word := range existing {
+		seen[NormalizeText(strings.TrimSpace(word))] = true
+	}
+	added := 0
+	for _, word := range imported {
+		key := NormalizeText(word)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, word)
+		added++
+	}
+	return merged, added
+}
+
+// WriteWordList writes a word list as a text file, one word per line
+func WriteWordList(path string, words []string) error {
+	var b strings.Builder
+	for _, word := range words {
+		b.WriteString(word + "\n")
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
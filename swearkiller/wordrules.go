@@ -0,0 +1,72 @@
+package swearkiller
+
+// WordRules describes how a language builds longer words around a swear, which decides
+// whether a swear inside a longer word still counts as the word itself
+type WordRules struct {
+	// Suffixes means case endings and other suffixes are attached to the word, so a swear
+	// counts as a whole word when it starts one ("vittu" in "vitussa", "siktir" in "siktirin")
+	Suffixes bool
+	// Compounds means words are joined into compounds, so a swear counts as a whole word
+	// when it starts or ends one ("scheiß" in "scheißegal", "paska" in "kusipaska")
+	Compounds bool
+}
+
+// languageWordRules lists the built-in languages whose words don't simply end at a space.
+// Languages not listed only count swears standing between spaces or punctuation.
+var languageWordRules = map[string]WordRules{
+	"de": {Compounds: true},
+	"fi": {Suffixes: true, Compounds: true},
+	"tr": {Suffixes: true},
+}
+
+// LanguageWordRules returns the word rules of a BuiltinLists language code
+func LanguageWordRules(code string) WordRules {
+	return languageWordRules[code]
+}
+
+// union returns rules that allow everything either of r and other allows
+func (r WordRules) union(other WordRules) WordRules {
+	return WordRules{Suffixes: r.Suffixes || other.Suffixes, Compounds: r.Compounds || other.Compounds}
+}
+
+// wholeWord reports whether an occurrence with the given boundaries counts as a whole word
+func (r WordRules) wholeWord(startBoundary, endBoundary bool) bool {
+	switch {
+	case startBoundary && endBoundary:
+		return true
+	case r.Suffixes && startBoundary:
+		return true
+	case r.Compounds && (startBoundary || endBoundary):
+		return true
+	}
+	return false
+}
+
+// swearWordRules picks the word rules for each normalized swear. Words from a built-in list
+// follow the rules of that list's language; the user's own words follow the rules of the
+// subtitle's languages, since that's the text they are matched against.
+func swearWordRules(normalized []string, languages []string) []WordRules {
+	var subtitleRules WordRules
+	for _, code := range languages {
+		subtitleRules = subtitleRules.union(languageWordRules[NormalizeLanguage(code)])
+	}
+
+	builtin := map[string]WordRules{}
+	listed := map[string]bool{}
+	for code, list := range BuiltinLists {
+		for _, word := range normalizeAll(list) {
+			builtin[word] = builtin[word].union(languageWordRules[code])
+			listed[word] = true
+		}
+	}
+
+	rules := make([]WordRules, len(normalized))
+	for i, word := range normalized {
+		if listed[word] {
+			rules[i] = builtin[word]
+		} else {
+			rules[i] = subtitleRules
+		}
+	}
+	return rules
+}
@@ -0,0 +1,164 @@
+package swearkiller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// wordListMetaFile keeps the validators of a downloaded list and its signature files
+const wordListMetaFile = "meta.json"
+
+// WordListCacheDir returns the folder downloaded word lists are kept in
+func WordListCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "swear-killer", "wordlists")
+}
+
+// IsWordListURL reports whether a word list source is an http or https URL rather than a file
+func IsWordListURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// WordListSync is the outcome of SyncWordList
+type WordListSync struct {
+	Path    string // The local copy, with the list's .minisig and .sha256 files beside it if it has them
+	Updated bool   // A new version was downloaded; otherwise the copy was already current
+	Offline error  // Why the copy was used without checking it is current, if it was
+}
+
+// cachedValidators are what the server said identifies each downloaded file's version
+type cachedValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// wordListMeta is a downloaded list's meta.json
+type wordListMeta struct {
+	URL     string                      `json:"url"`
+	Files   map[string]cachedValidators `json:"files"`
+	Checked time.Time                   `json:"checked"`
+}
+
+// SyncWordList brings the local copy of a shared word list up to date and returns where it
+// is, so a list kept at one URL, like a Gist, can be used on several machines. The server
+// is asked for the list only if it changed (using its ETag or Last-Modified), and the
+// list's .minisig and .sha256 files are fetched alongside it, so a shared list can be
+// checked like a local one. If the server can't be reached, the copy from last time is
+// used. An empty cacheDir means WordListCacheDir().
+func SyncWordList(ctx context.Context, listURL, cacheDir string) (WordListSync, error) {
+	if cacheDir == "" {
+		cacheDir = WordListCacheDir()
+	}
+	parsed, err := url.Parse(listURL)
+	if err != nil || !IsWordListURL(listURL) || parsed.Host == "" {
+		return WordListSync{}, fmt.Errorf("word list URL %q must be an http or https URL", listURL)
+	}
+	sum := sha256.Sum256([]byte(listURL))
+	dir := filepath.Join(cacheDir, hex.EncodeToString(sum[:8]))
+	name := path.Base(parsed.Path)
+	if name == "." || name == "/" {
+		name = "list.txt"
+	}
+	sync := WordListSync{Path: filepath.Join(dir, name)}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return sync, fmt.Errorf("failed to create the word list cache: %v", err)
+	}
+
+	meta := wordListMeta{URL: listURL, Files: map[string]cachedValidators{}}
+	if data, err := os.ReadFile(filepath.Join(dir, wordListMetaFile)); err == nil {
+		json.Unmarshal(data, &meta)
+		if meta.Files == nil {
+			meta.Files = map[string]cachedValidators{}
+		}
+	}
+	_, statErr := os.Stat(sync.Path)
+	cached := statErr == nil
+
+	ctx, cancel := context.WithTimeout(ctx, wordListTimeout)
+	defer cancel()
+	updated, err := syncFile(ctx, listURL, sync.Path, meta.Files, true)
+	if err != nil {
+		if !cached {
+			return sync, err
+		}
+		sync.Offline = err
+		return sync, nil
+	}
+	sync.Updated = updated
+	// The signature files are optional, but one the server no longer has mustn't be kept
+	for _, ext := range []string{SignatureExt, ChecksumExt} {
+		if _, err := syncFile(ctx, listURL+ext, sync.Path+ext, meta.Files, false); err != nil {
+			return sync, err
+		}
+	}
+	meta.Checked = time.Now()
+	if data, err := json.MarshalIndent(meta, "", "  "); err == nil {
+		os.WriteFile(filepath.Join(dir, wordListMetaFile), data, 0644)
+	}
+	return sync, nil
+}
+
+// syncFile downloads target to local unless the validators in files show the local copy
+// is current, and reports whether it downloaded it. A file that isn't required and that
+// the server doesn't have is removed.
+func syncFile(ctx context.Context, target, local string, files map[string]cachedValidators, required bool) (bool, error) {
+	key := filepath.Base(local)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(local); err == nil {
+		if v := files[key]; v.ETag != "" {
+			req.Header.Set("If-None-Match", v.ETag)
+		} else if v.LastModified != "" {
+			req.Header.Set("If-Modified-Since", v.LastModified)
+		}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to download %s: %v", target, err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return false, nil
+	case !required && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone):
+		os.Remove(local)
+		delete(files, key)
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("failed to download %s: the server answered %s", target, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWordListSize+1))
+	if err != nil {
+		return false, fmt.Errorf("failed to download %s: %v", target, err)
+	}
+	if len(data) > maxWordListSize {
+		return false, fmt.Errorf("%s is larger than %s; is it a word list?", target, FormatBytes(maxWordListSize))
+	}
+	// Written beside it and renamed, so a failed download never leaves half a list
+	tmp := local + ".part"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return false, fmt.Errorf("failed to save %s: %v", target, err)
+	}
+	if err := os.Rename(tmp, local); err != nil {
+		os.Remove(tmp)
+		return false, fmt.Errorf("failed to save %s: %v", target, err)
+	}
+	files[key] = cachedValidators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	return true, nil
+}